@@ -0,0 +1,63 @@
+// Command audit-verify walks the audit log's hash chain and its signed
+// checkpoints and reports the first broken link. It exits with status 1 when
+// the log fails verification and 2 when it cannot be verified.
+//
+// It reads POSTGRES_DSN and the audit keys like the server does; a verifier
+// needs only AUDIT_PUBLIC_KEY to check checkpoint signatures.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/config"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+func main() {
+	report, err := run(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit-verify:", err)
+		os.Exit(2)
+	}
+	printReport(os.Stdout, report)
+	if report.Broken != nil {
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context) (*port.AuditVerificationResponse, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.New(ctx, cfg.PostgresDSN)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	svc := service.NewAuditService(postgres.NewFlagStore(pool), service.WithVerifyKey(cfg.AuditPublicKey))
+	return svc.VerifyAudit(ctx)
+}
+
+func printReport(w io.Writer, report *port.AuditVerificationResponse) {
+	fmt.Fprintf(w, "entries verified: %d\ncheckpoints: %d\n", report.Entries, report.Checkpoints)
+	if !report.SignaturesVerified {
+		fmt.Fprintln(w, "checkpoint signatures: not verified, AUDIT_PUBLIC_KEY is not set")
+	}
+	if report.Broken == nil {
+		fmt.Fprintln(w, "audit log intact")
+		return
+	}
+	fmt.Fprintf(w, "audit log BROKEN at entry %d", report.Broken.EntryID)
+	if report.Broken.CheckpointID != 0 {
+		fmt.Fprintf(w, " (checkpoint %d)", report.Broken.CheckpointID)
+	}
+	fmt.Fprintf(w, ": %s\n", report.Broken.Reason)
+}
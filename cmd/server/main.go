@@ -0,0 +1,175 @@
+// Command server is the composition root of the feature flags service: it
+// wires the Postgres, Redis and HTTP adapters together and serves the API.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // schedule targeting must resolve IANA zones on hosts without a zone database
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/xNakero/feature-flags/internal/adapter/eventlog"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	redisadapter "github.com/xNakero/feature-flags/internal/adapter/redis"
+	"github.com/xNakero/feature-flags/internal/adapter/webhook"
+	"github.com/xNakero/feature-flags/internal/config"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return err
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := pgxpool.New(ctx, cfg.PostgresDSN)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	store := postgres.NewFlagStore(pool)
+	experiments := postgres.NewExperimentStore(pool)
+	events := postgres.NewEventStore(pool)
+	webhooks := postgres.NewWebhookStore(pool)
+	apiKeys := postgres.NewAPIKeyStore(pool)
+	access := postgres.NewAccessStore(pool)
+	freezes := postgres.NewFreezeStore(pool)
+	// Experiments reference flags, so the flags table is created first.
+	for _, s := range []interface{ CreateSchema(context.Context) error }{store, experiments, events, webhooks, apiKeys, access, freezes} {
+		if err := s.CreateSchema(ctx); err != nil {
+			return err
+		}
+	}
+
+	redisClient := goredis.NewClient(&goredis.Options{Addr: cfg.RedisAddr})
+	defer redisClient.Close()
+	cache := redisadapter.NewFlagCache(redisClient)
+
+	svc := service.New(store, store, freezes, cache,
+		service.WithLogger(logger),
+		service.WithEventStore(events),
+		service.WithNamingPolicy(cfg.Naming),
+		service.WithChangeRequestTTL(cfg.ChangeRequestTTL),
+	)
+	experimentSvc := service.NewExperimentService(experiments, store, store, freezes, events, cache,
+		service.WithLogger(logger),
+		service.WithHoldout(cfg.HoldoutPercent),
+	)
+	auditSvc := service.NewAuditService(store,
+		service.WithLogger(logger),
+		service.WithSigningKey(cfg.AuditSigningKey),
+		service.WithVerifyKey(cfg.AuditPublicKey),
+	)
+	retry := domain.DefaultRetryPolicy()
+	retry.MaxAttempts = cfg.WebhookMaxAttempts
+	webhookSvc := service.NewWebhookService(webhooks, webhook.NewSender(&http.Client{Timeout: cfg.WebhookTimeout}),
+		service.WithLogger(logger),
+		service.WithRetryPolicy(retry),
+	)
+	var sinks []port.EventSink
+	for _, name := range cfg.OutboxSinks {
+		switch name {
+		case "webhooks":
+			sinks = append(sinks, webhookSvc)
+		case "redis":
+			sinks = append(sinks, redisadapter.NewEventStream(redisClient, cfg.OutboxStream))
+		case "log":
+			sinks = append(sinks, eventlog.NewSink(logger))
+		}
+	}
+	relay := service.NewOutboxRelay(store, sinks, service.WithLogger(logger))
+	handlerOpts := []httpadapter.HandlerOption{
+		httpadapter.WithTrustedProxies(cfg.TrustedProxies),
+		httpadapter.WithAudit(auditSvc),
+	}
+	// Role-based access control needs an authenticated principal, so it is
+	// only enforced together with API keys.
+	var flags port.FlagService = svc
+	var experimentsAPI port.ExperimentService = experimentSvc
+	var webhooksAPI port.WebhookService = webhookSvc
+	if cfg.APIKeysEnabled {
+		apiKeySvc := service.NewAPIKeyService(apiKeys, access,
+			service.WithLogger(logger),
+			service.WithAdminKey(cfg.AdminAPIKey),
+		)
+		flags = service.NewAuthorizedService(svc, store, access, service.WithLogger(logger))
+		experimentsAPI = service.NewAuthorizedExperimentService(experimentSvc, experiments, store, access, service.WithLogger(logger))
+		webhooksAPI = service.NewAuthorizedWebhookService(webhookSvc, access, service.WithLogger(logger))
+		handlerOpts = append(handlerOpts,
+			httpadapter.WithAPIKeys(apiKeySvc),
+			httpadapter.WithAccess(service.NewAccessService(access,
+				service.WithLogger(logger),
+				service.WithNamingPolicy(cfg.Naming),
+			)),
+		)
+	} else {
+		logger.Warn("api keys disabled: requests are not authenticated")
+	}
+	handlerOpts = append(handlerOpts,
+		httpadapter.WithExperiments(experimentsAPI),
+		httpadapter.WithWebhooks(webhooksAPI),
+	)
+	handler := httpadapter.NewHandler(flags, logger, handlerOpts...)
+
+	go experimentSvc.RunBandits(ctx, cfg.BanditInterval)
+	go relay.RunRelay(ctx, cfg.OutboxInterval)
+	go webhookSvc.RunDeliveries(ctx, cfg.WebhookInterval)
+	if cfg.AuditSigningKey != nil {
+		go auditSvc.RunCheckpoints(ctx, cfg.AuditCheckpointInterval)
+	}
+
+	server := &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           handler.Routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", cfg.HTTPAddr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,39 @@
+// Package eventlog is the outbound adapter that publishes flag change events
+// as structured log records, for deployments that ship logs elsewhere.
+package eventlog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// Sink logs each event at info level under the message "flag event".
+type Sink struct {
+	logger *slog.Logger
+}
+
+func NewSink(logger *slog.Logger) *Sink {
+	return &Sink{logger: logger}
+}
+
+func (s *Sink) Name() string { return "log" }
+
+func (s *Sink) Publish(ctx context.Context, events []domain.FlagEvent) error {
+	for _, event := range events {
+		s.logger.InfoContext(ctx, "flag event",
+			"id", event.ID,
+			"idempotency_key", event.Key,
+			"type", string(event.Action),
+			"project", event.Project,
+			"environment", event.Environment,
+			"flag", event.FlagName,
+			"version", event.Version,
+			"actor", event.Actor,
+			"request_id", event.RequestID,
+			"occurred_at", event.OccurredAt,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package eventlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/eventlog"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestSink_Publish(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	sink := eventlog.NewSink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	err := sink.Publish(context.Background(), []domain.FlagEvent{{
+		ID: 7, Key: "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10", Action: domain.AuditUpdateValue, Project: "checkout",
+		Environment: "production", FlagName: "new-checkout", Version: 3, Actor: "alice", RequestID: "req-1",
+		OccurredAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+	}})
+
+	require.NoError(t, err)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "flag event", record["msg"])
+	assert.Equal(t, float64(7), record["id"])
+	assert.Equal(t, "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10", record["idempotency_key"])
+	assert.Equal(t, "update-value", record["type"])
+	assert.Equal(t, "new-checkout", record["flag"])
+	assert.Equal(t, "2026-06-01T09:00:00Z", record["occurred_at"])
+}
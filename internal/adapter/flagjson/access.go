@@ -0,0 +1,47 @@
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type permissionDoc struct {
+	Scope        string   `json:"scope"`
+	Projects     []string `json:"projects,omitempty"`
+	Environments []string `json:"environments,omitempty"`
+	Flags        []string `json:"flags,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+// MarshalPermissions encodes a role's permissions as a JSON array. A nil slice
+// encodes as [].
+func MarshalPermissions(permissions []domain.Permission) ([]byte, error) {
+	docs := make([]permissionDoc, len(permissions))
+	for i, p := range permissions {
+		docs[i] = permissionDoc{
+			Scope: string(p.Scope), Projects: p.Projects, Environments: p.Environments, Flags: p.Flags, Tags: p.Tags,
+		}
+	}
+	raw, err := json.Marshal(docs)
+	if err != nil {
+		return nil, fmt.Errorf("encode permissions: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalPermissions decodes a JSON array produced by MarshalPermissions.
+func UnmarshalPermissions(raw []byte) ([]domain.Permission, error) {
+	var docs []permissionDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode permissions: %w", err)
+	}
+	permissions := make([]domain.Permission, len(docs))
+	for i, doc := range docs {
+		permissions[i] = domain.Permission{
+			Scope: domain.Scope(doc.Scope), Projects: doc.Projects, Environments: doc.Environments, Flags: doc.Flags, Tags: doc.Tags,
+		}
+	}
+	return permissions, nil
+}
@@ -0,0 +1,242 @@
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type variantDoc struct {
+	Key     string   `json:"key"`
+	Bool    *bool    `json:"bool,omitempty"`
+	Numeric *float64 `json:"numeric,omitempty"`
+}
+
+type variantWeightDoc struct {
+	Variant string `json:"variant"`
+	Weight  int    `json:"weight"`
+}
+
+type banditDoc struct {
+	Algorithm      string  `json:"algorithm"`
+	Metric         string  `json:"metric"`
+	Epsilon        float64 `json:"epsilon,omitempty"`
+	MinExploration float64 `json:"min_exploration,omitempty"`
+}
+
+type experimentDoc struct {
+	Key         string             `json:"key"`
+	Project     string             `json:"project,omitempty"`
+	FlagName    string             `json:"flag"`
+	Environment string             `json:"environment,omitempty"`
+	Allocation  int                `json:"allocation"`
+	Variants    []variantWeightDoc `json:"variants"`
+	Layer       string             `json:"layer,omitempty"`
+	Offset      int                `json:"layer_offset,omitempty"`
+	Holdout     int                `json:"holdout,omitempty"`
+	Bandit      *banditDoc         `json:"bandit,omitempty"`
+	Status      string             `json:"status"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	StoppedAt   *time.Time         `json:"stopped_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// MarshalVariants encodes flag variants as a JSON array. A nil slice encodes as [].
+func MarshalVariants(variants []domain.Variant) ([]byte, error) {
+	raw, err := json.Marshal(variantsToDocs(variants))
+	if err != nil {
+		return nil, fmt.Errorf("encode variants: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalVariants decodes a JSON array produced by MarshalVariants. An empty
+// array decodes as nil.
+func UnmarshalVariants(raw []byte) ([]domain.Variant, error) {
+	var docs []variantDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode variants: %w", err)
+	}
+	return docsToVariants(docs), nil
+}
+
+// MarshalVariantWeights encodes an experiment's variant weights as a JSON array.
+func MarshalVariantWeights(weights []domain.VariantWeight) ([]byte, error) {
+	raw, err := json.Marshal(weightsToDocs(weights))
+	if err != nil {
+		return nil, fmt.Errorf("encode variant weights: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalVariantWeights decodes a JSON array produced by MarshalVariantWeights.
+func UnmarshalVariantWeights(raw []byte) ([]domain.VariantWeight, error) {
+	var docs []variantWeightDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode variant weights: %w", err)
+	}
+	return docsToWeights(docs), nil
+}
+
+// MarshalBandit encodes a bandit configuration as a JSON document. A nil
+// configuration encodes as nil so it can be stored as SQL NULL.
+func MarshalBandit(cfg *domain.BanditConfig) ([]byte, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(banditToDoc(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("encode bandit: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalBandit decodes a document produced by MarshalBandit. Empty input
+// decodes as nil.
+func UnmarshalBandit(raw []byte) (*domain.BanditConfig, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var doc banditDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decode bandit: %w", err)
+	}
+	return docToBandit(&doc), nil
+}
+
+// MarshalExperiment encodes an experiment as a JSON document. A nil experiment
+// encodes as nil so it can be stored as SQL NULL.
+func MarshalExperiment(exp *domain.Experiment) ([]byte, error) {
+	if exp == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(experimentToDoc(exp))
+	if err != nil {
+		return nil, fmt.Errorf("encode experiment %q: %w", exp.Key, err)
+	}
+	return raw, nil
+}
+
+// UnmarshalExperiment decodes a document produced by MarshalExperiment. Empty
+// input decodes as nil.
+func UnmarshalExperiment(raw []byte) (*domain.Experiment, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var doc experimentDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decode experiment: %w", err)
+	}
+	return docToExperiment(&doc), nil
+}
+
+func variantsToDocs(variants []domain.Variant) []variantDoc {
+	docs := make([]variantDoc, len(variants))
+	for i, v := range variants {
+		docs[i] = variantDoc{Key: v.Key, Bool: v.Value.Bool, Numeric: v.Value.Numeric}
+	}
+	return docs
+}
+
+func docsToVariants(docs []variantDoc) []domain.Variant {
+	if len(docs) == 0 {
+		return nil
+	}
+	variants := make([]domain.Variant, len(docs))
+	for i, v := range docs {
+		variants[i] = domain.Variant{Key: v.Key, Value: domain.FlagValue{Bool: v.Bool, Numeric: v.Numeric}}
+	}
+	return variants
+}
+
+func weightsToDocs(weights []domain.VariantWeight) []variantWeightDoc {
+	docs := make([]variantWeightDoc, len(weights))
+	for i, w := range weights {
+		docs[i] = variantWeightDoc{Variant: w.Variant, Weight: w.Weight}
+	}
+	return docs
+}
+
+func docsToWeights(docs []variantWeightDoc) []domain.VariantWeight {
+	if len(docs) == 0 {
+		return nil
+	}
+	weights := make([]domain.VariantWeight, len(docs))
+	for i, w := range docs {
+		weights[i] = domain.VariantWeight{Variant: w.Variant, Weight: w.Weight}
+	}
+	return weights
+}
+
+func banditToDoc(cfg *domain.BanditConfig) *banditDoc {
+	if cfg == nil {
+		return nil
+	}
+	return &banditDoc{
+		Algorithm:      string(cfg.Algorithm),
+		Metric:         cfg.Metric,
+		Epsilon:        cfg.Epsilon,
+		MinExploration: cfg.MinExploration,
+	}
+}
+
+func docToBandit(doc *banditDoc) *domain.BanditConfig {
+	if doc == nil {
+		return nil
+	}
+	return &domain.BanditConfig{
+		Algorithm:      domain.BanditAlgorithm(doc.Algorithm),
+		Metric:         doc.Metric,
+		Epsilon:        doc.Epsilon,
+		MinExploration: doc.MinExploration,
+	}
+}
+
+func experimentToDoc(exp *domain.Experiment) *experimentDoc {
+	if exp == nil {
+		return nil
+	}
+	return &experimentDoc{
+		Key:         exp.Key,
+		Project:     exp.Project,
+		FlagName:    exp.FlagName,
+		Environment: exp.Environment,
+		Allocation:  exp.Allocation,
+		Variants:    weightsToDocs(exp.Variants),
+		Layer:       exp.Layer,
+		Offset:      exp.LayerOffset,
+		Holdout:     exp.Holdout,
+		Bandit:      banditToDoc(exp.Bandit),
+		Status:      string(exp.Status),
+		StartedAt:   exp.StartedAt,
+		StoppedAt:   exp.StoppedAt,
+		CreatedAt:   exp.CreatedAt,
+		UpdatedAt:   exp.UpdatedAt,
+	}
+}
+
+func docToExperiment(doc *experimentDoc) *domain.Experiment {
+	if doc == nil {
+		return nil
+	}
+	return &domain.Experiment{
+		Key:         doc.Key,
+		Project:     doc.Project,
+		FlagName:    doc.FlagName,
+		Environment: doc.Environment,
+		Allocation:  doc.Allocation,
+		Variants:    docsToWeights(doc.Variants),
+		Layer:       doc.Layer,
+		LayerOffset: doc.Offset,
+		Holdout:     doc.Holdout,
+		Bandit:      docToBandit(doc.Bandit),
+		Status:      domain.ExperimentStatus(doc.Status),
+		StartedAt:   doc.StartedAt,
+		StoppedAt:   doc.StoppedAt,
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
+	}
+}
@@ -0,0 +1,86 @@
+// Package flagjson is the JSON document format shared by the outbound
+// adapters: the Postgres adapter stores the structured parts of a flag (rules,
+// targets, variants, experiments) in JSONB columns and the Redis adapter caches
+// whole flags as JSON documents.
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type flagDoc struct {
+	Project     string              `json:"project,omitempty"`
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Environment string              `json:"environment,omitempty"`
+	Source      string              `json:"source_environment,omitempty"`
+	Disabled    bool                `json:"disabled,omitempty"`
+	Bool        *bool               `json:"bool,omitempty"`
+	Numeric     *float64            `json:"numeric,omitempty"`
+	Rules       []ruleDoc           `json:"rules,omitempty"`
+	Targets     map[string]valueDoc `json:"targets,omitempty"`
+	Variants    []variantDoc        `json:"variants,omitempty"`
+	Experiment  *experimentDoc      `json:"experiment,omitempty"`
+	Version     int64               `json:"version"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// MarshalFlag encodes a whole flag as a JSON document.
+func MarshalFlag(flag domain.Flag) ([]byte, error) {
+	raw, err := json.Marshal(flagDoc{
+		Project:     flag.Project,
+		Name:        flag.Name,
+		Type:        string(flag.Type),
+		Description: flag.Description,
+		Tags:        flag.Tags,
+		Environment: flag.Environment,
+		Source:      flag.SourceEnvironment,
+		Disabled:    flag.Disabled,
+		Bool:        flag.Value.Bool,
+		Numeric:     flag.Value.Numeric,
+		Rules:       rulesToDocs(flag.Rules),
+		Targets:     targetsToDocs(flag.Targets),
+		Variants:    variantsToDocs(flag.Variants),
+		Experiment:  experimentToDoc(flag.Experiment),
+		Version:     flag.Version,
+		CreatedAt:   flag.CreatedAt,
+		UpdatedAt:   flag.UpdatedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode flag %q: %w", flag.Name, err)
+	}
+	return raw, nil
+}
+
+// UnmarshalFlag decodes a document produced by MarshalFlag.
+func UnmarshalFlag(raw []byte) (*domain.Flag, error) {
+	var doc flagDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decode flag: %w", err)
+	}
+	return &domain.Flag{
+		Project:           doc.Project,
+		Name:              doc.Name,
+		Type:              domain.FlagType(doc.Type),
+		Description:       doc.Description,
+		Tags:              doc.Tags,
+		Environment:       doc.Environment,
+		SourceEnvironment: doc.Source,
+		Disabled:          doc.Disabled,
+		Value:             domain.FlagValue{Bool: doc.Bool, Numeric: doc.Numeric},
+		Rules:             docsToRules(doc.Rules),
+		Targets:           docsToTargets(doc.Targets),
+		Variants:          docsToVariants(doc.Variants),
+		Experiment:        docToExperiment(doc.Experiment),
+		Version:           doc.Version,
+		CreatedAt:         doc.CreatedAt,
+		UpdatedAt:         doc.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,220 @@
+package flagjson_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestFlag_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	flag := domain.Flag{
+		Project:           "checkout",
+		Name:              "office-hours",
+		Type:              domain.FlagTypeBoolean,
+		Description:       "on during business hours",
+		Tags:              []string{"office", "web"},
+		Environment:       "staging",
+		SourceEnvironment: "production",
+		Disabled:          true,
+		Value:             domain.FlagValue{Bool: &off},
+		Rules: []domain.Rule{{
+			ID:    "weekdays",
+			Value: domain.FlagValue{Bool: &on},
+			Clauses: []domain.Clause{
+				{Operator: domain.OperatorDayOfWeekIn, Values: []string{"mon", "fri"}, Timezone: "Europe/Warsaw"},
+				{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}},
+			},
+		}},
+		Targets: map[string]domain.FlagValue{
+			"user-1234": {Bool: &on},
+		},
+		Variants: []domain.Variant{
+			{Key: "off", Value: domain.FlagValue{Bool: &off}},
+			{Key: "on", Value: domain.FlagValue{Bool: &on}},
+		},
+		Experiment: &domain.Experiment{
+			Key:         "office-hours-test",
+			Project:     "checkout",
+			FlagName:    "office-hours",
+			Environment: "staging",
+			Allocation:  25,
+			Variants:    []domain.VariantWeight{{Variant: "off", Weight: 1}, {Variant: "on", Weight: 1}},
+			Layer:       "office",
+			LayerOffset: 50,
+			Holdout:     5,
+			Bandit:      &domain.BanditConfig{Algorithm: domain.BanditThompson, Metric: "click", MinExploration: 0.05},
+			Status:      domain.ExperimentRunning,
+			StartedAt:   &now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		},
+		Version:   4,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	raw, err := flagjson.MarshalFlag(flag)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalFlag(raw)
+	require.NoError(t, err)
+
+	domain.CompileRules(flag.Rules)
+	assert.Equal(t, flag, *got, "decoded rules come back compiled")
+}
+
+func TestRules_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	numVal := 2.5
+	rules := []domain.Rule{
+		{
+			ID:      "pro",
+			Value:   domain.FlagValue{Numeric: &numVal},
+			Clauses: []domain.Clause{{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}}},
+		},
+		{
+			ID:         "staff",
+			Value:      domain.FlagValue{Numeric: &numVal},
+			Expression: `email.endsWith("@ourco.com")`,
+		},
+	}
+
+	raw, err := flagjson.MarshalRules(rules)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalRules(raw)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	domain.CompileRules(rules)
+	assert.Equal(t, rules[0], got[0])
+	// A compiled expression holds closures, which never compare equal.
+	assert.Equal(t, rules[1].ID, got[1].ID)
+	assert.Equal(t, rules[1].Expression, got[1].Expression)
+	assert.Equal(t, rules[1].Value, got[1].Value)
+
+	empty, err := flagjson.MarshalRules(nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(empty))
+	decoded, err := flagjson.UnmarshalRules(empty)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestUnmarshalFlag_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := flagjson.UnmarshalFlag([]byte("b:true"))
+	require.Error(t, err)
+}
+
+func TestTargets_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	numVal := 7.0
+	targets := map[string]domain.FlagValue{
+		"user-1":   {Bool: &on},
+		"device-2": {Numeric: &numVal},
+	}
+
+	raw, err := flagjson.MarshalTargets(targets)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalTargets(raw)
+	require.NoError(t, err)
+	assert.Equal(t, targets, got)
+
+	empty, err := flagjson.MarshalTargets(nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(empty))
+	decoded, err := flagjson.UnmarshalTargets(empty)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestExperiment_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	raw, err := flagjson.MarshalExperiment(nil)
+	require.NoError(t, err)
+	assert.Nil(t, raw)
+	exp, err := flagjson.UnmarshalExperiment(raw)
+	require.NoError(t, err)
+	assert.Nil(t, exp)
+
+	weights := []domain.VariantWeight{{Variant: "a", Weight: 3}, {Variant: "b", Weight: 1}}
+	raw, err = flagjson.MarshalVariantWeights(weights)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalVariantWeights(raw)
+	require.NoError(t, err)
+	assert.Equal(t, weights, got)
+}
+
+func TestBandit_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	raw, err := flagjson.MarshalBandit(nil)
+	require.NoError(t, err)
+	assert.Nil(t, raw)
+	cfg, err := flagjson.UnmarshalBandit(raw)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	want := &domain.BanditConfig{Algorithm: domain.BanditEpsilonGreedy, Metric: "click", Epsilon: 0.1, MinExploration: 0.05}
+	raw, err = flagjson.MarshalBandit(want)
+	require.NoError(t, err)
+	cfg, err = flagjson.UnmarshalBandit(raw)
+	require.NoError(t, err)
+	assert.Equal(t, want, cfg)
+}
+
+func TestDiffs_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	raw, err := flagjson.MarshalDiffs(nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(raw))
+
+	off := false
+	on := true
+	want := []domain.FlagDiff{
+		{
+			Name:    "new-checkout",
+			Value:   &domain.ValueChange{From: domain.FlagValue{Bool: &off}, To: domain.FlagValue{Bool: &on}},
+			Enabled: &domain.EnabledChange{From: false, To: true},
+			Rules:   []domain.RuleChange{{ID: "pro", Kind: domain.ChangeAdded}},
+			Targets: []domain.TargetChange{{Key: "user-1", Kind: domain.ChangeRemoved}},
+		},
+		{Name: "other-flag", Rules: []domain.RuleChange{{ID: "beta", Kind: domain.ChangeMoved}}},
+	}
+	raw, err = flagjson.MarshalDiffs(want)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalDiffs(raw)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestPermissions_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	raw, err := flagjson.MarshalPermissions(nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(raw))
+
+	want := []domain.Permission{
+		{Scope: domain.ScopeFlagsWrite, Projects: []string{"shop"}, Tags: []string{"checkout"}},
+		{Scope: domain.ScopeFlagsRead, Environments: []string{"staging"}, Flags: []string{"new-checkout"}},
+	}
+	raw, err = flagjson.MarshalPermissions(want)
+	require.NoError(t, err)
+	got, err := flagjson.UnmarshalPermissions(raw)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
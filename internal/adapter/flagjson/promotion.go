@@ -0,0 +1,94 @@
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type flagDiffDoc struct {
+	Name    string            `json:"name"`
+	Value   *valueChangeDoc   `json:"value,omitempty"`
+	Enabled *enabledChangeDoc `json:"enabled,omitempty"`
+	Rules   []changeDoc       `json:"rules,omitempty"`
+	Targets []changeDoc       `json:"targets,omitempty"`
+}
+
+type valueChangeDoc struct {
+	From valueDoc `json:"from"`
+	To   valueDoc `json:"to"`
+}
+
+type enabledChangeDoc struct {
+	From bool `json:"from"`
+	To   bool `json:"to"`
+}
+
+// changeDoc is a rule or target change; Key is the rule id or targeting key.
+type changeDoc struct {
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+}
+
+// MarshalDiffs encodes promotion diffs as a JSON array. A nil slice encodes as [].
+func MarshalDiffs(diffs []domain.FlagDiff) ([]byte, error) {
+	docs := make([]flagDiffDoc, len(diffs))
+	for i, d := range diffs {
+		doc := flagDiffDoc{Name: d.Name}
+		if d.Value != nil {
+			doc.Value = &valueChangeDoc{
+				From: valueDoc{Bool: d.Value.From.Bool, Numeric: d.Value.From.Numeric},
+				To:   valueDoc{Bool: d.Value.To.Bool, Numeric: d.Value.To.Numeric},
+			}
+		}
+		if d.Enabled != nil {
+			doc.Enabled = &enabledChangeDoc{From: d.Enabled.From, To: d.Enabled.To}
+		}
+		for _, r := range d.Rules {
+			doc.Rules = append(doc.Rules, changeDoc{Key: r.ID, Kind: string(r.Kind)})
+		}
+		for _, t := range d.Targets {
+			doc.Targets = append(doc.Targets, changeDoc{Key: t.Key, Kind: string(t.Kind)})
+		}
+		docs[i] = doc
+	}
+	raw, err := json.Marshal(docs)
+	if err != nil {
+		return nil, fmt.Errorf("encode promotion diffs: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalDiffs decodes a JSON array produced by MarshalDiffs. An empty array
+// decodes as nil.
+func UnmarshalDiffs(raw []byte) ([]domain.FlagDiff, error) {
+	var docs []flagDiffDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode promotion diffs: %w", err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	diffs := make([]domain.FlagDiff, len(docs))
+	for i, doc := range docs {
+		d := domain.FlagDiff{Name: doc.Name}
+		if doc.Value != nil {
+			d.Value = &domain.ValueChange{
+				From: domain.FlagValue{Bool: doc.Value.From.Bool, Numeric: doc.Value.From.Numeric},
+				To:   domain.FlagValue{Bool: doc.Value.To.Bool, Numeric: doc.Value.To.Numeric},
+			}
+		}
+		if doc.Enabled != nil {
+			d.Enabled = &domain.EnabledChange{From: doc.Enabled.From, To: doc.Enabled.To}
+		}
+		for _, r := range doc.Rules {
+			d.Rules = append(d.Rules, domain.RuleChange{ID: r.Key, Kind: domain.ChangeKind(r.Kind)})
+		}
+		for _, t := range doc.Targets {
+			d.Targets = append(d.Targets, domain.TargetChange{Key: t.Key, Kind: domain.ChangeKind(t.Kind)})
+		}
+		diffs[i] = d
+	}
+	return diffs, nil
+}
@@ -0,0 +1,88 @@
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type ruleDoc struct {
+	ID         string      `json:"id"`
+	Clauses    []clauseDoc `json:"clauses"`
+	Expression string      `json:"expression,omitempty"`
+	Bool       *bool       `json:"bool,omitempty"`
+	Numeric    *float64    `json:"numeric,omitempty"`
+}
+
+type clauseDoc struct {
+	Attribute string   `json:"attribute,omitempty"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+// MarshalRules encodes targeting rules as a JSON array. A nil slice encodes as [].
+func MarshalRules(rules []domain.Rule) ([]byte, error) {
+	raw, err := json.Marshal(rulesToDocs(rules))
+	if err != nil {
+		return nil, fmt.Errorf("encode rules: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalRules decodes a JSON array produced by MarshalRules, compiled for
+// evaluation. An empty array decodes as nil.
+func UnmarshalRules(raw []byte) ([]domain.Rule, error) {
+	var docs []ruleDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode rules: %w", err)
+	}
+	return docsToRules(docs), nil
+}
+
+func rulesToDocs(rules []domain.Rule) []ruleDoc {
+	docs := make([]ruleDoc, len(rules))
+	for i, r := range rules {
+		clauses := make([]clauseDoc, len(r.Clauses))
+		for j, c := range r.Clauses {
+			clauses[j] = clauseDoc{
+				Attribute: c.Attribute,
+				Operator:  string(c.Operator),
+				Values:    c.Values,
+				Timezone:  c.Timezone,
+			}
+		}
+		docs[i] = ruleDoc{ID: r.ID, Clauses: clauses, Expression: r.Expression, Bool: r.Value.Bool, Numeric: r.Value.Numeric}
+	}
+	return docs
+}
+
+func docsToRules(docs []ruleDoc) []domain.Rule {
+	if len(docs) == 0 {
+		return nil
+	}
+	rules := make([]domain.Rule, len(docs))
+	for i, r := range docs {
+		var clauses []domain.Clause
+		if len(r.Clauses) > 0 {
+			clauses = make([]domain.Clause, len(r.Clauses))
+		}
+		for j, c := range r.Clauses {
+			clauses[j] = domain.Clause{
+				Attribute: c.Attribute,
+				Operator:  domain.Operator(c.Operator),
+				Values:    c.Values,
+				Timezone:  c.Timezone,
+			}
+		}
+		rules[i] = domain.Rule{
+			ID:         r.ID,
+			Clauses:    clauses,
+			Expression: r.Expression,
+			Value:      domain.FlagValue{Bool: r.Bool, Numeric: r.Numeric},
+		}
+	}
+	domain.CompileRules(rules)
+	return rules
+}
@@ -0,0 +1,52 @@
+package flagjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type valueDoc struct {
+	Bool    *bool    `json:"bool,omitempty"`
+	Numeric *float64 `json:"numeric,omitempty"`
+}
+
+// MarshalTargets encodes individual targets as a JSON object keyed by
+// targeting key. A nil map encodes as {}.
+func MarshalTargets(targets map[string]domain.FlagValue) ([]byte, error) {
+	raw, err := json.Marshal(targetsToDocs(targets))
+	if err != nil {
+		return nil, fmt.Errorf("encode targets: %w", err)
+	}
+	return raw, nil
+}
+
+// UnmarshalTargets decodes a JSON object produced by MarshalTargets. An empty
+// object decodes as nil.
+func UnmarshalTargets(raw []byte) (map[string]domain.FlagValue, error) {
+	var docs map[string]valueDoc
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("decode targets: %w", err)
+	}
+	return docsToTargets(docs), nil
+}
+
+func targetsToDocs(targets map[string]domain.FlagValue) map[string]valueDoc {
+	docs := make(map[string]valueDoc, len(targets))
+	for key, v := range targets {
+		docs[key] = valueDoc{Bool: v.Bool, Numeric: v.Numeric}
+	}
+	return docs
+}
+
+func docsToTargets(docs map[string]valueDoc) map[string]domain.FlagValue {
+	if len(docs) == 0 {
+		return nil
+	}
+	targets := make(map[string]domain.FlagValue, len(docs))
+	for key, v := range docs {
+		targets[key] = domain.FlagValue{Bool: v.Bool, Numeric: v.Numeric}
+	}
+	return targets
+}
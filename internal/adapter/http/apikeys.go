@@ -0,0 +1,166 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// bearerPrefix starts the Authorization header of a request made with an API
+// key.
+const bearerPrefix = "Bearer "
+
+// authenticated resolves the API key in the Authorization header of every
+// request to its principal and makes the principal the request's actor,
+// whatever X-Actor says, so every change is recorded against the key that
+// made it. Requests without a valid key are refused. Without an API key
+// service every request passes unauthenticated.
+func (h *Handler) authenticated(next http.Handler) http.Handler {
+	if h.apiKeys == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), bearerPrefix)
+		if !ok || token == "" {
+			h.unauthenticated(w, r, domain.ErrUnauthenticated)
+			return
+		}
+		principal, err := h.apiKeys.Authenticate(r.Context(), token)
+		if err != nil {
+			h.unauthenticated(w, r, err)
+			return
+		}
+		ctx := port.WithPrincipal(port.WithActor(r.Context(), principal.Name), *principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *Handler) unauthenticated(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, domain.ErrUnauthenticated) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="feature-flags"`)
+	}
+	h.writeError(w, r, err)
+}
+
+// authorized serves the route only to principals whose key grants scope.
+// Without an API key service it serves every request.
+func (h *Handler) authorized(scope domain.Scope, handler http.HandlerFunc) http.HandlerFunc {
+	if h.apiKeys == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := port.PrincipalFrom(r.Context())
+		if !ok || !principal.Allows(scope) {
+			h.writeError(w, r, fmt.Errorf("%s requires scope %s: %w", r.URL.Path, scope, domain.ErrInsufficientScope))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	create := port.APIKeyRequest{Principal: req.Principal, Scopes: req.Scopes}
+	if req.ExpiresAt != nil {
+		create.ExpiresAt = *req.ExpiresAt
+	}
+
+	resp, err := h.apiKeys.CreateAPIKey(r.Context(), create)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toAPIKeyResponse(resp))
+}
+
+func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.apiKeys.ListAPIKeys(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listAPIKeysResponse{APIKeys: make([]apiKeyResponse, len(resp))}
+	for i, key := range resp {
+		out.APIKeys[i] = toAPIKeyResponse(&key)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := apiKeyID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.apiKeys.GetAPIKey(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIKeyResponse(resp))
+}
+
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := apiKeyID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.apiKeys.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAPIKeyResponse(resp))
+}
+
+// rotateAPIKey accepts an empty body, which retires the old key at once.
+func (h *Handler) rotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := apiKeyID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	var req rotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, r, fmt.Errorf("%w: %v", errInvalidRequest, err))
+		return
+	}
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		if grace, err = time.ParseDuration(req.GracePeriod); err != nil {
+			h.writeError(w, r, fmt.Errorf("grace period %q is not a duration: %w", req.GracePeriod, domain.ErrInvalidAPIKey))
+			return
+		}
+	}
+
+	resp, err := h.apiKeys.RotateAPIKey(r.Context(), id, grace)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toAPIKeyResponse(resp))
+}
+
+// apiKeyID parses the key id in the path. An id that is not a number names
+// no key.
+func apiKeyID(r *http.Request) (int64, error) {
+	raw := r.PathValue("id")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("api key %q: %w", raw, domain.ErrAPIKeyNotFound)
+	}
+	return id, nil
+}
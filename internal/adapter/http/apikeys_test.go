@@ -0,0 +1,248 @@
+package http_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// fakeAPIKeyService is a hand-written fake implementing port.APIKeyService.
+// It authenticates the tokens in principals.
+type fakeAPIKeyService struct {
+	principals map[string]domain.Principal
+	key        *port.APIKeyResponse
+	err        error
+
+	req   port.APIKeyRequest
+	id    int64
+	grace time.Duration
+}
+
+func (f *fakeAPIKeyService) CreateAPIKey(_ context.Context, req port.APIKeyRequest) (*port.APIKeyResponse, error) {
+	f.req = req
+	return f.key, f.err
+}
+
+func (f *fakeAPIKeyService) ListAPIKeys(context.Context) ([]port.APIKeyResponse, error) {
+	if f.key == nil {
+		return nil, f.err
+	}
+	return []port.APIKeyResponse{*f.key}, f.err
+}
+
+func (f *fakeAPIKeyService) GetAPIKey(_ context.Context, id int64) (*port.APIKeyResponse, error) {
+	f.id = id
+	return f.key, f.err
+}
+
+func (f *fakeAPIKeyService) RevokeAPIKey(_ context.Context, id int64) (*port.APIKeyResponse, error) {
+	f.id = id
+	return f.key, f.err
+}
+
+func (f *fakeAPIKeyService) RotateAPIKey(_ context.Context, id int64, grace time.Duration) (*port.APIKeyResponse, error) {
+	f.id, f.grace = id, grace
+	return f.key, f.err
+}
+
+func (f *fakeAPIKeyService) Authenticate(_ context.Context, token string) (*domain.Principal, error) {
+	principal, ok := f.principals[token]
+	if !ok {
+		return nil, domain.ErrUnauthenticated
+	}
+	return &principal, nil
+}
+
+func newFakeAPIKeyService() *fakeAPIKeyService {
+	return &fakeAPIKeyService{principals: map[string]domain.Principal{
+		"ffk_admin":    {Name: "platform", KeyID: 1, Scopes: []domain.Scope{domain.ScopeAdmin}},
+		"ffk_write":    {Name: "checkout-team", KeyID: 2, Scopes: []domain.Scope{domain.ScopeFlagsWrite}},
+		"ffk_read":     {Name: "dashboards", KeyID: 3, Scopes: []domain.Scope{domain.ScopeFlagsRead}},
+		"ffk_evaluate": {Name: "checkout-web", KeyID: 4, Scopes: []domain.Scope{domain.ScopeFlagsEvaluate}},
+	}}
+}
+
+// serveAuthenticated serves the request with API keys enforced, made with
+// token unless it is empty.
+func serveAuthenticated(t *testing.T, svc port.FlagService, keys port.APIKeyService, token string, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	httpadapter.NewHandler(svc, slog.New(slog.DiscardHandler), httpadapter.WithAPIKeys(keys)).Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func serveAPIKeys(t *testing.T, keys port.APIKeyService, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveAuthenticated(t, &fakeFlagService{}, keys, "ffk_admin", httptest.NewRequest(method, target, strings.NewReader(body)))
+}
+
+func ciKey() *port.APIKeyResponse {
+	return &port.APIKeyResponse{
+		ID: 7, Principal: "ci", Scopes: []string{"flags:read"}, Hint: "ffk_0123abcd",
+		CreatedBy: "platform", CreatedAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		target     string
+		wantStatus int
+		wantCode   string
+		wantActor  string
+	}{
+		{name: "no key", method: http.MethodGet, target: "/audit", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHENTICATED"},
+		{name: "unknown key", token: "ffk_unknown", method: http.MethodGet, target: "/audit", wantStatus: http.StatusUnauthorized, wantCode: "UNAUTHENTICATED"},
+		{name: "read", token: "ffk_read", method: http.MethodGet, target: "/audit", wantStatus: http.StatusOK, wantActor: "dashboards"},
+		{name: "admin reads", token: "ffk_admin", method: http.MethodGet, target: "/audit", wantStatus: http.StatusOK, wantActor: "platform"},
+		{name: "evaluate cannot read", token: "ffk_evaluate", method: http.MethodGet, target: "/audit", wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_SCOPE"},
+		{name: "write", token: "ffk_write", method: http.MethodPost, target: "/projects/payments/change-requests/3/approve", wantStatus: http.StatusOK, wantActor: "checkout-team"},
+		{name: "read cannot write", token: "ffk_read", method: http.MethodPost, target: "/change-requests/3/approve", wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_SCOPE"},
+		{name: "write cannot administer", token: "ffk_write", method: http.MethodPost, target: "/freezes", wantStatus: http.StatusForbidden, wantCode: "INSUFFICIENT_SCOPE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{changeResp: &port.ChangeRequestResponse{ID: 3}}
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("X-Actor", "mallory")
+
+			rec := serveAuthenticated(t, svc, newFakeAPIKeyService(), tt.token, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, decodeBody(t, rec)["code"])
+				assert.Empty(t, svc.actor, "the service is not called")
+			} else {
+				assert.Equal(t, tt.wantActor, svc.actor, "the key's principal replaces X-Actor")
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Bearer realm="feature-flags"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestHandler_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "creates", body: `{"principal":"ci","scopes":["flags:read"],"expires_at":"2026-09-01T00:00:00Z"}`, wantStatus: http.StatusCreated},
+		{name: "malformed body", body: `{"scopes":"flags:read"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid key", body: `{"principal":"ci","scopes":["flags:all"]}`, svcErr: domain.ErrInvalidAPIKey, wantStatus: http.StatusBadRequest, wantCode: "INVALID_API_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			key := ciKey()
+			key.Token = "ffk_0123abcdef"
+			svc := newFakeAPIKeyService()
+			svc.key, svc.err = key, tt.svcErr
+
+			rec := serveAPIKeys(t, svc, http.MethodPost, "/api-keys", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, port.APIKeyRequest{
+				Principal: "ci", Scopes: []string{"flags:read"}, ExpiresAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+			}, svc.req)
+			assert.Equal(t, map[string]any{
+				"id": float64(7), "principal": "ci", "scopes": []any{"flags:read"}, "hint": "ffk_0123abcd",
+				"token": "ffk_0123abcdef", "created_by": "platform", "created_at": "2026-06-01T09:00:00Z",
+			}, body)
+		})
+	}
+}
+
+func TestHandler_ListAPIKeys_HidesToken(t *testing.T) {
+	t.Parallel()
+
+	svc := newFakeAPIKeyService()
+	svc.key = ciKey()
+
+	rec := serveAPIKeys(t, svc, http.MethodGet, "/api-keys", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	keys, ok := decodeBody(t, rec)["api_keys"].([]any)
+	require.True(t, ok)
+	require.Len(t, keys, 1)
+	assert.NotContains(t, keys[0], "token")
+}
+
+func TestHandler_APIKeyByID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantID     int64
+		wantGrace  time.Duration
+	}{
+		{name: "get", method: http.MethodGet, target: "/api-keys/7", wantStatus: http.StatusOK, wantID: 7},
+		{name: "revoke", method: http.MethodPost, target: "/api-keys/7/revoke", wantStatus: http.StatusOK, wantID: 7},
+		{name: "revoke twice", method: http.MethodPost, target: "/api-keys/7/revoke", svcErr: domain.ErrAPIKeyRevoked, wantStatus: http.StatusConflict, wantID: 7},
+		{name: "rotate at once", method: http.MethodPost, target: "/api-keys/7/rotate", wantStatus: http.StatusCreated, wantID: 7},
+		{name: "rotate with grace", method: http.MethodPost, target: "/api-keys/7/rotate", body: `{"grace_period":"1h"}`, wantStatus: http.StatusCreated, wantID: 7, wantGrace: time.Hour},
+		{name: "bad grace", method: http.MethodPost, target: "/api-keys/7/rotate", body: `{"grace_period":"a while"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing", method: http.MethodGet, target: "/api-keys/9", svcErr: domain.ErrAPIKeyNotFound, wantStatus: http.StatusNotFound, wantID: 9},
+		{name: "not a number", method: http.MethodPost, target: "/api-keys/ci/revoke", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newFakeAPIKeyService()
+			svc.key, svc.err = ciKey(), tt.svcErr
+
+			rec := serveAPIKeys(t, svc, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantID, svc.id)
+			assert.Equal(t, tt.wantGrace, svc.grace)
+		})
+	}
+}
+
+func TestHandler_APIKeyRoutesRequireService(t *testing.T) {
+	t.Parallel()
+
+	rec := serve(t, &fakeFlagService{}, http.MethodGet, "/api-keys", "")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
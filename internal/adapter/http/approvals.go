@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) getFlagProtection(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetFlagProtection(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagProtectionResponse(resp))
+}
+
+func (h *Handler) setFlagProtection(w http.ResponseWriter, r *http.Request) {
+	var req flagProtectionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if req.RequiredApprovals == nil {
+		h.writeError(w, r, fmt.Errorf("%w: required_approvals is required", errInvalidRequest))
+		return
+	}
+
+	resp, err := h.svc.SetFlagProtection(r.Context(), r.PathValue("name"), port.FlagProtectionRequest{
+		RequiredApprovals: *req.RequiredApprovals,
+		Environments:      req.Environments,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagProtectionResponse(resp))
+}
+
+func (h *Handler) listChangeRequests(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	resp, err := h.svc.ListChangeRequests(r.Context(), port.ChangeRequestFilter{
+		Flag:   query.Get("flag"),
+		Status: query.Get("status"),
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listChangeRequestsResponse{ChangeRequests: make([]changeRequestResponse, len(resp))}
+	for i, cr := range resp {
+		out.ChangeRequests[i] = toChangeRequestResponse(&cr)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, h.svc.GetChangeRequest)
+}
+
+func (h *Handler) approveChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, h.svc.ApproveChangeRequest)
+}
+
+func (h *Handler) rejectChangeRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideChangeRequest(w, r, h.svc.RejectChangeRequest)
+}
+
+// decideChangeRequest calls op with the change request id in the path and
+// writes the request it returns. An id that is not a number names no request.
+func (h *Handler) decideChangeRequest(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, id int64) (*port.ChangeRequestResponse, error)) {
+	raw := r.PathValue("id")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("change request %q: %w", raw, domain.ErrChangeRequestNotFound))
+		return
+	}
+	resp, err := op(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toChangeRequestResponse(resp))
+}
@@ -0,0 +1,169 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func pendingChange(created time.Time) *port.ChangeRequestResponse {
+	on := true
+	return &port.ChangeRequestResponse{
+		ID: 7, Project: "checkout", Flag: "new-checkout", Environment: "production",
+		Value: port.FlagValue{Bool: &on}, Status: "pending", RequestedBy: "alice", RequiredApprovals: 2,
+		Approvals: []port.ApprovalResponse{}, CreatedAt: created, ExpiresAt: created.Add(72 * time.Hour),
+	}
+}
+
+func TestHandler_SetFlagProtection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "protects", body: `{"required_approvals": 2, "environments": ["production"]}`, wantStatus: http.StatusOK},
+		{name: "missing approvals", body: `{"environments": ["production"]}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid protection", body: `{"required_approvals": 11}`, svcErr: domain.ErrInvalidProtection, wantStatus: http.StatusBadRequest, wantCode: "INVALID_PROTECTION"},
+		{name: "unknown flag", body: `{"required_approvals": 1}`, svcErr: domain.ErrNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{
+				protectResp: &port.FlagProtectionResponse{Project: "checkout", Flag: "new-checkout", RequiredApprovals: 2, Environments: []string{"production"}},
+				err:         tt.svcErr,
+			}
+
+			rec := serve(t, svc, http.MethodPut, "/projects/checkout/flags/new-checkout/protection", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, "checkout", svc.project)
+			assert.Equal(t, "new-checkout", svc.requestedAs)
+			assert.Equal(t, port.FlagProtectionRequest{RequiredApprovals: 2, Environments: []string{"production"}}, svc.protectReq)
+			assert.Equal(t, float64(2), body["required_approvals"])
+			assert.Equal(t, []any{"production"}, body["environments"])
+		})
+	}
+}
+
+func TestHandler_GetFlagProtection_Unprotected(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeFlagService{protectResp: &port.FlagProtectionResponse{Project: domain.DefaultProject, Flag: "new-checkout"}}
+
+	rec := serve(t, svc, http.MethodGet, "/flags/new-checkout/protection", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, float64(0), body["required_approvals"])
+	assert.Equal(t, []any{}, body["environments"])
+}
+
+func TestHandler_UpdateFlagValue_Protected(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	created := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name: "new-checkout", Type: "boolean", Enabled: true, Value: port.FlagValue{Bool: &off},
+		PendingChange: pendingChange(created),
+	}}
+
+	rec := serve(t, svc, http.MethodPut, "/flags/new-checkout/value", `{"value": true}`)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, float64(7), body["id"])
+	assert.Equal(t, "pending", body["status"])
+	assert.Equal(t, true, body["value"])
+	assert.Equal(t, "alice", body["requested_by"])
+	assert.Equal(t, []any{}, body["approvals"])
+	assert.NotContains(t, body, "decided_at")
+}
+
+func TestHandler_ListChangeRequests(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{changesResp: []port.ChangeRequestResponse{*pendingChange(created)}}
+
+	rec := serve(t, svc, http.MethodGet, "/projects/checkout/change-requests?flag=new-checkout&status=pending", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "checkout", svc.project)
+	assert.Equal(t, port.ChangeRequestFilter{Flag: "new-checkout", Status: "pending"}, svc.changeQuery)
+	changes, ok := decodeBody(t, rec)["change_requests"].([]any)
+	require.True(t, ok)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "new-checkout", changes[0].(map[string]any)["flag"])
+}
+
+func TestHandler_DecideChangeRequest(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	applied := pendingChange(created)
+	applied.Status, applied.DecidedBy, applied.DecidedAt = "applied", "carol", created.Add(time.Hour)
+	applied.Approvals = []port.ApprovalResponse{
+		{Approver: "bob", ApprovedAt: created.Add(time.Minute)},
+		{Approver: "carol", ApprovedAt: created.Add(time.Hour)},
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "get", method: http.MethodGet, target: "/change-requests/7", wantStatus: http.StatusOK},
+		{name: "approve", method: http.MethodPost, target: "/change-requests/7/approve", wantStatus: http.StatusOK},
+		{name: "reject", method: http.MethodPost, target: "/change-requests/7/reject", wantStatus: http.StatusOK},
+		{name: "malformed id", method: http.MethodGet, target: "/change-requests/seven", wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "unknown", method: http.MethodPost, target: "/change-requests/7/approve", svcErr: domain.ErrChangeRequestNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "self-approval", method: http.MethodPost, target: "/change-requests/7/approve", svcErr: domain.ErrApprovalNotAllowed, wantStatus: http.StatusForbidden, wantCode: "APPROVAL_NOT_ALLOWED"},
+		{name: "decided", method: http.MethodPost, target: "/change-requests/7/reject", svcErr: domain.ErrChangeRequestState, wantStatus: http.StatusConflict, wantCode: "INVALID_STATE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{changeResp: applied, err: tt.svcErr}
+
+			rec := serve(t, svc, tt.method, tt.target, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, int64(7), svc.changeID)
+			assert.Equal(t, "applied", body["status"])
+			assert.Equal(t, "carol", body["decided_by"])
+			assert.Equal(t, "2026-11-27T10:00:00Z", body["decided_at"])
+			approvals, ok := body["approvals"].([]any)
+			require.True(t, ok)
+			require.Len(t, approvals, 2)
+			assert.Equal(t, "bob", approvals[0].(map[string]any)["approver"])
+		})
+	}
+}
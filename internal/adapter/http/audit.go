@@ -0,0 +1,127 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+const (
+	// actorHeader names who is making the request. Requests without it are
+	// attributed to domain.AnonymousActor.
+	actorHeader     = "X-Actor"
+	requestIDHeader = "X-Request-ID"
+	// emergencyHeader gives the reason a request overrides change freezes.
+	emergencyHeader = "X-Emergency-Override"
+	// maxRequestIDLength bounds a caller-supplied request id; longer ones are
+	// replaced with a generated id.
+	maxRequestIDLength = 128
+)
+
+// attributed records the actor, request id and any emergency override of
+// every request on its context, so stores can audit the changes it makes, and
+// echoes the request id in the response.
+func attributed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get(actorHeader)
+		if actor == "" {
+			actor = domain.AnonymousActor
+		}
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := port.WithRequestID(port.WithActor(r.Context(), actor), id)
+		if reason := r.Header.Get(emergencyHeader); reason != "" {
+			ctx = port.WithEmergency(ctx, reason)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (h *Handler) listAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := port.AuditFilter{
+		Project:     query.Get("project"),
+		Environment: query.Get("environment"),
+		Flag:        query.Get("flag"),
+		Actor:       query.Get("actor"),
+		Action:      query.Get("action"),
+	}
+	var err error
+	if filter.Since, err = parseTime(query.Get("since"), domain.ErrInvalidAuditQuery); err != nil {
+		h.writeError(w, r, fmt.Errorf("since: %w", err))
+		return
+	}
+	if filter.Until, err = parseTime(query.Get("until"), domain.ErrInvalidAuditQuery); err != nil {
+		h.writeError(w, r, fmt.Errorf("until: %w", err))
+		return
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			h.writeError(w, r, fmt.Errorf("limit %q is not a number: %w", raw, domain.ErrInvalidAuditQuery))
+			return
+		}
+	}
+
+	resp, err := h.svc.ListAudit(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listAuditResponse{Entries: make([]auditEntryResponse, len(resp))}
+	for i, entry := range resp {
+		out.Entries[i] = toAuditEntryResponse(entry)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// verifyAudit reports a broken chain in the body rather than as an error: the
+// verification itself succeeded.
+func (h *Handler) verifyAudit(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.audit.VerifyAudit(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAuditVerificationResponse(resp))
+}
+
+func (h *Handler) listAuditCheckpoints(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.audit.ListAuditCheckpoints(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listAuditCheckpointsResponse{Checkpoints: make([]auditCheckpointResponse, len(resp))}
+	for i, cp := range resp {
+		out.Checkpoints[i] = toAuditCheckpointResponse(cp)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// parseTime parses an RFC 3339 query parameter; empty is the zero time. A
+// malformed time is reported as invalid.
+func parseTime(raw string, invalid error) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time: %w", raw, invalid)
+	}
+	return t, nil
+}
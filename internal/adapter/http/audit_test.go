@@ -0,0 +1,213 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_ListAudit(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{auditResp: []port.AuditEntryResponse{{
+		ID:          7,
+		Actor:       "alice",
+		Action:      "update-value",
+		Project:     "default",
+		Environment: "production",
+		Flag:        "new-checkout",
+		Before:      &port.FlagResponse{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}},
+		After:       &port.FlagResponse{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &on}},
+		RequestID:   "req-1",
+		RecordedAt:  at,
+		Hash:        "ab12",
+	}}}
+
+	rec := serve(t, svc, http.MethodGet, "/audit?flag=new-checkout&actor=alice&action=update-value&environment=production&since=2026-06-01T00:00:00Z&limit=20", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, port.AuditFilter{
+		Environment: "production",
+		Flag:        "new-checkout",
+		Actor:       "alice",
+		Action:      "update-value",
+		Since:       time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Limit:       20,
+	}, svc.auditFilter)
+	body := decodeBody(t, rec)
+	entries, ok := body["entries"].([]any)
+	require.True(t, ok)
+	require.Len(t, entries, 1)
+	entry := entries[0].(map[string]any)
+	assert.Equal(t, "alice", entry["actor"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "ab12", entry["hash"])
+	assert.Equal(t, false, entry["before"].(map[string]any)["value"])
+	assert.Equal(t, true, entry["after"].(map[string]any)["value"])
+}
+
+func TestHandler_ListAudit_InvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{"/audit?since=yesterday", "/audit?until=2026-06-01", "/audit?limit=ten"} {
+		rec := serve(t, &fakeFlagService{}, http.MethodGet, target, "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Equal(t, "INVALID_QUERY", decodeBody(t, rec)["code"], target)
+	}
+}
+
+func TestHandler_AttributesRequests(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		actor         string
+		requestID     string
+		emergency     string
+		wantActor     string
+		wantRequestID string
+	}{
+		{name: "named actor and request", actor: "alice", requestID: "req-1", wantActor: "alice", wantRequestID: "req-1"},
+		{name: "emergency override", actor: "alice", requestID: "req-2", emergency: "INC-42 checkout outage", wantActor: "alice", wantRequestID: "req-2"},
+		{name: "anonymous with generated id", wantActor: "anonymous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{}
+			req := httptest.NewRequest(http.MethodGet, "/audit", nil)
+			if tt.actor != "" {
+				req.Header.Set("X-Actor", tt.actor)
+			}
+			if tt.requestID != "" {
+				req.Header.Set("X-Request-ID", tt.requestID)
+			}
+			if tt.emergency != "" {
+				req.Header.Set("X-Emergency-Override", tt.emergency)
+			}
+			rec := httptest.NewRecorder()
+			httpadapter.NewHandler(svc, slog.New(slog.DiscardHandler)).Routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantActor, svc.actor)
+			assert.Equal(t, tt.emergency, svc.emergency)
+			assert.Equal(t, svc.requestID, rec.Header().Get("X-Request-ID"), "the request id is echoed")
+			if tt.wantRequestID != "" {
+				assert.Equal(t, tt.wantRequestID, svc.requestID)
+			} else {
+				assert.Len(t, svc.requestID, 32)
+			}
+		})
+	}
+}
+
+// fakeAuditService is a hand-written fake implementing port.AuditService.
+type fakeAuditService struct {
+	report      *port.AuditVerificationResponse
+	checkpoints []port.AuditCheckpointResponse
+	err         error
+}
+
+func (f *fakeAuditService) VerifyAudit(context.Context) (*port.AuditVerificationResponse, error) {
+	return f.report, f.err
+}
+
+func (f *fakeAuditService) ListAuditCheckpoints(context.Context) ([]port.AuditCheckpointResponse, error) {
+	return f.checkpoints, f.err
+}
+
+func serveAudit(t *testing.T, svc port.AuditService, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	httpadapter.NewHandler(&fakeFlagService{}, slog.New(slog.DiscardHandler), httpadapter.WithAudit(svc)).Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_VerifyAudit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		svc        *fakeAuditService
+		wantStatus int
+		wantBody   map[string]any
+	}{
+		{
+			name:       "intact chain",
+			svc:        &fakeAuditService{report: &port.AuditVerificationResponse{Entries: 12, Checkpoints: 2, SignaturesVerified: true}},
+			wantStatus: http.StatusOK,
+			wantBody:   map[string]any{"valid": true, "entries": float64(12), "checkpoints": float64(2), "signatures_verified": true},
+		},
+		{
+			name: "broken chain",
+			svc: &fakeAuditService{report: &port.AuditVerificationResponse{
+				Entries: 4, Checkpoints: 1,
+				Broken: &port.AuditBreak{EntryID: 5, CheckpointID: 1, Reason: "entry does not match its checkpoint"},
+			}},
+			wantStatus: http.StatusOK,
+			wantBody: map[string]any{
+				"valid": false, "entries": float64(4), "checkpoints": float64(1), "signatures_verified": false,
+				"broken": map[string]any{"entry_id": float64(5), "checkpoint_id": float64(1), "reason": "entry does not match its checkpoint"},
+			},
+		},
+		{
+			name:       "store failure",
+			svc:        &fakeAuditService{err: errors.New("connection refused")},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveAudit(t, tt.svc, "/audit/verify")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != nil {
+				assert.Equal(t, tt.wantBody, decodeBody(t, rec))
+			}
+		})
+	}
+}
+
+func TestHandler_ListAuditCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+	svc := &fakeAuditService{checkpoints: []port.AuditCheckpointResponse{{ID: 1, EntryID: 40, Hash: "ab12", Signature: "cd34", CreatedAt: at}}}
+
+	rec := serveAudit(t, svc, "/audit/checkpoints")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	checkpoints, ok := decodeBody(t, rec)["checkpoints"].([]any)
+	require.True(t, ok)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, map[string]any{
+		"id": float64(1), "entry_id": float64(40), "hash": "ab12", "signature": "cd34", "created_at": "2026-06-01T10:00:00Z",
+	}, checkpoints[0])
+}
+
+func TestHandler_AuditRoutesRequireService(t *testing.T) {
+	t.Parallel()
+
+	rec := serve(t, &fakeFlagService{}, http.MethodGet, "/audit/verify", "")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
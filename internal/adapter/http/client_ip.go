@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIP returns the address of the client that made the request. The
+// connection's peer address is used unless it belongs to a trusted proxy, in
+// which case X-Forwarded-For is walked from right to left and the first hop
+// that is not a trusted proxy is returned.
+func (h *Handler) clientIP(r *http.Request) (netip.Addr, bool) {
+	peer, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !h.isTrustedProxy(peer) {
+		return peer, true
+	}
+
+	hops := forwardedFor(r)
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(hops[i])
+		if !ok {
+			// Anything left of a malformed hop was not written by a trusted
+			// proxy, so the last proxy we trust is the best answer available.
+			return peer, true
+		}
+		if !h.isTrustedProxy(addr) {
+			return addr, true
+		}
+		peer = addr
+	}
+	return peer, true
+}
+
+func (h *Handler) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwardedFor(r *http.Request) []string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseAddr parses an address with or without a port.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
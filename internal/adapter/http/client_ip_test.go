@@ -0,0 +1,107 @@
+package http_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_Evaluate_ClientIP(t *testing.T) {
+	t.Parallel()
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		body         string
+		wantClientIP any
+	}{
+		{
+			name:         "direct connection",
+			remoteAddr:   "203.0.113.9:51234",
+			wantClientIP: "203.0.113.9",
+		},
+		{
+			name:         "forwarded header from untrusted peer is ignored",
+			remoteAddr:   "203.0.113.9:51234",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "203.0.113.9",
+		},
+		{
+			name:         "trusted proxy",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"198.51.100.1"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "chain of trusted proxies",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"6.6.6.6, 198.51.100.1, 10.9.9.9"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "chain split over headers",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"198.51.100.1", "10.9.9.9"},
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "all hops trusted",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"10.4.4.4"},
+			wantClientIP: "10.4.4.4",
+		},
+		{
+			name:         "malformed hop stops the walk",
+			remoteAddr:   "10.1.2.3:443",
+			forwardedFor: []string{"198.51.100.1, garbage, 10.9.9.9"},
+			wantClientIP: "10.9.9.9",
+		},
+		{
+			name:         "ipv6 trusted proxy",
+			remoteAddr:   "[fd00::1]:443",
+			forwardedFor: []string{"2001:db8::42"},
+			wantClientIP: "2001:db8::42",
+		},
+		{
+			name:         "caller-supplied client_ip is overridden",
+			remoteAddr:   "203.0.113.9:51234",
+			body:         `{"context":{"attributes":{"client_ip":"10.0.0.1","plan":"pro"}}}`,
+			wantClientIP: "203.0.113.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{evaluateResp: &port.EvaluateAllResponse{}}
+			handler := httpadapter.NewHandler(svc, slog.New(slog.DiscardHandler), httpadapter.WithTrustedProxies(trusted))
+
+			body := tt.body
+			if body == "" {
+				body = `{"context":{"targeting_key":"user-1"}}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(body))
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			rec := httptest.NewRecorder()
+			handler.Routes().ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantClientIP, svc.evalCtx.Attributes["client_ip"])
+		})
+	}
+}
@@ -0,0 +1,1019 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+type clauseJSON struct {
+	Attribute string   `json:"attribute,omitempty"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+type ruleRequest struct {
+	ID         string          `json:"id"`
+	Clauses    []clauseJSON    `json:"clauses"`
+	Expression string          `json:"expression"`
+	Value      json.RawMessage `json:"value"`
+}
+
+type ruleResponse struct {
+	ID         string       `json:"id"`
+	Clauses    []clauseJSON `json:"clauses"`
+	Expression string       `json:"expression,omitempty"`
+	Value      any          `json:"value"`
+}
+
+type createFlagRequest struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Tags        []string        `json:"tags"`
+	Value       json.RawMessage `json:"value"`
+	Rules       []ruleRequest   `json:"rules"`
+	Variants    []variantJSON   `json:"variants"`
+}
+
+type variantJSON struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type variantResponse struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+type updateFlagRulesRequest struct {
+	Rules []ruleRequest `json:"rules"`
+}
+
+type updateFlagValueRequest struct {
+	Value json.RawMessage `json:"value"`
+}
+
+type setFlagEnabledRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type addTargetsRequest struct {
+	Keys  []string        `json:"keys"`
+	Value json.RawMessage `json:"value"`
+}
+
+type evaluationContext struct {
+	TargetingKey string         `json:"targeting_key"`
+	Attributes   map[string]any `json:"attributes"`
+}
+
+type evaluateRequest struct {
+	Context evaluationContext `json:"context"`
+	Flags   []string          `json:"flags"`
+	Prefix  string            `json:"prefix"`
+}
+
+type flagResponse struct {
+	Project           string            `json:"project"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Description       string            `json:"description"`
+	Tags              []string          `json:"tags,omitempty"`
+	Environment       string            `json:"environment"`
+	SourceEnvironment string            `json:"source_environment"`
+	Enabled           bool              `json:"enabled"`
+	Value             any               `json:"value"`
+	Rules             []ruleResponse    `json:"rules"`
+	Targets           map[string]any    `json:"targets,omitempty"`
+	Variants          []variantResponse `json:"variants,omitempty"`
+	Experiment        string            `json:"experiment,omitempty"`
+	Version           int64             `json:"version"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+type flagValueResponse struct {
+	Value      any    `json:"value"`
+	Reason     string `json:"reason"`
+	RuleID     string `json:"rule_id,omitempty"`
+	Bucket     *int   `json:"bucket,omitempty"`
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	Version    int64  `json:"version"`
+	Source     string `json:"source"`
+}
+
+type evaluateResponse struct {
+	Flags map[string]flagValueResponse `json:"flags"`
+}
+
+type variantWeightJSON struct {
+	Variant string `json:"variant"`
+	Weight  int    `json:"weight"`
+}
+
+type banditJSON struct {
+	Algorithm      string  `json:"algorithm"`
+	Metric         string  `json:"metric"`
+	Epsilon        float64 `json:"epsilon,omitempty"`
+	MinExploration float64 `json:"min_exploration,omitempty"`
+}
+
+type createExperimentRequest struct {
+	Key        string              `json:"key"`
+	Flag       string              `json:"flag"`
+	Allocation int                 `json:"allocation"`
+	Variants   []variantWeightJSON `json:"variants"`
+	Layer      string              `json:"layer"`
+	Bandit     *banditJSON         `json:"bandit"`
+}
+
+type experimentResponse struct {
+	Key         string              `json:"key"`
+	Project     string              `json:"project"`
+	Flag        string              `json:"flag"`
+	Environment string              `json:"environment"`
+	Allocation  int                 `json:"allocation"`
+	Variants    []variantWeightJSON `json:"variants"`
+	Layer       string              `json:"layer,omitempty"`
+	LayerOffset *int                `json:"layer_offset,omitempty"`
+	Holdout     int                 `json:"holdout,omitempty"`
+	Bandit      *banditJSON         `json:"bandit,omitempty"`
+	Status      string              `json:"status"`
+	StartedAt   *time.Time          `json:"started_at,omitempty"`
+	StoppedAt   *time.Time          `json:"stopped_at,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type listExperimentsResponse struct {
+	Experiments []experimentResponse `json:"experiments"`
+}
+
+type weightChangeResponse struct {
+	Variants  []variantWeightJSON `json:"variants"`
+	ChangedAt time.Time           `json:"changed_at"`
+}
+
+type weightHistoryResponse struct {
+	Experiment string                 `json:"experiment"`
+	History    []weightChangeResponse `json:"history"`
+}
+
+type createEnvironmentRequest struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Parent      string `json:"parent"`
+	CopyFrom    string `json:"copy_from"`
+}
+
+type environmentResponse struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description,omitempty"`
+	Parent      string    `json:"parent,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type listEnvironmentsResponse struct {
+	Environments []environmentResponse `json:"environments"`
+}
+
+type createProjectRequest struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+type projectResponse struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type listProjectsResponse struct {
+	Projects []projectResponse `json:"projects"`
+}
+
+type auditEntryResponse struct {
+	ID          int64         `json:"id"`
+	Actor       string        `json:"actor"`
+	Action      string        `json:"action"`
+	Project     string        `json:"project"`
+	Environment string        `json:"environment"`
+	Flag        string        `json:"flag"`
+	Before      *flagResponse `json:"before"`
+	After       *flagResponse `json:"after"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Emergency   string        `json:"emergency,omitempty"`
+	RecordedAt  time.Time     `json:"recorded_at"`
+	Hash        string        `json:"hash"`
+}
+
+type listAuditResponse struct {
+	Entries []auditEntryResponse `json:"entries"`
+}
+
+type flagProtectionRequest struct {
+	RequiredApprovals *int     `json:"required_approvals"`
+	Environments      []string `json:"environments"`
+}
+
+type flagProtectionResponse struct {
+	Project           string   `json:"project"`
+	Flag              string   `json:"flag"`
+	RequiredApprovals int      `json:"required_approvals"`
+	Environments      []string `json:"environments"`
+}
+
+type approvalResponse struct {
+	Approver   string    `json:"approver"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+type changeRequestResponse struct {
+	ID                int64              `json:"id"`
+	Project           string             `json:"project"`
+	Flag              string             `json:"flag"`
+	Environment       string             `json:"environment"`
+	Value             any                `json:"value"`
+	Status            string             `json:"status"`
+	RequestedBy       string             `json:"requested_by"`
+	RequiredApprovals int                `json:"required_approvals"`
+	Approvals         []approvalResponse `json:"approvals"`
+	DecidedBy         string             `json:"decided_by,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+	ExpiresAt         time.Time          `json:"expires_at"`
+	DecidedAt         *time.Time         `json:"decided_at,omitempty"`
+}
+
+type listChangeRequestsResponse struct {
+	ChangeRequests []changeRequestResponse `json:"change_requests"`
+}
+
+type freezeWindowRequest struct {
+	Reason       string    `json:"reason"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	Projects     []string  `json:"projects"`
+	Environments []string  `json:"environments"`
+	Tags         []string  `json:"tags"`
+}
+
+type freezeWindowResponse struct {
+	ID           int64     `json:"id"`
+	Reason       string    `json:"reason"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	Projects     []string  `json:"projects"`
+	Environments []string  `json:"environments"`
+	Tags         []string  `json:"tags"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	Active       bool      `json:"active"`
+}
+
+type listFreezeWindowsResponse struct {
+	FreezeWindows []freezeWindowResponse `json:"freeze_windows"`
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type webhookResponse struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listWebhooksResponse struct {
+	Webhooks []webhookResponse `json:"webhooks"`
+}
+
+type apiKeyRequest struct {
+	Principal string     `json:"principal"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type rotateAPIKeyRequest struct {
+	GracePeriod string `json:"grace_period"`
+}
+
+type apiKeyResponse struct {
+	ID        int64      `json:"id"`
+	Principal string     `json:"principal"`
+	Scopes    []string   `json:"scopes"`
+	Hint      string     `json:"hint"`
+	Token     string     `json:"token,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []apiKeyResponse `json:"api_keys"`
+}
+
+type permissionRequest struct {
+	Scope        string   `json:"scope"`
+	Projects     []string `json:"projects,omitempty"`
+	Environments []string `json:"environments,omitempty"`
+	Flags        []string `json:"flags,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+type roleRequest struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Permissions []permissionRequest `json:"permissions"`
+}
+
+type roleResponse struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Permissions []permissionRequest `json:"permissions"`
+	CreatedBy   string              `json:"created_by"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type listRolesResponse struct {
+	Roles []roleResponse `json:"roles"`
+}
+
+type roleBindingRequest struct {
+	Principal string `json:"principal"`
+	Role      string `json:"role"`
+}
+
+type roleBindingResponse struct {
+	ID        int64     `json:"id"`
+	Principal string    `json:"principal"`
+	Role      string    `json:"role"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listRoleBindingsResponse struct {
+	RoleBindings []roleBindingResponse `json:"role_bindings"`
+}
+
+type deliveryResponse struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Project        string     `json:"project"`
+	Environment    string     `json:"environment"`
+	Flag           string     `json:"flag"`
+	Version        int64      `json:"version"`
+	Actor          string     `json:"actor"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type listDeliveriesResponse struct {
+	Deliveries []deliveryResponse `json:"deliveries"`
+}
+
+type auditBreakResponse struct {
+	EntryID      int64  `json:"entry_id"`
+	CheckpointID int64  `json:"checkpoint_id,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+type auditVerificationResponse struct {
+	Valid              bool                `json:"valid"`
+	Entries            int                 `json:"entries"`
+	Checkpoints        int                 `json:"checkpoints"`
+	SignaturesVerified bool                `json:"signatures_verified"`
+	Broken             *auditBreakResponse `json:"broken,omitempty"`
+}
+
+type auditCheckpointResponse struct {
+	ID        int64     `json:"id"`
+	EntryID   int64     `json:"entry_id"`
+	Hash      string    `json:"hash"`
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listAuditCheckpointsResponse struct {
+	Checkpoints []auditCheckpointResponse `json:"checkpoints"`
+}
+
+type flagVersionResponse struct {
+	Version     int64          `json:"version"`
+	Environment string         `json:"environment"`
+	Enabled     bool           `json:"enabled"`
+	Value       any            `json:"value"`
+	Rules       []ruleResponse `json:"rules"`
+	Targets     map[string]any `json:"targets,omitempty"`
+	RecordedAt  time.Time      `json:"recorded_at"`
+}
+
+type flagHistoryResponse struct {
+	Flag     string                `json:"flag"`
+	Versions []flagVersionResponse `json:"versions"`
+}
+
+type snapshotResponse struct {
+	Project     string         `json:"project"`
+	Environment string         `json:"environment"`
+	AsOf        time.Time      `json:"as_of"`
+	Flags       []flagResponse `json:"flags"`
+}
+
+type rollbackFlagRequest struct {
+	Version *int64 `json:"version"`
+}
+
+type promoteRequest struct {
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Flags  []string `json:"flags"`
+	DryRun bool     `json:"dry_run"`
+}
+
+type valueChangeResponse struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type enabledChangeResponse struct {
+	From bool `json:"from"`
+	To   bool `json:"to"`
+}
+
+type ruleChangeResponse struct {
+	ID     string `json:"id"`
+	Change string `json:"change"`
+}
+
+type targetChangeResponse struct {
+	Key    string `json:"key"`
+	Change string `json:"change"`
+}
+
+type flagDiffResponse struct {
+	Name    string                 `json:"name"`
+	Value   *valueChangeResponse   `json:"value,omitempty"`
+	Enabled *enabledChangeResponse `json:"enabled,omitempty"`
+	Rules   []ruleChangeResponse   `json:"rules,omitempty"`
+	Targets []targetChangeResponse `json:"targets,omitempty"`
+}
+
+type promotionResponse struct {
+	ID         int64              `json:"id,omitempty"`
+	Project    string             `json:"project"`
+	Source     string             `json:"source"`
+	Target     string             `json:"target"`
+	DryRun     bool               `json:"dry_run"`
+	Flags      []flagDiffResponse `json:"flags"`
+	PromotedAt *time.Time         `json:"promoted_at,omitempty"`
+}
+
+type listPromotionsResponse struct {
+	Promotions []promotionResponse `json:"promotions"`
+}
+
+type createLayerRequest struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+type layerSlotResponse struct {
+	Experiment string `json:"experiment"`
+	Offset     int    `json:"offset"`
+	Allocation int    `json:"allocation"`
+	Status     string `json:"status"`
+}
+
+type layerResponse struct {
+	Key         string              `json:"key"`
+	Description string              `json:"description,omitempty"`
+	Slots       []layerSlotResponse `json:"slots"`
+	Free        int                 `json:"free"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type listLayersResponse struct {
+	Layers []layerResponse `json:"layers"`
+}
+
+type metricEventJSON struct {
+	Metric       string `json:"metric"`
+	TargetingKey string `json:"targeting_key"`
+	// Value defaults to 1, a plain conversion, when omitted.
+	Value     *float64  `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type recordMetricsRequest struct {
+	Events []metricEventJSON `json:"events"`
+}
+
+type recordMetricsResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+type variantResultResponse struct {
+	Variant          string     `json:"variant"`
+	Users            int64      `json:"users"`
+	Conversions      int64      `json:"conversions"`
+	ConversionRate   float64    `json:"conversion_rate"`
+	ConversionCI     [2]float64 `json:"conversion_ci"`
+	Mean             float64    `json:"mean"`
+	MeanCI           [2]float64 `json:"mean_ci"`
+	ConversionPValue *float64   `json:"conversion_p_value,omitempty"`
+	MeanPValue       *float64   `json:"mean_p_value,omitempty"`
+	Significant      bool       `json:"significant"`
+}
+
+type experimentResultsResponse struct {
+	Experiment      string                  `json:"experiment"`
+	Metric          string                  `json:"metric"`
+	Control         string                  `json:"control"`
+	ConfidenceLevel float64                 `json:"confidence_level"`
+	Variants        []variantResultResponse `json:"variants"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// decodeValue converts a raw JSON value into a port.FlagValue. Only JSON
+// booleans and numbers are accepted; anything else, including null or a
+// missing value, is rejected with domain.ErrInvalidValue.
+func decodeValue(raw json.RawMessage) (port.FlagValue, error) {
+	var v any
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return port.FlagValue{}, fmt.Errorf("value is required: %w", domain.ErrInvalidValue)
+	}
+	switch typed := v.(type) {
+	case bool:
+		return port.FlagValue{Bool: &typed}, nil
+	case float64:
+		return port.FlagValue{Numeric: &typed}, nil
+	}
+	return port.FlagValue{}, fmt.Errorf("value must be a boolean or a number: %w", domain.ErrInvalidValue)
+}
+
+func decodeRules(rules []ruleRequest) ([]port.Rule, error) {
+	out := make([]port.Rule, len(rules))
+	for i, r := range rules {
+		value, err := decodeValue(r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.ID, err)
+		}
+		var clauses []port.Clause
+		if len(r.Clauses) > 0 {
+			clauses = make([]port.Clause, len(r.Clauses))
+		}
+		for j, c := range r.Clauses {
+			clauses[j] = port.Clause{Attribute: c.Attribute, Operator: c.Operator, Values: c.Values, Timezone: c.Timezone}
+		}
+		out[i] = port.Rule{ID: r.ID, Clauses: clauses, Expression: r.Expression, Value: value}
+	}
+	return out, nil
+}
+
+func encodeRules(rules []port.Rule) []ruleResponse {
+	out := make([]ruleResponse, len(rules))
+	for i, r := range rules {
+		clauses := make([]clauseJSON, len(r.Clauses))
+		for j, c := range r.Clauses {
+			clauses[j] = clauseJSON{Attribute: c.Attribute, Operator: c.Operator, Values: c.Values, Timezone: c.Timezone}
+		}
+		out[i] = ruleResponse{ID: r.ID, Clauses: clauses, Expression: r.Expression, Value: encodeValue(r.Value)}
+	}
+	return out
+}
+
+func decodeVariants(variants []variantJSON) ([]port.Variant, error) {
+	out := make([]port.Variant, len(variants))
+	for i, v := range variants {
+		value, err := decodeValue(v.Value)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", v.Key, err)
+		}
+		out[i] = port.Variant{Key: v.Key, Value: value}
+	}
+	return out, nil
+}
+
+func encodeVariants(variants []port.Variant) []variantResponse {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]variantResponse, len(variants))
+	for i, v := range variants {
+		out[i] = variantResponse{Key: v.Key, Value: encodeValue(v.Value)}
+	}
+	return out
+}
+
+func encodeTargets(targets map[string]port.FlagValue) map[string]any {
+	if len(targets) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(targets))
+	for key, v := range targets {
+		out[key] = encodeValue(v)
+	}
+	return out
+}
+
+func encodeValue(v port.FlagValue) any {
+	switch {
+	case v.Bool != nil:
+		return *v.Bool
+	case v.Numeric != nil:
+		return *v.Numeric
+	}
+	return nil
+}
+
+func toFlagResponse(resp *port.FlagResponse) flagResponse {
+	return flagResponse{
+		Project:           resp.Project,
+		Name:              resp.Name,
+		Type:              resp.Type,
+		Description:       resp.Description,
+		Tags:              resp.Tags,
+		Environment:       resp.Environment,
+		SourceEnvironment: resp.SourceEnvironment,
+		Enabled:           resp.Enabled,
+		Value:             encodeValue(resp.Value),
+		Rules:             encodeRules(resp.Rules),
+		Targets:           encodeTargets(resp.Targets),
+		Variants:          encodeVariants(resp.Variants),
+		Experiment:        resp.Experiment,
+		Version:           resp.Version,
+		CreatedAt:         resp.CreatedAt,
+		UpdatedAt:         resp.UpdatedAt,
+	}
+}
+
+func toFlagValueResponse(resp port.FlagValueResponse) flagValueResponse {
+	return flagValueResponse{
+		Value:      encodeValue(resp.Value),
+		Reason:     resp.Reason,
+		RuleID:     resp.RuleID,
+		Bucket:     resp.Bucket,
+		Experiment: resp.Experiment,
+		Variant:    resp.Variant,
+		Version:    resp.Version,
+		Source:     resp.Source,
+	}
+}
+
+func encodeWeights(weights []port.VariantWeight) []variantWeightJSON {
+	out := make([]variantWeightJSON, len(weights))
+	for i, w := range weights {
+		out[i] = variantWeightJSON{Variant: w.Variant, Weight: w.Weight}
+	}
+	return out
+}
+
+func toExperimentResponse(resp port.ExperimentResponse) experimentResponse {
+	out := experimentResponse{
+		Key:         resp.Key,
+		Project:     resp.Project,
+		Flag:        resp.FlagName,
+		Environment: resp.Environment,
+		Allocation:  resp.Allocation,
+		Variants:    encodeWeights(resp.Variants),
+		Layer:       resp.Layer,
+		Holdout:     resp.Holdout,
+		Status:      resp.Status,
+		StartedAt:   resp.StartedAt,
+		StoppedAt:   resp.StoppedAt,
+		CreatedAt:   resp.CreatedAt,
+		UpdatedAt:   resp.UpdatedAt,
+	}
+	if resp.Layer != "" {
+		out.LayerOffset = &resp.LayerOffset
+	}
+	if b := resp.Bandit; b != nil {
+		out.Bandit = &banditJSON{Algorithm: b.Algorithm, Metric: b.Metric, Epsilon: b.Epsilon, MinExploration: b.MinExploration}
+	}
+	return out
+}
+
+func toWeightHistoryResponse(key string, history []port.WeightChange) weightHistoryResponse {
+	out := weightHistoryResponse{Experiment: key, History: make([]weightChangeResponse, len(history))}
+	for i, change := range history {
+		out.History[i] = weightChangeResponse{Variants: encodeWeights(change.Variants), ChangedAt: change.ChangedAt}
+	}
+	return out
+}
+
+func toEnvironmentResponse(resp port.EnvironmentResponse) environmentResponse {
+	return environmentResponse{Key: resp.Key, Description: resp.Description, Parent: resp.Parent, CreatedAt: resp.CreatedAt}
+}
+
+func toProjectResponse(resp port.ProjectResponse) projectResponse {
+	return projectResponse{Key: resp.Key, Description: resp.Description, CreatedAt: resp.CreatedAt}
+}
+
+func toAuditEntryResponse(resp port.AuditEntryResponse) auditEntryResponse {
+	out := auditEntryResponse{
+		ID:          resp.ID,
+		Actor:       resp.Actor,
+		Action:      resp.Action,
+		Project:     resp.Project,
+		Environment: resp.Environment,
+		Flag:        resp.Flag,
+		RequestID:   resp.RequestID,
+		Emergency:   resp.Emergency,
+		RecordedAt:  resp.RecordedAt,
+		Hash:        resp.Hash,
+	}
+	if resp.Before != nil {
+		before := toFlagResponse(resp.Before)
+		out.Before = &before
+	}
+	if resp.After != nil {
+		after := toFlagResponse(resp.After)
+		out.After = &after
+	}
+	return out
+}
+
+func toFlagProtectionResponse(resp *port.FlagProtectionResponse) flagProtectionResponse {
+	out := flagProtectionResponse{
+		Project:           resp.Project,
+		Flag:              resp.Flag,
+		RequiredApprovals: resp.RequiredApprovals,
+		Environments:      resp.Environments,
+	}
+	if out.Environments == nil {
+		out.Environments = []string{}
+	}
+	return out
+}
+
+func toChangeRequestResponse(resp *port.ChangeRequestResponse) changeRequestResponse {
+	out := changeRequestResponse{
+		ID:                resp.ID,
+		Project:           resp.Project,
+		Flag:              resp.Flag,
+		Environment:       resp.Environment,
+		Value:             encodeValue(resp.Value),
+		Status:            resp.Status,
+		RequestedBy:       resp.RequestedBy,
+		RequiredApprovals: resp.RequiredApprovals,
+		Approvals:         make([]approvalResponse, len(resp.Approvals)),
+		DecidedBy:         resp.DecidedBy,
+		CreatedAt:         resp.CreatedAt,
+		ExpiresAt:         resp.ExpiresAt,
+	}
+	for i, approval := range resp.Approvals {
+		out.Approvals[i] = approvalResponse{Approver: approval.Approver, ApprovedAt: approval.ApprovedAt}
+	}
+	if !resp.DecidedAt.IsZero() {
+		out.DecidedAt = &resp.DecidedAt
+	}
+	return out
+}
+
+// toFreezeWindowResponse writes empty scopes as empty arrays: they match
+// everything rather than being unset.
+func toFreezeWindowResponse(resp *port.FreezeWindowResponse) freezeWindowResponse {
+	out := freezeWindowResponse{
+		ID:           resp.ID,
+		Reason:       resp.Reason,
+		StartsAt:     resp.StartsAt,
+		EndsAt:       resp.EndsAt,
+		Projects:     resp.Projects,
+		Environments: resp.Environments,
+		Tags:         resp.Tags,
+		CreatedBy:    resp.CreatedBy,
+		CreatedAt:    resp.CreatedAt,
+		Active:       resp.Active,
+	}
+	for _, scope := range []*[]string{&out.Projects, &out.Environments, &out.Tags} {
+		if *scope == nil {
+			*scope = []string{}
+		}
+	}
+	return out
+}
+
+func toWebhookResponse(resp *port.WebhookResponse) webhookResponse {
+	return webhookResponse{
+		ID:        resp.ID,
+		URL:       resp.URL,
+		Events:    resp.Events,
+		Secret:    resp.Secret,
+		CreatedBy: resp.CreatedBy,
+		CreatedAt: resp.CreatedAt,
+	}
+}
+
+// toDeliveryResponse only reports when the next attempt is due for a pending
+// delivery.
+func toDeliveryResponse(resp *port.DeliveryResponse) deliveryResponse {
+	out := deliveryResponse{
+		ID:             resp.ID,
+		WebhookID:      resp.WebhookID,
+		Event:          resp.Event,
+		Project:        resp.Project,
+		Environment:    resp.Environment,
+		Flag:           resp.Flag,
+		Version:        resp.Version,
+		Actor:          resp.Actor,
+		Status:         resp.Status,
+		Attempts:       resp.Attempts,
+		ResponseStatus: resp.ResponseStatus,
+		LastError:      resp.LastError,
+		CreatedAt:      resp.CreatedAt,
+	}
+	if resp.Status == string(domain.DeliveryPending) {
+		out.NextAttemptAt = &resp.NextAttemptAt
+	}
+	if !resp.DeliveredAt.IsZero() {
+		out.DeliveredAt = &resp.DeliveredAt
+	}
+	return out
+}
+
+func toAPIKeyResponse(resp *port.APIKeyResponse) apiKeyResponse {
+	out := apiKeyResponse{
+		ID:        resp.ID,
+		Principal: resp.Principal,
+		Scopes:    resp.Scopes,
+		Hint:      resp.Hint,
+		Token:     resp.Token,
+		CreatedBy: resp.CreatedBy,
+		CreatedAt: resp.CreatedAt,
+	}
+	if !resp.ExpiresAt.IsZero() {
+		out.ExpiresAt = &resp.ExpiresAt
+	}
+	if !resp.RevokedAt.IsZero() {
+		out.RevokedAt = &resp.RevokedAt
+	}
+	return out
+}
+
+func toAuditVerificationResponse(resp *port.AuditVerificationResponse) auditVerificationResponse {
+	out := auditVerificationResponse{
+		Valid:              resp.Broken == nil,
+		Entries:            resp.Entries,
+		Checkpoints:        resp.Checkpoints,
+		SignaturesVerified: resp.SignaturesVerified,
+	}
+	if resp.Broken != nil {
+		out.Broken = &auditBreakResponse{EntryID: resp.Broken.EntryID, CheckpointID: resp.Broken.CheckpointID, Reason: resp.Broken.Reason}
+	}
+	return out
+}
+
+func toAuditCheckpointResponse(resp port.AuditCheckpointResponse) auditCheckpointResponse {
+	return auditCheckpointResponse{ID: resp.ID, EntryID: resp.EntryID, Hash: resp.Hash, Signature: resp.Signature, CreatedAt: resp.CreatedAt}
+}
+
+func toFlagHistoryResponse(name string, versions []port.FlagVersionResponse) flagHistoryResponse {
+	out := flagHistoryResponse{Flag: name, Versions: make([]flagVersionResponse, len(versions))}
+	for i, v := range versions {
+		out.Versions[i] = flagVersionResponse{
+			Version:     v.Version,
+			Environment: v.Environment,
+			Enabled:     v.Enabled,
+			Value:       encodeValue(v.Value),
+			Rules:       encodeRules(v.Rules),
+			Targets:     encodeTargets(v.Targets),
+			RecordedAt:  v.RecordedAt,
+		}
+	}
+	return out
+}
+
+func toSnapshotResponse(resp *port.SnapshotResponse) snapshotResponse {
+	out := snapshotResponse{Project: resp.Project, Environment: resp.Environment, AsOf: resp.AsOf, Flags: make([]flagResponse, len(resp.Flags))}
+	for i := range resp.Flags {
+		out.Flags[i] = toFlagResponse(&resp.Flags[i])
+	}
+	return out
+}
+
+func toPromotionResponse(resp port.PromotionResponse) promotionResponse {
+	out := promotionResponse{
+		ID:      resp.ID,
+		Project: resp.Project,
+		Source:  resp.Source,
+		Target:  resp.Target,
+		DryRun:  resp.DryRun,
+		Flags:   make([]flagDiffResponse, len(resp.Flags)),
+	}
+	if !resp.PromotedAt.IsZero() {
+		out.PromotedAt = &resp.PromotedAt
+	}
+	for i, d := range resp.Flags {
+		diff := flagDiffResponse{Name: d.Name}
+		if d.Value != nil {
+			diff.Value = &valueChangeResponse{From: encodeValue(d.Value.From), To: encodeValue(d.Value.To)}
+		}
+		if d.Enabled != nil {
+			diff.Enabled = &enabledChangeResponse{From: d.Enabled.From, To: d.Enabled.To}
+		}
+		for _, r := range d.Rules {
+			diff.Rules = append(diff.Rules, ruleChangeResponse{ID: r.ID, Change: r.Change})
+		}
+		for _, t := range d.Targets {
+			diff.Targets = append(diff.Targets, targetChangeResponse{Key: t.Key, Change: t.Change})
+		}
+		out.Flags[i] = diff
+	}
+	return out
+}
+
+func toLayerResponse(resp port.LayerResponse) layerResponse {
+	slots := make([]layerSlotResponse, len(resp.Slots))
+	for i, s := range resp.Slots {
+		slots[i] = layerSlotResponse{Experiment: s.Experiment, Offset: s.Offset, Allocation: s.Allocation, Status: s.Status}
+	}
+	return layerResponse{
+		Key:         resp.Key,
+		Description: resp.Description,
+		Slots:       slots,
+		Free:        resp.Free,
+		CreatedAt:   resp.CreatedAt,
+	}
+}
+
+func toExperimentResultsResponse(resp port.ExperimentResultsResponse) experimentResultsResponse {
+	variants := make([]variantResultResponse, len(resp.Variants))
+	for i, r := range resp.Variants {
+		variants[i] = variantResultResponse{
+			Variant:          r.Variant,
+			Users:            r.Users,
+			Conversions:      r.Conversions,
+			ConversionRate:   r.ConversionRate,
+			ConversionCI:     [2]float64{r.ConversionCI.Low, r.ConversionCI.High},
+			Mean:             r.Mean,
+			MeanCI:           [2]float64{r.MeanCI.Low, r.MeanCI.High},
+			ConversionPValue: r.ConversionPValue,
+			MeanPValue:       r.MeanPValue,
+			Significant:      r.Significant,
+		}
+	}
+	return experimentResultsResponse{
+		Experiment:      resp.ExperimentKey,
+		Metric:          resp.Metric,
+		Control:         resp.Control,
+		ConfidenceLevel: resp.ConfidenceLevel,
+		Variants:        variants,
+	}
+}
+
+func fromRoleRequest(req roleRequest) port.RoleRequest {
+	out := port.RoleRequest{Name: req.Name, Description: req.Description, Permissions: make([]port.PermissionRequest, len(req.Permissions))}
+	for i, p := range req.Permissions {
+		out.Permissions[i] = port.PermissionRequest(p)
+	}
+	return out
+}
+
+func toRoleResponse(resp *port.RoleResponse) roleResponse {
+	out := roleResponse{
+		Name:        resp.Name,
+		Description: resp.Description,
+		Permissions: make([]permissionRequest, len(resp.Permissions)),
+		CreatedBy:   resp.CreatedBy,
+		CreatedAt:   resp.CreatedAt,
+		UpdatedAt:   resp.UpdatedAt,
+	}
+	for i, p := range resp.Permissions {
+		out.Permissions[i] = permissionRequest(p)
+	}
+	return out
+}
+
+func toRoleBindingResponse(resp *port.RoleBindingResponse) roleBindingResponse {
+	return roleBindingResponse{
+		ID:        resp.ID,
+		Principal: resp.Principal,
+		Role:      resp.Role,
+		CreatedBy: resp.CreatedBy,
+		CreatedAt: resp.CreatedAt,
+	}
+}
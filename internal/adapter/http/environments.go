@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// inEnvironment scopes the request to the environment named in the path.
+func inEnvironment(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(port.WithEnvironment(r.Context(), r.PathValue("env"))))
+	}
+}
+
+func (h *Handler) removeFlagOverride(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.RemoveFlagOverride(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) createEnvironment(w http.ResponseWriter, r *http.Request) {
+	var req createEnvironmentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.svc.CreateEnvironment(r.Context(), port.CreateEnvironmentRequest{
+		Key:         req.Key,
+		Description: req.Description,
+		Parent:      req.Parent,
+		CopyFrom:    req.CopyFrom,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toEnvironmentResponse(*resp))
+}
+
+func (h *Handler) listEnvironments(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.ListEnvironments(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listEnvironmentsResponse{Environments: make([]environmentResponse, len(resp))}
+	for i, env := range resp {
+		out.Environments[i] = toEnvironmentResponse(env)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
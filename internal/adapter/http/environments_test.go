@@ -0,0 +1,123 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_EnvironmentScopedRoutes(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		wantEnv string
+	}{
+		{"unscoped flag", http.MethodGet, "/flags/new-checkout", "", domain.DefaultEnvironment},
+		{"scoped flag", http.MethodGet, "/environments/staging/flags/new-checkout", "", "staging"},
+		{"scoped toggle", http.MethodPut, "/environments/staging/flags/new-checkout/enabled", `{"enabled":false}`, "staging"},
+		{"scoped evaluate", http.MethodPost, "/environments/staging/evaluate", `{"context":{"targeting_key":"user-1"}}`, "staging"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{
+				flagResp:     &port.FlagResponse{Name: "new-checkout", Type: "boolean", Environment: tt.wantEnv, Value: port.FlagValue{Bool: &on}},
+				evaluateResp: &port.EvaluateAllResponse{},
+			}
+			rec := serve(t, svc, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantEnv, svc.environment)
+		})
+	}
+}
+
+func TestHandler_SetFlagEnabled(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:        "new-checkout",
+		Type:        "boolean",
+		Environment: "staging",
+		Enabled:     false,
+		Value:       port.FlagValue{Bool: &off},
+		Version:     3,
+	}}
+
+	rec := serve(t, svc, http.MethodPut, "/environments/staging/flags/new-checkout/enabled", `{"enabled":false}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "staging", body["environment"])
+	assert.Equal(t, false, body["enabled"])
+	assert.Equal(t, "new-checkout", svc.requestedAs)
+	assert.False(t, svc.enabledReq.Enabled)
+}
+
+func TestHandler_CreateEnvironment(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{envResp: &port.EnvironmentResponse{Key: "staging", Description: "pre-release", CreatedAt: now}}
+
+	rec := serve(t, svc, http.MethodPost, "/environments", `{"key":"staging","description":"pre-release","copy_from":"production"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "staging", body["key"])
+	assert.Equal(t, "pre-release", body["description"])
+	assert.Equal(t, port.CreateEnvironmentRequest{Key: "staging", Description: "pre-release", CopyFrom: "production"}, svc.envReq)
+}
+
+func TestHandler_ListEnvironments(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeFlagService{envsResp: []port.EnvironmentResponse{{Key: "production"}, {Key: "staging", Parent: "production"}}}
+
+	rec := serve(t, svc, http.MethodGet, "/environments", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	envs, ok := decodeBody(t, rec)["environments"].([]any)
+	require.True(t, ok)
+	require.Len(t, envs, 2)
+	assert.NotContains(t, envs[0], "parent")
+	assert.Equal(t, "staging", envs[1].(map[string]any)["key"])
+	assert.Equal(t, "production", envs[1].(map[string]any)["parent"])
+}
+
+func TestHandler_RemoveFlagOverride(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:              "new-checkout",
+		Type:              "boolean",
+		Environment:       "staging",
+		SourceEnvironment: "production",
+		Enabled:           true,
+		Value:             port.FlagValue{Bool: &on},
+		Version:           2,
+	}}
+
+	rec := serve(t, svc, http.MethodDelete, "/environments/staging/flags/new-checkout/override", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "staging", body["environment"])
+	assert.Equal(t, "production", body["source_environment"])
+	assert.Equal(t, "new-checkout", svc.requestedAs)
+	assert.Equal(t, "staging", svc.environment)
+}
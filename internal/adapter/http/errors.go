@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// errInvalidRequest marks request bodies that are not valid JSON.
+var errInvalidRequest = errors.New("invalid request body")
+
+var errorMappings = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{domain.ErrNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrAlreadyExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrTypeMismatch, http.StatusBadRequest, "TYPE_MISMATCH"},
+	{domain.ErrInvalidName, http.StatusBadRequest, "INVALID_NAME"},
+	{domain.ErrInvalidValue, http.StatusBadRequest, "INVALID_VALUE"},
+	{domain.ErrInvalidRule, http.StatusBadRequest, "INVALID_RULE"},
+	{domain.ErrInvalidTarget, http.StatusBadRequest, "INVALID_TARGET"},
+	{domain.ErrInvalidVariant, http.StatusBadRequest, "INVALID_VARIANT"},
+	{domain.ErrExperimentNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrExperimentExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrInvalidExperiment, http.StatusBadRequest, "INVALID_EXPERIMENT"},
+	{domain.ErrExperimentState, http.StatusConflict, "INVALID_STATE"},
+	{domain.ErrInvalidMetric, http.StatusBadRequest, "INVALID_METRIC"},
+	{domain.ErrLayerNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrLayerExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrInvalidLayer, http.StatusBadRequest, "INVALID_LAYER"},
+	{domain.ErrLayerFull, http.StatusConflict, "LAYER_FULL"},
+	{domain.ErrEnvironmentNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrEnvironmentExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrInvalidEnvironment, http.StatusBadRequest, "INVALID_ENVIRONMENT"},
+	{domain.ErrInvalidPromotion, http.StatusBadRequest, "INVALID_PROMOTION"},
+	{domain.ErrProjectNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrProjectExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrInvalidProject, http.StatusBadRequest, "INVALID_PROJECT"},
+	{domain.ErrInvalidAuditQuery, http.StatusBadRequest, "INVALID_QUERY"},
+	{domain.ErrVersionNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrInvalidTimestamp, http.StatusBadRequest, "INVALID_TIMESTAMP"},
+	{domain.ErrInvalidProtection, http.StatusBadRequest, "INVALID_PROTECTION"},
+	{domain.ErrChangeRequestNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrChangeRequestState, http.StatusConflict, "INVALID_STATE"},
+	{domain.ErrInvalidChangeQuery, http.StatusBadRequest, "INVALID_QUERY"},
+	{domain.ErrApprovalNotAllowed, http.StatusForbidden, "APPROVAL_NOT_ALLOWED"},
+	{domain.ErrProtected, http.StatusConflict, "PROTECTED"},
+	{domain.ErrFrozen, http.StatusConflict, "FROZEN"},
+	{domain.ErrInvalidFreeze, http.StatusBadRequest, "INVALID_FREEZE"},
+	{domain.ErrInvalidTag, http.StatusBadRequest, "INVALID_TAG"},
+	{domain.ErrWebhookNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrInvalidWebhook, http.StatusBadRequest, "INVALID_WEBHOOK"},
+	{domain.ErrDeliveryNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrInvalidDeliveryQuery, http.StatusBadRequest, "INVALID_QUERY"},
+	{domain.ErrDeliveryState, http.StatusConflict, "INVALID_STATE"},
+	{domain.ErrFreezeNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrUnauthenticated, http.StatusUnauthorized, "UNAUTHENTICATED"},
+	{domain.ErrInsufficientScope, http.StatusForbidden, "INSUFFICIENT_SCOPE"},
+	{domain.ErrAPIKeyNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrInvalidAPIKey, http.StatusBadRequest, "INVALID_API_KEY"},
+	{domain.ErrAPIKeyRevoked, http.StatusConflict, "INVALID_STATE"},
+	{domain.ErrForbidden, http.StatusForbidden, "FORBIDDEN"},
+	{domain.ErrRoleNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrRoleExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{domain.ErrInvalidRole, http.StatusBadRequest, "INVALID_ROLE"},
+	{domain.ErrRoleBindingNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{domain.ErrRoleBindingExists, http.StatusConflict, "ALREADY_EXISTS"},
+	{errInvalidRequest, http.StatusBadRequest, "INVALID_REQUEST"},
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			writeJSON(w, m.status, errorResponse{Code: m.code, Message: err.Error()})
+			return
+		}
+	}
+	h.logger.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	writeJSON(w, http.StatusInternalServerError, errorResponse{Code: "INTERNAL", Message: "internal server error"})
+}
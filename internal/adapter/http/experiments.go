@@ -0,0 +1,158 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) createExperiment(w http.ResponseWriter, r *http.Request) {
+	var req createExperimentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	weights := make([]port.VariantWeight, len(req.Variants))
+	for i, v := range req.Variants {
+		weights[i] = port.VariantWeight{Variant: v.Variant, Weight: v.Weight}
+	}
+
+	var bandit *port.BanditConfig
+	if b := req.Bandit; b != nil {
+		bandit = &port.BanditConfig{Algorithm: b.Algorithm, Metric: b.Metric, Epsilon: b.Epsilon, MinExploration: b.MinExploration}
+	}
+
+	resp, err := h.experiments.CreateExperiment(r.Context(), port.CreateExperimentRequest{
+		Key:        req.Key,
+		FlagName:   req.Flag,
+		Allocation: req.Allocation,
+		Variants:   weights,
+		Layer:      req.Layer,
+		Bandit:     bandit,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toExperimentResponse(*resp))
+}
+
+func (h *Handler) listExperiments(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.ListExperiments(r.Context(), port.ExperimentFilter{
+		FlagName: r.URL.Query().Get("flag"),
+		Layer:    r.URL.Query().Get("layer"),
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listExperimentsResponse{Experiments: make([]experimentResponse, len(resp))}
+	for i, exp := range resp {
+		out.Experiments[i] = toExperimentResponse(exp)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getExperiment(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.GetExperiment(r.Context(), r.PathValue("key"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toExperimentResponse(*resp))
+}
+
+func (h *Handler) startExperiment(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.StartExperiment(r.Context(), r.PathValue("key"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toExperimentResponse(*resp))
+}
+
+func (h *Handler) stopExperiment(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.StopExperiment(r.Context(), r.PathValue("key"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toExperimentResponse(*resp))
+}
+
+func (h *Handler) getExperimentResults(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.GetExperimentResults(r.Context(), r.PathValue("key"), r.URL.Query().Get("metric"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toExperimentResultsResponse(*resp))
+}
+
+func (h *Handler) getWeightHistory(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	history, err := h.experiments.GetWeightHistory(r.Context(), key)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toWeightHistoryResponse(key, history))
+}
+
+func (h *Handler) recordMetrics(w http.ResponseWriter, r *http.Request) {
+	var req recordMetricsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	events := make([]port.MetricEvent, len(req.Events))
+	for i, e := range req.Events {
+		value := 1.0
+		if e.Value != nil {
+			value = *e.Value
+		}
+		events[i] = port.MetricEvent{Metric: e.Metric, TargetingKey: e.TargetingKey, Value: value, OccurredAt: e.Timestamp}
+	}
+
+	if err := h.experiments.RecordMetrics(r.Context(), port.RecordMetricsRequest{Events: events}); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, recordMetricsResponse{Accepted: len(events)})
+}
+
+func (h *Handler) createLayer(w http.ResponseWriter, r *http.Request) {
+	var req createLayerRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.experiments.CreateLayer(r.Context(), port.CreateLayerRequest{Key: req.Key, Description: req.Description})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toLayerResponse(*resp))
+}
+
+func (h *Handler) listLayers(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.ListLayers(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listLayersResponse{Layers: make([]layerResponse, len(resp))}
+	for i, layer := range resp {
+		out.Layers[i] = toLayerResponse(layer)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getLayer(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.experiments.GetLayer(r.Context(), r.PathValue("key"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toLayerResponse(*resp))
+}
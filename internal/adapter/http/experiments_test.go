@@ -0,0 +1,356 @@
+package http_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// fakeExperimentService is a hand-written fake implementing
+// port.ExperimentService. Each method returns the configured response and
+// error and records its input.
+type fakeExperimentService struct {
+	resp *port.ExperimentResponse
+	list []port.ExperimentResponse
+	err  error
+
+	results *port.ExperimentResultsResponse
+	history []port.WeightChange
+	layer   *port.LayerResponse
+	layers  []port.LayerResponse
+
+	createReq   port.CreateExperimentRequest
+	filter      port.ExperimentFilter
+	layerReq    port.CreateLayerRequest
+	metricsReq  port.RecordMetricsRequest
+	requestedAs string
+	metric      string
+	action      string
+}
+
+func (f *fakeExperimentService) RecordMetrics(_ context.Context, req port.RecordMetricsRequest) error {
+	f.metricsReq = req
+	return f.err
+}
+
+func (f *fakeExperimentService) GetExperimentResults(_ context.Context, key, metric string) (*port.ExperimentResultsResponse, error) {
+	f.requestedAs = key
+	f.metric = metric
+	return f.results, f.err
+}
+
+func (f *fakeExperimentService) CreateExperiment(_ context.Context, req port.CreateExperimentRequest) (*port.ExperimentResponse, error) {
+	f.createReq = req
+	return f.resp, f.err
+}
+
+func (f *fakeExperimentService) GetExperiment(_ context.Context, key string) (*port.ExperimentResponse, error) {
+	f.requestedAs = key
+	return f.resp, f.err
+}
+
+func (f *fakeExperimentService) ListExperiments(_ context.Context, filter port.ExperimentFilter) ([]port.ExperimentResponse, error) {
+	f.filter = filter
+	return f.list, f.err
+}
+
+func (f *fakeExperimentService) StartExperiment(_ context.Context, key string) (*port.ExperimentResponse, error) {
+	f.requestedAs = key
+	f.action = "start"
+	return f.resp, f.err
+}
+
+func (f *fakeExperimentService) StopExperiment(_ context.Context, key string) (*port.ExperimentResponse, error) {
+	f.requestedAs = key
+	f.action = "stop"
+	return f.resp, f.err
+}
+
+func (f *fakeExperimentService) GetWeightHistory(_ context.Context, key string) ([]port.WeightChange, error) {
+	f.requestedAs = key
+	return f.history, f.err
+}
+
+func (f *fakeExperimentService) CreateLayer(_ context.Context, req port.CreateLayerRequest) (*port.LayerResponse, error) {
+	f.layerReq = req
+	return f.layer, f.err
+}
+
+func (f *fakeExperimentService) GetLayer(_ context.Context, key string) (*port.LayerResponse, error) {
+	f.requestedAs = key
+	return f.layer, f.err
+}
+
+func (f *fakeExperimentService) ListLayers(_ context.Context) ([]port.LayerResponse, error) {
+	return f.layers, f.err
+}
+
+func serveExperiments(t *testing.T, svc port.ExperimentService, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := httpadapter.NewHandler(&fakeFlagService{}, slog.New(slog.DiscardHandler), httpadapter.WithExperiments(svc))
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func sampleExperiment(status string) *port.ExperimentResponse {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &port.ExperimentResponse{
+		Key:        "checkout-test",
+		FlagName:   "checkout",
+		Allocation: 40,
+		Variants:   []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+		Status:     status,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func TestHandler_CreateExperiment(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeExperimentService{resp: sampleExperiment("draft")}
+
+	rec := serveExperiments(t, svc, http.MethodPost, "/experiments",
+		`{"key":"checkout-test","flag":"checkout","allocation":40,"variants":[{"variant":"control","weight":1},{"variant":"treatment","weight":1}]}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, port.CreateExperimentRequest{
+		Key:        "checkout-test",
+		FlagName:   "checkout",
+		Allocation: 40,
+		Variants:   []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	}, svc.createReq)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "draft", body["status"])
+	assert.Equal(t, "checkout", body["flag"])
+}
+
+func TestHandler_ExperimentLifecycle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		target     string
+		wantAction string
+	}{
+		{name: "start", target: "/experiments/checkout-test/start", wantAction: "start"},
+		{name: "stop", target: "/experiments/checkout-test/stop", wantAction: "stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeExperimentService{resp: sampleExperiment("running")}
+
+			rec := serveExperiments(t, svc, http.MethodPost, tt.target, "")
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "checkout-test", svc.requestedAs)
+			assert.Equal(t, tt.wantAction, svc.action)
+		})
+	}
+}
+
+func TestHandler_ListExperiments(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeExperimentService{list: []port.ExperimentResponse{*sampleExperiment("running")}}
+
+	rec := serveExperiments(t, svc, http.MethodGet, "/experiments?flag=checkout&layer=storefront", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, port.ExperimentFilter{FlagName: "checkout", Layer: "storefront"}, svc.filter)
+	assert.Len(t, decodeBody(t, rec)["experiments"], 1)
+}
+
+func TestHandler_Layers(t *testing.T) {
+	t.Parallel()
+
+	layer := &port.LayerResponse{
+		Key:       "storefront",
+		Slots:     []port.LayerSlot{{Experiment: "checkout-test", Offset: 0, Allocation: 40, Status: "running"}},
+		Free:      60,
+		CreatedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("create", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &fakeExperimentService{layer: layer}
+		rec := serveExperiments(t, svc, http.MethodPost, "/layers", `{"key":"storefront","description":"storefront tests"}`)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, port.CreateLayerRequest{Key: "storefront", Description: "storefront tests"}, svc.layerReq)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		t.Parallel()
+
+		svc := &fakeExperimentService{layer: layer}
+		rec := serveExperiments(t, svc, http.MethodGet, "/layers/storefront", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "storefront", svc.requestedAs)
+		body := decodeBody(t, rec)
+		assert.InDelta(t, 60, body["free"], 0)
+		assert.Equal(t, []any{map[string]any{
+			"experiment": "checkout-test", "offset": float64(0), "allocation": float64(40), "status": "running",
+		}}, body["slots"])
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serveExperiments(t, &fakeExperimentService{layers: []port.LayerResponse{*layer}}, http.MethodGet, "/layers", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, decodeBody(t, rec)["layers"], 1)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serveExperiments(t, &fakeExperimentService{err: domain.ErrLayerFull}, http.MethodPost, "/experiments",
+			`{"key":"checkout-test","flag":"checkout","allocation":40,"layer":"storefront"}`)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, "LAYER_FULL", decodeBody(t, rec)["code"])
+	})
+}
+
+func TestHandler_ExperimentErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "not found", err: domain.ErrExperimentNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "wrong state", err: domain.ErrExperimentState, wantStatus: http.StatusConflict, wantCode: "INVALID_STATE"},
+		{name: "invalid", err: domain.ErrInvalidExperiment, wantStatus: http.StatusBadRequest, wantCode: "INVALID_EXPERIMENT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serveExperiments(t, &fakeExperimentService{err: tt.err}, http.MethodPost, "/experiments/checkout-test/start", "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, decodeBody(t, rec)["code"])
+		})
+	}
+}
+
+func TestHandler_ExperimentsNotRoutedWithoutService(t *testing.T) {
+	t.Parallel()
+
+	rec := serve(t, &fakeFlagService{}, http.MethodGet, "/experiments", "")
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_RecordMetrics(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeExperimentService{}
+
+	rec := serveExperiments(t, svc, http.MethodPost, "/events",
+		`{"events":[{"metric":"purchase","targeting_key":"user-1","value":19.99,"timestamp":"2026-05-01T10:00:00Z"},{"metric":"signup","targeting_key":"user-2"}]}`)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.InDelta(t, 2, decodeBody(t, rec)["accepted"], 0)
+	assert.Equal(t, []port.MetricEvent{
+		{Metric: "purchase", TargetingKey: "user-1", Value: 19.99, OccurredAt: time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Metric: "signup", TargetingKey: "user-2", Value: 1},
+	}, svc.metricsReq.Events)
+}
+
+func TestHandler_RecordMetrics_Invalid(t *testing.T) {
+	t.Parallel()
+
+	rec := serveExperiments(t, &fakeExperimentService{err: domain.ErrInvalidMetric}, http.MethodPost, "/events", `{"events":[]}`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "INVALID_METRIC", decodeBody(t, rec)["code"])
+}
+
+func TestHandler_GetExperimentResults(t *testing.T) {
+	t.Parallel()
+
+	p := 0.0007
+	svc := &fakeExperimentService{results: &port.ExperimentResultsResponse{
+		ExperimentKey:   "checkout-test",
+		Metric:          "purchase",
+		Control:         "control",
+		ConfidenceLevel: 0.95,
+		Variants: []port.VariantResult{
+			{Variant: "control", Users: 1000, Conversions: 100, ConversionRate: 0.1, ConversionCI: port.Interval{Low: 0.08, High: 0.12}},
+			{Variant: "treatment", Users: 1000, Conversions: 150, ConversionRate: 0.15, ConversionPValue: &p, Significant: true},
+		},
+	}}
+
+	rec := serveExperiments(t, svc, http.MethodGet, "/experiments/checkout-test/results?metric=purchase", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "checkout-test", svc.requestedAs)
+	assert.Equal(t, "purchase", svc.metric)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "control", body["control"])
+	variants := body["variants"].([]any)
+	require.Len(t, variants, 2)
+	control := variants[0].(map[string]any)
+	assert.Equal(t, []any{0.08, 0.12}, control["conversion_ci"])
+	assert.NotContains(t, control, "conversion_p_value")
+	treatment := variants[1].(map[string]any)
+	assert.Equal(t, true, treatment["significant"])
+	assert.InDelta(t, 0.0007, treatment["conversion_p_value"], 0)
+}
+
+func TestHandler_CreateBanditExperiment(t *testing.T) {
+	t.Parallel()
+
+	resp := sampleExperiment("draft")
+	resp.Bandit = &port.BanditConfig{Algorithm: "thompson", Metric: "click", MinExploration: 0.05}
+	svc := &fakeExperimentService{resp: resp}
+
+	rec := serveExperiments(t, svc, http.MethodPost, "/experiments",
+		`{"key":"checkout-test","flag":"checkout","allocation":40,"variants":[{"variant":"control","weight":1},{"variant":"treatment","weight":1}],`+
+			`"bandit":{"algorithm":"thompson","metric":"click","min_exploration":0.05}}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, resp.Bandit, svc.createReq.Bandit)
+	assert.Equal(t, map[string]any{"algorithm": "thompson", "metric": "click", "min_exploration": 0.05}, decodeBody(t, rec)["bandit"])
+}
+
+func TestHandler_GetWeightHistory(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeExperimentService{history: []port.WeightChange{
+		{Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}, ChangedAt: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{Variants: []port.VariantWeight{{Variant: "control", Weight: 300}, {Variant: "treatment", Weight: 700}}, ChangedAt: time.Date(2026, 5, 1, 13, 0, 0, 0, time.UTC)},
+	}}
+
+	rec := serveExperiments(t, svc, http.MethodGet, "/experiments/checkout-test/weights", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "checkout-test", svc.requestedAs)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "checkout-test", body["experiment"])
+	require.Len(t, body["history"], 2)
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) createFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	var req freezeWindowRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.svc.CreateFreezeWindow(r.Context(), port.FreezeWindowRequest{
+		Reason:       req.Reason,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		Projects:     req.Projects,
+		Environments: req.Environments,
+		Tags:         req.Tags,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toFreezeWindowResponse(resp))
+}
+
+func (h *Handler) listFreezeWindows(w http.ResponseWriter, r *http.Request) {
+	var filter port.FreezeWindowFilter
+	if raw := r.URL.Query().Get("active"); raw != "" {
+		active, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.writeError(w, r, fmt.Errorf("active %q is not a boolean: %w", raw, domain.ErrInvalidFreeze))
+			return
+		}
+		filter.Active = active
+	}
+
+	resp, err := h.svc.ListFreezeWindows(r.Context(), filter)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listFreezeWindowsResponse{FreezeWindows: make([]freezeWindowResponse, len(resp))}
+	for i, window := range resp {
+		out.FreezeWindows[i] = toFreezeWindowResponse(&window)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := freezeWindowID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.svc.GetFreezeWindow(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFreezeWindowResponse(resp))
+}
+
+func (h *Handler) deleteFreezeWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := freezeWindowID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.svc.DeleteFreezeWindow(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// freezeWindowID parses the window id in the path. An id that is not a number
+// names no window.
+func freezeWindowID(r *http.Request) (int64, error) {
+	raw := r.PathValue("id")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("freeze window %q: %w", raw, domain.ErrFreezeNotFound)
+	}
+	return id, nil
+}
@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func blackFriday() *port.FreezeWindowResponse {
+	return &port.FreezeWindowResponse{
+		ID: 3, Reason: "black friday",
+		StartsAt:  time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC),
+		EndsAt:    time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC),
+		Projects:  []string{"checkout"},
+		CreatedBy: "alice",
+		CreatedAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestHandler_CreateFreezeWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "creates",
+			body:       `{"reason":"black friday","starts_at":"2026-11-27T00:00:00Z","ends_at":"2026-11-30T00:00:00Z","projects":["checkout"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{name: "malformed time", body: `{"starts_at":"friday"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid window", body: `{"reason":"backwards"}`, svcErr: domain.ErrInvalidFreeze, wantStatus: http.StatusBadRequest, wantCode: "INVALID_FREEZE"},
+		{name: "invalid tags", body: `{"tags":["A"]}`, svcErr: fmt.Errorf("%w: %w", domain.ErrInvalidFreeze, domain.ErrInvalidTag), wantStatus: http.StatusBadRequest, wantCode: "INVALID_FREEZE"},
+		{name: "unknown project", body: `{"projects":["ghost"]}`, svcErr: domain.ErrProjectNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{freezeResp: blackFriday(), err: tt.svcErr}
+
+			rec := serve(t, svc, http.MethodPost, "/freezes", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, port.FreezeWindowRequest{
+				Reason:   "black friday",
+				StartsAt: time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC),
+				EndsAt:   time.Date(2026, 11, 30, 0, 0, 0, 0, time.UTC),
+				Projects: []string{"checkout"},
+			}, svc.freezeReq)
+			assert.Equal(t, float64(3), body["id"])
+			assert.Equal(t, "2026-11-27T00:00:00Z", body["starts_at"])
+			assert.Equal(t, []any{"checkout"}, body["projects"])
+			assert.Equal(t, []any{}, body["environments"], "an empty scope matches everything")
+			assert.Equal(t, false, body["active"])
+		})
+	}
+}
+
+func TestHandler_ListFreezeWindows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		target     string
+		wantActive bool
+		wantStatus int
+	}{
+		{name: "all", target: "/freezes", wantStatus: http.StatusOK},
+		{name: "active", target: "/freezes?active=true", wantActive: true, wantStatus: http.StatusOK},
+		{name: "invalid filter", target: "/freezes?active=soon", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			active := blackFriday()
+			active.Active = true
+			svc := &fakeFlagService{freezesResp: []port.FreezeWindowResponse{*active}}
+
+			rec := serve(t, svc, http.MethodGet, tt.target, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantStatus != http.StatusOK {
+				assert.Equal(t, "INVALID_FREEZE", body["code"])
+				return
+			}
+			assert.Equal(t, tt.wantActive, svc.freezeQuery.Active)
+			windows := body["freeze_windows"].([]any)
+			assert.Len(t, windows, 1)
+			assert.Equal(t, true, windows[0].(map[string]any)["active"])
+		})
+	}
+}
+
+func TestHandler_FreezeWindowByID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		svcErr     error
+		wantStatus int
+		wantID     int64
+	}{
+		{name: "get", method: http.MethodGet, target: "/freezes/3", wantStatus: http.StatusOK, wantID: 3},
+		{name: "delete", method: http.MethodDelete, target: "/freezes/3", wantStatus: http.StatusNoContent, wantID: 3},
+		{name: "unknown", method: http.MethodGet, target: "/freezes/9", svcErr: domain.ErrFreezeNotFound, wantStatus: http.StatusNotFound, wantID: 9},
+		{name: "not a number", method: http.MethodDelete, target: "/freezes/soon", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{freezeResp: blackFriday(), err: tt.svcErr}
+
+			rec := serve(t, svc, tt.method, tt.target, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantID, svc.freezeID)
+			if tt.wantStatus == http.StatusNotFound {
+				assert.Equal(t, "NOT_FOUND", decodeBody(t, rec)["code"])
+			}
+		})
+	}
+}
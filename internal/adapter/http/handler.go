@@ -0,0 +1,371 @@
+// Package http is the inbound REST adapter. It translates HTTP requests into
+// calls on port.FlagService and maps results and domain errors back to JSON.
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/netip"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+type Handler struct {
+	svc            port.FlagService
+	experiments    port.ExperimentService
+	audit          port.AuditService
+	webhooks       port.WebhookService
+	apiKeys        port.APIKeyService
+	access         port.AccessService
+	logger         *slog.Logger
+	trustedProxies []netip.Prefix
+}
+
+// HandlerOption customises a Handler created by NewHandler.
+type HandlerOption func(*Handler)
+
+// WithTrustedProxies sets the proxy ranges whose X-Forwarded-For entries are
+// believed when resolving the client address.
+func WithTrustedProxies(prefixes []netip.Prefix) HandlerOption {
+	return func(h *Handler) { h.trustedProxies = prefixes }
+}
+
+// WithExperiments serves the experiment endpoints from svc. Without it they
+// are not routed.
+func WithExperiments(svc port.ExperimentService) HandlerOption {
+	return func(h *Handler) { h.experiments = svc }
+}
+
+// WithAudit serves the audit chain verification endpoints from svc. Without
+// it they are not routed.
+func WithAudit(svc port.AuditService) HandlerOption {
+	return func(h *Handler) { h.audit = svc }
+}
+
+// WithWebhooks serves the webhook subscription and delivery log endpoints
+// from svc. Without it they are not routed.
+func WithWebhooks(svc port.WebhookService) HandlerOption {
+	return func(h *Handler) { h.webhooks = svc }
+}
+
+// WithAPIKeys requires every request to carry an API key with the scope its
+// route needs, authenticated by svc, and serves the key management endpoints.
+// Without it requests are not authenticated.
+func WithAPIKeys(svc port.APIKeyService) HandlerOption {
+	return func(h *Handler) { h.apiKeys = svc }
+}
+
+// WithAccess serves the role and role binding endpoints from svc. Without it
+// they are not routed.
+func WithAccess(svc port.AccessService) HandlerOption {
+	return func(h *Handler) { h.access = svc }
+}
+
+func NewHandler(svc port.FlagService, logger *slog.Logger, opts ...HandlerOption) *Handler {
+	h := &Handler{svc: svc, logger: logger}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Routes returns the router serving the API catalogue. Flag, evaluation and
+// experiment listing routes are served both at the root, in the default
+// environment, and under /environments/{env}. Those routes, promotions, flag
+// protections and change requests are in turn served both in the default
+// project and under /projects/{project}. Every request is attributed to the
+// actor it names for auditing or, with API keys, to its key's principal, and
+// each route requires the scope it is registered with.
+func (h *Handler) Routes() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(method, path string, scope domain.Scope, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" "+path, h.authorized(scope, handler))
+	}
+	projected := func(method, path string, scope domain.Scope, handler http.HandlerFunc) {
+		handle(method, path, scope, handler)
+		handle(method, "/projects/{project}"+path, scope, inProject(handler))
+	}
+	scoped := func(method, path string, scope domain.Scope, handler http.HandlerFunc) {
+		projected(method, path, scope, handler)
+		projected(method, "/environments/{env}"+path, scope, inEnvironment(handler))
+	}
+	const (
+		evaluate = domain.ScopeFlagsEvaluate
+		read     = domain.ScopeFlagsRead
+		write    = domain.ScopeFlagsWrite
+		admin    = domain.ScopeAdmin
+	)
+	scoped("POST", "/flags", write, h.createFlag)
+	scoped("GET", "/flags/{name}", read, h.atInstant(h.getFlag))
+	scoped("GET", "/flags/{name}/value", evaluate, h.atInstant(h.getFlagValue))
+	scoped("PUT", "/flags/{name}/value", write, h.updateFlagValue)
+	scoped("PUT", "/flags/{name}/rules", write, h.updateFlagRules)
+	scoped("PUT", "/flags/{name}/enabled", write, h.setFlagEnabled)
+	scoped("DELETE", "/flags/{name}/override", write, h.removeFlagOverride)
+	scoped("GET", "/flags/{name}/history", read, h.getFlagHistory)
+	scoped("POST", "/flags/{name}/rollback", write, h.rollbackFlag)
+	scoped("GET", "/snapshot", evaluate, h.atInstant(h.getSnapshot))
+	scoped("POST", "/flags/{name}/targets", write, h.addTargets)
+	scoped("DELETE", "/flags/{name}/targets/{key}", write, h.removeTarget)
+	scoped("POST", "/evaluate", evaluate, h.evaluate)
+	handle("POST", "/environments", admin, h.createEnvironment)
+	handle("GET", "/environments", read, h.listEnvironments)
+	handle("POST", "/projects", admin, h.createProject)
+	handle("GET", "/projects", read, h.listProjects)
+	projected("POST", "/promotions", write, h.promoteFlags)
+	projected("GET", "/promotions", read, h.listPromotions)
+	projected("GET", "/flags/{name}/protection", read, h.getFlagProtection)
+	projected("PUT", "/flags/{name}/protection", admin, h.setFlagProtection)
+	projected("GET", "/change-requests", read, h.listChangeRequests)
+	projected("GET", "/change-requests/{id}", read, h.getChangeRequest)
+	projected("POST", "/change-requests/{id}/approve", write, h.approveChangeRequest)
+	projected("POST", "/change-requests/{id}/reject", write, h.rejectChangeRequest)
+	handle("POST", "/freezes", admin, h.createFreezeWindow)
+	handle("GET", "/freezes", read, h.listFreezeWindows)
+	handle("GET", "/freezes/{id}", read, h.getFreezeWindow)
+	handle("DELETE", "/freezes/{id}", admin, h.deleteFreezeWindow)
+	handle("GET", "/audit", read, h.listAudit)
+	if h.audit != nil {
+		handle("GET", "/audit/verify", read, h.verifyAudit)
+		handle("GET", "/audit/checkpoints", read, h.listAuditCheckpoints)
+	}
+	if h.webhooks != nil {
+		handle("POST", "/webhooks", admin, h.createWebhook)
+		handle("GET", "/webhooks", admin, h.listWebhooks)
+		handle("GET", "/webhooks/{id}", admin, h.getWebhook)
+		handle("DELETE", "/webhooks/{id}", admin, h.deleteWebhook)
+		handle("GET", "/webhooks/{id}/deliveries", admin, h.listWebhookDeliveries)
+		handle("POST", "/webhooks/{id}/deliveries/{delivery}/retry", admin, h.retryWebhookDelivery)
+	}
+	if h.apiKeys != nil {
+		handle("POST", "/api-keys", admin, h.createAPIKey)
+		handle("GET", "/api-keys", admin, h.listAPIKeys)
+		handle("GET", "/api-keys/{id}", admin, h.getAPIKey)
+		handle("POST", "/api-keys/{id}/revoke", admin, h.revokeAPIKey)
+		handle("POST", "/api-keys/{id}/rotate", admin, h.rotateAPIKey)
+	}
+	if h.access != nil {
+		handle("POST", "/roles", admin, h.createRole)
+		handle("GET", "/roles", admin, h.listRoles)
+		handle("GET", "/roles/{name}", admin, h.getRole)
+		handle("PUT", "/roles/{name}", admin, h.updateRole)
+		handle("DELETE", "/roles/{name}", admin, h.deleteRole)
+		handle("POST", "/role-bindings", admin, h.createRoleBinding)
+		handle("GET", "/role-bindings", admin, h.listRoleBindings)
+		handle("DELETE", "/role-bindings/{id}", admin, h.deleteRoleBinding)
+	}
+	if h.experiments != nil {
+		scoped("POST", "/experiments", write, h.createExperiment)
+		scoped("GET", "/experiments", read, h.listExperiments)
+		handle("GET", "/experiments/{key}", read, h.getExperiment)
+		handle("POST", "/experiments/{key}/start", write, h.startExperiment)
+		handle("POST", "/experiments/{key}/stop", write, h.stopExperiment)
+		handle("GET", "/experiments/{key}/results", read, h.getExperimentResults)
+		handle("GET", "/experiments/{key}/weights", read, h.getWeightHistory)
+		handle("POST", "/events", evaluate, h.recordMetrics)
+		handle("POST", "/layers", write, h.createLayer)
+		handle("GET", "/layers", read, h.listLayers)
+		handle("GET", "/layers/{key}", read, h.getLayer)
+	}
+	return attributed(h.authenticated(mux))
+}
+
+func (h *Handler) createFlag(w http.ResponseWriter, r *http.Request) {
+	var req createFlagRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	value, err := decodeValue(req.Value)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	rules, err := decodeRules(req.Rules)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	variants, err := decodeVariants(req.Variants)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.svc.CreateFlag(r.Context(), port.CreateFlagRequest{
+		Name:        req.Name,
+		Type:        req.Type,
+		Description: req.Description,
+		Tags:        req.Tags,
+		Value:       value,
+		Rules:       rules,
+		Variants:    variants,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toFlagResponse(resp))
+}
+
+func (h *Handler) getFlag(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetFlag(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) getFlagValue(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetFlagValue(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagValueResponse(*resp))
+}
+
+func (h *Handler) updateFlagValue(w http.ResponseWriter, r *http.Request) {
+	var req updateFlagValueRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	value, err := decodeValue(req.Value)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.svc.UpdateFlagValue(r.Context(), r.PathValue("name"), port.UpdateFlagValueRequest{Value: value})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if resp.PendingChange != nil {
+		writeJSON(w, http.StatusAccepted, toChangeRequestResponse(resp.PendingChange))
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) updateFlagRules(w http.ResponseWriter, r *http.Request) {
+	var req updateFlagRulesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	rules, err := decodeRules(req.Rules)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.svc.UpdateFlagRules(r.Context(), r.PathValue("name"), port.UpdateFlagRulesRequest{Rules: rules})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) setFlagEnabled(w http.ResponseWriter, r *http.Request) {
+	var req setFlagEnabledRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if req.Enabled == nil {
+		h.writeError(w, r, fmt.Errorf("%w: enabled is required", errInvalidRequest))
+		return
+	}
+
+	resp, err := h.svc.SetFlagEnabled(r.Context(), r.PathValue("name"), port.SetFlagEnabledRequest{Enabled: *req.Enabled})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) addTargets(w http.ResponseWriter, r *http.Request) {
+	var req addTargetsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	value, err := decodeValue(req.Value)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.svc.AddTargets(r.Context(), r.PathValue("name"), port.AddTargetsRequest{Keys: req.Keys, Value: value})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) removeTarget(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.RemoveTargets(r.Context(), r.PathValue("name"),
+		port.RemoveTargetsRequest{Keys: []string{r.PathValue("key")}})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
+
+func (h *Handler) evaluate(w http.ResponseWriter, r *http.Request) {
+	var req evaluateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	// The client address is derived from the connection and always replaces a
+	// caller-supplied value, so network-based targeting cannot be spoofed.
+	attributes := maps.Clone(req.Context.Attributes)
+	if attributes == nil {
+		attributes = make(map[string]any)
+	}
+	delete(attributes, domain.AttributeClientIP)
+	if ip, ok := h.clientIP(r); ok {
+		attributes[domain.AttributeClientIP] = ip.String()
+	}
+
+	resp, err := h.svc.EvaluateAll(r.Context(),
+		port.EvaluationContext{TargetingKey: req.Context.TargetingKey, Attributes: attributes},
+		port.EvaluationFilter{Names: req.Flags, Prefix: req.Prefix},
+	)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	out := evaluateResponse{Flags: make(map[string]flagValueResponse, len(resp.Flags))}
+	for name, v := range resp.Flags {
+		out.Flags[name] = toFlagValueResponse(v)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func decodeJSON(r *http.Request, dst any) error {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRequest, err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
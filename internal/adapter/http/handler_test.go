@@ -0,0 +1,586 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// fakeFlagService is a hand-written fake implementing port.FlagService. Each
+// method returns the configured response and error and records its input.
+type fakeFlagService struct {
+	flagResp     *port.FlagResponse
+	valueResp    *port.FlagValueResponse
+	evaluateResp *port.EvaluateAllResponse
+	envResp      *port.EnvironmentResponse
+	envsResp     []port.EnvironmentResponse
+	promoteResp  *port.PromotionResponse
+	promotions   []port.PromotionResponse
+	projectResp  *port.ProjectResponse
+	projectsResp []port.ProjectResponse
+	auditResp    []port.AuditEntryResponse
+	historyResp  []port.FlagVersionResponse
+	snapshotResp *port.SnapshotResponse
+	protectResp  *port.FlagProtectionResponse
+	changeResp   *port.ChangeRequestResponse
+	changesResp  []port.ChangeRequestResponse
+	freezeResp   *port.FreezeWindowResponse
+	freezesResp  []port.FreezeWindowResponse
+	err          error
+
+	createReq   port.CreateFlagRequest
+	updateReq   port.UpdateFlagValueRequest
+	rulesReq    port.UpdateFlagRulesRequest
+	addReq      port.AddTargetsRequest
+	removeReq   port.RemoveTargetsRequest
+	evalCtx     port.EvaluationContext
+	evalFilter  port.EvaluationFilter
+	enabledReq  port.SetFlagEnabledRequest
+	envReq      port.CreateEnvironmentRequest
+	promoteReq  port.PromoteRequest
+	projectReq  port.CreateProjectRequest
+	auditFilter port.AuditFilter
+	toVersion   int64
+	protectReq  port.FlagProtectionRequest
+	changeQuery port.ChangeRequestFilter
+	changeID    int64
+	freezeReq   port.FreezeWindowRequest
+	freezeQuery port.FreezeWindowFilter
+	freezeID    int64
+	asOf        time.Time
+	actor       string
+	requestID   string
+	emergency   string
+	requestedAs string
+	environment string
+	project     string
+}
+
+func (f *fakeFlagService) CreateFlag(_ context.Context, req port.CreateFlagRequest) (*port.FlagResponse, error) {
+	f.createReq = req
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) GetFlag(ctx context.Context, name string) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.environment = port.EnvironmentFrom(ctx)
+	f.project = port.ProjectFrom(ctx)
+	f.asOf = port.AsOfFrom(ctx)
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) GetFlagValue(ctx context.Context, name string) (*port.FlagValueResponse, error) {
+	f.requestedAs = name
+	f.asOf = port.AsOfFrom(ctx)
+	return f.valueResp, f.err
+}
+
+func (f *fakeFlagService) UpdateFlagValue(_ context.Context, name string, req port.UpdateFlagValueRequest) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.updateReq = req
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) UpdateFlagRules(_ context.Context, name string, req port.UpdateFlagRulesRequest) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.rulesReq = req
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) AddTargets(_ context.Context, name string, req port.AddTargetsRequest) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.addReq = req
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) RemoveTargets(_ context.Context, name string, req port.RemoveTargetsRequest) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.removeReq = req
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) SetFlagEnabled(ctx context.Context, name string, req port.SetFlagEnabledRequest) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.enabledReq = req
+	f.environment = port.EnvironmentFrom(ctx)
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) RemoveFlagOverride(ctx context.Context, name string) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.environment = port.EnvironmentFrom(ctx)
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) EvaluateAll(ctx context.Context, evalCtx port.EvaluationContext, filter port.EvaluationFilter) (*port.EvaluateAllResponse, error) {
+	f.evalCtx = evalCtx
+	f.evalFilter = filter
+	f.environment = port.EnvironmentFrom(ctx)
+	return f.evaluateResp, f.err
+}
+
+func (f *fakeFlagService) CreateEnvironment(_ context.Context, req port.CreateEnvironmentRequest) (*port.EnvironmentResponse, error) {
+	f.envReq = req
+	return f.envResp, f.err
+}
+
+func (f *fakeFlagService) ListEnvironments(_ context.Context) ([]port.EnvironmentResponse, error) {
+	return f.envsResp, f.err
+}
+
+func (f *fakeFlagService) PromoteFlags(ctx context.Context, req port.PromoteRequest) (*port.PromotionResponse, error) {
+	f.promoteReq = req
+	f.project = port.ProjectFrom(ctx)
+	return f.promoteResp, f.err
+}
+
+func (f *fakeFlagService) ListPromotions(ctx context.Context) ([]port.PromotionResponse, error) {
+	f.project = port.ProjectFrom(ctx)
+	return f.promotions, f.err
+}
+
+func (f *fakeFlagService) CreateProject(_ context.Context, req port.CreateProjectRequest) (*port.ProjectResponse, error) {
+	f.projectReq = req
+	return f.projectResp, f.err
+}
+
+func (f *fakeFlagService) ListProjects(_ context.Context) ([]port.ProjectResponse, error) {
+	return f.projectsResp, f.err
+}
+
+func (f *fakeFlagService) ListAudit(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntryResponse, error) {
+	f.auditFilter = filter
+	f.actor = port.ActorFrom(ctx)
+	f.requestID = port.RequestIDFrom(ctx)
+	f.emergency = port.EmergencyFrom(ctx)
+	return f.auditResp, f.err
+}
+
+func (f *fakeFlagService) GetFlagHistory(ctx context.Context, name string) ([]port.FlagVersionResponse, error) {
+	f.requestedAs = name
+	f.environment = port.EnvironmentFrom(ctx)
+	return f.historyResp, f.err
+}
+
+func (f *fakeFlagService) RollbackFlag(ctx context.Context, name string, toVersion int64) (*port.FlagResponse, error) {
+	f.requestedAs = name
+	f.toVersion = toVersion
+	f.environment = port.EnvironmentFrom(ctx)
+	return f.flagResp, f.err
+}
+
+func (f *fakeFlagService) GetSnapshot(ctx context.Context) (*port.SnapshotResponse, error) {
+	f.environment = port.EnvironmentFrom(ctx)
+	f.asOf = port.AsOfFrom(ctx)
+	return f.snapshotResp, f.err
+}
+
+func (f *fakeFlagService) GetFlagProtection(ctx context.Context, name string) (*port.FlagProtectionResponse, error) {
+	f.requestedAs = name
+	f.project = port.ProjectFrom(ctx)
+	return f.protectResp, f.err
+}
+
+func (f *fakeFlagService) SetFlagProtection(ctx context.Context, name string, req port.FlagProtectionRequest) (*port.FlagProtectionResponse, error) {
+	f.requestedAs = name
+	f.protectReq = req
+	f.project = port.ProjectFrom(ctx)
+	return f.protectResp, f.err
+}
+
+func (f *fakeFlagService) ListChangeRequests(ctx context.Context, filter port.ChangeRequestFilter) ([]port.ChangeRequestResponse, error) {
+	f.changeQuery = filter
+	f.project = port.ProjectFrom(ctx)
+	return f.changesResp, f.err
+}
+
+func (f *fakeFlagService) GetChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	f.changeID = id
+	f.project = port.ProjectFrom(ctx)
+	return f.changeResp, f.err
+}
+
+func (f *fakeFlagService) ApproveChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	f.changeID = id
+	f.actor = port.ActorFrom(ctx)
+	return f.changeResp, f.err
+}
+
+func (f *fakeFlagService) RejectChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	f.changeID = id
+	f.actor = port.ActorFrom(ctx)
+	return f.changeResp, f.err
+}
+
+func (f *fakeFlagService) CreateFreezeWindow(ctx context.Context, req port.FreezeWindowRequest) (*port.FreezeWindowResponse, error) {
+	f.freezeReq = req
+	f.actor = port.ActorFrom(ctx)
+	return f.freezeResp, f.err
+}
+
+func (f *fakeFlagService) ListFreezeWindows(_ context.Context, filter port.FreezeWindowFilter) ([]port.FreezeWindowResponse, error) {
+	f.freezeQuery = filter
+	return f.freezesResp, f.err
+}
+
+func (f *fakeFlagService) GetFreezeWindow(_ context.Context, id int64) (*port.FreezeWindowResponse, error) {
+	f.freezeID = id
+	return f.freezeResp, f.err
+}
+
+func (f *fakeFlagService) DeleteFreezeWindow(_ context.Context, id int64) error {
+	f.freezeID = id
+	return f.err
+}
+
+func serve(t *testing.T, svc port.FlagService, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := httpadapter.NewHandler(svc, slog.New(slog.DiscardHandler))
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestHandler_CreateFlag(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:      "my-flag",
+		Type:      "boolean",
+		Tags:      []string{"checkout"},
+		Value:     port.FlagValue{Bool: &boolVal},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+
+	rec := serve(t, svc, http.MethodPost, "/flags", `{"name":"my-flag","type":"boolean","value":true,"tags":["checkout"]}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	body := decodeBody(t, rec)
+	assert.Equal(t, "my-flag", body["name"])
+	assert.Equal(t, true, body["value"])
+	assert.InDelta(t, 1, body["version"], 0)
+	assert.Equal(t, []any{"checkout"}, body["tags"])
+	assert.Equal(t, "my-flag", svc.createReq.Name)
+	assert.Equal(t, []string{"checkout"}, svc.createReq.Tags)
+	require.NotNil(t, svc.createReq.Value.Bool)
+	assert.True(t, *svc.createReq.Value.Bool)
+}
+
+func TestHandler_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"not found", http.MethodGet, "/flags/ghost", "", domain.ErrNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"already exists", http.MethodPost, "/flags", `{"name":"a","type":"boolean","value":true}`, domain.ErrAlreadyExists, http.StatusConflict, "ALREADY_EXISTS"},
+		{"type mismatch", http.MethodPut, "/flags/a/value", `{"value":1}`, domain.ErrTypeMismatch, http.StatusBadRequest, "TYPE_MISMATCH"},
+		{"invalid name", http.MethodPost, "/flags", `{"name":"A","type":"boolean","value":true}`, domain.ErrInvalidName, http.StatusBadRequest, "INVALID_NAME"},
+		{"null value", http.MethodPut, "/flags/a/value", `{"value":null}`, nil, http.StatusBadRequest, "INVALID_VALUE"},
+		{"missing value", http.MethodPut, "/flags/a/value", `{}`, nil, http.StatusBadRequest, "INVALID_VALUE"},
+		{"string value", http.MethodPost, "/flags", `{"name":"a","type":"boolean","value":"yes"}`, nil, http.StatusBadRequest, "INVALID_VALUE"},
+		{"invalid rule", http.MethodPut, "/flags/a/rules", `{"rules":[]}`, domain.ErrInvalidRule, http.StatusBadRequest, "INVALID_RULE"},
+		{"invalid target", http.MethodPost, "/flags/a/targets", `{"keys":[],"value":true}`, domain.ErrInvalidTarget, http.StatusBadRequest, "INVALID_TARGET"},
+		{"rule without value", http.MethodPut, "/flags/a/rules", `{"rules":[{"id":"r","clauses":[]}]}`, nil, http.StatusBadRequest, "INVALID_VALUE"},
+		{"malformed body", http.MethodPost, "/evaluate", `{`, nil, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"missing enabled", http.MethodPut, "/flags/a/enabled", `{}`, nil, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"unknown environment", http.MethodGet, "/environments/ghost/flags/a", "", domain.ErrEnvironmentNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"environment exists", http.MethodPost, "/environments", `{"key":"staging"}`, domain.ErrEnvironmentExists, http.StatusConflict, "ALREADY_EXISTS"},
+		{"invalid environment", http.MethodPost, "/environments", `{"key":"Staging"}`, domain.ErrInvalidEnvironment, http.StatusBadRequest, "INVALID_ENVIRONMENT"},
+		{"unknown project", http.MethodGet, "/projects/ghost/flags/a", "", domain.ErrProjectNotFound, http.StatusNotFound, "NOT_FOUND"},
+		{"project exists", http.MethodPost, "/projects", `{"key":"checkout"}`, domain.ErrProjectExists, http.StatusConflict, "ALREADY_EXISTS"},
+		{"invalid project", http.MethodPost, "/projects", `{"key":"Checkout"}`, domain.ErrInvalidProject, http.StatusBadRequest, "INVALID_PROJECT"},
+		{"invalid tag", http.MethodPost, "/flags", `{"name":"a","type":"boolean","value":true,"tags":["A"]}`, domain.ErrInvalidTag, http.StatusBadRequest, "INVALID_TAG"},
+		{"frozen", http.MethodPut, "/flags/a/value", `{"value":true}`, domain.ErrFrozen, http.StatusConflict, "FROZEN"},
+		{"protected", http.MethodPut, "/flags/a/rules", `{"rules":[]}`, domain.ErrProtected, http.StatusConflict, "PROTECTED"},
+		{"unexpected error", http.MethodGet, "/flags/a/value", "", errors.New("boom"), http.StatusInternalServerError, "INTERNAL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := serve(t, &fakeFlagService{err: tt.err}, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantCode, decodeBody(t, rec)["code"])
+		})
+	}
+}
+
+func TestHandler_GetFlagValue(t *testing.T) {
+	t.Parallel()
+
+	numVal := 0.5
+	bucket := 42
+	svc := &fakeFlagService{valueResp: &port.FlagValueResponse{
+		Value:   port.FlagValue{Numeric: &numVal},
+		Reason:  "SPLIT",
+		Bucket:  &bucket,
+		Version: 7,
+		Source:  "cache",
+	}}
+
+	rec := serve(t, svc, http.MethodGet, "/flags/rollout/value", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "rollout", svc.requestedAs)
+	body := decodeBody(t, rec)
+	assert.InDelta(t, 0.5, body["value"], 1e-9)
+	assert.Equal(t, "SPLIT", body["reason"])
+	assert.InDelta(t, 42, body["bucket"], 0)
+	assert.InDelta(t, 7, body["version"], 0)
+	assert.Equal(t, "cache", body["source"])
+	assert.NotContains(t, body, "rule_id")
+}
+
+func TestHandler_UpdateFlagValue(t *testing.T) {
+	t.Parallel()
+
+	numVal := 12.5
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:    "limit",
+		Type:    "numeric",
+		Value:   port.FlagValue{Numeric: &numVal},
+		Version: 2,
+	}}
+
+	rec := serve(t, svc, http.MethodPut, "/flags/limit/value", `{"value":12.5}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "limit", svc.requestedAs)
+	require.NotNil(t, svc.updateReq.Value.Numeric)
+	assert.InDelta(t, 12.5, *svc.updateReq.Value.Numeric, 1e-9)
+	assert.InDelta(t, 2, decodeBody(t, rec)["version"], 0)
+}
+
+func TestHandler_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	svc := &fakeFlagService{evaluateResp: &port.EvaluateAllResponse{Flags: map[string]port.FlagValueResponse{
+		"new-ui": {Value: port.FlagValue{Bool: &boolVal}, Reason: "STATIC", Version: 1, Source: "store"},
+	}}}
+
+	rec := serve(t, svc, http.MethodPost, "/evaluate",
+		`{"context":{"targeting_key":"user-1","attributes":{"plan":"pro"}},"flags":["new-ui"],"prefix":"new-"}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-1", svc.evalCtx.TargetingKey)
+	assert.Equal(t, "pro", svc.evalCtx.Attributes["plan"])
+	assert.Equal(t, []string{"new-ui"}, svc.evalFilter.Names)
+	assert.Equal(t, "new-", svc.evalFilter.Prefix)
+
+	var body struct {
+		Flags map[string]map[string]any `json:"flags"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Contains(t, body.Flags, "new-ui")
+	assert.Equal(t, true, body.Flags["new-ui"]["value"])
+	assert.Equal(t, "STATIC", body.Flags["new-ui"]["reason"])
+}
+
+func TestHandler_UpdateFlagRules(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:  "office-hours",
+		Type:  "boolean",
+		Value: port.FlagValue{Bool: &off},
+		Rules: []port.Rule{{
+			ID:      "weekdays",
+			Clauses: []port.Clause{{Operator: "day-of-week-in", Values: []string{"mon"}, Timezone: "Europe/Warsaw"}},
+			Value:   port.FlagValue{Bool: &on},
+		}},
+		Version: 2,
+	}}
+
+	rec := serve(t, svc, http.MethodPut, "/flags/office-hours/rules",
+		`{"rules":[{"id":"weekdays","clauses":[{"operator":"day-of-week-in","values":["mon"],"timezone":"Europe/Warsaw"}],"value":true}]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "office-hours", svc.requestedAs)
+	require.Len(t, svc.rulesReq.Rules, 1)
+	rule := svc.rulesReq.Rules[0]
+	assert.Equal(t, "weekdays", rule.ID)
+	assert.Equal(t, []port.Clause{{Operator: "day-of-week-in", Values: []string{"mon"}, Timezone: "Europe/Warsaw"}}, rule.Clauses)
+	require.NotNil(t, rule.Value.Bool)
+	assert.True(t, *rule.Value.Bool)
+
+	var body struct {
+		Rules []map[string]any `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Rules, 1)
+	assert.Equal(t, "weekdays", body.Rules[0]["id"])
+	assert.Equal(t, true, body.Rules[0]["value"])
+}
+
+func TestHandler_UpdateFlagRules_Expression(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name: "new-billing",
+		Type: "boolean",
+		Rules: []port.Rule{{
+			ID:         "large-pro",
+			Expression: `plan == "pro" && seats > 10`,
+			Value:      port.FlagValue{Bool: &on},
+		}},
+	}}
+
+	rec := serve(t, svc, http.MethodPut, "/flags/new-billing/rules",
+		`{"rules":[{"id":"large-pro","expression":"plan == \"pro\" && seats > 10","value":true}]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, svc.rulesReq.Rules, 1)
+	assert.Equal(t, `plan == "pro" && seats > 10`, svc.rulesReq.Rules[0].Expression)
+	assert.Empty(t, svc.rulesReq.Rules[0].Clauses)
+
+	var body struct {
+		Rules []map[string]any `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Rules, 1)
+	assert.Equal(t, `plan == "pro" && seats > 10`, body.Rules[0]["expression"])
+}
+
+func TestHandler_AddTargets(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:    "new-checkout",
+		Type:    "boolean",
+		Value:   port.FlagValue{Bool: &off},
+		Targets: map[string]port.FlagValue{"user-1234": {Bool: &on}},
+		Version: 2,
+	}}
+
+	rec := serve(t, svc, http.MethodPost, "/flags/new-checkout/targets", `{"keys":["user-1234"],"value":true}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "new-checkout", svc.requestedAs)
+	assert.Equal(t, []string{"user-1234"}, svc.addReq.Keys)
+	require.NotNil(t, svc.addReq.Value.Bool)
+	assert.True(t, *svc.addReq.Value.Bool)
+	body := decodeBody(t, rec)
+	assert.Equal(t, map[string]any{"user-1234": true}, body["targets"])
+}
+
+func TestHandler_AddTargets_InvalidValue(t *testing.T) {
+	t.Parallel()
+
+	rec := serve(t, &fakeFlagService{}, http.MethodPost, "/flags/new-checkout/targets", `{"keys":["user-1234"],"value":"on"}`)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "INVALID_VALUE", decodeBody(t, rec)["code"])
+}
+
+func TestHandler_RemoveTarget(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:    "new-checkout",
+		Type:    "boolean",
+		Value:   port.FlagValue{Bool: &off},
+		Version: 3,
+	}}
+
+	rec := serve(t, svc, http.MethodDelete, "/flags/new-checkout/targets/user-1234", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "new-checkout", svc.requestedAs)
+	assert.Equal(t, []string{"user-1234"}, svc.removeReq.Keys)
+	assert.NotContains(t, decodeBody(t, rec), "targets")
+}
+
+func TestHandler_Evaluate_ExperimentVariant(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	bucket := 17
+	svc := &fakeFlagService{evaluateResp: &port.EvaluateAllResponse{Flags: map[string]port.FlagValueResponse{
+		"checkout": {
+			Value:      port.FlagValue{Bool: &on},
+			Reason:     "SPLIT",
+			Bucket:     &bucket,
+			Experiment: "checkout-test",
+			Variant:    "treatment",
+			Version:    3,
+			Source:     "cache",
+		},
+	}}}
+
+	rec := serve(t, svc, http.MethodPost, "/evaluate", `{"context":{"targeting_key":"user-1"}}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	flags := decodeBody(t, rec)["flags"].(map[string]any)
+	got := flags["checkout"].(map[string]any)
+	assert.Equal(t, "SPLIT", got["reason"])
+	assert.Equal(t, "checkout-test", got["experiment"])
+	assert.Equal(t, "treatment", got["variant"])
+}
+
+func TestHandler_CreateFlag_Variants(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	svc := &fakeFlagService{flagResp: &port.FlagResponse{
+		Name:  "checkout",
+		Type:  "boolean",
+		Value: port.FlagValue{Bool: &off},
+		Variants: []port.Variant{
+			{Key: "control", Value: port.FlagValue{Bool: &off}},
+			{Key: "treatment", Value: port.FlagValue{Bool: &on}},
+		},
+		Version: 1,
+	}}
+
+	rec := serve(t, svc, http.MethodPost, "/flags",
+		`{"name":"checkout","type":"boolean","value":false,"variants":[{"key":"control","value":false},{"key":"treatment","value":true}]}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	require.Len(t, svc.createReq.Variants, 2)
+	assert.Equal(t, "treatment", svc.createReq.Variants[1].Key)
+	require.NotNil(t, svc.createReq.Variants[1].Value.Bool)
+	assert.True(t, *svc.createReq.Variants[1].Value.Bool)
+	assert.Len(t, decodeBody(t, rec)["variants"], 2)
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// atInstant scopes the request to the instant in its as_of query parameter,
+// when it has one, so the read is answered from the flags' history.
+func (h *Handler) atInstant(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		at, err := parseTime(r.URL.Query().Get("as_of"), domain.ErrInvalidTimestamp)
+		if err != nil {
+			h.writeError(w, r, fmt.Errorf("as_of: %w", err))
+			return
+		}
+		if !at.IsZero() {
+			r = r.WithContext(port.WithAsOf(r.Context(), at))
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) getFlagHistory(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	versions, err := h.svc.GetFlagHistory(r.Context(), name)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagHistoryResponse(name, versions))
+}
+
+func (h *Handler) getSnapshot(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetSnapshot(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toSnapshotResponse(resp))
+}
+
+func (h *Handler) rollbackFlag(w http.ResponseWriter, r *http.Request) {
+	var req rollbackFlagRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if req.Version == nil {
+		h.writeError(w, r, fmt.Errorf("%w: version is required", errInvalidRequest))
+		return
+	}
+
+	resp, err := h.svc.RollbackFlag(r.Context(), r.PathValue("name"), *req.Version)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toFlagResponse(resp))
+}
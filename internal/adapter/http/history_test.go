@@ -0,0 +1,149 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_GetFlagHistory(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{historyResp: []port.FlagVersionResponse{
+		{Version: 2, Environment: "staging", Enabled: true, Value: port.FlagValue{Bool: &on}, RecordedAt: at.Add(time.Hour)},
+		{Version: 1, Environment: "production", Enabled: true, Value: port.FlagValue{Bool: &off}, RecordedAt: at},
+	}}
+
+	rec := serve(t, svc, http.MethodGet, "/environments/staging/flags/new-checkout/history", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "new-checkout", svc.requestedAs)
+	assert.Equal(t, "staging", svc.environment)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "new-checkout", body["flag"])
+	versions, ok := body["versions"].([]any)
+	require.True(t, ok)
+	require.Len(t, versions, 2)
+	latest := versions[0].(map[string]any)
+	assert.Equal(t, float64(2), latest["version"])
+	assert.Equal(t, true, latest["value"])
+	assert.Equal(t, "production", versions[1].(map[string]any)["environment"])
+}
+
+func TestHandler_RollbackFlag(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "rolls back", body: `{"version": 1}`, wantStatus: http.StatusOK},
+		{name: "missing version", body: `{}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "unknown version", body: `{"version": 9}`, svcErr: domain.ErrVersionNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{
+				flagResp: &port.FlagResponse{Name: "new-checkout", Type: "boolean", Enabled: true, Value: port.FlagValue{Bool: &off}, Version: 4},
+				err:      tt.svcErr,
+			}
+
+			rec := serve(t, svc, http.MethodPost, "/flags/new-checkout/rollback", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, int64(1), svc.toVersion)
+			assert.Equal(t, "new-checkout", svc.requestedAs)
+			assert.Equal(t, float64(4), body["version"])
+			assert.Equal(t, false, body["value"])
+		})
+	}
+}
+
+func TestHandler_PointInTimeReads(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	at := time.Date(2026, 6, 1, 14, 2, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		target   string
+		wantAsOf time.Time
+	}{
+		{name: "flag as of", target: "/flags/new-checkout?as_of=2026-06-01T14:02:00Z", wantAsOf: at},
+		{name: "value as of", target: "/flags/new-checkout/value?as_of=2026-06-01T16:02:00%2B02:00", wantAsOf: at},
+		{name: "current flag", target: "/flags/new-checkout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{
+				flagResp:  &port.FlagResponse{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}},
+				valueResp: &port.FlagValueResponse{Value: port.FlagValue{Bool: &off}, Reason: "STATIC", Source: "history"},
+			}
+
+			rec := serve(t, svc, http.MethodGet, tt.target, "")
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.True(t, tt.wantAsOf.Equal(svc.asOf), "as_of %v, want %v", svc.asOf, tt.wantAsOf)
+		})
+	}
+}
+
+func TestHandler_PointInTimeReads_InvalidAsOf(t *testing.T) {
+	t.Parallel()
+
+	for _, target := range []string{"/flags/new-checkout?as_of=14:02", "/flags/new-checkout/value?as_of=yesterday", "/snapshot?as_of=2026-06-01"} {
+		rec := serve(t, &fakeFlagService{}, http.MethodGet, target, "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+		assert.Equal(t, "INVALID_TIMESTAMP", decodeBody(t, rec)["code"], target)
+	}
+}
+
+func TestHandler_GetSnapshot(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	at := time.Date(2026, 6, 1, 14, 2, 0, 0, time.UTC)
+	svc := &fakeFlagService{snapshotResp: &port.SnapshotResponse{
+		Project:     "default",
+		Environment: "staging",
+		AsOf:        at,
+		Flags:       []port.FlagResponse{{Name: "new-checkout", Type: "boolean", Enabled: true, Value: port.FlagValue{Bool: &on}, Version: 2}},
+	}}
+
+	rec := serve(t, svc, http.MethodGet, "/environments/staging/snapshot?as_of=2026-06-01T14:02:00Z", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "staging", svc.environment)
+	assert.True(t, at.Equal(svc.asOf))
+	body := decodeBody(t, rec)
+	assert.Equal(t, "2026-06-01T14:02:00Z", body["as_of"])
+	flags, ok := body["flags"].([]any)
+	require.True(t, ok)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "new-checkout", flags[0].(map[string]any)["name"])
+	assert.Equal(t, true, flags[0].(map[string]any)["value"])
+}
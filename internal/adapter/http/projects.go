@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// inProject scopes the request to the project named in the path.
+func inProject(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(port.WithProject(r.Context(), r.PathValue("project"))))
+	}
+}
+
+func (h *Handler) createProject(w http.ResponseWriter, r *http.Request) {
+	var req createProjectRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.svc.CreateProject(r.Context(), port.CreateProjectRequest{
+		Key:         req.Key,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toProjectResponse(*resp))
+}
+
+func (h *Handler) listProjects(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.ListProjects(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listProjectsResponse{Projects: make([]projectResponse, len(resp))}
+	for i, project := range resp {
+		out.Projects[i] = toProjectResponse(project)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
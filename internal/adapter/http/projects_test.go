@@ -0,0 +1,75 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_ProjectScopedRoutes(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		wantProject string
+		wantEnv     string
+	}{
+		{"unscoped flag", http.MethodGet, "/flags/new-checkout", "", domain.DefaultProject, domain.DefaultEnvironment},
+		{"project flag", http.MethodGet, "/projects/checkout/flags/new-checkout", "", "checkout", domain.DefaultEnvironment},
+		{"project and environment flag", http.MethodGet, "/projects/checkout/environments/staging/flags/new-checkout", "", "checkout", "staging"},
+		{"environment flag", http.MethodGet, "/environments/staging/flags/new-checkout", "", domain.DefaultProject, "staging"},
+		{"project promotions", http.MethodGet, "/projects/checkout/promotions", "", "checkout", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{flagResp: &port.FlagResponse{Project: tt.wantProject, Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &on}}}
+			rec := serve(t, svc, tt.method, tt.target, tt.body)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantProject, svc.project)
+			assert.Equal(t, tt.wantEnv, svc.environment)
+		})
+	}
+}
+
+func TestHandler_CreateProject(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{projectResp: &port.ProjectResponse{Key: "checkout", Description: "checkout team", CreatedAt: now}}
+
+	rec := serve(t, svc, http.MethodPost, "/projects", `{"key":"checkout","description":"checkout team"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	body := decodeBody(t, rec)
+	assert.Equal(t, "checkout", body["key"])
+	assert.Equal(t, "checkout team", body["description"])
+	assert.Equal(t, port.CreateProjectRequest{Key: "checkout", Description: "checkout team"}, svc.projectReq)
+}
+
+func TestHandler_ListProjects(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeFlagService{projectsResp: []port.ProjectResponse{{Key: "checkout"}, {Key: domain.DefaultProject}}}
+
+	rec := serve(t, svc, http.MethodGet, "/projects", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := decodeBody(t, rec)
+	projects, ok := body["projects"].([]any)
+	require.True(t, ok)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "checkout", projects[0].(map[string]any)["key"])
+}
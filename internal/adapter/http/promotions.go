@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) promoteFlags(w http.ResponseWriter, r *http.Request) {
+	var req promoteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.svc.PromoteFlags(r.Context(), port.PromoteRequest{
+		Source: req.Source,
+		Target: req.Target,
+		Flags:  req.Flags,
+		DryRun: req.DryRun,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	status := http.StatusCreated
+	if resp.DryRun {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, toPromotionResponse(*resp))
+}
+
+func (h *Handler) listPromotions(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.ListPromotions(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listPromotionsResponse{Promotions: make([]promotionResponse, len(resp))}
+	for i, p := range resp {
+		out.Promotions[i] = toPromotionResponse(p)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,106 @@
+package http_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestHandler_PromoteFlags(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	diffs := []port.FlagDiff{{
+		Name:    "new-checkout",
+		Value:   &port.ValueChange{From: port.FlagValue{Bool: &off}, To: port.FlagValue{Bool: &on}},
+		Rules:   []port.RuleChange{{ID: "beta", Change: "added"}},
+		Targets: []port.TargetChange{{Key: "user-1", Change: "removed"}},
+	}}
+
+	tests := []struct {
+		name       string
+		body       string
+		resp       *port.PromotionResponse
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "dry run",
+			body:       `{"source":"staging","target":"production","dry_run":true}`,
+			resp:       &port.PromotionResponse{Source: "staging", Target: "production", DryRun: true, Flags: diffs},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "applied",
+			body:       `{"source":"staging","target":"production","flags":["new-checkout"]}`,
+			resp:       &port.PromotionResponse{ID: 7, Source: "staging", Target: "production", Flags: diffs, PromotedAt: now},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "same environment",
+			body:       `{"source":"staging","target":"staging"}`,
+			err:        domain.ErrInvalidPromotion,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_PROMOTION",
+		},
+		{
+			name:       "unknown environment",
+			body:       `{"source":"ghost","target":"production"}`,
+			err:        domain.ErrEnvironmentNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeFlagService{promoteResp: tt.resp, err: tt.err}
+			rec := serve(t, svc, http.MethodPost, "/promotions", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, "staging", svc.promoteReq.Source)
+			assert.Equal(t, tt.resp.DryRun, body["dry_run"])
+			flags := body["flags"].([]any)
+			require.Len(t, flags, 1)
+			diff := flags[0].(map[string]any)
+			assert.Equal(t, map[string]any{"from": false, "to": true}, diff["value"])
+			assert.NotContains(t, diff, "enabled")
+			assert.Equal(t, []any{map[string]any{"id": "beta", "change": "added"}}, diff["rules"])
+			assert.Equal(t, []any{map[string]any{"key": "user-1", "change": "removed"}}, diff["targets"])
+		})
+	}
+}
+
+func TestHandler_ListPromotions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeFlagService{promotions: []port.PromotionResponse{
+		{ID: 2, Source: "staging", Target: "production", PromotedAt: now},
+	}}
+
+	rec := serve(t, svc, http.MethodGet, "/promotions", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	promotions := decodeBody(t, rec)["promotions"].([]any)
+	require.Len(t, promotions, 1)
+	got := promotions[0].(map[string]any)
+	assert.Equal(t, float64(2), got["id"])
+	assert.Equal(t, []any{}, got["flags"])
+	assert.Equal(t, "2026-06-01T09:00:00Z", got["promoted_at"])
+}
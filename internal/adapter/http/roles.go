@@ -0,0 +1,113 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) createRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.access.CreateRole(r.Context(), fromRoleRequest(req))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toRoleResponse(resp))
+}
+
+func (h *Handler) listRoles(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.access.ListRoles(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listRolesResponse{Roles: make([]roleResponse, len(resp))}
+	for i, role := range resp {
+		out.Roles[i] = toRoleResponse(&role)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getRole(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.access.GetRole(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toRoleResponse(resp))
+}
+
+// updateRole replaces the role's description and permissions; a name in the
+// body is ignored.
+func (h *Handler) updateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.access.UpdateRole(r.Context(), r.PathValue("name"), fromRoleRequest(req))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toRoleResponse(resp))
+}
+
+func (h *Handler) deleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := h.access.DeleteRole(r.Context(), r.PathValue("name")); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) createRoleBinding(w http.ResponseWriter, r *http.Request) {
+	var req roleBindingRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.access.CreateRoleBinding(r.Context(), port.RoleBindingRequest(req))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toRoleBindingResponse(resp))
+}
+
+// listRoleBindings lists the bindings of the principal in the query, or
+// every binding without one.
+func (h *Handler) listRoleBindings(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.access.ListRoleBindings(r.Context(), r.URL.Query().Get("principal"))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listRoleBindingsResponse{RoleBindings: make([]roleBindingResponse, len(resp))}
+	for i, binding := range resp {
+		out.RoleBindings[i] = toRoleBindingResponse(&binding)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) deleteRoleBinding(w http.ResponseWriter, r *http.Request) {
+	raw := r.PathValue("id")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("role binding %q: %w", raw, domain.ErrRoleBindingNotFound))
+		return
+	}
+	if err := h.access.DeleteRoleBinding(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
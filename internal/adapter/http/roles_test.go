@@ -0,0 +1,226 @@
+package http_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// fakeAccessService is a hand-written fake implementing port.AccessService.
+type fakeAccessService struct {
+	role    *port.RoleResponse
+	binding *port.RoleBindingResponse
+	err     error
+
+	req        port.RoleRequest
+	bindingReq port.RoleBindingRequest
+	name       string
+	principal  string
+	id         int64
+}
+
+func (f *fakeAccessService) CreateRole(_ context.Context, req port.RoleRequest) (*port.RoleResponse, error) {
+	f.req = req
+	return f.role, f.err
+}
+
+func (f *fakeAccessService) ListRoles(context.Context) ([]port.RoleResponse, error) {
+	if f.role == nil {
+		return nil, f.err
+	}
+	return []port.RoleResponse{*f.role}, f.err
+}
+
+func (f *fakeAccessService) GetRole(_ context.Context, name string) (*port.RoleResponse, error) {
+	f.name = name
+	return f.role, f.err
+}
+
+func (f *fakeAccessService) UpdateRole(_ context.Context, name string, req port.RoleRequest) (*port.RoleResponse, error) {
+	f.name, f.req = name, req
+	return f.role, f.err
+}
+
+func (f *fakeAccessService) DeleteRole(_ context.Context, name string) error {
+	f.name = name
+	return f.err
+}
+
+func (f *fakeAccessService) CreateRoleBinding(_ context.Context, req port.RoleBindingRequest) (*port.RoleBindingResponse, error) {
+	f.bindingReq = req
+	return f.binding, f.err
+}
+
+func (f *fakeAccessService) ListRoleBindings(_ context.Context, principal string) ([]port.RoleBindingResponse, error) {
+	f.principal = principal
+	if f.binding == nil {
+		return nil, f.err
+	}
+	return []port.RoleBindingResponse{*f.binding}, f.err
+}
+
+func (f *fakeAccessService) DeleteRoleBinding(_ context.Context, id int64) error {
+	f.id = id
+	return f.err
+}
+
+func serveRoles(t *testing.T, svc port.AccessService, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer ffk_admin")
+	rec := httptest.NewRecorder()
+	httpadapter.NewHandler(&fakeFlagService{}, slog.New(slog.DiscardHandler),
+		httpadapter.WithAPIKeys(newFakeAPIKeyService()), httpadapter.WithAccess(svc)).Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func checkoutOwnersRole() *port.RoleResponse {
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	return &port.RoleResponse{
+		Name: "checkout-owners",
+		Permissions: []port.PermissionRequest{
+			{Scope: "flags:write", Projects: []string{"shop"}, Tags: []string{"checkout"}},
+		},
+		CreatedBy: "platform", CreatedAt: at, UpdatedAt: at,
+	}
+}
+
+func TestHandler_CreateRole(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "creates", body: `{"name":"checkout-owners","permissions":[{"scope":"flags:write","projects":["shop"],"tags":["checkout"]}]}`, wantStatus: http.StatusCreated},
+		{name: "malformed body", body: `{"permissions":{}}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid role", body: `{"name":"checkout-owners"}`, svcErr: domain.ErrInvalidRole, wantStatus: http.StatusBadRequest, wantCode: "INVALID_ROLE"},
+		{name: "exists", body: `{"name":"checkout-owners"}`, svcErr: domain.ErrRoleExists, wantStatus: http.StatusConflict, wantCode: "ALREADY_EXISTS"},
+		{name: "forbidden", body: `{"name":"checkout-owners"}`, svcErr: domain.ErrForbidden, wantStatus: http.StatusForbidden, wantCode: "FORBIDDEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeAccessService{role: checkoutOwnersRole(), err: tt.svcErr}
+
+			rec := serveRoles(t, svc, http.MethodPost, "/roles", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, port.RoleRequest{Name: "checkout-owners", Permissions: []port.PermissionRequest{
+				{Scope: "flags:write", Projects: []string{"shop"}, Tags: []string{"checkout"}},
+			}}, svc.req)
+			assert.Equal(t, map[string]any{
+				"name": "checkout-owners",
+				"permissions": []any{map[string]any{
+					"scope": "flags:write", "projects": []any{"shop"}, "tags": []any{"checkout"},
+				}},
+				"created_by": "platform", "created_at": "2026-06-01T09:00:00Z", "updated_at": "2026-06-01T09:00:00Z",
+			}, body)
+		})
+	}
+}
+
+func TestHandler_RoleByName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		svcErr     error
+		wantStatus int
+	}{
+		{name: "get", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "update", method: http.MethodPut, body: `{"permissions":[{"scope":"flags:read"}]}`, wantStatus: http.StatusOK},
+		{name: "delete", method: http.MethodDelete, wantStatus: http.StatusNoContent},
+		{name: "missing", method: http.MethodGet, svcErr: domain.ErrRoleNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeAccessService{role: checkoutOwnersRole(), err: tt.svcErr}
+
+			rec := serveRoles(t, svc, tt.method, "/roles/checkout-owners", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "checkout-owners", svc.name)
+		})
+	}
+}
+
+func TestHandler_RoleBindings(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeAccessService{binding: &port.RoleBindingResponse{
+		ID: 3, Principal: "checkout-team", Role: "checkout-owners", CreatedBy: "platform",
+		CreatedAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+	}}
+
+	rec := serveRoles(t, svc, http.MethodPost, "/role-bindings", `{"principal":"checkout-team","role":"checkout-owners"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, port.RoleBindingRequest{Principal: "checkout-team", Role: "checkout-owners"}, svc.bindingReq)
+
+	rec = serveRoles(t, svc, http.MethodGet, "/role-bindings?principal=checkout-team", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "checkout-team", svc.principal)
+	bindings, ok := decodeBody(t, rec)["role_bindings"].([]any)
+	require.True(t, ok)
+	assert.Equal(t, []any{map[string]any{
+		"id": float64(3), "principal": "checkout-team", "role": "checkout-owners",
+		"created_by": "platform", "created_at": "2026-06-01T09:00:00Z",
+	}}, bindings)
+
+	rec = serveRoles(t, svc, http.MethodDelete, "/role-bindings/3", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, int64(3), svc.id)
+
+	rec = serveRoles(t, svc, http.MethodDelete, "/role-bindings/latest", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_RoleRoutesRequireAdmin(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/roles", nil)
+	req.Header.Set("Authorization", "Bearer ffk_write")
+	rec := httptest.NewRecorder()
+	httpadapter.NewHandler(&fakeFlagService{}, slog.New(slog.DiscardHandler),
+		httpadapter.WithAPIKeys(newFakeAPIKeyService()), httpadapter.WithAccess(&fakeAccessService{})).Routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "INSUFFICIENT_SCOPE", decodeBody(t, rec)["code"])
+}
+
+func TestHandler_Forbidden(t *testing.T) {
+	t.Parallel()
+
+	svc := &fakeFlagService{err: fmt.Errorf("principal %q may not use flags:write: %w", "checkout-team", domain.ErrForbidden)}
+
+	rec := serve(t, svc, http.MethodPut, "/flags/fuzzy-search/value", `{"value":true}`)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "FORBIDDEN", decodeBody(t, rec)["code"])
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (h *Handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := decodeJSON(r, &req); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	resp, err := h.webhooks.CreateWebhook(r.Context(), port.WebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toWebhookResponse(resp))
+}
+
+func (h *Handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.webhooks.ListWebhooks(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listWebhooksResponse{Webhooks: make([]webhookResponse, len(resp))}
+	for i, hook := range resp {
+		out.Webhooks[i] = toWebhookResponse(&hook)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := webhookID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	resp, err := h.webhooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toWebhookResponse(resp))
+}
+
+func (h *Handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := webhookID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if err := h.webhooks.DeleteWebhook(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := webhookID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	query := r.URL.Query()
+	filter := port.DeliveryFilter{Status: query.Get("status")}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			h.writeError(w, r, fmt.Errorf("limit %q is not a number: %w", raw, domain.ErrInvalidDeliveryQuery))
+			return
+		}
+	}
+
+	resp, err := h.webhooks.ListWebhookDeliveries(r.Context(), id, filter)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	out := listDeliveriesResponse{Deliveries: make([]deliveryResponse, len(resp))}
+	for i, d := range resp {
+		out.Deliveries[i] = toDeliveryResponse(&d)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *Handler) retryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := webhookID(r)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	raw := r.PathValue("delivery")
+	deliveryID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		h.writeError(w, r, fmt.Errorf("delivery %q: %w", raw, domain.ErrDeliveryNotFound))
+		return
+	}
+
+	resp, err := h.webhooks.RetryWebhookDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toDeliveryResponse(resp))
+}
+
+// webhookID parses the webhook id in the path. An id that is not a number
+// names no webhook.
+func webhookID(r *http.Request) (int64, error) {
+	raw := r.PathValue("id")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("webhook %q: %w", raw, domain.ErrWebhookNotFound)
+	}
+	return id, nil
+}
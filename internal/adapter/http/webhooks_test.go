@@ -0,0 +1,244 @@
+package http_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpadapter "github.com/xNakero/feature-flags/internal/adapter/http"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// fakeWebhookService is a hand-written fake implementing port.WebhookService.
+type fakeWebhookService struct {
+	hook       *port.WebhookResponse
+	deliveries []port.DeliveryResponse
+	err        error
+
+	req        port.WebhookRequest
+	id         int64
+	deliveryID int64
+	filter     port.DeliveryFilter
+}
+
+func (f *fakeWebhookService) CreateWebhook(_ context.Context, req port.WebhookRequest) (*port.WebhookResponse, error) {
+	f.req = req
+	return f.hook, f.err
+}
+
+func (f *fakeWebhookService) ListWebhooks(context.Context) ([]port.WebhookResponse, error) {
+	if f.hook == nil {
+		return nil, f.err
+	}
+	return []port.WebhookResponse{*f.hook}, f.err
+}
+
+func (f *fakeWebhookService) GetWebhook(_ context.Context, id int64) (*port.WebhookResponse, error) {
+	f.id = id
+	return f.hook, f.err
+}
+
+func (f *fakeWebhookService) DeleteWebhook(_ context.Context, id int64) error {
+	f.id = id
+	return f.err
+}
+
+func (f *fakeWebhookService) ListWebhookDeliveries(_ context.Context, id int64, filter port.DeliveryFilter) ([]port.DeliveryResponse, error) {
+	f.id, f.filter = id, filter
+	return f.deliveries, f.err
+}
+
+func (f *fakeWebhookService) RetryWebhookDelivery(_ context.Context, id, deliveryID int64) (*port.DeliveryResponse, error) {
+	f.id, f.deliveryID = id, deliveryID
+	if len(f.deliveries) == 0 {
+		return nil, f.err
+	}
+	return &f.deliveries[0], f.err
+}
+
+func serveWebhooks(t *testing.T, svc port.WebhookService, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	httpadapter.NewHandler(&fakeFlagService{}, slog.New(slog.DiscardHandler), httpadapter.WithWebhooks(svc)).Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func deployHook() *port.WebhookResponse {
+	return &port.WebhookResponse{
+		ID: 4, URL: "https://deploys.example.com/flags", Events: []string{"update-value"},
+		CreatedBy: "alice", CreatedAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestHandler_CreateWebhook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "creates", body: `{"url":"https://deploys.example.com/flags","events":["update-value"]}`, wantStatus: http.StatusCreated},
+		{name: "malformed body", body: `{"events":"update-value"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid webhook", body: `{"url":"/flags"}`, svcErr: domain.ErrInvalidWebhook, wantStatus: http.StatusBadRequest, wantCode: "INVALID_WEBHOOK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hook := deployHook()
+			hook.Secret = "0123456789abcdef"
+			svc := &fakeWebhookService{hook: hook, err: tt.svcErr}
+
+			rec := serveWebhooks(t, svc, http.MethodPost, "/webhooks", tt.body)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, port.WebhookRequest{URL: "https://deploys.example.com/flags", Events: []string{"update-value"}}, svc.req)
+			assert.Equal(t, map[string]any{
+				"id": float64(4), "url": "https://deploys.example.com/flags", "events": []any{"update-value"},
+				"secret": "0123456789abcdef", "created_by": "alice", "created_at": "2026-06-01T09:00:00Z",
+			}, body)
+		})
+	}
+}
+
+func TestHandler_WebhookByID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		svcErr     error
+		wantStatus int
+		wantID     int64
+	}{
+		{name: "get", method: http.MethodGet, target: "/webhooks/4", wantStatus: http.StatusOK, wantID: 4},
+		{name: "delete", method: http.MethodDelete, target: "/webhooks/4", wantStatus: http.StatusNoContent, wantID: 4},
+		{name: "missing", method: http.MethodGet, target: "/webhooks/9", svcErr: domain.ErrWebhookNotFound, wantStatus: http.StatusNotFound, wantID: 9},
+		{name: "not a number", method: http.MethodDelete, target: "/webhooks/deploys", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeWebhookService{hook: deployHook(), err: tt.svcErr}
+
+			rec := serveWebhooks(t, svc, tt.method, tt.target, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantID, svc.id)
+		})
+	}
+}
+
+func TestHandler_ListWebhooks_HidesSecret(t *testing.T) {
+	t.Parallel()
+
+	rec := serveWebhooks(t, &fakeWebhookService{hook: deployHook()}, http.MethodGet, "/webhooks", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	hooks, ok := decodeBody(t, rec)["webhooks"].([]any)
+	require.True(t, ok)
+	require.Len(t, hooks, 1)
+	assert.NotContains(t, hooks[0], "secret")
+}
+
+func TestHandler_ListWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := &fakeWebhookService{deliveries: []port.DeliveryResponse{{
+		ID: 12, WebhookID: 4, Event: "update-value", Project: "default", Environment: "production",
+		Flag: "new-checkout", Version: 3, Actor: "alice", Status: "dead", Attempts: 8,
+		NextAttemptAt: at.Add(time.Hour), ResponseStatus: 503, LastError: "receiver answered 503", CreatedAt: at,
+	}}}
+
+	rec := serveWebhooks(t, svc, http.MethodGet, "/webhooks/4/deliveries?status=dead&limit=20", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int64(4), svc.id)
+	assert.Equal(t, port.DeliveryFilter{Status: "dead", Limit: 20}, svc.filter)
+	deliveries, ok := decodeBody(t, rec)["deliveries"].([]any)
+	require.True(t, ok)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, map[string]any{
+		"id": float64(12), "webhook_id": float64(4), "event": "update-value", "project": "default",
+		"environment": "production", "flag": "new-checkout", "version": float64(3), "actor": "alice",
+		"status": "dead", "attempts": float64(8), "response_status": float64(503),
+		"last_error": "receiver answered 503", "created_at": "2026-06-01T09:00:00Z",
+	}, deliveries[0], "a dead delivery has no next attempt")
+}
+
+func TestHandler_ListWebhookDeliveries_InvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	rec := serveWebhooks(t, &fakeWebhookService{}, http.MethodGet, "/webhooks/4/deliveries?limit=ten", "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "INVALID_QUERY", decodeBody(t, rec)["code"])
+}
+
+func TestHandler_RetryWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		target     string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "retries", target: "/webhooks/4/deliveries/12/retry", wantStatus: http.StatusOK},
+		{name: "not dead", target: "/webhooks/4/deliveries/12/retry", svcErr: domain.ErrDeliveryState, wantStatus: http.StatusConflict, wantCode: "INVALID_STATE"},
+		{name: "missing", target: "/webhooks/4/deliveries/12/retry", svcErr: domain.ErrDeliveryNotFound, wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+		{name: "not a number", target: "/webhooks/4/deliveries/last/retry", wantStatus: http.StatusNotFound, wantCode: "NOT_FOUND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeWebhookService{err: tt.svcErr}
+			if tt.svcErr == nil {
+				svc.deliveries = []port.DeliveryResponse{{ID: 12, WebhookID: 4, Status: "pending", NextAttemptAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)}}
+			}
+
+			rec := serveWebhooks(t, svc, http.MethodPost, tt.target, "")
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			body := decodeBody(t, rec)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, body["code"])
+				return
+			}
+			assert.Equal(t, int64(12), svc.deliveryID)
+			assert.Equal(t, "2026-06-01T09:00:00Z", body["next_attempt_at"])
+		})
+	}
+}
+
+func TestHandler_WebhookRoutesRequireService(t *testing.T) {
+	t.Parallel()
+
+	rec := serve(t, &fakeFlagService{}, http.MethodGet, "/webhooks", "")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// accessSchema holds the roles with their permissions as JSONB and the
+// bindings granting them to principals, removed with their role. Requests
+// look bindings up by principal.
+const accessSchema = `
+CREATE TABLE IF NOT EXISTS roles (
+    name        TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    permissions JSONB       NOT NULL,
+    created_by  TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS role_bindings (
+    id         BIGSERIAL PRIMARY KEY,
+    principal  TEXT        NOT NULL,
+    role       TEXT        NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    created_by TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (principal, role)
+);`
+
+const (
+	roleColumns    = `name, description, permissions, created_by, created_at, updated_at`
+	bindingColumns = `id, principal, role, created_by, created_at`
+)
+
+// AccessStore keeps roles and role bindings.
+type AccessStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewAccessStore(pool *pgxpool.Pool) *AccessStore {
+	return &AccessStore{pool: pool}
+}
+
+func (s *AccessStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, accessSchema)
+	return err
+}
+
+func (s *AccessStore) CreateRole(ctx context.Context, role domain.Role) error {
+	permissions, err := flagjson.MarshalPermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx,
+		`INSERT INTO roles (`+roleColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		role.Name, role.Description, permissions, role.CreatedBy, role.CreatedAt, role.UpdatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("role %q: %w", role.Name, domain.ErrRoleExists)
+	}
+	return err
+}
+
+func (s *AccessStore) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	role, err := scanRole(s.pool.QueryRow(ctx, `SELECT `+roleColumns+` FROM roles WHERE name = $1`, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("role %q: %w", name, domain.ErrRoleNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *AccessStore) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+roleColumns+` FROM roles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Role, error) {
+		return scanRole(row)
+	})
+}
+
+func (s *AccessStore) UpdateRole(ctx context.Context, role domain.Role) error {
+	permissions, err := flagjson.MarshalPermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE roles SET description = $2, permissions = $3, updated_at = $4 WHERE name = $1`,
+		role.Name, role.Description, permissions, role.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("role %q: %w", role.Name, domain.ErrRoleNotFound)
+	}
+	return nil
+}
+
+func (s *AccessStore) DeleteRole(ctx context.Context, name string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM roles WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("role %q: %w", name, domain.ErrRoleNotFound)
+	}
+	return nil
+}
+
+func (s *AccessStore) CreateRoleBinding(ctx context.Context, binding domain.RoleBinding) (*domain.RoleBinding, error) {
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO role_bindings (principal, role, created_by, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		binding.Principal, binding.Role, binding.CreatedBy, binding.CreatedAt,
+	).Scan(&binding.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return nil, fmt.Errorf("%s already has role %q: %w", binding.Principal, binding.Role, domain.ErrRoleBindingExists)
+		case "23503":
+			return nil, fmt.Errorf("role %q: %w", binding.Role, domain.ErrRoleNotFound)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+func (s *AccessStore) ListRoleBindings(ctx context.Context, principal string) ([]domain.RoleBinding, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+bindingColumns+` FROM role_bindings WHERE $1 = '' OR principal = $1 ORDER BY id`,
+		principal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.RoleBinding, error) {
+		var b domain.RoleBinding
+		err := row.Scan(&b.ID, &b.Principal, &b.Role, &b.CreatedBy, &b.CreatedAt)
+		return b, err
+	})
+}
+
+func (s *AccessStore) DeleteRoleBinding(ctx context.Context, id int64) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM role_bindings WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("role binding %d: %w", id, domain.ErrRoleBindingNotFound)
+	}
+	return nil
+}
+
+func (s *AccessStore) RolesOf(ctx context.Context, principal string) ([]domain.Role, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT r.name, r.description, r.permissions, r.created_by, r.created_at, r.updated_at
+		 FROM roles r JOIN role_bindings b ON b.role = r.name
+		 WHERE b.principal = $1
+		 ORDER BY r.name`,
+		principal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Role, error) {
+		return scanRole(row)
+	})
+}
+
+func scanRole(row pgx.Row) (domain.Role, error) {
+	var (
+		role        domain.Role
+		permissions []byte
+	)
+	if err := row.Scan(&role.Name, &role.Description, &permissions, &role.CreatedBy, &role.CreatedAt, &role.UpdatedAt); err != nil {
+		return role, err
+	}
+	var err error
+	role.Permissions, err = flagjson.UnmarshalPermissions(permissions)
+	return role, err
+}
@@ -0,0 +1,62 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestAccessStore(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewAccessStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	role := domain.Role{
+		Name: "checkout-editors", Description: "Checkout team",
+		Permissions: []domain.Permission{{Scope: domain.ScopeFlagsWrite, Projects: []string{"shop"}, Tags: []string{"checkout"}}},
+		CreatedBy:   "platform", CreatedAt: now, UpdatedAt: now,
+	}
+	require.NoError(t, store.CreateRole(ctx, role))
+	require.ErrorIs(t, store.CreateRole(ctx, role), domain.ErrRoleExists)
+	got, err := store.GetRole(ctx, role.Name)
+	require.NoError(t, err)
+	assert.Equal(t, role, *got)
+
+	role.Permissions = append(role.Permissions, domain.Permission{Scope: domain.ScopeFlagsRead})
+	role.UpdatedAt = now.Add(time.Minute)
+	require.NoError(t, store.UpdateRole(ctx, role))
+	roles, err := store.ListRoles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Role{role}, roles)
+
+	binding, err := store.CreateRoleBinding(ctx, domain.RoleBinding{Principal: "checkout-team", Role: role.Name, CreatedBy: "platform", CreatedAt: now})
+	require.NoError(t, err)
+	_, err = store.CreateRoleBinding(ctx, domain.RoleBinding{Principal: "checkout-team", Role: role.Name, CreatedBy: "platform", CreatedAt: now})
+	require.ErrorIs(t, err, domain.ErrRoleBindingExists)
+	_, err = store.CreateRoleBinding(ctx, domain.RoleBinding{Principal: "checkout-team", Role: "viewers", CreatedBy: "platform", CreatedAt: now})
+	require.ErrorIs(t, err, domain.ErrRoleNotFound)
+
+	bound, err := store.RolesOf(ctx, "checkout-team")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Role{role}, bound)
+	bindings, err := store.ListRoleBindings(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.RoleBinding{*binding}, bindings)
+
+	require.NoError(t, store.DeleteRole(ctx, role.Name))
+	bindings, err = store.ListRoleBindings(ctx, "checkout-team")
+	require.NoError(t, err)
+	assert.Empty(t, bindings, "bindings go with their role")
+	require.ErrorIs(t, store.DeleteRoleBinding(ctx, binding.ID), domain.ErrRoleBindingNotFound)
+}
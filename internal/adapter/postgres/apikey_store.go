@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// apiKeySchema holds API keys by the SHA-256 hash of their token; the token
+// itself is never stored. Requests look keys up by that hash.
+const apiKeySchema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         BIGSERIAL PRIMARY KEY,
+    principal  TEXT        NOT NULL,
+    scopes     TEXT[]      NOT NULL,
+    hint       TEXT        NOT NULL,
+    hash       BYTEA       NOT NULL UNIQUE,
+    created_by TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);`
+
+const apiKeyColumns = `id, principal, scopes, hint, hash, created_by, created_at, expires_at, revoked_at`
+
+// APIKeyStore keeps API keys.
+type APIKeyStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyStore(pool *pgxpool.Pool) *APIKeyStore {
+	return &APIKeyStore{pool: pool}
+}
+
+func (s *APIKeyStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, apiKeySchema)
+	return err
+}
+
+func (s *APIKeyStore) CreateAPIKey(ctx context.Context, key domain.APIKey) (*domain.APIKey, error) {
+	return insertAPIKey(ctx, s.pool, key)
+}
+
+func (s *APIKeyStore) GetAPIKey(ctx context.Context, id int64) (*domain.APIKey, error) {
+	return getAPIKey(ctx, s.pool, id, "")
+}
+
+func (s *APIKeyStore) GetAPIKeyByHash(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	key, err := scanAPIKey(s.pool.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE hash = $1`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("api key: %w", domain.ErrAPIKeyNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *APIKeyStore) ListAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.APIKey, error) {
+		return scanAPIKey(row)
+	})
+}
+
+func (s *APIKeyStore) RevokeAPIKey(ctx context.Context, id int64, at time.Time) (*domain.APIKey, error) {
+	key, err := scanAPIKey(s.pool.QueryRow(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL RETURNING `+apiKeyColumns,
+		id, at,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either there is no such key or it is already revoked.
+		if _, err := s.GetAPIKey(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyRevoked)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RotateAPIKey locks the old key so that a concurrent revocation either
+// happens first and fails the rotation or waits for it. An old key that
+// already expires before expiresAt keeps its expiry.
+func (s *APIKeyStore) RotateAPIKey(ctx context.Context, id int64, expiresAt time.Time, replacement domain.APIKey) (*domain.APIKey, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	old, err := getAPIKey(ctx, tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if !old.RevokedAt.IsZero() {
+		return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyRevoked)
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE api_keys SET expires_at = least(coalesce(expires_at, $2), $2) WHERE id = $1`,
+		id, expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	created, err := insertAPIKey(ctx, tx, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func insertAPIKey(ctx context.Context, q querier, key domain.APIKey) (*domain.APIKey, error) {
+	err := q.QueryRow(ctx,
+		`INSERT INTO api_keys (principal, scopes, hint, hash, created_by, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		key.Principal, scopeArray(key.Scopes), key.Hint, key.Hash, key.CreatedBy, key.CreatedAt, nullTime(key.ExpiresAt),
+	).Scan(&key.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// getAPIKey reads the key with the id, appending lock to the query.
+func getAPIKey(ctx context.Context, q querier, id int64, lock string) (*domain.APIKey, error) {
+	key, err := scanAPIKey(q.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 `+lock, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func scanAPIKey(row pgx.Row) (domain.APIKey, error) {
+	var (
+		key                  domain.APIKey
+		scopes               []string
+		expiresAt, revokedAt *time.Time
+	)
+	err := row.Scan(&key.ID, &key.Principal, &scopes, &key.Hint, &key.Hash, &key.CreatedBy, &key.CreatedAt,
+		&expiresAt, &revokedAt)
+	if err != nil {
+		return key, err
+	}
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, domain.Scope(scope))
+	}
+	if expiresAt != nil {
+		key.ExpiresAt = *expiresAt
+	}
+	if revokedAt != nil {
+		key.RevokedAt = *revokedAt
+	}
+	return key, nil
+}
+
+func scopeArray(scopes []domain.Scope) []string {
+	out := make([]string, len(scopes))
+	for i, scope := range scopes {
+		out[i] = string(scope)
+	}
+	return out
+}
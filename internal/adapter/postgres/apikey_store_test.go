@@ -0,0 +1,63 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewAPIKeyStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	key, err := store.CreateAPIKey(ctx, domain.APIKey{
+		Principal: "checkout-team", Scopes: []domain.Scope{domain.ScopeFlagsWrite}, Hint: "ffk_0123abcd",
+		Hash: domain.HashAPIKey("ffk_0123abcd-one"), CreatedBy: "alice", CreatedAt: now,
+	})
+	require.NoError(t, err)
+	got, err := store.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *key, *got)
+	byHash, err := store.GetAPIKeyByHash(ctx, domain.HashAPIKey("ffk_0123abcd-one"))
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, byHash.ID)
+	_, err = store.GetAPIKeyByHash(ctx, domain.HashAPIKey("ffk_0123abcd-two"))
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	grace := now.Add(time.Hour)
+	replacement, err := store.RotateAPIKey(ctx, key.ID, grace, domain.APIKey{
+		Principal: "checkout-team", Scopes: []domain.Scope{domain.ScopeFlagsWrite}, Hint: "ffk_4567efgh",
+		Hash: domain.HashAPIKey("ffk_4567efgh-one"), CreatedBy: "alice", CreatedAt: now,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, key.ID, replacement.ID)
+	got, err = store.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, grace, got.ExpiresAt, "the old key works until the grace period ends")
+
+	revoked, err := store.RevokeAPIKey(ctx, key.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, revoked.RevokedAt)
+	_, err = store.RevokeAPIKey(ctx, key.ID, now)
+	require.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+	_, err = store.RotateAPIKey(ctx, key.ID, grace, domain.APIKey{Hash: domain.HashAPIKey("ffk_89abijkl-one")})
+	require.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+	_, err = store.RevokeAPIKey(ctx, replacement.ID+1, now)
+	require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+
+	all, err := store.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
@@ -0,0 +1,261 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// approvalSchema holds flag protections and the change requests made to
+// protected flags. A flag without a flag_protection row is unprotected. Each
+// approval is a row of change_request_approvals, so a principal approves a
+// request at most once.
+const approvalSchema = `
+CREATE TABLE IF NOT EXISTS flag_protection (
+    project            TEXT    NOT NULL,
+    flag_name          TEXT    NOT NULL,
+    required_approvals INTEGER NOT NULL CHECK (required_approvals > 0),
+    environments       TEXT[]  NOT NULL DEFAULT '{}',
+    PRIMARY KEY (project, flag_name),
+    FOREIGN KEY (project, flag_name) REFERENCES flags (project, name)
+);
+CREATE TABLE IF NOT EXISTS change_requests (
+    id                 BIGSERIAL PRIMARY KEY,
+    project            TEXT             NOT NULL,
+    flag_name          TEXT             NOT NULL,
+    environment        TEXT             NOT NULL REFERENCES environments (key),
+    bool_value         BOOLEAN,
+    numeric_value      DOUBLE PRECISION,
+    status             TEXT             NOT NULL,
+    requested_by       TEXT             NOT NULL,
+    required_approvals INTEGER          NOT NULL,
+    decided_by         TEXT             NOT NULL DEFAULT '',
+    created_at         TIMESTAMPTZ      NOT NULL,
+    expires_at         TIMESTAMPTZ      NOT NULL,
+    decided_at         TIMESTAMPTZ,
+    FOREIGN KEY (project, flag_name) REFERENCES flags (project, name)
+);
+CREATE INDEX IF NOT EXISTS change_requests_flag ON change_requests (project, flag_name, id);
+CREATE TABLE IF NOT EXISTS change_request_approvals (
+    change_request_id BIGINT      NOT NULL REFERENCES change_requests (id),
+    approver          TEXT        NOT NULL,
+    approved_at       TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (change_request_id, approver)
+);`
+
+// changeRequestColumns selects a change request with its approvals, in the
+// order they were given, as parallel arrays.
+const changeRequestColumns = `c.id, c.project, c.flag_name, c.environment, c.bool_value, c.numeric_value, c.status, c.requested_by,
+    c.required_approvals, c.decided_by, c.created_at, c.expires_at, c.decided_at,
+    ARRAY(SELECT a.approver FROM change_request_approvals a WHERE a.change_request_id = c.id ORDER BY a.approved_at, a.approver),
+    ARRAY(SELECT a.approved_at FROM change_request_approvals a WHERE a.change_request_id = c.id ORDER BY a.approved_at, a.approver)`
+
+func (s *FlagStore) GetProtection(ctx context.Context, project, name string) (*domain.FlagProtection, error) {
+	protection := domain.FlagProtection{Project: project, FlagName: name, Environments: []string{}}
+	err := s.pool.QueryRow(ctx,
+		`SELECT COALESCE(p.required_approvals, 0), COALESCE(p.environments, '{}')
+		 FROM flags f LEFT JOIN flag_protection p ON p.project = f.project AND p.flag_name = f.name
+		 WHERE f.project = $1 AND f.name = $2`,
+		project, name,
+	).Scan(&protection.RequiredApprovals, &protection.Environments)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("flag %q: %w", name, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &protection, nil
+}
+
+func (s *FlagStore) ListProtections(ctx context.Context, project string) ([]domain.FlagProtection, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT project, flag_name, required_approvals, environments FROM flag_protection
+		 WHERE project = $1
+		 ORDER BY flag_name`,
+		project,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.FlagProtection, error) {
+		var p domain.FlagProtection
+		err := row.Scan(&p.Project, &p.FlagName, &p.RequiredApprovals, &p.Environments)
+		return p, err
+	})
+}
+
+func (s *FlagStore) SetProtection(ctx context.Context, protection domain.FlagProtection) error {
+	if _, err := s.GetProtection(ctx, protection.Project, protection.FlagName); err != nil {
+		return err
+	}
+	if protection.RequiredApprovals == 0 {
+		_, err := s.pool.Exec(ctx,
+			`DELETE FROM flag_protection WHERE project = $1 AND flag_name = $2`,
+			protection.Project, protection.FlagName,
+		)
+		return err
+	}
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO flag_protection (project, flag_name, required_approvals, environments)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (project, flag_name) DO UPDATE
+		 SET required_approvals = EXCLUDED.required_approvals, environments = EXCLUDED.environments`,
+		protection.Project, protection.FlagName, protection.RequiredApprovals, textArray(protection.Environments),
+	)
+	return err
+}
+
+func (s *FlagStore) CreateChangeRequest(ctx context.Context, cr domain.ChangeRequest) (*domain.ChangeRequest, error) {
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO change_requests (project, flag_name, environment, bool_value, numeric_value, status, requested_by,
+		     required_approvals, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING id`,
+		cr.Project, cr.FlagName, cr.Environment, cr.Value.Bool, cr.Value.Numeric, string(cr.Status), cr.RequestedBy,
+		cr.RequiredApprovals, cr.CreatedAt, cr.ExpiresAt,
+	).Scan(&cr.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+func (s *FlagStore) GetChangeRequest(ctx context.Context, project string, id int64) (*domain.ChangeRequest, error) {
+	return getChangeRequest(ctx, s.pool, project, id, "")
+}
+
+// ListChangeRequests matches the status as of q.At, so pending requests past
+// their expiry are listed as expired.
+func (s *FlagStore) ListChangeRequests(ctx context.Context, q domain.ChangeRequestQuery) ([]domain.ChangeRequest, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+changeRequestColumns+` FROM change_requests c
+		 WHERE c.project = $1 AND ($2 = '' OR c.flag_name = $2)
+		   AND ($3 = '' OR CASE WHEN c.status = 'pending' AND c.expires_at <= $4 THEN 'expired' ELSE c.status END = $3)
+		 ORDER BY c.id DESC`,
+		q.Project, q.FlagName, string(q.Status), q.At,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.ChangeRequest, error) {
+		return scanChangeRequest(row)
+	})
+}
+
+// ApproveChangeRequest locks the request, so concurrent approvals are decided
+// one after the other and only the one that completes it applies the value.
+func (s *FlagStore) ApproveChangeRequest(ctx context.Context, project string, id int64, approver string, at time.Time) (*domain.ChangeRequest, *domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	cr, err := getChangeRequest(ctx, tx, project, id, "FOR UPDATE OF c")
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := cr.Approve(approver, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO change_request_approvals (change_request_id, approver, approved_at) VALUES ($1, $2, $3)`,
+		id, approver, at,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	var flag *domain.Flag
+	if applied {
+		if err := decideChangeRequest(ctx, tx, *cr); err != nil {
+			return nil, nil, err
+		}
+		flag, err = updateState(ctx, tx, domain.AuditApplyChange, cr.Project, cr.Environment, cr.FlagName, at,
+			`bool_value = $5, numeric_value = $6`, cr.Value.Bool, cr.Value.Numeric,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return cr, flag, nil
+}
+
+func (s *FlagStore) RejectChangeRequest(ctx context.Context, project string, id int64, reviewer string, at time.Time) (*domain.ChangeRequest, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	cr, err := getChangeRequest(ctx, tx, project, id, "FOR UPDATE OF c")
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.Reject(reviewer, at); err != nil {
+		return nil, err
+	}
+	if err := decideChangeRequest(ctx, tx, *cr); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// getChangeRequest reads the request, applying lock, a locking clause or
+// empty, to its row.
+func getChangeRequest(ctx context.Context, q querier, project string, id int64, lock string) (*domain.ChangeRequest, error) {
+	cr, err := scanChangeRequest(q.QueryRow(ctx,
+		`SELECT `+changeRequestColumns+` FROM change_requests c WHERE c.project = $1 AND c.id = $2 `+lock,
+		project, id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("change request %d: %w", id, domain.ErrChangeRequestNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// decideChangeRequest stores the status and decision of cr.
+func decideChangeRequest(ctx context.Context, q querier, cr domain.ChangeRequest) error {
+	_, err := q.Exec(ctx,
+		`UPDATE change_requests SET status = $2, decided_by = $3, decided_at = $4 WHERE id = $1`,
+		cr.ID, string(cr.Status), cr.DecidedBy, cr.DecidedAt,
+	)
+	return err
+}
+
+func scanChangeRequest(row pgx.Row) (domain.ChangeRequest, error) {
+	var (
+		cr          domain.ChangeRequest
+		status      string
+		decidedAt   *time.Time
+		approvers   []string
+		approvedAts []time.Time
+	)
+	err := row.Scan(&cr.ID, &cr.Project, &cr.FlagName, &cr.Environment, &cr.Value.Bool, &cr.Value.Numeric, &status,
+		&cr.RequestedBy, &cr.RequiredApprovals, &cr.DecidedBy, &cr.CreatedAt, &cr.ExpiresAt, &decidedAt,
+		&approvers, &approvedAts)
+	if err != nil {
+		return cr, err
+	}
+	cr.Status = domain.ChangeRequestStatus(status)
+	if decidedAt != nil {
+		cr.DecidedAt = *decidedAt
+	}
+	for i, approver := range approvers {
+		cr.Approvals = append(cr.Approvals, domain.Approval{Approver: approver, ApprovedAt: approvedAts[i]})
+	}
+	return cr, nil
+}
@@ -0,0 +1,142 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestFlagStore_Protection(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFlagStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	off := false
+	now := time.Now().UTC()
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	protection, err := store.GetProtection(ctx, domain.DefaultProject, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, protection.Covers(domain.DefaultEnvironment), "flags start unprotected")
+
+	want := domain.FlagProtection{Project: domain.DefaultProject, FlagName: "new-checkout", RequiredApprovals: 2, Environments: []string{domain.DefaultEnvironment}}
+	require.NoError(t, store.SetProtection(ctx, want))
+	protection, err = store.GetProtection(ctx, domain.DefaultProject, "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, want, *protection)
+	protections, err := store.ListProtections(ctx, domain.DefaultProject)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.FlagProtection{want}, protections)
+
+	require.NoError(t, store.SetProtection(ctx, domain.FlagProtection{Project: domain.DefaultProject, FlagName: "new-checkout"}))
+	protection, err = store.GetProtection(ctx, domain.DefaultProject, "new-checkout")
+	require.NoError(t, err)
+	assert.Zero(t, protection.RequiredApprovals)
+	protections, err = store.ListProtections(ctx, domain.DefaultProject)
+	require.NoError(t, err)
+	assert.Empty(t, protections)
+
+	_, err = store.GetProtection(ctx, domain.DefaultProject, "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	err = store.SetProtection(ctx, domain.FlagProtection{Project: domain.DefaultProject, FlagName: "ghost", RequiredApprovals: 1})
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagStore_ChangeRequests(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFlagStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	request := func(required int) *domain.ChangeRequest {
+		cr, err := store.CreateChangeRequest(ctx, domain.ChangeRequest{
+			Project: domain.DefaultProject, FlagName: "new-checkout", Environment: domain.DefaultEnvironment,
+			Value: domain.FlagValue{Bool: &on}, Status: domain.ChangeRequestPending, RequestedBy: "alice",
+			RequiredApprovals: required, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		return cr
+	}
+
+	rejected := request(1)
+	cr, err := store.RejectChangeRequest(ctx, domain.DefaultProject, rejected.ID, "bob", now)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ChangeRequestRejected, cr.Status)
+	_, _, err = store.ApproveChangeRequest(ctx, domain.DefaultProject, rejected.ID, "bob", now)
+	require.ErrorIs(t, err, domain.ErrChangeRequestState)
+
+	pending := request(2)
+	_, flag, err := store.ApproveChangeRequest(ctx, domain.DefaultProject, pending.ID, "alice", now)
+	require.ErrorIs(t, err, domain.ErrApprovalNotAllowed)
+	assert.Nil(t, flag)
+
+	// Concurrent approvals are serialized: exactly one of them applies the
+	// change.
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+	)
+	for _, approver := range []string{"bob", "carol", "dave"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, flag, err := store.ApproveChangeRequest(ctx, domain.DefaultProject, pending.ID, approver, now.Add(time.Minute))
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil && flag != nil {
+				applied++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, applied)
+
+	got, err := store.GetChangeRequest(ctx, domain.DefaultProject, pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ChangeRequestApplied, got.Status)
+	assert.Len(t, got.Approvals, 2)
+	current, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *current.Value.Bool)
+	assert.Equal(t, int64(2), current.Version)
+	entries, err := store.ListAudit(ctx, domain.AuditQuery{Action: domain.AuditApplyChange, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	request(1)
+	expired, err := store.ListChangeRequests(ctx, domain.ChangeRequestQuery{
+		Project: domain.DefaultProject, Status: domain.ChangeRequestExpired, At: now.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Len(t, expired, 1)
+	all, err := store.ListChangeRequests(ctx, domain.ChangeRequestQuery{Project: domain.DefaultProject, FlagName: "new-checkout", At: now})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Greater(t, all[0].ID, all[1].ID, "newest first")
+
+	_, err = store.GetChangeRequest(ctx, "payments", pending.ID)
+	require.ErrorIs(t, err, domain.ErrChangeRequestNotFound)
+}
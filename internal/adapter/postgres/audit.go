@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// auditSchema holds the audit log and its signed checkpoints. Entries are
+// written in the transaction of the change they record, each with the hash
+// chaining it to the entry before, and a trigger rejects any statement that
+// would rewrite or remove entries or checkpoints.
+const auditSchema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+    id          BIGSERIAL PRIMARY KEY,
+    actor       TEXT        NOT NULL,
+    action      TEXT        NOT NULL,
+    project     TEXT        NOT NULL,
+    environment TEXT        NOT NULL,
+    flag_name   TEXT        NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    request_id  TEXT        NOT NULL DEFAULT '',
+    emergency   TEXT        NOT NULL DEFAULT '',
+    recorded_at TIMESTAMPTZ NOT NULL,
+    prev_hash   BYTEA,
+    hash        BYTEA       NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_flag ON audit_log (project, flag_name, id);
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END
+$$;
+CREATE OR REPLACE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id         BIGSERIAL PRIMARY KEY,
+    entry_id   BIGINT      NOT NULL REFERENCES audit_log (id),
+    hash       BYTEA       NOT NULL,
+    signature  BYTEA       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE OR REPLACE TRIGGER audit_checkpoints_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();`
+
+const auditColumns = `id, actor, action, project, environment, flag_name, before, after, request_id, emergency, recorded_at, prev_hash, hash`
+
+const checkpointColumns = `id, entry_id, hash, signature, created_at`
+
+// recordAudit appends the change of the flag in env from before to after,
+// attributed to the actor, request and emergency override of ctx, chained to the latest entry,
+// and writes its event to the outbox. Appends are serialized by a
+// transaction-scoped advisory lock so that no two entries chain to the same
+// predecessor. The hash covers the snapshots as
+// they read back from JSONB, so that verification computes the same one.
+func recordAudit(ctx context.Context, q querier, action domain.AuditAction, env string, before, after *domain.Flag, at time.Time) error {
+	flag := after
+	if flag == nil {
+		flag = before
+	}
+	rawBefore, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+	rawAfter, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+	entry := domain.AuditEntry{
+		Actor:       port.ActorFrom(ctx),
+		Action:      action,
+		Project:     flag.Project,
+		Environment: env,
+		FlagName:    flag.Name,
+		RequestID:   port.RequestIDFrom(ctx),
+		Emergency:   port.EmergencyFrom(ctx),
+		RecordedAt:  at.UTC().Truncate(time.Microsecond),
+	}
+	if entry.Before, err = unmarshalSnapshot(rawBefore); err != nil {
+		return err
+	}
+	if entry.After, err = unmarshalSnapshot(rawAfter); err != nil {
+		return err
+	}
+
+	if _, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_log'))`); err != nil {
+		return err
+	}
+	err = q.QueryRow(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if entry.Hash, err = domain.AuditHash(entry.PrevHash, entry); err != nil {
+		return err
+	}
+	_, err = q.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, project, environment, flag_name, before, after, request_id, emergency, recorded_at,
+		     prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		entry.Actor, string(action), entry.Project, env, entry.FlagName, rawBefore, rawAfter, entry.RequestID, entry.Emergency,
+		entry.RecordedAt, entry.PrevHash, entry.Hash,
+	)
+	if err != nil {
+		return err
+	}
+	return recordEvent(ctx, q, entry, flag.Version)
+}
+
+func marshalSnapshot(flag *domain.Flag) ([]byte, error) {
+	if flag == nil {
+		return nil, nil
+	}
+	return flagjson.MarshalFlag(*flag)
+}
+
+func (s *FlagStore) ListAudit(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+auditColumns+` FROM audit_log
+		 WHERE ($1 = '' OR project = $1) AND ($2 = '' OR environment = $2) AND ($3 = '' OR flag_name = $3)
+		   AND ($4 = '' OR actor = $4) AND ($5 = '' OR action = $5)
+		   AND ($6::timestamptz IS NULL OR recorded_at >= $6) AND ($7::timestamptz IS NULL OR recorded_at < $7)
+		 ORDER BY id DESC
+		 LIMIT $8`,
+		q.Project, q.Environment, q.FlagName, q.Actor, string(q.Action), nullTime(q.Since), nullTime(q.Until), q.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectAudit(rows)
+}
+
+// ListAuditChain reads entries without a transaction: an entry is committed
+// with its predecessor already committed, since appends are serialized.
+func (s *FlagStore) ListAuditChain(ctx context.Context, afterID int64, limit int) ([]domain.AuditEntry, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+auditColumns+` FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`,
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectAudit(rows)
+}
+
+func (s *FlagStore) CreateAuditCheckpoint(ctx context.Context, cp domain.AuditCheckpoint) (*domain.AuditCheckpoint, error) {
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO audit_checkpoints (entry_id, hash, signature, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		cp.EntryID, cp.Hash, cp.Signature, cp.CreatedAt,
+	).Scan(&cp.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *FlagStore) LastAuditCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error) {
+	cp, err := scanCheckpoint(s.pool.QueryRow(ctx, `SELECT `+checkpointColumns+` FROM audit_checkpoints ORDER BY id DESC LIMIT 1`))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func (s *FlagStore) ListAuditCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+checkpointColumns+` FROM audit_checkpoints ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AuditCheckpoint, error) {
+		return scanCheckpoint(row)
+	})
+}
+
+func collectAudit(rows pgx.Rows) ([]domain.AuditEntry, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AuditEntry, error) {
+		var (
+			entry     domain.AuditEntry
+			action    string
+			rawBefore []byte
+			rawAfter  []byte
+		)
+		err := row.Scan(&entry.ID, &entry.Actor, &action, &entry.Project, &entry.Environment, &entry.FlagName,
+			&rawBefore, &rawAfter, &entry.RequestID, &entry.Emergency, &entry.RecordedAt, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			return entry, err
+		}
+		entry.Action = domain.AuditAction(action)
+		if entry.Before, err = unmarshalSnapshot(rawBefore); err != nil {
+			return entry, err
+		}
+		entry.After, err = unmarshalSnapshot(rawAfter)
+		return entry, err
+	})
+}
+
+func scanCheckpoint(row pgx.Row) (domain.AuditCheckpoint, error) {
+	var cp domain.AuditCheckpoint
+	err := row.Scan(&cp.ID, &cp.EntryID, &cp.Hash, &cp.Signature, &cp.CreatedAt)
+	return cp, err
+}
+
+func unmarshalSnapshot(raw []byte) (*domain.Flag, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	return flagjson.UnmarshalFlag(raw)
+}
+
+// nullTime maps the zero time to NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
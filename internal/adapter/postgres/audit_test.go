@@ -0,0 +1,145 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestFlagStore_Audit(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFlagStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	alice := port.WithRequestID(port.WithActor(ctx, "alice"), "req-1")
+	require.NoError(t, store.Create(alice, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	_, err := store.UpdateValue(port.WithActor(ctx, "bob"), domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	_, err = store.UpdateValue(port.WithActor(ctx, "bob"), domain.DefaultProject, domain.DefaultEnvironment, "ghost", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	entries, err := store.ListAudit(ctx, domain.AuditQuery{FlagName: "new-checkout", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "failed changes are not audited")
+
+	update := entries[0]
+	assert.Equal(t, "bob", update.Actor)
+	assert.Equal(t, domain.AuditUpdateValue, update.Action)
+	assert.Equal(t, domain.DefaultEnvironment, update.Environment)
+	assert.Empty(t, update.RequestID)
+	require.NotNil(t, update.Before)
+	assert.False(t, *update.Before.Value.Bool)
+	assert.True(t, *update.After.Value.Bool)
+	assert.Equal(t, int64(2), update.After.Version)
+
+	create := entries[1]
+	assert.Equal(t, "alice", create.Actor)
+	assert.Equal(t, domain.AuditCreate, create.Action)
+	assert.Equal(t, "req-1", create.RequestID)
+	assert.Nil(t, create.Before)
+	assert.Equal(t, "new-checkout", create.After.Name)
+
+	filtered, err := store.ListAudit(ctx, domain.AuditQuery{Actor: "alice", Action: domain.AuditCreate, Since: now, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, create.ID, filtered[0].ID)
+	limited, err := store.ListAudit(ctx, domain.AuditQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, update.ID, limited[0].ID)
+
+	_, err = pool.Exec(ctx, `UPDATE audit_log SET actor = 'mallory'`)
+	require.Error(t, err, "the audit log is append-only")
+	_, err = pool.Exec(ctx, `DELETE FROM audit_log`)
+	require.Error(t, err)
+}
+
+func TestFlagStore_AuditChain(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFlagStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	off := false
+	now := time.Now().UTC()
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	for i := range 3 {
+		value := i%2 == 0
+		_, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &value}, time.Now().UTC())
+		require.NoError(t, err)
+	}
+
+	entries, err := store.ListAuditChain(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Nil(t, entries[0].PrevHash)
+	chain := domain.NewAuditChain(nil, nil)
+	for i, entry := range entries {
+		if i > 0 {
+			assert.Equal(t, entries[i-1].Hash, entry.PrevHash)
+		}
+		brk, err := chain.Next(entry)
+		require.NoError(t, err)
+		assert.Nil(t, brk, "entry %d", entry.ID)
+	}
+	page, err := store.ListAuditChain(ctx, entries[1].ID, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, entries[2].ID, page[0].ID)
+
+	last, err := store.LastAuditCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, last)
+	cp, err := store.CreateAuditCheckpoint(ctx, domain.AuditCheckpoint{
+		EntryID: entries[3].ID, Hash: entries[3].Hash, Signature: []byte("sig"), CreatedAt: now.Truncate(time.Microsecond),
+	})
+	require.NoError(t, err)
+	last, err = store.LastAuditCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, cp.ID, last.ID)
+	assert.Equal(t, entries[3].Hash, last.Hash)
+	checkpoints, err := store.ListAuditCheckpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	_, err = pool.Exec(ctx, `DELETE FROM audit_checkpoints`)
+	require.Error(t, err, "checkpoints are append-only")
+
+	// Rewriting an entry around the trigger breaks the chain.
+	_, err = pool.Exec(ctx, `ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only`)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `UPDATE audit_log SET actor = 'mallory' WHERE id = $1`, entries[2].ID)
+	require.NoError(t, err)
+	tampered, err := store.ListAuditChain(ctx, 0, 10)
+	require.NoError(t, err)
+	chain = domain.NewAuditChain(nil, nil)
+	var brk *domain.AuditBreak
+	for _, entry := range tampered {
+		if brk, err = chain.Next(entry); brk != nil || err != nil {
+			break
+		}
+	}
+	require.NoError(t, err)
+	require.NotNil(t, brk)
+	assert.Equal(t, entries[2].ID, brk.EntryID)
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+const eventSchema = `
+CREATE TABLE IF NOT EXISTS exposure_events (
+    id             BIGSERIAL PRIMARY KEY,
+    experiment_key TEXT        NOT NULL,
+    flag_name      TEXT        NOT NULL,
+    variant        TEXT        NOT NULL,
+    targeting_key  TEXT        NOT NULL,
+    exposed_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS exposure_events_experiment
+    ON exposure_events (experiment_key, exposed_at);
+CREATE TABLE IF NOT EXISTS metric_events (
+    id            BIGSERIAL PRIMARY KEY,
+    metric        TEXT             NOT NULL,
+    targeting_key TEXT             NOT NULL,
+    value         DOUBLE PRECISION NOT NULL,
+    occurred_at   TIMESTAMPTZ      NOT NULL
+);
+CREATE INDEX IF NOT EXISTS metric_events_metric_key
+    ON metric_events (metric, targeting_key, occurred_at);`
+
+// EventStore appends experiment events. Events are immutable once written.
+type EventStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventStore(pool *pgxpool.Pool) *EventStore {
+	return &EventStore{pool: pool}
+}
+
+func (s *EventStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, eventSchema)
+	return err
+}
+
+func (s *EventStore) RecordMetrics(ctx context.Context, events []domain.MetricEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := s.pool.CopyFrom(ctx,
+		pgx.Identifier{"metric_events"},
+		[]string{"metric", "targeting_key", "value", "occurred_at"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			e := events[i]
+			return []any{e.Metric, e.TargetingKey, e.Value, e.OccurredAt}, nil
+		}),
+	)
+	return err
+}
+
+// VariantStats attributes each exposed user to the variant of their first
+// exposure and sums their events of metric from that moment on.
+func (s *EventStore) VariantStats(ctx context.Context, experimentKey, metric string) ([]domain.VariantStats, error) {
+	rows, err := s.pool.Query(ctx,
+		`WITH exposed AS (
+		     SELECT DISTINCT ON (targeting_key) targeting_key, variant, exposed_at
+		     FROM exposure_events
+		     WHERE experiment_key = $1
+		     ORDER BY targeting_key, exposed_at
+		 ), per_user AS (
+		     SELECT e.variant,
+		            COUNT(m.id) > 0          AS converted,
+		            COALESCE(SUM(m.value), 0) AS total
+		     FROM exposed e
+		     LEFT JOIN metric_events m
+		            ON m.targeting_key = e.targeting_key
+		           AND m.metric = $2
+		           AND m.occurred_at >= e.exposed_at
+		     GROUP BY e.variant, e.targeting_key
+		 )
+		 SELECT variant,
+		        COUNT(*),
+		        COUNT(*) FILTER (WHERE converted),
+		        COALESCE(SUM(total), 0),
+		        COALESCE(SUM(total * total), 0)
+		 FROM per_user
+		 GROUP BY variant
+		 ORDER BY variant`,
+		experimentKey, metric,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.VariantStats, error) {
+		var st domain.VariantStats
+		err := row.Scan(&st.Variant, &st.Users, &st.Conversions, &st.Sum, &st.SumSquares)
+		return st, err
+	})
+}
+
+// RecordExposures writes the events with the COPY protocol, so a bulk
+// evaluation that exposes many flags costs one round trip.
+func (s *EventStore) RecordExposures(ctx context.Context, events []domain.ExposureEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := s.pool.CopyFrom(ctx,
+		pgx.Identifier{"exposure_events"},
+		[]string{"experiment_key", "flag_name", "variant", "targeting_key", "exposed_at"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			e := events[i]
+			return []any{e.ExperimentKey, e.FlagName, e.Variant, e.TargetingKey, e.ExposedAt}, nil
+		}),
+	)
+	return err
+}
@@ -0,0 +1,381 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// experimentSchema depends on the flag tables and must be created after them.
+// An experiment runs on a flag of a project in one environment; the partial
+// unique index allows at most one running experiment per flag and environment.
+// Bucket ranges within a layer are kept disjoint by Create, which serialises on
+// the layer row. experiment_weights is the append-only history of the weights
+// bandit experiments ran with.
+const experimentSchema = `
+CREATE TABLE IF NOT EXISTS layers (
+    key         TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS experiments (
+    key          TEXT PRIMARY KEY,
+    project      TEXT        NOT NULL,
+    flag_name    TEXT        NOT NULL,
+    environment  TEXT        NOT NULL REFERENCES environments (key),
+    allocation   INTEGER     NOT NULL CHECK (allocation BETWEEN 1 AND 100),
+    variants     JSONB       NOT NULL,
+    layer        TEXT        REFERENCES layers (key),
+    layer_offset INTEGER     NOT NULL DEFAULT 0 CHECK (layer_offset >= 0 AND layer_offset + allocation <= 100),
+    holdout      INTEGER     NOT NULL DEFAULT 0 CHECK (holdout BETWEEN 0 AND 99),
+    bandit       JSONB,
+    status       TEXT        NOT NULL CHECK (status IN ('draft', 'running', 'stopped')),
+    started_at   TIMESTAMPTZ,
+    stopped_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (project, flag_name) REFERENCES flags (project, name)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS experiments_one_running_per_flag
+    ON experiments (project, flag_name, environment) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS experiments_layer ON experiments (layer) WHERE layer IS NOT NULL;
+CREATE TABLE IF NOT EXISTS experiment_weights (
+    id             BIGSERIAL PRIMARY KEY,
+    experiment_key TEXT        NOT NULL REFERENCES experiments (key),
+    variants       JSONB       NOT NULL,
+    changed_at     TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS experiment_weights_experiment
+    ON experiment_weights (experiment_key, id);`
+
+const experimentColumns = `key, project, flag_name, environment, allocation, variants, COALESCE(layer, ''), layer_offset, holdout, bandit, status, started_at, stopped_at, created_at, updated_at`
+
+const layerColumns = `key, description, created_at`
+
+type ExperimentStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewExperimentStore(pool *pgxpool.Pool) *ExperimentStore {
+	return &ExperimentStore{pool: pool}
+}
+
+func (s *ExperimentStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, moveLegacyExperiments+experimentSchema)
+	return err
+}
+
+// Create inserts the experiment. For an experiment in a layer, the layer row is
+// locked while the free range is chosen so concurrent creates cannot overlap.
+func (s *ExperimentStore) Create(ctx context.Context, exp domain.Experiment) (*domain.Experiment, error) {
+	variants, err := flagjson.MarshalVariantWeights(exp.Variants)
+	if err != nil {
+		return nil, err
+	}
+	bandit, err := flagjson.MarshalBandit(exp.Bandit)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var layer *string
+	if exp.Layer != "" {
+		layer = &exp.Layer
+		if exp.LayerOffset, err = placeInLayer(ctx, tx, exp); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO experiments (key, project, flag_name, environment, allocation, variants, layer, layer_offset, holdout, bandit, status, started_at, stopped_at, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		exp.Key, exp.Project, exp.FlagName, exp.Environment, exp.Allocation, variants, layer, exp.LayerOffset, exp.Holdout, bandit,
+		string(exp.Status), exp.StartedAt, exp.StoppedAt, exp.CreatedAt, exp.UpdatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return nil, domain.ErrExperimentExists
+			case "23503":
+				return nil, domain.ErrNotFound
+			}
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+// placeInLayer locks the experiment's layer and returns the lowest offset at
+// which its allocation fits beside the layer's live members.
+func placeInLayer(ctx context.Context, tx pgx.Tx, exp domain.Experiment) (int, error) {
+	var key string
+	err := tx.QueryRow(ctx, `SELECT key FROM layers WHERE key = $1 FOR UPDATE`, exp.Layer).Scan(&key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("layer %q: %w", exp.Layer, domain.ErrLayerNotFound)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT `+experimentColumns+` FROM experiments WHERE layer = $1 AND status <> 'stopped'`,
+		exp.Layer,
+	)
+	if err != nil {
+		return 0, err
+	}
+	members, err := collectExperiments(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, ok := domain.PlaceInLayer(members, exp.Allocation)
+	if !ok {
+		return 0, fmt.Errorf("layer %q: %w", exp.Layer, domain.ErrLayerFull)
+	}
+	return offset, nil
+}
+
+func (s *ExperimentStore) GetByKey(ctx context.Context, key string) (*domain.Experiment, error) {
+	row := s.pool.QueryRow(ctx,
+		`SELECT `+experimentColumns+` FROM experiments WHERE key = $1`,
+		key,
+	)
+	return scanExperiment(row)
+}
+
+func (s *ExperimentStore) List(ctx context.Context, filter port.ExperimentFilter) ([]domain.Experiment, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+experimentColumns+` FROM experiments
+		 WHERE ($1 = '' OR flag_name = $1) AND ($2 = '' OR layer = $2) AND ($3 = '' OR status = $3)
+		   AND ($4 = '' OR environment = $4) AND ($5 = '' OR project = $5)
+		 ORDER BY created_at DESC, key`,
+		filter.FlagName, filter.Layer, filter.Status, filter.Environment, filter.Project,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectExperiments(rows)
+}
+
+func collectExperiments(rows pgx.Rows) ([]domain.Experiment, error) {
+	defer rows.Close()
+
+	var experiments []domain.Experiment
+	for rows.Next() {
+		exp, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, *exp)
+	}
+	return experiments, rows.Err()
+}
+
+// Start marks the experiment running under the given holdout and attaches it
+// to its flag in one transaction.
+func (s *ExperimentStore) Start(ctx context.Context, key string, at time.Time, holdout int) (*domain.Experiment, *domain.Flag, error) {
+	return s.transition(ctx, domain.AuditStartExperiment, key, at,
+		`UPDATE experiments
+		 SET status = 'running', started_at = $2, updated_at = $2, holdout = $3
+		 WHERE key = $1 AND status = 'draft'
+		 RETURNING `+experimentColumns,
+		true, holdout,
+	)
+}
+
+// Stop marks the experiment stopped and detaches it from its flag in one
+// transaction.
+func (s *ExperimentStore) Stop(ctx context.Context, key string, at time.Time) (*domain.Experiment, *domain.Flag, error) {
+	return s.transition(ctx, domain.AuditStopExperiment, key, at,
+		`UPDATE experiments
+		 SET status = 'stopped', stopped_at = $2, updated_at = $2
+		 WHERE key = $1 AND status = 'running'
+		 RETURNING `+experimentColumns,
+		false,
+	)
+}
+
+// UpdateWeights replaces a running experiment's weights and refreshes the
+// snapshot on its flag in one transaction.
+func (s *ExperimentStore) UpdateWeights(ctx context.Context, key string, weights []domain.VariantWeight, at time.Time) (*domain.Experiment, *domain.Flag, error) {
+	raw, err := flagjson.MarshalVariantWeights(weights)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.transition(ctx, domain.AuditUpdateWeights, key, at,
+		`UPDATE experiments
+		 SET variants = $3, updated_at = $2
+		 WHERE key = $1 AND status = 'running'
+		 RETURNING `+experimentColumns,
+		true, raw,
+	)
+}
+
+func (s *ExperimentStore) WeightHistory(ctx context.Context, key string) ([]domain.WeightChange, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT variants, changed_at FROM experiment_weights
+		 WHERE experiment_key = $1
+		 ORDER BY id`,
+		key,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.WeightChange, error) {
+		change := domain.WeightChange{ExperimentKey: key}
+		var raw []byte
+		if err := row.Scan(&raw, &change.ChangedAt); err != nil {
+			return change, err
+		}
+		var err error
+		change.Variants, err = flagjson.UnmarshalVariantWeights(raw)
+		return change, err
+	})
+}
+
+// transition runs the guarded update query with key, at and any extra
+// arguments, then attaches the experiment to or detaches it from its flag in
+// the experiment's environment, audited as action.
+// Attaching a bandit experiment also records its weights in the history.
+func (s *ExperimentStore) transition(ctx context.Context, action domain.AuditAction, key string, at time.Time, query string, attach bool, args ...any) (*domain.Experiment, *domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	exp, err := scanExperiment(tx.QueryRow(ctx, query, append([]any{key, at}, args...)...))
+	if errors.Is(err, domain.ErrExperimentNotFound) {
+		// The guarded update matched nothing: either the key is unknown or the
+		// experiment is in the wrong state.
+		if _, err := scanExperiment(tx.QueryRow(ctx, `SELECT `+experimentColumns+` FROM experiments WHERE key = $1`, key)); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("experiment %q: %w", key, domain.ErrExperimentState)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, nil, fmt.Errorf("flag already runs an experiment: %w", domain.ErrExperimentState)
+		}
+		return nil, nil, err
+	}
+
+	var snapshot []byte
+	if attach {
+		if snapshot, err = flagjson.MarshalExperiment(exp); err != nil {
+			return nil, nil, err
+		}
+	}
+	if attach && exp.Bandit != nil {
+		weights, err := flagjson.MarshalVariantWeights(exp.Variants)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO experiment_weights (experiment_key, variants, changed_at) VALUES ($1, $2, $3)`,
+			exp.Key, weights, at,
+		); err != nil {
+			return nil, nil, err
+		}
+	}
+	flag, err := updateState(ctx, tx, action, exp.Project, exp.Environment, exp.FlagName, at, `experiment = $5`, snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return exp, flag, nil
+}
+
+func (s *ExperimentStore) CreateLayer(ctx context.Context, layer domain.Layer) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO layers (`+layerColumns+`) VALUES ($1, $2, $3)`,
+		layer.Key, layer.Description, layer.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return domain.ErrLayerExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ExperimentStore) GetLayer(ctx context.Context, key string) (*domain.Layer, error) {
+	var layer domain.Layer
+	err := s.pool.QueryRow(ctx,
+		`SELECT `+layerColumns+` FROM layers WHERE key = $1`,
+		key,
+	).Scan(&layer.Key, &layer.Description, &layer.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w", domain.ErrLayerNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &layer, nil
+}
+
+func (s *ExperimentStore) ListLayers(ctx context.Context) ([]domain.Layer, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+layerColumns+` FROM layers ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Layer, error) {
+		var layer domain.Layer
+		err := row.Scan(&layer.Key, &layer.Description, &layer.CreatedAt)
+		return layer, err
+	})
+}
+
+func scanExperiment(row pgx.Row) (*domain.Experiment, error) {
+	var (
+		exp         domain.Experiment
+		rawVariants []byte
+		rawBandit   []byte
+		rawStatus   string
+	)
+	err := row.Scan(
+		&exp.Key, &exp.Project, &exp.FlagName, &exp.Environment, &exp.Allocation, &rawVariants,
+		&exp.Layer, &exp.LayerOffset, &exp.Holdout, &rawBandit, &rawStatus,
+		&exp.StartedAt, &exp.StoppedAt, &exp.CreatedAt, &exp.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w", domain.ErrExperimentNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	exp.Status = domain.ExperimentStatus(rawStatus)
+	if exp.Variants, err = flagjson.UnmarshalVariantWeights(rawVariants); err != nil {
+		return nil, err
+	}
+	if exp.Bandit, err = flagjson.UnmarshalBandit(rawBandit); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
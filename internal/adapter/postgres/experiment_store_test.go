@@ -0,0 +1,250 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func newExperimentStores(t *testing.T) (*postgres.FlagStore, *postgres.ExperimentStore, *postgres.EventStore) {
+	t.Helper()
+	pool := testutil.NewPostgresPool(t)
+	flags := postgres.NewFlagStore(pool)
+	experiments := postgres.NewExperimentStore(pool)
+	events := postgres.NewEventStore(pool)
+	require.NoError(t, flags.CreateSchema(context.Background()))
+	require.NoError(t, experiments.CreateSchema(context.Background()))
+	require.NoError(t, events.CreateSchema(context.Background()))
+	return flags, experiments, events
+}
+
+func createMultivariateFlag(t *testing.T, store *postgres.FlagStore, name string) {
+	t.Helper()
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(context.Background(), domain.Flag{
+		Project: domain.DefaultProject,
+		Name:    name,
+		Type:    domain.FlagTypeBoolean,
+		Value:   domain.FlagValue{Bool: &off},
+		Variants: []domain.Variant{
+			{Key: "control", Value: domain.FlagValue{Bool: &off}},
+			{Key: "treatment", Value: domain.FlagValue{Bool: &on}},
+		},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+}
+
+func draftExperiment(key, flag string) domain.Experiment {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return domain.Experiment{
+		Key:         key,
+		Project:     domain.DefaultProject,
+		FlagName:    flag,
+		Environment: domain.DefaultEnvironment,
+		Allocation:  50,
+		Variants:    []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+		Status:      domain.ExperimentDraft,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func TestExperimentStore_Lifecycle(t *testing.T) {
+	t.Parallel()
+	flags, experiments, _ := newExperimentStores(t)
+	ctx := context.Background()
+	createMultivariateFlag(t, flags, "checkout")
+
+	exp := draftExperiment("checkout-test", "checkout")
+	_, err := experiments.Create(ctx, exp)
+	require.NoError(t, err)
+	_, err = experiments.Create(ctx, exp)
+	require.ErrorIs(t, err, domain.ErrExperimentExists)
+
+	got, err := experiments.GetByKey(ctx, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, exp, *got)
+
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+	started, flag, err := experiments.Start(ctx, "checkout-test", startedAt, 5)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ExperimentRunning, started.Status)
+	assert.Equal(t, 5, started.Holdout)
+	require.NotNil(t, flag.Experiment)
+	assert.Equal(t, "checkout-test", flag.Experiment.Key)
+	assert.Equal(t, 5, flag.Experiment.Holdout)
+	assert.Equal(t, int64(2), flag.Version)
+
+	_, _, err = experiments.Start(ctx, "checkout-test", startedAt, 0)
+	require.ErrorIs(t, err, domain.ErrExperimentState)
+
+	_, err = experiments.Create(ctx, draftExperiment("checkout-test-2", "checkout"))
+	require.NoError(t, err)
+	_, _, err = experiments.Start(ctx, "checkout-test-2", startedAt, 0)
+	require.ErrorIs(t, err, domain.ErrExperimentState, "one running experiment per flag")
+
+	stopped, flag, err := experiments.Stop(ctx, "checkout-test", startedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, domain.ExperimentStopped, stopped.Status)
+	require.NotNil(t, stopped.StoppedAt)
+	assert.Nil(t, flag.Experiment)
+
+	list, err := experiments.List(ctx, port.ExperimentFilter{FlagName: "checkout"})
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	audit, err := flags.ListAudit(ctx, domain.AuditQuery{FlagName: "checkout", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, audit, 3)
+	assert.Equal(t, domain.AuditStopExperiment, audit[0].Action)
+	assert.Equal(t, domain.AuditStartExperiment, audit[1].Action)
+	assert.Equal(t, domain.SystemActor, audit[1].Actor)
+	assert.Nil(t, audit[1].Before.Experiment)
+	assert.NotNil(t, audit[1].After.Experiment)
+
+	_, _, err = experiments.Stop(ctx, "ghost", startedAt)
+	require.ErrorIs(t, err, domain.ErrExperimentNotFound)
+}
+
+func TestExperimentStore_Create_UnknownFlag(t *testing.T) {
+	t.Parallel()
+	_, experiments, _ := newExperimentStores(t)
+
+	_, err := experiments.Create(context.Background(), draftExperiment("ghost-test", "ghost"))
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestExperimentStore_Layers(t *testing.T) {
+	t.Parallel()
+	flags, experiments, _ := newExperimentStores(t)
+	ctx := context.Background()
+	createMultivariateFlag(t, flags, "checkout")
+	createMultivariateFlag(t, flags, "pricing")
+
+	layer := domain.Layer{Key: "storefront", Description: "storefront tests", CreatedAt: time.Now().UTC().Truncate(time.Millisecond)}
+	require.NoError(t, experiments.CreateLayer(ctx, layer))
+	require.ErrorIs(t, experiments.CreateLayer(ctx, layer), domain.ErrLayerExists)
+
+	got, err := experiments.GetLayer(ctx, "storefront")
+	require.NoError(t, err)
+	assert.Equal(t, layer, *got)
+	_, err = experiments.GetLayer(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrLayerNotFound)
+
+	first := draftExperiment("checkout-test", "checkout")
+	first.Layer = "storefront"
+	placed, err := experiments.Create(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 0, placed.LayerOffset)
+
+	second := draftExperiment("pricing-test", "pricing")
+	second.Layer = "storefront"
+	placed, err = experiments.Create(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, 50, placed.LayerOffset)
+
+	third := draftExperiment("pricing-test-2", "pricing")
+	third.Layer = "storefront"
+	_, err = experiments.Create(ctx, third)
+	require.ErrorIs(t, err, domain.ErrLayerFull)
+
+	third.Layer = "ghost"
+	_, err = experiments.Create(ctx, third)
+	require.ErrorIs(t, err, domain.ErrLayerNotFound)
+
+	members, err := experiments.List(ctx, port.ExperimentFilter{Layer: "storefront"})
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	layers, err := experiments.ListLayers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Layer{layer}, layers)
+}
+
+func TestExperimentStore_UpdateWeights(t *testing.T) {
+	t.Parallel()
+	flags, experiments, _ := newExperimentStores(t)
+	ctx := context.Background()
+	createMultivariateFlag(t, flags, "banner")
+
+	exp := draftExperiment("banner-bandit", "banner")
+	exp.Bandit = &domain.BanditConfig{Algorithm: domain.BanditThompson, Metric: "click", MinExploration: 0.05}
+	_, err := experiments.Create(ctx, exp)
+	require.NoError(t, err)
+
+	weights := []domain.VariantWeight{{Variant: "control", Weight: 200}, {Variant: "treatment", Weight: 800}}
+	_, _, err = experiments.UpdateWeights(ctx, "banner-bandit", weights, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrExperimentState, "drafts have no live weights")
+
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+	_, _, err = experiments.Start(ctx, "banner-bandit", startedAt, 0)
+	require.NoError(t, err)
+
+	updated, flag, err := experiments.UpdateWeights(ctx, "banner-bandit", weights, startedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, weights, updated.Variants)
+	assert.Equal(t, exp.Bandit, updated.Bandit)
+	require.NotNil(t, flag.Experiment)
+	assert.Equal(t, weights, flag.Experiment.Variants)
+
+	history, err := experiments.WeightHistory(ctx, "banner-bandit")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, exp.Variants, history[0].Variants)
+	assert.Equal(t, weights, history[1].Variants)
+}
+
+func TestEventStore_RecordExposures(t *testing.T) {
+	t.Parallel()
+	_, _, events := newExperimentStores(t)
+
+	now := time.Now().UTC()
+	require.NoError(t, events.RecordExposures(context.Background(), []domain.ExposureEvent{
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "control", TargetingKey: "user-1", ExposedAt: now},
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "treatment", TargetingKey: "user-2", ExposedAt: now},
+	}))
+	require.NoError(t, events.RecordExposures(context.Background(), nil))
+}
+
+func TestEventStore_VariantStats(t *testing.T) {
+	t.Parallel()
+	_, _, events := newExperimentStores(t)
+	ctx := context.Background()
+
+	t0 := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, events.RecordExposures(ctx, []domain.ExposureEvent{
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "control", TargetingKey: "user-1", ExposedAt: t0},
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "control", TargetingKey: "user-1", ExposedAt: t0.Add(time.Minute)},
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "control", TargetingKey: "user-2", ExposedAt: t0},
+		{ExperimentKey: "checkout-test", FlagName: "checkout", Variant: "treatment", TargetingKey: "user-3", ExposedAt: t0},
+	}))
+	require.NoError(t, events.RecordMetrics(ctx, []domain.MetricEvent{
+		{Metric: "purchase", TargetingKey: "user-1", Value: 10, OccurredAt: t0.Add(time.Hour)},
+		{Metric: "purchase", TargetingKey: "user-1", Value: 5, OccurredAt: t0.Add(2 * time.Hour)},
+		{Metric: "purchase", TargetingKey: "user-2", Value: 99, OccurredAt: t0.Add(-time.Hour)},
+		{Metric: "signup", TargetingKey: "user-3", Value: 1, OccurredAt: t0.Add(time.Hour)},
+		{Metric: "purchase", TargetingKey: "user-3", Value: 20, OccurredAt: t0.Add(time.Hour)},
+	}))
+
+	stats, err := events.VariantStats(ctx, "checkout-test", "purchase")
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.VariantStats{
+		// user-2 purchased before being exposed, so it does not count.
+		{Variant: "control", Users: 2, Conversions: 1, Sum: 15, SumSquares: 225},
+		{Variant: "treatment", Users: 1, Conversions: 1, Sum: 20, SumSquares: 400},
+	}, stats)
+}
@@ -9,24 +9,95 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
 	"github.com/xNakero/feature-flags/internal/domain"
 )
 
+// schema splits a flag into its definition in flags, shared by every
+// environment, and its state in each environment in flag_environments. Flag
+// names are unique within a project; environments are shared by all projects.
+// An environment with a parent has state rows only for the flags it overrides.
+// The default project and environment always exist; the service checks that
+// values match the flag's type. promotions is the append-only record of
+// configuration promoted between environments.
 const schema = `
+CREATE TABLE IF NOT EXISTS projects (
+    key         TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+INSERT INTO projects (key, created_at) VALUES ('` + domain.DefaultProject + `', now())
+    ON CONFLICT DO NOTHING;
+CREATE TABLE IF NOT EXISTS environments (
+    key         TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    parent      TEXT        REFERENCES environments (key),
+    created_at  TIMESTAMPTZ NOT NULL
+);
+INSERT INTO environments (key, created_at) VALUES ('` + domain.DefaultEnvironment + `', now())
+    ON CONFLICT DO NOTHING;
 CREATE TABLE IF NOT EXISTS flags (
-    name          TEXT PRIMARY KEY,
-    type          TEXT             NOT NULL CHECK (type IN ('boolean', 'numeric')),
-    description   TEXT             NOT NULL DEFAULT '',
+    project     TEXT        NOT NULL REFERENCES projects (key),
+    name        TEXT        NOT NULL,
+    type        TEXT        NOT NULL CHECK (type IN ('boolean', 'numeric')),
+    description TEXT        NOT NULL DEFAULT '',
+    tags        TEXT[]      NOT NULL DEFAULT '{}',
+    variants    JSONB       NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (project, name)
+);
+CREATE TABLE IF NOT EXISTS flag_environments (
+    project       TEXT             NOT NULL,
+    flag_name     TEXT             NOT NULL,
+    environment   TEXT             NOT NULL REFERENCES environments (key),
+    enabled       BOOLEAN          NOT NULL DEFAULT TRUE,
     bool_value    BOOLEAN,
     numeric_value DOUBLE PRECISION,
-    created_at    TIMESTAMPTZ      NOT NULL,
+    rules         JSONB            NOT NULL DEFAULT '[]',
+    targets       JSONB            NOT NULL DEFAULT '{}',
+    experiment    JSONB,
+    version       BIGINT           NOT NULL DEFAULT 1,
     updated_at    TIMESTAMPTZ      NOT NULL,
-    CONSTRAINT exactly_one_value CHECK (
-        (type = 'boolean' AND bool_value IS NOT NULL AND numeric_value IS NULL) OR
-        (type = 'numeric' AND numeric_value IS NOT NULL AND bool_value IS NULL)
-    )
+    PRIMARY KEY (project, flag_name, environment),
+    FOREIGN KEY (project, flag_name) REFERENCES flags (project, name),
+    CONSTRAINT exactly_one_value CHECK (num_nonnulls(bool_value, numeric_value) = 1)
+);
+CREATE INDEX IF NOT EXISTS flag_environments_environment ON flag_environments (environment, project, flag_name);
+CREATE TABLE IF NOT EXISTS promotions (
+    id          BIGSERIAL PRIMARY KEY,
+    project     TEXT        NOT NULL REFERENCES projects (key),
+    source      TEXT        NOT NULL REFERENCES environments (key),
+    target      TEXT        NOT NULL REFERENCES environments (key),
+    diffs       JSONB       NOT NULL,
+    promoted_at TIMESTAMPTZ NOT NULL
 );`
 
+// lineage lists the environment $1 followed by its ancestors, nearest first.
+// Resolving a flag in $1 takes the state row with the lowest depth.
+const lineage = `lineage AS (
+    SELECT key, parent, 0 AS depth, key AS origin FROM environments WHERE key = $1
+    UNION ALL
+    SELECT p.key, p.parent, l.depth + 1, l.origin FROM environments p JOIN lineage l ON p.key = l.parent
+)`
+
+// flagColumns selects a whole flag from flagJoin. An experiment runs only in
+// its own environment, so an inherited flag has none.
+const flagColumns = `f.project, f.name, f.type, f.description, f.tags, l.origin, e.environment, NOT e.enabled, e.bool_value, e.numeric_value, e.rules, e.targets, f.variants,
+    CASE WHEN e.environment = l.origin THEN e.experiment END, e.version, f.created_at, e.updated_at`
+
+const flagJoin = `lineage l JOIN flag_environments e ON e.environment = l.key JOIN flags f ON f.project = e.project AND f.name = e.flag_name`
+
+const environmentColumns = `key, description, COALESCE(parent, ''), created_at`
+
+const projectColumns = `key, description, created_at`
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type FlagStore struct {
 	pool *pgxpool.Pool
 }
@@ -36,61 +107,561 @@ func NewFlagStore(pool *pgxpool.Pool) *FlagStore {
 }
 
 func (s *FlagStore) CreateSchema(ctx context.Context) error {
-	_, err := s.pool.Exec(ctx, schema)
+	_, err := s.pool.Exec(ctx, moveLegacyFlags+schema+auditSchema+historySchema+copyLegacyFlags+approvalSchema+outboxSchema)
 	return err
 }
 
+// Create inserts the definition and the flag's state in every environment
+// without a parent, and audits the creation and records the first versions,
+// in one transaction.
 func (s *FlagStore) Create(ctx context.Context, flag domain.Flag) error {
-	_, err := s.pool.Exec(ctx,
-		`INSERT INTO flags (name, type, description, bool_value, numeric_value, created_at, updated_at)
+	rules, err := flagjson.MarshalRules(flag.Rules)
+	if err != nil {
+		return err
+	}
+	targets, err := flagjson.MarshalTargets(flag.Targets)
+	if err != nil {
+		return err
+	}
+	variants, err := flagjson.MarshalVariants(flag.Variants)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO flags (project, name, type, description, tags, variants, created_at)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		flag.Name, string(flag.Type), flag.Description,
-		flag.Value.Bool, flag.Value.Numeric,
-		flag.CreatedAt, flag.UpdatedAt,
+		flag.Project, flag.Name, string(flag.Type), flag.Description, textArray(flag.Tags), variants, flag.CreatedAt,
 	)
 	if err != nil {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return domain.ErrAlreadyExists
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return domain.ErrAlreadyExists
+			case "23503":
+				return fmt.Errorf("project %q: %w", flag.Project, domain.ErrProjectNotFound)
+			}
 		}
 		return err
 	}
-	return nil
+	_, err = tx.Exec(ctx,
+		`INSERT INTO flag_environments (project, flag_name, environment, enabled, bool_value, numeric_value, rules, targets, version, updated_at)
+		 SELECT $1, $2, key, $3, $4, $5, $6, $7, $8, $9 FROM environments WHERE parent IS NULL`,
+		flag.Project, flag.Name, !flag.Disabled, flag.Value.Bool, flag.Value.Numeric, rules, targets, flag.Version, flag.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := recordHistory(ctx, tx, `project = $1 AND flag_name = $2`, flag.Project, flag.Name); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, domain.AuditCreate, flag.Environment, nil, &flag, flag.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *FlagStore) GetByName(ctx context.Context, project, env, name string) (*domain.Flag, error) {
+	return getFlag(ctx, s.pool, project, env, name)
 }
 
-func (s *FlagStore) GetByName(ctx context.Context, name string) (*domain.Flag, error) {
-	row := s.pool.QueryRow(ctx,
-		`SELECT name, type, description, bool_value, numeric_value, created_at, updated_at
-		 FROM flags WHERE name = $1`,
-		name,
+func getFlag(ctx context.Context, q querier, project, env, name string) (*domain.Flag, error) {
+	row := q.QueryRow(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT `+flagColumns+` FROM `+flagJoin+`
+		 WHERE f.project = $2 AND f.name = $3
+		 ORDER BY l.depth
+		 LIMIT 1`,
+		env, project, name,
 	)
 	return scanFlag(row)
 }
 
-func (s *FlagStore) UpdateValue(ctx context.Context, name string, flagValue domain.FlagValue) (*domain.Flag, error) {
-	now := time.Now().UTC()
-	row := s.pool.QueryRow(ctx,
-		`UPDATE flags
-		 SET bool_value = $1, numeric_value = $2, updated_at = $3
-		 WHERE name = $4
-		 RETURNING name, type, description, bool_value, numeric_value, created_at, updated_at`,
-		flagValue.Bool, flagValue.Numeric, now, name,
+func (s *FlagStore) UpdateValue(ctx context.Context, project, env, name string, flagValue domain.FlagValue, at time.Time) (*domain.Flag, error) {
+	return s.update(ctx, domain.AuditUpdateValue, project, env, name, at, `bool_value = $5, numeric_value = $6`, flagValue.Bool, flagValue.Numeric)
+}
+
+func (s *FlagStore) UpdateRules(ctx context.Context, project, env, name string, rules []domain.Rule, at time.Time) (*domain.Flag, error) {
+	encoded, err := flagjson.MarshalRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, domain.AuditUpdateRules, project, env, name, at, `rules = $5`, encoded)
+}
+
+func (s *FlagStore) UpdateEnabled(ctx context.Context, project, env, name string, enabled bool, at time.Time) (*domain.Flag, error) {
+	return s.update(ctx, domain.AuditSetEnabled, project, env, name, at, `enabled = $5`, enabled)
+}
+
+// AddTargets merges the keys into the targets object in place, so adding a
+// handful of keys does not rewrite the rest of the flag.
+func (s *FlagStore) AddTargets(ctx context.Context, project, env, name string, keys []string, value domain.FlagValue, at time.Time) (*domain.Flag, error) {
+	added := make(map[string]domain.FlagValue, len(keys))
+	for _, key := range keys {
+		added[key] = value
+	}
+	encoded, err := flagjson.MarshalTargets(added)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, domain.AuditAddTargets, project, env, name, at, `targets = targets || $5`, encoded)
+}
+
+func (s *FlagStore) RemoveTargets(ctx context.Context, project, env, name string, keys []string, at time.Time) (*domain.Flag, error) {
+	return s.update(ctx, domain.AuditRemoveTargets, project, env, name, at, `targets = targets - $5::text[]`, keys)
+}
+
+// update runs updateState in its own transaction, so an inherited flag only
+// becomes an override if the update succeeds.
+func (s *FlagStore) update(ctx context.Context, action domain.AuditAction, project, env, name string, at time.Time, set string, args ...any) (*domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	flag, err := updateState(ctx, tx, action, project, env, name, at, set, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+// updateState applies the SET clause set to the flag's state in env, bumping
+// its version, records the new version, audits the change as action and
+// returns the whole updated flag. The state rows the flag resolves from are locked first so the audited
+// before snapshot is the one replaced. An inherited flag is then copied into
+// env with the state and version it resolves to. In set, $1 to $4 are the
+// environment, project, name and update time; args are bound from $5.
+func updateState(ctx context.Context, q querier, action domain.AuditAction, project, env, name string, at time.Time, set string, args ...any) (*domain.Flag, error) {
+	_, err := q.Exec(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT 1 FROM lineage l JOIN flag_environments e ON e.environment = l.key
+		 WHERE e.project = $2 AND e.flag_name = $3
+		 FOR UPDATE OF e`,
+		env, project, name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	before, err := getFlag(ctx, q, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+	_, err = q.Exec(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 INSERT INTO flag_environments (project, flag_name, environment, enabled, bool_value, numeric_value, rules, targets, version, updated_at)
+		 SELECT e.project, e.flag_name, $1, e.enabled, e.bool_value, e.numeric_value, e.rules, e.targets, e.version, e.updated_at
+		 FROM lineage l JOIN flag_environments e ON e.environment = l.key
+		 WHERE e.project = $2 AND e.flag_name = $3
+		 ORDER BY l.depth
+		 LIMIT 1
+		 ON CONFLICT DO NOTHING`,
+		env, project, name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	row := q.QueryRow(ctx,
+		`WITH RECURSIVE `+lineage+`, e AS (
+		     UPDATE flag_environments
+		     SET `+set+`, version = version + 1, updated_at = $4
+		     WHERE environment = $1 AND project = $2 AND flag_name = $3
+		     RETURNING *
+		 )
+		 SELECT `+flagColumns+`
+		 FROM e JOIN lineage l ON l.key = e.environment JOIN flags f ON f.project = e.project AND f.name = e.flag_name`,
+		append([]any{env, project, name, at}, args...)...,
+	)
+	after, err := scanFlag(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordHistory(ctx, q, `environment = $1 AND project = $2 AND flag_name = $3`, env, project, name); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, q, action, env, before, after, at); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (s *FlagStore) GetByNames(ctx context.Context, project, env string, names []string) ([]domain.Flag, error) {
+	return getFlags(ctx, s.pool, project, env, names)
+}
+
+func getFlags(ctx context.Context, q querier, project, env string, names []string) ([]domain.Flag, error) {
+	rows, err := q.Query(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT DISTINCT ON (f.name) `+flagColumns+` FROM `+flagJoin+`
+		 WHERE f.project = $2 AND f.name = ANY($3)
+		 ORDER BY f.name, l.depth`,
+		env, project, names,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return collectFlags(rows)
+}
+
+func collectFlags(rows pgx.Rows) ([]domain.Flag, error) {
+	defer rows.Close()
+
+	var flags []domain.Flag
+	for rows.Next() {
+		flag, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	return flags, rows.Err()
+}
+
+func (s *FlagStore) ListNames(ctx context.Context, project, prefix string) ([]string, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT name FROM flags WHERE project = $1 AND starts_with(name, $2) ORDER BY name`,
+		project, prefix,
 	)
-	flag, err := scanFlag(row)
 	if err != nil {
 		return nil, err
 	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// CreateEnvironment inserts the environment and, unless it has a parent to
+// inherit from, copies the resolved state of every flag of every project in
+// source into it as their first versions, without running experiments, in one
+// transaction.
+func (s *FlagStore) CreateEnvironment(ctx context.Context, env domain.Environment, source string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var parent *string
+	if env.Parent != "" {
+		parent = &env.Parent
+		if _, err := getEnvironment(ctx, tx, env.Parent); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO environments (key, description, parent, created_at) VALUES ($1, $2, $3, $4)`,
+		env.Key, env.Description, parent, env.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return domain.ErrEnvironmentExists
+		}
+		return err
+	}
+	if env.Parent == "" {
+		if _, err := getEnvironment(ctx, tx, source); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx,
+			`WITH RECURSIVE `+lineage+`
+			 INSERT INTO flag_environments (project, flag_name, environment, enabled, bool_value, numeric_value, rules, targets, version, updated_at)
+			 SELECT DISTINCT ON (e.project, e.flag_name) e.project, e.flag_name, $2, e.enabled, e.bool_value, e.numeric_value, e.rules, e.targets, 1, $3
+			 FROM lineage l JOIN flag_environments e ON e.environment = l.key
+			 ORDER BY e.project, e.flag_name, l.depth`,
+			source, env.Key, env.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if err := recordHistory(ctx, tx, `environment = $1`, env.Key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *FlagStore) GetEnvironment(ctx context.Context, key string) (*domain.Environment, error) {
+	return getEnvironment(ctx, s.pool, key)
+}
+
+func getEnvironment(ctx context.Context, q querier, key string) (*domain.Environment, error) {
+	var env domain.Environment
+	err := q.QueryRow(ctx,
+		`SELECT `+environmentColumns+` FROM environments WHERE key = $1`,
+		key,
+	).Scan(&env.Key, &env.Description, &env.Parent, &env.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("environment %q: %w", key, domain.ErrEnvironmentNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+func (s *FlagStore) ListEnvironments(ctx context.Context) ([]domain.Environment, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+environmentColumns+` FROM environments ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Environment, error) {
+		var env domain.Environment
+		err := row.Scan(&env.Key, &env.Description, &env.Parent, &env.CreatedAt)
+		return env, err
+	})
+}
+
+// RemoveOverride deletes the flag's state row in env, refusing while an
+// experiment is attached to it, and reads the flag back as now inherited. A
+// removed override is audited and marked in the history in the same
+// transaction.
+func (s *FlagStore) RemoveOverride(ctx context.Context, project, env, name string, at time.Time) (*domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	environment, err := getEnvironment(ctx, tx, env)
+	if err != nil {
+		return nil, err
+	}
+	if environment.Parent == "" {
+		return nil, fmt.Errorf("environment %q has no parent to inherit from: %w", env, domain.ErrInvalidEnvironment)
+	}
+	_, err = tx.Exec(ctx,
+		`SELECT 1 FROM flag_environments WHERE environment = $1 AND project = $2 AND flag_name = $3 FOR UPDATE`,
+		env, project, name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	before, err := getFlag(ctx, tx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+	var running bool
+	err = tx.QueryRow(ctx,
+		`WITH removed AS (
+		     DELETE FROM flag_environments WHERE environment = $1 AND project = $2 AND flag_name = $3 RETURNING *
+		 ), recorded AS (
+		     INSERT INTO flag_history (project, flag_name, environment, version, removed, enabled, bool_value, numeric_value, rules, targets, recorded_at)
+		     SELECT project, flag_name, environment, version, TRUE, enabled, bool_value, numeric_value, rules, targets, $4 FROM removed
+		 )
+		 SELECT experiment IS NOT NULL FROM removed`,
+		env, project, name, at,
+	).Scan(&running)
+	removed := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if running {
+		return nil, fmt.Errorf("flag %q runs an experiment in %q: %w", name, env, domain.ErrExperimentState)
+	}
+	flag, err := getFlag(ctx, tx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+	if removed {
+		if err := recordAudit(ctx, tx, domain.AuditRemoveOverride, env, before, flag, at); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return flag, nil
 }
 
+// Promote locks the state rows the promoted flags resolve from in target, so
+// the diff recorded is exactly the change applied, and updates only the flags
+// that differ, overriding them in target if they were inherited.
+func (s *FlagStore) Promote(ctx context.Context, project, source, target string, names []string, at time.Time) (*domain.Promotion, []domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, env := range []string{source, target} {
+		if _, err := getEnvironment(ctx, tx, env); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(names) == 0 {
+		rows, err := tx.Query(ctx, `SELECT name FROM flags WHERE project = $1 ORDER BY name`, project)
+		if err != nil {
+			return nil, nil, err
+		}
+		if names, err = pgx.CollectRows(rows, pgx.RowTo[string]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT 1 FROM lineage l JOIN flag_environments e ON e.environment = l.key
+		 WHERE e.project = $2 AND e.flag_name = ANY($3)
+		 FOR UPDATE OF e`,
+		target, project, names,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := getFlags(ctx, tx, project, target, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	promoted, err := getFlags(ctx, tx, project, source, names)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Every flag resolves in every environment, so a short result means a
+	// name is unknown.
+	if len(promoted) != len(names) || len(current) != len(names) {
+		return nil, nil, fmt.Errorf("%d of %d flags to promote exist: %w", len(promoted), len(names), domain.ErrNotFound)
+	}
+
+	promotion := domain.Promotion{Project: project, Source: source, Target: target, PromotedAt: at}
+	var updated []domain.Flag
+	for i, src := range promoted {
+		diff := domain.DiffFlag(current[i], src)
+		if diff.Empty() {
+			continue
+		}
+		rules, err := flagjson.MarshalRules(src.Rules)
+		if err != nil {
+			return nil, nil, err
+		}
+		targets, err := flagjson.MarshalTargets(src.Targets)
+		if err != nil {
+			return nil, nil, err
+		}
+		flag, err := updateState(ctx, tx, domain.AuditPromote, project, target, src.Name, at,
+			`enabled = $5, bool_value = $6, numeric_value = $7, rules = $8, targets = $9`,
+			!src.Disabled, src.Value.Bool, src.Value.Numeric, rules, targets,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		promotion.Diffs = append(promotion.Diffs, diff)
+		updated = append(updated, *flag)
+	}
+
+	diffs, err := flagjson.MarshalDiffs(promotion.Diffs)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO promotions (project, source, target, diffs, promoted_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		project, source, target, diffs, at,
+	).Scan(&promotion.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return &promotion, updated, nil
+}
+
+func (s *FlagStore) ListPromotions(ctx context.Context, project string) ([]domain.Promotion, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, project, source, target, diffs, promoted_at FROM promotions WHERE project = $1 ORDER BY id DESC`,
+		project,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Promotion, error) {
+		var (
+			p   domain.Promotion
+			raw []byte
+		)
+		if err := row.Scan(&p.ID, &p.Project, &p.Source, &p.Target, &raw, &p.PromotedAt); err != nil {
+			return p, err
+		}
+		var err error
+		p.Diffs, err = flagjson.UnmarshalDiffs(raw)
+		return p, err
+	})
+}
+
+func (s *FlagStore) CreateProject(ctx context.Context, project domain.Project) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO projects (`+projectColumns+`) VALUES ($1, $2, $3)`,
+		project.Key, project.Description, project.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return domain.ErrProjectExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FlagStore) GetProject(ctx context.Context, key string) (*domain.Project, error) {
+	var project domain.Project
+	err := s.pool.QueryRow(ctx,
+		`SELECT `+projectColumns+` FROM projects WHERE key = $1`,
+		key,
+	).Scan(&project.Key, &project.Description, &project.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("project %q: %w", key, domain.ErrProjectNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *FlagStore) ListProjects(ctx context.Context) ([]domain.Project, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+projectColumns+` FROM projects ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Project, error) {
+		var project domain.Project
+		err := row.Scan(&project.Key, &project.Description, &project.CreatedAt)
+		return project, err
+	})
+}
+
+// textArray returns values for a TEXT[] NOT NULL column, which stores nil as
+// an empty array.
+func textArray(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 func scanFlag(row pgx.Row) (*domain.Flag, error) {
 	var (
-		flag    domain.Flag
-		rawType string
+		flag          domain.Flag
+		rawType       string
+		rawRules      []byte
+		rawTargets    []byte
+		rawVariants   []byte
+		rawExperiment []byte
 	)
 	err := row.Scan(
-		&flag.Name, &rawType, &flag.Description,
-		&flag.Value.Bool, &flag.Value.Numeric,
+		&flag.Project, &flag.Name, &rawType, &flag.Description, &flag.Tags, &flag.Environment, &flag.SourceEnvironment,
+		&flag.Disabled,
+		&flag.Value.Bool, &flag.Value.Numeric, &rawRules, &rawTargets, &rawVariants, &rawExperiment, &flag.Version,
 		&flag.CreatedAt, &flag.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -100,5 +671,20 @@ func scanFlag(row pgx.Row) (*domain.Flag, error) {
 		return nil, err
 	}
 	flag.Type = domain.FlagType(rawType)
+	if len(flag.Tags) == 0 {
+		flag.Tags = nil
+	}
+	if flag.Rules, err = flagjson.UnmarshalRules(rawRules); err != nil {
+		return nil, err
+	}
+	if flag.Targets, err = flagjson.UnmarshalTargets(rawTargets); err != nil {
+		return nil, err
+	}
+	if flag.Variants, err = flagjson.UnmarshalVariants(rawVariants); err != nil {
+		return nil, err
+	}
+	if flag.Experiment, err = flagjson.UnmarshalExperiment(rawExperiment); err != nil {
+		return nil, err
+	}
 	return &flag, nil
 }
@@ -29,23 +29,26 @@ func TestFlagStore_Create_GetByName_Boolean(t *testing.T) {
 	boolVal := true
 	now := time.Now().UTC().Truncate(time.Millisecond)
 	flag := domain.Flag{
+		Project:     domain.DefaultProject,
 		Name:        "feature-x",
 		Type:        domain.FlagTypeBoolean,
 		Description: "a boolean flag",
 		Value:       domain.FlagValue{Bool: &boolVal},
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
 	require.NoError(t, store.Create(context.Background(), flag))
 
-	got, err := store.GetByName(context.Background(), "feature-x")
+	got, err := store.GetByName(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "feature-x")
 	require.NoError(t, err)
 	assert.Equal(t, flag.Name, got.Name)
 	assert.Equal(t, flag.Type, got.Type)
 	assert.Equal(t, flag.Description, got.Description)
 	assert.Equal(t, flag.Value.Bool, got.Value.Bool)
 	assert.Nil(t, got.Value.Numeric)
+	assert.Equal(t, int64(1), got.Version)
 	assert.False(t, got.CreatedAt.IsZero())
 	assert.False(t, got.UpdatedAt.IsZero())
 }
@@ -57,6 +60,7 @@ func TestFlagStore_Create_GetByName_Numeric(t *testing.T) {
 	numVal := 3.14
 	now := time.Now().UTC().Truncate(time.Millisecond)
 	flag := domain.Flag{
+		Project:     domain.DefaultProject,
 		Name:        "rate-limit",
 		Type:        domain.FlagTypeNumeric,
 		Description: "a numeric flag",
@@ -67,7 +71,7 @@ func TestFlagStore_Create_GetByName_Numeric(t *testing.T) {
 
 	require.NoError(t, store.Create(context.Background(), flag))
 
-	got, err := store.GetByName(context.Background(), "rate-limit")
+	got, err := store.GetByName(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "rate-limit")
 	require.NoError(t, err)
 	assert.Equal(t, flag.Name, got.Name)
 	assert.Equal(t, flag.Type, got.Type)
@@ -82,6 +86,7 @@ func TestFlagStore_Create_Duplicate(t *testing.T) {
 	boolVal := true
 	now := time.Now().UTC()
 	flag := domain.Flag{
+		Project:   domain.DefaultProject,
 		Name:      "dup-flag",
 		Type:      domain.FlagTypeBoolean,
 		Value:     domain.FlagValue{Bool: &boolVal},
@@ -98,7 +103,7 @@ func TestFlagStore_GetByName_NotFound(t *testing.T) {
 	t.Parallel()
 	store := newStore(t)
 
-	_, err := store.GetByName(context.Background(), "ghost")
+	_, err := store.GetByName(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "ghost")
 	require.ErrorIs(t, err, domain.ErrNotFound)
 }
 
@@ -109,19 +114,22 @@ func TestFlagStore_UpdateValue(t *testing.T) {
 	boolVal := true
 	now := time.Now().UTC().Truncate(time.Millisecond)
 	flag := domain.Flag{
+		Project:   domain.DefaultProject,
 		Name:      "toggle",
 		Type:      domain.FlagTypeBoolean,
 		Value:     domain.FlagValue{Bool: &boolVal},
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 	require.NoError(t, store.Create(context.Background(), flag))
 
 	newBool := false
-	updated, err := store.UpdateValue(context.Background(), "toggle", domain.FlagValue{Bool: &newBool})
+	updated, err := store.UpdateValue(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "toggle", domain.FlagValue{Bool: &newBool}, time.Now().UTC())
 	require.NoError(t, err)
 	require.NotNil(t, updated)
 	assert.Equal(t, &newBool, updated.Value.Bool)
+	assert.Equal(t, int64(2), updated.Version)
 	assert.True(t, !updated.UpdatedAt.Before(now))
 }
 
@@ -130,6 +138,346 @@ func TestFlagStore_UpdateValue_NotFound(t *testing.T) {
 	store := newStore(t)
 
 	boolVal := true
-	_, err := store.UpdateValue(context.Background(), "ghost", domain.FlagValue{Bool: &boolVal})
+	_, err := store.UpdateValue(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "ghost", domain.FlagValue{Bool: &boolVal}, time.Now().UTC())
 	require.ErrorIs(t, err, domain.ErrNotFound)
 }
+
+func TestFlagStore_GetByNames(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	boolVal := true
+	now := time.Now().UTC()
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		require.NoError(t, store.Create(context.Background(), domain.Flag{
+			Project:   domain.DefaultProject,
+			Name:      name,
+			Type:      domain.FlagTypeBoolean,
+			Value:     domain.FlagValue{Bool: &boolVal},
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}))
+	}
+
+	got, err := store.GetByNames(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, []string{"gamma", "alpha", "ghost"})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "alpha", got[0].Name)
+	assert.Equal(t, "gamma", got[1].Name)
+}
+
+func TestFlagStore_ListNames(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	boolVal := true
+	now := time.Now().UTC()
+	for _, name := range []string{"checkout-v2", "checkout-banner", "search"} {
+		require.NoError(t, store.Create(context.Background(), domain.Flag{
+			Project:   domain.DefaultProject,
+			Name:      name,
+			Type:      domain.FlagTypeBoolean,
+			Value:     domain.FlagValue{Bool: &boolVal},
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}))
+	}
+
+	all, err := store.ListNames(context.Background(), domain.DefaultProject, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout-banner", "checkout-v2", "search"}, all)
+
+	prefixed, err := store.ListNames(context.Background(), domain.DefaultProject, "checkout-")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout-banner", "checkout-v2"}, prefixed)
+}
+
+func TestFlagStore_UpdateRules(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(context.Background(), domain.Flag{
+		Project:   domain.DefaultProject,
+		Name:      "office-hours",
+		Type:      domain.FlagTypeBoolean,
+		Value:     domain.FlagValue{Bool: &off},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+
+	rules := []domain.Rule{{
+		ID:    "weekdays",
+		Value: domain.FlagValue{Bool: &on},
+		Clauses: []domain.Clause{
+			{Operator: domain.OperatorDayOfWeekIn, Values: []string{"mon", "tue"}, Timezone: "Europe/Warsaw"},
+		},
+	}}
+	updated, err := store.UpdateRules(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "office-hours", rules, time.Now().UTC())
+	require.NoError(t, err)
+	domain.CompileRules(rules)
+	assert.Equal(t, rules, updated.Rules)
+	assert.Equal(t, int64(2), updated.Version)
+
+	got, err := store.GetByName(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "office-hours")
+	require.NoError(t, err)
+	assert.Equal(t, rules, got.Rules)
+
+	cleared, err := store.UpdateRules(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "office-hours", nil, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Nil(t, cleared.Rules)
+}
+
+func TestFlagStore_UpdateRules_NotFound(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	_, err := store.UpdateRules(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "ghost", nil, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagStore_AddTargets_RemoveTargets(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(context.Background(), domain.Flag{
+		Project:   domain.DefaultProject,
+		Name:      "new-checkout",
+		Type:      domain.FlagTypeBoolean,
+		Value:     domain.FlagValue{Bool: &off},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+
+	added, err := store.AddTargets(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", []string{"user-1", "user-2"}, domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]domain.FlagValue{"user-1": {Bool: &on}, "user-2": {Bool: &on}}, added.Targets)
+	assert.Equal(t, int64(2), added.Version)
+
+	overridden, err := store.AddTargets(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", []string{"user-2"}, domain.FlagValue{Bool: &off}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]domain.FlagValue{"user-1": {Bool: &on}, "user-2": {Bool: &off}}, overridden.Targets)
+
+	removed, err := store.RemoveTargets(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", []string{"user-1", "user-9"}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]domain.FlagValue{"user-2": {Bool: &off}}, removed.Targets)
+	assert.Equal(t, int64(4), removed.Version)
+
+	got, err := store.GetByName(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, removed.Targets, got.Targets)
+}
+
+func TestFlagStore_AddTargets_NotFound(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+
+	on := true
+	_, err := store.AddTargets(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "ghost", []string{"user-1"}, domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagStore_Environments(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project:   domain.DefaultProject,
+		Name:      "new-checkout",
+		Type:      domain.FlagTypeBoolean,
+		Value:     domain.FlagValue{Bool: &off},
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+
+	staging := domain.Environment{Key: "staging", Description: "pre-release", CreatedAt: now}
+	require.NoError(t, store.CreateEnvironment(ctx, staging, domain.DefaultEnvironment))
+	require.ErrorIs(t, store.CreateEnvironment(ctx, staging, domain.DefaultEnvironment), domain.ErrEnvironmentExists)
+	require.ErrorIs(t, store.CreateEnvironment(ctx, domain.Environment{Key: "qa", CreatedAt: now}, "ghost"), domain.ErrEnvironmentNotFound)
+
+	copied, err := store.GetByName(ctx, domain.DefaultProject, "staging", "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", copied.Environment)
+	assert.True(t, *copied.Value.Bool, "state is copied from the source")
+	assert.Equal(t, int64(1), copied.Version)
+
+	disabled, err := store.UpdateEnabled(ctx, domain.DefaultProject, "staging", "new-checkout", false, time.Now().UTC())
+	require.NoError(t, err)
+	assert.True(t, disabled.Disabled)
+
+	prod, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, prod.Disabled, "environments are independent")
+
+	envs, err := store.ListEnvironments(ctx)
+	require.NoError(t, err)
+	require.Len(t, envs, 2)
+	assert.Equal(t, domain.DefaultEnvironment, envs[0].Key)
+	assert.Equal(t, "staging", envs[1].Key)
+
+	_, err = store.GetEnvironment(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrEnvironmentNotFound)
+	_, err = store.GetByName(ctx, domain.DefaultProject, "ghost", "new-checkout")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagStore_Inheritance(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "staging", Parent: domain.DefaultEnvironment, CreatedAt: now}, ""))
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "dev", Parent: "staging", CreatedAt: now}, ""))
+	require.ErrorIs(t, store.CreateEnvironment(ctx, domain.Environment{Key: "qa", Parent: "ghost", CreatedAt: now}, ""), domain.ErrEnvironmentNotFound)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject,
+		Name:    "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	inherited, err := store.GetByName(ctx, domain.DefaultProject, "dev", "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", inherited.Environment)
+	assert.Equal(t, domain.DefaultEnvironment, inherited.SourceEnvironment)
+
+	overridden, err := store.UpdateValue(ctx, domain.DefaultProject, "staging", "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, "staging", overridden.SourceEnvironment)
+	assert.True(t, *overridden.Value.Bool)
+	assert.Equal(t, int64(2), overridden.Version)
+
+	flags, err := store.GetByNames(ctx, domain.DefaultProject, "dev", []string{"new-checkout"})
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, "staging", flags[0].SourceEnvironment, "the nearest override wins")
+	assert.True(t, *flags[0].Value.Bool)
+
+	prod, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, *prod.Value.Bool)
+
+	reset, err := store.RemoveOverride(ctx, domain.DefaultProject, "staging", "new-checkout", time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultEnvironment, reset.SourceEnvironment)
+	assert.False(t, *reset.Value.Bool)
+	_, err = store.RemoveOverride(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrInvalidEnvironment)
+
+	envs, err := store.ListEnvironments(ctx)
+	require.NoError(t, err)
+	require.Len(t, envs, 3)
+	assert.Equal(t, "staging", envs[0].Parent, "dev")
+	assert.Empty(t, envs[1].Parent, "production")
+}
+
+func TestFlagStore_Promote(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for _, name := range []string{"new-checkout", "other-flag"} {
+		require.NoError(t, store.Create(ctx, domain.Flag{
+			Project: domain.DefaultProject,
+			Name:    name, Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+		}))
+	}
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "staging", CreatedAt: now}, domain.DefaultEnvironment))
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, "staging", "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	_, err = store.AddTargets(ctx, domain.DefaultProject, "staging", "new-checkout", []string{"user-1"}, domain.FlagValue{Bool: &off}, time.Now().UTC())
+	require.NoError(t, err)
+
+	_, _, err = store.Promote(ctx, domain.DefaultProject, "staging", domain.DefaultEnvironment, []string{"new-checkout", "ghost"}, now)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+	unchanged, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, *unchanged.Value.Bool, "a failed promotion is rolled back")
+
+	promotion, updated, err := store.Promote(ctx, domain.DefaultProject, "staging", domain.DefaultEnvironment, nil, now)
+	require.NoError(t, err)
+	assert.NotZero(t, promotion.ID)
+	require.Len(t, promotion.Diffs, 1)
+	assert.Equal(t, "new-checkout", promotion.Diffs[0].Name)
+	assert.Equal(t, []domain.TargetChange{{Key: "user-1", Kind: domain.ChangeAdded}}, promotion.Diffs[0].Targets)
+	require.Len(t, updated, 1)
+	assert.Equal(t, domain.DefaultEnvironment, updated[0].Environment)
+	assert.True(t, *updated[0].Value.Bool)
+	assert.Equal(t, int64(2), updated[0].Version)
+
+	history, err := store.ListPromotions(ctx, domain.DefaultProject)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, promotion.Diffs, history[0].Diffs)
+	assert.Equal(t, "staging", history[0].Source)
+}
+
+func TestFlagStore_Projects(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	checkout := domain.Project{Key: "checkout", Description: "checkout team", CreatedAt: now}
+	require.NoError(t, store.CreateProject(ctx, checkout))
+	require.ErrorIs(t, store.CreateProject(ctx, checkout), domain.ErrProjectExists)
+
+	for _, flag := range []domain.Flag{
+		{Project: domain.DefaultProject, Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now},
+		{Project: "checkout", Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on}, Version: 1, CreatedAt: now, UpdatedAt: now},
+	} {
+		require.NoError(t, store.Create(ctx, flag), "names are unique per project")
+	}
+	require.ErrorIs(t, store.Create(ctx, domain.Flag{
+		Project: "ghost", Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on}, CreatedAt: now, UpdatedAt: now,
+	}), domain.ErrProjectNotFound)
+
+	updated, err := store.UpdateEnabled(ctx, "checkout", domain.DefaultEnvironment, "new-checkout", false, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, "checkout", updated.Project)
+	assert.True(t, updated.Disabled)
+
+	got, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultProject, got.Project)
+	assert.False(t, got.Disabled, "projects are independent")
+	assert.False(t, *got.Value.Bool)
+
+	names, err := store.ListNames(ctx, "checkout", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new-checkout"}, names)
+
+	project, err := store.GetProject(ctx, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, checkout.Description, project.Description)
+	_, err = store.GetProject(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrProjectNotFound)
+
+	projects, err := store.ListProjects(ctx)
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "checkout", projects[0].Key)
+	assert.Equal(t, domain.DefaultProject, projects[1].Key)
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// freezeSchema holds the freeze windows. Empty scope arrays match every
+// project, environment or tag; the service enforces the windows.
+const freezeSchema = `
+CREATE TABLE IF NOT EXISTS freeze_windows (
+    id           BIGSERIAL PRIMARY KEY,
+    reason       TEXT        NOT NULL DEFAULT '',
+    starts_at    TIMESTAMPTZ NOT NULL,
+    ends_at      TIMESTAMPTZ NOT NULL,
+    projects     TEXT[]      NOT NULL DEFAULT '{}',
+    environments TEXT[]      NOT NULL DEFAULT '{}',
+    tags         TEXT[]      NOT NULL DEFAULT '{}',
+    created_by   TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS freeze_windows_ends_at ON freeze_windows (ends_at);`
+
+const freezeColumns = `id, reason, starts_at, ends_at, projects, environments, tags, created_by, created_at`
+
+// FreezeStore keeps the freeze windows.
+type FreezeStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewFreezeStore(pool *pgxpool.Pool) *FreezeStore {
+	return &FreezeStore{pool: pool}
+}
+
+func (s *FreezeStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, freezeSchema)
+	return err
+}
+
+func (s *FreezeStore) CreateFreezeWindow(ctx context.Context, window domain.FreezeWindow) (*domain.FreezeWindow, error) {
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO freeze_windows (reason, starts_at, ends_at, projects, environments, tags, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id`,
+		window.Reason, window.StartsAt, window.EndsAt, textArray(window.Projects), textArray(window.Environments),
+		textArray(window.Tags), window.CreatedBy, window.CreatedAt,
+	).Scan(&window.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (s *FreezeStore) GetFreezeWindow(ctx context.Context, id int64) (*domain.FreezeWindow, error) {
+	window, err := scanFreezeWindow(s.pool.QueryRow(ctx, `SELECT `+freezeColumns+` FROM freeze_windows WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("freeze window %d: %w", id, domain.ErrFreezeNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (s *FreezeStore) ListFreezeWindows(ctx context.Context, q domain.FreezeQuery) ([]domain.FreezeWindow, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+freezeColumns+` FROM freeze_windows
+		 WHERE $1::timestamptz IS NULL OR (starts_at <= $1 AND ends_at > $1)
+		 ORDER BY starts_at, id`,
+		nullTime(q.ActiveAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.FreezeWindow, error) {
+		return scanFreezeWindow(row)
+	})
+}
+
+func (s *FreezeStore) DeleteFreezeWindow(ctx context.Context, id int64) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM freeze_windows WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("freeze window %d: %w", id, domain.ErrFreezeNotFound)
+	}
+	return nil
+}
+
+// scanFreezeWindow reads empty scope arrays as nil, matching windows built by
+// the service.
+func scanFreezeWindow(row pgx.Row) (domain.FreezeWindow, error) {
+	var window domain.FreezeWindow
+	err := row.Scan(&window.ID, &window.Reason, &window.StartsAt, &window.EndsAt, &window.Projects, &window.Environments,
+		&window.Tags, &window.CreatedBy, &window.CreatedAt)
+	for _, scope := range []*[]string{&window.Projects, &window.Environments, &window.Tags} {
+		if len(*scope) == 0 {
+			*scope = nil
+		}
+	}
+	return window, err
+}
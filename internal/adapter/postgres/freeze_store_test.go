@@ -0,0 +1,108 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestFreezeStore(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFreezeStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	window := func(reason string, starts, ends time.Time, tags ...string) *domain.FreezeWindow {
+		created, err := store.CreateFreezeWindow(ctx, domain.FreezeWindow{
+			Reason: reason, StartsAt: starts, EndsAt: ends, Projects: []string{domain.DefaultProject},
+			Tags: tags, CreatedBy: "alice", CreatedAt: now,
+		})
+		require.NoError(t, err)
+		return created
+	}
+	current := window("black friday", now.Add(-time.Hour), now.Add(time.Hour), "payments")
+	upcoming := window("new year", now.Add(time.Hour), now.Add(2*time.Hour))
+
+	got, err := store.GetFreezeWindow(ctx, current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *current, *got)
+	assert.Nil(t, got.Environments, "an empty scope reads as nil")
+
+	all, err := store.ListFreezeWindows(ctx, domain.FreezeQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, current.ID, all[0].ID)
+	assert.Equal(t, upcoming.ID, all[1].ID)
+
+	active, err := store.ListFreezeWindows(ctx, domain.FreezeQuery{ActiveAt: now})
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, current.ID, active[0].ID)
+	active, err = store.ListFreezeWindows(ctx, domain.FreezeQuery{ActiveAt: upcoming.StartsAt})
+	require.NoError(t, err)
+	require.Len(t, active, 1, "windows end exclusively")
+	assert.Equal(t, upcoming.ID, active[0].ID)
+
+	require.NoError(t, store.DeleteFreezeWindow(ctx, current.ID))
+	_, err = store.GetFreezeWindow(ctx, current.ID)
+	require.ErrorIs(t, err, domain.ErrFreezeNotFound)
+	require.ErrorIs(t, store.DeleteFreezeWindow(ctx, current.ID), domain.ErrFreezeNotFound)
+}
+
+func TestFlagStore_TagsAndEmergency(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewFlagStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	off := false
+	on := true
+	now := time.Now().UTC()
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment, Tags: []string{"payments", "web"},
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "dark-mode", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	flag, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments", "web"}, flag.Tags)
+	flag, err = store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "dark-mode")
+	require.NoError(t, err)
+	assert.Nil(t, flag.Tags)
+
+	incident := port.WithEmergency(port.WithActor(ctx, "bob"), "INC-42")
+	_, err = store.UpdateValue(incident, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+
+	entries, err := store.ListAudit(ctx, domain.AuditQuery{FlagName: "new-checkout", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "INC-42", entries[0].Emergency)
+	assert.Equal(t, []string{"payments", "web"}, entries[0].After.Tags)
+	assert.Empty(t, entries[1].Emergency)
+
+	chain, err := store.ListAuditChain(ctx, 0, 10)
+	require.NoError(t, err)
+	verifier := domain.NewAuditChain(nil, nil)
+	for _, entry := range chain {
+		brk, err := verifier.Next(entry)
+		require.NoError(t, err)
+		assert.Nil(t, brk, "the override is part of the chain")
+	}
+}
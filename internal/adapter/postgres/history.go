@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// historySchema holds every version of every flag's state in each
+// environment. A version is recorded in the transaction that writes it; a
+// removed override is recorded as a row marked removed, so the history also
+// tells when an environment went back to inheriting the flag. Versions are
+// ordered by id: an environment that overrides a flag again after removing
+// its override can record a version number twice.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS flag_history (
+    id            BIGSERIAL PRIMARY KEY,
+    project       TEXT             NOT NULL,
+    flag_name     TEXT             NOT NULL,
+    environment   TEXT             NOT NULL,
+    version       BIGINT           NOT NULL,
+    removed       BOOLEAN          NOT NULL DEFAULT FALSE,
+    enabled       BOOLEAN          NOT NULL,
+    bool_value    BOOLEAN,
+    numeric_value DOUBLE PRECISION,
+    rules         JSONB            NOT NULL,
+    targets       JSONB            NOT NULL,
+    recorded_at   TIMESTAMPTZ      NOT NULL
+);
+CREATE INDEX IF NOT EXISTS flag_history_flag ON flag_history (project, flag_name, environment, id);`
+
+const historyColumns = `h.project, h.flag_name, h.environment, h.version, NOT h.enabled, h.bool_value, h.numeric_value, h.rules, h.targets, h.recorded_at`
+
+// asOfColumns selects a whole flag, like flagColumns, from its latest version
+// in asOf. Versions do not record experiments.
+const asOfColumns = `f.project, f.name, f.type, f.description, f.tags, h.origin, h.environment, NOT h.enabled, h.bool_value, h.numeric_value, h.rules, h.targets, f.variants,
+    NULL::jsonb, h.version, f.created_at, h.recorded_at`
+
+// asOf resolves flags of project $2 in $1 at the instant $4 from their
+// history, for the flag named $3 or every flag when it is empty: each
+// environment of the lineage contributes its latest version recorded by then,
+// one whose override had been removed contributes none, and the nearest
+// environment wins.
+const asOf = `WITH RECURSIVE ` + lineage + `, latest AS (
+    SELECT DISTINCT ON (h.flag_name, h.environment) h.*, l.depth, l.origin
+    FROM lineage l JOIN flag_history h ON h.environment = l.key
+    WHERE h.project = $2 AND ($3 = '' OR h.flag_name = $3) AND h.recorded_at <= $4
+    ORDER BY h.flag_name, h.environment, h.id DESC
+)
+SELECT DISTINCT ON (h.flag_name) ` + asOfColumns + `
+FROM latest h JOIN flags f ON f.project = h.project AND f.name = h.flag_name
+WHERE NOT h.removed
+ORDER BY h.flag_name, h.depth`
+
+// recordHistory records the current state rows of flag_environments matching
+// where, which binds its own args, as versions.
+func recordHistory(ctx context.Context, q querier, where string, args ...any) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO flag_history (project, flag_name, environment, version, enabled, bool_value, numeric_value, rules, targets, recorded_at)
+		 SELECT project, flag_name, environment, version, enabled, bool_value, numeric_value, rules, targets, updated_at
+		 FROM flag_environments WHERE `+where,
+		args...,
+	)
+	return err
+}
+
+func (s *FlagStore) ListHistory(ctx context.Context, project, env, name string) ([]domain.FlagVersion, error) {
+	if _, err := getFlag(ctx, s.pool, project, env, name); err != nil {
+		return nil, err
+	}
+	rows, err := s.pool.Query(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT `+historyColumns+` FROM lineage l JOIN flag_history h ON h.environment = l.key
+		 WHERE h.project = $2 AND h.flag_name = $3 AND NOT h.removed
+		 ORDER BY h.id DESC`,
+		env, project, name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.FlagVersion, error) {
+		return scanVersion(row)
+	})
+}
+
+// Rollback reads the version from the nearest environment in env's lineage
+// that recorded it, taking the latest when it was recorded twice, and applies
+// it with updateState, so it is audited and recorded like any other update.
+func (s *FlagStore) Rollback(ctx context.Context, project, env, name string, version int64, at time.Time) (*domain.Flag, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := getFlag(ctx, tx, project, env, name); err != nil {
+		return nil, err
+	}
+	target, err := scanVersion(tx.QueryRow(ctx,
+		`WITH RECURSIVE `+lineage+`
+		 SELECT `+historyColumns+` FROM lineage l JOIN flag_history h ON h.environment = l.key
+		 WHERE h.project = $2 AND h.flag_name = $3 AND h.version = $4 AND NOT h.removed
+		 ORDER BY l.depth, h.id DESC
+		 LIMIT 1`,
+		env, project, name, version,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("flag %q version %d: %w", name, version, domain.ErrVersionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules, err := flagjson.MarshalRules(target.Rules)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := flagjson.MarshalTargets(target.Targets)
+	if err != nil {
+		return nil, err
+	}
+	flag, err := updateState(ctx, tx, domain.AuditRollback, project, env, name, at,
+		`enabled = $5, bool_value = $6, numeric_value = $7, rules = $8, targets = $9`,
+		!target.Disabled, target.Value.Bool, target.Value.Numeric, rules, targets,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (s *FlagStore) GetAsOf(ctx context.Context, project, env, name string, at time.Time) (*domain.Flag, error) {
+	return scanFlag(s.pool.QueryRow(ctx, asOf, env, project, name, at))
+}
+
+func (s *FlagStore) ListAsOf(ctx context.Context, project, env string, at time.Time) ([]domain.Flag, error) {
+	rows, err := s.pool.Query(ctx, asOf, env, project, "", at)
+	if err != nil {
+		return nil, err
+	}
+	return collectFlags(rows)
+}
+
+func scanVersion(row pgx.Row) (domain.FlagVersion, error) {
+	var (
+		version    domain.FlagVersion
+		rawRules   []byte
+		rawTargets []byte
+	)
+	err := row.Scan(&version.Project, &version.FlagName, &version.Environment, &version.Version, &version.Disabled,
+		&version.Value.Bool, &version.Value.Numeric, &rawRules, &rawTargets, &version.RecordedAt)
+	if err != nil {
+		return version, err
+	}
+	if version.Rules, err = flagjson.UnmarshalRules(rawRules); err != nil {
+		return version, err
+	}
+	version.Targets, err = flagjson.UnmarshalTargets(rawTargets)
+	return version, err
+}
@@ -0,0 +1,124 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestFlagStore_HistoryAndRollback(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "staging", Parent: domain.DefaultEnvironment, CreatedAt: now}, ""))
+	_, err = store.AddTargets(ctx, domain.DefaultProject, "staging", "new-checkout", []string{"user-1"}, domain.FlagValue{Bool: &off}, time.Now().UTC())
+	require.NoError(t, err)
+
+	history, err := store.ListHistory(ctx, domain.DefaultProject, "staging", "new-checkout")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "staging", history[0].Environment)
+	assert.Equal(t, int64(3), history[0].Version)
+	assert.Contains(t, history[0].Targets, "user-1")
+	assert.Equal(t, domain.DefaultEnvironment, history[1].Environment)
+	assert.True(t, *history[1].Value.Bool)
+	assert.False(t, *history[2].Value.Bool)
+
+	rolledBackAt := now.Add(time.Hour)
+	rolledBack, err := store.Rollback(ctx, domain.DefaultProject, "staging", "new-checkout", 1, rolledBackAt)
+	require.NoError(t, err)
+	assert.False(t, *rolledBack.Value.Bool)
+	assert.Empty(t, rolledBack.Targets)
+	assert.Equal(t, int64(4), rolledBack.Version)
+	assert.Equal(t, "staging", rolledBack.SourceEnvironment)
+	assert.True(t, rolledBackAt.Equal(rolledBack.UpdatedAt), "the change is stamped with the time passed in")
+
+	production, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *production.Value.Bool, "a rollback in a child leaves the parent alone")
+
+	audit, err := store.ListAudit(ctx, domain.AuditQuery{FlagName: "new-checkout", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, audit, 1)
+	assert.Equal(t, domain.AuditRollback, audit[0].Action)
+	assert.True(t, rolledBackAt.Equal(audit[0].RecordedAt))
+	history, err = store.ListHistory(ctx, domain.DefaultProject, "staging", "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, rolledBackAt.Equal(history[0].RecordedAt))
+
+	_, err = store.RemoveOverride(ctx, domain.DefaultProject, "staging", "new-checkout", time.Now().UTC())
+	require.NoError(t, err)
+	history, err = store.ListHistory(ctx, domain.DefaultProject, "staging", "new-checkout")
+	require.NoError(t, err)
+	assert.Len(t, history, 4, "a removed override is not a version")
+
+	_, err = store.Rollback(ctx, domain.DefaultProject, "staging", "new-checkout", 9, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrVersionNotFound)
+	_, err = store.ListHistory(ctx, domain.DefaultProject, "staging", "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagStore_AsOf(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	on := true
+	created := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Environment: domain.DefaultEnvironment,
+		Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: created, UpdatedAt: created,
+	}))
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "staging", Parent: domain.DefaultEnvironment, CreatedAt: created}, ""))
+	beforeUpdate := time.Now().UTC()
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	_, err = store.UpdateEnabled(ctx, domain.DefaultProject, "staging", "new-checkout", false, time.Now().UTC())
+	require.NoError(t, err)
+	overridden := time.Now().UTC()
+	_, err = store.RemoveOverride(ctx, domain.DefaultProject, "staging", "new-checkout", time.Now().UTC())
+	require.NoError(t, err)
+	removed := time.Now().UTC()
+
+	flag, err := store.GetAsOf(ctx, domain.DefaultProject, "staging", "new-checkout", beforeUpdate)
+	require.NoError(t, err)
+	assert.False(t, *flag.Value.Bool)
+	assert.Equal(t, int64(1), flag.Version)
+	assert.Equal(t, domain.DefaultEnvironment, flag.SourceEnvironment)
+
+	flag, err = store.GetAsOf(ctx, domain.DefaultProject, "staging", "new-checkout", overridden)
+	require.NoError(t, err)
+	assert.True(t, flag.Disabled)
+	assert.Equal(t, "staging", flag.SourceEnvironment)
+
+	flag, err = store.GetAsOf(ctx, domain.DefaultProject, "staging", "new-checkout", removed)
+	require.NoError(t, err)
+	assert.False(t, flag.Disabled, "a removed override resolves from the parent again")
+	assert.True(t, *flag.Value.Bool)
+	assert.Equal(t, domain.DefaultEnvironment, flag.SourceEnvironment)
+
+	_, err = store.GetAsOf(ctx, domain.DefaultProject, "staging", "new-checkout", created.Add(-time.Second))
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	snapshot, err := store.ListAsOf(ctx, domain.DefaultProject, domain.DefaultEnvironment, beforeUpdate)
+	require.NoError(t, err)
+	require.Len(t, snapshot, 1)
+	assert.False(t, *snapshot[0].Value.Bool)
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import "github.com/xNakero/feature-flags/internal/domain"
+
+// Tables created by earlier releases are brought to the current schema by the
+// statements below, run around the CREATE TABLE IF NOT EXISTS ones, so an
+// upgraded database ends up like a fresh one. They only act on what is still
+// in an old shape and CreateSchema runs each batch as one implicit
+// transaction, so running them again, or after a failed attempt, is safe.
+
+// moveLegacyFlags renames flag tables that predate environments, when a flag
+// kept its state in flags itself, or projects, when flags were keyed by name
+// alone, out of the way of the current ones. Columns added to the single flags
+// table over time are filled in with their defaults so copyLegacyFlags can
+// read any release's table the same way.
+const moveLegacyFlags = `
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'flags' AND column_name = 'bool_value') THEN
+        ALTER TABLE flags RENAME TO flags_unsplit;
+        ALTER INDEX flags_pkey RENAME TO flags_unsplit_pkey;
+        ALTER TABLE flags_unsplit
+            ADD COLUMN IF NOT EXISTS rules      JSONB  NOT NULL DEFAULT '[]',
+            ADD COLUMN IF NOT EXISTS targets    JSONB  NOT NULL DEFAULT '{}',
+            ADD COLUMN IF NOT EXISTS variants   JSONB  NOT NULL DEFAULT '[]',
+            ADD COLUMN IF NOT EXISTS experiment JSONB,
+            ADD COLUMN IF NOT EXISTS version    BIGINT NOT NULL DEFAULT 1;
+    ELSIF to_regclass('flags') IS NOT NULL AND NOT EXISTS (SELECT FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'flags' AND column_name = 'project') THEN
+        ALTER TABLE flags RENAME TO flags_unscoped;
+        ALTER INDEX flags_pkey RENAME TO flags_unscoped_pkey;
+        ALTER TABLE flag_environments RENAME TO flag_environments_unscoped;
+        ALTER INDEX flag_environments_pkey RENAME TO flag_environments_unscoped_pkey;
+        DROP INDEX IF EXISTS flag_environments_environment;
+    END IF;
+END
+$$;`
+
+// copyLegacyFlags moves the flags set aside by moveLegacyFlags into the
+// default project, and those from before environments into the default
+// environment, then drops the old tables along with the foreign keys other
+// tables had on them. It adds the columns later releases gave to tables that
+// are otherwise unchanged and records the current state of every flag without
+// a history as its first version.
+const copyLegacyFlags = `
+DO $$
+BEGIN
+    IF to_regclass('flags_unsplit') IS NOT NULL THEN
+        INSERT INTO flags (project, name, type, description, variants, created_at)
+        SELECT '` + domain.DefaultProject + `', name, type, description, variants, created_at FROM flags_unsplit;
+        INSERT INTO flag_environments (project, flag_name, environment, bool_value, numeric_value, rules, targets, experiment, version, updated_at)
+        SELECT '` + domain.DefaultProject + `', name, '` + domain.DefaultEnvironment + `', bool_value, numeric_value, rules, targets,
+               experiment || jsonb_build_object('project', '` + domain.DefaultProject + `', 'environment', '` + domain.DefaultEnvironment + `'),
+               version, updated_at
+        FROM flags_unsplit;
+        DROP TABLE flags_unsplit CASCADE;
+    END IF;
+    IF to_regclass('flags_unscoped') IS NOT NULL THEN
+        INSERT INTO flags (project, name, type, description, variants, created_at)
+        SELECT '` + domain.DefaultProject + `', name, type, description, variants, created_at FROM flags_unscoped;
+        INSERT INTO flag_environments (project, flag_name, environment, enabled, bool_value, numeric_value, rules, targets, experiment, version, updated_at)
+        SELECT '` + domain.DefaultProject + `', flag_name, environment, enabled, bool_value, numeric_value, rules, targets,
+               experiment || jsonb_build_object('project', '` + domain.DefaultProject + `'),
+               version, updated_at
+        FROM flag_environments_unscoped;
+        DROP TABLE flag_environments_unscoped CASCADE;
+        DROP TABLE flags_unscoped CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT FROM information_schema.columns
+                   WHERE table_schema = current_schema() AND table_name = 'promotions' AND column_name = 'project') THEN
+        ALTER TABLE promotions ADD COLUMN project TEXT NOT NULL DEFAULT '` + domain.DefaultProject + `' REFERENCES projects (key);
+        ALTER TABLE promotions ALTER COLUMN project DROP DEFAULT;
+    END IF;
+END
+$$;
+ALTER TABLE environments ADD COLUMN IF NOT EXISTS parent TEXT REFERENCES environments (key);
+ALTER TABLE flags ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+INSERT INTO flag_history (project, flag_name, environment, version, enabled, bool_value, numeric_value, rules, targets, recorded_at)
+SELECT e.project, e.flag_name, e.environment, e.version, e.enabled, e.bool_value, e.numeric_value, e.rules, e.targets, e.updated_at
+FROM flag_environments e
+WHERE NOT EXISTS (
+    SELECT FROM flag_history h WHERE h.project = e.project AND h.flag_name = e.flag_name AND h.environment = e.environment
+);`
+
+// moveLegacyExperiments gives experiments from before environments and
+// projects their columns, in the default environment and project, and
+// replaces the keys and the index that named the flag alone. It must run after
+// the flag schema is current.
+const moveLegacyExperiments = `
+DO $$
+BEGIN
+    IF to_regclass('experiments') IS NOT NULL AND NOT EXISTS (SELECT FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'experiments' AND column_name = 'environment') THEN
+        ALTER TABLE experiments ADD COLUMN environment TEXT NOT NULL DEFAULT '` + domain.DefaultEnvironment + `' REFERENCES environments (key);
+        ALTER TABLE experiments ALTER COLUMN environment DROP DEFAULT;
+    END IF;
+    IF to_regclass('experiments') IS NOT NULL AND NOT EXISTS (SELECT FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'experiments' AND column_name = 'project') THEN
+        ALTER TABLE experiments ADD COLUMN project TEXT NOT NULL DEFAULT '` + domain.DefaultProject + `';
+        ALTER TABLE experiments
+            ALTER COLUMN project DROP DEFAULT,
+            ADD FOREIGN KEY (project, flag_name) REFERENCES flags (project, name);
+        DROP INDEX IF EXISTS experiments_one_running_per_flag;
+    END IF;
+END
+$$;`
@@ -0,0 +1,184 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+// baselineSchema is the flags table of the first release, before versions,
+// rules, environments and projects.
+const baselineSchema = `
+CREATE TABLE flags (
+    name          TEXT PRIMARY KEY,
+    type          TEXT             NOT NULL CHECK (type IN ('boolean', 'numeric')),
+    description   TEXT             NOT NULL DEFAULT '',
+    bool_value    BOOLEAN,
+    numeric_value DOUBLE PRECISION,
+    created_at    TIMESTAMPTZ      NOT NULL,
+    updated_at    TIMESTAMPTZ      NOT NULL,
+    CONSTRAINT exactly_one_value CHECK (
+        (type = 'boolean' AND bool_value IS NOT NULL AND numeric_value IS NULL) OR
+        (type = 'numeric' AND numeric_value IS NOT NULL AND bool_value IS NULL)
+    )
+);
+INSERT INTO flags (name, type, description, bool_value, numeric_value, created_at, updated_at) VALUES
+    ('dark-mode', 'boolean', 'theme', TRUE, NULL, '2026-01-01T00:00:00Z', '2026-02-01T00:00:00Z'),
+    ('rate-limit', 'numeric', '', NULL, 2.5, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z');`
+
+// environmentsSchema is the schema as of environment inheritance, with flags
+// split per environment but not yet scoped to a project.
+const environmentsSchema = `
+CREATE TABLE environments (
+    key         TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    parent      TEXT        REFERENCES environments (key),
+    created_at  TIMESTAMPTZ NOT NULL
+);
+INSERT INTO environments (key, created_at) VALUES ('production', now()), ('staging', now());
+CREATE TABLE flags (
+    name        TEXT PRIMARY KEY,
+    type        TEXT        NOT NULL CHECK (type IN ('boolean', 'numeric')),
+    description TEXT        NOT NULL DEFAULT '',
+    variants    JSONB       NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE flag_environments (
+    flag_name     TEXT             NOT NULL REFERENCES flags (name),
+    environment   TEXT             NOT NULL REFERENCES environments (key),
+    enabled       BOOLEAN          NOT NULL DEFAULT TRUE,
+    bool_value    BOOLEAN,
+    numeric_value DOUBLE PRECISION,
+    rules         JSONB            NOT NULL DEFAULT '[]',
+    targets       JSONB            NOT NULL DEFAULT '{}',
+    experiment    JSONB,
+    version       BIGINT           NOT NULL DEFAULT 1,
+    updated_at    TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (flag_name, environment),
+    CONSTRAINT exactly_one_value CHECK (num_nonnulls(bool_value, numeric_value) = 1)
+);
+CREATE INDEX flag_environments_environment ON flag_environments (environment, flag_name);
+CREATE TABLE promotions (
+    id          BIGSERIAL PRIMARY KEY,
+    source      TEXT        NOT NULL REFERENCES environments (key),
+    target      TEXT        NOT NULL REFERENCES environments (key),
+    diffs       JSONB       NOT NULL,
+    promoted_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE layers (
+    key         TEXT PRIMARY KEY,
+    description TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE experiments (
+    key          TEXT PRIMARY KEY,
+    flag_name    TEXT        NOT NULL REFERENCES flags (name),
+    environment  TEXT        NOT NULL REFERENCES environments (key),
+    allocation   INTEGER     NOT NULL CHECK (allocation BETWEEN 1 AND 100),
+    variants     JSONB       NOT NULL,
+    layer        TEXT        REFERENCES layers (key),
+    layer_offset INTEGER     NOT NULL DEFAULT 0 CHECK (layer_offset >= 0 AND layer_offset + allocation <= 100),
+    holdout      INTEGER     NOT NULL DEFAULT 0 CHECK (holdout BETWEEN 0 AND 99),
+    bandit       JSONB,
+    status       TEXT        NOT NULL CHECK (status IN ('draft', 'running', 'stopped')),
+    started_at   TIMESTAMPTZ,
+    stopped_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX experiments_one_running_per_flag
+    ON experiments (flag_name, environment) WHERE status = 'running';
+INSERT INTO flags (name, type, created_at) VALUES ('checkout', 'boolean', now());
+INSERT INTO flag_environments (flag_name, environment, bool_value, version, updated_at) VALUES
+    ('checkout', 'production', FALSE, 3, now()),
+    ('checkout', 'staging', TRUE, 1, now());
+INSERT INTO promotions (source, target, diffs, promoted_at) VALUES ('staging', 'production', '[]', now());
+INSERT INTO experiments (key, flag_name, environment, allocation, variants, status, created_at, updated_at)
+    VALUES ('checkout-test', 'checkout', 'staging', 50, '[]', 'draft', now(), now());`
+
+func TestFlagStore_CreateSchema_MigratesBaseline(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool := testutil.NewPostgresPool(t)
+	_, err := pool.Exec(ctx, baselineSchema)
+	require.NoError(t, err)
+
+	store := postgres.NewFlagStore(pool)
+	require.NoError(t, store.CreateSchema(ctx))
+	require.NoError(t, store.CreateSchema(ctx), "migrating twice is a no-op")
+
+	flag, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "dark-mode")
+	require.NoError(t, err)
+	assert.Equal(t, domain.FlagTypeBoolean, flag.Type)
+	assert.Equal(t, "theme", flag.Description)
+	require.NotNil(t, flag.Value.Bool)
+	assert.True(t, *flag.Value.Bool)
+	assert.Equal(t, int64(1), flag.Version)
+	assert.False(t, flag.Disabled)
+
+	names, err := store.ListNames(ctx, domain.DefaultProject, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dark-mode", "rate-limit"}, names)
+
+	history, err := store.ListHistory(ctx, domain.DefaultProject, domain.DefaultEnvironment, "rate-limit")
+	require.NoError(t, err)
+	require.Len(t, history, 1, "the migrated state is the first version")
+
+	limit := 5.0
+	updated, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "rate-limit", domain.FlagValue{Numeric: &limit}, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+}
+
+func TestFlagStore_CreateSchema_MigratesUnscopedFlags(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	pool := testutil.NewPostgresPool(t)
+	_, err := pool.Exec(ctx, environmentsSchema)
+	require.NoError(t, err)
+
+	store := postgres.NewFlagStore(pool)
+	experiments := postgres.NewExperimentStore(pool)
+	for range 2 {
+		require.NoError(t, store.CreateSchema(ctx))
+		require.NoError(t, experiments.CreateSchema(ctx))
+	}
+
+	flag, err := store.GetByName(ctx, domain.DefaultProject, "staging", "checkout")
+	require.NoError(t, err)
+	require.NotNil(t, flag.Value.Bool)
+	assert.True(t, *flag.Value.Bool)
+	flag, err = store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), flag.Version)
+	assert.Empty(t, flag.Tags)
+
+	promotions, err := store.ListPromotions(ctx, domain.DefaultProject)
+	require.NoError(t, err)
+	assert.Len(t, promotions, 1)
+
+	exp, err := experiments.GetByKey(ctx, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultProject, exp.Project)
+	assert.Equal(t, "staging", exp.Environment)
+
+	on := true
+	err = store.Create(ctx, domain.Flag{
+		Project:   domain.DefaultProject,
+		Name:      "checkout",
+		Type:      domain.FlagTypeBoolean,
+		Value:     domain.FlagValue{Bool: &on},
+		Version:   1,
+		CreatedAt: flag.CreatedAt,
+		UpdatedAt: flag.CreatedAt,
+	})
+	assert.ErrorIs(t, err, domain.ErrAlreadyExists)
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// outboxSchema holds the events of committed flag changes and how far each
+// sink has been published to. Events are written by recordAudit while it
+// holds the audit lock, so their ids are allocated in commit order and a
+// reader never sees a later id before an earlier one commits.
+const outboxSchema = `
+CREATE TABLE IF NOT EXISTS outbox (
+    id              BIGSERIAL PRIMARY KEY,
+    idempotency_key UUID        NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    action          TEXT        NOT NULL,
+    project         TEXT        NOT NULL,
+    environment     TEXT        NOT NULL,
+    flag_name       TEXT        NOT NULL,
+    version         BIGINT      NOT NULL,
+    actor           TEXT        NOT NULL,
+    request_id      TEXT        NOT NULL DEFAULT '',
+    occurred_at     TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS outbox_cursors (
+    sink         TEXT PRIMARY KEY,
+    owner        TEXT        NOT NULL,
+    position     BIGINT      NOT NULL,
+    leased_until TIMESTAMPTZ NOT NULL
+);`
+
+const (
+	outboxColumns = `id, idempotency_key::text, action, project, environment, flag_name, version, actor, request_id, occurred_at`
+	cursorColumns = `sink, owner, position, leased_until`
+)
+
+// recordEvent appends the audited change to the outbox in the same
+// transaction. It must run under the audit lock taken by recordAudit.
+func recordEvent(ctx context.Context, q querier, entry domain.AuditEntry, version int64) error {
+	_, err := q.Exec(ctx,
+		`INSERT INTO outbox (action, project, environment, flag_name, version, actor, request_id, occurred_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		string(entry.Action), entry.Project, entry.Environment, entry.FlagName, version, entry.Actor, entry.RequestID,
+		entry.RecordedAt,
+	)
+	return err
+}
+
+// ClaimOutbox takes the lease when it is free, expired or already the
+// owner's, in one statement so that two relays cannot both win it. A new
+// cursor starts before the oldest event still kept, which PruneOutbox keeps
+// for every configured sink until it has a cursor, so events written before a
+// sink's first claim succeeds are not skipped.
+func (s *FlagStore) ClaimOutbox(ctx context.Context, sink, owner string, now, until time.Time) (*domain.OutboxCursor, bool, error) {
+	cursor, err := scanCursor(s.pool.QueryRow(ctx,
+		`INSERT INTO outbox_cursors (sink, owner, position, leased_until)
+		 VALUES ($1, $2, (SELECT coalesce(min(id) - 1, 0) FROM outbox), $4)
+		 ON CONFLICT (sink) DO UPDATE SET owner = EXCLUDED.owner, leased_until = EXCLUDED.leased_until
+		 WHERE outbox_cursors.owner = EXCLUDED.owner OR outbox_cursors.leased_until <= $3
+		 RETURNING `+cursorColumns,
+		sink, owner, now, until,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &cursor, true, nil
+}
+
+func (s *FlagStore) ListOutbox(ctx context.Context, afterID int64, limit int) ([]domain.FlagEvent, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+outboxColumns+` FROM outbox WHERE id > $1 ORDER BY id LIMIT $2`,
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.FlagEvent, error) {
+		return scanEvent(row)
+	})
+}
+
+func (s *FlagStore) AdvanceOutbox(ctx context.Context, cursor domain.OutboxCursor) (bool, error) {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE outbox_cursors SET position = $3, leased_until = $4 WHERE sink = $1 AND owner = $2`,
+		cursor.Sink, cursor.Owner, cursor.Position, cursor.LeasedUntil,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// PruneOutbox deletes nothing while one of the sinks has no cursor yet: it
+// starts at the oldest event kept once it claims one.
+func (s *FlagStore) PruneOutbox(ctx context.Context, sinks []string) (int64, error) {
+	tag, err := s.pool.Exec(ctx,
+		`DELETE FROM outbox
+		 WHERE id <= (SELECT min(position) FROM outbox_cursors WHERE sink = ANY($1))
+		   AND (SELECT count(*) FROM outbox_cursors WHERE sink = ANY($1)) = (SELECT count(DISTINCT sink) FROM unnest($1::text[]) AS sink)`,
+		sinks,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanEvent(row pgx.Row) (domain.FlagEvent, error) {
+	var (
+		event  domain.FlagEvent
+		action string
+	)
+	err := row.Scan(&event.ID, &event.Key, &action, &event.Project, &event.Environment, &event.FlagName, &event.Version,
+		&event.Actor, &event.RequestID, &event.OccurredAt)
+	event.Action = domain.AuditAction(action)
+	return event, err
+}
+
+func scanCursor(row pgx.Row) (domain.OutboxCursor, error) {
+	var cursor domain.OutboxCursor
+	err := row.Scan(&cursor.Sink, &cursor.Owner, &cursor.Position, &cursor.LeasedUntil)
+	return cursor, err
+}
@@ -0,0 +1,109 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestFlagStore_Outbox(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := port.WithRequestID(port.WithActor(context.Background(), "alice"), "req-1")
+
+	off, on := false, true
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Type: domain.FlagTypeBoolean, Environment: domain.DefaultEnvironment,
+		Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.NoError(t, err)
+	_, err = store.UpdateValue(ctx, domain.DefaultProject, domain.DefaultEnvironment, "ghost", domain.FlagValue{Bool: &on}, time.Now().UTC())
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	events, err := store.ListOutbox(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2, "failed changes write no event")
+	assert.Equal(t, domain.AuditCreate, events[0].Action)
+	assert.Equal(t, int64(1), events[0].Version)
+	update := events[1]
+	assert.Greater(t, update.ID, events[0].ID)
+	assert.NotEmpty(t, update.Key)
+	assert.NotEqual(t, events[0].Key, update.Key)
+	assert.Equal(t, domain.AuditUpdateValue, update.Action)
+	assert.Equal(t, domain.DefaultEnvironment, update.Environment)
+	assert.Equal(t, "new-checkout", update.FlagName)
+	assert.Equal(t, int64(2), update.Version)
+	assert.Equal(t, "alice", update.Actor)
+	assert.Equal(t, "req-1", update.RequestID)
+
+	rest, err := store.ListOutbox(ctx, events[0].ID, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, update, rest[0])
+}
+
+func TestFlagStore_OutboxCursors(t *testing.T) {
+	t.Parallel()
+	store := newStore(t)
+	ctx := context.Background()
+
+	off := false
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(t, store.Create(ctx, domain.Flag{
+		Project: domain.DefaultProject, Name: "new-checkout", Type: domain.FlagTypeBoolean, Environment: domain.DefaultEnvironment,
+		Value: domain.FlagValue{Bool: &off}, Version: 1, CreatedAt: now, UpdatedAt: now,
+	}))
+	events, err := store.ListOutbox(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	cursor, ok, err := store.ClaimOutbox(ctx, "webhooks", "relay-a", now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, events[0].ID-1, cursor.Position, "a new sink starts before the oldest event kept")
+	_, ok, err = store.ClaimOutbox(ctx, "webhooks", "relay-b", now.Add(30*time.Second), now.Add(90*time.Second))
+	require.NoError(t, err)
+	assert.False(t, ok, "a running lease is not taken")
+	_, ok, err = store.ClaimOutbox(ctx, "webhooks", "relay-a", now.Add(30*time.Second), now.Add(90*time.Second))
+	require.NoError(t, err)
+	assert.True(t, ok, "the owner renews its lease")
+
+	taken, ok, err := store.ClaimOutbox(ctx, "webhooks", "relay-b", now.Add(2*time.Minute), now.Add(3*time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok, "an expired lease is taken over")
+	assert.Equal(t, "relay-b", taken.Owner)
+	cursor.LeasedUntil = now.Add(4 * time.Minute)
+	advanced, err := store.AdvanceOutbox(ctx, *cursor)
+	require.NoError(t, err)
+	assert.False(t, advanced, "a relay that lost its lease cannot move the cursor")
+
+	log, ok, err := store.ClaimOutbox(ctx, "log", "relay-b", now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	log.Position = 0
+	advanced, err = store.AdvanceOutbox(ctx, *log)
+	require.NoError(t, err)
+	require.True(t, advanced)
+	taken.Position = events[0].ID
+	advanced, err = store.AdvanceOutbox(ctx, *taken)
+	require.NoError(t, err)
+	require.True(t, advanced)
+	pruned, err := store.PruneOutbox(ctx, []string{"webhooks", "log"})
+	require.NoError(t, err)
+	assert.Zero(t, pruned, "events a sink has not received are kept")
+	pruned, err = store.PruneOutbox(ctx, []string{"webhooks", "redis"})
+	require.NoError(t, err)
+	assert.Zero(t, pruned, "events are kept for a sink that has not claimed its cursor yet")
+	pruned, err = store.PruneOutbox(ctx, []string{"webhooks"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+}
@@ -0,0 +1,257 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// webhookSchema holds the subscriptions and their delivery queue. Pending
+// deliveries are found through a partial index on their due time; delivered
+// and dead ones stay behind as the delivery log. A webhook has at most one
+// delivery of each event, however often the event is published.
+const webhookSchema = `
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         BIGSERIAL PRIMARY KEY,
+    url        TEXT        NOT NULL,
+    events     TEXT[]      NOT NULL DEFAULT '{}',
+    secret     TEXT        NOT NULL,
+    created_by TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      BIGINT      NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_key       TEXT        NOT NULL,
+    action          TEXT        NOT NULL,
+    project         TEXT        NOT NULL,
+    environment     TEXT        NOT NULL,
+    flag_name       TEXT        NOT NULL,
+    version         BIGINT      NOT NULL,
+    actor           TEXT        NOT NULL,
+    request_id      TEXT        NOT NULL DEFAULT '',
+    occurred_at     TIMESTAMPTZ NOT NULL,
+    status          TEXT        NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    response_status INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL,
+    delivered_at    TIMESTAMPTZ,
+    UNIQUE (webhook_id, event_key)
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due
+    ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook
+    ON webhook_deliveries (webhook_id, id);`
+
+const (
+	webhookColumns  = `id, url, events, secret, created_by, created_at`
+	deliveryColumns = `id, webhook_id, event_key, action, project, environment, flag_name, version, actor, request_id, occurred_at,
+    status, attempts, next_attempt_at, response_status, last_error, created_at, delivered_at`
+)
+
+// WebhookStore keeps webhook subscriptions and queues their deliveries.
+type WebhookStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookStore(pool *pgxpool.Pool) *WebhookStore {
+	return &WebhookStore{pool: pool}
+}
+
+func (s *WebhookStore) CreateSchema(ctx context.Context) error {
+	_, err := s.pool.Exec(ctx, webhookSchema)
+	return err
+}
+
+func (s *WebhookStore) CreateWebhook(ctx context.Context, hook domain.Webhook) (*domain.Webhook, error) {
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO webhooks (url, events, secret, created_by, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		hook.URL, actionArray(hook.Events), hook.Secret, hook.CreatedBy, hook.CreatedAt,
+	).Scan(&hook.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (s *WebhookStore) GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	hook, err := scanWebhook(s.pool.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("webhook %d: %w", id, domain.ErrWebhookNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (s *WebhookStore) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.Webhook, error) {
+		return scanWebhook(row)
+	})
+}
+
+func (s *WebhookStore) DeleteWebhook(ctx context.Context, id int64) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("webhook %d: %w", id, domain.ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// EnqueueDeliveries sends the inserts as one batch. Deliveries of an event
+// the webhook already has are dropped by the unique constraint, so an event
+// published twice is still delivered once.
+func (s *WebhookStore) EnqueueDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(
+			`INSERT INTO webhook_deliveries (webhook_id, event_key, action, project, environment, flag_name, version, actor,
+			     request_id, occurred_at, status, next_attempt_at, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			 ON CONFLICT (webhook_id, event_key) DO NOTHING`,
+			d.WebhookID, d.Event.Key, string(d.Event.Action), d.Event.Project, d.Event.Environment, d.Event.FlagName,
+			d.Event.Version, d.Event.Actor, d.Event.RequestID, d.Event.OccurredAt, string(d.Status), d.NextAttemptAt,
+			d.CreatedAt,
+		)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// ClaimDeliveries locks the due rows with SKIP LOCKED, so concurrent workers
+// claim disjoint batches, and pushes their due time back by lease in the same
+// statement. A worker that dies mid-attempt leaves the delivery to be claimed
+// again once the lease runs out.
+func (s *WebhookStore) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	rows, err := s.pool.Query(ctx,
+		`WITH due AS (
+		     SELECT id AS due_id FROM webhook_deliveries
+		     WHERE status = 'pending' AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at, id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE webhook_deliveries d SET next_attempt_at = $2
+		 FROM due WHERE d.id = due.due_id
+		 RETURNING `+deliveryColumns,
+		now, now.Add(lease), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.WebhookDelivery, error) {
+		return scanDelivery(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING gives no order; attempt the oldest first.
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int { return cmp.Compare(a.ID, b.ID) })
+	return deliveries, nil
+}
+
+func (s *WebhookStore) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $2, attempts = $3, next_attempt_at = $4, response_status = $5, last_error = $6, delivered_at = $7
+		 WHERE id = $1`,
+		d.ID, string(d.Status), d.Attempts, d.NextAttemptAt, d.ResponseStatus, d.LastError, nullTime(d.DeliveredAt),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("delivery %d: %w", d.ID, domain.ErrDeliveryNotFound)
+	}
+	return nil
+}
+
+func (s *WebhookStore) GetDelivery(ctx context.Context, webhookID, id int64) (*domain.WebhookDelivery, error) {
+	d, err := scanDelivery(s.pool.QueryRow(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2`, id, webhookID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("delivery %d of webhook %d: %w", id, webhookID, domain.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *WebhookStore) ListDeliveries(ctx context.Context, q domain.DeliveryQuery) ([]domain.WebhookDelivery, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		 WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		 ORDER BY id DESC
+		 LIMIT $3`,
+		q.WebhookID, string(q.Status), q.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.WebhookDelivery, error) {
+		return scanDelivery(row)
+	})
+}
+
+func scanWebhook(row pgx.Row) (domain.Webhook, error) {
+	var (
+		hook   domain.Webhook
+		events []string
+	)
+	if err := row.Scan(&hook.ID, &hook.URL, &events, &hook.Secret, &hook.CreatedBy, &hook.CreatedAt); err != nil {
+		return hook, err
+	}
+	for _, event := range events {
+		hook.Events = append(hook.Events, domain.AuditAction(event))
+	}
+	return hook, nil
+}
+
+func scanDelivery(row pgx.Row) (domain.WebhookDelivery, error) {
+	var (
+		d           domain.WebhookDelivery
+		action      string
+		status      string
+		deliveredAt *time.Time
+	)
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event.Key, &action, &d.Event.Project, &d.Event.Environment, &d.Event.FlagName,
+		&d.Event.Version, &d.Event.Actor, &d.Event.RequestID, &d.Event.OccurredAt, &status, &d.Attempts,
+		&d.NextAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &deliveredAt)
+	if err != nil {
+		return d, err
+	}
+	d.Event.Action = domain.AuditAction(action)
+	d.Status = domain.DeliveryStatus(status)
+	if deliveredAt != nil {
+		d.DeliveredAt = *deliveredAt
+	}
+	return d, nil
+}
+
+func actionArray(actions []domain.AuditAction) []string {
+	out := make([]string, len(actions))
+	for i, action := range actions {
+		out[i] = string(action)
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/postgres"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestWebhookStore(t *testing.T) {
+	t.Parallel()
+	pool := testutil.NewPostgresPool(t)
+	store := postgres.NewWebhookStore(pool)
+	ctx := context.Background()
+	require.NoError(t, store.CreateSchema(ctx))
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	hook, err := store.CreateWebhook(ctx, domain.Webhook{
+		URL: "https://deploys.example.com/flags", Events: []domain.AuditAction{domain.AuditUpdateValue},
+		Secret: "0123456789abcdef", CreatedBy: "alice", CreatedAt: now,
+	})
+	require.NoError(t, err)
+	got, err := store.GetWebhook(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *hook, *got)
+	all, err := store.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	_, err = store.GetWebhook(ctx, hook.ID+1)
+	require.ErrorIs(t, err, domain.ErrWebhookNotFound)
+
+	event := domain.FlagEvent{
+		Key:    "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10",
+		Action: domain.AuditUpdateValue, Project: domain.DefaultProject, Environment: domain.DefaultEnvironment,
+		FlagName: "new-checkout", Version: 2, Actor: "alice", RequestID: "req-1", OccurredAt: now,
+	}
+	require.NoError(t, store.EnqueueDeliveries(ctx, []domain.WebhookDelivery{
+		{WebhookID: hook.ID, Event: event, Status: domain.DeliveryPending, NextAttemptAt: now, CreatedAt: now},
+	}))
+	later := event
+	later.Key = "0d6b0f7e-3c1a-4f0e-b1a4-7c2e9d5a8b31"
+	require.NoError(t, store.EnqueueDeliveries(ctx, []domain.WebhookDelivery{
+		{WebhookID: hook.ID, Event: event, Status: domain.DeliveryPending, NextAttemptAt: now, CreatedAt: now},
+		{WebhookID: hook.ID, Event: later, Status: domain.DeliveryPending, NextAttemptAt: now.Add(time.Hour), CreatedAt: now},
+	}), "an event already queued is skipped")
+
+	claimed, err := store.ClaimDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "deliveries not yet due are left")
+	assert.Equal(t, event, claimed[0].Event)
+	assert.Equal(t, now.Add(time.Minute), claimed[0].NextAttemptAt, "a claim is leased")
+	again, err := store.ClaimDeliveries(ctx, now, time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again, "leased deliveries are not claimed twice")
+
+	d := claimed[0]
+	d.Succeed(now, 204)
+	require.NoError(t, store.UpdateDelivery(ctx, d))
+	delivered, err := store.GetDelivery(ctx, hook.ID, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, d, *delivered)
+	_, err = store.GetDelivery(ctx, hook.ID+1, d.ID)
+	require.ErrorIs(t, err, domain.ErrDeliveryNotFound)
+
+	log, err := store.ListDeliveries(ctx, domain.DeliveryQuery{WebhookID: hook.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Greater(t, log[0].ID, log[1].ID, "newest first")
+	log, err = store.ListDeliveries(ctx, domain.DeliveryQuery{WebhookID: hook.ID, Status: domain.DeliveryDelivered, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+
+	require.NoError(t, store.DeleteWebhook(ctx, hook.ID))
+	require.ErrorIs(t, store.DeleteWebhook(ctx, hook.ID), domain.ErrWebhookNotFound)
+	log, err = store.ListDeliveries(ctx, domain.DeliveryQuery{WebhookID: hook.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, log, "deliveries go with their webhook")
+}
@@ -0,0 +1,71 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// streamMaxLen is roughly how many events a stream keeps; older ones are
+// trimmed as new ones are added.
+const streamMaxLen = 100_000
+
+// EventStream publishes outbox events to a Redis stream. Each entry's id is
+// the event's outbox id, so entries are in outbox order and an event
+// published again is refused by Redis instead of being added twice.
+type EventStream struct {
+	client redis.UniversalClient
+	stream string
+}
+
+func NewEventStream(client redis.UniversalClient, stream string) *EventStream {
+	return &EventStream{client: client, stream: stream}
+}
+
+func (s *EventStream) Name() string { return "redis-stream" }
+
+// Publish adds the events with one pipelined XADD each. An XADD refused
+// because the stream already holds the id is an event published before.
+func (s *EventStream) Publish(ctx context.Context, events []domain.FlagEvent) error {
+	cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: s.stream,
+				ID:     strconv.FormatInt(event.ID, 10) + "-0",
+				MaxLen: streamMaxLen,
+				Approx: true,
+				Values: []any{
+					"idempotency_key", event.Key,
+					"type", string(event.Action),
+					"project", event.Project,
+					"environment", event.Environment,
+					"flag", event.FlagName,
+					"version", event.Version,
+					"actor", event.Actor,
+					"request_id", event.RequestID,
+					"occurred_at", event.OccurredAt.UTC().Format(time.RFC3339Nano),
+				},
+			})
+		}
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !published(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// published reports whether err is Redis refusing an id that is not above
+// the stream's last one.
+func published(err error) bool {
+	return strings.Contains(err.Error(), "equal or smaller than the target stream top item")
+}
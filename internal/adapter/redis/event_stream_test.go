@@ -0,0 +1,41 @@
+//go:build integration
+
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/redis"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func TestEventStream_Publish(t *testing.T) {
+	t.Parallel()
+	client := testutil.NewRedisClient(t)
+	stream := redis.NewEventStream(client, "flags:events")
+	ctx := context.Background()
+
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	events := []domain.FlagEvent{
+		{ID: 1, Key: "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10", Action: domain.AuditCreate, Project: "checkout", Environment: "production", FlagName: "new-checkout", Version: 1, Actor: "alice", OccurredAt: at},
+		{ID: 2, Key: "0d6b0f7e-3c1a-4f0e-b1a4-7c2e9d5a8b31", Action: domain.AuditUpdateValue, Project: "checkout", Environment: "production", FlagName: "new-checkout", Version: 2, Actor: "bob", RequestID: "req-2", OccurredAt: at},
+	}
+	require.NoError(t, stream.Publish(ctx, events[:1]))
+	require.NoError(t, stream.Publish(ctx, events), "events published before are skipped")
+
+	entries, err := client.XRange(ctx, "flags:events", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "1-0", entries[0].ID)
+	assert.Equal(t, "2-0", entries[1].ID)
+	assert.Equal(t, map[string]any{
+		"idempotency_key": "0d6b0f7e-3c1a-4f0e-b1a4-7c2e9d5a8b31", "type": "update-value", "project": "checkout",
+		"environment": "production", "flag": "new-checkout", "version": "2", "actor": "bob", "request_id": "req-2",
+		"occurred_at": "2026-06-01T09:00:00Z",
+	}, entries[1].Values)
+}
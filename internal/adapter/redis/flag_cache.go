@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xNakero/feature-flags/internal/adapter/flagjson"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// keyPrefix starts every cache key; the full layout is
+// flags:value:{project}:{environment}:{name}.
+const keyPrefix = "flags:value:"
+
+type FlagCache struct {
+	client redis.UniversalClient
+}
+
+func NewFlagCache(client redis.UniversalClient) *FlagCache {
+	return &FlagCache{client: client}
+}
+
+func (c *FlagCache) Get(ctx context.Context, project, env, name string) (*domain.Flag, error) {
+	raw, err := c.client.Get(ctx, key(project, env, name)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return flagjson.UnmarshalFlag([]byte(raw))
+}
+
+// GetMany fetches all names with a single MGET. Keys that are missing or hold
+// an undecodable document are treated as misses.
+func (c *FlagCache) GetMany(ctx context.Context, project, env string, names []string) (map[string]domain.Flag, error) {
+	hits := make(map[string]domain.Flag, len(names))
+	if len(names) == 0 {
+		return hits, nil
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = key(project, env, name)
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
+		flag, err := flagjson.UnmarshalFlag([]byte(raw))
+		if err != nil {
+			continue
+		}
+		hits[names[i]] = *flag
+	}
+	return hits, nil
+}
+
+func (c *FlagCache) Set(ctx context.Context, flag domain.Flag) error {
+	raw, err := flagjson.MarshalFlag(flag)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key(flag.Project, flag.Environment, flag.Name), raw, 0).Err()
+}
+
+func (c *FlagCache) Delete(ctx context.Context, project, env, name string) error {
+	return c.client.Del(ctx, key(project, env, name)).Err()
+}
+
+func key(project, env, name string) string {
+	return keyPrefix + project + ":" + env + ":" + name
+}
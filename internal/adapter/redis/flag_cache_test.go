@@ -0,0 +1,151 @@
+//go:build integration
+
+package redis_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/redis"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/testutil"
+)
+
+func newCache(t *testing.T) *redis.FlagCache {
+	t.Helper()
+	return redis.NewFlagCache(testutil.NewRedisClient(t))
+}
+
+func TestFlagCache_SetGet_Boolean(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	boolVal := true
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	flag := domain.Flag{
+		Project:     domain.DefaultProject,
+		Name:        "feature-x",
+		Type:        domain.FlagTypeBoolean,
+		Description: "a boolean flag",
+		Environment: domain.DefaultEnvironment,
+		Value:       domain.FlagValue{Bool: &boolVal},
+		Version:     3,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	require.NoError(t, cache.Set(context.Background(), flag))
+
+	got, err := cache.Get(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "feature-x")
+	require.NoError(t, err)
+	assert.Equal(t, flag, *got)
+}
+
+func TestFlagCache_SetGet_Numeric(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	numVal := 0.25
+	flag := domain.Flag{
+		Project:     domain.DefaultProject,
+		Name:        "rollout",
+		Environment: domain.DefaultEnvironment,
+		Type:        domain.FlagTypeNumeric,
+		Value:       domain.FlagValue{Numeric: &numVal},
+		Version:     1,
+	}
+
+	require.NoError(t, cache.Set(context.Background(), flag))
+
+	got, err := cache.Get(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "rollout")
+	require.NoError(t, err)
+	require.NotNil(t, got.Value.Numeric)
+	assert.InDelta(t, numVal, *got.Value.Numeric, 1e-9)
+	assert.Nil(t, got.Value.Bool)
+}
+
+func TestFlagCache_Get_Miss(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	_, err := cache.Get(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagCache_GetMany(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	boolVal := true
+	for _, name := range []string{"alpha", "beta"} {
+		require.NoError(t, cache.Set(context.Background(), domain.Flag{
+			Project:     domain.DefaultProject,
+			Name:        name,
+			Environment: domain.DefaultEnvironment,
+			Type:        domain.FlagTypeBoolean,
+			Value:       domain.FlagValue{Bool: &boolVal},
+			Version:     1,
+		}))
+	}
+
+	hits, err := cache.GetMany(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, []string{"alpha", "ghost", "beta"})
+	require.NoError(t, err)
+	assert.Len(t, hits, 2)
+	assert.Contains(t, hits, "alpha")
+	assert.Contains(t, hits, "beta")
+}
+
+func TestFlagCache_Delete(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	boolVal := true
+	require.NoError(t, cache.Set(context.Background(), domain.Flag{
+		Project:     domain.DefaultProject,
+		Name:        "feature-x",
+		Environment: domain.DefaultEnvironment,
+		Type:        domain.FlagTypeBoolean,
+		Value:       domain.FlagValue{Bool: &boolVal},
+	}))
+
+	require.NoError(t, cache.Delete(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "feature-x"))
+	require.NoError(t, cache.Delete(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "feature-x"))
+
+	_, err := cache.Get(context.Background(), domain.DefaultProject, domain.DefaultEnvironment, "feature-x")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestFlagCache_KeyedByProjectAndEnvironment(t *testing.T) {
+	t.Parallel()
+	cache := newCache(t)
+
+	on := true
+	off := false
+	for _, flag := range []domain.Flag{
+		{Project: domain.DefaultProject, Name: "feature-x", Environment: "staging", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on}},
+		{Project: domain.DefaultProject, Name: "feature-x", Environment: "production", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}},
+		{Project: "checkout", Name: "feature-x", Environment: "staging", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}},
+	} {
+		require.NoError(t, cache.Set(context.Background(), flag))
+	}
+
+	got, err := cache.Get(context.Background(), domain.DefaultProject, "staging", "feature-x")
+	require.NoError(t, err)
+	assert.True(t, *got.Value.Bool)
+
+	require.NoError(t, cache.Delete(context.Background(), domain.DefaultProject, "staging", "feature-x"))
+	_, err = cache.Get(context.Background(), domain.DefaultProject, "staging", "feature-x")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+
+	got, err = cache.Get(context.Background(), domain.DefaultProject, "production", "feature-x")
+	require.NoError(t, err)
+	assert.False(t, *got.Value.Bool)
+
+	hits, err := cache.GetMany(context.Background(), "checkout", "staging", []string{"feature-x"})
+	require.NoError(t, err)
+	require.Contains(t, hits, "feature-x")
+	assert.Equal(t, "checkout", hits["feature-x"].Project)
+}
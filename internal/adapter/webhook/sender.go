@@ -0,0 +1,102 @@
+// Package webhook is the outbound adapter that delivers flag change events to
+// webhook receivers over HTTP.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+const (
+	// IDHeader carries the delivery id, which stays the same across retries.
+	IDHeader = "X-Webhook-ID"
+	// IdempotencyKeyHeader carries the event's key, which stays the same
+	// however often the event is published, so receivers can drop
+	// duplicates.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	EventHeader          = "X-Webhook-Event"
+	TimestampHeader      = "X-Webhook-Timestamp"
+	// SignatureHeader carries "sha256=" and domain.SignWebhook of the
+	// timestamp and body.
+	SignatureHeader = "X-Webhook-Signature"
+
+	userAgent = "feature-flags-webhooks"
+	// maxDrain bounds how much of a response body is read so the connection
+	// can be reused.
+	maxDrain = 64 << 10
+)
+
+// event is the JSON body of a delivery.
+type event struct {
+	ID          int64     `json:"id"`
+	Key         string    `json:"idempotency_key"`
+	Type        string    `json:"type"`
+	Project     string    `json:"project"`
+	Environment string    `json:"environment"`
+	Flag        string    `json:"flag"`
+	Version     int64     `json:"version"`
+	Actor       string    `json:"actor"`
+	RequestID   string    `json:"request_id,omitempty"`
+	OccurredAt  time.Time `json:"occurred_at"`
+}
+
+// Sender posts deliveries as signed JSON. The client's timeout bounds each
+// attempt.
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// Send posts the delivery's event to the webhook's URL. Any 2xx answer
+// acknowledges it; other answers and transport errors fail the attempt.
+func (s *Sender) Send(ctx context.Context, hook domain.Webhook, delivery domain.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(event{
+		ID:          delivery.ID,
+		Key:         delivery.Event.Key,
+		Type:        string(delivery.Event.Action),
+		Project:     delivery.Event.Project,
+		Environment: delivery.Event.Environment,
+		Flag:        delivery.Event.FlagName,
+		Version:     delivery.Event.Version,
+		Actor:       delivery.Event.Actor,
+		RequestID:   delivery.Event.RequestID,
+		OccurredAt:  delivery.Event.OccurredAt,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(IDHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(IdempotencyKeyHeader, delivery.Event.Key)
+	req.Header.Set(EventHeader, string(delivery.Event.Action))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "sha256="+domain.SignWebhook(hook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
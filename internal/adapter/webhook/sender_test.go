@@ -0,0 +1,150 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/adapter/webhook"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+const secret = "whsec-0123456789abcdef"
+
+// receiver is a local webhook endpoint that checks signatures the way a
+// subscriber would and answers with status.
+type receiver struct {
+	status   int
+	bodies   []map[string]any
+	headers  []http.Header
+	verified []bool
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Header.Get(webhook.TimestampHeader) + "."))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	rc.verified = append(rc.verified, hmac.Equal([]byte(want), []byte(r.Header.Get(webhook.SignatureHeader))))
+	var decoded map[string]any
+	_ = json.Unmarshal(body, &decoded)
+	rc.bodies = append(rc.bodies, decoded)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	w.WriteHeader(rc.status)
+}
+
+func delivery() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID: 42, WebhookID: 1, Status: domain.DeliveryPending,
+		Event: domain.FlagEvent{
+			Key:    "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10",
+			Action: domain.AuditUpdateValue, Project: "checkout", Environment: "production", FlagName: "new-checkout",
+			Version: 3, Actor: "alice", RequestID: "req-1", OccurredAt: time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestSender_Send(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "acknowledged", status: http.StatusNoContent},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "server error", status: http.StatusServiceUnavailable, wantErr: true},
+		{name: "rejected", status: http.StatusUnauthorized, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rc := &receiver{status: tt.status}
+			server := httptest.NewServer(rc)
+			t.Cleanup(server.Close)
+			sender := webhook.NewSender(server.Client())
+
+			status, err := sender.Send(context.Background(), domain.Webhook{ID: 1, URL: server.URL + "/hooks", Secret: secret}, delivery())
+
+			assert.Equal(t, tt.status, status)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, rc.bodies, 1)
+			assert.True(t, rc.verified[0], "the signature verifies")
+			assert.Equal(t, "42", rc.headers[0].Get(webhook.IDHeader))
+			assert.Equal(t, "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10", rc.headers[0].Get(webhook.IdempotencyKeyHeader))
+			assert.Equal(t, "update-value", rc.headers[0].Get(webhook.EventHeader))
+			assert.Equal(t, "application/json", rc.headers[0].Get("Content-Type"))
+			assert.Equal(t, map[string]any{
+				"id": float64(42), "idempotency_key": "5f0c6c1e-8d3e-4c55-9a0e-2d8f3f1b7a10", "type": "update-value", "project": "checkout", "environment": "production",
+				"flag": "new-checkout", "version": float64(3), "actor": "alice", "request_id": "req-1",
+				"occurred_at": "2026-06-01T09:00:00Z",
+			}, rc.bodies[0])
+		})
+	}
+}
+
+func TestSender_Send_WrongSecret(t *testing.T) {
+	t.Parallel()
+
+	rc := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(rc)
+	t.Cleanup(server.Close)
+
+	_, err := webhook.NewSender(server.Client()).Send(context.Background(), domain.Webhook{URL: server.URL, Secret: "some-other-secret"}, delivery())
+
+	require.NoError(t, err)
+	assert.False(t, rc.verified[0], "a receiver with another secret rejects the signature")
+}
+
+func TestSender_Send_Unreachable(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	status, err := webhook.NewSender(&http.Client{Timeout: time.Second}).Send(context.Background(), domain.Webhook{URL: url, Secret: secret}, delivery())
+
+	require.Error(t, err)
+	assert.Zero(t, status, "nobody answered")
+}
+
+func TestSender_Send_Timeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+
+	status, err := webhook.NewSender(client).Send(context.Background(), domain.Webhook{URL: server.URL, Secret: secret}, delivery())
+
+	require.Error(t, err)
+	assert.Zero(t, status)
+}
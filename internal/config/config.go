@@ -1,8 +1,18 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net/netip"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
 )
 
 // Config holds all application configuration.
@@ -11,8 +21,61 @@ type Config struct {
 	PostgresDSN string
 	RedisAddr   string
 	LogLevel    string
+	// TrustedProxies are the ranges whose X-Forwarded-For headers are believed
+	// when resolving client addresses. Empty means no proxy is trusted.
+	TrustedProxies []netip.Prefix
+	// HoldoutPercent is the global share of contexts (0-99) that never
+	// receive experimental treatment. It is fixed on each experiment at start.
+	HoldoutPercent int
+	// BanditInterval is how often bandit experiments have their variant
+	// weights recomputed.
+	BanditInterval time.Duration
+	// Naming is the policy new flag names must follow.
+	Naming domain.NamingPolicy
+	// AuditSigningKey signs audit checkpoints. Nil means none are signed.
+	AuditSigningKey ed25519.PrivateKey
+	// AuditPublicKey verifies audit checkpoint signatures. It defaults to the
+	// public half of AuditSigningKey, so a verifier can be given the public
+	// key alone.
+	AuditPublicKey ed25519.PublicKey
+	// AuditCheckpointInterval is how often a signed checkpoint of the audit
+	// log is taken.
+	AuditCheckpointInterval time.Duration
+	// ChangeRequestTTL is how long a change to a protected flag waits for
+	// approval before it expires.
+	ChangeRequestTTL time.Duration
+	// WebhookInterval is how often due webhook deliveries are attempted.
+	WebhookInterval time.Duration
+	// WebhookTimeout bounds a single webhook delivery attempt.
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is how many times a delivery is attempted before it
+	// is dead.
+	WebhookMaxAttempts int
+	// OutboxSinks names the sinks flag change events are published to from
+	// the outbox: any of OutboxSinkNames.
+	OutboxSinks []string
+	// OutboxInterval is how often the outbox is relayed to its sinks.
+	OutboxInterval time.Duration
+	// OutboxStream is the Redis stream the redis sink adds events to.
+	OutboxStream string
+	// APIKeysEnabled requires every request to carry a valid API key. It is
+	// on unless API_KEYS_ENABLED turns it off, for local development. Role
+	// checks come with it: a key whose principal has no role binding may do
+	// nothing.
+	APIKeysEnabled bool
+	// AdminAPIKey authenticates as the bootstrap principal with the admin
+	// scope without being stored, and is exempt from role checks, so the
+	// first keys, roles and role bindings can be created. Empty means none
+	// is accepted.
+	AdminAPIKey string
 }
 
+// minAdminAPIKey is the shortest ADMIN_API_KEY accepted.
+const minAdminAPIKey = 32
+
+// OutboxSinkNames lists the sinks OUTBOX_SINKS may name.
+var OutboxSinkNames = []string{"webhooks", "redis", "log"}
+
 // Load reads configuration from environment variables and returns a Config.
 // Returns an error if required variables are missing.
 func Load() (*Config, error) {
@@ -21,16 +84,268 @@ func Load() (*Config, error) {
 		return nil, errors.New("POSTGRES_DSN environment variable is required")
 	}
 
+	trustedProxies, err := parsePrefixes(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	holdout, err := parseHoldout(os.Getenv("HOLDOUT_PERCENT"))
+	if err != nil {
+		return nil, fmt.Errorf("HOLDOUT_PERCENT: %w", err)
+	}
+
+	banditInterval, err := time.ParseDuration(getEnvOrDefault("BANDIT_INTERVAL", "15m"))
+	if err != nil || banditInterval <= 0 {
+		return nil, fmt.Errorf("BANDIT_INTERVAL: invalid duration %q", os.Getenv("BANDIT_INTERVAL"))
+	}
+
+	naming, err := loadNamingPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey, publicKey, err := loadAuditKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpointInterval, err := time.ParseDuration(getEnvOrDefault("AUDIT_CHECKPOINT_INTERVAL", "1h"))
+	if err != nil || checkpointInterval <= 0 {
+		return nil, fmt.Errorf("AUDIT_CHECKPOINT_INTERVAL: invalid duration %q", os.Getenv("AUDIT_CHECKPOINT_INTERVAL"))
+	}
+
+	changeTTL, err := time.ParseDuration(getEnvOrDefault("CHANGE_REQUEST_TTL", "72h"))
+	if err != nil || changeTTL <= 0 {
+		return nil, fmt.Errorf("CHANGE_REQUEST_TTL: invalid duration %q", os.Getenv("CHANGE_REQUEST_TTL"))
+	}
+
+	webhookInterval, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_INTERVAL", "5s"))
+	if err != nil || webhookInterval <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_INTERVAL: invalid duration %q", os.Getenv("WEBHOOK_INTERVAL"))
+	}
+
+	webhookTimeout, err := time.ParseDuration(getEnvOrDefault("WEBHOOK_TIMEOUT", "10s"))
+	if err != nil || webhookTimeout <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_TIMEOUT: invalid duration %q", os.Getenv("WEBHOOK_TIMEOUT"))
+	}
+
+	webhookAttempts, err := strconv.Atoi(getEnvOrDefault("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || webhookAttempts <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS: invalid count %q", os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	}
+
+	outboxSinks, err := parseSinks(getEnvOrDefault("OUTBOX_SINKS", "webhooks"))
+	if err != nil {
+		return nil, fmt.Errorf("OUTBOX_SINKS: %w", err)
+	}
+
+	outboxInterval, err := time.ParseDuration(getEnvOrDefault("OUTBOX_INTERVAL", "1s"))
+	if err != nil || outboxInterval <= 0 {
+		return nil, fmt.Errorf("OUTBOX_INTERVAL: invalid duration %q", os.Getenv("OUTBOX_INTERVAL"))
+	}
+
+	apiKeysEnabled, err := strconv.ParseBool(getEnvOrDefault("API_KEYS_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("API_KEYS_ENABLED: invalid boolean %q", os.Getenv("API_KEYS_ENABLED"))
+	}
+
+	adminAPIKey := os.Getenv("ADMIN_API_KEY")
+	if adminAPIKey != "" && len(adminAPIKey) < minAdminAPIKey {
+		return nil, fmt.Errorf("ADMIN_API_KEY: must be at least %d characters", minAdminAPIKey)
+	}
+
 	cfg := &Config{
-		PostgresDSN: postgresDSN,
-		HTTPAddr:    getEnvOrDefault("HTTP_ADDR", ":8080"),
-		RedisAddr:   getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
-		LogLevel:    getEnvOrDefault("LOG_LEVEL", "info"),
+		PostgresDSN:    postgresDSN,
+		HTTPAddr:       getEnvOrDefault("HTTP_ADDR", ":8080"),
+		RedisAddr:      getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
+		LogLevel:       getEnvOrDefault("LOG_LEVEL", "info"),
+		TrustedProxies: trustedProxies,
+		HoldoutPercent: holdout,
+		BanditInterval: banditInterval,
+		Naming:         naming,
+
+		ChangeRequestTTL: changeTTL,
+
+		AuditSigningKey:         signingKey,
+		AuditPublicKey:          publicKey,
+		AuditCheckpointInterval: checkpointInterval,
+
+		WebhookInterval:    webhookInterval,
+		WebhookTimeout:     webhookTimeout,
+		WebhookMaxAttempts: webhookAttempts,
+
+		OutboxSinks:    outboxSinks,
+		OutboxInterval: outboxInterval,
+		OutboxStream:   getEnvOrDefault("OUTBOX_STREAM", "flags:events"),
+
+		APIKeysEnabled: apiKeysEnabled,
+		AdminAPIKey:    adminAPIKey,
 	}
 
 	return cfg, nil
 }
 
+// loadAuditKeys reads AUDIT_SIGNING_KEY, a base64 Ed25519 seed, and
+// AUDIT_PUBLIC_KEY, a base64 Ed25519 public key. Both are optional.
+func loadAuditKeys() (ed25519.PrivateKey, ed25519.PublicKey, error) {
+	var (
+		signing ed25519.PrivateKey
+		public  ed25519.PublicKey
+	)
+	if raw := os.Getenv("AUDIT_SIGNING_KEY"); raw != "" {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, nil, fmt.Errorf("AUDIT_SIGNING_KEY: want a base64 %d-byte Ed25519 seed", ed25519.SeedSize)
+		}
+		signing = ed25519.NewKeyFromSeed(seed)
+		public = signing.Public().(ed25519.PublicKey)
+	}
+	if raw := os.Getenv("AUDIT_PUBLIC_KEY"); raw != "" {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("AUDIT_PUBLIC_KEY: want a base64 %d-byte Ed25519 public key", ed25519.PublicKeySize)
+		}
+		if public != nil && !public.Equal(ed25519.PublicKey(key)) {
+			return nil, nil, errors.New("AUDIT_PUBLIC_KEY does not match AUDIT_SIGNING_KEY")
+		}
+		public = key
+	}
+	return signing, public, nil
+}
+
+// parsePrefixes parses a comma-separated list of CIDR ranges. Bare addresses
+// are read as single-host ranges.
+func parsePrefixes(raw string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", item)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseSinks parses a comma-separated list of outbox sinks, each named once.
+func parseSinks(raw string) ([]string, error) {
+	sinks := parseList(raw)
+	for i, sink := range sinks {
+		if !slices.Contains(OutboxSinkNames, sink) {
+			return nil, fmt.Errorf("unknown sink %q, want one of %s", sink, strings.Join(OutboxSinkNames, ", "))
+		}
+		if slices.Contains(sinks[:i], sink) {
+			return nil, fmt.Errorf("sink %q named twice", sink)
+		}
+	}
+	return sinks, nil
+}
+
+// parseHoldout parses a holdout percentage. Empty means no holdout.
+func parseHoldout(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	percent, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || percent < 0 || percent > 99 {
+		return 0, fmt.Errorf("invalid percentage %q, want 0-99", raw)
+	}
+	return percent, nil
+}
+
+// loadNamingPolicy reads the FLAG_NAME_* variables over the default policy
+// and checks the result.
+func loadNamingPolicy() (domain.NamingPolicy, error) {
+	policy := domain.DefaultNamingPolicy()
+	if raw := os.Getenv("FLAG_NAME_CHARSET"); raw != "" {
+		charset, err := parseCharset(raw)
+		if err != nil {
+			return policy, fmt.Errorf("FLAG_NAME_CHARSET: %w", err)
+		}
+		policy.Charset = charset
+	}
+	if raw := os.Getenv("FLAG_NAME_SEPARATORS"); raw != "" {
+		policy.Separators = raw
+	}
+	if raw := os.Getenv("FLAG_NAME_MAX_LENGTH"); raw != "" {
+		maxLength, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return policy, fmt.Errorf("FLAG_NAME_MAX_LENGTH: invalid length %q", raw)
+		}
+		policy.MaxLength = maxLength
+	}
+	prefixes, err := parseProjectPrefixes(os.Getenv("FLAG_NAME_PREFIXES"))
+	if err != nil {
+		return policy, fmt.Errorf("FLAG_NAME_PREFIXES: %w", err)
+	}
+	policy.Prefixes = prefixes
+	policy.Reserved = parseList(os.Getenv("FLAG_NAME_RESERVED"))
+	if err := domain.ValidateNamingPolicy(policy); err != nil {
+		return policy, fmt.Errorf("flag naming policy: %w", err)
+	}
+	return policy, nil
+}
+
+// parseCharset expands a character class such as "a-z0-9_" into the
+// characters it lists. A hyphen is literal at the start or end.
+func parseCharset(raw string) (string, error) {
+	var b strings.Builder
+	chars := []rune(raw)
+	for i := 0; i < len(chars); i++ {
+		if i+2 < len(chars) && chars[i+1] == '-' {
+			lo, hi := chars[i], chars[i+2]
+			if lo > hi {
+				return "", fmt.Errorf("invalid range %q", string(chars[i:i+3]))
+			}
+			for ch := lo; ch <= hi; ch++ {
+				b.WriteRune(ch)
+			}
+			i += 2
+			continue
+		}
+		b.WriteRune(chars[i])
+	}
+	return b.String(), nil
+}
+
+// parseProjectPrefixes parses semicolon-separated project=prefix,prefix
+// entries, such as "checkout=checkout.,payments.;search=search.".
+func parseProjectPrefixes(raw string) (map[string][]string, error) {
+	prefixes := make(map[string][]string)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		project, list, ok := strings.Cut(entry, "=")
+		project = strings.TrimSpace(project)
+		if !ok || project == "" {
+			return nil, fmt.Errorf("invalid entry %q, want project=prefix,prefix", entry)
+		}
+		prefixes[project] = append(prefixes[project], parseList(list)...)
+	}
+	return prefixes, nil
+}
+
+// parseList splits a comma-separated list, dropping blank items.
+func parseList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
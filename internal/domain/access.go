@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// MaxRolePermissions bounds the permissions a role grants.
+	MaxRolePermissions = 50
+	// MaxRoleDescription bounds the length of a role's description.
+	MaxRoleDescription = 500
+)
+
+// Permission grants Scope on the flags of the listed Projects in the listed
+// Environments that are named in Flags or carry any of the listed Tags. An
+// empty list matches every project, environment or flag respectively.
+type Permission struct {
+	Scope        Scope
+	Projects     []string
+	Environments []string
+	Flags        []string
+	Tags         []string
+}
+
+// Resource is what a request acts on. An empty Project or Environment stands
+// for all of them and an empty Flag for every flag, such as when a project is
+// created or a flag is defined in every environment; only a permission that
+// does not restrict that field covers it.
+type Resource struct {
+	Project     string
+	Environment string
+	Flag        string
+	Tags        []string
+}
+
+// String describes the resource for error messages.
+func (r Resource) String() string {
+	project, env := r.Project, r.Environment
+	if project == "" {
+		project = "every project"
+	} else {
+		project = fmt.Sprintf("project %q", project)
+	}
+	if env == "" {
+		env = "every environment"
+	} else {
+		env = fmt.Sprintf("environment %q", env)
+	}
+	if r.Flag == "" {
+		return project + " in " + env
+	}
+	return fmt.Sprintf("flag %q of %s in %s", r.Flag, project, env)
+}
+
+// FlagResource is the flag name, carrying tags, in project and env.
+func FlagResource(project, env, name string, tags []string) Resource {
+	return Resource{Project: project, Environment: env, Flag: name, Tags: tags}
+}
+
+// Allows reports whether the permission grants scope on r.
+func (p Permission) Allows(scope Scope, r Resource) bool {
+	if !p.Scope.Includes(scope) || !matchesAny(p.Projects, r.Project) || !matchesAny(p.Environments, r.Environment) {
+		return false
+	}
+	if r.Flag == "" {
+		return len(p.Flags) == 0 && len(p.Tags) == 0
+	}
+	return matchesAny(p.Flags, r.Flag) &&
+		(len(p.Tags) == 0 || slices.ContainsFunc(r.Tags, func(tag string) bool { return slices.Contains(p.Tags, tag) }))
+}
+
+// Role is a named set of permissions, granted to principals by role bindings.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []Permission
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Allows reports whether one of the role's permissions grants scope on r.
+func (r Role) Allows(scope Scope, res Resource) bool {
+	return slices.ContainsFunc(r.Permissions, func(p Permission) bool { return p.Allows(scope, res) })
+}
+
+// ValidateRole checks that the role is named like a flag, that its
+// description is bounded and that it grants between one and
+// MaxRolePermissions permissions with known scopes and valid selectors. Flag
+// selectors are held to naming, without its per-project prefixes since a
+// permission may span projects; the others follow the default rules.
+func ValidateRole(r Role, naming NamingPolicy) error {
+	if ValidateFlagName(r.Name) != nil {
+		return fmt.Errorf("role name %q is not a valid name: %w", r.Name, ErrInvalidRole)
+	}
+	if len(r.Description) > MaxRoleDescription {
+		return fmt.Errorf("description exceeds %d characters: %w", MaxRoleDescription, ErrInvalidRole)
+	}
+	if len(r.Permissions) == 0 || len(r.Permissions) > MaxRolePermissions {
+		return fmt.Errorf("a role grants between 1 and %d permissions: %w", MaxRolePermissions, ErrInvalidRole)
+	}
+	for _, p := range r.Permissions {
+		if !slices.Contains(Scopes, p.Scope) {
+			return fmt.Errorf("unknown scope %q: %w", p.Scope, ErrInvalidRole)
+		}
+		for _, keys := range [][]string{p.Projects, p.Environments, p.Tags} {
+			for _, key := range keys {
+				if ValidateFlagName(key) != nil {
+					return fmt.Errorf("selector %q is not a valid name: %w", key, ErrInvalidRole)
+				}
+			}
+		}
+		for _, name := range p.Flags {
+			if naming.ValidateName("", name) != nil {
+				return fmt.Errorf("flag selector %q is not a valid flag name: %w", name, ErrInvalidRole)
+			}
+		}
+	}
+	return nil
+}
+
+// RoleBinding grants the permissions of Role to Principal.
+type RoleBinding struct {
+	ID        int64
+	Principal string
+	Role      string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// ValidateRoleBinding checks that the binding names a principal an API key
+// could authenticate as and a role.
+func ValidateRoleBinding(b RoleBinding) error {
+	if ValidateFlagName(b.Principal) != nil {
+		return fmt.Errorf("principal %q is not a valid name: %w", b.Principal, ErrInvalidRole)
+	}
+	if b.Principal == SystemActor || b.Principal == AnonymousActor || b.Principal == BootstrapPrincipal {
+		return fmt.Errorf("principal %q is reserved: %w", b.Principal, ErrInvalidRole)
+	}
+	if ValidateFlagName(b.Role) != nil {
+		return fmt.Errorf("role name %q is not a valid name: %w", b.Role, ErrInvalidRole)
+	}
+	return nil
+}
+
+// Grants is what a principal may do: what its key's scopes and its roles
+// both allow. The bootstrap principal may do everything.
+type Grants struct {
+	Principal Principal
+	Roles     []Role
+}
+
+// Allows reports whether the principal may act with scope on r.
+func (g Grants) Allows(scope Scope, r Resource) bool {
+	if g.Principal.Name == BootstrapPrincipal {
+		return true
+	}
+	return g.Principal.Allows(scope) && slices.ContainsFunc(g.Roles, func(role Role) bool { return role.Allows(scope, r) })
+}
+
+// AllowsSome reports whether the principal may act with scope on anything in
+// project, or anywhere when project is empty. It guards reads of data shared
+// by every flag, such as the list of environments.
+func (g Grants) AllowsSome(scope Scope, project string) bool {
+	if g.Principal.Name == BootstrapPrincipal {
+		return true
+	}
+	return g.Principal.Allows(scope) && slices.ContainsFunc(g.Roles, func(role Role) bool {
+		return slices.ContainsFunc(role.Permissions, func(p Permission) bool {
+			return p.Scope.Includes(scope) && (project == "" || matchesAny(p.Projects, project))
+		})
+	})
+}
@@ -0,0 +1,115 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestPermission_Allows(t *testing.T) {
+	t.Parallel()
+
+	checkout := domain.FlagResource("shop", "production", "new-checkout", []string{"checkout", "payments"})
+	tests := []struct {
+		name       string
+		permission domain.Permission
+		scope      domain.Scope
+		resource   domain.Resource
+		want       bool
+	}{
+		{name: "unrestricted", permission: domain.Permission{Scope: domain.ScopeFlagsWrite}, scope: domain.ScopeFlagsWrite, resource: checkout, want: true},
+		{name: "wider scope", permission: domain.Permission{Scope: domain.ScopeFlagsWrite}, scope: domain.ScopeFlagsRead, resource: checkout, want: true},
+		{name: "narrower scope", permission: domain.Permission{Scope: domain.ScopeFlagsRead}, scope: domain.ScopeFlagsWrite, resource: checkout},
+		{name: "project listed", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Projects: []string{"shop"}}, scope: domain.ScopeFlagsWrite, resource: checkout, want: true},
+		{name: "other project", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Projects: []string{"search"}}, scope: domain.ScopeFlagsWrite, resource: checkout},
+		{name: "other environment", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Environments: []string{"staging"}}, scope: domain.ScopeFlagsWrite, resource: checkout},
+		{name: "flag listed", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Flags: []string{"new-checkout"}}, scope: domain.ScopeFlagsWrite, resource: checkout, want: true},
+		{name: "other flag", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Flags: []string{"dark-mode"}}, scope: domain.ScopeFlagsWrite, resource: checkout},
+		{name: "any tag", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Tags: []string{"search", "payments"}}, scope: domain.ScopeFlagsWrite, resource: checkout, want: true},
+		{name: "no tag", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Tags: []string{"search"}}, scope: domain.ScopeFlagsWrite, resource: checkout},
+		{name: "every environment needs unrestricted environments", permission: domain.Permission{Scope: domain.ScopeFlagsWrite, Environments: []string{"production"}}, scope: domain.ScopeFlagsWrite, resource: domain.FlagResource("shop", "", "new-checkout", nil)},
+		{name: "whole project needs unrestricted flags", permission: domain.Permission{Scope: domain.ScopeFlagsRead, Tags: []string{"checkout"}}, scope: domain.ScopeFlagsRead, resource: domain.Resource{Project: "shop"}},
+		{name: "whole project", permission: domain.Permission{Scope: domain.ScopeFlagsRead, Projects: []string{"shop"}}, scope: domain.ScopeFlagsRead, resource: domain.Resource{Project: "shop"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.permission.Allows(tt.scope, tt.resource))
+		})
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	t.Parallel()
+
+	write := []domain.Permission{{Scope: domain.ScopeFlagsWrite, Projects: []string{"shop"}, Tags: []string{"checkout"}}}
+	dotted := domain.DefaultNamingPolicy()
+	dotted.Separators = "-."
+	dotted.Prefixes = map[string][]string{"shop": {"shop."}}
+	tests := []struct {
+		name    string
+		role    domain.Role
+		naming  *domain.NamingPolicy
+		wantErr bool
+	}{
+		{name: "valid", role: domain.Role{Name: "checkout-owners", Permissions: write}},
+		{name: "invalid name", role: domain.Role{Name: "Checkout Owners", Permissions: write}, wantErr: true},
+		{name: "no permissions", role: domain.Role{Name: "checkout-owners"}, wantErr: true},
+		{name: "unknown scope", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: "flags:delete"}}}, wantErr: true},
+		{name: "invalid selector", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: domain.ScopeFlagsRead, Tags: []string{"Checkout"}}}}, wantErr: true},
+		{name: "flag selector outside default policy", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: domain.ScopeFlagsRead, Flags: []string{"shop.checkout"}}}}, wantErr: true},
+		{name: "flag selector under configured policy", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: domain.ScopeFlagsRead, Flags: []string{"shop.checkout"}}}}, naming: &dotted},
+		{name: "flag selector without project prefix", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: domain.ScopeFlagsRead, Projects: []string{"shop"}, Flags: []string{"checkout"}}}}, naming: &dotted},
+		{name: "tag selector ignores configured policy", role: domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{{Scope: domain.ScopeFlagsRead, Tags: []string{"shop.checkout"}}}}, naming: &dotted, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			naming := domain.DefaultNamingPolicy()
+			if tt.naming != nil {
+				naming = *tt.naming
+			}
+			err := domain.ValidateRole(tt.role, naming)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidRole)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateRoleBinding(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, domain.ValidateRoleBinding(domain.RoleBinding{Principal: "checkout-team", Role: "checkout-owners"}))
+	assert.ErrorIs(t, domain.ValidateRoleBinding(domain.RoleBinding{Principal: domain.BootstrapPrincipal, Role: "checkout-owners"}), domain.ErrInvalidRole)
+	assert.ErrorIs(t, domain.ValidateRoleBinding(domain.RoleBinding{Principal: "checkout-team", Role: "Owners"}), domain.ErrInvalidRole)
+}
+
+func TestGrants(t *testing.T) {
+	t.Parallel()
+
+	owners := domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{
+		{Scope: domain.ScopeFlagsWrite, Projects: []string{"shop"}, Tags: []string{"checkout"}},
+	}}
+	checkout := domain.FlagResource("shop", "production", "new-checkout", []string{"checkout"})
+	search := domain.FlagResource("shop", "production", "fuzzy-search", []string{"search"})
+	writer := domain.Principal{Name: "checkout-team", Scopes: []domain.Scope{domain.ScopeFlagsWrite}}
+	reader := domain.Principal{Name: "checkout-team", Scopes: []domain.Scope{domain.ScopeFlagsRead}}
+
+	g := domain.Grants{Principal: writer, Roles: []domain.Role{owners}}
+	assert.True(t, g.Allows(domain.ScopeFlagsWrite, checkout))
+	assert.False(t, g.Allows(domain.ScopeFlagsWrite, search), "the role does not cover the tag")
+	assert.True(t, g.AllowsSome(domain.ScopeFlagsRead, "shop"))
+	assert.False(t, g.AllowsSome(domain.ScopeFlagsRead, "search"))
+
+	limited := domain.Grants{Principal: reader, Roles: []domain.Role{owners}}
+	assert.False(t, limited.Allows(domain.ScopeFlagsWrite, checkout), "the key's scopes cap the role")
+	assert.True(t, limited.Allows(domain.ScopeFlagsRead, checkout))
+
+	assert.False(t, domain.Grants{Principal: writer}.AllowsSome(domain.ScopeFlagsEvaluate, ""), "no role grants nothing")
+	bootstrap := domain.Grants{Principal: domain.Principal{Name: domain.BootstrapPrincipal, Scopes: []domain.Scope{domain.ScopeAdmin}}}
+	assert.True(t, bootstrap.Allows(domain.ScopeAdmin, domain.Resource{}))
+}
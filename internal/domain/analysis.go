@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// MetricEvent is a conversion or metric observation for a targeting key, such
+// as a purchase with its amount. Value is 1 for plain conversions.
+type MetricEvent struct {
+	Metric       string
+	TargetingKey string
+	Value        float64
+	OccurredAt   time.Time
+}
+
+// VariantStats aggregates one metric over the users exposed to a variant.
+// A user counts once, for the first variant they were exposed to, and only
+// their events at or after that exposure are attributed to it.
+type VariantStats struct {
+	Variant string
+	// Users is the number of distinct exposed targeting keys.
+	Users int64
+	// Conversions is the number of exposed users with at least one event.
+	Conversions int64
+	// Sum and SumSquares are over each user's total metric value, with users
+	// that have no events contributing zero.
+	Sum        float64
+	SumSquares float64
+}
+
+// Interval is a two-sided confidence interval.
+type Interval struct {
+	Low  float64
+	High float64
+}
+
+// VariantResult is the analysis of one variant against the control.
+type VariantResult struct {
+	Variant        string
+	Users          int64
+	Conversions    int64
+	ConversionRate float64
+	ConversionCI   Interval
+	Mean           float64
+	MeanCI         Interval
+	// ConversionPValue and MeanPValue are two-sided p-values of the difference
+	// from the control; they are nil for the control itself and whenever
+	// either side has too little data to test.
+	ConversionPValue *float64
+	MeanPValue       *float64
+	// Significant reports whether either difference is significant at
+	// 1 - ConfidenceLevel.
+	Significant bool
+}
+
+// ExperimentResults is the per-variant analysis of one metric.
+type ExperimentResults struct {
+	ExperimentKey   string
+	Metric          string
+	Control         string
+	ConfidenceLevel float64
+	Variants        []VariantResult
+}
+
+// ConfidenceLevel is the level of the reported intervals and significance tests.
+const ConfidenceLevel = 0.95
+
+// zCritical is the two-sided standard normal quantile for ConfidenceLevel.
+const zCritical = 1.959963984540054
+
+// AnalyzeExperiment computes conversion rates, means, confidence intervals and
+// significance against the control, which is the experiment's first variant.
+// Conversion rates use Wilson score intervals and a pooled two-proportion
+// z-test; means use normal intervals and Welch's z-test, which is accurate at
+// the sample sizes experiments run at.
+func AnalyzeExperiment(exp Experiment, metric string, stats []VariantStats) ExperimentResults {
+	byVariant := make(map[string]VariantStats, len(stats))
+	for _, s := range stats {
+		byVariant[s.Variant] = s
+	}
+
+	results := ExperimentResults{ExperimentKey: exp.Key, Metric: metric, ConfidenceLevel: ConfidenceLevel}
+	if len(exp.Variants) == 0 {
+		return results
+	}
+	results.Control = exp.Variants[0].Variant
+	control := byVariant[results.Control]
+
+	for i, w := range exp.Variants {
+		s := byVariant[w.Variant]
+		s.Variant = w.Variant
+		r := VariantResult{
+			Variant:        s.Variant,
+			Users:          s.Users,
+			Conversions:    s.Conversions,
+			ConversionRate: rate(s.Conversions, s.Users),
+			ConversionCI:   wilson(s.Conversions, s.Users),
+			Mean:           mean(s),
+			MeanCI:         meanInterval(s),
+		}
+		if i > 0 {
+			r.ConversionPValue = proportionTest(control, s)
+			r.MeanPValue = meanTest(control, s)
+			alpha := 1 - ConfidenceLevel
+			r.Significant = (r.ConversionPValue != nil && *r.ConversionPValue < alpha) ||
+				(r.MeanPValue != nil && *r.MeanPValue < alpha)
+		}
+		results.Variants = append(results.Variants, r)
+	}
+	return results
+}
+
+func rate(conversions, users int64) float64 {
+	if users == 0 {
+		return 0
+	}
+	return float64(conversions) / float64(users)
+}
+
+func wilson(conversions, users int64) Interval {
+	if users == 0 {
+		return Interval{}
+	}
+	n := float64(users)
+	p := float64(conversions) / n
+	z2 := zCritical * zCritical
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	half := zCritical * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return Interval{Low: math.Max(0, center-half), High: math.Min(1, center+half)}
+}
+
+func mean(s VariantStats) float64 {
+	if s.Users == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Users)
+}
+
+// variance is the unbiased sample variance of the per-user totals.
+func variance(s VariantStats) float64 {
+	if s.Users < 2 {
+		return 0
+	}
+	n := float64(s.Users)
+	v := (s.SumSquares - s.Sum*s.Sum/n) / (n - 1)
+	return math.Max(0, v)
+}
+
+func meanInterval(s VariantStats) Interval {
+	m := mean(s)
+	if s.Users < 2 {
+		return Interval{Low: m, High: m}
+	}
+	half := zCritical * math.Sqrt(variance(s)/float64(s.Users))
+	return Interval{Low: m - half, High: m + half}
+}
+
+func proportionTest(a, b VariantStats) *float64 {
+	if a.Users == 0 || b.Users == 0 {
+		return nil
+	}
+	na, nb := float64(a.Users), float64(b.Users)
+	pooled := float64(a.Conversions+b.Conversions) / (na + nb)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/na + 1/nb))
+	if se == 0 {
+		return nil
+	}
+	z := (rate(b.Conversions, b.Users) - rate(a.Conversions, a.Users)) / se
+	return twoSided(z)
+}
+
+func meanTest(a, b VariantStats) *float64 {
+	if a.Users < 2 || b.Users < 2 {
+		return nil
+	}
+	se := math.Sqrt(variance(a)/float64(a.Users) + variance(b)/float64(b.Users))
+	if se == 0 {
+		return nil
+	}
+	z := (mean(b) - mean(a)) / se
+	return twoSided(z)
+}
+
+func twoSided(z float64) *float64 {
+	p := math.Erfc(math.Abs(z) / math.Sqrt2)
+	return &p
+}
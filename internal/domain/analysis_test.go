@@ -0,0 +1,78 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestAnalyzeExperiment(t *testing.T) {
+	t.Parallel()
+
+	exp := domain.Experiment{
+		Key: "checkout-test",
+		Variants: []domain.VariantWeight{
+			{Variant: "control", Weight: 1},
+			{Variant: "treatment", Weight: 1},
+			{Variant: "unseen", Weight: 1},
+		},
+	}
+	// Every conversion is worth 10, so the mean is ten times the rate.
+	stats := []domain.VariantStats{
+		{Variant: "control", Users: 1000, Conversions: 100, Sum: 1000, SumSquares: 10000},
+		{Variant: "treatment", Users: 1000, Conversions: 150, Sum: 1500, SumSquares: 15000},
+	}
+
+	results := domain.AnalyzeExperiment(exp, "purchase", stats)
+
+	assert.Equal(t, "control", results.Control)
+	assert.Equal(t, "purchase", results.Metric)
+	assert.InDelta(t, 0.95, results.ConfidenceLevel, 0)
+	require.Len(t, results.Variants, 3)
+
+	control := results.Variants[0]
+	assert.InDelta(t, 0.10, control.ConversionRate, 1e-9)
+	assert.InDelta(t, 0.0829, control.ConversionCI.Low, 1e-3)
+	assert.InDelta(t, 0.1203, control.ConversionCI.High, 1e-3)
+	assert.InDelta(t, 1.0, control.Mean, 1e-9)
+	assert.Nil(t, control.ConversionPValue)
+	assert.False(t, control.Significant)
+
+	treatment := results.Variants[1]
+	assert.InDelta(t, 0.15, treatment.ConversionRate, 1e-9)
+	require.NotNil(t, treatment.ConversionPValue)
+	assert.InDelta(t, 0.00072, *treatment.ConversionPValue, 5e-5)
+	require.NotNil(t, treatment.MeanPValue)
+	assert.Less(t, *treatment.MeanPValue, 0.01)
+	assert.Less(t, treatment.MeanCI.Low, treatment.Mean)
+	assert.Greater(t, treatment.MeanCI.High, treatment.Mean)
+	assert.True(t, treatment.Significant)
+
+	unseen := results.Variants[2]
+	assert.Equal(t, "unseen", unseen.Variant)
+	assert.Zero(t, unseen.Users)
+	assert.Nil(t, unseen.ConversionPValue)
+	assert.Nil(t, unseen.MeanPValue)
+	assert.False(t, unseen.Significant)
+}
+
+func TestAnalyzeExperiment_NoDifference(t *testing.T) {
+	t.Parallel()
+
+	exp := domain.Experiment{Key: "copy-test", Variants: []domain.VariantWeight{
+		{Variant: "a", Weight: 1}, {Variant: "b", Weight: 1},
+	}}
+	stats := []domain.VariantStats{
+		{Variant: "a", Users: 500, Conversions: 50, Sum: 50, SumSquares: 50},
+		{Variant: "b", Users: 500, Conversions: 52, Sum: 52, SumSquares: 52},
+	}
+
+	results := domain.AnalyzeExperiment(exp, "signup", stats)
+
+	b := results.Variants[1]
+	require.NotNil(t, b.ConversionPValue)
+	assert.Greater(t, *b.ConversionPValue, 0.5)
+	assert.False(t, b.Significant)
+}
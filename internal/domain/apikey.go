@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	// ScopeFlagsEvaluate allows evaluating flags and reading their values,
+	// as SDKs do, and recording metric events.
+	ScopeFlagsEvaluate Scope = "flags:evaluate"
+	// ScopeFlagsRead allows every read of flags, experiments and their
+	// history, and includes ScopeFlagsEvaluate.
+	ScopeFlagsRead Scope = "flags:read"
+	// ScopeFlagsWrite allows changing flags and experiments and deciding
+	// change requests, and includes ScopeFlagsRead.
+	ScopeFlagsWrite Scope = "flags:write"
+	// ScopeAdmin allows everything, including managing API keys, webhooks,
+	// protections, freezes, environments and projects.
+	ScopeAdmin Scope = "admin"
+)
+
+// Scopes lists every scope, each including the ones before it.
+var Scopes = []Scope{ScopeFlagsEvaluate, ScopeFlagsRead, ScopeFlagsWrite, ScopeAdmin}
+
+// Includes reports whether holding s grants other.
+func (s Scope) Includes(other Scope) bool {
+	held, wanted := slices.Index(Scopes, s), slices.Index(Scopes, other)
+	return held >= 0 && wanted >= 0 && held >= wanted
+}
+
+const (
+	// APIKeyPrefix starts every API key token, so leaked tokens are easy to
+	// recognise.
+	APIKeyPrefix = "ffk_"
+	// APIKeyHintLength is how much of a token is kept in the clear to tell
+	// keys apart.
+	APIKeyHintLength = len(APIKeyPrefix) + 8
+	// MaxRotationGrace bounds how long a rotated key keeps working.
+	MaxRotationGrace = 7 * 24 * time.Hour
+	// BootstrapPrincipal is the principal of the admin key given in the
+	// configuration rather than stored.
+	BootstrapPrincipal = "bootstrap"
+)
+
+// APIKey authenticates requests as Principal with Scopes. Only the SHA-256
+// hash of its token is kept; Hint is the token's first characters. A key
+// stops working once revoked or expired.
+type APIKey struct {
+	ID        int64
+	Principal string
+	Scopes    []Scope
+	Hint      string
+	Hash      []byte
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// Active reports whether the key authenticates requests at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt))
+}
+
+// HashAPIKey returns the hash an API key token is stored and looked up by.
+// Tokens carry 256 random bits, so a fast unsalted hash suffices.
+func HashAPIKey(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// ValidateAPIKey checks that the principal is a valid name other than one the
+// service records itself and that the scopes are known, distinct and not
+// empty.
+func ValidateAPIKey(k APIKey) error {
+	if err := ValidateFlagName(k.Principal); err != nil {
+		return fmt.Errorf("principal %q is not a valid name: %w", k.Principal, ErrInvalidAPIKey)
+	}
+	if k.Principal == SystemActor || k.Principal == AnonymousActor || k.Principal == BootstrapPrincipal {
+		return fmt.Errorf("principal %q is reserved: %w", k.Principal, ErrInvalidAPIKey)
+	}
+	if len(k.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required: %w", ErrInvalidAPIKey)
+	}
+	seen := make(map[Scope]bool, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q: %w", scope, ErrInvalidAPIKey)
+		}
+		if seen[scope] {
+			return fmt.Errorf("duplicate scope %q: %w", scope, ErrInvalidAPIKey)
+		}
+		seen[scope] = true
+	}
+	if !k.ExpiresAt.IsZero() && !k.ExpiresAt.After(k.CreatedAt) {
+		return fmt.Errorf("key expires before it is created: %w", ErrInvalidAPIKey)
+	}
+	return nil
+}
+
+// Principal is who an authenticated request acts as, with the scopes its key
+// grants.
+type Principal struct {
+	Name   string
+	KeyID  int64
+	Scopes []Scope
+}
+
+// Allows reports whether one of the principal's scopes includes scope.
+func (p Principal) Allows(scope Scope) bool {
+	return slices.ContainsFunc(p.Scopes, func(held Scope) bool { return held.Includes(scope) })
+}
+
+// LooksLikeAPIKey reports whether token has the shape of an API key token, so
+// obviously foreign credentials are refused without a lookup.
+func LooksLikeAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix) && len(token) > APIKeyHintLength
+}
@@ -0,0 +1,85 @@
+package domain_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestValidateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	write := []domain.Scope{domain.ScopeFlagsWrite}
+	tests := []struct {
+		name    string
+		key     domain.APIKey
+		wantErr bool
+	}{
+		{name: "valid", key: domain.APIKey{Principal: "checkout-team", Scopes: write, CreatedAt: now}},
+		{name: "expiring", key: domain.APIKey{Principal: "ci", Scopes: []domain.Scope{domain.ScopeFlagsRead, domain.ScopeAdmin}, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}},
+		{name: "invalid principal", key: domain.APIKey{Principal: "Checkout Team", Scopes: write, CreatedAt: now}, wantErr: true},
+		{name: "reserved principal", key: domain.APIKey{Principal: domain.SystemActor, Scopes: write, CreatedAt: now}, wantErr: true},
+		{name: "bootstrap principal", key: domain.APIKey{Principal: domain.BootstrapPrincipal, Scopes: write, CreatedAt: now}, wantErr: true},
+		{name: "no scopes", key: domain.APIKey{Principal: "ci", CreatedAt: now}, wantErr: true},
+		{name: "unknown scope", key: domain.APIKey{Principal: "ci", Scopes: []domain.Scope{"flags:delete"}, CreatedAt: now}, wantErr: true},
+		{name: "duplicate scope", key: domain.APIKey{Principal: "ci", Scopes: []domain.Scope{domain.ScopeAdmin, domain.ScopeAdmin}, CreatedAt: now}, wantErr: true},
+		{name: "already expired", key: domain.APIKey{Principal: "ci", Scopes: write, CreatedAt: now, ExpiresAt: now}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateAPIKey(tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	assert.True(t, domain.APIKey{}.Active(now))
+	assert.True(t, domain.APIKey{ExpiresAt: now.Add(time.Second)}.Active(now))
+	assert.False(t, domain.APIKey{ExpiresAt: now}.Active(now))
+	assert.False(t, domain.APIKey{RevokedAt: now.Add(-time.Hour)}.Active(now))
+}
+
+func TestPrincipal_Allows(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		held    []domain.Scope
+		wanted  domain.Scope
+		allowed bool
+	}{
+		{name: "same scope", held: []domain.Scope{domain.ScopeFlagsEvaluate}, wanted: domain.ScopeFlagsEvaluate, allowed: true},
+		{name: "narrower scope", held: []domain.Scope{domain.ScopeFlagsEvaluate}, wanted: domain.ScopeFlagsRead},
+		{name: "read includes evaluate", held: []domain.Scope{domain.ScopeFlagsRead}, wanted: domain.ScopeFlagsEvaluate, allowed: true},
+		{name: "write includes read", held: []domain.Scope{domain.ScopeFlagsWrite}, wanted: domain.ScopeFlagsRead, allowed: true},
+		{name: "write excludes admin", held: []domain.Scope{domain.ScopeFlagsWrite}, wanted: domain.ScopeAdmin},
+		{name: "any scope suffices", held: []domain.Scope{domain.ScopeFlagsEvaluate, domain.ScopeAdmin}, wanted: domain.ScopeFlagsWrite, allowed: true},
+		{name: "no scopes", held: nil, wanted: domain.ScopeFlagsEvaluate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.allowed, domain.Principal{Scopes: tt.held}.Allows(tt.wanted))
+		})
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	t.Parallel()
+
+	// echo -n 'ffk_example' | sha256sum
+	assert.Equal(t, "825db0eaa6f42d23c325ea946f7def27a529fbea5a1aa46af6d6830c68c3cf71", hex.EncodeToString(domain.HashAPIKey("ffk_example")))
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaxRequiredApprovals bounds the approvals a protected flag can require.
+const MaxRequiredApprovals = 10
+
+// FlagProtection requires changes to a flag's value to be approved before
+// they are applied; other changes to the flag are refused. It covers the flag in every environment listed in
+// Environments, or in all of them when the list is empty. A flag with no
+// RequiredApprovals is unprotected.
+type FlagProtection struct {
+	Project           string
+	FlagName          string
+	RequiredApprovals int
+	Environments      []string
+}
+
+// Covers reports whether changes in env need approval.
+func (p FlagProtection) Covers(env string) bool {
+	return p.RequiredApprovals > 0 && (len(p.Environments) == 0 || slices.Contains(p.Environments, env))
+}
+
+// ValidateProtection checks the approval count and environment keys of p.
+func ValidateProtection(p FlagProtection) error {
+	if p.RequiredApprovals < 0 || p.RequiredApprovals > MaxRequiredApprovals {
+		return fmt.Errorf("required approvals %d, want 0-%d: %w", p.RequiredApprovals, MaxRequiredApprovals, ErrInvalidProtection)
+	}
+	for _, env := range p.Environments {
+		if env == "" {
+			return fmt.Errorf("empty environment key: %w", ErrInvalidProtection)
+		}
+	}
+	return nil
+}
+
+// ChangeRequestStatus is the lifecycle state of a ChangeRequest.
+type ChangeRequestStatus string
+
+const (
+	ChangeRequestPending  ChangeRequestStatus = "pending"
+	ChangeRequestApplied  ChangeRequestStatus = "applied"
+	ChangeRequestRejected ChangeRequestStatus = "rejected"
+	ChangeRequestExpired  ChangeRequestStatus = "expired"
+)
+
+// ChangeRequestStatuses lists every status in lifecycle order.
+var ChangeRequestStatuses = []ChangeRequestStatus{ChangeRequestPending, ChangeRequestApplied, ChangeRequestRejected, ChangeRequestExpired}
+
+// Approval is one principal's sign-off on a change request.
+type Approval struct {
+	Approver   string
+	ApprovedAt time.Time
+}
+
+// ChangeRequest is a value change to a protected flag awaiting approval. It
+// is applied once RequiredApprovals principals other than RequestedBy have
+// approved it, and can be rejected by anyone until then. A pending request
+// that reaches ExpiresAt expires and can no longer be decided.
+type ChangeRequest struct {
+	ID          int64
+	Project     string
+	FlagName    string
+	Environment string
+	Value       FlagValue
+	// Status is the stored status; see StatusAt for the effective one.
+	Status            ChangeRequestStatus
+	RequestedBy       string
+	RequiredApprovals int
+	Approvals         []Approval
+	// DecidedBy is the approver whose approval applied the change, or the
+	// principal who rejected it.
+	DecidedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	DecidedAt time.Time
+}
+
+// StatusAt returns the request's status at the instant at: a pending request
+// is expired from ExpiresAt on.
+func (c ChangeRequest) StatusAt(at time.Time) ChangeRequestStatus {
+	if c.Status == ChangeRequestPending && !at.Before(c.ExpiresAt) {
+		return ChangeRequestExpired
+	}
+	return c.Status
+}
+
+// Approve records approver's approval at the instant at and reports whether
+// the request now has enough approvals to be applied, in which case it is
+// marked applied. The requester, anonymous and system actors, and anyone who
+// already approved are refused with ErrApprovalNotAllowed; a request that is
+// no longer pending with ErrChangeRequestState.
+func (c *ChangeRequest) Approve(approver string, at time.Time) (bool, error) {
+	if status := c.StatusAt(at); status != ChangeRequestPending {
+		return false, fmt.Errorf("change request %d is %s: %w", c.ID, status, ErrChangeRequestState)
+	}
+	switch {
+	case approver == c.RequestedBy:
+		return false, fmt.Errorf("%q requested change %d: %w", approver, c.ID, ErrApprovalNotAllowed)
+	case approver == "" || approver == AnonymousActor || approver == SystemActor:
+		return false, fmt.Errorf("approver must be identified: %w", ErrApprovalNotAllowed)
+	case slices.ContainsFunc(c.Approvals, func(a Approval) bool { return a.Approver == approver }):
+		return false, fmt.Errorf("%q already approved change %d: %w", approver, c.ID, ErrApprovalNotAllowed)
+	}
+	c.Approvals = append(c.Approvals, Approval{Approver: approver, ApprovedAt: at})
+	if len(c.Approvals) < c.RequiredApprovals {
+		return false, nil
+	}
+	c.Status, c.DecidedBy, c.DecidedAt = ChangeRequestApplied, approver, at
+	return true, nil
+}
+
+// Reject marks the request rejected by reviewer at the instant at. The
+// requester may reject, that is withdraw, their own request. A request that
+// is no longer pending is refused with ErrChangeRequestState.
+func (c *ChangeRequest) Reject(reviewer string, at time.Time) error {
+	if status := c.StatusAt(at); status != ChangeRequestPending {
+		return fmt.Errorf("change request %d is %s: %w", c.ID, status, ErrChangeRequestState)
+	}
+	c.Status, c.DecidedBy, c.DecidedAt = ChangeRequestRejected, reviewer, at
+	return nil
+}
+
+// ChangeRequestQuery selects the change requests of Project. Empty fields
+// match every request; Status is matched as of At.
+type ChangeRequestQuery struct {
+	Project  string
+	FlagName string
+	Status   ChangeRequestStatus
+	At       time.Time
+}
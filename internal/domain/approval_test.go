@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestFlagProtection_Covers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		protection domain.FlagProtection
+		env        string
+		want       bool
+	}{
+		{name: "unprotected", protection: domain.FlagProtection{}, env: "production", want: false},
+		{name: "every environment", protection: domain.FlagProtection{RequiredApprovals: 1}, env: "staging", want: true},
+		{name: "listed environment", protection: domain.FlagProtection{RequiredApprovals: 2, Environments: []string{"production"}}, env: "production", want: true},
+		{name: "unlisted environment", protection: domain.FlagProtection{RequiredApprovals: 2, Environments: []string{"production"}}, env: "staging", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.protection.Covers(tt.env))
+		})
+	}
+}
+
+func TestValidateProtection(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, domain.ValidateProtection(domain.FlagProtection{RequiredApprovals: 2, Environments: []string{"production"}}))
+	assert.NoError(t, domain.ValidateProtection(domain.FlagProtection{}))
+	assert.ErrorIs(t, domain.ValidateProtection(domain.FlagProtection{RequiredApprovals: -1}), domain.ErrInvalidProtection)
+	assert.ErrorIs(t, domain.ValidateProtection(domain.FlagProtection{RequiredApprovals: domain.MaxRequiredApprovals + 1}), domain.ErrInvalidProtection)
+	assert.ErrorIs(t, domain.ValidateProtection(domain.FlagProtection{RequiredApprovals: 1, Environments: []string{""}}), domain.ErrInvalidProtection)
+}
+
+func TestChangeRequest_Approve(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	pending := func() domain.ChangeRequest {
+		return domain.ChangeRequest{
+			ID: 1, Status: domain.ChangeRequestPending, RequestedBy: "alice", RequiredApprovals: 2,
+			CreatedAt: created, ExpiresAt: created.Add(time.Hour),
+		}
+	}
+
+	t.Run("applies once enough principals approve", func(t *testing.T) {
+		t.Parallel()
+
+		cr := pending()
+		applied, err := cr.Approve("bob", created.Add(time.Minute))
+		require.NoError(t, err)
+		assert.False(t, applied)
+		assert.Equal(t, domain.ChangeRequestPending, cr.Status)
+
+		applied, err = cr.Approve("carol", created.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, applied)
+		assert.Equal(t, domain.ChangeRequestApplied, cr.Status)
+		assert.Equal(t, "carol", cr.DecidedBy)
+		assert.Equal(t, []domain.Approval{
+			{Approver: "bob", ApprovedAt: created.Add(time.Minute)},
+			{Approver: "carol", ApprovedAt: created.Add(2 * time.Minute)},
+		}, cr.Approvals)
+
+		_, err = cr.Approve("dave", created.Add(3*time.Minute))
+		assert.ErrorIs(t, err, domain.ErrChangeRequestState)
+	})
+
+	refused := []struct {
+		name     string
+		approver string
+		at       time.Time
+		prepare  func(*domain.ChangeRequest)
+		wantErr  error
+	}{
+		{name: "self-approval", approver: "alice", at: created, wantErr: domain.ErrApprovalNotAllowed},
+		{name: "anonymous", approver: domain.AnonymousActor, at: created, wantErr: domain.ErrApprovalNotAllowed},
+		{name: "system", approver: domain.SystemActor, at: created, wantErr: domain.ErrApprovalNotAllowed},
+		{
+			name: "second approval by the same principal", approver: "bob", at: created,
+			prepare: func(cr *domain.ChangeRequest) {
+				cr.Approvals = []domain.Approval{{Approver: "bob", ApprovedAt: created}}
+			},
+			wantErr: domain.ErrApprovalNotAllowed,
+		},
+		{name: "expired", approver: "bob", at: created.Add(time.Hour), wantErr: domain.ErrChangeRequestState},
+		{
+			name: "rejected", approver: "bob", at: created,
+			prepare: func(cr *domain.ChangeRequest) { cr.Status = domain.ChangeRequestRejected },
+			wantErr: domain.ErrChangeRequestState,
+		},
+	}
+	for _, tt := range refused {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cr := pending()
+			if tt.prepare != nil {
+				tt.prepare(&cr)
+			}
+			approvals := len(cr.Approvals)
+			_, err := cr.Approve(tt.approver, tt.at)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Len(t, cr.Approvals, approvals, "a refused approval is not recorded")
+		})
+	}
+}
+
+func TestChangeRequest_Reject(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	cr := domain.ChangeRequest{ID: 1, Status: domain.ChangeRequestPending, RequestedBy: "alice", RequiredApprovals: 1, ExpiresAt: created.Add(time.Hour)}
+
+	require.NoError(t, cr.Reject("alice", created), "the requester may withdraw")
+	assert.Equal(t, domain.ChangeRequestRejected, cr.Status)
+	assert.Equal(t, "alice", cr.DecidedBy)
+	assert.ErrorIs(t, cr.Reject("bob", created), domain.ErrChangeRequestState)
+
+	expired := domain.ChangeRequest{ID: 2, Status: domain.ChangeRequestPending, ExpiresAt: created}
+	assert.Equal(t, domain.ChangeRequestExpired, expired.StatusAt(created))
+	assert.ErrorIs(t, expired.Reject("bob", created), domain.ErrChangeRequestState)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SystemActor is the actor recorded for changes the service makes on its own,
+// such as bandit rebalancing.
+const SystemActor = "system"
+
+// AnonymousActor is the actor recorded for requests that do not name one.
+const AnonymousActor = "anonymous"
+
+// AuditAction names the kind of change an AuditEntry records.
+type AuditAction string
+
+const (
+	AuditCreate          AuditAction = "create"
+	AuditUpdateValue     AuditAction = "update-value"
+	AuditUpdateRules     AuditAction = "update-rules"
+	AuditSetEnabled      AuditAction = "set-enabled"
+	AuditAddTargets      AuditAction = "add-targets"
+	AuditRemoveTargets   AuditAction = "remove-targets"
+	AuditRemoveOverride  AuditAction = "remove-override"
+	AuditPromote         AuditAction = "promote"
+	AuditStartExperiment AuditAction = "start-experiment"
+	AuditStopExperiment  AuditAction = "stop-experiment"
+	AuditUpdateWeights   AuditAction = "update-weights"
+	AuditRollback        AuditAction = "rollback"
+	AuditApplyChange     AuditAction = "apply-change"
+)
+
+// AuditActions lists every action in the order they are documented.
+var AuditActions = []AuditAction{
+	AuditCreate, AuditUpdateValue, AuditUpdateRules, AuditSetEnabled, AuditAddTargets, AuditRemoveTargets,
+	AuditRemoveOverride, AuditPromote, AuditStartExperiment, AuditStopExperiment, AuditUpdateWeights,
+	AuditRollback, AuditApplyChange,
+}
+
+// AuditEntry records one change to a flag in one environment. Before is the
+// flag as it resolved in Environment beforehand, nil for a new flag; After is
+// the flag as it resolves afterwards. Hash chains the entry to the one
+// recorded before it, whose hash is PrevHash; see AuditHash.
+type AuditEntry struct {
+	ID          int64
+	Actor       string
+	Action      AuditAction
+	Project     string
+	Environment string
+	FlagName    string
+	Before      *Flag
+	After       *Flag
+	RequestID   string
+	// Emergency is the reason given for an emergency override of change
+	// freezes, empty for a change made without one.
+	Emergency  string
+	RecordedAt time.Time
+	PrevHash   []byte
+	Hash       []byte
+}
+
+// MaxAuditPage bounds the number of audit entries returned by one query.
+const MaxAuditPage = 1000
+
+// AuditQuery selects audit entries recorded at or after Since and before
+// Until. Empty fields and zero times match every entry; Limit is between 1 and
+// MaxAuditPage.
+type AuditQuery struct {
+	Project     string
+	Environment string
+	FlagName    string
+	Actor       string
+	Action      AuditAction
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+// ValidateAuditQuery checks that the action is known, the time range is not
+// inverted and the limit is within bounds.
+func ValidateAuditQuery(q AuditQuery) error {
+	if q.Action != "" && !slices.Contains(AuditActions, q.Action) {
+		return fmt.Errorf("unknown action %q: %w", q.Action, ErrInvalidAuditQuery)
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && q.Until.Before(q.Since) {
+		return fmt.Errorf("until must not be before since: %w", ErrInvalidAuditQuery)
+	}
+	if q.Limit < 1 || q.Limit > MaxAuditPage {
+		return fmt.Errorf("limit must be between 1 and %d: %w", MaxAuditPage, ErrInvalidAuditQuery)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// auditHashVersion identifies the encoding below. The structs are frozen:
+// renaming or retyping a field, or adding one to Flag that should be hashed,
+// means a new version, so that hashes recorded under this one still verify.
+const auditHashVersion = 1
+
+// auditContent is what an audit entry's hash covers: everything it records
+// except its id, which only orders the chain. It is encoded with explicit
+// names, independently of the domain types, so that changing those does not
+// change the hash of entries already recorded.
+type auditContent struct {
+	Version     int        `json:"v"`
+	Actor       string     `json:"actor"`
+	Action      string     `json:"action"`
+	Project     string     `json:"project"`
+	Environment string     `json:"environment"`
+	FlagName    string     `json:"flag"`
+	Before      *auditFlag `json:"before"`
+	After       *auditFlag `json:"after"`
+	RequestID   string     `json:"request_id"`
+	Emergency   string     `json:"emergency,omitempty"`
+	RecordedAt  time.Time  `json:"recorded_at"`
+}
+
+type auditFlag struct {
+	Project     string                `json:"project"`
+	Name        string                `json:"name"`
+	Type        string                `json:"type"`
+	Description string                `json:"description"`
+	Tags        []string              `json:"tags,omitempty"`
+	Environment string                `json:"environment"`
+	Source      string                `json:"source_environment"`
+	Disabled    bool                  `json:"disabled"`
+	Value       auditValue            `json:"value"`
+	Rules       []auditRule           `json:"rules,omitempty"`
+	Targets     map[string]auditValue `json:"targets,omitempty"`
+	Variants    []auditVariant        `json:"variants,omitempty"`
+	Experiment  *auditExperiment      `json:"experiment,omitempty"`
+	Version     int64                 `json:"version"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+type auditValue struct {
+	Bool    *bool    `json:"bool,omitempty"`
+	Numeric *float64 `json:"numeric,omitempty"`
+}
+
+type auditRule struct {
+	ID         string        `json:"id"`
+	Clauses    []auditClause `json:"clauses,omitempty"`
+	Expression string        `json:"expression,omitempty"`
+	Value      auditValue    `json:"value"`
+}
+
+type auditClause struct {
+	Attribute string   `json:"attribute"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+type auditVariant struct {
+	Key   string     `json:"key"`
+	Value auditValue `json:"value"`
+}
+
+type auditExperiment struct {
+	Key         string               `json:"key"`
+	Project     string               `json:"project"`
+	FlagName    string               `json:"flag"`
+	Environment string               `json:"environment"`
+	Allocation  int                  `json:"allocation"`
+	Variants    []auditVariantWeight `json:"variants"`
+	Layer       string               `json:"layer,omitempty"`
+	LayerOffset int                  `json:"layer_offset,omitempty"`
+	Holdout     int                  `json:"holdout,omitempty"`
+	Bandit      *auditBandit         `json:"bandit,omitempty"`
+	Status      string               `json:"status"`
+	StartedAt   *time.Time           `json:"started_at,omitempty"`
+	StoppedAt   *time.Time           `json:"stopped_at,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type auditVariantWeight struct {
+	Variant string `json:"variant"`
+	Weight  int    `json:"weight"`
+}
+
+type auditBandit struct {
+	Algorithm      string  `json:"algorithm"`
+	Metric         string  `json:"metric"`
+	Epsilon        float64 `json:"epsilon,omitempty"`
+	MinExploration float64 `json:"min_exploration,omitempty"`
+}
+
+// AuditHash returns the SHA-256 of prev, the hash of the entry recorded
+// before, followed by the JSON encoding of entry's content. Times are taken in
+// UTC at microsecond precision, as stored, and empty lists and maps are
+// encoded like missing ones. The first entry of the log has no prev.
+func AuditHash(prev []byte, entry AuditEntry) ([]byte, error) {
+	content, err := json.Marshal(auditContent{
+		Version:     auditHashVersion,
+		Actor:       entry.Actor,
+		Action:      string(entry.Action),
+		Project:     entry.Project,
+		Environment: entry.Environment,
+		FlagName:    entry.FlagName,
+		Before:      auditFlagOf(entry.Before),
+		After:       auditFlagOf(entry.After),
+		RequestID:   entry.RequestID,
+		Emergency:   entry.Emergency,
+		RecordedAt:  auditTime(entry.RecordedAt),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding audit entry: %w", err)
+	}
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(content)
+	return h.Sum(nil), nil
+}
+
+func auditFlagOf(flag *Flag) *auditFlag {
+	if flag == nil {
+		return nil
+	}
+	out := &auditFlag{
+		Project:     flag.Project,
+		Name:        flag.Name,
+		Type:        string(flag.Type),
+		Description: flag.Description,
+		Tags:        flag.Tags,
+		Environment: flag.Environment,
+		Source:      flag.SourceEnvironment,
+		Disabled:    flag.Disabled,
+		Value:       auditValue(flag.Value),
+		Experiment:  auditExperimentOf(flag.Experiment),
+		Version:     flag.Version,
+		CreatedAt:   auditTime(flag.CreatedAt),
+		UpdatedAt:   auditTime(flag.UpdatedAt),
+	}
+	for _, rule := range flag.Rules {
+		r := auditRule{ID: rule.ID, Expression: rule.Expression, Value: auditValue(rule.Value)}
+		for _, c := range rule.Clauses {
+			r.Clauses = append(r.Clauses, auditClause{Attribute: c.Attribute, Operator: string(c.Operator), Values: c.Values, Timezone: c.Timezone})
+		}
+		out.Rules = append(out.Rules, r)
+	}
+	if len(flag.Targets) > 0 {
+		out.Targets = make(map[string]auditValue, len(flag.Targets))
+		for key, value := range flag.Targets {
+			out.Targets[key] = auditValue(value)
+		}
+	}
+	for _, v := range flag.Variants {
+		out.Variants = append(out.Variants, auditVariant{Key: v.Key, Value: auditValue(v.Value)})
+	}
+	return out
+}
+
+func auditExperimentOf(exp *Experiment) *auditExperiment {
+	if exp == nil {
+		return nil
+	}
+	out := &auditExperiment{
+		Key:         exp.Key,
+		Project:     exp.Project,
+		FlagName:    exp.FlagName,
+		Environment: exp.Environment,
+		Allocation:  exp.Allocation,
+		Layer:       exp.Layer,
+		LayerOffset: exp.LayerOffset,
+		Holdout:     exp.Holdout,
+		Status:      string(exp.Status),
+		StartedAt:   auditTimePtr(exp.StartedAt),
+		StoppedAt:   auditTimePtr(exp.StoppedAt),
+		CreatedAt:   auditTime(exp.CreatedAt),
+		UpdatedAt:   auditTime(exp.UpdatedAt),
+	}
+	for _, w := range exp.Variants {
+		out.Variants = append(out.Variants, auditVariantWeight(w))
+	}
+	if b := exp.Bandit; b != nil {
+		out.Bandit = &auditBandit{Algorithm: string(b.Algorithm), Metric: b.Metric, Epsilon: b.Epsilon, MinExploration: b.MinExploration}
+	}
+	return out
+}
+
+func auditTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func auditTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	at := auditTime(*t)
+	return &at
+}
+
+// AuditCheckpoint is a signed statement that the audit log's entry EntryID
+// had hash Hash at CreatedAt. Rewriting the chain up to that entry, even
+// consistently, no longer matches a checkpoint whose signature verifies.
+type AuditCheckpoint struct {
+	ID        int64
+	EntryID   int64
+	Hash      []byte
+	Signature []byte
+	CreatedAt time.Time
+}
+
+// Message returns the bytes the checkpoint's signature covers. CreatedAt is
+// taken in UTC at microsecond precision, as stored.
+func (c AuditCheckpoint) Message() []byte {
+	return fmt.Appendf(nil, "audit-checkpoint:%d:%x:%s", c.EntryID, c.Hash, c.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano))
+}
+
+// AuditBreak locates the first point where the audit log fails verification:
+// the entry whose link or contents do not match, or the checkpoint that does
+// not match the chain.
+type AuditBreak struct {
+	EntryID      int64
+	CheckpointID int64
+	Reason       string
+}
+
+// AuditChain verifies audit entries fed to it in id order, from the first
+// entry of the log, against each other and against checkpoints. Checkpoint
+// signatures are verified when it has a public key.
+type AuditChain struct {
+	key         ed25519.PublicKey
+	prev        []byte
+	checkpoints map[int64][]AuditCheckpoint
+	entries     int
+}
+
+// NewAuditChain verifies the log from its first entry.
+func NewAuditChain(checkpoints []AuditCheckpoint, key ed25519.PublicKey) *AuditChain {
+	byEntry := make(map[int64][]AuditCheckpoint, len(checkpoints))
+	for _, cp := range checkpoints {
+		byEntry[cp.EntryID] = append(byEntry[cp.EntryID], cp)
+	}
+	return &AuditChain{key: key, checkpoints: byEntry}
+}
+
+// ResumeAuditChain verifies the entries following the checkpointed one,
+// trusting the chain up to it. The checkpoint's signature is verified by the
+// caller.
+func ResumeAuditChain(from AuditCheckpoint, key ed25519.PublicKey) *AuditChain {
+	return &AuditChain{key: key, prev: from.Hash, checkpoints: map[int64][]AuditCheckpoint{}}
+}
+
+// Next verifies entry, the one following those verified so far, and returns
+// the break it finds, if any. Once a break is found the chain cannot be
+// verified further.
+func (c *AuditChain) Next(entry AuditEntry) (*AuditBreak, error) {
+	c.entries++
+	if !bytes.Equal(entry.PrevHash, c.prev) {
+		return &AuditBreak{EntryID: entry.ID, Reason: "previous hash does not match the entry before it"}, nil
+	}
+	hash, err := AuditHash(c.prev, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(entry.Hash, hash) {
+		return &AuditBreak{EntryID: entry.ID, Reason: "hash does not match the entry's contents"}, nil
+	}
+	for _, cp := range c.checkpoints[entry.ID] {
+		if !bytes.Equal(cp.Hash, hash) {
+			return &AuditBreak{EntryID: entry.ID, CheckpointID: cp.ID, Reason: "entry does not match its checkpoint"}, nil
+		}
+		if c.key != nil && !cp.VerifySignature(c.key) {
+			return &AuditBreak{EntryID: entry.ID, CheckpointID: cp.ID, Reason: "checkpoint signature is invalid"}, nil
+		}
+	}
+	delete(c.checkpoints, entry.ID)
+	c.prev = hash
+	return nil, nil
+}
+
+// VerifySignature reports whether the checkpoint was signed with the private
+// half of key.
+func (c AuditCheckpoint) VerifySignature(key ed25519.PublicKey) bool {
+	return ed25519.Verify(key, c.Message(), c.Signature)
+}
+
+// Finish returns a break for a checkpoint whose entry was never verified,
+// which means entries were removed from the end of the log.
+func (c *AuditChain) Finish() *AuditBreak {
+	var missing *AuditCheckpoint
+	for _, cps := range c.checkpoints {
+		for _, cp := range cps {
+			if missing == nil || cp.EntryID < missing.EntryID {
+				missing = &cp
+			}
+		}
+	}
+	if missing == nil {
+		return nil
+	}
+	return &AuditBreak{EntryID: missing.EntryID, CheckpointID: missing.ID, Reason: "checkpointed entry is missing"}
+}
+
+// Entries returns the number of entries verified so far.
+func (c *AuditChain) Entries() int {
+	return c.entries
+}
@@ -0,0 +1,233 @@
+package domain_test
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// auditLog chains three entries the way the store records them.
+func auditLog(t *testing.T) []domain.AuditEntry {
+	t.Helper()
+	off := false
+	on := true
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	entries := []domain.AuditEntry{
+		{ID: 1, Actor: "alice", Action: domain.AuditCreate, FlagName: "new-checkout", After: &domain.Flag{Name: "new-checkout", Value: domain.FlagValue{Bool: &off}}, RecordedAt: at},
+		{ID: 2, Actor: "bob", Action: domain.AuditUpdateValue, FlagName: "new-checkout", After: &domain.Flag{Name: "new-checkout", Value: domain.FlagValue{Bool: &on}}, RecordedAt: at.Add(time.Minute)},
+		{ID: 3, Actor: "alice", Action: domain.AuditSetEnabled, FlagName: "new-checkout", After: &domain.Flag{Name: "new-checkout", Disabled: true}, RecordedAt: at.Add(time.Hour)},
+	}
+	var prev []byte
+	for i := range entries {
+		hash, err := domain.AuditHash(prev, entries[i])
+		require.NoError(t, err)
+		entries[i].PrevHash, entries[i].Hash = prev, hash
+		prev = hash
+	}
+	return entries
+}
+
+func signedCheckpoint(key ed25519.PrivateKey, id int64, entry domain.AuditEntry) domain.AuditCheckpoint {
+	cp := domain.AuditCheckpoint{ID: id, EntryID: entry.ID, Hash: entry.Hash, CreatedAt: entry.RecordedAt.Add(time.Second)}
+	cp.Signature = ed25519.Sign(key, cp.Message())
+	return cp
+}
+
+func TestAuditHash(t *testing.T) {
+	t.Parallel()
+
+	entry := auditLog(t)[1]
+	same, err := domain.AuditHash(entry.PrevHash, entry)
+	require.NoError(t, err)
+	assert.Equal(t, entry.Hash, same)
+
+	local := entry
+	local.RecordedAt = entry.RecordedAt.In(time.FixedZone("CEST", 2*60*60))
+	hash, err := domain.AuditHash(entry.PrevHash, local)
+	require.NoError(t, err)
+	assert.Equal(t, entry.Hash, hash, "the zone of the time does not matter")
+
+	local.ID = 99
+	hash, err = domain.AuditHash(entry.PrevHash, local)
+	require.NoError(t, err)
+	assert.Equal(t, entry.Hash, hash, "the id is not hashed")
+
+	local.Emergency = "incident 4711"
+	hash, err = domain.AuditHash(entry.PrevHash, local)
+	require.NoError(t, err)
+	assert.NotEqual(t, entry.Hash, hash, "an emergency override is hashed")
+
+	local.Emergency = ""
+	local.Actor = "mallory"
+	hash, err = domain.AuditHash(entry.PrevHash, local)
+	require.NoError(t, err)
+	assert.NotEqual(t, entry.Hash, hash)
+
+	hash, err = domain.AuditHash(nil, entry)
+	require.NoError(t, err)
+	assert.NotEqual(t, entry.Hash, hash, "the previous hash is chained in")
+}
+
+func TestAuditChain(t *testing.T) {
+	t.Parallel()
+
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		tamper      func([]domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint)
+		key         ed25519.PublicKey
+		wantBreak   *domain.AuditBreak
+		wantEntries int
+	}{
+		{
+			name: "intact log",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries, []domain.AuditCheckpoint{signedCheckpoint(private, 1, entries[1])}
+			},
+			key:         public,
+			wantEntries: 3,
+		},
+		{
+			name: "edited entry",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				entries[1].Actor = "mallory"
+				return entries, nil
+			},
+			wantBreak:   &domain.AuditBreak{EntryID: 2, Reason: "hash does not match the entry's contents"},
+			wantEntries: 2,
+		},
+		{
+			name: "removed entry",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return []domain.AuditEntry{entries[0], entries[2]}, nil
+			},
+			wantBreak:   &domain.AuditBreak{EntryID: 3, Reason: "previous hash does not match the entry before it"},
+			wantEntries: 2,
+		},
+		{
+			name: "rehashed chain",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				cp := signedCheckpoint(private, 1, entries[1])
+				entries[1].Actor = "mallory"
+				entries[1].Hash, _ = domain.AuditHash(entries[1].PrevHash, entries[1])
+				entries[2].PrevHash = entries[1].Hash
+				entries[2].Hash, _ = domain.AuditHash(entries[2].PrevHash, entries[2])
+				return entries, []domain.AuditCheckpoint{cp}
+			},
+			key:         public,
+			wantBreak:   &domain.AuditBreak{EntryID: 2, CheckpointID: 1, Reason: "entry does not match its checkpoint"},
+			wantEntries: 2,
+		},
+		{
+			name: "forged checkpoint",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries, []domain.AuditCheckpoint{signedCheckpoint(otherKey, 4, entries[2])}
+			},
+			key:         public,
+			wantBreak:   &domain.AuditBreak{EntryID: 3, CheckpointID: 4, Reason: "checkpoint signature is invalid"},
+			wantEntries: 3,
+		},
+		{
+			name: "unverified signature without a key",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries, []domain.AuditCheckpoint{signedCheckpoint(otherKey, 4, entries[2])}
+			},
+			wantEntries: 3,
+		},
+		{
+			name: "truncated log",
+			tamper: func(entries []domain.AuditEntry) ([]domain.AuditEntry, []domain.AuditCheckpoint) {
+				return entries[:2], []domain.AuditCheckpoint{signedCheckpoint(private, 2, entries[2])}
+			},
+			key:         public,
+			wantBreak:   &domain.AuditBreak{EntryID: 3, CheckpointID: 2, Reason: "checkpointed entry is missing"},
+			wantEntries: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, checkpoints := tt.tamper(auditLog(t))
+			chain := domain.NewAuditChain(checkpoints, tt.key)
+			var got *domain.AuditBreak
+			for _, entry := range entries {
+				brk, err := chain.Next(entry)
+				require.NoError(t, err)
+				if brk != nil {
+					got = brk
+					break
+				}
+			}
+			if got == nil {
+				got = chain.Finish()
+			}
+			assert.Equal(t, tt.wantBreak, got)
+			assert.Equal(t, tt.wantEntries, chain.Entries())
+		})
+	}
+}
+
+func TestAuditHash_Pinned(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	share := 0.25
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	started := at.Add(-time.Hour)
+	after := &domain.Flag{
+		Project:           "payments",
+		Name:              "new-checkout",
+		Type:              domain.FlagTypeBoolean,
+		Description:       "checkout rewrite",
+		Tags:              []string{"checkout"},
+		Environment:       "staging",
+		SourceEnvironment: "production",
+		Value:             domain.FlagValue{Bool: &on},
+		Rules: []domain.Rule{
+			{ID: "beta", Clauses: []domain.Clause{{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"beta"}}}, Value: domain.FlagValue{Bool: &on}},
+		},
+		Targets:  map[string]domain.FlagValue{"user-1": {Bool: &on}},
+		Variants: []domain.Variant{{Key: "on", Value: domain.FlagValue{Bool: &on}}},
+		Experiment: &domain.Experiment{
+			Key: "checkout-test", Project: "payments", FlagName: "new-checkout", Environment: "staging", Allocation: 50,
+			Variants: []domain.VariantWeight{{Variant: "on", Weight: 1}},
+			Bandit:   &domain.BanditConfig{Algorithm: domain.BanditEpsilonGreedy, Metric: "purchase", Epsilon: share},
+			Status:   domain.ExperimentRunning, StartedAt: &started, CreatedAt: started, UpdatedAt: started,
+		},
+		Version:   7,
+		CreatedAt: at.Add(-24 * time.Hour),
+		UpdatedAt: at,
+	}
+	entry := domain.AuditEntry{
+		Actor: "alice", Action: domain.AuditUpdateRules, Project: "payments", Environment: "staging", FlagName: "new-checkout",
+		After: after, RequestID: "req-1", RecordedAt: at,
+	}
+
+	hash, err := domain.AuditHash([]byte{0x01}, entry)
+	require.NoError(t, err)
+	assert.Equal(t, "636bc4151ced4344a11897f7975b978527578d381519d94125105de96c9d24e4", hex.EncodeToString(hash),
+		"recorded hashes must keep verifying")
+
+	empty := *after
+	empty.Tags, empty.Rules, empty.Targets = []string{}, []domain.Rule{}, map[string]domain.FlagValue{}
+	bare := empty
+	bare.Tags, bare.Rules, bare.Targets = nil, nil, nil
+	entry.After = &empty
+	emptyHash, err := domain.AuditHash(nil, entry)
+	require.NoError(t, err)
+	entry.After = &bare
+	bareHash, err := domain.AuditHash(nil, entry)
+	require.NoError(t, err)
+	assert.Equal(t, bareHash, emptyHash, "empty and missing lists hash the same")
+}
@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BanditAlgorithm selects how a bandit experiment turns rewards into weights.
+type BanditAlgorithm string
+
+const (
+	// BanditThompson weights each variant by the probability that it is the
+	// best one under a Beta posterior of its conversion rate.
+	BanditThompson BanditAlgorithm = "thompson"
+	// BanditEpsilonGreedy sends 1-Epsilon of traffic to the variant with the
+	// highest observed conversion rate and spreads Epsilon evenly.
+	BanditEpsilonGreedy BanditAlgorithm = "epsilon-greedy"
+)
+
+// BanditConfig turns an experiment into a multi-armed bandit whose variant
+// weights are periodically recomputed from conversions on Metric.
+type BanditConfig struct {
+	Algorithm BanditAlgorithm
+	// Metric is the reward: an exposed user who converted on it is a success.
+	Metric string
+	// Epsilon is the exploration share of epsilon-greedy, in (0, 1].
+	Epsilon float64
+	// MinExploration is the share of traffic every variant keeps whatever its
+	// performance, in [0, 1/len(variants)].
+	MinExploration float64
+}
+
+// WeightChange records the variant weights a bandit experiment moved to.
+type WeightChange struct {
+	ExperimentKey string
+	Variants      []VariantWeight
+	ChangedAt     time.Time
+}
+
+// BanditWeightTotal is the sum bandit weights are scaled to, giving a
+// resolution of 0.1% of enrolled traffic.
+const BanditWeightTotal = 1000
+
+// BanditMinWeightChange is how far, out of BanditWeightTotal, some variant's
+// weight must move before new bandit weights replace the current ones. It
+// sits above the sampling noise of thompson, so weights are not rewritten,
+// and users at the split boundaries re-bucketed, while the rewards stand still.
+const BanditMinWeightChange = BanditWeightTotal / 100
+
+// BanditWeightsMoved reports whether next differs from current by more than
+// BanditMinWeightChange for some variant, comparing both as shares of
+// BanditWeightTotal so weights given on another scale compare by their split.
+// Weights naming different variants have always moved.
+func BanditWeightsMoved(current, next []VariantWeight) bool {
+	if len(current) != len(next) {
+		return true
+	}
+	currentTotal, nextTotal := 0, 0
+	for i := range current {
+		if current[i].Variant != next[i].Variant {
+			return true
+		}
+		currentTotal += current[i].Weight
+		nextTotal += next[i].Weight
+	}
+	if currentTotal == 0 || nextTotal == 0 {
+		return currentTotal != nextTotal
+	}
+	for i := range current {
+		was := float64(current[i].Weight) * BanditWeightTotal / float64(currentTotal)
+		is := float64(next[i].Weight) * BanditWeightTotal / float64(nextTotal)
+		if math.Abs(is-was) > BanditMinWeightChange {
+			return true
+		}
+	}
+	return false
+}
+
+// thompsonDraws is the number of posterior samples used to estimate each
+// variant's probability of being best.
+const thompsonDraws = 10000
+
+// ComputeBanditWeights returns new weights for variants, in the same order,
+// from the per-variant reward stats. Variants without stats count as unseen.
+// Every variant keeps at least cfg.MinExploration of traffic and a weight of
+// at least 1, so no arm is ever starved entirely.
+func ComputeBanditWeights(cfg BanditConfig, variants []VariantWeight, stats []VariantStats, rng *rand.Rand) []VariantWeight {
+	byVariant := make(map[string]VariantStats, len(stats))
+	for _, s := range stats {
+		byVariant[s.Variant] = s
+	}
+	arms := make([]VariantStats, len(variants))
+	for i, v := range variants {
+		arms[i] = byVariant[v.Variant]
+	}
+
+	var shares []float64
+	switch cfg.Algorithm {
+	case BanditEpsilonGreedy:
+		shares = epsilonGreedyShares(arms, cfg.Epsilon)
+	default:
+		shares = thompsonShares(arms, rng)
+	}
+
+	k := float64(len(variants))
+	floor := min(cfg.MinExploration, 1/k)
+	for i := range shares {
+		shares[i] = floor + (1-k*floor)*shares[i]
+	}
+	return scaleWeights(variants, shares)
+}
+
+func epsilonGreedyShares(arms []VariantStats, epsilon float64) []float64 {
+	best := 0
+	for i, arm := range arms {
+		if rate(arm.Conversions, arm.Users) > rate(arms[best].Conversions, arms[best].Users) {
+			best = i
+		}
+	}
+	shares := make([]float64, len(arms))
+	for i := range shares {
+		shares[i] = epsilon / float64(len(arms))
+	}
+	shares[best] += 1 - epsilon
+	return shares
+}
+
+// thompsonShares estimates, by sampling, the probability that each arm has
+// the highest conversion rate under a Beta(1+conversions, 1+failures) posterior.
+func thompsonShares(arms []VariantStats, rng *rand.Rand) []float64 {
+	wins := make([]float64, len(arms))
+	for range thompsonDraws {
+		best, bestDraw := 0, -1.0
+		for i, arm := range arms {
+			failures := max(arm.Users-arm.Conversions, 0)
+			if draw := sampleBeta(rng, float64(1+arm.Conversions), float64(1+failures)); draw > bestDraw {
+				best, bestDraw = i, draw
+			}
+		}
+		wins[best]++
+	}
+	for i := range wins {
+		wins[i] /= thompsonDraws
+	}
+	return wins
+}
+
+func sampleBeta(rng *rand.Rand, a, b float64) float64 {
+	x := sampleGamma(rng, a)
+	return x / (x + sampleGamma(rng, b))
+}
+
+// sampleGamma draws from Gamma(shape, 1) with the Marsaglia-Tsang method,
+// which needs shape >= 1; Beta posteriors with a uniform prior always satisfy it.
+func sampleGamma(rng *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// scaleWeights converts shares summing to 1 into integer weights summing to
+// BanditWeightTotal by largest remainder. A variant rounded down to zero is
+// raised to 1 at the expense of the heaviest one.
+func scaleWeights(variants []VariantWeight, shares []float64) []VariantWeight {
+	out := make([]VariantWeight, len(variants))
+	remaining, excess := BanditWeightTotal, 0
+	fractions := make([]float64, len(variants))
+	for i, v := range variants {
+		exact := shares[i] * BanditWeightTotal
+		out[i] = VariantWeight{Variant: v.Variant, Weight: int(exact)}
+		fractions[i] = exact - math.Floor(exact)
+		remaining -= out[i].Weight
+	}
+	for ; remaining > 0; remaining-- {
+		largest := 0
+		for i := range fractions {
+			if fractions[i] > fractions[largest] {
+				largest = i
+			}
+		}
+		out[largest].Weight++
+		fractions[largest] = -1
+	}
+	largest := 0
+	for i := range out {
+		if out[i].Weight == 0 {
+			out[i].Weight = 1
+			excess++
+		}
+		if out[i].Weight > out[largest].Weight {
+			largest = i
+		}
+	}
+	out[largest].Weight -= excess
+	return out
+}
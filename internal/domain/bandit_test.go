@@ -0,0 +1,118 @@
+package domain_test
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestComputeBanditWeights(t *testing.T) {
+	t.Parallel()
+
+	variants := []domain.VariantWeight{
+		{Variant: "control", Weight: 1},
+		{Variant: "banner-a", Weight: 1},
+		{Variant: "banner-b", Weight: 1},
+	}
+	stats := []domain.VariantStats{
+		{Variant: "control", Users: 1000, Conversions: 50},
+		{Variant: "banner-a", Users: 1000, Conversions: 52},
+		{Variant: "banner-b", Users: 1000, Conversions: 120},
+	}
+
+	tests := []struct {
+		name string
+		cfg  domain.BanditConfig
+		// want is the expected weight per variant; zero means only the bounds are checked.
+		want map[string]int
+	}{
+		{
+			name: "thompson favours the clear winner",
+			cfg:  domain.BanditConfig{Algorithm: domain.BanditThompson, Metric: "click", MinExploration: 0.05},
+			want: map[string]int{"control": 50, "banner-a": 50, "banner-b": 900},
+		},
+		{
+			name: "epsilon-greedy",
+			cfg:  domain.BanditConfig{Algorithm: domain.BanditEpsilonGreedy, Metric: "click", Epsilon: 0.3},
+			want: map[string]int{"control": 100, "banner-a": 100, "banner-b": 800},
+		},
+		{
+			name: "epsilon-greedy with exploration floor",
+			cfg:  domain.BanditConfig{Algorithm: domain.BanditEpsilonGreedy, Metric: "click", Epsilon: 0.3, MinExploration: 0.2},
+			want: map[string]int{"control": 240, "banner-a": 240, "banner-b": 520},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := domain.ComputeBanditWeights(tt.cfg, variants, stats, rand.New(rand.NewPCG(1, 2)))
+
+			require.Len(t, got, len(variants))
+			total := 0
+			for i, w := range got {
+				assert.Equal(t, variants[i].Variant, w.Variant, "order is preserved")
+				assert.InDelta(t, tt.want[w.Variant], w.Weight, 5)
+				total += w.Weight
+			}
+			assert.Equal(t, domain.BanditWeightTotal, total)
+		})
+	}
+}
+
+func TestComputeBanditWeights_NoData(t *testing.T) {
+	t.Parallel()
+
+	variants := []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	cfg := domain.BanditConfig{Algorithm: domain.BanditThompson, Metric: "click"}
+
+	got := domain.ComputeBanditWeights(cfg, variants, nil, rand.New(rand.NewPCG(1, 2)))
+
+	assert.InDelta(t, 500, got[0].Weight, 25, "unseen arms are equally likely to be best")
+	assert.InDelta(t, 500, got[1].Weight, 25)
+}
+
+func TestComputeBanditWeights_NeverStarves(t *testing.T) {
+	t.Parallel()
+
+	variants := []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	stats := []domain.VariantStats{
+		{Variant: "control", Users: 100000, Conversions: 100},
+		{Variant: "treatment", Users: 100000, Conversions: 50000},
+	}
+	cfg := domain.BanditConfig{Algorithm: domain.BanditThompson, Metric: "click"}
+
+	got := domain.ComputeBanditWeights(cfg, variants, stats, rand.New(rand.NewPCG(1, 2)))
+
+	assert.Equal(t, 1, got[0].Weight)
+	assert.Equal(t, domain.BanditWeightTotal-1, got[1].Weight)
+}
+
+func TestBanditWeightsMoved(t *testing.T) {
+	t.Parallel()
+
+	weights := func(control, treatment int) []domain.VariantWeight {
+		return []domain.VariantWeight{{Variant: "control", Weight: control}, {Variant: "treatment", Weight: treatment}}
+	}
+	tests := []struct {
+		name          string
+		current, next []domain.VariantWeight
+		want          bool
+	}{
+		{name: "sampling noise", current: weights(497, 503), next: weights(503, 497), want: false},
+		{name: "same split on another scale", current: weights(1, 1), next: weights(495, 505), want: false},
+		{name: "moved", current: weights(500, 500), next: weights(480, 520), want: true},
+		{name: "moved from another scale", current: weights(1, 1), next: weights(100, 900), want: true},
+		{name: "other variants", current: weights(500, 500), next: []domain.VariantWeight{{Variant: "control", Weight: 500}, {Variant: "banner", Weight: 500}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, domain.BanditWeightsMoved(tt.current, tt.next))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+// Lineage returns key followed by its ancestors, nearest first. It is empty
+// when key is not one of envs.
+func Lineage(envs []Environment, key string) []string {
+	parents := make(map[string]string, len(envs))
+	for _, env := range envs {
+		parents[env.Key] = env.Parent
+	}
+	var lineage []string
+	for {
+		parent, ok := parents[key]
+		if !ok {
+			return lineage
+		}
+		lineage = append(lineage, key)
+		if parent == "" {
+			return lineage
+		}
+		key = parent
+	}
+}
+
+// Descendants returns every environment that inherits from key, directly or
+// through another environment, in breadth-first order.
+func Descendants(envs []Environment, key string) []string {
+	children := make(map[string][]string, len(envs))
+	for _, env := range envs {
+		if env.Parent != "" {
+			children[env.Parent] = append(children[env.Parent], env.Key)
+		}
+	}
+	var descendants []string
+	queue := children[key]
+	for len(queue) > 0 {
+		descendants = append(descendants, queue[0])
+		queue = append(queue[1:], children[queue[0]]...)
+	}
+	return descendants
+}
+
+// Roots returns the keys of the environments without a parent, in the order
+// of envs. New flags are given their state in these.
+func Roots(envs []Environment) []string {
+	var roots []string
+	for _, env := range envs {
+		if env.Parent == "" {
+			roots = append(roots, env.Key)
+		}
+	}
+	return roots
+}
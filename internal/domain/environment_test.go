@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestLineageAndDescendants(t *testing.T) {
+	t.Parallel()
+
+	envs := []domain.Environment{
+		{Key: "production"},
+		{Key: "staging", Parent: "production"},
+		{Key: "dev", Parent: "staging"},
+		{Key: "qa", Parent: "production"},
+		{Key: "sandbox"},
+	}
+
+	tests := []struct {
+		key             string
+		wantLineage     []string
+		wantDescendants []string
+	}{
+		{key: "production", wantLineage: []string{"production"}, wantDescendants: []string{"staging", "qa", "dev"}},
+		{key: "staging", wantLineage: []string{"staging", "production"}, wantDescendants: []string{"dev"}},
+		{key: "dev", wantLineage: []string{"dev", "staging", "production"}},
+		{key: "sandbox", wantLineage: []string{"sandbox"}},
+		{key: "ghost"},
+	}
+
+	assert.Equal(t, []string{"production", "sandbox"}, domain.Roots(envs))
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantLineage, domain.Lineage(envs, tt.key))
+			assert.Equal(t, tt.wantDescendants, domain.Descendants(envs, tt.key))
+		})
+	}
+}
@@ -3,9 +3,58 @@ package domain
 import "errors"
 
 var (
-	ErrNotFound      = errors.New("flag not found")
-	ErrAlreadyExists = errors.New("flag already exists")
-	ErrTypeMismatch  = errors.New("value type does not match flag type")
-	ErrInvalidName   = errors.New("invalid flag name")
-	ErrInvalidValue  = errors.New("invalid flag value")
+	ErrNotFound              = errors.New("flag not found")
+	ErrAlreadyExists         = errors.New("flag already exists")
+	ErrTypeMismatch          = errors.New("value type does not match flag type")
+	ErrInvalidName           = errors.New("invalid flag name")
+	ErrInvalidValue          = errors.New("invalid flag value")
+	ErrInvalidRule           = errors.New("invalid targeting rule")
+	ErrInvalidTarget         = errors.New("invalid individual target")
+	ErrInvalidVariant        = errors.New("invalid flag variant")
+	ErrExperimentNotFound    = errors.New("experiment not found")
+	ErrExperimentExists      = errors.New("experiment already exists")
+	ErrInvalidExperiment     = errors.New("invalid experiment")
+	ErrExperimentState       = errors.New("experiment cannot make this transition")
+	ErrInvalidMetric         = errors.New("invalid metric event")
+	ErrLayerNotFound         = errors.New("layer not found")
+	ErrLayerExists           = errors.New("layer already exists")
+	ErrInvalidLayer          = errors.New("invalid layer")
+	ErrLayerFull             = errors.New("layer has no room for the allocation")
+	ErrEnvironmentNotFound   = errors.New("environment not found")
+	ErrEnvironmentExists     = errors.New("environment already exists")
+	ErrInvalidEnvironment    = errors.New("invalid environment")
+	ErrInvalidPromotion      = errors.New("invalid promotion")
+	ErrProjectNotFound       = errors.New("project not found")
+	ErrProjectExists         = errors.New("project already exists")
+	ErrInvalidProject        = errors.New("invalid project")
+	ErrInvalidAuditQuery     = errors.New("invalid audit query")
+	ErrVersionNotFound       = errors.New("flag version not found")
+	ErrInvalidTimestamp      = errors.New("invalid timestamp")
+	ErrAuditChainBroken      = errors.New("audit chain is broken")
+	ErrInvalidProtection     = errors.New("invalid flag protection")
+	ErrChangeRequestNotFound = errors.New("change request not found")
+	ErrChangeRequestState    = errors.New("change request is no longer pending")
+	ErrInvalidChangeQuery    = errors.New("invalid change request query")
+	ErrApprovalNotAllowed    = errors.New("approver may not approve this change")
+	ErrProtected             = errors.New("flag is protected")
+	ErrInvalidTag            = errors.New("invalid flag tag")
+	ErrFrozen                = errors.New("changes are frozen")
+	ErrInvalidFreeze         = errors.New("invalid freeze window")
+	ErrFreezeNotFound        = errors.New("freeze window not found")
+	ErrWebhookNotFound       = errors.New("webhook not found")
+	ErrInvalidWebhook        = errors.New("invalid webhook")
+	ErrDeliveryNotFound      = errors.New("webhook delivery not found")
+	ErrInvalidDeliveryQuery  = errors.New("invalid webhook delivery query")
+	ErrDeliveryState         = errors.New("webhook delivery is not dead")
+	ErrUnauthenticated       = errors.New("missing or invalid api key")
+	ErrInsufficientScope     = errors.New("api key lacks the required scope")
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrInvalidAPIKey         = errors.New("invalid api key")
+	ErrAPIKeyRevoked         = errors.New("api key is revoked or expired")
+	ErrForbidden             = errors.New("principal is not permitted to do this")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrRoleExists            = errors.New("role already exists")
+	ErrInvalidRole           = errors.New("invalid role")
+	ErrRoleBindingNotFound   = errors.New("role binding not found")
+	ErrRoleBindingExists     = errors.New("role binding already exists")
 )
@@ -0,0 +1,104 @@
+package domain
+
+import "time"
+
+// EvaluationReason explains why an evaluation produced the value it did.
+type EvaluationReason string
+
+const (
+	// ReasonStatic means the flag has no targeting and served its stored value.
+	ReasonStatic EvaluationReason = "STATIC"
+	// ReasonDefault means the flag has targeting but nothing matched, so the
+	// stored value was served as the fallthrough.
+	ReasonDefault EvaluationReason = "DEFAULT"
+	// ReasonTargetMatch means the targeting key is an individual target of the flag.
+	ReasonTargetMatch EvaluationReason = "TARGET_MATCH"
+	// ReasonTargetingMatch means a targeting rule matched; see Evaluation.RuleID.
+	ReasonTargetingMatch EvaluationReason = "TARGETING_MATCH"
+	// ReasonSplit means the value was picked by a percentage split; see Evaluation.Bucket.
+	ReasonSplit EvaluationReason = "SPLIT"
+	// ReasonPrerequisiteFailed means a prerequisite flag did not evaluate as required.
+	ReasonPrerequisiteFailed EvaluationReason = "PREREQUISITE_FAILED"
+	// ReasonDisabled means the flag is switched off and served its stored value.
+	ReasonDisabled EvaluationReason = "DISABLED"
+	// ReasonError means the flag could not be evaluated.
+	ReasonError EvaluationReason = "ERROR"
+)
+
+// ValueSource identifies the storage a flag was read from during evaluation.
+type ValueSource string
+
+const (
+	SourceCache ValueSource = "cache"
+	SourceStore ValueSource = "store"
+	// SourceHistory marks a flag reconstructed from its recorded versions for
+	// a point-in-time read.
+	SourceHistory ValueSource = "history"
+)
+
+// EvaluationContext describes the subject a flag is evaluated for.
+type EvaluationContext struct {
+	// TargetingKey uniquely identifies the subject, e.g. a user or device id.
+	TargetingKey string
+	// Attributes holds arbitrary subject properties used by targeting.
+	Attributes map[string]any
+}
+
+// Evaluation is the outcome of evaluating a flag.
+type Evaluation struct {
+	Value  FlagValue
+	Reason EvaluationReason
+	// RuleID identifies the matching rule when Reason is ReasonTargetingMatch.
+	RuleID string
+	// Bucket is the bucket the context hashed into when Reason is ReasonSplit.
+	Bucket *int
+	// Experiment and Variant identify the experiment and the variant served
+	// when Reason is ReasonSplit.
+	Experiment string
+	Variant    string
+	// Version is the version of the flag that was evaluated.
+	Version int64
+}
+
+// Evaluate resolves the value a flag serves for the given context at time now.
+// A disabled flag serves its stored value. Otherwise an individual target for the context's targeting key wins outright; then
+// rules are tried in order and the first match wins; then a running experiment
+// splits enrolled contexts between its variants; otherwise the flag's stored
+// value is served.
+func Evaluate(flag Flag, evalCtx EvaluationContext, now time.Time) Evaluation {
+	eval := Evaluation{Value: flag.Value, Reason: ReasonStatic, Version: flag.Version}
+	if flag.Disabled {
+		eval.Reason = ReasonDisabled
+		return eval
+	}
+	if value, ok := flag.Targets[evalCtx.TargetingKey]; ok && evalCtx.TargetingKey != "" {
+		eval.Value = value
+		eval.Reason = ReasonTargetMatch
+		return eval
+	}
+	if len(flag.Rules) == 0 && len(flag.Targets) == 0 && flag.Experiment == nil {
+		return eval
+	}
+	for _, rule := range flag.Rules {
+		if rule.matches(evalCtx, now) {
+			eval.Value = rule.Value
+			eval.Reason = ReasonTargetingMatch
+			eval.RuleID = rule.ID
+			return eval
+		}
+	}
+	if exp := flag.Experiment; exp != nil {
+		if a, ok := exp.Assign(evalCtx.TargetingKey); ok {
+			if value, ok := flag.variantValue(a.Variant); ok {
+				eval.Value = value
+				eval.Reason = ReasonSplit
+				eval.Bucket = &a.Bucket
+				eval.Experiment = exp.Key
+				eval.Variant = a.Variant
+				return eval
+			}
+		}
+	}
+	eval.Reason = ReasonDefault
+	return eval
+}
@@ -0,0 +1,375 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func boolValue(b bool) domain.FlagValue {
+	return domain.FlagValue{Bool: &b}
+}
+
+func TestEvaluate_Rules(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday 2026-03-04 15:30 UTC, 10:30 in New York.
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		rules      []domain.Rule
+		evalCtx    domain.EvaluationContext
+		wantValue  bool
+		wantReason domain.EvaluationReason
+		wantRuleID string
+	}{
+		{
+			name:       "no rules serves stored value",
+			wantValue:  false,
+			wantReason: domain.ReasonStatic,
+		},
+		{
+			name: "attribute in",
+			rules: []domain.Rule{{ID: "pro", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro", "enterprise"}},
+			}}},
+			evalCtx:    domain.EvaluationContext{Attributes: map[string]any{"plan": "pro"}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "pro",
+		},
+		{
+			name: "numeric attribute in",
+			rules: []domain.Rule{{ID: "seats", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: "seats", Operator: domain.OperatorIn, Values: []string{"10"}},
+			}}},
+			evalCtx:    domain.EvaluationContext{Attributes: map[string]any{"seats": 10.0}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "seats",
+		},
+		{
+			name: "targeting key in",
+			rules: []domain.Rule{{ID: "beta", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: domain.AttributeTargetingKey, Operator: domain.OperatorIn, Values: []string{"user-1"}},
+			}}},
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-1"},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "beta",
+		},
+		{
+			name: "missing attribute matches not-in",
+			rules: []domain.Rule{{ID: "not-free", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: "plan", Operator: domain.OperatorNotIn, Values: []string{"free"}},
+			}}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "not-free",
+		},
+		{
+			name: "no match falls through to default",
+			rules: []domain.Rule{{ID: "pro", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}},
+			}}},
+			evalCtx:    domain.EvaluationContext{Attributes: map[string]any{"plan": "free"}},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name: "all clauses must match",
+			rules: []domain.Rule{{ID: "pro-weekend", Value: boolValue(true), Clauses: []domain.Clause{
+				{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}},
+				{Operator: domain.OperatorDayOfWeekIn, Values: []string{"sat", "sun"}},
+			}}},
+			evalCtx:    domain.EvaluationContext{Attributes: map[string]any{"plan": "pro"}},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name: "first matching rule wins",
+			rules: []domain.Rule{
+				{ID: "first", Value: boolValue(true), Clauses: []domain.Clause{
+					{Operator: domain.OperatorDayOfWeekIn, Values: []string{"wed"}},
+				}},
+				{ID: "second", Value: boolValue(false), Clauses: []domain.Clause{
+					{Operator: domain.OperatorDayOfWeekIn, Values: []string{"wed"}},
+				}},
+			},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "first",
+		},
+		{
+			name: "inside absolute range",
+			rules: []domain.Rule{{ID: "launch", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"2026-03-01T00:00:00Z", "2026-03-05T00:00:00Z"}},
+			}}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "launch",
+		},
+		{
+			name: "after open-ended start",
+			rules: []domain.Rule{{ID: "launch", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"2026-03-04T15:30:00Z", ""}},
+			}}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "launch",
+		},
+		{
+			name: "range end is exclusive",
+			rules: []domain.Rule{{ID: "launch", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"", "2026-03-04T15:30:00Z"}},
+			}}},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name: "business hours in timezone",
+			rules: []domain.Rule{{ID: "office", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorDayOfWeekIn, Values: []string{"mon", "tue", "wed", "thu", "fri"}, Timezone: "America/New_York"},
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"09:00", "17:00"}, Timezone: "America/New_York"},
+			}}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "office",
+		},
+		{
+			name: "outside time of day in timezone",
+			rules: []domain.Rule{{ID: "office", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"12:00", "17:00"}, Timezone: "America/New_York"},
+			}}},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name: "time of day window wrapping midnight",
+			rules: []domain.Rule{{ID: "night", Value: boolValue(true), Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"22:00", "06:00"}, Timezone: "Asia/Tokyo"},
+			}}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+			wantRuleID: "night",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flag := domain.Flag{
+				Name:    "my-flag",
+				Type:    domain.FlagTypeBoolean,
+				Value:   boolValue(false),
+				Rules:   tt.rules,
+				Version: 5,
+			}
+
+			eval := domain.Evaluate(flag, tt.evalCtx, now)
+
+			assert.Equal(t, tt.wantValue, *eval.Value.Bool)
+			assert.Equal(t, tt.wantReason, eval.Reason)
+			assert.Equal(t, tt.wantRuleID, eval.RuleID)
+			assert.Equal(t, int64(5), eval.Version)
+		})
+	}
+}
+
+func TestEvaluate_IPRules(t *testing.T) {
+	t.Parallel()
+
+	office := []string{"10.20.0.0/16", "2001:db8:abcd::/48", "192.0.2.7"}
+
+	tests := []struct {
+		name     string
+		operator domain.Operator
+		clientIP any
+		want     bool
+	}{
+		{name: "ipv4 inside range", operator: domain.OperatorIPInCIDR, clientIP: "10.20.3.4", want: true},
+		{name: "ipv4 outside range", operator: domain.OperatorIPInCIDR, clientIP: "10.21.0.1", want: false},
+		{name: "ipv4 single host", operator: domain.OperatorIPInCIDR, clientIP: "192.0.2.7", want: true},
+		{name: "ipv4-mapped ipv6 inside range", operator: domain.OperatorIPInCIDR, clientIP: "::ffff:10.20.0.9", want: true},
+		{name: "ipv6 inside range", operator: domain.OperatorIPInCIDR, clientIP: "2001:db8:abcd:12::1", want: true},
+		{name: "ipv6 outside range", operator: domain.OperatorIPInCIDR, clientIP: "2001:db8:ffff::1", want: false},
+		{name: "malformed address", operator: domain.OperatorIPInCIDR, clientIP: "not-an-ip", want: false},
+		{name: "missing address", operator: domain.OperatorIPInCIDR, want: false},
+		{name: "not-in outside range", operator: domain.OperatorIPNotInCIDR, clientIP: "203.0.113.5", want: true},
+		{name: "not-in inside range", operator: domain.OperatorIPNotInCIDR, clientIP: "10.20.3.4", want: false},
+		{name: "not-in missing address", operator: domain.OperatorIPNotInCIDR, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flag := domain.Flag{
+				Name:  "internal-tools",
+				Type:  domain.FlagTypeBoolean,
+				Value: boolValue(false),
+				Rules: []domain.Rule{{ID: "office", Value: boolValue(true), Clauses: []domain.Clause{
+					{Attribute: domain.AttributeClientIP, Operator: tt.operator, Values: office},
+				}}},
+			}
+			evalCtx := domain.EvaluationContext{Attributes: map[string]any{}}
+			if tt.clientIP != nil {
+				evalCtx.Attributes[domain.AttributeClientIP] = tt.clientIP
+			}
+
+			eval := domain.Evaluate(flag, evalCtx, time.Now())
+
+			assert.Equal(t, tt.want, *eval.Value.Bool)
+		})
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday 2026-03-04 15:30 UTC, 10:30 in New York.
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	rules := []domain.Rule{
+		{ID: "broken", Value: boolValue(true), Expression: `plan ==`},
+		{ID: "malformed", Value: boolValue(true), Clauses: []domain.Clause{
+			{Attribute: domain.AttributeClientIP, Operator: domain.OperatorIPNotInCIDR, Values: []string{"10.0.0.0/33"}},
+		}},
+		{ID: "office", Value: boolValue(true), Clauses: []domain.Clause{
+			{Attribute: domain.AttributeClientIP, Operator: domain.OperatorIPInCIDR, Values: []string{"10.20.0.0/16"}},
+			{Operator: domain.OperatorTimeBetween, Values: []string{"2026-03-01T00:00:00Z", ""}},
+			{Operator: domain.OperatorDayOfWeekIn, Values: []string{"wed"}, Timezone: "America/New_York"},
+			{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"09:00", "17:00"}, Timezone: "America/New_York"},
+		}},
+	}
+	domain.CompileRules(rules)
+	flag := domain.Flag{Name: "internal-tools", Type: domain.FlagTypeBoolean, Value: boolValue(false), Rules: rules}
+
+	inside := domain.Evaluate(flag, domain.EvaluationContext{Attributes: map[string]any{domain.AttributeClientIP: "10.20.3.4"}}, now)
+	assert.Equal(t, "office", inside.RuleID, "a clause or expression that does not parse never matches")
+	assert.True(t, *inside.Value.Bool)
+
+	evening := domain.Evaluate(flag, domain.EvaluationContext{Attributes: map[string]any{domain.AttributeClientIP: "10.20.3.4"}}, now.Add(8*time.Hour))
+	assert.Equal(t, domain.ReasonDefault, evening.Reason)
+}
+
+func TestEvaluate_ExpressionRules(t *testing.T) {
+	t.Parallel()
+
+	flag := domain.Flag{
+		Name:  "new-billing",
+		Type:  domain.FlagTypeBoolean,
+		Value: boolValue(false),
+		Rules: []domain.Rule{{
+			ID:         "pro-or-staff",
+			Value:      boolValue(true),
+			Expression: `(plan == "pro" && seats > 10) || email.endsWith("@ourco.com") || targeting_key == "user-1"`,
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		evalCtx domain.EvaluationContext
+		want    bool
+	}{
+		{name: "large pro account", evalCtx: domain.EvaluationContext{Attributes: map[string]any{"plan": "pro", "seats": 25.0}}, want: true},
+		{name: "small pro account", evalCtx: domain.EvaluationContext{Attributes: map[string]any{"plan": "pro", "seats": 3.0}}, want: false},
+		{name: "staff email", evalCtx: domain.EvaluationContext{Attributes: map[string]any{"email": "ada@ourco.com"}}, want: true},
+		{name: "targeting key", evalCtx: domain.EvaluationContext{TargetingKey: "user-1"}, want: true},
+		{name: "no attributes", evalCtx: domain.EvaluationContext{}, want: false},
+		{name: "attribute of unexpected type", evalCtx: domain.EvaluationContext{Attributes: map[string]any{"plan": "pro", "seats": "many"}}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			eval := domain.Evaluate(flag, tt.evalCtx, time.Now())
+
+			assert.Equal(t, tt.want, *eval.Value.Bool)
+		})
+	}
+}
+
+func TestEvaluate_Targets(t *testing.T) {
+	t.Parallel()
+
+	flag := domain.Flag{
+		Name:  "new-checkout",
+		Type:  domain.FlagTypeBoolean,
+		Value: boolValue(false),
+		Rules: []domain.Rule{{ID: "everyone-pro", Value: boolValue(true), Clauses: []domain.Clause{
+			{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}},
+		}}},
+		Targets: map[string]domain.FlagValue{
+			"user-1234": boolValue(true),
+			"user-5678": boolValue(false),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		flag       domain.Flag
+		evalCtx    domain.EvaluationContext
+		wantValue  bool
+		wantReason domain.EvaluationReason
+	}{
+		{
+			name:       "target forces value",
+			flag:       flag,
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-1234"},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetMatch,
+		},
+		{
+			name:       "target takes precedence over a matching rule",
+			flag:       flag,
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-5678", Attributes: map[string]any{"plan": "pro"}},
+			wantValue:  false,
+			wantReason: domain.ReasonTargetMatch,
+		},
+		{
+			name:       "other keys fall through to rules",
+			flag:       flag,
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-9", Attributes: map[string]any{"plan": "pro"}},
+			wantValue:  true,
+			wantReason: domain.ReasonTargetingMatch,
+		},
+		{
+			name:       "targets without rules fall through to default",
+			flag:       domain.Flag{Value: boolValue(false), Targets: flag.Targets},
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-9"},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name:       "empty targeting key never matches",
+			flag:       domain.Flag{Value: boolValue(false), Targets: map[string]domain.FlagValue{"": boolValue(true)}},
+			wantValue:  false,
+			wantReason: domain.ReasonDefault,
+		},
+		{
+			name: "disabled flag ignores targets and rules",
+			flag: domain.Flag{
+				Value: boolValue(false), Rules: flag.Rules, Targets: flag.Targets, Disabled: true,
+			},
+			evalCtx:    domain.EvaluationContext{TargetingKey: "user-1234", Attributes: map[string]any{"plan": "pro"}},
+			wantValue:  false,
+			wantReason: domain.ReasonDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			eval := domain.Evaluate(tt.flag, tt.evalCtx, time.Now())
+
+			assert.Equal(t, tt.wantValue, *eval.Value.Bool)
+			assert.Equal(t, tt.wantReason, eval.Reason)
+		})
+	}
+}
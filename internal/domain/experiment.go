@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"hash/fnv"
+	"sort"
+	"time"
+)
+
+// Variant is a named value a multivariate flag can serve.
+type Variant struct {
+	Key   string
+	Value FlagValue
+}
+
+// ExperimentStatus is the lifecycle state of an Experiment. Experiments move
+// from draft to running to stopped and never back.
+type ExperimentStatus string
+
+const (
+	ExperimentDraft   ExperimentStatus = "draft"
+	ExperimentRunning ExperimentStatus = "running"
+	ExperimentStopped ExperimentStatus = "stopped"
+)
+
+// VariantWeight is the relative share of enrolled traffic a variant receives.
+type VariantWeight struct {
+	Variant string
+	Weight  int
+}
+
+// Experiment splits the traffic of a multivariate flag between its variants
+// in one environment.
+type Experiment struct {
+	Key         string
+	Project     string
+	FlagName    string
+	Environment string
+	// Allocation is the percentage (1-100) of contexts enrolled in the
+	// experiment. Contexts outside it are evaluated as if it did not exist.
+	Allocation int
+	// Variants are the flag variants under test and their relative weights.
+	Variants []VariantWeight
+	// Layer names the mutually exclusive layer the experiment belongs to, if
+	// any. Its allocation is then the slice [LayerOffset, LayerOffset+Allocation)
+	// of the layer's buckets, which no other live experiment in the layer shares.
+	Layer       string
+	LayerOffset int
+	// Holdout is the global holdout percentage in force when the experiment
+	// started. Contexts in the holdout are never enrolled.
+	Holdout int
+	// Bandit, when set, makes the experiment a multi-armed bandit whose
+	// variant weights are recomputed from rewards while it runs.
+	Bandit    *BanditConfig
+	Status    ExperimentStatus
+	StartedAt *time.Time
+	StoppedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ExposureEvent records that a context was assigned to an experiment variant.
+type ExposureEvent struct {
+	ExperimentKey string
+	FlagName      string
+	Variant       string
+	TargetingKey  string
+	ExposedAt     time.Time
+}
+
+// Layer is a group of mutually exclusive experiments. Member experiments
+// partition the layer's 100 buckets, so a context is enrolled in at most one
+// of them.
+type Layer struct {
+	Key         string
+	Description string
+	CreatedAt   time.Time
+}
+
+// LayerBuckets is the number of buckets a layer's hash space is divided into.
+const LayerBuckets = 100
+
+// Assignment is the outcome of enrolling a context in an experiment.
+type Assignment struct {
+	Variant string
+	// Bucket is the allocation bucket (0-99) the context hashed into.
+	Bucket int
+}
+
+// Assign deterministically places targetingKey in the experiment. It reports
+// false when the key is empty, in the global holdout, or hashes outside the
+// allocation. The same key always lands in the same variant for a given
+// experiment. Experiments outside a layer bucket independently; experiments in
+// the same layer bucket on the layer key and own disjoint bucket ranges.
+func (e Experiment) Assign(targetingKey string) (Assignment, bool) {
+	if targetingKey == "" || InHoldout(e.Holdout, targetingKey) {
+		return Assignment{}, false
+	}
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return Assignment{}, false
+	}
+
+	h := hashKey(e.Key, targetingKey)
+	bucket, start := int(h%100), 0
+	if e.Layer != "" {
+		bucket, start = int(hashKey(e.Layer, targetingKey)%LayerBuckets), e.LayerOffset
+	}
+	if bucket < start || bucket >= start+e.Allocation {
+		return Assignment{}, false
+	}
+	pick := int((h / 100) % uint64(total))
+	for _, v := range e.Variants {
+		if pick < v.Weight {
+			return Assignment{Variant: v.Variant, Bucket: bucket}, true
+		}
+		pick -= v.Weight
+	}
+	return Assignment{}, false
+}
+
+// holdoutSeed is shared by every experiment so the holdout is the same
+// population everywhere.
+const holdoutSeed = "global-holdout"
+
+// InHoldout reports whether targetingKey falls in a global holdout of the given
+// percentage.
+func InHoldout(percentage int, targetingKey string) bool {
+	return percentage > 0 && int(hashKey(holdoutSeed, targetingKey)%100) < percentage
+}
+
+// PlaceInLayer returns the lowest offset at which allocation buckets fit
+// between the ranges held by the layer's live (draft or running) members.
+func PlaceInLayer(members []Experiment, allocation int) (int, bool) {
+	var live []Experiment
+	for _, m := range members {
+		if m.Status != ExperimentStopped {
+			live = append(live, m)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].LayerOffset < live[j].LayerOffset })
+
+	offset := 0
+	for _, m := range live {
+		if m.LayerOffset-offset >= allocation {
+			return offset, true
+		}
+		offset = max(offset, m.LayerOffset+m.Allocation)
+	}
+	if LayerBuckets-offset >= allocation {
+		return offset, true
+	}
+	return 0, false
+}
+
+// variantValue returns the value of the flag variant named key.
+func (f Flag) variantValue(key string) (FlagValue, bool) {
+	for _, v := range f.Variants {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return FlagValue{}, false
+}
+
+func hashKey(seed, key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seed))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
@@ -0,0 +1,222 @@
+package domain_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func numericValue(n float64) domain.FlagValue {
+	return domain.FlagValue{Numeric: &n}
+}
+
+func TestExperiment_Assign(t *testing.T) {
+	t.Parallel()
+
+	exp := domain.Experiment{
+		Key:        "checkout-copy",
+		Allocation: 50,
+		Variants: []domain.VariantWeight{
+			{Variant: "control", Weight: 1},
+			{Variant: "treatment", Weight: 3},
+		},
+	}
+
+	_, ok := exp.Assign("")
+	assert.False(t, ok, "empty targeting key is never enrolled")
+
+	first, firstOK := exp.Assign("user-42")
+	again, againOK := exp.Assign("user-42")
+	assert.Equal(t, firstOK, againOK, "assignment is deterministic")
+	assert.Equal(t, first, again, "assignment is deterministic")
+
+	const n = 20000
+	enrolled := 0
+	counts := map[string]int{}
+	for i := range n {
+		a, ok := exp.Assign(fmt.Sprintf("user-%d", i))
+		if !ok {
+			continue
+		}
+		enrolled++
+		counts[a.Variant]++
+		assert.Less(t, a.Bucket, exp.Allocation)
+	}
+
+	assert.InDelta(t, 0.5, float64(enrolled)/n, 0.02)
+	assert.InDelta(t, 0.75, float64(counts["treatment"])/float64(enrolled), 0.02)
+	assert.InDelta(t, 0.25, float64(counts["control"])/float64(enrolled), 0.02)
+}
+
+func TestExperiment_AssignLayer(t *testing.T) {
+	t.Parallel()
+
+	weights := []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	first := domain.Experiment{Key: "copy", Layer: "checkout", LayerOffset: 0, Allocation: 30, Variants: weights}
+	second := domain.Experiment{Key: "layout", Layer: "checkout", LayerOffset: 30, Allocation: 50, Variants: weights}
+
+	const n = 20000
+	var inFirst, inSecond int
+	for i := range n {
+		key := fmt.Sprintf("user-%d", i)
+		_, a := first.Assign(key)
+		_, b := second.Assign(key)
+		require.False(t, a && b, "%s enrolled in two experiments of the same layer", key)
+		if a {
+			inFirst++
+		}
+		if b {
+			inSecond++
+		}
+	}
+
+	assert.InDelta(t, 0.3, float64(inFirst)/n, 0.02)
+	assert.InDelta(t, 0.5, float64(inSecond)/n, 0.02)
+}
+
+func TestExperiment_AssignHoldout(t *testing.T) {
+	t.Parallel()
+
+	weights := []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	exps := []domain.Experiment{
+		{Key: "copy", Allocation: 100, Holdout: 10, Variants: weights},
+		{Key: "layout", Layer: "checkout", Allocation: 100, Holdout: 10, Variants: weights},
+	}
+
+	const n = 20000
+	held := 0
+	for i := range n {
+		key := fmt.Sprintf("user-%d", i)
+		if !domain.InHoldout(10, key) {
+			continue
+		}
+		held++
+		for _, exp := range exps {
+			_, ok := exp.Assign(key)
+			assert.False(t, ok, "%s is in the holdout but enrolled in %s", key, exp.Key)
+		}
+	}
+
+	assert.InDelta(t, 0.1, float64(held)/n, 0.02)
+	assert.False(t, domain.InHoldout(0, "user-1"))
+}
+
+func TestPlaceInLayer(t *testing.T) {
+	t.Parallel()
+
+	member := func(offset, allocation int, status domain.ExperimentStatus) domain.Experiment {
+		return domain.Experiment{LayerOffset: offset, Allocation: allocation, Status: status}
+	}
+
+	tests := []struct {
+		name       string
+		members    []domain.Experiment
+		allocation int
+		want       int
+		ok         bool
+	}{
+		{name: "empty layer", allocation: 100, want: 0, ok: true},
+		{
+			name:       "after existing member",
+			members:    []domain.Experiment{member(0, 40, domain.ExperimentRunning)},
+			allocation: 60, want: 40, ok: true,
+		},
+		{
+			name: "fills first gap",
+			members: []domain.Experiment{
+				member(70, 30, domain.ExperimentRunning),
+				member(0, 20, domain.ExperimentDraft),
+			},
+			allocation: 50, want: 20, ok: true,
+		},
+		{
+			name:       "stopped members free their range",
+			members:    []domain.Experiment{member(0, 100, domain.ExperimentStopped)},
+			allocation: 100, want: 0, ok: true,
+		},
+		{
+			name: "no room",
+			members: []domain.Experiment{
+				member(0, 50, domain.ExperimentRunning),
+				member(60, 40, domain.ExperimentRunning),
+			},
+			allocation: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := domain.PlaceInLayer(tt.members, tt.allocation)
+
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Experiment(t *testing.T) {
+	t.Parallel()
+
+	flag := domain.Flag{
+		Name:  "checkout-copy",
+		Type:  domain.FlagTypeNumeric,
+		Value: numericValue(0),
+		Variants: []domain.Variant{
+			{Key: "control", Value: numericValue(1)},
+			{Key: "treatment", Value: numericValue(2)},
+		},
+		Experiment: &domain.Experiment{
+			Key:        "copy-test",
+			Allocation: 100,
+			Variants: []domain.VariantWeight{
+				{Variant: "control", Weight: 1},
+				{Variant: "treatment", Weight: 1},
+			},
+		},
+		Rules: []domain.Rule{{ID: "staff", Value: numericValue(9), Clauses: []domain.Clause{
+			{Attribute: "staff", Operator: domain.OperatorIn, Values: []string{"true"}},
+		}}},
+		Targets: map[string]domain.FlagValue{"user-qa": numericValue(7)},
+	}
+
+	t.Run("enrolled context gets a variant", func(t *testing.T) {
+		t.Parallel()
+
+		eval := domain.Evaluate(flag, domain.EvaluationContext{TargetingKey: "user-1"}, time.Now())
+
+		assert.Equal(t, domain.ReasonSplit, eval.Reason)
+		assert.Equal(t, "copy-test", eval.Experiment)
+		require.NotNil(t, eval.Bucket)
+		require.Contains(t, []string{"control", "treatment"}, eval.Variant)
+		want := map[string]float64{"control": 1, "treatment": 2}[eval.Variant]
+		assert.InDelta(t, want, *eval.Value.Numeric, 0)
+	})
+
+	t.Run("targets and rules take precedence", func(t *testing.T) {
+		t.Parallel()
+
+		target := domain.Evaluate(flag, domain.EvaluationContext{TargetingKey: "user-qa"}, time.Now())
+		assert.Equal(t, domain.ReasonTargetMatch, target.Reason)
+
+		rule := domain.Evaluate(flag, domain.EvaluationContext{TargetingKey: "user-1", Attributes: map[string]any{"staff": true}}, time.Now())
+		assert.Equal(t, domain.ReasonTargetingMatch, rule.Reason)
+		assert.Empty(t, rule.Variant)
+	})
+
+	t.Run("anonymous context falls through", func(t *testing.T) {
+		t.Parallel()
+
+		eval := domain.Evaluate(flag, domain.EvaluationContext{}, time.Now())
+
+		assert.Equal(t, domain.ReasonDefault, eval.Reason)
+		assert.InDelta(t, 0, *eval.Value.Numeric, 0)
+	})
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/expr"
+)
+
+// expressionEnv declares the attributes whose types are known ahead of
+// evaluation. Any other identifier in an expression is a caller-supplied
+// attribute and is type checked at evaluation time.
+var expressionEnv = expr.Env{
+	AttributeTargetingKey: expr.TypeString,
+	AttributeClientIP:     expr.TypeString,
+}
+
+// compileExpression parses and type checks source. The program is kept only
+// with the rule it was compiled for by CompileRules, so expressions that are
+// validated and rejected leave nothing behind.
+func compileExpression(source string) (*expr.Program, error) {
+	return expr.Compile(source, expressionEnv)
+}
+
+func validateExpression(source string) error {
+	if _, err := compileExpression(source); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRule, err)
+	}
+	return nil
+}
+
+// matchExpression evaluates prog against the context. An expression that
+// failed to compile, leaving prog nil, or fails to evaluate does not match,
+// like a clause over a missing attribute.
+func matchExpression(prog *expr.Program, evalCtx EvaluationContext) bool {
+	if prog == nil {
+		return false
+	}
+	vars := make(map[string]any, len(evalCtx.Attributes)+1)
+	for k, v := range evalCtx.Attributes {
+		vars[k] = v
+	}
+	if evalCtx.TargetingKey != "" {
+		vars[AttributeTargetingKey] = evalCtx.TargetingKey
+	}
+	ok, err := prog.Eval(vars)
+	return err == nil && ok
+}
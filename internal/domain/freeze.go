@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MaxFreezeReason bounds the reason given for a freeze window or an emergency
+// override.
+const MaxFreezeReason = 500
+
+// FreezeWindow suspends changes to flags from StartsAt until EndsAt. It covers
+// the flags of the listed Projects in the listed Environments that carry any
+// of the listed Tags; an empty list matches every project, environment or
+// flag respectively.
+type FreezeWindow struct {
+	ID           int64
+	Reason       string
+	StartsAt     time.Time
+	EndsAt       time.Time
+	Projects     []string
+	Environments []string
+	Tags         []string
+	CreatedBy    string
+	CreatedAt    time.Time
+}
+
+// ActiveAt reports whether the window is in force at the instant at.
+func (w FreezeWindow) ActiveAt(at time.Time) bool {
+	return !at.Before(w.StartsAt) && at.Before(w.EndsAt)
+}
+
+// Covers reports whether the window scopes project and env, ignoring its tags.
+func (w FreezeWindow) Covers(project, env string) bool {
+	return matchesAny(w.Projects, project) && matchesAny(w.Environments, env)
+}
+
+// CoversTags reports whether a flag carrying tags is within the window's tag
+// scope.
+func (w FreezeWindow) CoversTags(tags []string) bool {
+	return len(w.Tags) == 0 || slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(w.Tags, tag) })
+}
+
+func matchesAny(scope []string, key string) bool {
+	return len(scope) == 0 || slices.Contains(scope, key)
+}
+
+// ValidateFreezeWindow checks that the window ends after it starts, that its
+// reason is bounded and that its scope names valid keys and tags.
+func ValidateFreezeWindow(w FreezeWindow) error {
+	if w.StartsAt.IsZero() || w.EndsAt.IsZero() {
+		return fmt.Errorf("start and end are required: %w", ErrInvalidFreeze)
+	}
+	if !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("end must be after start: %w", ErrInvalidFreeze)
+	}
+	if len(w.Reason) > MaxFreezeReason {
+		return fmt.Errorf("reason exceeds %d characters: %w", MaxFreezeReason, ErrInvalidFreeze)
+	}
+	for _, keys := range [][]string{w.Projects, w.Environments} {
+		for _, key := range keys {
+			if ValidateFlagName(key) != nil {
+				return fmt.Errorf("scope key %q is not a valid name: %w", key, ErrInvalidFreeze)
+			}
+		}
+	}
+	if err := ValidateTags(w.Tags); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFreeze, err)
+	}
+	return nil
+}
+
+// FreezeQuery selects freeze windows. A zero ActiveAt matches every window;
+// otherwise only those in force at that instant.
+type FreezeQuery struct {
+	ActiveAt time.Time
+}
@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestFreezeWindow_Covers(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	window := domain.FreezeWindow{
+		StartsAt: start, EndsAt: start.Add(96 * time.Hour),
+		Projects: []string{"checkout"}, Environments: []string{"production"}, Tags: []string{"payments"},
+	}
+
+	assert.False(t, window.ActiveAt(start.Add(-time.Second)))
+	assert.True(t, window.ActiveAt(start))
+	assert.False(t, window.ActiveAt(start.Add(96*time.Hour)), "the end is exclusive")
+
+	tests := []struct {
+		name    string
+		project string
+		env     string
+		tags    []string
+		want    bool
+	}{
+		{name: "in scope", project: "checkout", env: "production", tags: []string{"web", "payments"}, want: true},
+		{name: "other project", project: "search", env: "production", tags: []string{"payments"}, want: false},
+		{name: "other environment", project: "checkout", env: "staging", tags: []string{"payments"}, want: false},
+		{name: "untagged flag", project: "checkout", env: "production", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, window.Covers(tt.project, tt.env) && window.CoversTags(tt.tags))
+		})
+	}
+
+	everything := domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour)}
+	assert.True(t, everything.Covers("search", "staging"))
+	assert.True(t, everything.CoversTags(nil))
+}
+
+func TestValidateFreezeWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		window  domain.FreezeWindow
+		wantErr bool
+	}{
+		{name: "valid", window: domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour), Projects: []string{"checkout"}, Tags: []string{"payments"}}},
+		{name: "no end", window: domain.FreezeWindow{StartsAt: start}, wantErr: true},
+		{name: "inverted", window: domain.FreezeWindow{StartsAt: start, EndsAt: start}, wantErr: true},
+		{name: "long reason", window: domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour), Reason: strings.Repeat("r", domain.MaxFreezeReason+1)}, wantErr: true},
+		{name: "invalid environment", window: domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour), Environments: []string{"Prod"}}, wantErr: true},
+		{name: "invalid tag", window: domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(time.Hour), Tags: []string{""}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateFreezeWindow(tt.window)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidFreeze)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// FlagVersion is the state a flag had in one environment at one version, as
+// recorded in its history. A rollback restores its enabled state, value, rules
+// and targets as a new version.
+type FlagVersion struct {
+	Project  string
+	FlagName string
+	// Environment is the environment whose state the version records. In an
+	// environment that inherits the flag it can be an ancestor.
+	Environment string
+	Version     int64
+	Disabled    bool
+	Value       FlagValue
+	Rules       []Rule
+	Targets     map[string]FlagValue
+	RecordedAt  time.Time
+}
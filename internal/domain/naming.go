@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingPolicy is the set of rules a flag name must follow. Start from
+// DefaultNamingPolicy and adjust it; the zero value accepts no name.
+type NamingPolicy struct {
+	// Charset holds every character a name may contain other than its
+	// separators.
+	Charset string
+	// Separators holds the characters joining the parts of a name, such as the
+	// dots in team.area.flag. A name must not end with one.
+	Separators string
+	// MaxLength bounds the length of a name in bytes.
+	MaxLength int
+	// Prefixes lists, per project, the prefixes a flag name must start with
+	// one of. Projects without an entry accept any name.
+	Prefixes map[string][]string
+	// Reserved lists names no flag may take, in any project.
+	Reserved []string
+}
+
+// DefaultNamingPolicy returns the built-in rules: lowercase letters, digits
+// and hyphens, at most 63 characters.
+func DefaultNamingPolicy() NamingPolicy {
+	return NamingPolicy{
+		Charset:    "abcdefghijklmnopqrstuvwxyz0123456789",
+		Separators: "-",
+		MaxLength:  63,
+	}
+}
+
+// ValidateName checks name against the policy for a flag in project. A name
+// must start with a letter from Charset and contain only characters from
+// Charset and Separators.
+func (p NamingPolicy) ValidateName(project, name string) error {
+	if name == "" {
+		return fmt.Errorf("name must not be empty: %w", ErrInvalidName)
+	}
+	if len(name) > p.MaxLength {
+		return fmt.Errorf("name must not exceed %d characters: %w", p.MaxLength, ErrInvalidName)
+	}
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(first) || !strings.ContainsRune(p.Charset, first) {
+		return fmt.Errorf("name must start with a letter from %q: %w", p.Charset, ErrInvalidName)
+	}
+	if last, _ := utf8.DecodeLastRuneInString(name); strings.ContainsRune(p.Separators, last) {
+		return fmt.Errorf("name must not end with a separator: %w", ErrInvalidName)
+	}
+	for _, ch := range name {
+		if !strings.ContainsRune(p.Charset, ch) && !strings.ContainsRune(p.Separators, ch) {
+			return fmt.Errorf("name must contain only %q and separators %q: %w", p.Charset, p.Separators, ErrInvalidName)
+		}
+	}
+	if slices.Contains(p.Reserved, name) {
+		return fmt.Errorf("name %q is reserved: %w", name, ErrInvalidName)
+	}
+	if prefixes := p.Prefixes[project]; len(prefixes) > 0 &&
+		!slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+		return fmt.Errorf("name in project %q must start with one of %q: %w", project, prefixes, ErrInvalidName)
+	}
+	return nil
+}
+
+// ValidateNamingPolicy checks that a configured policy can accept names and
+// only admits characters that are safe unescaped in URL paths and cache keys:
+// ASCII letters, digits and "-._~".
+func ValidateNamingPolicy(p NamingPolicy) error {
+	if p.MaxLength <= 0 {
+		return fmt.Errorf("max length must be positive, got %d", p.MaxLength)
+	}
+	if !strings.ContainsFunc(p.Charset, unicode.IsLetter) {
+		return fmt.Errorf("charset %q must contain a letter to start names with", p.Charset)
+	}
+	for _, ch := range p.Charset + p.Separators {
+		if !isUnreserved(ch) {
+			return fmt.Errorf("character %q is not allowed in names", ch)
+		}
+	}
+	if strings.ContainsAny(p.Charset, p.Separators) {
+		return fmt.Errorf("separators %q overlap the charset %q", p.Separators, p.Charset)
+	}
+	return nil
+}
+
+// isUnreserved reports whether ch is an unreserved URI character (RFC 3986).
+func isUnreserved(ch rune) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || strings.ContainsRune("-._~", ch)
+}
@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestNamingPolicy_ValidateName(t *testing.T) {
+	t.Parallel()
+
+	dotted := domain.DefaultNamingPolicy()
+	dotted.Charset += "_"
+	dotted.Separators = ".-"
+	dotted.MaxLength = 20
+	dotted.Prefixes = map[string][]string{"checkout": {"checkout.", "payments."}}
+	dotted.Reserved = []string{"all"}
+
+	tests := []struct {
+		name    string
+		policy  domain.NamingPolicy
+		project string
+		input   string
+		wantErr bool
+	}{
+		{name: "default accepts hyphens", policy: domain.DefaultNamingPolicy(), input: "new-checkout"},
+		{name: "default rejects dots", policy: domain.DefaultNamingPolicy(), input: "team.area.flag", wantErr: true},
+		{name: "dotted convention", policy: dotted, input: "team.area.flag"},
+		{name: "extra charset", policy: dotted, input: "team.area_flag"},
+		{name: "trailing separator", policy: dotted, input: "team.area.", wantErr: true},
+		{name: "leading separator", policy: dotted, input: ".team", wantErr: true},
+		{name: "over max length", policy: dotted, input: "team.area.long-flag-name", wantErr: true},
+		{name: "reserved", policy: dotted, input: "all", wantErr: true},
+		{name: "required prefix", policy: dotted, project: "checkout", input: "payments.retry"},
+		{name: "missing prefix", policy: dotted, project: "checkout", input: "search.ranking", wantErr: true},
+		{name: "prefix only binds its project", policy: dotted, project: "search", input: "search.ranking"},
+		{name: "non-ASCII", policy: dotted, input: "café", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.policy.ValidateName(tt.project, tt.input)
+			if tt.wantErr {
+				require.ErrorIs(t, err, domain.ErrInvalidName)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateNamingPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		modify  func(*domain.NamingPolicy)
+		wantErr bool
+	}{
+		{name: "default", modify: func(*domain.NamingPolicy) {}},
+		{name: "dots and underscores", modify: func(p *domain.NamingPolicy) { p.Charset += "_"; p.Separators = "." }},
+		{name: "zero max length", modify: func(p *domain.NamingPolicy) { p.MaxLength = 0 }, wantErr: true},
+		{name: "no letters", modify: func(p *domain.NamingPolicy) { p.Charset = "0123456789" }, wantErr: true},
+		{name: "slash separator", modify: func(p *domain.NamingPolicy) { p.Separators = "/" }, wantErr: true},
+		{name: "colon in charset", modify: func(p *domain.NamingPolicy) { p.Charset += ":" }, wantErr: true},
+		{name: "separator in charset", modify: func(p *domain.NamingPolicy) { p.Charset += "-" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policy := domain.DefaultNamingPolicy()
+			tt.modify(&policy)
+			err := domain.ValidateNamingPolicy(policy)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// FlagEvent is one committed change to a flag in one environment. Events are
+// written to the outbox in the transaction of the change and published from
+// there in ID order. Key identifies the event to its consumers: it stays the
+// same however often the event is published. Version is the flag's version
+// in the environment after the change.
+type FlagEvent struct {
+	ID          int64
+	Key         string
+	Action      AuditAction
+	Project     string
+	Environment string
+	FlagName    string
+	Version     int64
+	Actor       string
+	RequestID   string
+	OccurredAt  time.Time
+}
+
+// OutboxCursor is how far through the outbox the events have been published
+// to a sink. Owner is the relay holding the sink until LeasedUntil; no other
+// relay publishes to the sink in that time, so events reach it in order.
+type OutboxCursor struct {
+	Sink        string
+	Owner       string
+	Position    int64
+	LeasedUntil time.Time
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// ChangeKind describes what happened to a rule or target in a FlagDiff.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+	// ChangeMoved marks a rule that is unchanged but evaluated at a different
+	// position, which can change the value served.
+	ChangeMoved ChangeKind = "moved"
+)
+
+// ValueChange is a flag value before and after a promotion.
+type ValueChange struct {
+	From FlagValue
+	To   FlagValue
+}
+
+// EnabledChange is a flag's enabled state before and after a promotion.
+type EnabledChange struct {
+	From bool
+	To   bool
+}
+
+type RuleChange struct {
+	ID   string
+	Kind ChangeKind
+}
+
+type TargetChange struct {
+	Key  string
+	Kind ChangeKind
+}
+
+// FlagDiff is what promoting a flag would change in the target environment.
+// Value and Enabled are nil when unchanged.
+type FlagDiff struct {
+	Name    string
+	Value   *ValueChange
+	Enabled *EnabledChange
+	Rules   []RuleChange
+	Targets []TargetChange
+}
+
+// Empty reports whether the promotion would leave the flag as it is.
+func (d FlagDiff) Empty() bool {
+	return d.Value == nil && d.Enabled == nil && len(d.Rules) == 0 && len(d.Targets) == 0
+}
+
+// Promotion records flag configuration copied from one environment to another.
+// Diffs holds only the flags that changed.
+type Promotion struct {
+	ID         int64
+	Project    string
+	Source     string
+	Target     string
+	Diffs      []FlagDiff
+	PromotedAt time.Time
+}
+
+// DiffFlag compares the flag's state in the target environment, current, with
+// the state promotion would give it, promoted. Only the value, enabled state,
+// rules and targets are promoted; running experiments stay where they are.
+// Rule changes are listed in the promoted order followed by removals; target
+// changes are ordered by key.
+func DiffFlag(current, promoted Flag) FlagDiff {
+	diff := FlagDiff{Name: promoted.Name}
+	if !valueEqual(current.Value, promoted.Value) {
+		diff.Value = &ValueChange{From: current.Value, To: promoted.Value}
+	}
+	if current.Disabled != promoted.Disabled {
+		diff.Enabled = &EnabledChange{From: !current.Disabled, To: !promoted.Disabled}
+	}
+	diff.Rules = diffRules(current.Rules, promoted.Rules)
+	diff.Targets = diffTargets(current.Targets, promoted.Targets)
+	return diff
+}
+
+// Promote returns current with the promoted fields of source.
+func Promote(current, source Flag) Flag {
+	current.Value = source.Value
+	current.Disabled = source.Disabled
+	current.Rules = source.Rules
+	current.Targets = source.Targets
+	return current
+}
+
+func diffRules(current, promoted []Rule) []RuleChange {
+	index := make(map[string]int, len(current))
+	for i, r := range current {
+		index[r.ID] = i
+	}
+	kept := make(map[string]bool, len(promoted))
+	var changes []RuleChange
+	for i, r := range promoted {
+		kept[r.ID] = true
+		j, ok := index[r.ID]
+		switch {
+		case !ok:
+			changes = append(changes, RuleChange{ID: r.ID, Kind: ChangeAdded})
+		case !ruleEqual(current[j], r):
+			changes = append(changes, RuleChange{ID: r.ID, Kind: ChangeModified})
+		case i >= len(current) || current[i].ID != r.ID:
+			changes = append(changes, RuleChange{ID: r.ID, Kind: ChangeMoved})
+		}
+	}
+	for _, r := range current {
+		if !kept[r.ID] {
+			changes = append(changes, RuleChange{ID: r.ID, Kind: ChangeRemoved})
+		}
+	}
+	return changes
+}
+
+func diffTargets(current, promoted map[string]FlagValue) []TargetChange {
+	var changes []TargetChange
+	for key, v := range promoted {
+		old, ok := current[key]
+		switch {
+		case !ok:
+			changes = append(changes, TargetChange{Key: key, Kind: ChangeAdded})
+		case !valueEqual(old, v):
+			changes = append(changes, TargetChange{Key: key, Kind: ChangeModified})
+		}
+	}
+	for key := range current {
+		if _, ok := promoted[key]; !ok {
+			changes = append(changes, TargetChange{Key: key, Kind: ChangeRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func ruleEqual(a, b Rule) bool {
+	return a.ID == b.ID && a.Expression == b.Expression && valueEqual(a.Value, b.Value) &&
+		slices.EqualFunc(a.Clauses, b.Clauses, func(x, y Clause) bool {
+			return x.Attribute == y.Attribute && x.Operator == y.Operator &&
+				x.Timezone == y.Timezone && slices.Equal(x.Values, y.Values)
+		})
+}
+
+func valueEqual(a, b FlagValue) bool {
+	return ptrEqual(a.Bool, b.Bool) && ptrEqual(a.Numeric, b.Numeric)
+}
+
+func ptrEqual[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
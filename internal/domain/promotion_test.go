@@ -0,0 +1,117 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestDiffFlag(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	rule := func(id, plan string) domain.Rule {
+		return domain.Rule{
+			ID:      id,
+			Clauses: []domain.Clause{{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{plan}}},
+			Value:   domain.FlagValue{Bool: &on},
+		}
+	}
+	base := domain.Flag{
+		Name:    "new-checkout",
+		Value:   domain.FlagValue{Bool: &off},
+		Rules:   []domain.Rule{rule("pro", "pro"), rule("beta", "beta")},
+		Targets: map[string]domain.FlagValue{"user-1": {Bool: &on}, "user-2": {Bool: &on}},
+	}
+
+	tests := []struct {
+		name     string
+		promoted func(domain.Flag) domain.Flag
+		want     domain.FlagDiff
+	}{
+		{
+			name:     "identical",
+			promoted: func(f domain.Flag) domain.Flag { return f },
+			want:     domain.FlagDiff{Name: "new-checkout"},
+		},
+		{
+			name: "value and enabled state",
+			promoted: func(f domain.Flag) domain.Flag {
+				f.Value = domain.FlagValue{Bool: &on}
+				f.Disabled = true
+				return f
+			},
+			want: domain.FlagDiff{
+				Name:    "new-checkout",
+				Value:   &domain.ValueChange{From: domain.FlagValue{Bool: &off}, To: domain.FlagValue{Bool: &on}},
+				Enabled: &domain.EnabledChange{From: true, To: false},
+			},
+		},
+		{
+			name: "rules",
+			promoted: func(f domain.Flag) domain.Flag {
+				f.Rules = []domain.Rule{rule("new", "team"), rule("pro", "enterprise")}
+				return f
+			},
+			want: domain.FlagDiff{Name: "new-checkout", Rules: []domain.RuleChange{
+				{ID: "new", Kind: domain.ChangeAdded},
+				{ID: "pro", Kind: domain.ChangeModified},
+				{ID: "beta", Kind: domain.ChangeRemoved},
+			}},
+		},
+		{
+			name: "reordered rules",
+			promoted: func(f domain.Flag) domain.Flag {
+				f.Rules = []domain.Rule{rule("beta", "beta"), rule("pro", "pro")}
+				return f
+			},
+			want: domain.FlagDiff{Name: "new-checkout", Rules: []domain.RuleChange{
+				{ID: "beta", Kind: domain.ChangeMoved},
+				{ID: "pro", Kind: domain.ChangeMoved},
+			}},
+		},
+		{
+			name: "targets",
+			promoted: func(f domain.Flag) domain.Flag {
+				f.Targets = map[string]domain.FlagValue{"user-2": {Bool: &off}, "user-3": {Bool: &on}}
+				return f
+			},
+			want: domain.FlagDiff{Name: "new-checkout", Targets: []domain.TargetChange{
+				{Key: "user-1", Kind: domain.ChangeRemoved},
+				{Key: "user-2", Kind: domain.ChangeModified},
+				{Key: "user-3", Kind: domain.ChangeAdded},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := domain.DiffFlag(base, tt.promoted(base))
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Value == nil && tt.want.Enabled == nil && tt.want.Rules == nil && tt.want.Targets == nil, got.Empty())
+		})
+	}
+}
+
+func TestPromote(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	exp := &domain.Experiment{Key: "checkout-test"}
+	current := domain.Flag{Name: "new-checkout", Environment: "production", Value: domain.FlagValue{Bool: &off}, Experiment: exp, Version: 4}
+	source := domain.Flag{Name: "new-checkout", Environment: "staging", Value: domain.FlagValue{Bool: &on}, Disabled: true, Version: 9}
+
+	got := domain.Promote(current, source)
+
+	assert.Equal(t, "production", got.Environment)
+	assert.True(t, *got.Value.Bool)
+	assert.True(t, got.Disabled)
+	assert.Same(t, exp, got.Experiment, "experiments are not promoted")
+	assert.Equal(t, int64(4), got.Version)
+}
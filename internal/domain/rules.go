@@ -0,0 +1,323 @@
+package domain
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/expr"
+)
+
+// Operator is the comparison a Clause applies.
+type Operator string
+
+const (
+	// OperatorIn matches when the attribute equals any of the clause values.
+	OperatorIn Operator = "in"
+	// OperatorNotIn matches when the attribute equals none of the clause values.
+	OperatorNotIn Operator = "not-in"
+	// OperatorTimeBetween matches when the evaluation time falls in the absolute
+	// range [Values[0], Values[1]), both RFC 3339 timestamps. Either bound may be
+	// empty to leave that side of the range open.
+	OperatorTimeBetween Operator = "time-between"
+	// OperatorDayOfWeekIn matches when the evaluation time, in the clause's
+	// timezone, falls on one of the listed days ("mon" through "sun").
+	OperatorDayOfWeekIn Operator = "day-of-week-in"
+	// OperatorTimeOfDayBetween matches when the evaluation time, in the clause's
+	// timezone, falls in [Values[0], Values[1]), both "HH:MM". A start later than
+	// the end describes a window that wraps past midnight.
+	OperatorTimeOfDayBetween Operator = "time-of-day-between"
+	// OperatorIPInCIDR matches when the attribute is an IPv4 or IPv6 address
+	// inside any of the listed CIDR ranges.
+	OperatorIPInCIDR Operator = "ip-in-cidr"
+	// OperatorIPNotInCIDR matches when the attribute is an IPv4 or IPv6 address
+	// outside all of the listed CIDR ranges. A missing or malformed address never
+	// matches, so an unknown client is not mistaken for an outside one.
+	OperatorIPNotInCIDR Operator = "ip-not-in-cidr"
+)
+
+const (
+	// AttributeTargetingKey addresses EvaluationContext.TargetingKey in a Clause.
+	AttributeTargetingKey = "targeting_key"
+	// AttributeClientIP is the attribute inbound adapters populate with the
+	// address of the calling client.
+	AttributeClientIP = "client_ip"
+)
+
+// Clause is a single condition of a Rule.
+type Clause struct {
+	// Attribute is the context attribute the clause tests. Time operators
+	// test the evaluation time instead and ignore it.
+	Attribute string
+	Operator  Operator
+	Values    []string
+	// Timezone is the IANA zone the day-of-week and time-of-day operators are
+	// evaluated in. Empty means UTC.
+	Timezone string
+}
+
+// Rule serves Value when all of its clauses match, or when its Expression
+// evaluates to true. A rule has either clauses or an expression, never both.
+type Rule struct {
+	ID      string
+	Clauses []Clause
+	// Expression is a boolean expression over the context attributes, for
+	// example `plan == "pro" && seats > 10`. See package expr for the syntax.
+	Expression string
+	Value      FlagValue
+
+	// compiled holds the clauses or expression parsed by CompileRules.
+	compiled *compiledRule
+}
+
+// compiledRule is a rule's clauses with their values parsed for evaluation,
+// or its compiled expression. program is nil when the expression does not
+// compile.
+type compiledRule struct {
+	clauses []compiledClause
+	program *expr.Program
+}
+
+// compiledClause holds the parsed form of a clause's values for its operator.
+// A clause whose values or timezone do not parse is invalid and never matches.
+type compiledClause struct {
+	Clause
+	invalid  bool
+	prefixes []netip.Prefix
+	// start and end bound a time-between range; either may be zero.
+	start, end time.Time
+	days       [7]bool
+	// startMinute and endMinute bound a time-of-day window in minutes since
+	// midnight.
+	startMinute, endMinute int
+	loc                    *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// locations caches loaded timezones; time.LoadLocation reads the zone database
+// on every call.
+var locations sync.Map
+
+// CompileRules parses the clause values and compiles the expression of each
+// rule in place, so evaluating the rules does not parse them again. Adapters
+// call it on rules they load. A clause or expression that does not parse never
+// matches, and a rule that was not compiled is parsed on every evaluation.
+func CompileRules(rules []Rule) {
+	for i := range rules {
+		rules[i].compiled = compileRule(rules[i])
+	}
+}
+
+func compileRule(r Rule) *compiledRule {
+	c := &compiledRule{clauses: make([]compiledClause, len(r.Clauses))}
+	if r.Expression != "" {
+		c.program, _ = compileExpression(r.Expression)
+	}
+	for i, clause := range r.Clauses {
+		compiled, err := compileClause(clause)
+		if err != nil {
+			compiled = compiledClause{Clause: clause, invalid: true}
+		}
+		c.clauses[i] = compiled
+	}
+	return c
+}
+
+// compileClause parses the clause's values for its operator. Every CIDR range
+// must parse; validateClause reports the first that does not.
+func compileClause(clause Clause) (compiledClause, error) {
+	c := compiledClause{Clause: clause}
+	var err error
+	switch clause.Operator {
+	case OperatorIPInCIDR, OperatorIPNotInCIDR:
+		c.prefixes = make([]netip.Prefix, len(clause.Values))
+		for i, v := range clause.Values {
+			if c.prefixes[i], err = parsePrefix(v); err != nil {
+				return c, err
+			}
+		}
+	case OperatorTimeBetween:
+		c.start, c.end, err = parseTimeRange(clause.Values)
+	case OperatorDayOfWeekIn:
+		for _, v := range clause.Values {
+			day, ok := weekdays[v]
+			if !ok {
+				return c, fmt.Errorf("unknown day %q", v)
+			}
+			c.days[day] = true
+		}
+		c.loc, err = loadLocation(clause.Timezone)
+	case OperatorTimeOfDayBetween:
+		if c.startMinute, c.endMinute, err = parseTimeOfDayRange(clause.Values); err != nil {
+			return c, err
+		}
+		c.loc, err = loadLocation(clause.Timezone)
+	}
+	return c, err
+}
+
+func (r Rule) matches(evalCtx EvaluationContext, now time.Time) bool {
+	compiled := r.compiled
+	if compiled == nil {
+		compiled = compileRule(r)
+	}
+	if r.Expression != "" {
+		return matchExpression(compiled.program, evalCtx)
+	}
+	for _, c := range compiled.clauses {
+		if !c.matches(evalCtx, now) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c compiledClause) matches(evalCtx EvaluationContext, now time.Time) bool {
+	if c.invalid {
+		return false
+	}
+	switch c.Operator {
+	case OperatorIn:
+		attr, ok := attributeString(evalCtx, c.Attribute)
+		return ok && contains(c.Values, attr)
+	case OperatorNotIn:
+		attr, ok := attributeString(evalCtx, c.Attribute)
+		return !ok || !contains(c.Values, attr)
+	case OperatorTimeBetween:
+		if !c.start.IsZero() && now.Before(c.start) {
+			return false
+		}
+		return c.end.IsZero() || now.Before(c.end)
+	case OperatorDayOfWeekIn:
+		return c.days[now.In(c.loc).Weekday()]
+	case OperatorTimeOfDayBetween:
+		local := now.In(c.loc)
+		minute := local.Hour()*60 + local.Minute()
+		if c.startMinute <= c.endMinute {
+			return minute >= c.startMinute && minute < c.endMinute
+		}
+		return minute >= c.startMinute || minute < c.endMinute
+	case OperatorIPInCIDR, OperatorIPNotInCIDR:
+		attr, ok := attributeString(evalCtx, c.Attribute)
+		if !ok {
+			return false
+		}
+		addr, err := netip.ParseAddr(attr)
+		if err != nil {
+			return false
+		}
+		return matchPrefixes(c.prefixes, addr.Unmap()) == (c.Operator == OperatorIPInCIDR)
+	}
+	return false
+}
+
+func attributeString(evalCtx EvaluationContext, attribute string) (string, bool) {
+	if attribute == AttributeTargetingKey {
+		return evalCtx.TargetingKey, evalCtx.TargetingKey != ""
+	}
+	v, ok := evalCtx.Attributes[attribute]
+	if !ok || v == nil {
+		return "", false
+	}
+	switch typed := v.(type) {
+	case string:
+		return typed, true
+	case bool:
+		return strconv.FormatBool(typed), true
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64), true
+	}
+	return fmt.Sprint(v), true
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPrefixes(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix parses a CIDR range. A bare address is read as a single-host range.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither a CIDR range nor an IP address", s)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func parseTimeRange(values []string) (time.Time, time.Time, error) {
+	if len(values) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected a start and an end, got %d values", len(values))
+	}
+	var bounds [2]time.Time
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		bounds[i] = t
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// parseTimeOfDayRange returns the start and end of a time-of-day window as
+// minutes since midnight.
+func parseTimeOfDayRange(values []string) (int, int, error) {
+	if len(values) != 2 {
+		return 0, 0, fmt.Errorf("expected a start and an end, got %d values", len(values))
+	}
+	var minutes [2]int
+	for i, v := range values {
+		t, err := time.Parse("15:04", v)
+		if err != nil {
+			return 0, 0, err
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return minutes[0], minutes[1], nil
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -16,11 +16,71 @@ type FlagValue struct {
 	Numeric *float64
 }
 
+// Flag is a flag definition as configured in one environment. Name, Type,
+// Description and Variants are shared by every environment; the remaining
+// fields are the environment's own. Names are unique within the Project.
 type Flag struct {
+	Project     string
 	Name        string
 	Type        FlagType
 	Description string
-	Value       FlagValue
+	// Tags label the flag for scoping changes to groups of flags, such as
+	// freeze windows. Like the description they are shared by every
+	// environment.
+	Tags []string
+	// Environment is the environment the per-environment fields belong to.
+	Environment string
+	// SourceEnvironment is the environment the per-environment fields were
+	// resolved from: Environment itself when it overrides the flag, otherwise
+	// the nearest ancestor that does.
+	SourceEnvironment string
+	// Disabled switches the flag off in its environment: it serves Value and
+	// ignores targets, rules and experiments.
+	Disabled bool
+	Value    FlagValue
+	// Rules are evaluated in order; the first rule whose clauses all match
+	// serves its value. When none match, Value is served.
+	Rules []Rule
+	// Targets maps individual targeting keys to the value forced for them.
+	// An individual target takes precedence over every rule.
+	Targets map[string]FlagValue
+	// Variants are the named values a multivariate flag can serve through an
+	// experiment.
+	Variants []Variant
+	// Experiment is the running experiment on the flag, if any. Contexts that
+	// match no target or rule are split between its variants.
+	Experiment *Experiment
+	// Version starts at 1 when the flag is created and is incremented on every
+	// change to the flag's value, rules, targets, enabled state or running
+	// experiment in its environment.
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DefaultProject owns the flags of requests that name no project. It always
+// exists.
+const DefaultProject = "default"
+
+// Project is a namespace owning flags, so teams sharing an instance can pick
+// flag names independently. Environments are shared by every project.
+type Project struct {
+	Key         string
+	Description string
+	CreatedAt   time.Time
+}
+
+// DefaultEnvironment is the environment used when a request names none. It
+// always exists.
+const DefaultEnvironment = "production"
+
+// Environment is a deployment stage such as dev, staging or production. Every
+// flag has its own value, rules, targets and enabled state in each environment.
+// An environment with a Parent holds only the flags it overrides and inherits
+// the state of every other flag from its parent.
+type Environment struct {
+	Key         string
+	Description string
+	Parent      string
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
 }
@@ -1,21 +1,15 @@
 package domain
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
+// ValidateFlagName checks name against the default naming policy. Keys of
+// environments, projects, layers and experiments always follow it, whatever
+// policy flag names are held to.
 func ValidateFlagName(name string) error {
-	if err := validateNotEmpty(name); err != nil {
-		return err
-	}
-	if err := validateMaxLength(name); err != nil {
-		return err
-	}
-	if err := validateStartsWithLetter(name); err != nil {
-		return err
-	}
-	if err := validateNoLeadingTrailingHyphen(name); err != nil {
-		return err
-	}
-	return validateAllowedChars(name)
+	return DefaultNamingPolicy().ValidateName("", name)
 }
 
 func ValidateFlagValue(flagType FlagType, flagValue FlagValue) error {
@@ -28,39 +22,271 @@ func ValidateFlagValue(flagType FlagType, flagValue FlagValue) error {
 	return nil
 }
 
-func validateNotEmpty(name string) error {
-	if len(name) == 0 {
-		return fmt.Errorf("name must not be empty: %w", ErrInvalidName)
+// ValidateRules checks that every rule is well formed and serves a value of
+// flagType. Rule IDs must be unique within the flag.
+func ValidateRules(flagType FlagType, rules []Rule) error {
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule id must not be empty: %w", ErrInvalidRule)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("duplicate rule id %q: %w", rule.ID, ErrInvalidRule)
+		}
+		seen[rule.ID] = true
+
+		if rule.Expression != "" {
+			if len(rule.Clauses) > 0 {
+				return fmt.Errorf("rule %q must not have both clauses and an expression: %w", rule.ID, ErrInvalidRule)
+			}
+			if err := validateExpression(rule.Expression); err != nil {
+				return fmt.Errorf("rule %q: %w", rule.ID, err)
+			}
+		} else if len(rule.Clauses) == 0 {
+			return fmt.Errorf("rule %q must have at least one clause or an expression: %w", rule.ID, ErrInvalidRule)
+		}
+		for _, clause := range rule.Clauses {
+			if err := validateClause(clause); err != nil {
+				return fmt.Errorf("rule %q: %w", rule.ID, err)
+			}
+		}
+		if err := ValidateFlagValue(flagType, rule.Value); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.ID, err)
+		}
 	}
 	return nil
 }
 
-func validateMaxLength(name string) error {
-	if len(name) > 63 {
-		return fmt.Errorf("name must not exceed 63 characters: %w", ErrInvalidName)
+// maxTargetKeyLength bounds a single targeting key so one request cannot bloat
+// a flag's target index.
+const maxTargetKeyLength = 256
+
+// ValidateTargetKeys checks a batch of targeting keys for an individual target
+// change: at least one key, none empty or longer than maxTargetKeyLength.
+func ValidateTargetKeys(keys []string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("at least one targeting key is required: %w", ErrInvalidTarget)
+	}
+	for _, key := range keys {
+		if key == "" {
+			return fmt.Errorf("targeting key must not be empty: %w", ErrInvalidTarget)
+		}
+		if len(key) > maxTargetKeyLength {
+			return fmt.Errorf("targeting key exceeds %d characters: %w", maxTargetKeyLength, ErrInvalidTarget)
+		}
 	}
 	return nil
 }
 
-func validateStartsWithLetter(name string) error {
-	if name[0] < 'a' || name[0] > 'z' {
-		return fmt.Errorf("name must start with a lowercase letter: %w", ErrInvalidName)
+// MaxFlagTags bounds the tags one flag can carry.
+const MaxFlagTags = 20
+
+// ValidateTags checks that there are at most MaxFlagTags tags, each unique
+// and following the default naming rules.
+func ValidateTags(tags []string) error {
+	if len(tags) > MaxFlagTags {
+		return fmt.Errorf("at most %d tags are allowed: %w", MaxFlagTags, ErrInvalidTag)
+	}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if ValidateFlagName(tag) != nil {
+			return fmt.Errorf("tag %q is not a valid name: %w", tag, ErrInvalidTag)
+		}
+		if seen[tag] {
+			return fmt.Errorf("duplicate tag %q: %w", tag, ErrInvalidTag)
+		}
+		seen[tag] = true
 	}
 	return nil
 }
 
-func validateNoLeadingTrailingHyphen(name string) error {
-	if name[0] == '-' || name[len(name)-1] == '-' {
-		return fmt.Errorf("name must not start or end with a hyphen: %w", ErrInvalidName)
+// ValidateVariants checks that variant keys are non-empty and unique and that
+// every variant holds a value of flagType.
+func ValidateVariants(flagType FlagType, variants []Variant) error {
+	seen := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if v.Key == "" {
+			return fmt.Errorf("variant key must not be empty: %w", ErrInvalidVariant)
+		}
+		if seen[v.Key] {
+			return fmt.Errorf("duplicate variant key %q: %w", v.Key, ErrInvalidVariant)
+		}
+		seen[v.Key] = true
+		if err := ValidateFlagValue(flagType, v.Value); err != nil {
+			return fmt.Errorf("variant %q: %w", v.Key, err)
+		}
 	}
 	return nil
 }
 
-func validateAllowedChars(name string) error {
-	for _, ch := range name {
-		if !((ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') || ch == '-') {
-			return fmt.Errorf("name must contain only lowercase letters, digits, and hyphens: %w", ErrInvalidName)
+// ValidateExperiment checks an experiment against the flag it runs on: the key
+// follows the flag naming rules, the allocation is a percentage, at least
+// two distinct variants of the flag carry a positive weight, and any bandit
+// configuration is complete.
+func ValidateExperiment(exp Experiment, flag Flag) error {
+	if err := ValidateFlagName(exp.Key); err != nil {
+		return fmt.Errorf("experiment key %q is not a valid name: %w", exp.Key, ErrInvalidExperiment)
+	}
+	if exp.Allocation < 1 || exp.Allocation > 100 {
+		return fmt.Errorf("allocation must be between 1 and 100: %w", ErrInvalidExperiment)
+	}
+	if exp.Layer != "" && ValidateFlagName(exp.Layer) != nil {
+		return fmt.Errorf("layer key %q is not a valid name: %w", exp.Layer, ErrInvalidExperiment)
+	}
+	if len(exp.Variants) < 2 {
+		return fmt.Errorf("experiment needs at least two variants: %w", ErrInvalidExperiment)
+	}
+	seen := make(map[string]bool, len(exp.Variants))
+	for _, v := range exp.Variants {
+		if _, ok := flag.variantValue(v.Variant); !ok {
+			return fmt.Errorf("flag %q has no variant %q: %w", flag.Name, v.Variant, ErrInvalidExperiment)
 		}
+		if seen[v.Variant] {
+			return fmt.Errorf("duplicate variant %q: %w", v.Variant, ErrInvalidExperiment)
+		}
+		seen[v.Variant] = true
+		if v.Weight < 1 {
+			return fmt.Errorf("variant %q weight must be positive: %w", v.Variant, ErrInvalidExperiment)
+		}
+	}
+	if exp.Bandit != nil {
+		return validateBandit(*exp.Bandit, len(exp.Variants))
+	}
+	return nil
+}
+
+func validateBandit(cfg BanditConfig, variants int) error {
+	switch cfg.Algorithm {
+	case BanditThompson:
+	case BanditEpsilonGreedy:
+		if !(cfg.Epsilon > 0 && cfg.Epsilon <= 1) {
+			return fmt.Errorf("epsilon must be in (0, 1]: %w", ErrInvalidExperiment)
+		}
+	default:
+		return fmt.Errorf("unknown bandit algorithm %q: %w", cfg.Algorithm, ErrInvalidExperiment)
+	}
+	if cfg.Metric == "" || len(cfg.Metric) > 63 {
+		return fmt.Errorf("bandit metric name must be 1 to 63 characters: %w", ErrInvalidExperiment)
+	}
+	if variants > 0 && !(cfg.MinExploration >= 0 && cfg.MinExploration <= 1/float64(variants)) {
+		return fmt.Errorf("minimum exploration must be between 0 and 1/%d: %w", variants, ErrInvalidExperiment)
+	}
+	return nil
+}
+
+// ValidateEnvironment checks that the environment key follows the flag naming
+// rules, so it can appear in paths and cache keys unescaped.
+func ValidateEnvironment(env Environment) error {
+	if err := ValidateFlagName(env.Key); err != nil {
+		return fmt.Errorf("environment key %q is not a valid name: %w", env.Key, ErrInvalidEnvironment)
+	}
+	return nil
+}
+
+// ValidateProject checks that the project key follows the flag naming rules,
+// so it can appear in paths and cache keys unescaped.
+func ValidateProject(project Project) error {
+	if err := ValidateFlagName(project.Key); err != nil {
+		return fmt.Errorf("project key %q is not a valid name: %w", project.Key, ErrInvalidProject)
+	}
+	return nil
+}
+
+// ValidateLayer checks that the layer key follows the flag naming rules.
+func ValidateLayer(layer Layer) error {
+	if err := ValidateFlagName(layer.Key); err != nil {
+		return fmt.Errorf("layer key %q is not a valid name: %w", layer.Key, ErrInvalidLayer)
+	}
+	return nil
+}
+
+// MaxMetricBatch bounds the number of metric events accepted in one request.
+const MaxMetricBatch = 1000
+
+// ValidateMetricEvents checks a batch of metric events: between one and
+// MaxMetricBatch events, each with a metric name of at most 63 characters, a
+// targeting key and a finite value.
+func ValidateMetricEvents(events []MetricEvent) error {
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event is required: %w", ErrInvalidMetric)
+	}
+	if len(events) > MaxMetricBatch {
+		return fmt.Errorf("at most %d events are accepted per batch: %w", MaxMetricBatch, ErrInvalidMetric)
+	}
+	for i, e := range events {
+		if e.Metric == "" || len(e.Metric) > 63 {
+			return fmt.Errorf("event %d: metric name must be 1 to 63 characters: %w", i, ErrInvalidMetric)
+		}
+		if e.TargetingKey == "" {
+			return fmt.Errorf("event %d: targeting key is required: %w", i, ErrInvalidMetric)
+		}
+		if math.IsNaN(e.Value) || math.IsInf(e.Value, 0) {
+			return fmt.Errorf("event %d: value must be finite: %w", i, ErrInvalidMetric)
+		}
+	}
+	return nil
+}
+
+func validateClause(clause Clause) error {
+	switch clause.Operator {
+	case OperatorIn, OperatorNotIn:
+		if clause.Attribute == "" {
+			return fmt.Errorf("operator %q requires an attribute: %w", clause.Operator, ErrInvalidRule)
+		}
+		if len(clause.Values) == 0 {
+			return fmt.Errorf("operator %q requires at least one value: %w", clause.Operator, ErrInvalidRule)
+		}
+	case OperatorIPInCIDR, OperatorIPNotInCIDR:
+		if clause.Attribute == "" {
+			return fmt.Errorf("operator %q requires an attribute: %w", clause.Operator, ErrInvalidRule)
+		}
+		if len(clause.Values) == 0 {
+			return fmt.Errorf("operator %q requires at least one CIDR range: %w", clause.Operator, ErrInvalidRule)
+		}
+		for _, v := range clause.Values {
+			if _, err := parsePrefix(v); err != nil {
+				return fmt.Errorf("%v: %w", err, ErrInvalidRule)
+			}
+		}
+	case OperatorTimeBetween:
+		start, end, err := parseTimeRange(clause.Values)
+		if err != nil {
+			return fmt.Errorf("time range: %v: %w", err, ErrInvalidRule)
+		}
+		if start.IsZero() && end.IsZero() {
+			return fmt.Errorf("time range needs a start or an end: %w", ErrInvalidRule)
+		}
+		if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+			return fmt.Errorf("time range start must be before its end: %w", ErrInvalidRule)
+		}
+	case OperatorDayOfWeekIn:
+		if len(clause.Values) == 0 {
+			return fmt.Errorf("operator %q requires at least one day: %w", clause.Operator, ErrInvalidRule)
+		}
+		for _, v := range clause.Values {
+			if _, ok := weekdays[v]; !ok {
+				return fmt.Errorf("unknown day %q: %w", v, ErrInvalidRule)
+			}
+		}
+		return validateTimezone(clause.Timezone)
+	case OperatorTimeOfDayBetween:
+		start, end, err := parseTimeOfDayRange(clause.Values)
+		if err != nil {
+			return fmt.Errorf("time of day range: %v: %w", err, ErrInvalidRule)
+		}
+		if start == end {
+			return fmt.Errorf("time of day range must not be empty: %w", ErrInvalidRule)
+		}
+		return validateTimezone(clause.Timezone)
+	default:
+		return fmt.Errorf("unknown operator %q: %w", clause.Operator, ErrInvalidRule)
+	}
+	return nil
+}
+
+func validateTimezone(name string) error {
+	if _, err := loadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q: %w", name, ErrInvalidRule)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -117,3 +118,407 @@ func TestValidateFlagValue(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRules(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	numVal := 1.0
+	clause := domain.Clause{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}}
+
+	tests := []struct {
+		name    string
+		rules   []domain.Rule
+		wantErr error
+	}{
+		{
+			name:  "no rules",
+			rules: nil,
+		},
+		{
+			name: "valid attribute and schedule rules",
+			rules: []domain.Rule{
+				{ID: "pro", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{clause}},
+				{ID: "office", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+					{Operator: domain.OperatorDayOfWeekIn, Values: []string{"mon", "fri"}, Timezone: "Europe/Warsaw"},
+					{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"09:00", "17:30"}, Timezone: "Europe/Warsaw"},
+					{Operator: domain.OperatorTimeBetween, Values: []string{"2026-01-01T00:00:00Z", ""}},
+				}},
+			},
+		},
+		{
+			name: "valid cidr ranges",
+			rules: []domain.Rule{{ID: "office", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Attribute: "client_ip", Operator: domain.OperatorIPInCIDR, Values: []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1"}},
+				{Attribute: "client_ip", Operator: domain.OperatorIPNotInCIDR, Values: []string{"10.9.0.0/16"}},
+			}}},
+		},
+		{
+			name: "malformed cidr",
+			rules: []domain.Rule{{ID: "office", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Attribute: "client_ip", Operator: domain.OperatorIPInCIDR, Values: []string{"10.0.0.0/33"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "cidr without attribute",
+			rules: []domain.Rule{{ID: "office", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorIPNotInCIDR, Values: []string{"10.0.0.0/8"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "cidr without ranges",
+			rules: []domain.Rule{{ID: "office", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Attribute: "client_ip", Operator: domain.OperatorIPInCIDR},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:    "empty id",
+			rules:   []domain.Rule{{Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{clause}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "duplicate id",
+			rules: []domain.Rule{
+				{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{clause}},
+				{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{clause}},
+			},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:    "no clauses",
+			rules:   []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:  "valid expression",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Expression: `plan == "pro" && targeting_key.startsWith("user-")`}},
+		},
+		{
+			name:    "expression syntax error",
+			rules:   []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Expression: `plan ==`}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:    "expression type error",
+			rules:   []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Expression: `targeting_key > 10`}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "clauses and expression",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{clause},
+				Expression: `plan == "pro"`}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:    "value of wrong type",
+			rules:   []domain.Rule{{ID: "a", Value: domain.FlagValue{Numeric: &numVal}, Clauses: []domain.Clause{clause}}},
+			wantErr: domain.ErrTypeMismatch,
+		},
+		{
+			name: "unknown operator",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Attribute: "plan", Operator: "like", Values: []string{"pro"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "in without attribute",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorIn, Values: []string{"pro"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "time range start after end",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"2026-02-01T00:00:00Z", "2026-01-01T00:00:00Z"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "time range without bounds",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"", ""}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "malformed timestamp",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeBetween, Values: []string{"yesterday", ""}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "unknown day",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorDayOfWeekIn, Values: []string{"funday"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "unknown timezone",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"09:00", "17:00"}, Timezone: "Mars/Olympus"},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name: "malformed time of day",
+			rules: []domain.Rule{{ID: "a", Value: domain.FlagValue{Bool: &boolVal}, Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"9am", "17:00"}},
+			}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateRules(domain.FlagTypeBoolean, tt.rules)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateTargetKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		keys    []string
+		wantErr error
+	}{
+		{name: "valid keys", keys: []string{"user-1", "device:ab12"}},
+		{name: "no keys", keys: nil, wantErr: domain.ErrInvalidTarget},
+		{name: "empty key", keys: []string{"user-1", ""}, wantErr: domain.ErrInvalidTarget},
+		{name: "key too long", keys: []string{strings.Repeat("k", 257)}, wantErr: domain.ErrInvalidTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateTargetKeys(tt.keys)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tags    []string
+		wantErr error
+	}{
+		{name: "no tags", tags: nil},
+		{name: "valid tags", tags: []string{"checkout", "payments-team"}},
+		{name: "invalid tag", tags: []string{"Checkout Team"}, wantErr: domain.ErrInvalidTag},
+		{name: "duplicate tag", tags: []string{"checkout", "checkout"}, wantErr: domain.ErrInvalidTag},
+		{name: "too many tags", tags: strings.Split(strings.Repeat("t,", domain.MaxFlagTags)+"t", ","), wantErr: domain.ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateTags(tt.tags)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	numVal := 1.0
+
+	tests := []struct {
+		name     string
+		variants []domain.Variant
+		wantErr  error
+	}{
+		{name: "no variants", variants: nil},
+		{name: "valid", variants: []domain.Variant{{Key: "on", Value: domain.FlagValue{Bool: &on}}}},
+		{name: "empty key", variants: []domain.Variant{{Value: domain.FlagValue{Bool: &on}}}, wantErr: domain.ErrInvalidVariant},
+		{
+			name:     "duplicate key",
+			variants: []domain.Variant{{Key: "on", Value: domain.FlagValue{Bool: &on}}, {Key: "on", Value: domain.FlagValue{Bool: &on}}},
+			wantErr:  domain.ErrInvalidVariant,
+		},
+		{name: "wrong type", variants: []domain.Variant{{Key: "on", Value: domain.FlagValue{Numeric: &numVal}}}, wantErr: domain.ErrTypeMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateVariants(domain.FlagTypeBoolean, tt.variants)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateExperiment(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	off := false
+	flag := domain.Flag{
+		Name: "checkout",
+		Type: domain.FlagTypeBoolean,
+		Variants: []domain.Variant{
+			{Key: "control", Value: domain.FlagValue{Bool: &off}},
+			{Key: "treatment", Value: domain.FlagValue{Bool: &on}},
+		},
+	}
+	weights := []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+
+	tests := []struct {
+		name string
+		exp  domain.Experiment
+		ok   bool
+	}{
+		{name: "valid", exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights}, ok: true},
+		{name: "invalid key", exp: domain.Experiment{Key: "Checkout Test", Allocation: 20, Variants: weights}},
+		{name: "zero allocation", exp: domain.Experiment{Key: "checkout-test", Variants: weights}},
+		{name: "allocation over 100", exp: domain.Experiment{Key: "checkout-test", Allocation: 101, Variants: weights}},
+		{name: "valid in layer", exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Layer: "checkout"}, ok: true},
+		{name: "invalid layer key", exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Layer: "Checkout Layer"}},
+		{name: "single variant", exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights[:1]}},
+		{
+			name: "thompson bandit",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: domain.BanditThompson, Metric: "click", MinExploration: 0.1,
+			}},
+			ok: true,
+		},
+		{
+			name: "epsilon-greedy bandit",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: domain.BanditEpsilonGreedy, Metric: "click", Epsilon: 0.1,
+			}},
+			ok: true,
+		},
+		{
+			name: "unknown bandit algorithm",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: "ucb", Metric: "click",
+			}},
+		},
+		{
+			name: "epsilon-greedy without epsilon",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: domain.BanditEpsilonGreedy, Metric: "click",
+			}},
+		},
+		{
+			name: "bandit without metric",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: domain.BanditThompson,
+			}},
+		},
+		{
+			name: "exploration floor over even split",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: weights, Bandit: &domain.BanditConfig{
+				Algorithm: domain.BanditThompson, Metric: "click", MinExploration: 0.6,
+			}},
+		},
+		{
+			name: "unknown variant",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: []domain.VariantWeight{
+				{Variant: "control", Weight: 1}, {Variant: "ghost", Weight: 1},
+			}},
+		},
+		{
+			name: "duplicate variant",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: []domain.VariantWeight{
+				{Variant: "control", Weight: 1}, {Variant: "control", Weight: 1},
+			}},
+		},
+		{
+			name: "non-positive weight",
+			exp: domain.Experiment{Key: "checkout-test", Allocation: 20, Variants: []domain.VariantWeight{
+				{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 0},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateExperiment(tt.exp, flag)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrInvalidExperiment)
+			}
+		})
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, domain.ValidateEnvironment(domain.Environment{Key: "staging"}))
+	require.ErrorIs(t, domain.ValidateEnvironment(domain.Environment{Key: "Staging EU"}), domain.ErrInvalidEnvironment)
+	require.ErrorIs(t, domain.ValidateEnvironment(domain.Environment{}), domain.ErrInvalidEnvironment)
+}
+
+func TestValidateProject(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, domain.ValidateProject(domain.Project{Key: "checkout-team"}))
+	require.ErrorIs(t, domain.ValidateProject(domain.Project{Key: "Checkout Team"}), domain.ErrInvalidProject)
+	require.ErrorIs(t, domain.ValidateProject(domain.Project{}), domain.ErrInvalidProject)
+}
+
+func TestValidateMetricEvents(t *testing.T) {
+	t.Parallel()
+
+	valid := domain.MetricEvent{Metric: "purchase", TargetingKey: "user-1", Value: 19.99}
+
+	tests := []struct {
+		name    string
+		events  []domain.MetricEvent
+		wantErr bool
+	}{
+		{name: "valid", events: []domain.MetricEvent{valid}},
+		{name: "empty batch", events: nil, wantErr: true},
+		{name: "batch too large", events: make([]domain.MetricEvent, domain.MaxMetricBatch+1), wantErr: true},
+		{name: "missing metric", events: []domain.MetricEvent{{TargetingKey: "user-1", Value: 1}}, wantErr: true},
+		{name: "missing targeting key", events: []domain.MetricEvent{{Metric: "purchase", Value: 1}}, wantErr: true},
+		{name: "infinite value", events: []domain.MetricEvent{{Metric: "purchase", TargetingKey: "user-1", Value: math.Inf(1)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateMetricEvents(tt.events)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidMetric)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// MinWebhookSecret is the shortest secret a webhook may be signed with.
+	MinWebhookSecret = 16
+	// MaxWebhookURL bounds the length of a webhook's URL.
+	MaxWebhookURL = 2048
+	// MaxDeliveryPage bounds the number of deliveries returned by one query.
+	MaxDeliveryPage = 1000
+)
+
+// Webhook subscribes URL to changes to flags. It receives the changes whose
+// action is listed in Events, or every change when the list is empty, each
+// signed with Secret; see SignWebhook.
+type Webhook struct {
+	ID        int64
+	URL       string
+	Events    []AuditAction
+	Secret    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// Subscribes reports whether the webhook receives changes made by action.
+func (w Webhook) Subscribes(action AuditAction) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, action)
+}
+
+// ValidateWebhook checks that the URL is an absolute http or https URL, that
+// the events are known and distinct and that the secret is long enough.
+func ValidateWebhook(w Webhook) error {
+	if len(w.URL) > MaxWebhookURL {
+		return fmt.Errorf("url exceeds %d characters: %w", MaxWebhookURL, ErrInvalidWebhook)
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q is not an absolute http or https URL: %w", w.URL, ErrInvalidWebhook)
+	}
+	seen := make(map[AuditAction]bool, len(w.Events))
+	for _, event := range w.Events {
+		if !slices.Contains(AuditActions, event) {
+			return fmt.Errorf("unknown event %q: %w", event, ErrInvalidWebhook)
+		}
+		if seen[event] {
+			return fmt.Errorf("duplicate event %q: %w", event, ErrInvalidWebhook)
+		}
+		seen[event] = true
+	}
+	if len(w.Secret) < MinWebhookSecret {
+		return fmt.Errorf("secret must be at least %d characters: %w", MinWebhookSecret, ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256, keyed with secret, of the unix
+// timestamp, a dot and the body. Receivers recompute it to check that a
+// delivery came from this service and reject stale timestamps to stop
+// replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeliveryStatus is the lifecycle state of a WebhookDelivery.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead marks a delivery that failed every attempt it was allowed.
+	// It is kept for inspection and is only retried on request.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// DeliveryStatuses lists every status in lifecycle order.
+var DeliveryStatuses = []DeliveryStatus{DeliveryPending, DeliveryDelivered, DeliveryDead}
+
+// WebhookDelivery is the delivery of one event to one webhook. A pending
+// delivery is attempted from NextAttemptAt on. ResponseStatus and LastError
+// describe the latest attempt; ResponseStatus is 0 when the receiver did not
+// answer.
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int64
+	Event          FlagEvent
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+// RetryPolicy spaces out the attempts of a failing delivery. The delay after
+// the nth failed attempt is BaseDelay doubled n-1 times, at most MaxDelay; a
+// delivery whose MaxAttempts attempts all failed is dead.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy gives a delivery 8 attempts over about an hour.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 8, BaseDelay: 30 * time.Second, MaxDelay: 30 * time.Minute}
+}
+
+// Backoff returns the delay before the attempt that follows attempts failed
+// ones.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// Succeed records an attempt at the instant at that the receiver acknowledged
+// with status.
+func (d *WebhookDelivery) Succeed(at time.Time, status int) {
+	d.Attempts++
+	d.Status = DeliveryDelivered
+	d.ResponseStatus = status
+	d.LastError = ""
+	d.DeliveredAt = at
+}
+
+// Fail records an attempt at the instant at that failed with reason, and the
+// HTTP status when the receiver answered. The delivery is scheduled again
+// after the policy's backoff, or is dead once its attempts are spent.
+func (d *WebhookDelivery) Fail(p RetryPolicy, at time.Time, status int, reason string) {
+	d.Attempts++
+	d.ResponseStatus = status
+	d.LastError = reason
+	if d.Attempts >= p.MaxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+	d.NextAttemptAt = at.Add(p.Backoff(d.Attempts))
+}
+
+// DeliveryQuery selects the deliveries of a webhook, newest first. An empty
+// Status matches every delivery; Limit is between 1 and MaxDeliveryPage.
+type DeliveryQuery struct {
+	WebhookID int64
+	Status    DeliveryStatus
+	Limit     int
+}
+
+// ValidateDeliveryQuery checks that the status is known and the limit is
+// within bounds.
+func ValidateDeliveryQuery(q DeliveryQuery) error {
+	if q.Status != "" && !slices.Contains(DeliveryStatuses, q.Status) {
+		return fmt.Errorf("unknown status %q: %w", q.Status, ErrInvalidDeliveryQuery)
+	}
+	if q.Limit < 1 || q.Limit > MaxDeliveryPage {
+		return fmt.Errorf("limit must be between 1 and %d: %w", MaxDeliveryPage, ErrInvalidDeliveryQuery)
+	}
+	return nil
+}
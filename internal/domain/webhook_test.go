@@ -0,0 +1,113 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	t.Parallel()
+
+	secret := strings.Repeat("s", domain.MinWebhookSecret)
+	tests := []struct {
+		name    string
+		webhook domain.Webhook
+		wantErr bool
+	}{
+		{name: "every event", webhook: domain.Webhook{URL: "https://deploys.example.com/hooks/flags", Secret: secret}},
+		{name: "filtered", webhook: domain.Webhook{URL: "http://localhost:9000", Events: []domain.AuditAction{domain.AuditUpdateValue, domain.AuditRollback}, Secret: secret}},
+		{name: "relative url", webhook: domain.Webhook{URL: "/hooks", Secret: secret}, wantErr: true},
+		{name: "other scheme", webhook: domain.Webhook{URL: "ftp://example.com/hooks", Secret: secret}, wantErr: true},
+		{name: "url too long", webhook: domain.Webhook{URL: "https://example.com/" + strings.Repeat("a", domain.MaxWebhookURL), Secret: secret}, wantErr: true},
+		{name: "unknown event", webhook: domain.Webhook{URL: "https://example.com", Events: []domain.AuditAction{"delete"}, Secret: secret}, wantErr: true},
+		{name: "duplicate event", webhook: domain.Webhook{URL: "https://example.com", Events: []domain.AuditAction{domain.AuditCreate, domain.AuditCreate}, Secret: secret}, wantErr: true},
+		{name: "short secret", webhook: domain.Webhook{URL: "https://example.com", Secret: "hunter2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.ValidateWebhook(tt.webhook)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidWebhook)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWebhook_Subscribes(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, domain.Webhook{}.Subscribes(domain.AuditPromote), "an empty filter receives everything")
+	filtered := domain.Webhook{Events: []domain.AuditAction{domain.AuditUpdateValue}}
+	assert.True(t, filtered.Subscribes(domain.AuditUpdateValue))
+	assert.False(t, filtered.Subscribes(domain.AuditCreate))
+}
+
+func TestSignWebhook(t *testing.T) {
+	t.Parallel()
+
+	// echo -n '1700000000.{"type":"create"}' | openssl dgst -sha256 -hmac 'whsec-0123456789abcdef'
+	got := domain.SignWebhook("whsec-0123456789abcdef", 1700000000, []byte(`{"type":"create"}`))
+	assert.Equal(t, "6cdf48f9f63b543be55ff7c0e4410de253e02e67b590792912895a4d0472b57e", got)
+	assert.NotEqual(t, got, domain.SignWebhook("whsec-0123456789abcdef", 1700000001, []byte(`{"type":"create"}`)), "the timestamp is signed")
+	assert.NotEqual(t, got, domain.SignWebhook("whsec-fedcba9876543210", 1700000000, []byte(`{"type":"create"}`)))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	p := domain.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 200, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.Backoff(tt.attempts), "after %d attempts", tt.attempts)
+	}
+}
+
+func TestWebhookDelivery_Attempts(t *testing.T) {
+	t.Parallel()
+
+	p := domain.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	d := domain.WebhookDelivery{Status: domain.DeliveryPending, NextAttemptAt: at}
+
+	d.Fail(p, at, 503, "receiver answered 503")
+	assert.Equal(t, domain.DeliveryPending, d.Status)
+	assert.Equal(t, at.Add(time.Minute), d.NextAttemptAt)
+	d.Fail(p, at, 0, "connection refused")
+	assert.Equal(t, at.Add(2*time.Minute), d.NextAttemptAt)
+	assert.Equal(t, 0, d.ResponseStatus)
+	d.Fail(p, at, 500, "receiver answered 500")
+	assert.Equal(t, domain.DeliveryDead, d.Status, "attempts are spent")
+	assert.Equal(t, 3, d.Attempts)
+
+	ok := domain.WebhookDelivery{Status: domain.DeliveryPending, LastError: "timeout", Attempts: 1}
+	ok.Succeed(at, 204)
+	assert.Equal(t, domain.DeliveryDelivered, ok.Status)
+	assert.Equal(t, 2, ok.Attempts)
+	assert.Empty(t, ok.LastError)
+	assert.Equal(t, at, ok.DeliveredAt)
+}
+
+func TestValidateDeliveryQuery(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, domain.ValidateDeliveryQuery(domain.DeliveryQuery{Status: domain.DeliveryDead, Limit: 10}))
+	assert.ErrorIs(t, domain.ValidateDeliveryQuery(domain.DeliveryQuery{Status: "lost", Limit: 10}), domain.ErrInvalidDeliveryQuery)
+	assert.ErrorIs(t, domain.ValidateDeliveryQuery(domain.DeliveryQuery{Limit: domain.MaxDeliveryPage + 1}), domain.ErrInvalidDeliveryQuery)
+}
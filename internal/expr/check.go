@@ -0,0 +1,178 @@
+package expr
+
+// methods lists the callable methods by receiver type with their argument
+// and result types.
+var methods = map[Type]map[string]struct {
+	args   []Type
+	result Type
+}{
+	TypeString: {
+		"startsWith": {args: []Type{TypeString}, result: TypeBool},
+		"endsWith":   {args: []Type{TypeString}, result: TypeBool},
+		"contains":   {args: []Type{TypeString}, result: TypeBool},
+		"lower":      {result: TypeString},
+		"upper":      {result: TypeString},
+	},
+	TypeList: {
+		"contains": {args: []Type{TypeAny}, result: TypeBool},
+	},
+}
+
+func check(n *node, env Env) (Type, error) {
+	switch n.kind {
+	case nodeLiteral:
+		return typeOf(n.value), nil
+	case nodeIdent:
+		return env[n.name], nil
+	case nodeList:
+		for _, elem := range n.args {
+			if _, err := check(elem, env); err != nil {
+				return TypeAny, err
+			}
+		}
+		return TypeList, nil
+	case nodeNot:
+		if err := checkOperand(n.args[0], env, TypeBool, "!"); err != nil {
+			return TypeAny, err
+		}
+		return TypeBool, nil
+	case nodeAnd, nodeOr:
+		op := "&&"
+		if n.kind == nodeOr {
+			op = "||"
+		}
+		for _, arg := range n.args {
+			if err := checkOperand(arg, env, TypeBool, op); err != nil {
+				return TypeAny, err
+			}
+		}
+		return TypeBool, nil
+	case nodeCompare:
+		return checkCompare(n, env)
+	case nodeIn:
+		if _, err := check(n.args[0], env); err != nil {
+			return TypeAny, err
+		}
+		if err := checkOperand(n.args[1], env, TypeList, "in"); err != nil {
+			return TypeAny, err
+		}
+		return TypeBool, nil
+	case nodeField:
+		recv, err := check(n.args[0], env)
+		if err != nil {
+			return TypeAny, err
+		}
+		if recv != TypeAny {
+			return TypeAny, errorf(n.pos, "cannot read field %q of %s", n.name, recv)
+		}
+		return TypeAny, nil
+	case nodeCall:
+		return checkCall(n, env)
+	}
+	return TypeAny, errorf(n.pos, "unknown expression")
+}
+
+func checkOperand(n *node, env Env, want Type, op string) error {
+	got, err := check(n, env)
+	if err != nil {
+		return err
+	}
+	if got != want && got != TypeAny {
+		return errorf(n.pos, "operator %s needs %s, found %s", op, want, got)
+	}
+	return nil
+}
+
+func checkCompare(n *node, env Env) (Type, error) {
+	left, err := check(n.args[0], env)
+	if err != nil {
+		return TypeAny, err
+	}
+	right, err := check(n.args[1], env)
+	if err != nil {
+		return TypeAny, err
+	}
+	if left == TypeAny || right == TypeAny {
+		return TypeBool, nil
+	}
+	switch n.op {
+	case "==", "!=":
+		if left != right && left != TypeNull && right != TypeNull {
+			return TypeAny, errorf(n.pos, "cannot compare %s with %s", left, right)
+		}
+	default:
+		if left != right || (left != TypeNumber && left != TypeString) {
+			return TypeAny, errorf(n.pos, "operator %s needs two numbers or two strings, found %s and %s", n.op, left, right)
+		}
+	}
+	return TypeBool, nil
+}
+
+func checkCall(n *node, env Env) (Type, error) {
+	recv, err := check(n.args[0], env)
+	if err != nil {
+		return TypeAny, err
+	}
+	args := n.args[1:]
+
+	if recv == TypeAny {
+		// The receiver is only known at evaluation time, so accept any method
+		// that exists on some type and check the arity it has everywhere.
+		var result Type
+		found := false
+		for _, byName := range methods {
+			if m, ok := byName[n.name]; ok {
+				if len(m.args) != len(args) {
+					return TypeAny, errorf(n.pos, "method %s takes %d arguments, found %d", n.name, len(m.args), len(args))
+				}
+				result, found = m.result, true
+			}
+		}
+		if !found {
+			return TypeAny, errorf(n.pos, "unknown method %s", n.name)
+		}
+		for _, arg := range args {
+			if _, err := check(arg, env); err != nil {
+				return TypeAny, err
+			}
+		}
+		return result, nil
+	}
+
+	m, ok := methods[recv][n.name]
+	if !ok {
+		return TypeAny, errorf(n.pos, "%s has no method %s", recv, n.name)
+	}
+	if len(m.args) != len(args) {
+		return TypeAny, errorf(n.pos, "method %s takes %d arguments, found %d", n.name, len(m.args), len(args))
+	}
+	for i, arg := range args {
+		if m.args[i] == TypeAny {
+			if _, err := check(arg, env); err != nil {
+				return TypeAny, err
+			}
+			continue
+		}
+		if err := checkOperand(arg, env, m.args[i], n.name); err != nil {
+			return TypeAny, err
+		}
+	}
+	return m.result, nil
+}
+
+// typeOf returns the dynamic type of an evaluated value.
+func typeOf(v any) Type {
+	switch v.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBool
+	case float64:
+		return TypeNumber
+	case string:
+		return TypeString
+	case []any:
+		return TypeList
+	}
+	return TypeAny
+}
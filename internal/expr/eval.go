@@ -0,0 +1,349 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errBudget = errors.New("evaluation cost budget exceeded")
+
+type evalFunc func(*state) (any, error)
+
+type state struct {
+	vars   map[string]any
+	budget int
+}
+
+func (s *state) spend(cost int) error {
+	s.budget -= cost
+	if s.budget < 0 {
+		return errBudget
+	}
+	return nil
+}
+
+// compile turns a checked AST into a tree of closures so evaluation does not
+// re-dispatch on node kinds.
+func compile(n *node) evalFunc {
+	switch n.kind {
+	case nodeLiteral:
+		value := n.value
+		return func(s *state) (any, error) { return value, s.spend(1) }
+	case nodeIdent:
+		name := n.name
+		return func(s *state) (any, error) { return normalize(s.vars[name]), s.spend(1) }
+	case nodeList:
+		return compileList(n)
+	case nodeNot:
+		operand := compile(n.args[0])
+		return func(s *state) (any, error) {
+			b, err := evalBool(s, operand, "!")
+			return !b, err
+		}
+	case nodeAnd, nodeOr:
+		return compileLogical(n)
+	case nodeCompare:
+		return compileCompare(n)
+	case nodeIn:
+		return compileIn(n)
+	case nodeField:
+		return compileField(n)
+	case nodeCall:
+		return compileCall(n)
+	}
+	return func(*state) (any, error) { return nil, errorf(n.pos, "unknown expression") }
+}
+
+func compileList(n *node) evalFunc {
+	elems := make([]evalFunc, len(n.args))
+	for i, arg := range n.args {
+		elems[i] = compile(arg)
+	}
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		out := make([]any, len(elems))
+		for i, elem := range elems {
+			v, err := elem(s)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	}
+}
+
+func compileLogical(n *node) evalFunc {
+	left, right := compile(n.args[0]), compile(n.args[1])
+	isOr := n.kind == nodeOr
+	op := "&&"
+	if isOr {
+		op = "||"
+	}
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		l, err := evalBool(s, left, op)
+		if err != nil {
+			return nil, err
+		}
+		if l == isOr {
+			return l, nil
+		}
+		return evalBool(s, right, op)
+	}
+}
+
+func evalBool(s *state, f evalFunc, op string) (bool, error) {
+	v, err := f(s)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s needs bool, found %s", op, typeOf(v))
+	}
+	return b, nil
+}
+
+func compileCompare(n *node) evalFunc {
+	left, right := compile(n.args[0]), compile(n.args[1])
+	op, pos := n.op, n.pos
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		l, err := left(s)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(s)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return equal(l, r), nil
+		case "!=":
+			return !equal(l, r), nil
+		}
+		// Ordering against a missing value is simply false, so that one absent
+		// attribute does not fail an otherwise satisfiable expression.
+		if l == nil || r == nil {
+			return false, nil
+		}
+		cmp, err := order(l, r)
+		if err != nil {
+			return nil, errorf(pos, "%v", err)
+		}
+		switch op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	}
+}
+
+func compileIn(n *node) evalFunc {
+	needle, haystack := compile(n.args[0]), compile(n.args[1])
+	pos := n.pos
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		v, err := needle(s)
+		if err != nil {
+			return nil, err
+		}
+		list, err := haystack(s)
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			return false, nil
+		}
+		elems, ok := list.([]any)
+		if !ok {
+			return nil, errorf(pos, "operator in needs list, found %s", typeOf(list))
+		}
+		return listContains(s, elems, v)
+	}
+}
+
+func compileField(n *node) evalFunc {
+	recv := compile(n.args[0])
+	name, pos := n.name, n.pos
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		v, err := recv(s)
+		if err != nil {
+			return nil, err
+		}
+		switch typed := v.(type) {
+		case nil:
+			return nil, nil
+		case map[string]any:
+			return normalize(typed[name]), nil
+		}
+		return nil, errorf(pos, "cannot read field %q of %s", name, typeOf(v))
+	}
+}
+
+func compileCall(n *node) evalFunc {
+	recv := compile(n.args[0])
+	args := make([]evalFunc, len(n.args)-1)
+	for i, arg := range n.args[1:] {
+		args[i] = compile(arg)
+	}
+	name, pos := n.name, n.pos
+	return func(s *state) (any, error) {
+		if err := s.spend(1); err != nil {
+			return nil, err
+		}
+		r, err := recv(s)
+		if err != nil {
+			return nil, err
+		}
+		argv := make([]any, len(args))
+		for i, arg := range args {
+			if argv[i], err = arg(s); err != nil {
+				return nil, err
+			}
+		}
+
+		switch typed := r.(type) {
+		case nil:
+			// Calling a method on a missing value yields a missing value, or
+			// false for predicates.
+			if name == "lower" || name == "upper" {
+				return nil, nil
+			}
+			return false, nil
+		case string:
+			return callString(s, pos, typed, name, argv)
+		case []any:
+			if name == "contains" {
+				return listContains(s, typed, argv[0])
+			}
+		}
+		return nil, errorf(pos, "%s has no method %s", typeOf(r), name)
+	}
+}
+
+func callString(s *state, pos int, recv, name string, args []any) (any, error) {
+	if err := s.spend(len(recv)); err != nil {
+		return nil, err
+	}
+	if name == "lower" {
+		return strings.ToLower(recv), nil
+	}
+	if name == "upper" {
+		return strings.ToUpper(recv), nil
+	}
+
+	arg, ok := args[0].(string)
+	if !ok {
+		if args[0] == nil {
+			return false, nil
+		}
+		return nil, errorf(pos, "method %s needs string, found %s", name, typeOf(args[0]))
+	}
+	if err := s.spend(len(arg)); err != nil {
+		return nil, err
+	}
+	switch name {
+	case "startsWith":
+		return strings.HasPrefix(recv, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(recv, arg), nil
+	case "contains":
+		return strings.Contains(recv, arg), nil
+	}
+	return nil, errorf(pos, "string has no method %s", name)
+}
+
+func listContains(s *state, elems []any, v any) (bool, error) {
+	for _, elem := range elems {
+		if err := s.spend(1); err != nil {
+			return false, err
+		}
+		if equal(normalize(elem), v) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func equal(a, b any) bool {
+	la, aIsList := a.([]any)
+	lb, bIsList := b.([]any)
+	if aIsList || bIsList {
+		if !aIsList || !bIsList || len(la) != len(lb) {
+			return false
+		}
+		for i := range la {
+			if !equal(normalize(la[i]), normalize(lb[i])) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := a.(map[string]any); ok {
+		return false
+	}
+	if _, ok := b.(map[string]any); ok {
+		return false
+	}
+	return a == b
+}
+
+func order(a, b any) (int, error) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot order %s and %s", typeOf(a), typeOf(b))
+}
+
+// normalize converts Go numeric types to float64 so callers may pass ints.
+func normalize(v any) any {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case uint32:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+// Package expr implements the small expression language used by expression
+// targeting rules, e.g.
+//
+//	(plan == "pro" && seats > 10) || email.endsWith("@ourco.com")
+//
+// Expressions are side-effect free: they can read variables, compare values,
+// combine booleans and call a fixed set of string and list methods. There are
+// no loops, assignments or function definitions. Compile parses and type checks
+// a source string once; the resulting Program can be evaluated many times.
+// Both steps are bounded: the source length, node count and nesting depth are
+// capped at compile time and every evaluation runs against a cost budget.
+package expr
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxSourceLength is the longest expression source Compile accepts.
+	MaxSourceLength = 2048
+	// MaxNodes is the largest number of syntax nodes an expression may have.
+	MaxNodes = 256
+	// MaxDepth is the deepest parentheses, list or negation nesting allowed.
+	MaxDepth = 32
+	// MaxCost is the evaluation budget. Every node costs one unit and string
+	// methods additionally cost the length of the strings they scan.
+	MaxCost = 100_000
+)
+
+var (
+	// ErrCompile is wrapped by every error returned from Compile.
+	ErrCompile = errors.New("invalid expression")
+	// ErrEval is wrapped by every error returned from Program.Eval.
+	ErrEval = errors.New("expression evaluation failed")
+)
+
+// Type is the static type of an expression or variable.
+type Type int
+
+const (
+	// TypeAny is the type of values only known at evaluation time.
+	TypeAny Type = iota
+	TypeNull
+	TypeBool
+	TypeNumber
+	TypeString
+	TypeList
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeNull:
+		return "null"
+	case TypeBool:
+		return "bool"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeList:
+		return "list"
+	}
+	return "any"
+}
+
+// Env declares the types of known variables. Variables missing from Env are
+// typed TypeAny and checked when the program is evaluated.
+type Env map[string]Type
+
+// Program is a compiled, type-checked expression.
+type Program struct {
+	source string
+	eval   evalFunc
+}
+
+// Compile parses and type checks source against env. The expression must
+// produce a boolean.
+func Compile(source string, env Env) (*Program, error) {
+	root, _, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCompile, err)
+	}
+	typ, err := check(root, env)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCompile, err)
+	}
+	if typ != TypeBool && typ != TypeAny {
+		return nil, fmt.Errorf("%w: expression produces %s, not bool", ErrCompile, typ)
+	}
+	return &Program{source: source, eval: compile(root)}, nil
+}
+
+// Source returns the expression the program was compiled from.
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Eval runs the program against vars. Values must be nil, bool, string,
+// numbers, []any or map[string]any, as produced by encoding/json. A variable
+// missing from vars evaluates to null.
+func (p *Program) Eval(vars map[string]any) (bool, error) {
+	st := &state{vars: vars, budget: MaxCost}
+	v, err := p.eval(st)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrEval, err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%w: expression produced %s, not bool", ErrEval, typeOf(v))
+	}
+	return b, nil
+}
+
+// posError is an error tied to a position in the expression source.
+type posError struct {
+	pos int
+	msg string
+}
+
+func (e *posError) Error() string {
+	return fmt.Sprintf("at position %d: %s", e.pos, e.msg)
+}
+
+func errorf(pos int, format string, args ...any) error {
+	return &posError{pos: pos, msg: fmt.Sprintf(format, args...)}
+}
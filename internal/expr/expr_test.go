@@ -0,0 +1,136 @@
+package expr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/expr"
+)
+
+func TestCompile_Errors(t *testing.T) {
+	t.Parallel()
+
+	env := expr.Env{"plan": expr.TypeString, "seats": expr.TypeNumber}
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "empty", source: ""},
+		{name: "unterminated string", source: `plan == "pro`},
+		{name: "unknown character", source: `plan == "pro" ; true`},
+		{name: "dangling operator", source: `plan ==`},
+		{name: "trailing tokens", source: `true false`},
+		{name: "unbalanced parenthesis", source: `(plan == "pro"`},
+		{name: "not a boolean", source: `seats`},
+		{name: "string compared with number", source: `plan == 10`},
+		{name: "ordering booleans", source: `true < false`},
+		{name: "and on number", source: `seats && true`},
+		{name: "not on string", source: `!plan`},
+		{name: "unknown method", source: `plan.matches("p.*")`},
+		{name: "method on number", source: `seats.startsWith("1")`},
+		{name: "wrong arity", source: `plan.startsWith()`},
+		{name: "wrong argument type", source: `plan.startsWith(1)`},
+		{name: "in on string", source: `"a" in plan`},
+		{name: "field of string", source: `plan.tier == "x"`},
+		{name: "too long", source: strings.Repeat(" ", expr.MaxSourceLength+1) + "true"},
+		{name: "too many nodes", source: strings.Repeat("true && ", expr.MaxNodes) + "true"},
+		{name: "too deep", source: strings.Repeat("(", expr.MaxDepth+1) + "true" + strings.Repeat(")", expr.MaxDepth+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := expr.Compile(tt.source, env)
+			require.ErrorIs(t, err, expr.ErrCompile)
+		})
+	}
+}
+
+func TestProgram_Eval(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]any{
+		"plan":    "pro",
+		"seats":   12.0,
+		"email":   "ada@ourco.com",
+		"beta":    true,
+		"country": "PL",
+		"tags":    []any{"early", "vip"},
+		"org":     map[string]any{"tier": "gold", "size": 250},
+		"age":     30,
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   bool
+	}{
+		{name: "headline example", source: `(plan == "pro" && seats > 10) || email.endsWith("@ourco.com")`, want: true},
+		{name: "and short circuits", source: `plan == "free" && missing.startsWith("x")`, want: false},
+		{name: "or short circuits", source: `beta || seats`, want: true},
+		{name: "negation", source: `!beta`, want: false},
+		{name: "not equal", source: `plan != "free"`, want: true},
+		{name: "number ordering", source: `seats >= 12 && seats < 13 && seats <= 12.5`, want: true},
+		{name: "negative literal", source: `seats > -1`, want: true},
+		{name: "string ordering", source: `country < "US"`, want: true},
+		{name: "in list literal", source: `country in ["PL", "DE"]`, want: true},
+		{name: "not in list literal", source: `!(country in ["US"])`, want: true},
+		{name: "list attribute contains", source: `tags.contains("vip")`, want: true},
+		{name: "string contains", source: `email.contains("@")`, want: true},
+		{name: "startsWith", source: `email.startsWith("bob")`, want: false},
+		{name: "lower", source: `email.upper().lower() == email`, want: true},
+		{name: "single quotes", source: `plan == 'pro'`, want: true},
+		{name: "nested field", source: `org.tier == "gold" && org.size > 100`, want: true},
+		{name: "go ints are numbers", source: `age == 30`, want: true},
+		{name: "missing equals null", source: `missing == null`, want: true},
+		{name: "missing is not a string", source: `missing == "x"`, want: false},
+		{name: "ordering missing is false", source: `missing > 1 || beta`, want: true},
+		{name: "method on missing is false", source: `missing.endsWith("x")`, want: false},
+		{name: "field of missing is null", source: `missing.tier == null`, want: true},
+		{name: "mixed types are unequal", source: `seats == "12"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			prog, err := expr.Compile(tt.source, nil)
+			require.NoError(t, err)
+
+			got, err := prog.Eval(vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProgram_Eval_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		source string
+		vars   map[string]any
+	}{
+		{name: "non-boolean result", source: `plan`, vars: map[string]any{"plan": "pro"}},
+		{name: "and on string", source: `plan && true`, vars: map[string]any{"plan": "pro"}},
+		{name: "ordering mixed types", source: `plan > 1`, vars: map[string]any{"plan": "pro"}},
+		{name: "method on wrong type", source: `seats.startsWith("1")`, vars: map[string]any{"seats": 1.0}},
+		{name: "cost budget exceeded", source: `blob.endsWith("x")`, vars: map[string]any{"blob": strings.Repeat("a", expr.MaxCost)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			prog, err := expr.Compile(tt.source, nil)
+			require.NoError(t, err)
+
+			_, err = prog.Eval(tt.vars)
+			require.ErrorIs(t, err, expr.ErrEval)
+		})
+	}
+}
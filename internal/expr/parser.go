@@ -0,0 +1,313 @@
+package expr
+
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeIdent
+	nodeList
+	nodeNot
+	nodeAnd
+	nodeOr
+	nodeCompare
+	nodeIn
+	nodeField
+	nodeCall
+)
+
+// node is an expression AST node. Which fields are set depends on kind.
+type node struct {
+	kind  nodeKind
+	pos   int
+	value any    // nodeLiteral
+	name  string // nodeIdent, nodeField and nodeCall: identifier, field or method name
+	op    string // nodeCompare
+	args  []*node
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+	nodes  int
+}
+
+func parse(src string) (*node, int, error) {
+	if len(src) > MaxSourceLength {
+		return nil, 0, errorf(0, "expression is longer than %d characters", MaxSourceLength)
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, 0, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, 0, errorf(tok.pos, "unexpected %s", tok)
+	}
+	return n, p.nodes, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return errorf(tok.pos, "expected %q, found %s", op, tok)
+	}
+	return nil
+}
+
+func (p *parser) newNode(kind nodeKind, pos int) (*node, error) {
+	p.nodes++
+	if p.nodes > MaxNodes {
+		return nil, errorf(pos, "expression has more than %d nodes", MaxNodes)
+	}
+	return &node{kind: kind, pos: pos}, nil
+}
+
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return errorf(pos, "expression is nested deeper than %d levels", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() { p.depth-- }
+
+func (p *parser) parseOr() (*node, error) {
+	return p.parseBinary("||", nodeOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	return p.parseBinary("&&", nodeAnd, p.parseUnary)
+}
+
+func (p *parser) parseBinary(op string, kind nodeKind, operand func() (*node, error)) (*node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if !p.accept(op) {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		n, err := p.newNode(kind, tok.pos)
+		if err != nil {
+			return nil, err
+		}
+		n.args = []*node{left, right}
+		left = n
+	}
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	tok := p.peek()
+	if !p.accept("!") {
+		return p.parseComparison()
+	}
+	if err := p.enter(tok.pos); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.newNode(nodeNot, tok.pos)
+	if err != nil {
+		return nil, err
+	}
+	n.args = []*node{operand}
+	return n, nil
+}
+
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	var kind nodeKind
+	switch {
+	case tok.kind == tokOp && isComparison(tok.text):
+		kind = nodeCompare
+	case tok.kind == tokIdent && tok.text == "in":
+		kind = nodeIn
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.newNode(kind, tok.pos)
+	if err != nil {
+		return nil, err
+	}
+	n.op = tok.text
+	n.args = []*node{left, right}
+	return n, nil
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parsePostfix() (*node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		dot := p.peek()
+		if !p.accept(".") {
+			return n, nil
+		}
+		name := p.next()
+		if name.kind != tokIdent {
+			return nil, errorf(name.pos, "expected a field or method name, found %s", name)
+		}
+		if !p.accept("(") {
+			field, err := p.newNode(nodeField, dot.pos)
+			if err != nil {
+				return nil, err
+			}
+			field.name = name.text
+			field.args = []*node{n}
+			n = field
+			continue
+		}
+		args, err := p.parseArgs(")")
+		if err != nil {
+			return nil, err
+		}
+		call, err := p.newNode(nodeCall, dot.pos)
+		if err != nil {
+			return nil, err
+		}
+		call.name = name.text
+		call.args = append([]*node{n}, args...)
+		n = call
+	}
+}
+
+// parseArgs parses a comma-separated list of expressions up to and including
+// the closing token.
+func (p *parser) parseArgs(closing string) ([]*node, error) {
+	var args []*node
+	if p.accept(closing) {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseNested()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseNested() (*node, error) {
+	if err := p.enter(p.peek().pos); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	return p.parseOr()
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return p.literal(tok, tok.num)
+	case tokString:
+		return p.literal(tok, tok.text)
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return p.literal(tok, true)
+		case "false":
+			return p.literal(tok, false)
+		case "null":
+			return p.literal(tok, nil)
+		case "in":
+			return nil, errorf(tok.pos, "unexpected %s", tok)
+		}
+		n, err := p.newNode(nodeIdent, tok.pos)
+		if err != nil {
+			return nil, err
+		}
+		n.name = tok.text
+		return n, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			n, err := p.parseNested()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			elems, err := p.parseArgs("]")
+			if err != nil {
+				return nil, err
+			}
+			n, err := p.newNode(nodeList, tok.pos)
+			if err != nil {
+				return nil, err
+			}
+			n.args = elems
+			return n, nil
+		}
+	}
+	return nil, errorf(tok.pos, "unexpected %s", tok)
+}
+
+func (p *parser) literal(tok token, value any) (*node, error) {
+	n, err := p.newNode(nodeLiteral, tok.pos)
+	if err != nil {
+		return nil, err
+	}
+	n.value = value
+	return n, nil
+}
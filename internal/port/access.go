@@ -0,0 +1,68 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// PermissionRequest grants Scope on the flags the selectors match; an empty
+// selector matches everything.
+type PermissionRequest struct {
+	Scope        string
+	Projects     []string
+	Environments []string
+	Flags        []string
+	Tags         []string
+}
+
+// RoleRequest defines a role, or replaces the description and permissions of
+// an existing one.
+type RoleRequest struct {
+	Name        string
+	Description string
+	Permissions []PermissionRequest
+}
+
+// RoleResponse is a role with its permissions.
+type RoleResponse struct {
+	Name        string
+	Description string
+	Permissions []PermissionRequest
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// RoleBindingRequest grants Role to Principal.
+type RoleBindingRequest struct {
+	Principal string
+	Role      string
+}
+
+// RoleBindingResponse is a role granted to a principal.
+type RoleBindingResponse struct {
+	ID        int64
+	Principal string
+	Role      string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// AccessService is the inbound port for managing roles and role bindings.
+// Every method requires the admin scope on everything and otherwise fails
+// with domain.ErrForbidden.
+type AccessService interface {
+	CreateRole(ctx context.Context, req RoleRequest) (*RoleResponse, error)
+	ListRoles(ctx context.Context) ([]RoleResponse, error)
+	GetRole(ctx context.Context, name string) (*RoleResponse, error)
+	// UpdateRole replaces the description and permissions of the role
+	// called name; req.Name is ignored.
+	UpdateRole(ctx context.Context, name string, req RoleRequest) (*RoleResponse, error)
+	// DeleteRole removes the role together with its bindings.
+	DeleteRole(ctx context.Context, name string) error
+	CreateRoleBinding(ctx context.Context, req RoleBindingRequest) (*RoleBindingResponse, error)
+	// ListRoleBindings returns the bindings of principal, or every binding
+	// when it is empty.
+	ListRoleBindings(ctx context.Context, principal string) ([]RoleBindingResponse, error)
+	DeleteRoleBinding(ctx context.Context, id int64) error
+}
@@ -0,0 +1,36 @@
+package port
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// AccessStore is the outbound port for roles and the bindings granting them.
+type AccessStore interface {
+	// CreateRole returns an error wrapping domain.ErrRoleExists when the name
+	// is taken.
+	CreateRole(ctx context.Context, role domain.Role) error
+	// GetRole returns an error wrapping domain.ErrRoleNotFound when no role
+	// has the name.
+	GetRole(ctx context.Context, name string) (*domain.Role, error)
+	// ListRoles returns every role in name order.
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	// UpdateRole replaces the description, permissions and update time of
+	// the role with role's name.
+	UpdateRole(ctx context.Context, role domain.Role) error
+	// DeleteRole removes the role together with its bindings.
+	DeleteRole(ctx context.Context, name string) error
+	// CreateRoleBinding stores the binding and returns it with its id. It
+	// fails with domain.ErrRoleNotFound for an unknown role and
+	// domain.ErrRoleBindingExists when the principal already has the role.
+	CreateRoleBinding(ctx context.Context, binding domain.RoleBinding) (*domain.RoleBinding, error)
+	// ListRoleBindings returns the bindings of principal, or every binding
+	// when it is empty, in id order.
+	ListRoleBindings(ctx context.Context, principal string) ([]domain.RoleBinding, error)
+	// DeleteRoleBinding returns an error wrapping
+	// domain.ErrRoleBindingNotFound when no binding has the id.
+	DeleteRoleBinding(ctx context.Context, id int64) error
+	// RolesOf returns the roles bound to principal.
+	RolesOf(ctx context.Context, principal string) ([]domain.Role, error)
+}
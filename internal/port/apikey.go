@@ -0,0 +1,49 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// APIKeyRequest asks for a key authenticating as Principal with Scopes. A
+// zero ExpiresAt means the key never expires.
+type APIKeyRequest struct {
+	Principal string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// APIKeyResponse is an API key. Token is only set in the response to the
+// key's creation or rotation; afterwards only Hint identifies it.
+type APIKeyResponse struct {
+	ID        int64
+	Principal string
+	Scopes    []string
+	Hint      string
+	Token     string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// APIKeyService is the inbound port for managing API keys and authenticating
+// the requests made with them.
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req APIKeyRequest) (*APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) ([]APIKeyResponse, error)
+	GetAPIKey(ctx context.Context, id int64) (*APIKeyResponse, error)
+	// RevokeAPIKey stops the key working at once. It fails with
+	// domain.ErrAPIKeyRevoked when the key is already revoked.
+	RevokeAPIKey(ctx context.Context, id int64) (*APIKeyResponse, error)
+	// RotateAPIKey issues a new key with the same principal and scopes. The
+	// old key keeps working for grace, at most domain.MaxRotationGrace, so
+	// clients can switch over. It fails with domain.ErrAPIKeyRevoked when the
+	// key is revoked or expired.
+	RotateAPIKey(ctx context.Context, id int64, grace time.Duration) (*APIKeyResponse, error)
+	// Authenticate returns the principal token authenticates as. It fails
+	// with domain.ErrUnauthenticated unless token is an active key.
+	Authenticate(ctx context.Context, token string) (*domain.Principal, error)
+}
@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// APIKeyStore is the outbound port for API keys.
+type APIKeyStore interface {
+	// CreateAPIKey stores the key and returns it with its id.
+	CreateAPIKey(ctx context.Context, key domain.APIKey) (*domain.APIKey, error)
+	// GetAPIKey returns an error wrapping domain.ErrAPIKeyNotFound when no
+	// key has the id.
+	GetAPIKey(ctx context.Context, id int64) (*domain.APIKey, error)
+	// GetAPIKeyByHash returns an error wrapping domain.ErrAPIKeyNotFound when
+	// no key's token has the hash.
+	GetAPIKeyByHash(ctx context.Context, hash []byte) (*domain.APIKey, error)
+	// ListAPIKeys returns every key in id order.
+	ListAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	// RevokeAPIKey marks the key revoked at at. It fails with
+	// domain.ErrAPIKeyRevoked when the key already is.
+	RevokeAPIKey(ctx context.Context, id int64, at time.Time) (*domain.APIKey, error)
+	// RotateAPIKey stores replacement and makes the key with the id expire by
+	// expiresAt, atomically. It fails with domain.ErrAPIKeyRevoked when the
+	// key is revoked.
+	RotateAPIKey(ctx context.Context, id int64, expiresAt time.Time, replacement domain.APIKey) (*domain.APIKey, error)
+}
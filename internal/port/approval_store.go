@@ -0,0 +1,40 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// ApprovalStore is the outbound port for flag protections and the change
+// requests made to protected flags. It is implemented alongside the FlagStore,
+// since the approval that completes a change request applies its value, with
+// its audit entry, in the same transaction.
+type ApprovalStore interface {
+	// GetProtection returns the flag's protection, without RequiredApprovals
+	// when it is unprotected.
+	GetProtection(ctx context.Context, project, name string) (*domain.FlagProtection, error)
+	// ListProtections returns the project's protected flags in name order.
+	ListProtections(ctx context.Context, project string) ([]domain.FlagProtection, error)
+	// SetProtection replaces the flag's protection; one without
+	// RequiredApprovals removes it.
+	SetProtection(ctx context.Context, protection domain.FlagProtection) error
+	// CreateChangeRequest stores a pending change request and returns it with
+	// its id.
+	CreateChangeRequest(ctx context.Context, cr domain.ChangeRequest) (*domain.ChangeRequest, error)
+	// GetChangeRequest returns domain.ErrChangeRequestNotFound when the
+	// project has no change request with the id.
+	GetChangeRequest(ctx context.Context, project string, id int64) (*domain.ChangeRequest, error)
+	// ListChangeRequests returns the change requests matching q, newest first.
+	ListChangeRequests(ctx context.Context, q domain.ChangeRequestQuery) ([]domain.ChangeRequest, error)
+	// ApproveChangeRequest records approver's approval with
+	// domain.ChangeRequest.Approve, serialized with other decisions on the
+	// request. The approval that completes the request applies its value in
+	// the same transaction, audited as domain.AuditApplyChange, and the
+	// updated flag is returned; otherwise the flag is nil.
+	ApproveChangeRequest(ctx context.Context, project string, id int64, approver string, at time.Time) (*domain.ChangeRequest, *domain.Flag, error)
+	// RejectChangeRequest marks the change request rejected with
+	// domain.ChangeRequest.Reject.
+	RejectChangeRequest(ctx context.Context, project string, id int64, reviewer string, at time.Time) (*domain.ChangeRequest, error)
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// AuditBreak is the first point where the audit log failed verification.
+// CheckpointID is set when a checkpoint is involved.
+type AuditBreak struct {
+	EntryID      int64
+	CheckpointID int64
+	Reason       string
+}
+
+// AuditVerificationResponse reports a walk of the audit chain. Broken is nil
+// when every entry and checkpoint verified. SignaturesVerified is false when
+// no public key was configured to check checkpoint signatures with.
+type AuditVerificationResponse struct {
+	Entries            int
+	Checkpoints        int
+	SignaturesVerified bool
+	Broken             *AuditBreak
+}
+
+// AuditCheckpointResponse is a signed checkpoint of the audit chain. Hash and
+// Signature are hex encoded.
+type AuditCheckpointResponse struct {
+	ID        int64
+	EntryID   int64
+	Hash      string
+	Signature string
+	CreatedAt time.Time
+}
+
+// AuditService is the inbound port for proving the audit log has not been
+// edited.
+type AuditService interface {
+	// VerifyAudit walks the whole audit chain and its checkpoints and reports
+	// the first broken link.
+	VerifyAudit(ctx context.Context) (*AuditVerificationResponse, error)
+	ListAuditCheckpoints(ctx context.Context) ([]AuditCheckpointResponse, error)
+}
@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// AuditStore is the outbound port for reading the audit log as a hash chain
+// and for keeping its signed checkpoints. Entries are written by the FlagStore
+// and ExperimentStore with the changes they record, each chained to the one
+// before it with domain.AuditHash.
+type AuditStore interface {
+	// ListAuditChain returns up to limit entries with ids greater than
+	// afterID, in id order, with their hashes.
+	ListAuditChain(ctx context.Context, afterID int64, limit int) ([]domain.AuditEntry, error)
+	// CreateAuditCheckpoint stores the checkpoint and returns it with its id.
+	CreateAuditCheckpoint(ctx context.Context, cp domain.AuditCheckpoint) (*domain.AuditCheckpoint, error)
+	// LastAuditCheckpoint returns the latest checkpoint, or nil when there
+	// is none.
+	LastAuditCheckpoint(ctx context.Context) (*domain.AuditCheckpoint, error)
+	// ListAuditCheckpoints returns every checkpoint in id order.
+	ListAuditCheckpoints(ctx context.Context) ([]domain.AuditCheckpoint, error)
+}
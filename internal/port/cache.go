@@ -6,10 +6,16 @@ import (
 	"github.com/xNakero/feature-flags/internal/domain"
 )
 
-// FlagCache is the outbound port for caching feature flag values.
+// FlagCache is the outbound port for caching feature flags on the hot read path.
 // Concrete implementations (e.g. Redis) must satisfy this interface.
+//
+// Entries are keyed by project, environment and name; Set uses the flag's
+// Project and Environment.
+// Get returns domain.ErrNotFound on a cache miss. GetMany returns only the hits,
+// keyed by name, in a single round trip. Delete is idempotent.
 type FlagCache interface {
-	Get(ctx context.Context, name string) (*domain.FlagValue, error)
-	Set(ctx context.Context, name string, flagValue domain.FlagValue) error
-	Delete(ctx context.Context, name string) error
+	Get(ctx context.Context, project, env, name string) (*domain.Flag, error)
+	GetMany(ctx context.Context, project, env string, names []string) (map[string]domain.Flag, error)
+	Set(ctx context.Context, flag domain.Flag) error
+	Delete(ctx context.Context, project, env, name string) error
 }
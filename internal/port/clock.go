@@ -0,0 +1,9 @@
+package port
+
+import "time"
+
+// Clock is the outbound port for reading the current time. Injecting it keeps
+// time-dependent behaviour, such as schedule targeting, deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// EventStore is the outbound port for persisting experiment events.
+// Concrete implementations (e.g. PostgreSQL) must satisfy this interface.
+type EventStore interface {
+	// RecordExposures appends the exposure events in a single round trip.
+	RecordExposures(ctx context.Context, events []domain.ExposureEvent) error
+	// RecordMetrics appends the metric events in a single round trip.
+	RecordMetrics(ctx context.Context, events []domain.MetricEvent) error
+	// VariantStats joins the experiment's exposures with events of metric and
+	// aggregates them per variant. Variants nobody was exposed to are omitted.
+	VariantStats(ctx context.Context, experimentKey, metric string) ([]domain.VariantStats, error)
+}
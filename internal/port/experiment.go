@@ -0,0 +1,172 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// Variant is the port-level representation of a named flag value.
+type Variant struct {
+	Key   string
+	Value FlagValue
+}
+
+// VariantWeight is the relative share of enrolled traffic a variant receives.
+type VariantWeight struct {
+	Variant string
+	Weight  int
+}
+
+// BanditConfig makes an experiment a multi-armed bandit. Algorithm is
+// "thompson" or "epsilon-greedy"; Metric is the reward metric.
+type BanditConfig struct {
+	Algorithm      string
+	Metric         string
+	Epsilon        float64
+	MinExploration float64
+}
+
+// WeightChange is one entry of a bandit experiment's weight history.
+type WeightChange struct {
+	Variants  []VariantWeight
+	ChangedAt time.Time
+}
+
+// CreateExperimentRequest defines a draft experiment on a multivariate flag.
+type CreateExperimentRequest struct {
+	// Key identifies the experiment and follows the flag naming rules.
+	Key      string
+	FlagName string
+	// Allocation is the percentage (1-100) of contexts enrolled.
+	Allocation int
+	Variants   []VariantWeight
+	// Layer optionally places the experiment in a mutually exclusive layer,
+	// where it takes Allocation of the layer's buckets.
+	Layer string
+	// Bandit optionally lets the variant weights follow the reward metric.
+	Bandit *BanditConfig
+}
+
+// ExperimentFilter narrows the experiments listed by a store. Empty fields
+// match everything. ListExperiments always filters on the context's project
+// and environment.
+type ExperimentFilter struct {
+	Project     string
+	Environment string
+	FlagName    string
+	Layer       string
+	Status      string
+}
+
+// ExperimentResponse is the DTO returned by ExperimentService methods.
+type ExperimentResponse struct {
+	Key         string
+	Project     string
+	FlagName    string
+	Environment string
+	Allocation  int
+	Variants    []VariantWeight
+	// Layer and LayerOffset locate the experiment's buckets in its layer.
+	Layer       string
+	LayerOffset int
+	// Holdout is the global holdout percentage fixed when the experiment started.
+	Holdout int
+	Bandit  *BanditConfig
+	// Status is "draft", "running" or "stopped".
+	Status    string
+	StartedAt *time.Time
+	StoppedAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreateLayerRequest defines a new mutually exclusive layer.
+type CreateLayerRequest struct {
+	Key         string
+	Description string
+}
+
+// LayerSlot is the bucket range a live experiment holds in its layer.
+type LayerSlot struct {
+	Experiment string
+	Offset     int
+	Allocation int
+	Status     string
+}
+
+// LayerResponse is the DTO returned by the layer methods of ExperimentService.
+type LayerResponse struct {
+	Key         string
+	Description string
+	// Slots are the ranges held by draft and running experiments, by offset.
+	Slots []LayerSlot
+	// Free is the number of buckets not held by any slot.
+	Free      int
+	CreatedAt time.Time
+}
+
+// MetricEvent is the port-level representation of a conversion or metric
+// observation. A zero OccurredAt means now.
+type MetricEvent struct {
+	Metric       string
+	TargetingKey string
+	Value        float64
+	OccurredAt   time.Time
+}
+
+// RecordMetricsRequest is a batch of metric events to ingest.
+type RecordMetricsRequest struct {
+	Events []MetricEvent
+}
+
+// Interval is a two-sided confidence interval.
+type Interval struct {
+	Low  float64
+	High float64
+}
+
+// VariantResult is the analysis of one variant against the control.
+type VariantResult struct {
+	Variant        string
+	Users          int64
+	Conversions    int64
+	ConversionRate float64
+	ConversionCI   Interval
+	Mean           float64
+	MeanCI         Interval
+	// ConversionPValue and MeanPValue are nil for the control and whenever
+	// there is too little data to test.
+	ConversionPValue *float64
+	MeanPValue       *float64
+	Significant      bool
+}
+
+// ExperimentResultsResponse is the DTO returned by GetExperimentResults.
+type ExperimentResultsResponse struct {
+	ExperimentKey   string
+	Metric          string
+	Control         string
+	ConfidenceLevel float64
+	Variants        []VariantResult
+}
+
+// ExperimentService is the inbound port for managing experiments. Experiments
+// are created and listed in the project and environment the context is scoped
+// to; their keys are unique across projects.
+type ExperimentService interface {
+	CreateExperiment(ctx context.Context, req CreateExperimentRequest) (*ExperimentResponse, error)
+	GetExperiment(ctx context.Context, key string) (*ExperimentResponse, error)
+	ListExperiments(ctx context.Context, filter ExperimentFilter) ([]ExperimentResponse, error)
+	StartExperiment(ctx context.Context, key string) (*ExperimentResponse, error)
+	StopExperiment(ctx context.Context, key string) (*ExperimentResponse, error)
+	// RecordMetrics ingests conversion and metric events keyed by targeting key.
+	RecordMetrics(ctx context.Context, req RecordMetricsRequest) error
+	// GetExperimentResults analyses metric for every variant of the experiment.
+	GetExperimentResults(ctx context.Context, key, metric string) (*ExperimentResultsResponse, error)
+	// GetWeightHistory lists a bandit experiment's weights, oldest first,
+	// starting with those it was started with.
+	GetWeightHistory(ctx context.Context, key string) ([]WeightChange, error)
+	CreateLayer(ctx context.Context, req CreateLayerRequest) (*LayerResponse, error)
+	GetLayer(ctx context.Context, key string) (*LayerResponse, error)
+	ListLayers(ctx context.Context) ([]LayerResponse, error)
+}
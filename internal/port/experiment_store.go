@@ -0,0 +1,47 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// ExperimentStore is the outbound port for persisting experiments.
+// Concrete implementations (e.g. PostgreSQL) must satisfy this interface.
+//
+// Starting and stopping an experiment also attaches it to or detaches it from
+// its flag, so both happen atomically and the updated flag is returned for the
+// caller to cache.
+type ExperimentStore interface {
+	// Create returns domain.ErrExperimentExists when the key is taken. An
+	// experiment in a layer is placed at the lowest free offset of the layer,
+	// atomically with respect to other creates in the same layer, and returned
+	// with LayerOffset set. It returns domain.ErrLayerNotFound for an unknown
+	// layer and domain.ErrLayerFull when the allocation does not fit.
+	Create(ctx context.Context, exp domain.Experiment) (*domain.Experiment, error)
+	// GetByKey returns domain.ErrExperimentNotFound when no experiment has the key.
+	GetByKey(ctx context.Context, key string) (*domain.Experiment, error)
+	// List returns the experiments matching filter, newest first.
+	List(ctx context.Context, filter ExperimentFilter) ([]domain.Experiment, error)
+	// Start moves a draft experiment to running with the given global holdout
+	// percentage; a bandit experiment's starting weights open its weight
+	// history. It returns domain.ErrExperimentState when the experiment is
+	// not a draft or its flag already runs an experiment.
+	Start(ctx context.Context, key string, at time.Time, holdout int) (*domain.Experiment, *domain.Flag, error)
+	// Stop moves a running experiment to stopped. It returns
+	// domain.ErrExperimentState when the experiment is not running.
+	Stop(ctx context.Context, key string, at time.Time) (*domain.Experiment, *domain.Flag, error)
+	// UpdateWeights replaces a running experiment's variant weights on the
+	// experiment and its flag and appends them to the weight history. It
+	// returns domain.ErrExperimentState when the experiment is not running.
+	UpdateWeights(ctx context.Context, key string, weights []domain.VariantWeight, at time.Time) (*domain.Experiment, *domain.Flag, error)
+	// WeightHistory returns the recorded weights of an experiment, oldest first.
+	WeightHistory(ctx context.Context, key string) ([]domain.WeightChange, error)
+	// CreateLayer returns domain.ErrLayerExists when the key is taken.
+	CreateLayer(ctx context.Context, layer domain.Layer) error
+	// GetLayer returns domain.ErrLayerNotFound when no layer has the key.
+	GetLayer(ctx context.Context, key string) (*domain.Layer, error)
+	// ListLayers returns all layers ordered by key.
+	ListLayers(ctx context.Context) ([]domain.Layer, error)
+}
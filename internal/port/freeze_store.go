@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// FreezeStore is the outbound port for freeze windows. The service enforces
+// them; the store only keeps them.
+type FreezeStore interface {
+	// CreateFreezeWindow stores the window and returns it with its id.
+	CreateFreezeWindow(ctx context.Context, window domain.FreezeWindow) (*domain.FreezeWindow, error)
+	// GetFreezeWindow returns domain.ErrFreezeNotFound for an unknown id.
+	GetFreezeWindow(ctx context.Context, id int64) (*domain.FreezeWindow, error)
+	// ListFreezeWindows returns the windows matching q ordered by start.
+	ListFreezeWindows(ctx context.Context, q domain.FreezeQuery) ([]domain.FreezeWindow, error)
+	// DeleteFreezeWindow returns domain.ErrFreezeNotFound for an unknown id.
+	DeleteFreezeWindow(ctx context.Context, id int64) error
+}
@@ -0,0 +1,38 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// OutboxStore is the outbound port for the outbox of flag change events and
+// the cursors of the sinks they are published to. Events are written by the
+// FlagStore and ExperimentStore in the transaction of the change, alongside
+// its audit entry, so no committed change goes without its event.
+type OutboxStore interface {
+	// ClaimOutbox leases the sink's cursor to owner until until and returns
+	// it. A sink without a cursor starts before the oldest event kept. It
+	// reports false while another owner's lease runs at now.
+	ClaimOutbox(ctx context.Context, sink, owner string, now, until time.Time) (*domain.OutboxCursor, bool, error)
+	// ListOutbox returns up to limit events with ids greater than afterID,
+	// in id order. Ids follow commit order.
+	ListOutbox(ctx context.Context, afterID int64, limit int) ([]domain.FlagEvent, error)
+	// AdvanceOutbox stores the cursor's position and lease. It reports false
+	// when the cursor's owner no longer holds the lease.
+	AdvanceOutbox(ctx context.Context, cursor domain.OutboxCursor) (bool, error)
+	// PruneOutbox deletes the events every one of the sinks has passed and
+	// returns how many there were. It deletes none while one of the sinks has
+	// no cursor.
+	PruneOutbox(ctx context.Context, sinks []string) (int64, error)
+}
+
+// EventSink is the outbound port events are published to from the outbox.
+// Publish receives events in order but may receive an event again after a
+// failure, so sinks pass on each event's key for consumers to drop repeats.
+// Name identifies the sink's cursor and must not change between releases.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, events []domain.FlagEvent) error
+}
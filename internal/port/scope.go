@@ -0,0 +1,119 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+type (
+	projectKey     struct{}
+	environmentKey struct{}
+	actorKey       struct{}
+	requestIDKey   struct{}
+	asOfKey        struct{}
+	emergencyKey   struct{}
+	principalKey   struct{}
+)
+
+// WithProject scopes the flag operations made with ctx to the project
+// project. Like the environment, it is set by inbound adapters and passed
+// explicitly to stores and caches.
+func WithProject(ctx context.Context, project string) context.Context {
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// ProjectFrom returns the project ctx is scoped to, or domain.DefaultProject
+// when none was set.
+func ProjectFrom(ctx context.Context) string {
+	if project, ok := ctx.Value(projectKey{}).(string); ok && project != "" {
+		return project
+	}
+	return domain.DefaultProject
+}
+
+// WithEnvironment scopes the flag operations made with ctx to the environment
+// env. Inbound adapters set it from the request; services read it with
+// EnvironmentFrom and pass it explicitly to stores and caches.
+func WithEnvironment(ctx context.Context, env string) context.Context {
+	return context.WithValue(ctx, environmentKey{}, env)
+}
+
+// EnvironmentFrom returns the environment ctx is scoped to, or
+// domain.DefaultEnvironment when none was set.
+func EnvironmentFrom(ctx context.Context) string {
+	if env, ok := ctx.Value(environmentKey{}).(string); ok && env != "" {
+		return env
+	}
+	return domain.DefaultEnvironment
+}
+
+// WithActor records who is making the changes requested with ctx. Unlike the
+// project and environment it is not passed explicitly: outbound adapters read
+// it with ActorFrom when they record a change.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor set on ctx, or domain.SystemActor when none was
+// set.
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return domain.SystemActor
+}
+
+// WithRequestID records the id of the inbound request ctx serves, so changes
+// can be correlated with it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithAsOf asks the reads made with ctx for the flags as they were at the
+// instant at, answered from their recorded history instead of their current
+// state. Only the reads documented to honour it do.
+func WithAsOf(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, asOfKey{}, at)
+}
+
+// AsOfFrom returns the instant set on ctx, or the zero time when reads should
+// see the current state.
+func AsOfFrom(ctx context.Context) time.Time {
+	at, _ := ctx.Value(asOfKey{}).(time.Time)
+	return at
+}
+
+// RequestIDFrom returns the request id set on ctx, or "" when none was set.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithEmergency marks the changes requested with ctx as an emergency override
+// of change freezes, for the reason given. Like the actor, outbound adapters
+// read it with EmergencyFrom and record it with each change.
+func WithEmergency(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, emergencyKey{}, reason)
+}
+
+// EmergencyFrom returns the emergency override reason set on ctx, or "" when
+// the changes are not an emergency.
+func EmergencyFrom(ctx context.Context) string {
+	reason, _ := ctx.Value(emergencyKey{}).(string)
+	return reason
+}
+
+// WithPrincipal records the authenticated principal making the requests made
+// with ctx. Inbound adapters set it alongside the actor once they have
+// checked the request's credentials.
+func WithPrincipal(ctx context.Context, principal domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal set on ctx and whether one was set.
+func PrincipalFrom(ctx context.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return principal, ok
+}
@@ -12,40 +12,433 @@ type FlagValue struct {
 	Numeric *float64
 }
 
+// Clause is the port-level representation of a targeting rule condition.
+type Clause struct {
+	// Attribute is the context attribute tested; "targeting_key" addresses the
+	// context's targeting key. Ignored by time operators.
+	Attribute string
+	// Operator is one of "in", "not-in", "time-between", "day-of-week-in" or
+	// "time-of-day-between".
+	Operator string
+	Values   []string
+	// Timezone is the IANA zone used by the day-of-week and time-of-day operators.
+	Timezone string
+}
+
+// Rule is the port-level representation of a targeting rule. Value is served
+// when all clauses match, or when Expression evaluates to true.
+type Rule struct {
+	ID         string
+	Clauses    []Clause
+	Expression string
+	Value      FlagValue
+}
+
 type CreateFlagRequest struct {
-	// Name is the desired flag name. Must contain only lowercase letters, digits,
-	// and hyphens, start with a letter, and be at most 63 characters long.
+	// Name is the desired flag name. It must follow the service's naming
+	// policy; by default only lowercase letters, digits and hyphens, starting
+	// with a letter and at most 63 characters long.
 	Name string
 	// Type is the flag's value type. Accepted values: "boolean", "numeric".
 	Type        string
 	Description string
-	Value       FlagValue
+	// Tags are optional labels shared by every environment; see
+	// FreezeWindowRequest.
+	Tags  []string
+	Value FlagValue
+	// Rules are optional targeting rules, evaluated in order.
+	Rules []Rule
+	// Variants are optional named values an experiment can split traffic
+	// between.
+	Variants []Variant
 }
 
 type UpdateFlagValueRequest struct {
 	Value FlagValue
 }
 
-// FlagResponse is the DTO returned by service methods that operate on a full flag.
+// UpdateFlagRulesRequest replaces a flag's targeting rules. An empty list
+// removes all targeting.
+type UpdateFlagRulesRequest struct {
+	Rules []Rule
+}
+
+// AddTargetsRequest forces Value for each of Keys, overriding any value a key
+// was already targeted with.
+type AddTargetsRequest struct {
+	Keys  []string
+	Value FlagValue
+}
+
+// RemoveTargetsRequest drops individual targets so the keys are evaluated by
+// the flag's rules again.
+type RemoveTargetsRequest struct {
+	Keys []string
+}
+
+// SetFlagEnabledRequest switches a flag on or off in an environment.
+type SetFlagEnabledRequest struct {
+	Enabled bool
+}
+
+// CreateEnvironmentRequest defines a new environment. With a Parent it
+// inherits every flag it does not override; otherwise its flags start as
+// copies of those in CopyFrom, or in the default environment when it is empty.
+type CreateEnvironmentRequest struct {
+	Key         string
+	Description string
+	Parent      string
+	CopyFrom    string
+}
+
+// CreateProjectRequest defines a new, empty project.
+type CreateProjectRequest struct {
+	Key         string
+	Description string
+}
+
+// ProjectResponse is the DTO returned by the project methods.
+type ProjectResponse struct {
+	Key         string
+	Description string
+	CreatedAt   time.Time
+}
+
+// EnvironmentResponse is the DTO returned by the environment methods.
+type EnvironmentResponse struct {
+	Key         string
+	Description string
+	Parent      string
+	CreatedAt   time.Time
+}
+
+// PromoteRequest copies flag configuration from Source to Target. Flags
+// names the flags to promote; empty means all of them. A dry run only returns
+// the diff.
+type PromoteRequest struct {
+	Source string
+	Target string
+	Flags  []string
+	DryRun bool
+}
+
+// ValueChange is a flag value before and after a promotion.
+type ValueChange struct {
+	From FlagValue
+	To   FlagValue
+}
+
+// EnabledChange is a flag's enabled state before and after a promotion.
+type EnabledChange struct {
+	From bool
+	To   bool
+}
+
+// RuleChange names a rule and how it changes: added, removed, modified or moved.
+type RuleChange struct {
+	ID     string
+	Change string
+}
+
+// TargetChange names a targeting key and how its target changes.
+type TargetChange struct {
+	Key    string
+	Change string
+}
+
+// FlagDiff is the change promotion makes to one flag in the target
+// environment. Value and Enabled are nil when unchanged.
+type FlagDiff struct {
+	Name    string
+	Value   *ValueChange
+	Enabled *EnabledChange
+	Rules   []RuleChange
+	Targets []TargetChange
+}
+
+// PromotionResponse lists the flags a promotion changes. ID and PromotedAt
+// are set once it has been applied.
+type PromotionResponse struct {
+	ID         int64
+	Project    string
+	Source     string
+	Target     string
+	DryRun     bool
+	Flags      []FlagDiff
+	PromotedAt time.Time
+}
+
+// AuditFilter selects audit entries. Empty fields and zero times match every
+// entry; Limit defaults to 100.
+type AuditFilter struct {
+	Project     string
+	Environment string
+	Flag        string
+	Actor       string
+	Action      string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+// AuditEntryResponse is one recorded change to a flag. Before is nil for a
+// newly created flag.
+type AuditEntryResponse struct {
+	ID          int64
+	Actor       string
+	Action      string
+	Project     string
+	Environment string
+	Flag        string
+	Before      *FlagResponse
+	After       *FlagResponse
+	RequestID   string
+	// Emergency is the reason given for overriding a change freeze, if any.
+	Emergency  string
+	RecordedAt time.Time
+	// Hash is the hex encoded hash chaining the entry to the one before it.
+	Hash string
+}
+
+// FlagVersionResponse is one recorded version of a flag's state.
+// Environment is the environment it was recorded in, which is an ancestor of
+// the requested one for versions inherited from there.
+type FlagVersionResponse struct {
+	Version     int64
+	Environment string
+	Enabled     bool
+	Value       FlagValue
+	Rules       []Rule
+	Targets     map[string]FlagValue
+	RecordedAt  time.Time
+}
+
+// SnapshotResponse is every flag of a project as it resolved in Environment
+// at AsOf.
+type SnapshotResponse struct {
+	Project     string
+	Environment string
+	AsOf        time.Time
+	Flags       []FlagResponse
+}
+
+// FlagProtectionRequest sets how many principals must approve value changes
+// to a flag, in the listed environments or in all of them when none are
+// listed. Zero RequiredApprovals removes the protection.
+type FlagProtectionRequest struct {
+	RequiredApprovals int
+	Environments      []string
+}
+
+// FlagProtectionResponse is a flag's protection. RequiredApprovals is zero
+// for an unprotected flag.
+type FlagProtectionResponse struct {
+	Project           string
+	Flag              string
+	RequiredApprovals int
+	Environments      []string
+}
+
+// ApprovalResponse is one principal's approval of a change request.
+type ApprovalResponse struct {
+	Approver   string
+	ApprovedAt time.Time
+}
+
+// ChangeRequestFilter selects change requests of the context's project.
+// Empty fields match every request.
+type ChangeRequestFilter struct {
+	Flag string
+	// Status is one of "pending", "applied", "rejected" or "expired".
+	Status string
+}
+
+// ChangeRequestResponse is a value change to a protected flag and its
+// approvals. DecidedBy and DecidedAt are set once it is applied or rejected.
+type ChangeRequestResponse struct {
+	ID                int64
+	Project           string
+	Flag              string
+	Environment       string
+	Value             FlagValue
+	Status            string
+	RequestedBy       string
+	RequiredApprovals int
+	Approvals         []ApprovalResponse
+	DecidedBy         string
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+	DecidedAt         time.Time
+}
+
+// FreezeWindowRequest freezes changes to flags from StartsAt until EndsAt.
+// The window covers the flags of the listed Projects in the listed
+// Environments carrying any of the listed Tags; empty lists match everything.
+type FreezeWindowRequest struct {
+	Reason       string
+	StartsAt     time.Time
+	EndsAt       time.Time
+	Projects     []string
+	Environments []string
+	Tags         []string
+}
+
+// FreezeWindowFilter selects freeze windows. The zero value selects all of
+// them.
+type FreezeWindowFilter struct {
+	// Active selects only the windows in force now.
+	Active bool
+}
+
+// FreezeWindowResponse is a stored freeze window. Active reports whether it
+// is in force now.
+type FreezeWindowResponse struct {
+	ID           int64
+	Reason       string
+	StartsAt     time.Time
+	EndsAt       time.Time
+	Projects     []string
+	Environments []string
+	Tags         []string
+	CreatedBy    string
+	CreatedAt    time.Time
+	Active       bool
+}
+
+// FlagResponse is the DTO returned by service methods that operate on a full
+// flag. The per-environment fields are those of Environment.
 type FlagResponse struct {
+	Project     string
 	Name        string
 	Type        string
 	Description string
-	Value       FlagValue
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Tags        []string
+	Environment string
+	// SourceEnvironment is the environment the flag's state was resolved
+	// from; it differs from Environment when the state is inherited.
+	SourceEnvironment string
+	Enabled           bool
+	Value             FlagValue
+	Rules             []Rule
+	// Targets maps individually targeted keys to the value forced for them.
+	Targets  map[string]FlagValue
+	Variants []Variant
+	// Experiment is the key of the running experiment, if any.
+	Experiment string
+	Version    int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// PendingChange is set by UpdateFlagValue on a protected flag: the flag
+	// is returned unchanged and the change awaits approval.
+	PendingChange *ChangeRequestResponse
 }
 
 // FlagValueResponse is the DTO returned by GetFlagValue.
 type FlagValueResponse struct {
 	Value FlagValue
+	// Reason explains why Value was served, e.g. "STATIC", "TARGET_MATCH" or
+	// "TARGETING_MATCH".
+	Reason string
+	// RuleID identifies the matching rule when Reason is "TARGETING_MATCH".
+	RuleID string
+	// Bucket is the bucket the context hashed into when Reason is "SPLIT".
+	Bucket *int
+	// Experiment and Variant identify the experiment and the variant served
+	// when Reason is "SPLIT".
+	Experiment string
+	Variant    string
+	// Version is the version of the flag that produced Value.
+	Version int64
+	// Source reports where the flag was read from: "cache", "store", or
+	// "history" for a point-in-time read.
+	Source string
+}
+
+// EvaluationContext is the port-level representation of the subject flags are
+// evaluated for.
+type EvaluationContext struct {
+	TargetingKey string
+	Attributes   map[string]any
+}
+
+// EvaluationFilter narrows the set of flags evaluated by EvaluateAll.
+// The zero value selects every flag.
+type EvaluationFilter struct {
+	// Names restricts evaluation to the listed flags. Unknown names are skipped.
+	Names []string
+	// Prefix restricts evaluation to flags whose name starts with it.
+	Prefix string
+}
+
+// EvaluateAllResponse is the DTO returned by EvaluateAll.
+type EvaluateAllResponse struct {
+	// Flags maps each evaluated flag name to its evaluation result.
+	Flags map[string]FlagValueResponse
 }
 
 // FlagService is the inbound port through which HTTP handlers interact with the application's core logic.
+//
+// Every flag method operates in the project and environment the context is
+// scoped to with WithProject and WithEnvironment, or in the defaults.
+// Environments and projects themselves are shared by the whole instance.
+//
+// Methods that change flags return domain.ErrFrozen while a freeze window
+// covers the change, unless the context carries an emergency override set
+// with WithEmergency.
 type FlagService interface {
 	CreateFlag(ctx context.Context, req CreateFlagRequest) (*FlagResponse, error)
+	// GetFlag returns the flag, or as it was at the instant set with WithAsOf.
 	GetFlag(ctx context.Context, name string) (*FlagResponse, error)
-	// GetFlagValue retrieves only the current value of the flag, not the full record.
+	// GetFlagValue retrieves only the current value of the flag, not the full
+	// record. With WithAsOf it evaluates the flag as it was at that instant.
 	GetFlagValue(ctx context.Context, name string) (*FlagValueResponse, error)
+	// UpdateFlagValue changes the flag's value, or on a flag protected in the
+	// context's environment records the change as a pending change request,
+	// returned as the response's PendingChange.
 	UpdateFlagValue(ctx context.Context, name string, req UpdateFlagValueRequest) (*FlagResponse, error)
+	UpdateFlagRules(ctx context.Context, name string, req UpdateFlagRulesRequest) (*FlagResponse, error)
+	AddTargets(ctx context.Context, name string, req AddTargetsRequest) (*FlagResponse, error)
+	RemoveTargets(ctx context.Context, name string, req RemoveTargetsRequest) (*FlagResponse, error)
+	SetFlagEnabled(ctx context.Context, name string, req SetFlagEnabledRequest) (*FlagResponse, error)
+	// RemoveFlagOverride drops the flag's own state in the context's
+	// environment, so it inherits from the parent again.
+	RemoveFlagOverride(ctx context.Context, name string) (*FlagResponse, error)
+	// EvaluateAll evaluates every flag selected by filter for evalCtx in a single call.
+	EvaluateAll(ctx context.Context, evalCtx EvaluationContext, filter EvaluationFilter) (*EvaluateAllResponse, error)
+	CreateEnvironment(ctx context.Context, req CreateEnvironmentRequest) (*EnvironmentResponse, error)
+	ListEnvironments(ctx context.Context) ([]EnvironmentResponse, error)
+	// PromoteFlags diffs flag configuration between two environments and,
+	// unless req.DryRun is set, applies the diff atomically.
+	PromoteFlags(ctx context.Context, req PromoteRequest) (*PromotionResponse, error)
+	ListPromotions(ctx context.Context) ([]PromotionResponse, error)
+	CreateProject(ctx context.Context, req CreateProjectRequest) (*ProjectResponse, error)
+	ListProjects(ctx context.Context) ([]ProjectResponse, error)
+	// ListAudit returns the recorded flag changes matching filter, newest
+	// first, across every project unless filter names one.
+	ListAudit(ctx context.Context, filter AuditFilter) ([]AuditEntryResponse, error)
+	// GetFlagHistory lists the recorded versions of the flag, newest first.
+	GetFlagHistory(ctx context.Context, name string) ([]FlagVersionResponse, error)
+	// RollbackFlag restores the state the flag had at toVersion as a new
+	// version, like any other update.
+	RollbackFlag(ctx context.Context, name string, toVersion int64) (*FlagResponse, error)
+	// GetSnapshot returns every flag as it was at the instant set with
+	// WithAsOf, or now when none was set.
+	GetSnapshot(ctx context.Context) (*SnapshotResponse, error)
+	GetFlagProtection(ctx context.Context, name string) (*FlagProtectionResponse, error)
+	SetFlagProtection(ctx context.Context, name string, req FlagProtectionRequest) (*FlagProtectionResponse, error)
+	ListChangeRequests(ctx context.Context, filter ChangeRequestFilter) ([]ChangeRequestResponse, error)
+	GetChangeRequest(ctx context.Context, id int64) (*ChangeRequestResponse, error)
+	// ApproveChangeRequest approves the change request as the context's
+	// actor, applying it once it has the approvals it requires. Requesters
+	// cannot approve their own changes.
+	ApproveChangeRequest(ctx context.Context, id int64) (*ChangeRequestResponse, error)
+	// RejectChangeRequest rejects, or for its requester withdraws, a pending
+	// change request.
+	RejectChangeRequest(ctx context.Context, id int64) (*ChangeRequestResponse, error)
+	CreateFreezeWindow(ctx context.Context, req FreezeWindowRequest) (*FreezeWindowResponse, error)
+	ListFreezeWindows(ctx context.Context, filter FreezeWindowFilter) ([]FreezeWindowResponse, error)
+	GetFreezeWindow(ctx context.Context, id int64) (*FreezeWindowResponse, error)
+	// DeleteFreezeWindow removes the window, lifting the freeze early or
+	// cancelling an upcoming one.
+	DeleteFreezeWindow(ctx context.Context, id int64) error
 }
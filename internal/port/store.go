@@ -4,14 +4,97 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/xNakero/feature-flags/internal/domain"
 )
 
 // FlagStore is the outbound port for persisting and retrieving feature flags.
 // Concrete implementations (e.g. PostgreSQL) must satisfy this interface.
+//
+// Flags belong to a project and their definitions are shared by all
+// environments; every read and update names the project and, for the
+// per-environment fields, the environment explicitly. A flag that does not
+// exist in the project is reported as domain.ErrNotFound.
+//
+// Every change to a flag is recorded in the audit log atomically with the
+// change itself, attributed to ActorFrom and RequestIDFrom of the
+// context it was made with, together with its EmergencyFrom.
 type FlagStore interface {
+	// Create stores the definition and gives the flag its value, rules and
+	// targets in every environment without a parent; the others inherit them.
 	Create(ctx context.Context, flag domain.Flag) error
-	GetByName(ctx context.Context, name string) (*domain.Flag, error)
-	UpdateValue(ctx context.Context, name string, flagValue domain.FlagValue) (*domain.Flag, error)
+	// GetByName returns the flag as resolved in env: its own state when env
+	// overrides it, otherwise that of the nearest ancestor.
+	GetByName(ctx context.Context, project, env, name string) (*domain.Flag, error)
+	// The update methods below first copy an inherited flag's state into env,
+	// so a change always creates or edits an override. Like the other changes
+	// they take the time at, which stamps the update, its version and its
+	// audit entry.
+	UpdateValue(ctx context.Context, project, env, name string, flagValue domain.FlagValue, at time.Time) (*domain.Flag, error)
+	// UpdateRules replaces the flag's targeting rules and returns the updated flag.
+	UpdateRules(ctx context.Context, project, env, name string, rules []domain.Rule, at time.Time) (*domain.Flag, error)
+	// UpdateEnabled switches the flag on or off and returns the updated flag.
+	UpdateEnabled(ctx context.Context, project, env, name string, enabled bool, at time.Time) (*domain.Flag, error)
+	// AddTargets maps each key to value in the flag's individual targets,
+	// replacing any value a key already had, and returns the updated flag.
+	AddTargets(ctx context.Context, project, env, name string, keys []string, value domain.FlagValue, at time.Time) (*domain.Flag, error)
+	// RemoveTargets drops the keys from the flag's individual targets and
+	// returns the updated flag. Keys that are not targeted are ignored.
+	RemoveTargets(ctx context.Context, project, env, name string, keys []string, at time.Time) (*domain.Flag, error)
+	// GetByNames returns the flags with the given names in a single round trip.
+	// Names that do not exist are omitted from the result.
+	GetByNames(ctx context.Context, project, env string, names []string) ([]domain.Flag, error)
+	// ListNames returns the names of the project's flags starting with
+	// prefix, in name order.
+	ListNames(ctx context.Context, project, prefix string) ([]string, error)
+	// RemoveOverride deletes the flag's own state in env and returns the flag
+	// as it is now inherited. It returns domain.ErrInvalidEnvironment when env
+	// has no parent and domain.ErrExperimentState while an experiment runs on
+	// the override.
+	RemoveOverride(ctx context.Context, project, env, name string, at time.Time) (*domain.Flag, error)
+	// CreateEnvironment adds an environment. One with a parent starts empty
+	// and inherits every flag; otherwise its flags start as copies of those
+	// resolved in source, without running experiments. It returns
+	// domain.ErrEnvironmentExists when the key is taken and
+	// domain.ErrEnvironmentNotFound when the parent or source does not exist.
+	CreateEnvironment(ctx context.Context, env domain.Environment, source string) error
+	// GetEnvironment returns domain.ErrEnvironmentNotFound for an unknown key.
+	GetEnvironment(ctx context.Context, key string) (*domain.Environment, error)
+	// ListEnvironments returns all environments ordered by key.
+	ListEnvironments(ctx context.Context) ([]domain.Environment, error)
+	// Promote copies the value, enabled state, rules and targets of the named
+	// flags, or of every flag in the project when names is empty, from source
+	// to target. In
+	// one transaction it diffs the flags with domain.DiffFlag, updates those
+	// that change and records the promotion, returning it with the updated
+	// target flags. An unknown flag fails the whole promotion with
+	// domain.ErrNotFound.
+	Promote(ctx context.Context, project, source, target string, names []string, at time.Time) (*domain.Promotion, []domain.Flag, error)
+	// ListPromotions returns the project's recorded promotions, newest first.
+	ListPromotions(ctx context.Context, project string) ([]domain.Promotion, error)
+	// CreateProject returns domain.ErrProjectExists when the key is taken.
+	CreateProject(ctx context.Context, project domain.Project) error
+	// GetProject returns domain.ErrProjectNotFound for an unknown key.
+	GetProject(ctx context.Context, key string) (*domain.Project, error)
+	// ListProjects returns all projects ordered by key.
+	ListProjects(ctx context.Context) ([]domain.Project, error)
+	// ListAudit returns the audit entries matching q, newest first.
+	ListAudit(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error)
+	// ListHistory returns the versions recorded for the flag in env and in
+	// the environments it inherits from, newest first. Every change to a
+	// flag's state records a version atomically with the change.
+	ListHistory(ctx context.Context, project, env, name string) ([]domain.FlagVersion, error)
+	// Rollback restores the enabled state, value, rules and targets of
+	// version, as resolved in env, as a new version of the flag in env and
+	// returns the updated flag. It returns domain.ErrVersionNotFound when no
+	// such version was recorded.
+	Rollback(ctx context.Context, project, env, name string, version int64, at time.Time) (*domain.Flag, error)
+	// GetAsOf returns the flag as it resolved in env at the instant at,
+	// reconstructed from the recorded versions, without an experiment. It
+	// returns domain.ErrNotFound when the flag had no version in env then.
+	GetAsOf(ctx context.Context, project, env, name string, at time.Time) (*domain.Flag, error)
+	// ListAsOf returns every flag of the project as GetAsOf would, in name
+	// order.
+	ListAsOf(ctx context.Context, project, env string, at time.Time) ([]domain.Flag, error)
 }
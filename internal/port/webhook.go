@@ -0,0 +1,66 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookRequest subscribes URL to the flag changes listed in Events, or to
+// every change when it is empty. An empty Secret asks for a generated one.
+type WebhookRequest struct {
+	URL    string
+	Events []string
+	Secret string
+}
+
+// WebhookResponse is a webhook subscription. Secret is only set in the
+// response to its creation.
+type WebhookResponse struct {
+	ID        int64
+	URL       string
+	Events    []string
+	Secret    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// DeliveryFilter selects a webhook's deliveries. Empty fields match every
+// delivery; a zero Limit means the default page.
+type DeliveryFilter struct {
+	Status string
+	Limit  int
+}
+
+// DeliveryResponse is one event's delivery to a webhook and the outcome of
+// its latest attempt. ResponseStatus is 0 when the receiver did not answer.
+type DeliveryResponse struct {
+	ID             int64
+	WebhookID      int64
+	Event          string
+	Project        string
+	Environment    string
+	Flag           string
+	Version        int64
+	Actor          string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+// WebhookService is the inbound port for managing webhook subscriptions and
+// inspecting their deliveries. Flag changes are queued for delivery by the
+// services that make them.
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, req WebhookRequest) (*WebhookResponse, error)
+	ListWebhooks(ctx context.Context) ([]WebhookResponse, error)
+	GetWebhook(ctx context.Context, id int64) (*WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListWebhookDeliveries(ctx context.Context, id int64, filter DeliveryFilter) ([]DeliveryResponse, error)
+	// RetryWebhookDelivery queues a dead delivery again with a fresh set of
+	// attempts. It fails with domain.ErrDeliveryState for any other delivery.
+	RetryWebhookDelivery(ctx context.Context, id, deliveryID int64) (*DeliveryResponse, error)
+}
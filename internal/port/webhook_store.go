@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+)
+
+// WebhookStore is the outbound port for webhook subscriptions and the queue
+// of their deliveries.
+type WebhookStore interface {
+	// CreateWebhook stores the webhook and returns it with its id.
+	CreateWebhook(ctx context.Context, hook domain.Webhook) (*domain.Webhook, error)
+	// GetWebhook returns an error wrapping domain.ErrWebhookNotFound when no
+	// webhook has the id.
+	GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error)
+	// ListWebhooks returns every webhook in id order.
+	ListWebhooks(ctx context.Context) ([]domain.Webhook, error)
+	// DeleteWebhook removes the webhook together with its deliveries.
+	DeleteWebhook(ctx context.Context, id int64) error
+	// EnqueueDeliveries stores new deliveries, skipping any whose webhook
+	// already has a delivery of the same event key.
+	EnqueueDeliveries(ctx context.Context, deliveries []domain.WebhookDelivery) error
+	// ClaimDeliveries returns up to limit pending deliveries due at now,
+	// oldest first, and postpones each by lease so that other claimers skip
+	// them while they are attempted.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+	// UpdateDelivery records the status, attempts and outcome of delivery.
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+	// GetDelivery returns an error wrapping domain.ErrDeliveryNotFound when
+	// the webhook has no delivery with the id.
+	GetDelivery(ctx context.Context, webhookID, id int64) (*domain.WebhookDelivery, error)
+	// ListDeliveries returns the deliveries q selects, newest first.
+	ListDeliveries(ctx context.Context, q domain.DeliveryQuery) ([]domain.WebhookDelivery, error)
+}
+
+// WebhookSender is the outbound port that makes one delivery attempt. It
+// returns the receiver's HTTP status, or 0 when none answered, and an error
+// unless the receiver acknowledged the delivery.
+type WebhookSender interface {
+	Send(ctx context.Context, hook domain.Webhook, delivery domain.WebhookDelivery) (int, error)
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.AccessService = (*AccessService)(nil)
+
+// AccessService manages roles and the bindings granting them. Only a
+// principal that may administer everything can manage them, so a role
+// scoped to some projects cannot be used to widen itself.
+type AccessService struct {
+	store  port.AccessStore
+	access accessControl
+	clock  port.Clock
+	naming domain.NamingPolicy
+	logger *slog.Logger
+}
+
+func NewAccessService(store port.AccessStore, opts ...Option) *AccessService {
+	o := newOptions(opts)
+	return &AccessService{
+		store:  store,
+		access: accessControl{store: store, logger: o.logger},
+		clock:  o.clock,
+		naming: o.naming,
+		logger: o.logger,
+	}
+}
+
+// CreateRole stores a role attributed to the context's actor.
+func (s *AccessService) CreateRole(ctx context.Context, req port.RoleRequest) (*port.RoleResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	now := s.clock.Now().UTC()
+	role := roleFromRequest(req)
+	role.CreatedBy, role.CreatedAt, role.UpdatedAt = port.ActorFrom(ctx), now, now
+	if err := domain.ValidateRole(role, s.naming); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "role created", "role", role.Name, "actor", role.CreatedBy)
+	return roleToResponse(role), nil
+}
+
+func (s *AccessService) ListRoles(ctx context.Context) ([]port.RoleResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	roles, err := s.store.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.RoleResponse, len(roles))
+	for i, role := range roles {
+		out[i] = *roleToResponse(role)
+	}
+	return out, nil
+}
+
+func (s *AccessService) GetRole(ctx context.Context, name string) (*port.RoleResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	role, err := s.store.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return roleToResponse(*role), nil
+}
+
+// UpdateRole takes effect on the next request of every principal bound to
+// the role.
+func (s *AccessService) UpdateRole(ctx context.Context, name string, req port.RoleRequest) (*port.RoleResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	role, err := s.store.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	update := roleFromRequest(req)
+	role.Description, role.Permissions, role.UpdatedAt = update.Description, update.Permissions, s.clock.Now().UTC()
+	if err := domain.ValidateRole(*role, s.naming); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateRole(ctx, *role); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "role updated", "role", name, "actor", port.ActorFrom(ctx))
+	return roleToResponse(*role), nil
+}
+
+func (s *AccessService) DeleteRole(ctx context.Context, name string) error {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return err
+	}
+	if err := s.store.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "role deleted", "role", name, "actor", port.ActorFrom(ctx))
+	return nil
+}
+
+// CreateRoleBinding grants the role to the principal, attributed to the
+// context's actor.
+func (s *AccessService) CreateRoleBinding(ctx context.Context, req port.RoleBindingRequest) (*port.RoleBindingResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	binding := domain.RoleBinding{
+		Principal: req.Principal,
+		Role:      req.Role,
+		CreatedBy: port.ActorFrom(ctx),
+		CreatedAt: s.clock.Now().UTC(),
+	}
+	if err := domain.ValidateRoleBinding(binding); err != nil {
+		return nil, err
+	}
+	created, err := s.store.CreateRoleBinding(ctx, binding)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "role granted", "binding", created.ID, "principal", created.Principal, "role", created.Role,
+		"actor", created.CreatedBy)
+	return roleBindingToResponse(*created), nil
+}
+
+func (s *AccessService) ListRoleBindings(ctx context.Context, principal string) ([]port.RoleBindingResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	bindings, err := s.store.ListRoleBindings(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.RoleBindingResponse, len(bindings))
+	for i, binding := range bindings {
+		out[i] = *roleBindingToResponse(binding)
+	}
+	return out, nil
+}
+
+func (s *AccessService) DeleteRoleBinding(ctx context.Context, id int64) error {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return err
+	}
+	if err := s.store.DeleteRoleBinding(ctx, id); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "role revoked", "binding", id, "actor", port.ActorFrom(ctx))
+	return nil
+}
+
+// accessControl decides what the context's principal may do from its key's
+// scopes and the roles bound to it.
+type accessControl struct {
+	store  port.AccessStore
+	logger *slog.Logger
+}
+
+// grants loads what the context's principal may do. A context without an
+// authenticated principal may do nothing.
+func (a accessControl) grants(ctx context.Context) (domain.Grants, error) {
+	principal, ok := port.PrincipalFrom(ctx)
+	if !ok {
+		return domain.Grants{}, fmt.Errorf("no authenticated principal: %w", domain.ErrForbidden)
+	}
+	g := domain.Grants{Principal: principal}
+	if principal.Name == domain.BootstrapPrincipal {
+		return g, nil
+	}
+	roles, err := a.store.RolesOf(ctx, principal.Name)
+	if err != nil {
+		return domain.Grants{}, err
+	}
+	g.Roles = roles
+	return g, nil
+}
+
+// require returns an error wrapping domain.ErrForbidden unless the context's
+// principal may act with scope on every one of resources. A change carrying
+// an emergency override of change freezes, set with port.WithEmergency, needs
+// admin rather than write: only those who may administer a flag can push
+// changes to it through a freeze.
+func (a accessControl) require(ctx context.Context, scope domain.Scope, resources ...domain.Resource) error {
+	g, err := a.grants(ctx)
+	if err != nil {
+		return err
+	}
+	if scope == domain.ScopeFlagsWrite && port.EmergencyFrom(ctx) != "" {
+		scope = domain.ScopeAdmin
+	}
+	for _, r := range resources {
+		if !g.Allows(scope, r) {
+			return a.denied(ctx, g, scope, r.String())
+		}
+	}
+	return nil
+}
+
+// requireSome returns the principal's grants when it may act with scope on
+// something in project, or anywhere when project is empty.
+func (a accessControl) requireSome(ctx context.Context, scope domain.Scope, project string) (domain.Grants, error) {
+	g, err := a.grants(ctx)
+	if err != nil {
+		return domain.Grants{}, err
+	}
+	if !g.AllowsSome(scope, project) {
+		what := "anything"
+		if project != "" {
+			what = domain.Resource{Project: project}.String()
+		}
+		return domain.Grants{}, a.denied(ctx, g, scope, what)
+	}
+	return g, nil
+}
+
+// denied logs and returns the refusal of scope on what.
+func (a accessControl) denied(ctx context.Context, g domain.Grants, scope domain.Scope, what string) error {
+	a.logger.InfoContext(ctx, "access denied", "principal", g.Principal.Name, "scope", scope, "resource", what)
+	return fmt.Errorf("principal %q may not use %s on %s: %w", g.Principal.Name, scope, what, domain.ErrForbidden)
+}
+
+// flagTags maps the names of the flags that exist in project to their tags,
+// which tag selectors match. Tags are shared by every environment; env only
+// has to exist.
+func flagTags(ctx context.Context, store port.FlagStore, project, env string, names ...string) (map[string][]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	flags, err := store.GetByNames(ctx, project, env, names)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string, len(flags))
+	for _, flag := range flags {
+		out[flag.Name] = flag.Tags
+	}
+	return out, nil
+}
+
+func roleFromRequest(req port.RoleRequest) domain.Role {
+	role := domain.Role{Name: req.Name, Description: req.Description, Permissions: make([]domain.Permission, len(req.Permissions))}
+	for i, p := range req.Permissions {
+		role.Permissions[i] = domain.Permission{
+			Scope:        domain.Scope(p.Scope),
+			Projects:     p.Projects,
+			Environments: p.Environments,
+			Flags:        p.Flags,
+			Tags:         p.Tags,
+		}
+	}
+	return role
+}
+
+func roleToResponse(role domain.Role) *port.RoleResponse {
+	resp := &port.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: make([]port.PermissionRequest, len(role.Permissions)),
+		CreatedBy:   role.CreatedBy,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+	for i, p := range role.Permissions {
+		resp.Permissions[i] = port.PermissionRequest{
+			Scope:        string(p.Scope),
+			Projects:     p.Projects,
+			Environments: p.Environments,
+			Flags:        p.Flags,
+			Tags:         p.Tags,
+		}
+	}
+	return resp
+}
+
+func roleBindingToResponse(binding domain.RoleBinding) *port.RoleBindingResponse {
+	return &port.RoleBindingResponse{
+		ID:        binding.ID,
+		Principal: binding.Principal,
+		Role:      binding.Role,
+		CreatedBy: binding.CreatedBy,
+		CreatedAt: binding.CreatedAt,
+	}
+}
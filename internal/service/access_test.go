@@ -0,0 +1,470 @@
+package service_test
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+var _ port.AccessStore = (*fakeAccessStore)(nil)
+
+// fakeAccessStore keeps roles and bindings in memory.
+type fakeAccessStore struct {
+	roles    map[string]domain.Role
+	bindings []domain.RoleBinding
+	seq      int64
+}
+
+func newFakeAccessStore(roles ...domain.Role) *fakeAccessStore {
+	f := &fakeAccessStore{roles: make(map[string]domain.Role)}
+	for _, role := range roles {
+		f.roles[role.Name] = role
+	}
+	return f
+}
+
+func (f *fakeAccessStore) bind(principal, role string) *fakeAccessStore {
+	f.seq++
+	f.bindings = append(f.bindings, domain.RoleBinding{ID: f.seq, Principal: principal, Role: role})
+	return f
+}
+
+func (f *fakeAccessStore) CreateRole(_ context.Context, role domain.Role) error {
+	if _, ok := f.roles[role.Name]; ok {
+		return domain.ErrRoleExists
+	}
+	f.roles[role.Name] = role
+	return nil
+}
+
+func (f *fakeAccessStore) GetRole(_ context.Context, name string) (*domain.Role, error) {
+	role, ok := f.roles[name]
+	if !ok {
+		return nil, domain.ErrRoleNotFound
+	}
+	return &role, nil
+}
+
+func (f *fakeAccessStore) ListRoles(context.Context) ([]domain.Role, error) {
+	var out []domain.Role
+	for _, role := range f.roles {
+		out = append(out, role)
+	}
+	slices.SortFunc(out, func(a, b domain.Role) int { return cmp.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (f *fakeAccessStore) UpdateRole(_ context.Context, role domain.Role) error {
+	if _, ok := f.roles[role.Name]; !ok {
+		return domain.ErrRoleNotFound
+	}
+	f.roles[role.Name] = role
+	return nil
+}
+
+func (f *fakeAccessStore) DeleteRole(_ context.Context, name string) error {
+	if _, ok := f.roles[name]; !ok {
+		return domain.ErrRoleNotFound
+	}
+	delete(f.roles, name)
+	f.bindings = slices.DeleteFunc(f.bindings, func(b domain.RoleBinding) bool { return b.Role == name })
+	return nil
+}
+
+func (f *fakeAccessStore) CreateRoleBinding(_ context.Context, binding domain.RoleBinding) (*domain.RoleBinding, error) {
+	if _, ok := f.roles[binding.Role]; !ok {
+		return nil, domain.ErrRoleNotFound
+	}
+	for _, b := range f.bindings {
+		if b.Principal == binding.Principal && b.Role == binding.Role {
+			return nil, domain.ErrRoleBindingExists
+		}
+	}
+	f.seq++
+	binding.ID = f.seq
+	f.bindings = append(f.bindings, binding)
+	return &binding, nil
+}
+
+func (f *fakeAccessStore) ListRoleBindings(_ context.Context, principal string) ([]domain.RoleBinding, error) {
+	var out []domain.RoleBinding
+	for _, b := range f.bindings {
+		if principal == "" || b.Principal == principal {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAccessStore) DeleteRoleBinding(_ context.Context, id int64) error {
+	n := len(f.bindings)
+	f.bindings = slices.DeleteFunc(f.bindings, func(b domain.RoleBinding) bool { return b.ID == id })
+	if len(f.bindings) == n {
+		return domain.ErrRoleBindingNotFound
+	}
+	return nil
+}
+
+func (f *fakeAccessStore) RolesOf(_ context.Context, principal string) ([]domain.Role, error) {
+	var out []domain.Role
+	for _, b := range f.bindings {
+		if b.Principal == principal {
+			out = append(out, f.roles[b.Role])
+		}
+	}
+	return out, nil
+}
+
+// checkoutOwners may change the flags tagged checkout in the default project
+// and evaluate every flag there.
+func checkoutOwners() domain.Role {
+	return domain.Role{Name: "checkout-owners", Permissions: []domain.Permission{
+		{Scope: domain.ScopeFlagsWrite, Projects: []string{domain.DefaultProject}, Tags: []string{"checkout"}},
+		{Scope: domain.ScopeFlagsEvaluate, Projects: []string{domain.DefaultProject}},
+	}}
+}
+
+// asPrincipal authenticates the context as name holding scopes.
+func asPrincipal(name string, scopes ...domain.Scope) context.Context {
+	ctx := port.WithActor(context.Background(), name)
+	return port.WithPrincipal(ctx, domain.Principal{Name: name, Scopes: scopes})
+}
+
+// newAuthorizedService guards a service over a store holding a checkout
+// flag and a search flag.
+func newAuthorizedService(t *testing.T, access *fakeAccessStore) (*service.AuthorizedService, *fakeFlagStore) {
+	t.Helper()
+	store := newFakeFlagStore()
+	boolVal := true
+	for name, tag := range map[string]string{"new-checkout": "checkout", "fuzzy-search": "search"} {
+		require.NoError(t, store.Create(context.Background(), domain.Flag{
+			Name: name, Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &boolVal}, Tags: []string{tag}, Version: 1,
+		}))
+	}
+	logger := service.WithLogger(slog.New(slog.DiscardHandler))
+	return service.NewAuthorizedService(newService(store, newFakeFlagCache()), store, access, logger), store
+}
+
+func TestAuthorizedService_UpdateFlagValue(t *testing.T) {
+	t.Parallel()
+
+	access := newFakeAccessStore(checkoutOwners()).bind("checkout-team", "checkout-owners")
+	write := []domain.Scope{domain.ScopeFlagsWrite}
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		flag    string
+		wantErr bool
+	}{
+		{name: "own flag", ctx: asPrincipal("checkout-team", write...), flag: "new-checkout"},
+		{name: "another team's flag", ctx: asPrincipal("checkout-team", write...), flag: "fuzzy-search", wantErr: true},
+		{name: "key without write", ctx: asPrincipal("checkout-team", domain.ScopeFlagsRead), flag: "new-checkout", wantErr: true},
+		{name: "principal without roles", ctx: asPrincipal("search-team", write...), flag: "fuzzy-search", wantErr: true},
+		{name: "unauthenticated", ctx: context.Background(), flag: "new-checkout", wantErr: true},
+		{name: "bootstrap", ctx: asPrincipal(domain.BootstrapPrincipal, domain.ScopeAdmin), flag: "fuzzy-search"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc, store := newAuthorizedService(t, access)
+			off := false
+
+			_, err := svc.UpdateFlagValue(tt.ctx, tt.flag, port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &off}})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, domain.ErrForbidden)
+				assert.True(t, *store.flags[tt.flag].Value.Bool, "a refused change is not applied")
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, *store.flags[tt.flag].Value.Bool)
+		})
+	}
+}
+
+func TestAuthorizedService_EmergencyOverrideNeedsAdmin(t *testing.T) {
+	t.Parallel()
+
+	checkoutAdmins := domain.Role{Name: "checkout-admins", Permissions: []domain.Permission{
+		{Scope: domain.ScopeAdmin, Projects: []string{domain.DefaultProject}, Tags: []string{"checkout"}},
+	}}
+	access := newFakeAccessStore(checkoutOwners(), checkoutAdmins).
+		bind("checkout-team", "checkout-owners").
+		bind("checkout-lead", "checkout-admins")
+	svc, store := newAuthorizedService(t, access)
+	off := false
+	req := port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &off}}
+
+	writer := port.WithEmergency(asPrincipal("checkout-team", domain.ScopeFlagsWrite), "incident 4711")
+	_, err := svc.UpdateFlagValue(writer, "new-checkout", req)
+	require.ErrorIs(t, err, domain.ErrForbidden, "write alone does not override a freeze")
+	assert.True(t, *store.flags["new-checkout"].Value.Bool)
+
+	lead := port.WithEmergency(asPrincipal("checkout-lead", domain.ScopeAdmin), "incident 4711")
+	_, err = svc.UpdateFlagValue(lead, "new-checkout", req)
+	require.NoError(t, err)
+	assert.False(t, *store.flags["new-checkout"].Value.Bool)
+}
+
+func TestAuthorizedExperimentService(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	flags := newFakeFlagStore()
+	for name, tag := range map[string]string{"new-checkout": "checkout", "fuzzy-search": "search"} {
+		require.NoError(t, flags.Create(context.Background(), domain.Flag{
+			Name: name, Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Tags: []string{tag}, Version: 1,
+			Variants: []domain.Variant{{Key: "control", Value: domain.FlagValue{Bool: &off}}, {Key: "treatment", Value: domain.FlagValue{Bool: &on}}},
+		}))
+	}
+	experiments := newFakeExperimentStore(flags)
+	next := newExperimentService(experiments, flags, &fakeEventStore{}, newFakeFlagCache())
+	access := newFakeAccessStore(checkoutOwners()).bind("checkout-team", "checkout-owners")
+	svc := service.NewAuthorizedExperimentService(next, experiments, flags, access, service.WithLogger(slog.New(slog.DiscardHandler)))
+	variants := []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	_, err := next.CreateExperiment(context.Background(), port.CreateExperimentRequest{Key: "search-test", FlagName: "fuzzy-search", Allocation: 50, Variants: variants})
+	require.NoError(t, err)
+	team := asPrincipal("checkout-team", domain.ScopeFlagsWrite)
+
+	_, err = svc.CreateExperiment(team, port.CreateExperimentRequest{Key: "checkout-test", FlagName: "new-checkout", Allocation: 50, Variants: variants})
+	require.NoError(t, err)
+	_, err = svc.CreateExperiment(team, port.CreateExperimentRequest{Key: "search-test-2", FlagName: "fuzzy-search", Allocation: 50, Variants: variants})
+	require.ErrorIs(t, err, domain.ErrForbidden, "another team's flag")
+
+	started, err := svc.StartExperiment(team, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, "running", started.Status)
+	_, err = svc.StartExperiment(team, "search-test")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	assert.Equal(t, domain.ExperimentDraft, experiments.experiments["search-test"].Status, "a refused start is not applied")
+	_, err = svc.GetExperiment(team, "search-test")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.GetExperimentResults(team, "search-test", "purchase")
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	list, err := svc.ListExperiments(team, port.ExperimentFilter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "checkout-test", list[0].Key)
+
+	_, err = svc.GetExperiment(asPrincipal("stranger", domain.ScopeFlagsRead), "ghost")
+	require.ErrorIs(t, err, domain.ErrForbidden, "a principal without roles cannot probe keys")
+}
+
+func TestAuthorizedService_CreateFlag(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newAuthorizedService(t, newFakeAccessStore(checkoutOwners()).bind("checkout-team", "checkout-owners"))
+	ctx := asPrincipal("checkout-team", domain.ScopeFlagsWrite)
+	boolVal := true
+	req := port.CreateFlagRequest{Name: "express-checkout", Type: "boolean", Value: port.FlagValue{Bool: &boolVal}}
+
+	_, err := svc.CreateFlag(ctx, req)
+	require.ErrorIs(t, err, domain.ErrForbidden, "an untagged flag is not the team's")
+
+	req.Tags = []string{"checkout"}
+	_, err = svc.CreateFlag(ctx, req)
+	require.NoError(t, err)
+}
+
+func TestAuthorizedService_EvaluateAll_FiltersFlags(t *testing.T) {
+	t.Parallel()
+
+	role := domain.Role{Name: "checkout-clients", Permissions: []domain.Permission{
+		{Scope: domain.ScopeFlagsEvaluate, Tags: []string{"checkout"}},
+	}}
+	svc, _ := newAuthorizedService(t, newFakeAccessStore(role).bind("storefront", "checkout-clients"))
+
+	resp, err := svc.EvaluateAll(asPrincipal("storefront", domain.ScopeFlagsEvaluate), port.EvaluationContext{TargetingKey: "user-1"}, port.EvaluationFilter{})
+
+	require.NoError(t, err)
+	assert.Contains(t, resp.Flags, "new-checkout")
+	assert.NotContains(t, resp.Flags, "fuzzy-search")
+
+	_, err = svc.EvaluateAll(asPrincipal("stranger", domain.ScopeFlagsEvaluate), port.EvaluationContext{}, port.EvaluationFilter{})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+}
+
+func TestAuthorizedService_EvaluateAll_RecordsNoExposureForHiddenFlags(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	flags := newFakeFlagStore()
+	for name, tag := range map[string]string{"new-checkout": "checkout", "fuzzy-search": "search"} {
+		require.NoError(t, flags.Create(context.Background(), domain.Flag{
+			Name: name, Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Tags: []string{tag}, Version: 1,
+			Variants: []domain.Variant{{Key: "control", Value: domain.FlagValue{Bool: &off}}, {Key: "treatment", Value: domain.FlagValue{Bool: &on}}},
+		}))
+	}
+	events := &fakeEventStore{}
+	experiments := newExperimentService(newFakeExperimentStore(flags), flags, events, newFakeFlagCache())
+	ctx := context.Background()
+	_, err := experiments.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "search-test", FlagName: "fuzzy-search", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+	_, err = experiments.StartExperiment(ctx, "search-test")
+	require.NoError(t, err)
+
+	role := domain.Role{Name: "checkout-clients", Permissions: []domain.Permission{
+		{Scope: domain.ScopeFlagsEvaluate, Tags: []string{"checkout"}},
+	}}
+	next := newService(flags, newFakeFlagCache(), service.WithEventStore(events))
+	svc := service.NewAuthorizedService(next, flags, newFakeAccessStore(role).bind("storefront", "checkout-clients"),
+		service.WithLogger(slog.New(slog.DiscardHandler)))
+
+	resp, err := svc.EvaluateAll(asPrincipal("storefront", domain.ScopeFlagsEvaluate), port.EvaluationContext{TargetingKey: "user-1"}, port.EvaluationFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new-checkout"}, slices.Collect(maps.Keys(resp.Flags)))
+	assert.Empty(t, events.exposures, "a flag the principal may not evaluate is not evaluated")
+
+	resp, err = svc.EvaluateAll(asPrincipal("storefront", domain.ScopeFlagsEvaluate), port.EvaluationContext{TargetingKey: "user-1"},
+		port.EvaluationFilter{Names: []string{"fuzzy-search"}})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Flags)
+	assert.Empty(t, events.exposures)
+}
+
+func TestAuthorizedService_ChangeRequests_CannotProbeIDs(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newAuthorizedService(t, newFakeAccessStore(checkoutOwners()).bind("checkout-team", "checkout-owners"))
+
+	stranger := asPrincipal("stranger", domain.ScopeFlagsWrite)
+	_, err := svc.GetChangeRequest(stranger, 404)
+	require.ErrorIs(t, err, domain.ErrForbidden, "a principal without roles cannot probe ids")
+	_, err = svc.ApproveChangeRequest(stranger, 404)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.RejectChangeRequest(stranger, 404)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	_, err = svc.ApproveChangeRequest(asPrincipal("checkout-team", domain.ScopeFlagsWrite), 404)
+	require.ErrorIs(t, err, domain.ErrChangeRequestNotFound)
+}
+
+func TestAuthorizedService_AdministrationNeedsAdminEverywhere(t *testing.T) {
+	t.Parallel()
+
+	projectAdmin := domain.Role{Name: "shop-admins", Permissions: []domain.Permission{
+		{Scope: domain.ScopeAdmin, Projects: []string{domain.DefaultProject}},
+	}}
+	svc, _ := newAuthorizedService(t, newFakeAccessStore(projectAdmin).bind("shop-lead", "shop-admins"))
+	ctx := asPrincipal("shop-lead", domain.ScopeAdmin)
+
+	_, err := svc.CreateProject(ctx, port.CreateProjectRequest{Key: "search"})
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	_, err = svc.SetFlagProtection(ctx, "new-checkout", port.FlagProtectionRequest{RequiredApprovals: 2})
+	require.NoError(t, err, "a project admin may protect the project's flags")
+
+	projects, err := svc.ListProjects(ctx)
+	require.NoError(t, err)
+	assert.Len(t, projects, 1)
+}
+
+func TestAuthorizedWebhookService_RequiresAdminEverywhere(t *testing.T) {
+	t.Parallel()
+
+	projectAdmin := domain.Role{Name: "shop-admins", Permissions: []domain.Permission{
+		{Scope: domain.ScopeAdmin, Projects: []string{domain.DefaultProject}},
+	}}
+	globalAdmin := domain.Role{Name: "admins", Permissions: []domain.Permission{{Scope: domain.ScopeAdmin}}}
+	access := newFakeAccessStore(projectAdmin, globalAdmin).bind("shop-lead", "shop-admins").bind("platform", "admins")
+	store := &fakeWebhookStore{}
+	svc := service.NewAuthorizedWebhookService(newWebhookService(store, &fakeSender{}), access, service.WithLogger(slog.New(slog.DiscardHandler)))
+	req := port.WebhookRequest{URL: "https://hooks.example.com/flags"}
+
+	lead := asPrincipal("shop-lead", domain.ScopeAdmin)
+	_, err := svc.CreateWebhook(lead, req)
+	require.ErrorIs(t, err, domain.ErrForbidden, "a webhook sees every project's changes")
+	_, err = svc.ListWebhooks(lead)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	require.ErrorIs(t, svc.DeleteWebhook(lead, 1), domain.ErrForbidden)
+	_, err = svc.RetryWebhookDelivery(lead, 1, 1)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+
+	created, err := svc.CreateWebhook(asPrincipal("platform", domain.ScopeAdmin), req)
+	require.NoError(t, err)
+	require.ErrorIs(t, svc.DeleteWebhook(lead, created.ID), domain.ErrForbidden)
+}
+
+func TestAccessService_Roles(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := newFakeAccessStore()
+	svc := service.NewAccessService(store, service.WithClock(fakeClock{now: now}), service.WithLogger(slog.New(slog.DiscardHandler)))
+	admin := asPrincipal(domain.BootstrapPrincipal, domain.ScopeAdmin)
+	req := port.RoleRequest{Name: "checkout-owners", Permissions: []port.PermissionRequest{
+		{Scope: "flags:write", Projects: []string{"shop"}, Tags: []string{"checkout"}},
+	}}
+
+	created, err := svc.CreateRole(admin, req)
+	require.NoError(t, err)
+	assert.Equal(t, domain.BootstrapPrincipal, created.CreatedBy)
+	assert.Equal(t, now, created.CreatedAt)
+	assert.Equal(t, req.Permissions, created.Permissions)
+
+	binding, err := svc.CreateRoleBinding(admin, port.RoleBindingRequest{Principal: "checkout-team", Role: "checkout-owners"})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.RoleBinding{{
+		ID: binding.ID, Principal: "checkout-team", Role: "checkout-owners", CreatedBy: domain.BootstrapPrincipal, CreatedAt: now,
+	}}, store.bindings)
+
+	_, err = svc.CreateRole(admin, port.RoleRequest{Name: "everything", Permissions: []port.PermissionRequest{{Scope: "flags:delete"}}})
+	require.ErrorIs(t, err, domain.ErrInvalidRole)
+	_, err = svc.CreateRoleBinding(admin, port.RoleBindingRequest{Principal: "checkout-team", Role: "checkout-owners"})
+	require.ErrorIs(t, err, domain.ErrRoleBindingExists)
+
+	require.NoError(t, svc.DeleteRole(admin, "checkout-owners"))
+	assert.Empty(t, store.bindings, "bindings go with their role")
+}
+
+func TestAccessService_FlagSelectorsFollowNamingPolicy(t *testing.T) {
+	t.Parallel()
+
+	naming := domain.DefaultNamingPolicy()
+	naming.Separators = "-."
+	svc := service.NewAccessService(newFakeAccessStore(), service.WithNamingPolicy(naming), service.WithLogger(slog.New(slog.DiscardHandler)))
+	admin := asPrincipal(domain.BootstrapPrincipal, domain.ScopeAdmin)
+
+	_, err := svc.CreateRole(admin, port.RoleRequest{Name: "checkout-owners", Permissions: []port.PermissionRequest{
+		{Scope: "flags:write", Flags: []string{"checkout.express"}},
+	}})
+	require.NoError(t, err)
+}
+
+func TestAccessService_RequiresAdminEverywhere(t *testing.T) {
+	t.Parallel()
+
+	projectAdmin := domain.Role{Name: "shop-admins", Permissions: []domain.Permission{
+		{Scope: domain.ScopeAdmin, Projects: []string{"shop"}},
+	}}
+	globalAdmin := domain.Role{Name: "admins", Permissions: []domain.Permission{{Scope: domain.ScopeAdmin}}}
+	store := newFakeAccessStore(projectAdmin, globalAdmin).bind("shop-lead", "shop-admins").bind("platform", "admins")
+	svc := service.NewAccessService(store, service.WithLogger(slog.New(slog.DiscardHandler)))
+	escalate := port.RoleBindingRequest{Principal: "shop-lead", Role: "admins"}
+
+	_, err := svc.CreateRoleBinding(asPrincipal("shop-lead", domain.ScopeAdmin), escalate)
+	require.ErrorIs(t, err, domain.ErrForbidden, "a project admin cannot widen its own access")
+
+	_, err = svc.ListRoles(asPrincipal("platform", domain.ScopeFlagsWrite))
+	require.ErrorIs(t, err, domain.ErrForbidden, "the key's scopes cap the role")
+
+	_, err = svc.CreateRoleBinding(asPrincipal("platform", domain.ScopeAdmin), escalate)
+	require.NoError(t, err)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.APIKeyService = (*APIKeyService)(nil)
+
+// tokenBytes is the number of random bytes in an API key token.
+const tokenBytes = 32
+
+// APIKeyService issues API keys and authenticates requests by them. Tokens
+// are only returned when issued; the store keeps their hashes. Only a
+// principal that may administer everything can manage keys, since a key
+// lets its holder act as any principal.
+type APIKeyService struct {
+	store port.APIKeyStore
+	// admin is the hash of the bootstrap admin token, or nil without one.
+	admin  []byte
+	access accessControl
+	clock  port.Clock
+	logger *slog.Logger
+}
+
+func NewAPIKeyService(store port.APIKeyStore, access port.AccessStore, opts ...Option) *APIKeyService {
+	o := newOptions(opts)
+	s := &APIKeyService{
+		store:  store,
+		access: accessControl{store: access, logger: o.logger},
+		clock:  o.clock,
+		logger: o.logger,
+	}
+	if o.adminKey != "" {
+		s.admin = domain.HashAPIKey(o.adminKey)
+	}
+	return s
+}
+
+// CreateAPIKey issues a key attributed to the context's actor.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req port.APIKeyRequest) (*port.APIKeyResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	key, token := s.newKey(port.ActorFrom(ctx))
+	key.Principal = req.Principal
+	if !req.ExpiresAt.IsZero() {
+		key.ExpiresAt = req.ExpiresAt.UTC()
+	}
+	for _, scope := range req.Scopes {
+		key.Scopes = append(key.Scopes, domain.Scope(scope))
+	}
+	if err := domain.ValidateAPIKey(key); err != nil {
+		return nil, err
+	}
+	created, err := s.store.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "api key created", "key", created.ID, "principal", created.Principal, "actor", key.CreatedBy)
+	resp := apiKeyToResponse(*created)
+	resp.Token = token
+	return &resp, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]port.APIKeyResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	keys, err := s.store.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		out[i] = apiKeyToResponse(key)
+	}
+	return out, nil
+}
+
+func (s *APIKeyService) GetAPIKey(ctx context.Context, id int64) (*port.APIKeyResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	key, err := s.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := apiKeyToResponse(*key)
+	return &resp, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int64) (*port.APIKeyResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	key, err := s.store.RevokeAPIKey(ctx, id, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "api key revoked", "key", id, "principal", key.Principal, "actor", port.ActorFrom(ctx))
+	resp := apiKeyToResponse(*key)
+	return &resp, nil
+}
+
+// RotateAPIKey gives the replacement the old key's expiry, so rotating never
+// extends a key's life.
+func (s *APIKeyService) RotateAPIKey(ctx context.Context, id int64, grace time.Duration) (*port.APIKeyResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	if grace < 0 || grace > domain.MaxRotationGrace {
+		return nil, fmt.Errorf("grace period must be between 0 and %s: %w", domain.MaxRotationGrace, domain.ErrInvalidAPIKey)
+	}
+	old, err := s.store.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now().UTC()
+	if !old.Active(now) {
+		return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyRevoked)
+	}
+	key, token := s.newKey(port.ActorFrom(ctx))
+	key.Principal, key.Scopes, key.ExpiresAt = old.Principal, old.Scopes, old.ExpiresAt
+	created, err := s.store.RotateAPIKey(ctx, id, now.Add(grace), key)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "api key rotated", "key", id, "replacement", created.ID, "principal", created.Principal,
+		"actor", key.CreatedBy)
+	resp := apiKeyToResponse(*created)
+	resp.Token = token
+	return &resp, nil
+}
+
+// Authenticate tells unknown, revoked and expired keys apart only in the
+// log, so callers cannot probe which keys exist.
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	hash := domain.HashAPIKey(token)
+	if s.admin != nil && subtle.ConstantTimeCompare(hash, s.admin) == 1 {
+		return &domain.Principal{Name: domain.BootstrapPrincipal, Scopes: []domain.Scope{domain.ScopeAdmin}}, nil
+	}
+	if !domain.LooksLikeAPIKey(token) {
+		return nil, domain.ErrUnauthenticated
+	}
+	key, err := s.store.GetAPIKeyByHash(ctx, hash)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(s.clock.Now()) {
+		s.logger.InfoContext(ctx, "inactive api key used", "key", key.ID, "principal", key.Principal)
+		return nil, domain.ErrUnauthenticated
+	}
+	return &domain.Principal{Name: key.Principal, KeyID: key.ID, Scopes: key.Scopes}, nil
+}
+
+// newKey returns a key with a fresh token, created now by actor, and the
+// token.
+func (s *APIKeyService) newKey(actor string) (domain.APIKey, string) {
+	token := domain.APIKeyPrefix + randomHex(tokenBytes)
+	return domain.APIKey{
+		Hint:      token[:domain.APIKeyHintLength],
+		Hash:      domain.HashAPIKey(token),
+		CreatedBy: actor,
+		CreatedAt: s.clock.Now().UTC(),
+	}, token
+}
+
+func apiKeyToResponse(key domain.APIKey) port.APIKeyResponse {
+	resp := port.APIKeyResponse{
+		ID:        key.ID,
+		Principal: key.Principal,
+		Scopes:    make([]string, len(key.Scopes)),
+		Hint:      key.Hint,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+		RevokedAt: key.RevokedAt,
+	}
+	for i, scope := range key.Scopes {
+		resp.Scopes[i] = string(scope)
+	}
+	return resp
+}
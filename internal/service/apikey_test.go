@@ -0,0 +1,229 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+var _ port.APIKeyStore = (*fakeAPIKeyStore)(nil)
+
+type fakeAPIKeyStore struct {
+	keys []domain.APIKey
+}
+
+func (f *fakeAPIKeyStore) CreateAPIKey(_ context.Context, key domain.APIKey) (*domain.APIKey, error) {
+	key.ID = int64(len(f.keys) + 1)
+	f.keys = append(f.keys, key)
+	return &key, nil
+}
+
+func (f *fakeAPIKeyStore) GetAPIKey(_ context.Context, id int64) (*domain.APIKey, error) {
+	for _, key := range f.keys {
+		if key.ID == id {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyNotFound)
+}
+
+func (f *fakeAPIKeyStore) GetAPIKeyByHash(_ context.Context, hash []byte) (*domain.APIKey, error) {
+	for _, key := range f.keys {
+		if bytes.Equal(key.Hash, hash) {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("api key: %w", domain.ErrAPIKeyNotFound)
+}
+
+func (f *fakeAPIKeyStore) ListAPIKeys(context.Context) ([]domain.APIKey, error) {
+	return f.keys, nil
+}
+
+func (f *fakeAPIKeyStore) RevokeAPIKey(_ context.Context, id int64, at time.Time) (*domain.APIKey, error) {
+	for i, key := range f.keys {
+		if key.ID != id {
+			continue
+		}
+		if !key.RevokedAt.IsZero() {
+			return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyRevoked)
+		}
+		f.keys[i].RevokedAt = at
+		return &f.keys[i], nil
+	}
+	return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyNotFound)
+}
+
+func (f *fakeAPIKeyStore) RotateAPIKey(ctx context.Context, id int64, expiresAt time.Time, replacement domain.APIKey) (*domain.APIKey, error) {
+	for i, key := range f.keys {
+		if key.ID != id {
+			continue
+		}
+		if !key.RevokedAt.IsZero() {
+			return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyRevoked)
+		}
+		if key.ExpiresAt.IsZero() || expiresAt.Before(key.ExpiresAt) {
+			f.keys[i].ExpiresAt = expiresAt
+		}
+		return f.CreateAPIKey(ctx, replacement)
+	}
+	return nil, fmt.Errorf("api key %d: %w", id, domain.ErrAPIKeyNotFound)
+}
+
+func newAPIKeyService(store *fakeAPIKeyStore, now time.Time, opts ...service.Option) *service.APIKeyService {
+	opts = append([]service.Option{service.WithLogger(slog.New(slog.DiscardHandler)), service.WithClock(fakeClock{now: now})}, opts...)
+	admins := domain.Role{Name: "admins", Permissions: []domain.Permission{{Scope: domain.ScopeAdmin}}}
+	return service.NewAPIKeyService(store, newFakeAccessStore(admins).bind("alice", "admins"), opts...)
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.APIKeyRequest
+		wantErr error
+	}{
+		{name: "creates", req: port.APIKeyRequest{Principal: "checkout-team", Scopes: []string{"flags:write"}}},
+		{name: "unknown scope", req: port.APIKeyRequest{Principal: "checkout-team", Scopes: []string{"flags:all"}}, wantErr: domain.ErrInvalidAPIKey},
+		{name: "reserved principal", req: port.APIKeyRequest{Principal: "anonymous", Scopes: []string{"flags:read"}}, wantErr: domain.ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &fakeAPIKeyStore{}
+			now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+			svc := newAPIKeyService(store, now)
+			ctx := asPrincipal("alice", domain.ScopeAdmin)
+
+			resp, err := svc.CreateAPIKey(ctx, tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.keys)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(resp.Token, domain.APIKeyPrefix))
+			assert.Equal(t, resp.Token[:domain.APIKeyHintLength], resp.Hint)
+			assert.Equal(t, "alice", resp.CreatedBy)
+			assert.Equal(t, domain.HashAPIKey(resp.Token), store.keys[0].Hash, "only the hash is stored")
+			listed, err := svc.ListAPIKeys(ctx)
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Empty(t, listed[0].Token, "the token is not listed")
+
+			principal, err := svc.Authenticate(ctx, resp.Token)
+			require.NoError(t, err)
+			assert.Equal(t, domain.Principal{Name: "checkout-team", KeyID: resp.ID, Scopes: []domain.Scope{domain.ScopeFlagsWrite}}, *principal)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeAPIKeyStore{keys: []domain.APIKey{
+		{ID: 1, Principal: "ci", Scopes: []domain.Scope{domain.ScopeFlagsRead}, Hash: domain.HashAPIKey("ffk_active-0000")},
+		{ID: 2, Principal: "ci", Scopes: []domain.Scope{domain.ScopeFlagsRead}, Hash: domain.HashAPIKey("ffk_revoked-000"), RevokedAt: now.Add(-time.Hour)},
+		{ID: 3, Principal: "ci", Scopes: []domain.Scope{domain.ScopeFlagsRead}, Hash: domain.HashAPIKey("ffk_expired-000"), ExpiresAt: now},
+	}}
+	svc := newAPIKeyService(store, now, service.WithAdminKey("bootstrap-secret-0123456789abcdef"))
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *domain.Principal
+		wantErr error
+	}{
+		{name: "active", token: "ffk_active-0000", want: &domain.Principal{Name: "ci", KeyID: 1, Scopes: []domain.Scope{domain.ScopeFlagsRead}}},
+		{name: "bootstrap admin", token: "bootstrap-secret-0123456789abcdef", want: &domain.Principal{Name: domain.BootstrapPrincipal, Scopes: []domain.Scope{domain.ScopeAdmin}}},
+		{name: "revoked", token: "ffk_revoked-000", wantErr: domain.ErrUnauthenticated},
+		{name: "expired", token: "ffk_expired-000", wantErr: domain.ErrUnauthenticated},
+		{name: "unknown", token: "ffk_unknown-000", wantErr: domain.ErrUnauthenticated},
+		{name: "not a key", token: "hunter2", wantErr: domain.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := svc.Authenticate(context.Background(), tt.token)
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAPIKeyService_RotateAPIKey(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeAPIKeyStore{}
+	svc := newAPIKeyService(store, now)
+	ctx := asPrincipal("alice", domain.ScopeAdmin)
+	created, err := svc.CreateAPIKey(ctx, port.APIKeyRequest{Principal: "ci", Scopes: []string{"flags:read", "flags:evaluate"}, ExpiresAt: now.Add(30 * 24 * time.Hour)})
+	require.NoError(t, err)
+
+	_, err = svc.RotateAPIKey(ctx, created.ID, 8*24*time.Hour)
+	require.ErrorIs(t, err, domain.ErrInvalidAPIKey, "the grace period is bounded")
+
+	rotated, err := svc.RotateAPIKey(ctx, created.ID, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Token, rotated.Token)
+	assert.Equal(t, created.Scopes, rotated.Scopes)
+	assert.Equal(t, created.ExpiresAt, rotated.ExpiresAt, "rotation does not extend the key's life")
+	assert.Equal(t, now.Add(time.Hour), store.keys[0].ExpiresAt, "the old key works for the grace period")
+	_, err = svc.Authenticate(ctx, created.Token)
+	require.NoError(t, err)
+
+	_, err = svc.RevokeAPIKey(ctx, created.ID)
+	require.NoError(t, err)
+	_, err = svc.RevokeAPIKey(ctx, created.ID)
+	require.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+	_, err = svc.RotateAPIKey(ctx, created.ID, 0)
+	require.ErrorIs(t, err, domain.ErrAPIKeyRevoked)
+	_, err = svc.Authenticate(ctx, created.Token)
+	require.ErrorIs(t, err, domain.ErrUnauthenticated)
+}
+
+func TestAPIKeyService_RequiresAdminEverywhere(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	shopAdmins := domain.Role{Name: "shop-admins", Permissions: []domain.Permission{
+		{Scope: domain.ScopeAdmin, Projects: []string{"shop"}},
+	}}
+	store := &fakeAPIKeyStore{keys: []domain.APIKey{
+		{ID: 1, Principal: "platform", Scopes: []domain.Scope{domain.ScopeAdmin}, Hash: domain.HashAPIKey("ffk_platform-000")},
+	}}
+	access := newFakeAccessStore(shopAdmins).bind("shop-lead", "shop-admins")
+	svc := service.NewAPIKeyService(store, access, service.WithClock(fakeClock{now: now}), service.WithLogger(slog.New(slog.DiscardHandler)))
+	ctx := asPrincipal("shop-lead", domain.ScopeAdmin)
+
+	_, err := svc.CreateAPIKey(ctx, port.APIKeyRequest{Principal: "platform", Scopes: []string{"admin"}})
+	require.ErrorIs(t, err, domain.ErrForbidden, "a project admin cannot mint keys for wider principals")
+	_, err = svc.ListAPIKeys(ctx)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.GetAPIKey(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.RotateAPIKey(ctx, 1, 0)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	_, err = svc.RevokeAPIKey(ctx, 1)
+	require.ErrorIs(t, err, domain.ErrForbidden)
+	assert.Len(t, store.keys, 1)
+	assert.True(t, store.keys[0].RevokedAt.IsZero())
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (s *Service) GetFlagProtection(ctx context.Context, name string) (*port.FlagProtectionResponse, error) {
+	protection, err := s.approvals.GetProtection(ctx, port.ProjectFrom(ctx), name)
+	if err != nil {
+		return nil, err
+	}
+	return protectionToResponse(*protection), nil
+}
+
+// SetFlagProtection replaces the flag's protection. Change requests already
+// pending keep the approval count they were created with.
+func (s *Service) SetFlagProtection(ctx context.Context, name string, req port.FlagProtectionRequest) (*port.FlagProtectionResponse, error) {
+	protection := domain.FlagProtection{
+		Project:           port.ProjectFrom(ctx),
+		FlagName:          name,
+		RequiredApprovals: req.RequiredApprovals,
+		Environments:      req.Environments,
+	}
+	if err := domain.ValidateProtection(protection); err != nil {
+		return nil, err
+	}
+	for _, env := range protection.Environments {
+		if _, err := s.store.GetEnvironment(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.approvals.SetProtection(ctx, protection); err != nil {
+		return nil, err
+	}
+	return protectionToResponse(protection), nil
+}
+
+// requestChange records the value change to a protected flag as a pending
+// change request, attributed to the context's actor, and returns the flag
+// unchanged with the request.
+func (s *Service) requestChange(ctx context.Context, flag domain.Flag, value domain.FlagValue, protection domain.FlagProtection) (*port.FlagResponse, error) {
+	now := s.clock.Now().UTC()
+	cr, err := s.approvals.CreateChangeRequest(ctx, domain.ChangeRequest{
+		Project:           flag.Project,
+		FlagName:          flag.Name,
+		Environment:       flag.Environment,
+		Value:             value,
+		Status:            domain.ChangeRequestPending,
+		RequestedBy:       port.ActorFrom(ctx),
+		RequiredApprovals: protection.RequiredApprovals,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(s.changeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := flagToResponse(flag)
+	resp.PendingChange = changeRequestToResponse(*cr, now)
+	return resp, nil
+}
+
+// protectionGuard refuses changes that would bypass a flag's protection. Only
+// value changes can be requested for approval, so any other change to a flag
+// in an environment its protection covers is refused outright.
+type protectionGuard struct {
+	approvals port.ApprovalStore
+}
+
+// check returns an error wrapping domain.ErrProtected when the protection of
+// one of the flags called names in project, or of any of its flags when names
+// is empty, covers env.
+func (g protectionGuard) check(ctx context.Context, project, env string, names ...string) error {
+	protections, err := g.approvals.ListProtections(ctx, project)
+	if err != nil {
+		return err
+	}
+	for _, protection := range protections {
+		if protection.Covers(env) && (len(names) == 0 || slices.Contains(names, protection.FlagName)) {
+			return fmt.Errorf("flag %q needs %d approvals in %q, only its value can be changed through a change request: %w",
+				protection.FlagName, protection.RequiredApprovals, env, domain.ErrProtected)
+		}
+	}
+	return nil
+}
+
+func (s *Service) ListChangeRequests(ctx context.Context, filter port.ChangeRequestFilter) ([]port.ChangeRequestResponse, error) {
+	status := domain.ChangeRequestStatus(filter.Status)
+	if status != "" && !slices.Contains(domain.ChangeRequestStatuses, status) {
+		return nil, fmt.Errorf("unknown change request status %q: %w", filter.Status, domain.ErrInvalidChangeQuery)
+	}
+	now := s.clock.Now().UTC()
+	requests, err := s.approvals.ListChangeRequests(ctx, domain.ChangeRequestQuery{
+		Project:  port.ProjectFrom(ctx),
+		FlagName: filter.Flag,
+		Status:   status,
+		At:       now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.ChangeRequestResponse, len(requests))
+	for i, cr := range requests {
+		out[i] = *changeRequestToResponse(cr, now)
+	}
+	return out, nil
+}
+
+func (s *Service) GetChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	cr, err := s.approvals.GetChangeRequest(ctx, port.ProjectFrom(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return changeRequestToResponse(*cr, s.clock.Now().UTC()), nil
+}
+
+// ApproveChangeRequest approves the change request as the context's actor.
+// When the approval completes it, the applied value is written through to the
+// cache. Only the completing approval is subject to change freezes, so
+// requests can collect approvals while frozen.
+func (s *Service) ApproveChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	now := s.clock.Now().UTC()
+	project := port.ProjectFrom(ctx)
+	pending, err := s.approvals.GetChangeRequest(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending.Approvals)+1 >= pending.RequiredApprovals {
+		named := s.freezes.named(project, pending.Environment, pending.FlagName)
+		if err := s.freezes.check(ctx, project, []string{pending.Environment}, named); err != nil {
+			return nil, err
+		}
+	}
+	cr, flag, err := s.approvals.ApproveChangeRequest(ctx, project, id, port.ActorFrom(ctx), now)
+	if err != nil {
+		return nil, err
+	}
+	if flag != nil {
+		s.writeThrough(ctx, *flag)
+	}
+	return changeRequestToResponse(*cr, now), nil
+}
+
+func (s *Service) RejectChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	now := s.clock.Now().UTC()
+	cr, err := s.approvals.RejectChangeRequest(ctx, port.ProjectFrom(ctx), id, port.ActorFrom(ctx), now)
+	if err != nil {
+		return nil, err
+	}
+	return changeRequestToResponse(*cr, now), nil
+}
+
+func protectionToResponse(protection domain.FlagProtection) *port.FlagProtectionResponse {
+	return &port.FlagProtectionResponse{
+		Project:           protection.Project,
+		Flag:              protection.FlagName,
+		RequiredApprovals: protection.RequiredApprovals,
+		Environments:      protection.Environments,
+	}
+}
+
+// changeRequestToResponse reports the request's status as of now, so a
+// pending request past its expiry reads as expired.
+func changeRequestToResponse(cr domain.ChangeRequest, now time.Time) *port.ChangeRequestResponse {
+	resp := &port.ChangeRequestResponse{
+		ID:                cr.ID,
+		Project:           cr.Project,
+		Flag:              cr.FlagName,
+		Environment:       cr.Environment,
+		Value:             port.FlagValue{Bool: cr.Value.Bool, Numeric: cr.Value.Numeric},
+		Status:            string(cr.StatusAt(now)),
+		RequestedBy:       cr.RequestedBy,
+		RequiredApprovals: cr.RequiredApprovals,
+		Approvals:         make([]port.ApprovalResponse, len(cr.Approvals)),
+		DecidedBy:         cr.DecidedBy,
+		CreatedAt:         cr.CreatedAt,
+		ExpiresAt:         cr.ExpiresAt,
+		DecidedAt:         cr.DecidedAt,
+	}
+	for i, approval := range cr.Approvals {
+		resp.Approvals[i] = port.ApprovalResponse{Approver: approval.Approver, ApprovedAt: approval.ApprovedAt}
+	}
+	return resp
+}
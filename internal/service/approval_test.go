@@ -0,0 +1,298 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+// protectedFlag creates new-checkout, off, in a store where it needs two
+// approvals in production, and returns the store.
+func protectedFlag(t *testing.T) *fakeFlagStore {
+	t.Helper()
+	off := false
+	store := newFakeFlagStore()
+	store.environments["staging"] = domain.Environment{Key: "staging", Parent: domain.DefaultEnvironment}
+	store.envs["staging"] = make(map[string]domain.Flag)
+	svc := newService(store, newFakeFlagCache())
+	ctx := context.Background()
+	_, err := svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	_, err = svc.SetFlagProtection(ctx, "new-checkout", port.FlagProtectionRequest{RequiredApprovals: 2, Environments: []string{domain.DefaultEnvironment}})
+	require.NoError(t, err)
+	return store
+}
+
+func TestService_SetFlagProtection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		flag    string
+		req     port.FlagProtectionRequest
+		wantErr error
+	}{
+		{name: "protects", flag: "new-checkout", req: port.FlagProtectionRequest{RequiredApprovals: 1}},
+		{name: "removes", flag: "new-checkout", req: port.FlagProtectionRequest{}},
+		{name: "too many approvals", flag: "new-checkout", req: port.FlagProtectionRequest{RequiredApprovals: 11}, wantErr: domain.ErrInvalidProtection},
+		{name: "unknown environment", flag: "new-checkout", req: port.FlagProtectionRequest{RequiredApprovals: 1, Environments: []string{"qa"}}, wantErr: domain.ErrEnvironmentNotFound},
+		{name: "unknown flag", flag: "ghost", req: port.FlagProtectionRequest{RequiredApprovals: 1}, wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(protectedFlag(t), newFakeFlagCache())
+			ctx := context.Background()
+
+			resp, err := svc.SetFlagProtection(ctx, tt.flag, tt.req)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.req.RequiredApprovals, resp.RequiredApprovals)
+			got, err := svc.GetFlagProtection(ctx, tt.flag)
+			require.NoError(t, err)
+			assert.Equal(t, resp, got)
+		})
+	}
+}
+
+func TestService_UpdateFlagValue_Protected(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	now := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	store := protectedFlag(t)
+	cache := newFakeFlagCache()
+	svc := newService(store, cache, service.WithClock(fakeClock{now: now}), service.WithChangeRequestTTL(time.Hour))
+	alice := port.WithActor(context.Background(), "alice")
+
+	resp, err := svc.UpdateFlagValue(alice, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	assert.False(t, *resp.Value.Bool, "the change is not applied")
+	require.NotNil(t, resp.PendingChange)
+	assert.Equal(t, "pending", resp.PendingChange.Status)
+	assert.Equal(t, "alice", resp.PendingChange.RequestedBy)
+	assert.Equal(t, 2, resp.PendingChange.RequiredApprovals)
+	assert.Equal(t, now.Add(time.Hour), resp.PendingChange.ExpiresAt)
+	flag, err := svc.GetFlag(alice, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, *flag.Value.Bool)
+
+	staging := port.WithEnvironment(alice, "staging")
+	resp, err = svc.UpdateFlagValue(staging, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	assert.Nil(t, resp.PendingChange, "the flag is not protected in staging")
+	assert.True(t, *resp.Value.Bool)
+}
+
+func TestService_ApproveChangeRequest(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	now := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	store := protectedFlag(t)
+	cache := newFakeFlagCache()
+	svc := newService(store, cache, service.WithClock(fakeClock{now: now}))
+	ctx := context.Background()
+	as := func(actor string) context.Context { return port.WithActor(ctx, actor) }
+
+	resp, err := svc.UpdateFlagValue(as("alice"), "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	id := resp.PendingChange.ID
+
+	_, err = svc.ApproveChangeRequest(as("alice"), id)
+	require.ErrorIs(t, err, domain.ErrApprovalNotAllowed, "self-approval is blocked")
+
+	cr, err := svc.ApproveChangeRequest(as("bob"), id)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", cr.Status)
+	require.Len(t, cr.Approvals, 1)
+	assert.Equal(t, "bob", cr.Approvals[0].Approver)
+	_, err = svc.ApproveChangeRequest(as("bob"), id)
+	require.ErrorIs(t, err, domain.ErrApprovalNotAllowed, "one principal approves once")
+
+	cr, err = svc.ApproveChangeRequest(as("carol"), id)
+	require.NoError(t, err)
+	assert.Equal(t, "applied", cr.Status)
+	assert.Equal(t, "carol", cr.DecidedBy)
+	flag, err := svc.GetFlag(ctx, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *flag.Value.Bool)
+	cached, err := cache.Get(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *cached.Value.Bool, "the applied value is written through")
+
+	_, err = svc.RejectChangeRequest(as("dave"), id)
+	require.ErrorIs(t, err, domain.ErrChangeRequestState)
+	_, err = svc.ApproveChangeRequest(as("bob"), 99)
+	require.ErrorIs(t, err, domain.ErrChangeRequestNotFound)
+}
+
+func TestService_ChangeRequestRejectAndExpire(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	now := time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+	store := protectedFlag(t)
+	svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: now}), service.WithChangeRequestTTL(time.Hour))
+	alice := port.WithActor(context.Background(), "alice")
+
+	first, err := svc.UpdateFlagValue(alice, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	second, err := svc.UpdateFlagValue(alice, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+
+	cr, err := svc.RejectChangeRequest(port.WithActor(alice, "bob"), first.PendingChange.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "rejected", cr.Status)
+	assert.Equal(t, "bob", cr.DecidedBy)
+
+	later := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: now.Add(2 * time.Hour)}))
+	got, err := later.GetChangeRequest(alice, second.PendingChange.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "expired", got.Status)
+	_, err = later.ApproveChangeRequest(port.WithActor(alice, "bob"), second.PendingChange.ID)
+	require.ErrorIs(t, err, domain.ErrChangeRequestState)
+
+	expired, err := later.ListChangeRequests(alice, port.ChangeRequestFilter{Status: "expired"})
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, second.PendingChange.ID, expired[0].ID)
+	all, err := later.ListChangeRequests(alice, port.ChangeRequestFilter{Flag: "new-checkout"})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	_, err = later.ListChangeRequests(alice, port.ChangeRequestFilter{Status: "approved"})
+	require.ErrorIs(t, err, domain.ErrInvalidChangeQuery)
+}
+
+func TestService_ProtectedFlag_RefusesOtherChanges(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	tests := []struct {
+		name      string
+		env       string
+		change    func(svc *service.Service, ctx context.Context) error
+		protected bool
+	}{
+		{
+			name: "rules", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.UpdateFlagRules(ctx, "new-checkout", port.UpdateFlagRulesRequest{})
+				return err
+			},
+		},
+		{
+			name: "enabled", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.SetFlagEnabled(ctx, "new-checkout", port.SetFlagEnabledRequest{Enabled: false})
+				return err
+			},
+		},
+		{
+			name: "add targets", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.AddTargets(ctx, "new-checkout", port.AddTargetsRequest{Keys: []string{"user-1"}, Value: port.FlagValue{Bool: &on}})
+				return err
+			},
+		},
+		{
+			name: "remove targets", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.RemoveTargets(ctx, "new-checkout", port.RemoveTargetsRequest{Keys: []string{"user-1"}})
+				return err
+			},
+		},
+		{
+			name: "remove override", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.RemoveFlagOverride(ctx, "new-checkout")
+				return err
+			},
+		},
+		{
+			name: "rollback", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.RollbackFlag(ctx, "new-checkout", 1)
+				return err
+			},
+		},
+		{
+			name: "promotion", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.PromoteFlags(ctx, port.PromoteRequest{Source: "staging", Target: domain.DefaultEnvironment, Flags: []string{"new-checkout"}})
+				return err
+			},
+		},
+		{
+			name: "promotion of every flag", protected: true,
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.PromoteFlags(ctx, port.PromoteRequest{Source: "staging", Target: domain.DefaultEnvironment})
+				return err
+			},
+		},
+		{
+			name: "promotion dry run",
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.PromoteFlags(ctx, port.PromoteRequest{Source: "staging", Target: domain.DefaultEnvironment, DryRun: true})
+				return err
+			},
+		},
+		{
+			name: "unprotected environment", env: "staging",
+			change: func(svc *service.Service, ctx context.Context) error {
+				_, err := svc.UpdateFlagRules(ctx, "new-checkout", port.UpdateFlagRulesRequest{})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := protectedFlag(t)
+			svc := newService(store, newFakeFlagCache())
+			ctx := port.WithEnvironment(port.WithActor(context.Background(), "alice"), tt.env)
+
+			err := tt.change(svc, ctx)
+			if tt.protected {
+				require.ErrorIs(t, err, domain.ErrProtected)
+				flag, err := store.GetByName(ctx, domain.DefaultProject, domain.DefaultEnvironment, "new-checkout")
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), flag.Version, "nothing is applied")
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestExperimentService_Protected(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	svc := newExperimentService(experiments, flags, &fakeEventStore{}, newFakeFlagCache())
+	ctx := port.WithActor(context.Background(), "alice")
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "checkout-test", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, flags.SetProtection(ctx, domain.FlagProtection{Project: domain.DefaultProject, FlagName: "checkout", RequiredApprovals: 1}))
+
+	_, err = svc.StartExperiment(ctx, "checkout-test")
+	require.ErrorIs(t, err, domain.ErrProtected)
+}
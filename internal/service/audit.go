@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// defaultAuditPage is the number of audit entries returned when the filter
+// sets no limit.
+const defaultAuditPage = 100
+
+func (s *Service) ListAudit(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntryResponse, error) {
+	q := domain.AuditQuery{
+		Project:     filter.Project,
+		Environment: filter.Environment,
+		FlagName:    filter.Flag,
+		Actor:       filter.Actor,
+		Action:      domain.AuditAction(filter.Action),
+		Since:       filter.Since,
+		Until:       filter.Until,
+		Limit:       filter.Limit,
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultAuditPage
+	}
+	if err := domain.ValidateAuditQuery(q); err != nil {
+		return nil, err
+	}
+	entries, err := s.store.ListAudit(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		out[i] = auditEntryToResponse(entry)
+	}
+	return out, nil
+}
+
+func auditEntryToResponse(entry domain.AuditEntry) port.AuditEntryResponse {
+	resp := port.AuditEntryResponse{
+		ID:          entry.ID,
+		Actor:       entry.Actor,
+		Action:      string(entry.Action),
+		Project:     entry.Project,
+		Environment: entry.Environment,
+		Flag:        entry.FlagName,
+		RequestID:   entry.RequestID,
+		RecordedAt:  entry.RecordedAt,
+		Hash:        hex.EncodeToString(entry.Hash),
+	}
+	if entry.Before != nil {
+		resp.Before = flagToResponse(*entry.Before)
+	}
+	if entry.After != nil {
+		resp.After = flagToResponse(*entry.After)
+	}
+	return resp
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.AuditService = (*AuditService)(nil)
+
+// auditChainPage is the number of audit entries read per round trip while
+// walking the chain.
+const auditChainPage = 1000
+
+// errNoSigningKey is returned by Checkpoint when no signing key is set.
+var errNoSigningKey = errors.New("no audit signing key configured")
+
+// AuditService verifies the audit log's hash chain and signs checkpoints of
+// it, so that rewriting the log, even consistently, can be detected.
+type AuditService struct {
+	store   port.AuditStore
+	signing ed25519.PrivateKey
+	verify  ed25519.PublicKey
+	clock   port.Clock
+	logger  *slog.Logger
+}
+
+func NewAuditService(store port.AuditStore, opts ...Option) *AuditService {
+	o := newOptions(opts)
+	verify := o.verify
+	if verify == nil && o.signing != nil {
+		verify = o.signing.Public().(ed25519.PublicKey)
+	}
+	return &AuditService{store: store, signing: o.signing, verify: verify, clock: o.clock, logger: o.logger}
+}
+
+func (s *AuditService) VerifyAudit(ctx context.Context) (*port.AuditVerificationResponse, error) {
+	checkpoints, err := s.store.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	chain := domain.NewAuditChain(checkpoints, s.verify)
+	brk, _, err := s.walk(ctx, chain, 0)
+	if err != nil {
+		return nil, err
+	}
+	if brk == nil {
+		brk = chain.Finish()
+	}
+	resp := &port.AuditVerificationResponse{
+		Entries:            chain.Entries(),
+		Checkpoints:        len(checkpoints),
+		SignaturesVerified: s.verify != nil,
+	}
+	if brk != nil {
+		resp.Broken = &port.AuditBreak{EntryID: brk.EntryID, CheckpointID: brk.CheckpointID, Reason: brk.Reason}
+	}
+	return resp, nil
+}
+
+// walk feeds chain the entries after afterID until the log ends or a break is
+// found, and returns the break and the last entry verified.
+func (s *AuditService) walk(ctx context.Context, chain *domain.AuditChain, afterID int64) (*domain.AuditBreak, *domain.AuditEntry, error) {
+	var last *domain.AuditEntry
+	for {
+		entries, err := s.store.ListAuditChain(ctx, afterID, auditChainPage)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range entries {
+			brk, err := chain.Next(entries[i])
+			if err != nil || brk != nil {
+				return brk, last, err
+			}
+			last = &entries[i]
+		}
+		if len(entries) < auditChainPage {
+			return nil, last, nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
+
+func (s *AuditService) ListAuditCheckpoints(ctx context.Context) ([]port.AuditCheckpointResponse, error) {
+	checkpoints, err := s.store.ListAuditCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.AuditCheckpointResponse, len(checkpoints))
+	for i, cp := range checkpoints {
+		out[i] = checkpointToResponse(cp)
+	}
+	return out, nil
+}
+
+// Checkpoint verifies the entries recorded since the last checkpoint and
+// signs the latest of them. It returns nil when nothing was recorded since,
+// and an error wrapping domain.ErrAuditChainBroken, without signing, when the
+// entries or the last checkpoint do not verify.
+func (s *AuditService) Checkpoint(ctx context.Context) (*port.AuditCheckpointResponse, error) {
+	if s.signing == nil {
+		return nil, errNoSigningKey
+	}
+	chain := domain.NewAuditChain(nil, s.verify)
+	var afterID int64
+	last, err := s.store.LastAuditCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		if !last.VerifySignature(s.verify) {
+			return nil, fmt.Errorf("checkpoint %d signature is invalid: %w", last.ID, domain.ErrAuditChainBroken)
+		}
+		chain = domain.ResumeAuditChain(*last, s.verify)
+		afterID = last.EntryID
+	}
+	brk, entry, err := s.walk(ctx, chain, afterID)
+	if err != nil {
+		return nil, err
+	}
+	if brk != nil {
+		return nil, fmt.Errorf("entry %d: %s: %w", brk.EntryID, brk.Reason, domain.ErrAuditChainBroken)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	cp := domain.AuditCheckpoint{EntryID: entry.ID, Hash: entry.Hash, CreatedAt: s.clock.Now().UTC().Truncate(time.Microsecond)}
+	cp.Signature = ed25519.Sign(s.signing, cp.Message())
+	created, err := s.store.CreateAuditCheckpoint(ctx, cp)
+	if err != nil {
+		return nil, err
+	}
+	resp := checkpointToResponse(*created)
+	return &resp, nil
+}
+
+// RunCheckpoints signs a checkpoint every interval until ctx is cancelled.
+// Failures are logged and retried on the next tick; a broken chain is logged
+// as an error.
+func (s *AuditService) RunCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cp, err := s.Checkpoint(ctx)
+			switch {
+			case errors.Is(err, domain.ErrAuditChainBroken):
+				s.logger.ErrorContext(ctx, "audit checkpoint refused", "error", err)
+			case err != nil:
+				s.logger.WarnContext(ctx, "audit checkpoint failed", "error", err)
+			case cp != nil:
+				s.logger.InfoContext(ctx, "audit checkpoint signed", "checkpoint", cp.ID, "entry", cp.EntryID)
+			}
+		}
+	}
+}
+
+func checkpointToResponse(cp domain.AuditCheckpoint) port.AuditCheckpointResponse {
+	return port.AuditCheckpointResponse{
+		ID:        cp.ID,
+		EntryID:   cp.EntryID,
+		Hash:      hex.EncodeToString(cp.Hash),
+		Signature: hex.EncodeToString(cp.Signature),
+		CreatedAt: cp.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+var _ port.AuditStore = (*fakeAuditStore)(nil)
+
+type fakeAuditStore struct {
+	entries     []domain.AuditEntry
+	checkpoints []domain.AuditCheckpoint
+}
+
+// append chains an entry for the flag onto the log the way the store does.
+func (f *fakeAuditStore) append(t *testing.T, actor string) {
+	t.Helper()
+	entry := domain.AuditEntry{
+		ID: int64(len(f.entries) + 1), Actor: actor, Action: domain.AuditUpdateValue, Project: domain.DefaultProject,
+		Environment: domain.DefaultEnvironment, FlagName: "new-checkout",
+		RecordedAt: time.Date(2026, 6, 1, 9, len(f.entries), 0, 0, time.UTC),
+	}
+	if len(f.entries) > 0 {
+		entry.PrevHash = f.entries[len(f.entries)-1].Hash
+	}
+	var err error
+	entry.Hash, err = domain.AuditHash(entry.PrevHash, entry)
+	require.NoError(t, err)
+	f.entries = append(f.entries, entry)
+}
+
+func (f *fakeAuditStore) ListAuditChain(_ context.Context, afterID int64, limit int) ([]domain.AuditEntry, error) {
+	var out []domain.AuditEntry
+	for _, entry := range f.entries {
+		if entry.ID > afterID && len(out) < limit {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeAuditStore) CreateAuditCheckpoint(_ context.Context, cp domain.AuditCheckpoint) (*domain.AuditCheckpoint, error) {
+	cp.ID = int64(len(f.checkpoints) + 1)
+	f.checkpoints = append(f.checkpoints, cp)
+	return &cp, nil
+}
+
+func (f *fakeAuditStore) LastAuditCheckpoint(context.Context) (*domain.AuditCheckpoint, error) {
+	if len(f.checkpoints) == 0 {
+		return nil, nil
+	}
+	cp := f.checkpoints[len(f.checkpoints)-1]
+	return &cp, nil
+}
+
+func (f *fakeAuditStore) ListAuditCheckpoints(context.Context) ([]domain.AuditCheckpoint, error) {
+	return f.checkpoints, nil
+}
+
+func newAuditKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return public, private
+}
+
+func TestAuditService_Checkpoint(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	public, private := newAuditKey(t)
+	now := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+	store := &fakeAuditStore{}
+	svc := service.NewAuditService(store, service.WithSigningKey(private), service.WithClock(fakeClock{now: now}))
+
+	cp, err := svc.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp, "an empty log is not checkpointed")
+
+	store.append(t, "alice")
+	store.append(t, "bob")
+	cp, err = svc.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	assert.Equal(t, int64(2), cp.EntryID)
+	assert.Equal(t, hex.EncodeToString(store.entries[1].Hash), cp.Hash)
+	assert.Equal(t, now, cp.CreatedAt)
+	assert.True(t, store.checkpoints[0].VerifySignature(public))
+
+	cp, err = svc.Checkpoint(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp, "nothing was recorded since the last checkpoint")
+
+	store.append(t, "carol")
+	cp, err = svc.Checkpoint(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	assert.Equal(t, int64(3), cp.EntryID)
+
+	store.append(t, "dave")
+	store.entries[3].Actor = "mallory"
+	_, err = svc.Checkpoint(ctx)
+	require.ErrorIs(t, err, domain.ErrAuditChainBroken)
+	assert.Len(t, store.checkpoints, 2, "a broken chain is not signed")
+}
+
+func TestAuditService_Checkpoint_Refused(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, private := newAuditKey(t)
+	_, other := newAuditKey(t)
+
+	store := &fakeAuditStore{}
+	store.append(t, "alice")
+	_, err := service.NewAuditService(store).Checkpoint(ctx)
+	require.Error(t, err, "checkpoints need a signing key")
+
+	forged := domain.AuditCheckpoint{ID: 1, EntryID: 1, Hash: store.entries[0].Hash}
+	forged.Signature = ed25519.Sign(other, forged.Message())
+	store.checkpoints = append(store.checkpoints, forged)
+	store.append(t, "bob")
+	_, err = service.NewAuditService(store, service.WithSigningKey(private)).Checkpoint(ctx)
+	require.ErrorIs(t, err, domain.ErrAuditChainBroken)
+}
+
+func TestAuditService_VerifyAudit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	public, private := newAuditKey(t)
+
+	tests := []struct {
+		name       string
+		opts       []service.Option
+		tamper     func(*fakeAuditStore)
+		wantBroken *port.AuditBreak
+		wantSigned bool
+	}{
+		{
+			name:       "intact log",
+			opts:       []service.Option{service.WithVerifyKey(public)},
+			tamper:     func(*fakeAuditStore) {},
+			wantSigned: true,
+		},
+		{
+			name: "edited entry",
+			opts: []service.Option{service.WithVerifyKey(public)},
+			tamper: func(s *fakeAuditStore) {
+				s.entries[2].Actor = "mallory"
+			},
+			wantBroken: &port.AuditBreak{EntryID: 3, Reason: "hash does not match the entry's contents"},
+			wantSigned: true,
+		},
+		{
+			name: "rehashed chain under a checkpoint",
+			tamper: func(s *fakeAuditStore) {
+				s.entries[0].Actor = "mallory"
+				s.entries[0].Hash, _ = domain.AuditHash(nil, s.entries[0])
+				s.entries[1].PrevHash = s.entries[0].Hash
+				s.entries[1].Hash, _ = domain.AuditHash(s.entries[1].PrevHash, s.entries[1])
+			},
+			wantBroken: &port.AuditBreak{EntryID: 2, CheckpointID: 1, Reason: "entry does not match its checkpoint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &fakeAuditStore{}
+			store.append(t, "alice")
+			store.append(t, "bob")
+			_, err := service.NewAuditService(store, service.WithSigningKey(private)).Checkpoint(ctx)
+			require.NoError(t, err)
+			store.append(t, "carol")
+			tt.tamper(store)
+
+			report, err := service.NewAuditService(store, tt.opts...).VerifyAudit(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBroken, report.Broken)
+			assert.Equal(t, tt.wantSigned, report.SignaturesVerified)
+			assert.Equal(t, 1, report.Checkpoints)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestService_ListAudit(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	store := newFakeFlagStore()
+	store.audit = []domain.AuditEntry{
+		{
+			ID: 1, Actor: "alice", Action: domain.AuditCreate, Project: domain.DefaultProject, Environment: domain.DefaultEnvironment,
+			FlagName: "new-checkout", After: &domain.Flag{Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}},
+			RequestID: "req-1", RecordedAt: at,
+		},
+		{
+			ID: 2, Actor: "bob", Action: domain.AuditUpdateValue, Project: domain.DefaultProject, Environment: domain.DefaultEnvironment,
+			FlagName:   "new-checkout",
+			Before:     &domain.Flag{Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}},
+			After:      &domain.Flag{Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on}, Version: 2},
+			RecordedAt: at.Add(time.Minute),
+		},
+	}
+	svc := newService(store, newFakeFlagCache())
+
+	entries, err := svc.ListAudit(context.Background(), port.AuditFilter{Flag: "new-checkout", Action: "update-value"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AuditQuery{FlagName: "new-checkout", Action: domain.AuditUpdateValue, Limit: 100}, store.auditQuery)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "bob", entries[0].Actor)
+	assert.Equal(t, "update-value", entries[0].Action)
+	assert.False(t, *entries[0].Before.Value.Bool)
+	assert.True(t, *entries[0].After.Value.Bool)
+	assert.Nil(t, entries[1].Before, "a created flag has no prior state")
+	assert.Equal(t, "req-1", entries[1].RequestID)
+}
+
+func TestService_ListAudit_InvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter port.AuditFilter
+	}{
+		{name: "unknown action", filter: port.AuditFilter{Action: "delete"}},
+		{name: "inverted range", filter: port.AuditFilter{Since: at, Until: at.Add(-time.Hour)}},
+		{name: "limit too large", filter: port.AuditFilter{Limit: domain.MaxAuditPage + 1}},
+		{name: "negative limit", filter: port.AuditFilter{Limit: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(newFakeFlagStore(), newFakeFlagCache())
+			_, err := svc.ListAudit(context.Background(), tt.filter)
+			require.ErrorIs(t, err, domain.ErrInvalidAuditQuery)
+		})
+	}
+}
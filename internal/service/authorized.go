@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.FlagService = (*AuthorizedService)(nil)
+
+// AuthorizedService guards a port.FlagService with role-based access
+// control, so every entry point enforces the same permissions. A change to a
+// flag needs write on it, reading it needs read and evaluating it needs
+// evaluate; environments, projects and freeze windows are administered only
+// by principals that may administer everything. Lists are filtered to what
+// the principal may see. Refusals wrap domain.ErrForbidden.
+type AuthorizedService struct {
+	next   port.FlagService
+	store  port.FlagStore
+	access accessControl
+	logger *slog.Logger
+}
+
+// NewAuthorizedService guards next. The flag store is read for the tags of
+// the flags a request touches, which tag selectors match.
+func NewAuthorizedService(next port.FlagService, store port.FlagStore, access port.AccessStore, opts ...Option) *AuthorizedService {
+	o := newOptions(opts)
+	return &AuthorizedService{
+		next:   next,
+		store:  store,
+		access: accessControl{store: access, logger: o.logger},
+		logger: o.logger,
+	}
+}
+
+// CreateFlag needs write on the flag in every environment, since it is
+// defined in all of them.
+func (s *AuthorizedService) CreateFlag(ctx context.Context, req port.CreateFlagRequest) (*port.FlagResponse, error) {
+	r := domain.FlagResource(port.ProjectFrom(ctx), "", req.Name, req.Tags)
+	if err := s.access.require(ctx, domain.ScopeFlagsWrite, r); err != nil {
+		return nil, err
+	}
+	return s.next.CreateFlag(ctx, req)
+}
+
+func (s *AuthorizedService) GetFlag(ctx context.Context, name string) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsRead, name); err != nil {
+		return nil, err
+	}
+	return s.next.GetFlag(ctx, name)
+}
+
+func (s *AuthorizedService) GetFlagValue(ctx context.Context, name string) (*port.FlagValueResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsEvaluate, name); err != nil {
+		return nil, err
+	}
+	return s.next.GetFlagValue(ctx, name)
+}
+
+func (s *AuthorizedService) UpdateFlagValue(ctx context.Context, name string, req port.UpdateFlagValueRequest) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.UpdateFlagValue(ctx, name, req)
+}
+
+func (s *AuthorizedService) UpdateFlagRules(ctx context.Context, name string, req port.UpdateFlagRulesRequest) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.UpdateFlagRules(ctx, name, req)
+}
+
+func (s *AuthorizedService) AddTargets(ctx context.Context, name string, req port.AddTargetsRequest) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.AddTargets(ctx, name, req)
+}
+
+func (s *AuthorizedService) RemoveTargets(ctx context.Context, name string, req port.RemoveTargetsRequest) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.RemoveTargets(ctx, name, req)
+}
+
+func (s *AuthorizedService) SetFlagEnabled(ctx context.Context, name string, req port.SetFlagEnabledRequest) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.SetFlagEnabled(ctx, name, req)
+}
+
+func (s *AuthorizedService) RemoveFlagOverride(ctx context.Context, name string) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.RemoveFlagOverride(ctx, name)
+}
+
+// EvaluateAll leaves out the flags the principal may not evaluate. They are
+// left out of the evaluation itself, not only of its response, so no exposure
+// is recorded for them.
+func (s *AuthorizedService) EvaluateAll(ctx context.Context, evalCtx port.EvaluationContext, filter port.EvaluationFilter) (*port.EvaluateAllResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsEvaluate, project)
+	if err != nil {
+		return nil, err
+	}
+	names := filter.Names
+	if len(names) == 0 {
+		if names, err = s.store.ListNames(ctx, project, filter.Prefix); err != nil {
+			return nil, err
+		}
+	}
+	tags, err := s.tags(ctx, project, env, names...)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make([]string, 0, len(names))
+	for _, name := range names {
+		if g.Allows(domain.ScopeFlagsEvaluate, domain.FlagResource(project, env, name, tags[name])) {
+			allowed = append(allowed, name)
+		}
+	}
+	if len(allowed) == 0 {
+		return &port.EvaluateAllResponse{Flags: map[string]port.FlagValueResponse{}}, nil
+	}
+	filter.Names = allowed
+	return s.next.EvaluateAll(ctx, evalCtx, filter)
+}
+
+func (s *AuthorizedService) CreateEnvironment(ctx context.Context, req port.CreateEnvironmentRequest) (*port.EnvironmentResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return s.next.CreateEnvironment(ctx, req)
+}
+
+func (s *AuthorizedService) ListEnvironments(ctx context.Context) ([]port.EnvironmentResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, ""); err != nil {
+		return nil, err
+	}
+	return s.next.ListEnvironments(ctx)
+}
+
+// PromoteFlags needs write on every promoted flag in the target
+// environment, or read for a dry run.
+func (s *AuthorizedService) PromoteFlags(ctx context.Context, req port.PromoteRequest) (*port.PromotionResponse, error) {
+	project := port.ProjectFrom(ctx)
+	scope := domain.ScopeFlagsWrite
+	if req.DryRun {
+		scope = domain.ScopeFlagsRead
+	}
+	names := req.Flags
+	if len(names) == 0 {
+		var err error
+		if names, err = s.store.ListNames(ctx, project, ""); err != nil {
+			return nil, err
+		}
+	}
+	tags, err := s.tags(ctx, project, req.Target, names...)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]domain.Resource, len(names))
+	for i, name := range names {
+		resources[i] = domain.FlagResource(project, req.Target, name, tags[name])
+	}
+	if err := s.access.require(ctx, scope, resources...); err != nil {
+		return nil, err
+	}
+	return s.next.PromoteFlags(ctx, req)
+}
+
+// ListPromotions needs read on the whole project, since promotions name
+// flags of every team.
+func (s *AuthorizedService) ListPromotions(ctx context.Context) ([]port.PromotionResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeFlagsRead, domain.Resource{Project: port.ProjectFrom(ctx)}); err != nil {
+		return nil, err
+	}
+	return s.next.ListPromotions(ctx)
+}
+
+func (s *AuthorizedService) CreateProject(ctx context.Context, req port.CreateProjectRequest) (*port.ProjectResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return s.next.CreateProject(ctx, req)
+}
+
+// ListProjects leaves out the projects the principal may read nothing in.
+func (s *AuthorizedService) ListProjects(ctx context.Context) ([]port.ProjectResponse, error) {
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, "")
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.next.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := projects[:0]
+	for _, project := range projects {
+		if g.AllowsSome(domain.ScopeFlagsRead, project.Key) {
+			out = append(out, project)
+		}
+	}
+	return out, nil
+}
+
+// ListAudit leaves out the changes to flags the principal may not read, so
+// a page may hold fewer entries than the filter's limit.
+func (s *AuthorizedService) ListAudit(ctx context.Context, filter port.AuditFilter) ([]port.AuditEntryResponse, error) {
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, filter.Project)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.next.ListAudit(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := entries[:0]
+	for _, entry := range entries {
+		var tags []string
+		if entry.After != nil {
+			tags = entry.After.Tags
+		} else if entry.Before != nil {
+			tags = entry.Before.Tags
+		}
+		if g.Allows(domain.ScopeFlagsRead, domain.FlagResource(entry.Project, entry.Environment, entry.Flag, tags)) {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+func (s *AuthorizedService) GetFlagHistory(ctx context.Context, name string) ([]port.FlagVersionResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsRead, name); err != nil {
+		return nil, err
+	}
+	return s.next.GetFlagHistory(ctx, name)
+}
+
+func (s *AuthorizedService) RollbackFlag(ctx context.Context, name string, toVersion int64) (*port.FlagResponse, error) {
+	if err := s.requireFlag(ctx, domain.ScopeFlagsWrite, name); err != nil {
+		return nil, err
+	}
+	return s.next.RollbackFlag(ctx, name, toVersion)
+}
+
+// GetSnapshot leaves out the flags the principal may not evaluate.
+func (s *AuthorizedService) GetSnapshot(ctx context.Context) (*port.SnapshotResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsEvaluate, project)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.next.GetSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := resp.Flags[:0]
+	for _, flag := range resp.Flags {
+		if g.Allows(domain.ScopeFlagsEvaluate, domain.FlagResource(project, env, flag.Name, flag.Tags)) {
+			flags = append(flags, flag)
+		}
+	}
+	resp.Flags = flags
+	return resp, nil
+}
+
+// GetFlagProtection needs read on the flag in every environment, since
+// protection spans them.
+func (s *AuthorizedService) GetFlagProtection(ctx context.Context, name string) (*port.FlagProtectionResponse, error) {
+	if err := s.requireProjectFlag(ctx, domain.ScopeFlagsRead, name); err != nil {
+		return nil, err
+	}
+	return s.next.GetFlagProtection(ctx, name)
+}
+
+// SetFlagProtection needs admin on the flag in every environment, so that
+// the owners of a flag cannot lift the approvals it requires.
+func (s *AuthorizedService) SetFlagProtection(ctx context.Context, name string, req port.FlagProtectionRequest) (*port.FlagProtectionResponse, error) {
+	if err := s.requireProjectFlag(ctx, domain.ScopeAdmin, name); err != nil {
+		return nil, err
+	}
+	return s.next.SetFlagProtection(ctx, name, req)
+}
+
+// ListChangeRequests leaves out the requests to flags the principal may not
+// read.
+func (s *AuthorizedService) ListChangeRequests(ctx context.Context, filter port.ChangeRequestFilter) ([]port.ChangeRequestResponse, error) {
+	project := port.ProjectFrom(ctx)
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, project)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := s.next.ListChangeRequests(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(requests))
+	for i, cr := range requests {
+		names[i] = cr.Flag
+	}
+	tags, err := s.tags(ctx, project, port.EnvironmentFrom(ctx), names...)
+	if err != nil {
+		return nil, err
+	}
+	out := requests[:0]
+	for _, cr := range requests {
+		if g.Allows(domain.ScopeFlagsRead, domain.FlagResource(project, cr.Environment, cr.Flag, tags[cr.Flag])) {
+			out = append(out, cr)
+		}
+	}
+	return out, nil
+}
+
+func (s *AuthorizedService) GetChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	return s.requireChange(ctx, domain.ScopeFlagsRead, id)
+}
+
+// ApproveChangeRequest needs write on the flag in the environment the
+// change request changes.
+func (s *AuthorizedService) ApproveChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	if _, err := s.requireChange(ctx, domain.ScopeFlagsWrite, id); err != nil {
+		return nil, err
+	}
+	return s.next.ApproveChangeRequest(ctx, id)
+}
+
+// RejectChangeRequest needs write on the flag in the environment the change
+// request changes.
+func (s *AuthorizedService) RejectChangeRequest(ctx context.Context, id int64) (*port.ChangeRequestResponse, error) {
+	if _, err := s.requireChange(ctx, domain.ScopeFlagsWrite, id); err != nil {
+		return nil, err
+	}
+	return s.next.RejectChangeRequest(ctx, id)
+}
+
+func (s *AuthorizedService) CreateFreezeWindow(ctx context.Context, req port.FreezeWindowRequest) (*port.FreezeWindowResponse, error) {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return nil, err
+	}
+	return s.next.CreateFreezeWindow(ctx, req)
+}
+
+func (s *AuthorizedService) ListFreezeWindows(ctx context.Context, filter port.FreezeWindowFilter) ([]port.FreezeWindowResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, ""); err != nil {
+		return nil, err
+	}
+	return s.next.ListFreezeWindows(ctx, filter)
+}
+
+func (s *AuthorizedService) GetFreezeWindow(ctx context.Context, id int64) (*port.FreezeWindowResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, ""); err != nil {
+		return nil, err
+	}
+	return s.next.GetFreezeWindow(ctx, id)
+}
+
+func (s *AuthorizedService) DeleteFreezeWindow(ctx context.Context, id int64) error {
+	if err := s.access.require(ctx, domain.ScopeAdmin, domain.Resource{}); err != nil {
+		return err
+	}
+	return s.next.DeleteFreezeWindow(ctx, id)
+}
+
+// requireFlag checks scope on the flag called name in the context's project
+// and environment.
+func (s *AuthorizedService) requireFlag(ctx context.Context, scope domain.Scope, name string) error {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	tags, err := s.tags(ctx, project, env, name)
+	if err != nil {
+		return err
+	}
+	return s.access.require(ctx, scope, domain.FlagResource(project, env, name, tags[name]))
+}
+
+// requireProjectFlag checks scope on the flag called name in every
+// environment of the context's project.
+func (s *AuthorizedService) requireProjectFlag(ctx context.Context, scope domain.Scope, name string) error {
+	project := port.ProjectFrom(ctx)
+	tags, err := s.tags(ctx, project, port.EnvironmentFrom(ctx), name)
+	if err != nil {
+		return err
+	}
+	return s.access.require(ctx, scope, domain.FlagResource(project, "", name, tags[name]))
+}
+
+// requireChange checks scope on the flag the change request id changes and
+// returns the request. A principal that may use scope nowhere in the project
+// is refused before the request is looked up, so it cannot probe which ids
+// exist.
+func (s *AuthorizedService) requireChange(ctx context.Context, scope domain.Scope, id int64) (*port.ChangeRequestResponse, error) {
+	if _, err := s.access.requireSome(ctx, scope, port.ProjectFrom(ctx)); err != nil {
+		return nil, err
+	}
+	cr, err := s.next.GetChangeRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.tags(ctx, cr.Project, cr.Environment, cr.Flag)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.require(ctx, scope, domain.FlagResource(cr.Project, cr.Environment, cr.Flag, tags[cr.Flag])); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func (s *AuthorizedService) tags(ctx context.Context, project, env string, names ...string) (map[string][]string, error) {
+	return flagTags(ctx, s.store, project, env, names...)
+}
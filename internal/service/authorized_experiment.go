@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.ExperimentService = (*AuthorizedExperimentService)(nil)
+
+// AuthorizedExperimentService guards a port.ExperimentService with the same
+// role-based access control as AuthorizedService. An experiment is treated
+// as its flag in the experiment's environment: creating, starting or stopping
+// one needs write on the flag and reading it or its results needs read.
+// Layers and metric events span every project, so they need the scope on
+// something. Refusals wrap domain.ErrForbidden.
+type AuthorizedExperimentService struct {
+	next        port.ExperimentService
+	experiments port.ExperimentStore
+	flags       port.FlagStore
+	access      accessControl
+	logger      *slog.Logger
+}
+
+// NewAuthorizedExperimentService guards next. Experiments are read from the
+// experiment store for the flag they run on and the flag store for its tags.
+func NewAuthorizedExperimentService(next port.ExperimentService, experiments port.ExperimentStore, flags port.FlagStore, access port.AccessStore, opts ...Option) *AuthorizedExperimentService {
+	o := newOptions(opts)
+	return &AuthorizedExperimentService{
+		next:        next,
+		experiments: experiments,
+		flags:       flags,
+		access:      accessControl{store: access, logger: o.logger},
+		logger:      o.logger,
+	}
+}
+
+func (s *AuthorizedExperimentService) CreateExperiment(ctx context.Context, req port.CreateExperimentRequest) (*port.ExperimentResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	tags, err := flagTags(ctx, s.flags, project, env, req.FlagName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.require(ctx, domain.ScopeFlagsWrite, domain.FlagResource(project, env, req.FlagName, tags[req.FlagName])); err != nil {
+		return nil, err
+	}
+	return s.next.CreateExperiment(ctx, req)
+}
+
+func (s *AuthorizedExperimentService) GetExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	if err := s.requireExperiment(ctx, domain.ScopeFlagsRead, key); err != nil {
+		return nil, err
+	}
+	return s.next.GetExperiment(ctx, key)
+}
+
+// ListExperiments leaves out the experiments on flags the principal may not
+// read.
+func (s *AuthorizedExperimentService) ListExperiments(ctx context.Context, filter port.ExperimentFilter) ([]port.ExperimentResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	g, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, project)
+	if err != nil {
+		return nil, err
+	}
+	experiments, err := s.next.ListExperiments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(experiments))
+	for i, exp := range experiments {
+		names[i] = exp.FlagName
+	}
+	tags, err := flagTags(ctx, s.flags, project, env, names...)
+	if err != nil {
+		return nil, err
+	}
+	out := experiments[:0]
+	for _, exp := range experiments {
+		if g.Allows(domain.ScopeFlagsRead, domain.FlagResource(exp.Project, exp.Environment, exp.FlagName, tags[exp.FlagName])) {
+			out = append(out, exp)
+		}
+	}
+	return out, nil
+}
+
+func (s *AuthorizedExperimentService) StartExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	if err := s.requireExperiment(ctx, domain.ScopeFlagsWrite, key); err != nil {
+		return nil, err
+	}
+	return s.next.StartExperiment(ctx, key)
+}
+
+func (s *AuthorizedExperimentService) StopExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	if err := s.requireExperiment(ctx, domain.ScopeFlagsWrite, key); err != nil {
+		return nil, err
+	}
+	return s.next.StopExperiment(ctx, key)
+}
+
+// RecordMetrics needs evaluate on something: metric events are keyed by
+// targeting key, not by flag.
+func (s *AuthorizedExperimentService) RecordMetrics(ctx context.Context, req port.RecordMetricsRequest) error {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsEvaluate, ""); err != nil {
+		return err
+	}
+	return s.next.RecordMetrics(ctx, req)
+}
+
+func (s *AuthorizedExperimentService) GetExperimentResults(ctx context.Context, key, metric string) (*port.ExperimentResultsResponse, error) {
+	if err := s.requireExperiment(ctx, domain.ScopeFlagsRead, key); err != nil {
+		return nil, err
+	}
+	return s.next.GetExperimentResults(ctx, key, metric)
+}
+
+func (s *AuthorizedExperimentService) GetWeightHistory(ctx context.Context, key string) ([]port.WeightChange, error) {
+	if err := s.requireExperiment(ctx, domain.ScopeFlagsRead, key); err != nil {
+		return nil, err
+	}
+	return s.next.GetWeightHistory(ctx, key)
+}
+
+func (s *AuthorizedExperimentService) CreateLayer(ctx context.Context, req port.CreateLayerRequest) (*port.LayerResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsWrite, ""); err != nil {
+		return nil, err
+	}
+	return s.next.CreateLayer(ctx, req)
+}
+
+func (s *AuthorizedExperimentService) GetLayer(ctx context.Context, key string) (*port.LayerResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, ""); err != nil {
+		return nil, err
+	}
+	return s.next.GetLayer(ctx, key)
+}
+
+func (s *AuthorizedExperimentService) ListLayers(ctx context.Context) ([]port.LayerResponse, error) {
+	if _, err := s.access.requireSome(ctx, domain.ScopeFlagsRead, ""); err != nil {
+		return nil, err
+	}
+	return s.next.ListLayers(ctx)
+}
+
+// requireExperiment checks scope on the flag of the experiment called key in
+// the experiment's environment. A principal that may use scope nowhere is
+// refused before the experiment is looked up, so it cannot probe which keys
+// exist.
+func (s *AuthorizedExperimentService) requireExperiment(ctx context.Context, scope domain.Scope, key string) error {
+	if _, err := s.access.requireSome(ctx, scope, ""); err != nil {
+		return err
+	}
+	exp, err := s.experiments.GetByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	tags, err := flagTags(ctx, s.flags, exp.Project, exp.Environment, exp.FlagName)
+	if err != nil {
+		return err
+	}
+	return s.access.require(ctx, scope, domain.FlagResource(exp.Project, exp.Environment, exp.FlagName, tags[exp.FlagName]))
+}
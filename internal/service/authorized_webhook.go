@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.WebhookService = (*AuthorizedWebhookService)(nil)
+
+// AuthorizedWebhookService guards a port.WebhookService with role-based
+// access control. A webhook receives the changes of every project and
+// environment, so only a principal that may administer everything can
+// manage webhooks or their deliveries. Refusals wrap domain.ErrForbidden.
+type AuthorizedWebhookService struct {
+	next   port.WebhookService
+	access accessControl
+}
+
+func NewAuthorizedWebhookService(next port.WebhookService, access port.AccessStore, opts ...Option) *AuthorizedWebhookService {
+	o := newOptions(opts)
+	return &AuthorizedWebhookService{next: next, access: accessControl{store: access, logger: o.logger}}
+}
+
+func (s *AuthorizedWebhookService) CreateWebhook(ctx context.Context, req port.WebhookRequest) (*port.WebhookResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.CreateWebhook(ctx, req)
+}
+
+func (s *AuthorizedWebhookService) ListWebhooks(ctx context.Context) ([]port.WebhookResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhooks(ctx)
+}
+
+func (s *AuthorizedWebhookService) GetWebhook(ctx context.Context, id int64) (*port.WebhookResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.GetWebhook(ctx, id)
+}
+
+func (s *AuthorizedWebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := s.requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+func (s *AuthorizedWebhookService) ListWebhookDeliveries(ctx context.Context, id int64, filter port.DeliveryFilter) ([]port.DeliveryResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.ListWebhookDeliveries(ctx, id, filter)
+}
+
+func (s *AuthorizedWebhookService) RetryWebhookDelivery(ctx context.Context, id, deliveryID int64) (*port.DeliveryResponse, error) {
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.next.RetryWebhookDelivery(ctx, id, deliveryID)
+}
+
+func (s *AuthorizedWebhookService) requireAdmin(ctx context.Context) error {
+	return s.access.require(ctx, domain.ScopeAdmin, domain.Resource{})
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// RunBandits rebalances running bandit experiments every interval until ctx
+// is cancelled. Failures are logged and retried on the next tick.
+func (s *ExperimentService) RunBandits(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.RebalanceBandits(ctx); err != nil {
+				s.logger.WarnContext(ctx, "bandit rebalance failed", "error", err)
+			}
+		}
+	}
+}
+
+// RebalanceBandits recomputes the variant weights of every running bandit
+// experiment, in every environment, from its reward metric. Experiments whose
+// weights barely move, as judged by domain.BanditWeightsMoved, or whose flag
+// is frozen or protected, are left alone; one experiment failing does not
+// stop the others.
+func (s *ExperimentService) RebalanceBandits(ctx context.Context) error {
+	running, err := s.experiments.List(ctx, port.ExperimentFilter{Status: string(domain.ExperimentRunning)})
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now().UTC()
+	rng := rand.New(rand.NewPCG(uint64(now.UnixNano()), 0))
+	var errs []error
+	for _, exp := range running {
+		if exp.Bandit == nil {
+			continue
+		}
+		if err := s.rebalance(ctx, exp, now, rng); err != nil {
+			errs = append(errs, fmt.Errorf("experiment %q: %w", exp.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ExperimentService) rebalance(ctx context.Context, exp domain.Experiment, now time.Time, rng *rand.Rand) error {
+	stats, err := s.events.VariantStats(ctx, exp.Key, exp.Bandit.Metric)
+	if err != nil {
+		return err
+	}
+	weights := domain.ComputeBanditWeights(*exp.Bandit, exp.Variants, stats, rng)
+	if !domain.BanditWeightsMoved(exp.Variants, weights) {
+		return nil
+	}
+	if err := s.guard(ctx, exp); errors.Is(err, domain.ErrFrozen) || errors.Is(err, domain.ErrProtected) {
+		s.logger.InfoContext(ctx, "bandit rebalance skipped", "experiment", exp.Key, "reason", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, flag, err := s.experiments.UpdateWeights(ctx, exp.Key, weights, now)
+	if err != nil {
+		return err
+	}
+	s.cacheFlag(ctx, *flag)
+	s.logger.InfoContext(ctx, "bandit weights updated", "experiment", exp.Key, "weights", weights)
+	return nil
+}
+
+// GetWeightHistory returns the weights a bandit experiment has used, oldest
+// first.
+func (s *ExperimentService) GetWeightHistory(ctx context.Context, key string) ([]port.WeightChange, error) {
+	if _, err := s.experiments.GetByKey(ctx, key); err != nil {
+		return nil, err
+	}
+	history, err := s.experiments.WeightHistory(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.WeightChange, len(history))
+	for i, change := range history {
+		out[i] = port.WeightChange{Variants: weightsToResponse(change.Variants), ChangedAt: change.ChangedAt}
+	}
+	return out, nil
+}
+
+func banditToDomain(cfg *port.BanditConfig) *domain.BanditConfig {
+	if cfg == nil {
+		return nil
+	}
+	return &domain.BanditConfig{
+		Algorithm:      domain.BanditAlgorithm(cfg.Algorithm),
+		Metric:         cfg.Metric,
+		Epsilon:        cfg.Epsilon,
+		MinExploration: cfg.MinExploration,
+	}
+}
+
+func banditToResponse(cfg *domain.BanditConfig) *port.BanditConfig {
+	if cfg == nil {
+		return nil
+	}
+	return &port.BanditConfig{
+		Algorithm:      string(cfg.Algorithm),
+		Metric:         cfg.Metric,
+		Epsilon:        cfg.Epsilon,
+		MinExploration: cfg.MinExploration,
+	}
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+func TestExperimentService_RebalanceBandits(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	events := &fakeEventStore{stats: []domain.VariantStats{
+		{Variant: "control", Users: 500, Conversions: 10},
+		{Variant: "treatment", Users: 500, Conversions: 60},
+	}}
+	cache := newFakeFlagCache()
+	svc := newExperimentService(experiments, flags, events, cache)
+	ctx := context.Background()
+	weights := []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "banner-bandit", FlagName: "checkout", Allocation: 100, Variants: weights,
+		Bandit: &port.BanditConfig{Algorithm: "epsilon-greedy", Metric: "click", Epsilon: 0.2},
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "banner-bandit-2", FlagName: "checkout", Allocation: 100, Variants: weights,
+		Bandit: &port.BanditConfig{Algorithm: "greedy", Metric: "click"},
+	})
+	require.ErrorIs(t, err, domain.ErrInvalidExperiment)
+
+	_, err = svc.StartExperiment(ctx, "banner-bandit")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RebalanceBandits(ctx))
+
+	want := []domain.VariantWeight{{Variant: "control", Weight: 100}, {Variant: "treatment", Weight: 900}}
+	require.NotNil(t, cache.flags["checkout"].Experiment)
+	assert.Equal(t, want, cache.flags["checkout"].Experiment.Variants, "new weights are written through to the cache")
+
+	require.NoError(t, svc.RebalanceBandits(ctx))
+	history, err := svc.GetWeightHistory(ctx, "banner-bandit")
+	require.NoError(t, err)
+	require.Len(t, history, 2, "unchanged weights are not recorded again")
+	assert.Equal(t, weights, history[0].Variants)
+	assert.Equal(t, []port.VariantWeight{{Variant: "control", Weight: 100}, {Variant: "treatment", Weight: 900}}, history[1].Variants)
+
+	_, err = svc.GetWeightHistory(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrExperimentNotFound)
+}
+
+func TestExperimentService_RebalanceBandits_SkipsFixedExperiments(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	events := &fakeEventStore{err: errors.New("must not be queried")}
+	svc := newExperimentService(experiments, flags, events, newFakeFlagCache())
+	ctx := context.Background()
+
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "checkout-test", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+	_, err = svc.StartExperiment(ctx, "checkout-test")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RebalanceBandits(ctx))
+	assert.Equal(t, 1, experiments.experiments["checkout-test"].Variants[0].Weight)
+}
+
+func TestExperimentService_RebalanceBandits_UnchangedStats(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	events := &fakeEventStore{stats: []domain.VariantStats{
+		{Variant: "control", Users: 200, Conversions: 20},
+		{Variant: "treatment", Users: 200, Conversions: 22},
+	}}
+	ctx := context.Background()
+	svc := newExperimentService(experiments, flags, events, newFakeFlagCache())
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "banner-bandit", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 300}, {Variant: "treatment", Weight: 700}},
+		Bandit:   &port.BanditConfig{Algorithm: "thompson", Metric: "click"},
+	})
+	require.NoError(t, err)
+	_, err = svc.StartExperiment(ctx, "banner-bandit")
+	require.NoError(t, err)
+
+	tick := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 20 {
+		// Each tick seeds thompson sampling differently, as the clock does.
+		ticker := newExperimentService(experiments, flags, events, newFakeFlagCache(),
+			service.WithClock(fakeClock{now: tick.Add(time.Duration(i) * 15 * time.Minute)}))
+		require.NoError(t, ticker.RebalanceBandits(ctx))
+	}
+
+	history, err := svc.GetWeightHistory(ctx, "banner-bandit")
+	require.NoError(t, err)
+	assert.Len(t, history, 2, "sampling noise alone does not rewrite the weights")
+}
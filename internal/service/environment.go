@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// CreateEnvironment adds an environment. With req.Parent it inherits every
+// flag from the parent until overridden; otherwise its flags start with the
+// values, rules and targets they have in req.CopyFrom, or in the default
+// environment.
+func (s *Service) CreateEnvironment(ctx context.Context, req port.CreateEnvironmentRequest) (*port.EnvironmentResponse, error) {
+	env := domain.Environment{
+		Key:         req.Key,
+		Description: req.Description,
+		Parent:      req.Parent,
+		CreatedAt:   s.clock.Now().UTC(),
+	}
+	if err := domain.ValidateEnvironment(env); err != nil {
+		return nil, err
+	}
+	if req.Parent != "" && req.CopyFrom != "" {
+		return nil, fmt.Errorf("an environment inherits from its parent or copies another, not both: %w", domain.ErrInvalidEnvironment)
+	}
+
+	source := req.CopyFrom
+	if source == "" && req.Parent == "" {
+		source = domain.DefaultEnvironment
+	}
+	if err := s.store.CreateEnvironment(ctx, env, source); err != nil {
+		return nil, err
+	}
+	return environmentToResponse(env), nil
+}
+
+func (s *Service) ListEnvironments(ctx context.Context) ([]port.EnvironmentResponse, error) {
+	envs, err := s.store.ListEnvironments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.EnvironmentResponse, len(envs))
+	for i, env := range envs {
+		out[i] = *environmentToResponse(env)
+	}
+	return out, nil
+}
+
+func environmentToResponse(env domain.Environment) *port.EnvironmentResponse {
+	return &port.EnvironmentResponse{Key: env.Key, Description: env.Description, Parent: env.Parent, CreatedAt: env.CreatedAt}
+}
@@ -0,0 +1,179 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestService_CreateEnvironment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.CreateEnvironmentRequest
+		wantErr error
+	}{
+		{name: "copies the default environment", req: port.CreateEnvironmentRequest{Key: "staging", Description: "pre-release"}},
+		{name: "copies another environment", req: port.CreateEnvironmentRequest{Key: "qa", CopyFrom: "production"}},
+		{name: "invalid key", req: port.CreateEnvironmentRequest{Key: "Staging"}, wantErr: domain.ErrInvalidEnvironment},
+		{name: "key taken", req: port.CreateEnvironmentRequest{Key: "production"}, wantErr: domain.ErrEnvironmentExists},
+		{name: "unknown source", req: port.CreateEnvironmentRequest{Key: "qa", CopyFrom: "ghost"}, wantErr: domain.ErrEnvironmentNotFound},
+		{name: "inherits from a parent", req: port.CreateEnvironmentRequest{Key: "qa", Parent: "production"}},
+		{name: "unknown parent", req: port.CreateEnvironmentRequest{Key: "qa", Parent: "ghost"}, wantErr: domain.ErrEnvironmentNotFound},
+		{name: "parent and copy", req: port.CreateEnvironmentRequest{Key: "qa", Parent: "production", CopyFrom: "production"}, wantErr: domain.ErrInvalidEnvironment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := newService(newFakeFlagStore(), newFakeFlagCache()).CreateEnvironment(context.Background(), tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.req.Key, resp.Key)
+			assert.Equal(t, tt.req.Description, resp.Description)
+			assert.Equal(t, tt.req.Parent, resp.Parent)
+			assert.False(t, resp.CreatedAt.IsZero())
+		})
+	}
+}
+
+func TestService_EnvironmentsIsolateFlagState(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+	ctx := context.Background()
+	staging := port.WithEnvironment(ctx, "staging")
+
+	_, err := svc.CreateEnvironment(ctx, port.CreateEnvironmentRequest{Key: "staging"})
+	require.NoError(t, err)
+
+	created, err := svc.CreateFlag(staging, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", created.Environment)
+	assert.True(t, created.Enabled)
+
+	updated, err := svc.UpdateFlagValue(staging, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	assert.True(t, *updated.Value.Bool)
+	assert.True(t, *cache.envs["staging"]["new-checkout"].Value.Bool, "cache is keyed by environment")
+
+	prod, err := svc.GetFlag(ctx, "new-checkout")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultEnvironment, prod.Environment)
+	assert.False(t, *prod.Value.Bool, "other environments keep their value")
+
+	value, err := svc.GetFlagValue(staging, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *value.Value.Bool)
+
+	_, err = svc.CreateFlag(port.WithEnvironment(ctx, "ghost"), port.CreateFlagRequest{Name: "other", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.ErrorIs(t, err, domain.ErrEnvironmentNotFound)
+}
+
+func TestService_EnvironmentInheritance(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+	ctx := context.Background()
+	staging := port.WithEnvironment(ctx, "staging")
+	dev := port.WithEnvironment(ctx, "dev")
+
+	_, err := svc.CreateEnvironment(ctx, port.CreateEnvironmentRequest{Key: "staging", Parent: domain.DefaultEnvironment})
+	require.NoError(t, err)
+	_, err = svc.CreateEnvironment(ctx, port.CreateEnvironmentRequest{Key: "dev", Parent: "staging"})
+	require.NoError(t, err)
+
+	created, err := svc.CreateFlag(dev, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	assert.Equal(t, "dev", created.Environment)
+	assert.Equal(t, domain.DefaultEnvironment, created.SourceEnvironment)
+	assert.Empty(t, store.envs["dev"], "an inheriting environment holds no copy")
+
+	// Reading in dev caches the inherited state there.
+	_, err = svc.GetFlagValue(dev, "new-checkout")
+	require.NoError(t, err)
+	require.Contains(t, cache.envs["dev"], "new-checkout")
+
+	_, err = svc.UpdateFlagValue(ctx, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	assert.NotContains(t, cache.envs["dev"], "new-checkout", "a parent change evicts inheriting environments")
+
+	got, err := svc.GetFlag(dev, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *got.Value.Bool)
+	assert.Equal(t, domain.DefaultEnvironment, got.SourceEnvironment)
+
+	overridden, err := svc.UpdateFlagValue(staging, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", overridden.SourceEnvironment)
+	assert.Equal(t, int64(3), overridden.Version, "an override continues the inherited version")
+
+	got, err = svc.GetFlag(dev, "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, *got.Value.Bool)
+	assert.Equal(t, "staging", got.SourceEnvironment, "dev resolves from the nearest override")
+
+	prod, err := svc.GetFlag(ctx, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *prod.Value.Bool, "an override leaves the parent alone")
+
+	reset, err := svc.RemoveFlagOverride(staging, "new-checkout")
+	require.NoError(t, err)
+	assert.True(t, *reset.Value.Bool)
+	assert.Equal(t, domain.DefaultEnvironment, reset.SourceEnvironment)
+	assert.True(t, *cache.envs["staging"]["new-checkout"].Value.Bool)
+
+	_, err = svc.RemoveFlagOverride(ctx, "new-checkout")
+	require.ErrorIs(t, err, domain.ErrInvalidEnvironment)
+}
+
+func TestService_SetFlagEnabled(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:    "new-checkout",
+		Type:    domain.FlagTypeBoolean,
+		Value:   domain.FlagValue{Bool: &off},
+		Targets: map[string]domain.FlagValue{"user-1234": {Bool: &on}},
+		Version: 1,
+	})
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+
+	resp, err := svc.SetFlagEnabled(context.Background(), "new-checkout", port.SetFlagEnabledRequest{Enabled: false})
+	require.NoError(t, err)
+	assert.False(t, resp.Enabled)
+	assert.Equal(t, int64(2), resp.Version)
+	assert.True(t, cache.flags["new-checkout"].Disabled)
+
+	all, err := svc.EvaluateAll(context.Background(), port.EvaluationContext{TargetingKey: "user-1234"}, port.EvaluationFilter{})
+	require.NoError(t, err)
+	got := all.Flags["new-checkout"]
+	assert.Equal(t, string(domain.ReasonDisabled), got.Reason)
+	assert.False(t, *got.Value.Bool, "a disabled flag ignores its targets")
+
+	_, err = svc.SetFlagEnabled(context.Background(), "ghost", port.SetFlagEnabledRequest{Enabled: true})
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var _ port.ExperimentService = (*ExperimentService)(nil)
+
+// ExperimentService manages the experiment lifecycle, metric ingestion and
+// result analysis. Starting or stopping an experiment changes how its flag
+// evaluates, so the updated flag is written through to the cache.
+type ExperimentService struct {
+	experiments port.ExperimentStore
+	flags       port.FlagStore
+	events      port.EventStore
+	cache       port.FlagCache
+	clock       port.Clock
+	logger      *slog.Logger
+	holdout     int
+	freezes     freezeGuard
+	protected   protectionGuard
+}
+
+func NewExperimentService(experiments port.ExperimentStore, flags port.FlagStore, approvals port.ApprovalStore, windows port.FreezeStore, events port.EventStore, cache port.FlagCache, opts ...Option) *ExperimentService {
+	o := newOptions(opts)
+	return &ExperimentService{
+		experiments: experiments, flags: flags, events: events, cache: cache, clock: o.clock, logger: o.logger, holdout: o.holdout,
+		freezes:   freezeGuard{store: flags, windows: windows, clock: o.clock, logger: o.logger},
+		protected: protectionGuard{approvals: approvals},
+	}
+}
+
+// CreateExperiment validates the experiment against its flag's variants and
+// stores it as a draft in the context's project and environment. An experiment in a layer
+// is placed in the first free range of the layer's buckets; layers span all
+// environments.
+func (s *ExperimentService) CreateExperiment(ctx context.Context, req port.CreateExperimentRequest) (*port.ExperimentResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	flag, err := s.flags.GetByName(ctx, project, env, req.FlagName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	exp := domain.Experiment{
+		Key:         req.Key,
+		Project:     project,
+		FlagName:    req.FlagName,
+		Environment: env,
+		Allocation:  req.Allocation,
+		Variants:    weightsToDomain(req.Variants),
+		Layer:       req.Layer,
+		Bandit:      banditToDomain(req.Bandit),
+		Status:      domain.ExperimentDraft,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := domain.ValidateExperiment(exp, *flag); err != nil {
+		return nil, err
+	}
+
+	created, err := s.experiments.Create(ctx, exp)
+	if err != nil {
+		return nil, err
+	}
+	return experimentToResponse(*created), nil
+}
+
+func (s *ExperimentService) GetExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	exp, err := s.experiments.GetByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return experimentToResponse(*exp), nil
+}
+
+func (s *ExperimentService) ListExperiments(ctx context.Context, filter port.ExperimentFilter) ([]port.ExperimentResponse, error) {
+	filter.Project = port.ProjectFrom(ctx)
+	filter.Environment = port.EnvironmentFrom(ctx)
+	experiments, err := s.experiments.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.ExperimentResponse, len(experiments))
+	for i, exp := range experiments {
+		out[i] = *experimentToResponse(exp)
+	}
+	return out, nil
+}
+
+// StartExperiment begins splitting the flag's traffic between the
+// experiment's variants. The current global holdout is fixed on the
+// experiment so its population does not shift while it runs.
+func (s *ExperimentService) StartExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	if err := s.checkChange(ctx, key); err != nil {
+		return nil, err
+	}
+	exp, flag, err := s.experiments.Start(ctx, key, s.clock.Now().UTC(), s.holdout)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheFlag(ctx, *flag)
+	return experimentToResponse(*exp), nil
+}
+
+// StopExperiment ends the experiment; its flag goes back to serving its
+// targets, rules and stored value.
+func (s *ExperimentService) StopExperiment(ctx context.Context, key string) (*port.ExperimentResponse, error) {
+	if err := s.checkChange(ctx, key); err != nil {
+		return nil, err
+	}
+	exp, flag, err := s.experiments.Stop(ctx, key, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.cacheFlag(ctx, *flag)
+	return experimentToResponse(*exp), nil
+}
+
+// checkChange refuses starting or stopping the experiment called key when
+// guard refuses changing it.
+func (s *ExperimentService) checkChange(ctx context.Context, key string) error {
+	exp, err := s.experiments.GetByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.guard(ctx, *exp)
+}
+
+// guard refuses changing exp when its flag is protected in the experiment's
+// environment or a freeze window covers it.
+func (s *ExperimentService) guard(ctx context.Context, exp domain.Experiment) error {
+	if err := s.protected.check(ctx, exp.Project, exp.Environment, exp.FlagName); err != nil {
+		return err
+	}
+	named := s.freezes.named(exp.Project, exp.Environment, exp.FlagName)
+	return s.freezes.check(ctx, exp.Project, []string{exp.Environment}, named)
+}
+
+// RecordMetrics validates and stores a batch of metric events. Events without
+// a timestamp are stamped with the current time.
+func (s *ExperimentService) RecordMetrics(ctx context.Context, req port.RecordMetricsRequest) error {
+	now := s.clock.Now().UTC()
+	events := make([]domain.MetricEvent, len(req.Events))
+	for i, e := range req.Events {
+		occurredAt := e.OccurredAt.UTC()
+		if e.OccurredAt.IsZero() {
+			occurredAt = now
+		}
+		events[i] = domain.MetricEvent{Metric: e.Metric, TargetingKey: e.TargetingKey, Value: e.Value, OccurredAt: occurredAt}
+	}
+	if err := domain.ValidateMetricEvents(events); err != nil {
+		return err
+	}
+	return s.events.RecordMetrics(ctx, events)
+}
+
+// GetExperimentResults joins the experiment's exposures with events of metric
+// and compares every variant with the control.
+func (s *ExperimentService) GetExperimentResults(ctx context.Context, key, metric string) (*port.ExperimentResultsResponse, error) {
+	if metric == "" {
+		return nil, fmt.Errorf("metric is required: %w", domain.ErrInvalidMetric)
+	}
+	exp, err := s.experiments.GetByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.events.VariantStats(ctx, key, metric)
+	if err != nil {
+		return nil, err
+	}
+	return resultsToResponse(domain.AnalyzeExperiment(*exp, metric, stats)), nil
+}
+
+// CreateLayer stores a new, empty mutually exclusive layer.
+func (s *ExperimentService) CreateLayer(ctx context.Context, req port.CreateLayerRequest) (*port.LayerResponse, error) {
+	layer := domain.Layer{Key: req.Key, Description: req.Description, CreatedAt: s.clock.Now().UTC()}
+	if err := domain.ValidateLayer(layer); err != nil {
+		return nil, err
+	}
+	if err := s.experiments.CreateLayer(ctx, layer); err != nil {
+		return nil, err
+	}
+	return layerToResponse(layer, nil), nil
+}
+
+// GetLayer returns the layer with the bucket ranges its live experiments hold.
+func (s *ExperimentService) GetLayer(ctx context.Context, key string) (*port.LayerResponse, error) {
+	layer, err := s.experiments.GetLayer(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.experiments.List(ctx, port.ExperimentFilter{Layer: key})
+	if err != nil {
+		return nil, err
+	}
+	return layerToResponse(*layer, members), nil
+}
+
+func (s *ExperimentService) ListLayers(ctx context.Context) ([]port.LayerResponse, error) {
+	layers, err := s.experiments.ListLayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	experiments, err := s.experiments.List(ctx, port.ExperimentFilter{})
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string][]domain.Experiment)
+	for _, exp := range experiments {
+		if exp.Layer != "" {
+			members[exp.Layer] = append(members[exp.Layer], exp)
+		}
+	}
+	out := make([]port.LayerResponse, len(layers))
+	for i, layer := range layers {
+		out[i] = *layerToResponse(layer, members[layer.Key])
+	}
+	return out, nil
+}
+
+func (s *ExperimentService) cacheFlag(ctx context.Context, flag domain.Flag) {
+	if err := s.cache.Set(ctx, flag); err != nil {
+		s.logger.WarnContext(ctx, "cache write failed", "project", flag.Project, "flag", flag.Name, "environment", flag.Environment, "error", err)
+	}
+}
+
+func weightsToDomain(weights []port.VariantWeight) []domain.VariantWeight {
+	out := make([]domain.VariantWeight, len(weights))
+	for i, w := range weights {
+		out[i] = domain.VariantWeight{Variant: w.Variant, Weight: w.Weight}
+	}
+	return out
+}
+
+func resultsToResponse(results domain.ExperimentResults) *port.ExperimentResultsResponse {
+	variants := make([]port.VariantResult, len(results.Variants))
+	for i, r := range results.Variants {
+		variants[i] = port.VariantResult{
+			Variant:          r.Variant,
+			Users:            r.Users,
+			Conversions:      r.Conversions,
+			ConversionRate:   r.ConversionRate,
+			ConversionCI:     port.Interval{Low: r.ConversionCI.Low, High: r.ConversionCI.High},
+			Mean:             r.Mean,
+			MeanCI:           port.Interval{Low: r.MeanCI.Low, High: r.MeanCI.High},
+			ConversionPValue: r.ConversionPValue,
+			MeanPValue:       r.MeanPValue,
+			Significant:      r.Significant,
+		}
+	}
+	return &port.ExperimentResultsResponse{
+		ExperimentKey:   results.ExperimentKey,
+		Metric:          results.Metric,
+		Control:         results.Control,
+		ConfidenceLevel: results.ConfidenceLevel,
+		Variants:        variants,
+	}
+}
+
+// layerToResponse lists the ranges held by the layer's live members, ordered by
+// offset. Stopped experiments no longer hold buckets.
+func layerToResponse(layer domain.Layer, members []domain.Experiment) *port.LayerResponse {
+	slots := []port.LayerSlot{}
+	free := domain.LayerBuckets
+	for _, m := range members {
+		if m.Status == domain.ExperimentStopped {
+			continue
+		}
+		slots = append(slots, port.LayerSlot{Experiment: m.Key, Offset: m.LayerOffset, Allocation: m.Allocation, Status: string(m.Status)})
+		free -= m.Allocation
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Offset < slots[j].Offset })
+	return &port.LayerResponse{
+		Key:         layer.Key,
+		Description: layer.Description,
+		Slots:       slots,
+		Free:        free,
+		CreatedAt:   layer.CreatedAt,
+	}
+}
+
+func weightsToResponse(weights []domain.VariantWeight) []port.VariantWeight {
+	out := make([]port.VariantWeight, len(weights))
+	for i, w := range weights {
+		out[i] = port.VariantWeight{Variant: w.Variant, Weight: w.Weight}
+	}
+	return out
+}
+
+func experimentToResponse(exp domain.Experiment) *port.ExperimentResponse {
+	return &port.ExperimentResponse{
+		Key:         exp.Key,
+		Project:     exp.Project,
+		FlagName:    exp.FlagName,
+		Environment: exp.Environment,
+		Allocation:  exp.Allocation,
+		Variants:    weightsToResponse(exp.Variants),
+		Layer:       exp.Layer,
+		LayerOffset: exp.LayerOffset,
+		Holdout:     exp.Holdout,
+		Bandit:      banditToResponse(exp.Bandit),
+		Status:      string(exp.Status),
+		StartedAt:   exp.StartedAt,
+		StoppedAt:   exp.StoppedAt,
+		CreatedAt:   exp.CreatedAt,
+		UpdatedAt:   exp.UpdatedAt,
+	}
+}
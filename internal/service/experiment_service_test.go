@@ -0,0 +1,496 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+// fakeExperimentStore is an in-memory hand-written fake implementing
+// port.ExperimentStore. Start and Stop attach the experiment to, and detach it
+// from, the flag held by flags in the experiment's environment.
+type fakeExperimentStore struct {
+	experiments map[string]domain.Experiment
+	layers      map[string]domain.Layer
+	history     map[string][]domain.WeightChange
+	flags       *fakeFlagStore
+}
+
+func newFakeExperimentStore(flags *fakeFlagStore) *fakeExperimentStore {
+	return &fakeExperimentStore{
+		experiments: make(map[string]domain.Experiment),
+		layers:      make(map[string]domain.Layer),
+		history:     make(map[string][]domain.WeightChange),
+		flags:       flags,
+	}
+}
+
+func (f *fakeExperimentStore) Create(ctx context.Context, exp domain.Experiment) (*domain.Experiment, error) {
+	if _, exists := f.experiments[exp.Key]; exists {
+		return nil, domain.ErrExperimentExists
+	}
+	if exp.Layer != "" {
+		if _, ok := f.layers[exp.Layer]; !ok {
+			return nil, domain.ErrLayerNotFound
+		}
+		members, _ := f.List(ctx, port.ExperimentFilter{Layer: exp.Layer})
+		offset, ok := domain.PlaceInLayer(members, exp.Allocation)
+		if !ok {
+			return nil, domain.ErrLayerFull
+		}
+		exp.LayerOffset = offset
+	}
+	f.experiments[exp.Key] = exp
+	return &exp, nil
+}
+
+func (f *fakeExperimentStore) GetByKey(_ context.Context, key string) (*domain.Experiment, error) {
+	exp, ok := f.experiments[key]
+	if !ok {
+		return nil, domain.ErrExperimentNotFound
+	}
+	return &exp, nil
+}
+
+func (f *fakeExperimentStore) List(_ context.Context, filter port.ExperimentFilter) ([]domain.Experiment, error) {
+	var out []domain.Experiment
+	for _, exp := range f.experiments {
+		if (filter.FlagName == "" || exp.FlagName == filter.FlagName) &&
+			(filter.Project == "" || exp.Project == filter.Project) &&
+			(filter.Environment == "" || exp.Environment == filter.Environment) &&
+			(filter.Layer == "" || exp.Layer == filter.Layer) &&
+			(filter.Status == "" || string(exp.Status) == filter.Status) {
+			out = append(out, exp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeExperimentStore) Start(_ context.Context, key string, at time.Time, holdout int) (*domain.Experiment, *domain.Flag, error) {
+	exp, ok := f.experiments[key]
+	if !ok {
+		return nil, nil, domain.ErrExperimentNotFound
+	}
+	flag := f.flags.project(exp.Project)[exp.Environment][exp.FlagName]
+	if exp.Status != domain.ExperimentDraft || flag.Experiment != nil {
+		return nil, nil, domain.ErrExperimentState
+	}
+	exp.Status = domain.ExperimentRunning
+	exp.StartedAt = &at
+	exp.Holdout = holdout
+	f.experiments[key] = exp
+	if exp.Bandit != nil {
+		f.history[key] = append(f.history[key], domain.WeightChange{ExperimentKey: key, Variants: exp.Variants, ChangedAt: at})
+	}
+	flag.Experiment = &exp
+	flag.Version++
+	f.flags.project(exp.Project)[exp.Environment][flag.Name] = flag
+	return &exp, &flag, nil
+}
+
+func (f *fakeExperimentStore) Stop(_ context.Context, key string, at time.Time) (*domain.Experiment, *domain.Flag, error) {
+	exp, ok := f.experiments[key]
+	if !ok {
+		return nil, nil, domain.ErrExperimentNotFound
+	}
+	if exp.Status != domain.ExperimentRunning {
+		return nil, nil, domain.ErrExperimentState
+	}
+	exp.Status = domain.ExperimentStopped
+	exp.StoppedAt = &at
+	f.experiments[key] = exp
+	flag := f.flags.project(exp.Project)[exp.Environment][exp.FlagName]
+	flag.Experiment = nil
+	flag.Version++
+	f.flags.project(exp.Project)[exp.Environment][flag.Name] = flag
+	return &exp, &flag, nil
+}
+
+func (f *fakeExperimentStore) UpdateWeights(_ context.Context, key string, weights []domain.VariantWeight, at time.Time) (*domain.Experiment, *domain.Flag, error) {
+	exp, ok := f.experiments[key]
+	if !ok {
+		return nil, nil, domain.ErrExperimentNotFound
+	}
+	if exp.Status != domain.ExperimentRunning {
+		return nil, nil, domain.ErrExperimentState
+	}
+	exp.Variants = weights
+	exp.UpdatedAt = at
+	f.experiments[key] = exp
+	f.history[key] = append(f.history[key], domain.WeightChange{ExperimentKey: key, Variants: weights, ChangedAt: at})
+	flag := f.flags.project(exp.Project)[exp.Environment][exp.FlagName]
+	flag.Experiment = &exp
+	flag.Version++
+	f.flags.project(exp.Project)[exp.Environment][flag.Name] = flag
+	return &exp, &flag, nil
+}
+
+func (f *fakeExperimentStore) WeightHistory(_ context.Context, key string) ([]domain.WeightChange, error) {
+	return f.history[key], nil
+}
+
+func (f *fakeExperimentStore) CreateLayer(_ context.Context, layer domain.Layer) error {
+	if _, exists := f.layers[layer.Key]; exists {
+		return domain.ErrLayerExists
+	}
+	f.layers[layer.Key] = layer
+	return nil
+}
+
+func (f *fakeExperimentStore) GetLayer(_ context.Context, key string) (*domain.Layer, error) {
+	layer, ok := f.layers[key]
+	if !ok {
+		return nil, domain.ErrLayerNotFound
+	}
+	return &layer, nil
+}
+
+func (f *fakeExperimentStore) ListLayers(_ context.Context) ([]domain.Layer, error) {
+	var out []domain.Layer
+	for _, layer := range f.layers {
+		out = append(out, layer)
+	}
+	return out, nil
+}
+
+// fakeEventStore records events in memory and returns the configured stats.
+// Setting err makes every call fail with it.
+type fakeEventStore struct {
+	exposures []domain.ExposureEvent
+	metrics   []domain.MetricEvent
+	stats     []domain.VariantStats
+	err       error
+}
+
+func (f *fakeEventStore) RecordMetrics(_ context.Context, events []domain.MetricEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.metrics = append(f.metrics, events...)
+	return nil
+}
+
+func (f *fakeEventStore) VariantStats(_ context.Context, _, _ string) ([]domain.VariantStats, error) {
+	return f.stats, f.err
+}
+
+func (f *fakeEventStore) RecordExposures(_ context.Context, events []domain.ExposureEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.exposures = append(f.exposures, events...)
+	return nil
+}
+
+func newMultivariateStore() *fakeFlagStore {
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:  "checkout",
+		Type:  domain.FlagTypeBoolean,
+		Value: domain.FlagValue{Bool: &off},
+		Variants: []domain.Variant{
+			{Key: "control", Value: domain.FlagValue{Bool: &off}},
+			{Key: "treatment", Value: domain.FlagValue{Bool: &on}},
+		},
+		Version: 1,
+	})
+	return store
+}
+
+func newExperimentService(experiments *fakeExperimentStore, flags *fakeFlagStore, events *fakeEventStore, cache *fakeFlagCache, opts ...service.Option) *service.ExperimentService {
+	return service.NewExperimentService(experiments, flags, flags, flags, events, cache, append([]service.Option{
+		service.WithLogger(slog.New(slog.DiscardHandler)),
+		service.WithClock(fakeClock{now: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}),
+	}, opts...)...)
+}
+
+func TestExperimentService_CreateExperiment(t *testing.T) {
+	t.Parallel()
+
+	weights := []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+
+	tests := []struct {
+		name    string
+		req     port.CreateExperimentRequest
+		wantErr error
+	}{
+		{
+			name: "creates draft",
+			req:  port.CreateExperimentRequest{Key: "checkout-test", FlagName: "checkout", Allocation: 40, Variants: weights},
+		},
+		{
+			name:    "unknown flag",
+			req:     port.CreateExperimentRequest{Key: "checkout-test", FlagName: "ghost", Allocation: 40, Variants: weights},
+			wantErr: domain.ErrNotFound,
+		},
+		{
+			name: "unknown variant",
+			req: port.CreateExperimentRequest{Key: "checkout-test", FlagName: "checkout", Allocation: 40, Variants: []port.VariantWeight{
+				{Variant: "control", Weight: 1}, {Variant: "ghost", Weight: 1},
+			}},
+			wantErr: domain.ErrInvalidExperiment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := newMultivariateStore()
+			experiments := newFakeExperimentStore(flags)
+
+			resp, err := newExperimentService(experiments, flags, &fakeEventStore{}, newFakeFlagCache()).CreateExperiment(context.Background(), tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "draft", resp.Status)
+			assert.Equal(t, weights, resp.Variants)
+			assert.Contains(t, experiments.experiments, "checkout-test")
+		})
+	}
+}
+
+func TestExperimentService_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	cache := newFakeFlagCache()
+	svc := newExperimentService(experiments, flags, &fakeEventStore{}, cache)
+	ctx := context.Background()
+
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "checkout-test", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+
+	started, err := svc.StartExperiment(ctx, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, "running", started.Status)
+	require.NotNil(t, started.StartedAt)
+	require.NotNil(t, cache.flags["checkout"].Experiment, "running experiment is written through to the cache")
+
+	_, err = svc.StartExperiment(ctx, "checkout-test")
+	require.ErrorIs(t, err, domain.ErrExperimentState)
+
+	stopped, err := svc.StopExperiment(ctx, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", stopped.Status)
+	assert.Nil(t, cache.flags["checkout"].Experiment)
+
+	list, err := svc.ListExperiments(ctx, port.ExperimentFilter{FlagName: "checkout"})
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	_, err = svc.GetExperiment(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrExperimentNotFound)
+}
+
+func TestExperimentService_Layers(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	svc := newExperimentService(experiments, flags, &fakeEventStore{}, newFakeFlagCache())
+	ctx := context.Background()
+	weights := []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}}
+	create := func(key string, allocation int, layer string) (*port.ExperimentResponse, error) {
+		return svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+			Key: key, FlagName: "checkout", Allocation: allocation, Variants: weights, Layer: layer,
+		})
+	}
+
+	_, err := svc.CreateLayer(ctx, port.CreateLayerRequest{Key: "checkout", Description: "checkout page"})
+	require.NoError(t, err)
+	_, err = svc.CreateLayer(ctx, port.CreateLayerRequest{Key: "checkout"})
+	require.ErrorIs(t, err, domain.ErrLayerExists)
+	_, err = svc.CreateLayer(ctx, port.CreateLayerRequest{Key: "Not Valid"})
+	require.ErrorIs(t, err, domain.ErrInvalidLayer)
+
+	first, err := create("copy-test", 40, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 0, first.LayerOffset)
+	second, err := create("layout-test", 60, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 40, second.LayerOffset)
+
+	_, err = create("price-test", 10, "checkout")
+	require.ErrorIs(t, err, domain.ErrLayerFull)
+	_, err = create("price-test", 10, "ghost")
+	require.ErrorIs(t, err, domain.ErrLayerNotFound)
+
+	layer, err := svc.GetLayer(ctx, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 0, layer.Free)
+	assert.Equal(t, []port.LayerSlot{
+		{Experiment: "copy-test", Offset: 0, Allocation: 40, Status: "draft"},
+		{Experiment: "layout-test", Offset: 40, Allocation: 60, Status: "draft"},
+	}, layer.Slots)
+
+	_, err = svc.StartExperiment(ctx, "copy-test")
+	require.NoError(t, err)
+	_, err = svc.StopExperiment(ctx, "copy-test")
+	require.NoError(t, err)
+
+	layers, err := svc.ListLayers(ctx)
+	require.NoError(t, err)
+	require.Len(t, layers, 1)
+	assert.Equal(t, 40, layers[0].Free, "stopped experiments release their buckets")
+
+	third, err := create("price-test", 10, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 0, third.LayerOffset)
+}
+
+func TestExperimentService_StartAppliesHoldout(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	cache := newFakeFlagCache()
+	svc := newExperimentService(experiments, flags, &fakeEventStore{}, cache, service.WithHoldout(5))
+	ctx := context.Background()
+
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "checkout-test", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+
+	started, err := svc.StartExperiment(ctx, "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, 5, started.Holdout)
+	require.NotNil(t, cache.flags["checkout"].Experiment)
+	assert.Equal(t, 5, cache.flags["checkout"].Experiment.Holdout, "evaluation sees the holdout")
+}
+
+func TestService_EvaluateAll_RecordsExposures(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	_, _ = experiments.Create(context.Background(), domain.Experiment{
+		Key: "checkout-test", FlagName: "checkout", Environment: domain.DefaultEnvironment, Allocation: 100, Status: domain.ExperimentDraft,
+		Variants: []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	_, _, _ = experiments.Start(context.Background(), "checkout-test", time.Now(), 0)
+	events := &fakeEventStore{}
+	svc := newService(flags, newFakeFlagCache(), service.WithEventStore(events))
+
+	resp, err := svc.EvaluateAll(context.Background(), port.EvaluationContext{TargetingKey: "user-1"}, port.EvaluationFilter{})
+	require.NoError(t, err)
+
+	got := resp.Flags["checkout"]
+	assert.Equal(t, string(domain.ReasonSplit), got.Reason)
+	assert.Equal(t, "checkout-test", got.Experiment)
+	require.Len(t, events.exposures, 1)
+	assert.Equal(t, "checkout-test", events.exposures[0].ExperimentKey)
+	assert.Equal(t, got.Variant, events.exposures[0].Variant)
+	assert.Equal(t, "user-1", events.exposures[0].TargetingKey)
+
+	_, err = svc.EvaluateAll(context.Background(), port.EvaluationContext{}, port.EvaluationFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events.exposures, 1, "anonymous contexts are not enrolled")
+
+	events.err = errors.New("db down")
+	_, err = svc.EvaluateAll(context.Background(), port.EvaluationContext{TargetingKey: "user-2"}, port.EvaluationFilter{})
+	require.NoError(t, err, "exposure failures do not fail evaluation")
+}
+
+func TestExperimentService_RecordMetrics(t *testing.T) {
+	t.Parallel()
+
+	occurred := time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		events  []port.MetricEvent
+		wantErr error
+		wantAt  []time.Time
+	}{
+		{
+			name: "stamps missing timestamps",
+			events: []port.MetricEvent{
+				{Metric: "purchase", TargetingKey: "user-1", Value: 19.99, OccurredAt: occurred},
+				{Metric: "signup", TargetingKey: "user-2", Value: 1},
+			},
+			wantAt: []time.Time{occurred, time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "empty batch",
+			wantErr: domain.ErrInvalidMetric,
+		},
+		{
+			name:    "missing targeting key",
+			events:  []port.MetricEvent{{Metric: "purchase", Value: 1}},
+			wantErr: domain.ErrInvalidMetric,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flags := newMultivariateStore()
+			events := &fakeEventStore{}
+			svc := newExperimentService(newFakeExperimentStore(flags), flags, events, newFakeFlagCache())
+
+			err := svc.RecordMetrics(context.Background(), port.RecordMetricsRequest{Events: tt.events})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, events.metrics)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, events.metrics, len(tt.wantAt))
+			for i, at := range tt.wantAt {
+				assert.Equal(t, at, events.metrics[i].OccurredAt)
+			}
+		})
+	}
+}
+
+func TestExperimentService_GetExperimentResults(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	_, _ = experiments.Create(context.Background(), domain.Experiment{
+		Key: "checkout-test", FlagName: "checkout", Environment: domain.DefaultEnvironment, Allocation: 100, Status: domain.ExperimentRunning,
+		Variants: []domain.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	events := &fakeEventStore{stats: []domain.VariantStats{
+		{Variant: "control", Users: 1000, Conversions: 100, Sum: 100, SumSquares: 100},
+		{Variant: "treatment", Users: 1000, Conversions: 150, Sum: 150, SumSquares: 150},
+	}}
+	svc := newExperimentService(experiments, flags, events, newFakeFlagCache())
+
+	resp, err := svc.GetExperimentResults(context.Background(), "checkout-test", "purchase")
+	require.NoError(t, err)
+	assert.Equal(t, "control", resp.Control)
+	require.Len(t, resp.Variants, 2)
+	assert.InDelta(t, 0.15, resp.Variants[1].ConversionRate, 1e-9)
+	assert.True(t, resp.Variants[1].Significant)
+
+	_, err = svc.GetExperimentResults(context.Background(), "checkout-test", "")
+	require.ErrorIs(t, err, domain.ErrInvalidMetric)
+
+	_, err = svc.GetExperimentResults(context.Background(), "ghost", "purchase")
+	require.ErrorIs(t, err, domain.ErrExperimentNotFound)
+}
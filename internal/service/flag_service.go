@@ -2,25 +2,60 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/xNakero/feature-flags/internal/domain"
 	"github.com/xNakero/feature-flags/internal/port"
 )
 
+var _ port.FlagService = (*Service)(nil)
+
 type Service struct {
-	store port.FlagStore
+	store     port.FlagStore
+	approvals port.ApprovalStore
+	windows   port.FreezeStore
+	cache     port.FlagCache
+	events    port.EventStore
+	clock     port.Clock
+	logger    *slog.Logger
+	naming    domain.NamingPolicy
+	changeTTL time.Duration
+	freezes   freezeGuard
+	protected protectionGuard
 }
 
-func New(store port.FlagStore) *Service {
-	return &Service{store: store}
+func New(store port.FlagStore, approvals port.ApprovalStore, windows port.FreezeStore, cache port.FlagCache, opts ...Option) *Service {
+	o := newOptions(opts)
+	return &Service{
+		store: store, approvals: approvals, windows: windows, cache: cache, events: o.events, clock: o.clock, logger: o.logger,
+		naming: o.naming, changeTTL: o.changeTTL, freezes: freezeGuard{store: store, windows: windows, clock: o.clock, logger: o.logger},
+		protected: protectionGuard{approvals: approvals},
+	}
 }
 
+// CreateFlag defines the flag in every environment with the requested value
+// and rules, and returns its state in the context's environment. Environments
+// with a parent inherit the state rather than holding a copy.
 func (s *Service) CreateFlag(ctx context.Context, req port.CreateFlagRequest) (*port.FlagResponse, error) {
-	if err := domain.ValidateFlagName(req.Name); err != nil {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	if err := s.naming.ValidateName(project, req.Name); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetProject(ctx, project); err != nil {
+		return nil, err
+	}
+	envs, err := s.store.ListEnvironments(ctx)
+	if err != nil {
 		return nil, err
 	}
+	lineage := domain.Lineage(envs, env)
+	if len(lineage) == 0 {
+		return nil, fmt.Errorf("environment %q: %w", env, domain.ErrEnvironmentNotFound)
+	}
 
 	flagType, err := parseFlagType(req.Type)
 	if err != nil {
@@ -32,23 +67,387 @@ func (s *Service) CreateFlag(ctx context.Context, req port.CreateFlagRequest) (*
 		return nil, err
 	}
 
-	now := time.Now().UTC()
+	rules := rulesToDomain(req.Rules)
+	if err := domain.ValidateRules(flagType, rules); err != nil {
+		return nil, err
+	}
+
+	variants := variantsToDomain(req.Variants)
+	if err := domain.ValidateVariants(flagType, variants); err != nil {
+		return nil, err
+	}
+
+	if err := domain.ValidateTags(req.Tags); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
 	flag := domain.Flag{
-		Name:        req.Name,
-		Type:        flagType,
-		Description: req.Description,
-		Value:       domainValue,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		Project:           project,
+		Name:              req.Name,
+		Type:              flagType,
+		Description:       req.Description,
+		Tags:              req.Tags,
+		Environment:       env,
+		SourceEnvironment: lineage[len(lineage)-1],
+		Value:             domainValue,
+		Rules:             rules,
+		Variants:          variants,
+		Version:           1,
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
+	if err := s.freezes.check(ctx, project, domain.Roots(envs), these(flag)); err != nil {
+		return nil, err
+	}
 	if err := s.store.Create(ctx, flag); err != nil {
 		return nil, err
 	}
+	s.cacheFlag(ctx, flag)
 
 	return flagToResponse(flag), nil
 }
 
+// GetFlag reads the flag from the store, or from its history when the context
+// asks for it as of an earlier instant.
+func (s *Service) GetFlag(ctx context.Context, name string) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	var (
+		flag *domain.Flag
+		err  error
+	)
+	if at := port.AsOfFrom(ctx); !at.IsZero() {
+		flag, err = s.store.GetAsOf(ctx, project, env, name, at)
+	} else {
+		flag, err = s.store.GetByName(ctx, project, env, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return flagToResponse(*flag), nil
+}
+
+// GetFlagValue evaluates the flag, reading it from the cache first and falling
+// back to the store on a miss or cache failure. A store read repopulates the cache.
+// A point-in-time read bypasses the cache and evaluates the flag as it was at
+// that instant, time-based rules included.
+func (s *Service) GetFlagValue(ctx context.Context, name string) (*port.FlagValueResponse, error) {
+	if at := port.AsOfFrom(ctx); !at.IsZero() {
+		flag, err := s.store.GetAsOf(ctx, port.ProjectFrom(ctx), port.EnvironmentFrom(ctx), name, at)
+		if err != nil {
+			return nil, err
+		}
+		return evaluationToResponse(domain.Evaluate(*flag, domain.EvaluationContext{}, at), domain.SourceHistory), nil
+	}
+	flag, source, err := s.loadFlag(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return evaluationToResponse(domain.Evaluate(*flag, domain.EvaluationContext{}, s.clock.Now()), source), nil
+}
+
+// EvaluateAll evaluates every flag selected by filter for evalCtx. Cached flags
+// are fetched with one multi-key cache read; the misses are loaded from the
+// store with one query and written back to the cache.
+func (s *Service) EvaluateAll(ctx context.Context, evalCtx port.EvaluationContext, filter port.EvaluationFilter) (*port.EvaluateAllResponse, error) {
+	names, err := s.selectNames(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &port.EvaluateAllResponse{Flags: make(map[string]port.FlagValueResponse, len(names))}
+	if len(names) == 0 {
+		return resp, nil
+	}
+
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	domainCtx := domain.EvaluationContext{TargetingKey: evalCtx.TargetingKey, Attributes: evalCtx.Attributes}
+	now := s.clock.Now()
+
+	cached, err := s.cache.GetMany(ctx, project, env, names)
+	if err != nil {
+		s.logger.WarnContext(ctx, "cache read failed, falling back to store", "error", err)
+		cached = nil
+	}
+
+	var (
+		misses    []string
+		exposures []domain.ExposureEvent
+	)
+	for _, name := range names {
+		flag, ok := cached[name]
+		if !ok {
+			misses = append(misses, name)
+			continue
+		}
+		eval := domain.Evaluate(flag, domainCtx, now)
+		exposures = appendExposure(exposures, flag.Name, eval, domainCtx, now)
+		resp.Flags[name] = *evaluationToResponse(eval, domain.SourceCache)
+	}
+
+	if len(misses) > 0 {
+		stored, err := s.store.GetByNames(ctx, project, env, misses)
+		if err != nil {
+			return nil, err
+		}
+		for _, flag := range stored {
+			s.cacheFlag(ctx, flag)
+			eval := domain.Evaluate(flag, domainCtx, now)
+			exposures = appendExposure(exposures, flag.Name, eval, domainCtx, now)
+			resp.Flags[flag.Name] = *evaluationToResponse(eval, domain.SourceStore)
+		}
+	}
+
+	s.recordExposures(ctx, exposures)
+	return resp, nil
+}
+
+// appendExposure adds an exposure event when eval assigned the context to an
+// experiment variant.
+func appendExposure(events []domain.ExposureEvent, flagName string, eval domain.Evaluation, evalCtx domain.EvaluationContext, now time.Time) []domain.ExposureEvent {
+	if eval.Reason != domain.ReasonSplit || eval.Experiment == "" {
+		return events
+	}
+	return append(events, domain.ExposureEvent{
+		ExperimentKey: eval.Experiment,
+		FlagName:      flagName,
+		Variant:       eval.Variant,
+		TargetingKey:  evalCtx.TargetingKey,
+		ExposedAt:     now.UTC(),
+	})
+}
+
+// recordExposures persists exposure events. Failures are logged and otherwise
+// ignored so experiment bookkeeping never fails an evaluation.
+func (s *Service) recordExposures(ctx context.Context, events []domain.ExposureEvent) {
+	if s.events == nil || len(events) == 0 {
+		return
+	}
+	if err := s.events.RecordExposures(ctx, events); err != nil {
+		s.logger.WarnContext(ctx, "recording exposures failed", "count", len(events), "error", err)
+	}
+}
+
+func (s *Service) selectNames(ctx context.Context, filter port.EvaluationFilter) ([]string, error) {
+	if len(filter.Names) == 0 {
+		return s.store.ListNames(ctx, port.ProjectFrom(ctx), filter.Prefix)
+	}
+	names := make([]string, 0, len(filter.Names))
+	for _, name := range filter.Names {
+		if strings.HasPrefix(name, filter.Prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// UpdateFlagValue changes the flag's value and writes the result through to
+// the cache, unless the flag is protected in the context's environment: then
+// the change is recorded as a change request to be approved by others.
+func (s *Service) UpdateFlagValue(ctx context.Context, name string, req port.UpdateFlagValueRequest) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	existing, err := s.store.GetByName(ctx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+
+	domainValue := domain.FlagValue{Bool: req.Value.Bool, Numeric: req.Value.Numeric}
+	if err := domain.ValidateFlagValue(existing.Type, domainValue); err != nil {
+		return nil, err
+	}
+
+	protection, err := s.approvals.GetProtection(ctx, project, name)
+	if err != nil {
+		return nil, err
+	}
+	if protection.Covers(env) {
+		return s.requestChange(ctx, *existing, domainValue, *protection)
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, these(*existing)); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.UpdateValue(ctx, project, env, name, domainValue, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// UpdateFlagRules replaces the flag's targeting rules and writes the result
+// through to the cache.
+func (s *Service) UpdateFlagRules(ctx context.Context, name string, req port.UpdateFlagRulesRequest) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	existing, err := s.store.GetByName(ctx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := rulesToDomain(req.Rules)
+	if err := domain.ValidateRules(existing.Type, rules); err != nil {
+		return nil, err
+	}
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, these(*existing)); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.UpdateRules(ctx, project, env, name, rules, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// AddTargets forces req.Value for each targeting key and writes the result
+// through to the cache.
+func (s *Service) AddTargets(ctx context.Context, name string, req port.AddTargetsRequest) (*port.FlagResponse, error) {
+	if err := domain.ValidateTargetKeys(req.Keys); err != nil {
+		return nil, err
+	}
+
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	existing, err := s.store.GetByName(ctx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+
+	domainValue := domain.FlagValue{Bool: req.Value.Bool, Numeric: req.Value.Numeric}
+	if err := domain.ValidateFlagValue(existing.Type, domainValue); err != nil {
+		return nil, err
+	}
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, these(*existing)); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.store.AddTargets(ctx, project, env, name, req.Keys, domainValue, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// RemoveTargets drops individual targets and writes the result through to the
+// cache.
+func (s *Service) RemoveTargets(ctx context.Context, name string, req port.RemoveTargetsRequest) (*port.FlagResponse, error) {
+	if err := domain.ValidateTargetKeys(req.Keys); err != nil {
+		return nil, err
+	}
+
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, s.freezes.named(project, env, name)); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.RemoveTargets(ctx, project, env, name, req.Keys, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// RemoveFlagOverride deletes the flag's own state in the context's
+// environment and writes the inherited state through to the cache.
+func (s *Service) RemoveFlagOverride(ctx context.Context, name string) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, s.freezes.named(project, env, name)); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.RemoveOverride(ctx, project, env, name, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// SetFlagEnabled switches the flag on or off in the context's environment and
+// writes the result through to the cache. A disabled flag serves its value.
+func (s *Service) SetFlagEnabled(ctx context.Context, name string, req port.SetFlagEnabledRequest) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, s.freezes.named(project, env, name)); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.UpdateEnabled(ctx, project, env, name, req.Enabled, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+func (s *Service) loadFlag(ctx context.Context, name string) (*domain.Flag, domain.ValueSource, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	flag, err := s.cache.Get(ctx, project, env, name)
+	if err == nil {
+		return flag, domain.SourceCache, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		s.logger.WarnContext(ctx, "cache read failed, falling back to store", "project", project, "flag", name, "environment", env, "error", err)
+	}
+
+	flag, err = s.store.GetByName(ctx, project, env, name)
+	if err != nil {
+		return nil, "", err
+	}
+	s.cacheFlag(ctx, *flag)
+	return flag, domain.SourceStore, nil
+}
+
+// writeThrough caches changed flags and evicts them from every environment
+// that inherits from theirs, since the resolved state there may have changed
+// too. The next read in those environments repopulates the cache.
+func (s *Service) writeThrough(ctx context.Context, flags ...domain.Flag) {
+	for _, flag := range flags {
+		s.cacheFlag(ctx, flag)
+	}
+
+	envs, err := s.store.ListEnvironments(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "listing inheriting environments failed", "error", err)
+		return
+	}
+	for _, flag := range flags {
+		for _, env := range domain.Descendants(envs, flag.Environment) {
+			if err := s.cache.Delete(ctx, flag.Project, env, flag.Name); err != nil {
+				s.logger.WarnContext(ctx, "cache delete failed", "project", flag.Project, "flag", flag.Name, "environment", env, "error", err)
+			}
+		}
+	}
+}
+
+// cacheFlag writes the flag to the cache. Failures are logged and otherwise
+// ignored: the store is authoritative and the next read repopulates the cache.
+func (s *Service) cacheFlag(ctx context.Context, flag domain.Flag) {
+	if err := s.cache.Set(ctx, flag); err != nil {
+		s.logger.WarnContext(ctx, "cache write failed", "project", flag.Project, "flag", flag.Name, "environment", flag.Environment, "error", err)
+	}
+}
+
 func parseFlagType(raw string) (domain.FlagType, error) {
 	switch domain.FlagType(raw) {
 	case domain.FlagTypeBoolean, domain.FlagTypeNumeric:
@@ -58,12 +457,127 @@ func parseFlagType(raw string) (domain.FlagType, error) {
 }
 
 func flagToResponse(flag domain.Flag) *port.FlagResponse {
-	return &port.FlagResponse{
-		Name:        flag.Name,
-		Type:        string(flag.Type),
-		Description: flag.Description,
-		Value:       port.FlagValue{Bool: flag.Value.Bool, Numeric: flag.Value.Numeric},
-		CreatedAt:   flag.CreatedAt,
-		UpdatedAt:   flag.UpdatedAt,
+	resp := &port.FlagResponse{
+		Project:           flag.Project,
+		Name:              flag.Name,
+		Type:              string(flag.Type),
+		Description:       flag.Description,
+		Tags:              flag.Tags,
+		Environment:       flag.Environment,
+		SourceEnvironment: flag.SourceEnvironment,
+		Enabled:           !flag.Disabled,
+		Value:             port.FlagValue{Bool: flag.Value.Bool, Numeric: flag.Value.Numeric},
+		Rules:             rulesToResponse(flag.Rules),
+		Targets:           targetsToResponse(flag.Targets),
+		Variants:          variantsToResponse(flag.Variants),
+		Version:           flag.Version,
+		CreatedAt:         flag.CreatedAt,
+		UpdatedAt:         flag.UpdatedAt,
+	}
+	if flag.Experiment != nil {
+		resp.Experiment = flag.Experiment.Key
+	}
+	return resp
+}
+
+func rulesToDomain(rules []port.Rule) []domain.Rule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]domain.Rule, len(rules))
+	for i, r := range rules {
+		var clauses []domain.Clause
+		if len(r.Clauses) > 0 {
+			clauses = make([]domain.Clause, len(r.Clauses))
+		}
+		for j, c := range r.Clauses {
+			clauses[j] = domain.Clause{
+				Attribute: c.Attribute,
+				Operator:  domain.Operator(c.Operator),
+				Values:    c.Values,
+				Timezone:  c.Timezone,
+			}
+		}
+		out[i] = domain.Rule{
+			ID:         r.ID,
+			Clauses:    clauses,
+			Expression: r.Expression,
+			Value:      domain.FlagValue{Bool: r.Value.Bool, Numeric: r.Value.Numeric},
+		}
+	}
+	return out
+}
+
+func rulesToResponse(rules []domain.Rule) []port.Rule {
+	if len(rules) == 0 {
+		return nil
+	}
+	out := make([]port.Rule, len(rules))
+	for i, r := range rules {
+		var clauses []port.Clause
+		if len(r.Clauses) > 0 {
+			clauses = make([]port.Clause, len(r.Clauses))
+		}
+		for j, c := range r.Clauses {
+			clauses[j] = port.Clause{
+				Attribute: c.Attribute,
+				Operator:  string(c.Operator),
+				Values:    c.Values,
+				Timezone:  c.Timezone,
+			}
+		}
+		out[i] = port.Rule{
+			ID:         r.ID,
+			Clauses:    clauses,
+			Expression: r.Expression,
+			Value:      port.FlagValue{Bool: r.Value.Bool, Numeric: r.Value.Numeric},
+		}
+	}
+	return out
+}
+
+func variantsToDomain(variants []port.Variant) []domain.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]domain.Variant, len(variants))
+	for i, v := range variants {
+		out[i] = domain.Variant{Key: v.Key, Value: domain.FlagValue{Bool: v.Value.Bool, Numeric: v.Value.Numeric}}
+	}
+	return out
+}
+
+func variantsToResponse(variants []domain.Variant) []port.Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	out := make([]port.Variant, len(variants))
+	for i, v := range variants {
+		out[i] = port.Variant{Key: v.Key, Value: port.FlagValue{Bool: v.Value.Bool, Numeric: v.Value.Numeric}}
+	}
+	return out
+}
+
+func targetsToResponse(targets map[string]domain.FlagValue) map[string]port.FlagValue {
+	if len(targets) == 0 {
+		return nil
+	}
+	out := make(map[string]port.FlagValue, len(targets))
+	for key, v := range targets {
+		out[key] = port.FlagValue{Bool: v.Bool, Numeric: v.Numeric}
+	}
+	return out
+}
+
+func evaluationToResponse(eval domain.Evaluation, source domain.ValueSource) *port.FlagValueResponse {
+	return &port.FlagValueResponse{
+		Value:      port.FlagValue{Bool: eval.Value.Bool, Numeric: eval.Value.Numeric},
+		Reason:     string(eval.Reason),
+		RuleID:     eval.RuleID,
+		Bucket:     eval.Bucket,
+		Experiment: eval.Experiment,
+		Variant:    eval.Variant,
+		Version:    eval.Version,
+		Source:     string(source),
 	}
 }
@@ -2,7 +2,14 @@ package service_test
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,40 +19,588 @@ import (
 )
 
 // fakeFlagStore is an in-memory hand-written fake implementing port.FlagStore.
+// projects holds each project's flags by environment, an environment holding
+// only its overrides. envs is the default project and flags its default
+// environment, the ones most tests work in.
 type fakeFlagStore struct {
-	flags map[string]domain.Flag
+	flags        map[string]domain.Flag
+	envs         map[string]map[string]domain.Flag
+	projects     map[string]map[string]map[string]domain.Flag
+	projectDefs  map[string]domain.Project
+	environments map[string]domain.Environment
+	promotions   []domain.Promotion
+	audit        []domain.AuditEntry
+	auditQuery   domain.AuditQuery
+	history      []domain.FlagVersion
+	protections  map[string]domain.FlagProtection
+	changes      []domain.ChangeRequest
+	freezes      []domain.FreezeWindow
+	freezeSeq    int64
 }
 
 func newFakeFlagStore() *fakeFlagStore {
-	return &fakeFlagStore{flags: make(map[string]domain.Flag)}
+	flags := make(map[string]domain.Flag)
+	envs := map[string]map[string]domain.Flag{domain.DefaultEnvironment: flags}
+	return &fakeFlagStore{
+		flags:        flags,
+		envs:         envs,
+		projects:     map[string]map[string]map[string]domain.Flag{domain.DefaultProject: envs},
+		projectDefs:  map[string]domain.Project{domain.DefaultProject: {Key: domain.DefaultProject}},
+		environments: map[string]domain.Environment{domain.DefaultEnvironment: {Key: domain.DefaultEnvironment}},
+		protections:  make(map[string]domain.FlagProtection),
+	}
+}
+
+// project returns the project's flags by environment. Flags created by tests
+// without a project belong to the default one.
+func (f *fakeFlagStore) project(key string) map[string]map[string]domain.Flag {
+	if key == "" {
+		key = domain.DefaultProject
+	}
+	return f.projects[key]
 }
 
 func (f *fakeFlagStore) Create(_ context.Context, flag domain.Flag) error {
-	if _, exists := f.flags[flag.Name]; exists {
-		return domain.ErrAlreadyExists
+	if flag.Project == "" {
+		flag.Project = domain.DefaultProject
+	}
+	envs := f.project(flag.Project)
+	if envs == nil {
+		return domain.ErrProjectNotFound
+	}
+	for env, flags := range envs {
+		if _, exists := flags[flag.Name]; exists {
+			return domain.ErrAlreadyExists
+		}
+		if f.environments[env].Parent == "" {
+			flag.Environment = env
+			flags[flag.Name] = flag
+			f.record(flag)
+		}
 	}
-	f.flags[flag.Name] = flag
 	return nil
 }
 
-func (f *fakeFlagStore) GetByName(_ context.Context, name string) (*domain.Flag, error) {
-	flag, ok := f.flags[name]
+// record appends the flag's state in its environment to the history.
+func (f *fakeFlagStore) record(flag domain.Flag) {
+	f.history = append(f.history, domain.FlagVersion{
+		Project:     flag.Project,
+		FlagName:    flag.Name,
+		Environment: flag.Environment,
+		Version:     flag.Version,
+		Disabled:    flag.Disabled,
+		Value:       flag.Value,
+		Rules:       flag.Rules,
+		Targets:     flag.Targets,
+		RecordedAt:  flag.UpdatedAt,
+	})
+}
+
+// resolve returns the flag as seen from env: its override there or the state
+// of the nearest ancestor that has one.
+func (f *fakeFlagStore) resolve(project, env, name string) (domain.Flag, bool) {
+	envs := f.project(project)
+	for key := env; key != ""; key = f.environments[key].Parent {
+		if flag, ok := envs[key][name]; ok {
+			flag.Environment = env
+			flag.SourceEnvironment = key
+			if key != env {
+				flag.Experiment = nil
+			}
+			return flag, true
+		}
+	}
+	return domain.Flag{}, false
+}
+
+func (f *fakeFlagStore) GetByName(_ context.Context, project, env, name string) (*domain.Flag, error) {
+	flag, ok := f.resolve(project, env, name)
 	if !ok {
 		return nil, domain.ErrNotFound
 	}
 	return &flag, nil
 }
 
-func (f *fakeFlagStore) UpdateValue(_ context.Context, name string, flagValue domain.FlagValue) (*domain.Flag, error) {
-	flag, ok := f.flags[name]
+// update applies mutate to the flag in env, overriding it there if it was
+// inherited, and bumps its version and update time.
+func (f *fakeFlagStore) update(project, env, name string, at time.Time, mutate func(*domain.Flag)) (*domain.Flag, error) {
+	flag, ok := f.resolve(project, env, name)
 	if !ok {
 		return nil, domain.ErrNotFound
 	}
-	flag.Value = flagValue
-	f.flags[name] = flag
+	mutate(&flag)
+	flag.Version++
+	flag.UpdatedAt = at
+	flag.SourceEnvironment = env
+	f.project(project)[env][name] = flag
+	f.record(flag)
 	return &flag, nil
 }
 
+func (f *fakeFlagStore) UpdateValue(_ context.Context, project, env, name string, flagValue domain.FlagValue, at time.Time) (*domain.Flag, error) {
+	return f.update(project, env, name, at, func(flag *domain.Flag) { flag.Value = flagValue })
+}
+
+func (f *fakeFlagStore) UpdateRules(_ context.Context, project, env, name string, rules []domain.Rule, at time.Time) (*domain.Flag, error) {
+	return f.update(project, env, name, at, func(flag *domain.Flag) { flag.Rules = rules })
+}
+
+func (f *fakeFlagStore) UpdateEnabled(_ context.Context, project, env, name string, enabled bool, at time.Time) (*domain.Flag, error) {
+	return f.update(project, env, name, at, func(flag *domain.Flag) { flag.Disabled = !enabled })
+}
+
+func (f *fakeFlagStore) AddTargets(_ context.Context, project, env, name string, keys []string, value domain.FlagValue, at time.Time) (*domain.Flag, error) {
+	return f.update(project, env, name, at, func(flag *domain.Flag) {
+		targets := maps.Clone(flag.Targets)
+		if targets == nil {
+			targets = make(map[string]domain.FlagValue, len(keys))
+		}
+		for _, key := range keys {
+			targets[key] = value
+		}
+		flag.Targets = targets
+	})
+}
+
+func (f *fakeFlagStore) RemoveTargets(_ context.Context, project, env, name string, keys []string, at time.Time) (*domain.Flag, error) {
+	return f.update(project, env, name, at, func(flag *domain.Flag) {
+		targets := maps.Clone(flag.Targets)
+		for _, key := range keys {
+			delete(targets, key)
+		}
+		if len(targets) == 0 {
+			targets = nil
+		}
+		flag.Targets = targets
+	})
+}
+
+func (f *fakeFlagStore) GetByNames(_ context.Context, project, env string, names []string) ([]domain.Flag, error) {
+	var flags []domain.Flag
+	for _, name := range names {
+		if flag, ok := f.resolve(project, env, name); ok {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
+
+func (f *fakeFlagStore) RemoveOverride(ctx context.Context, project, env, name string, _ time.Time) (*domain.Flag, error) {
+	environment, ok := f.environments[env]
+	if !ok {
+		return nil, domain.ErrEnvironmentNotFound
+	}
+	if environment.Parent == "" {
+		return nil, domain.ErrInvalidEnvironment
+	}
+	if f.project(project)[env][name].Experiment != nil {
+		return nil, domain.ErrExperimentState
+	}
+	delete(f.project(project)[env], name)
+	return f.GetByName(ctx, project, env, name)
+}
+
+// ListNames lists the flags of the project's default environment, which holds
+// every flag.
+func (f *fakeFlagStore) ListNames(_ context.Context, project, prefix string) ([]string, error) {
+	var names []string
+	for name := range f.project(project)[domain.DefaultEnvironment] {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (f *fakeFlagStore) CreateEnvironment(_ context.Context, env domain.Environment, source string) error {
+	if _, exists := f.environments[env.Key]; exists {
+		return domain.ErrEnvironmentExists
+	}
+	if env.Parent != "" {
+		if _, ok := f.environments[env.Parent]; !ok {
+			return domain.ErrEnvironmentNotFound
+		}
+	} else if _, ok := f.environments[source]; !ok {
+		return domain.ErrEnvironmentNotFound
+	}
+	for project, envs := range f.projects {
+		flags := make(map[string]domain.Flag)
+		if env.Parent == "" {
+			for name := range envs[domain.DefaultEnvironment] {
+				flag, _ := f.resolve(project, source, name)
+				flag.Environment = env.Key
+				flag.SourceEnvironment = env.Key
+				flag.Experiment = nil
+				flag.Version = 1
+				flags[name] = flag
+				f.record(flag)
+			}
+		}
+		envs[env.Key] = flags
+	}
+	f.environments[env.Key] = env
+	return nil
+}
+
+func (f *fakeFlagStore) GetEnvironment(_ context.Context, key string) (*domain.Environment, error) {
+	env, ok := f.environments[key]
+	if !ok {
+		return nil, domain.ErrEnvironmentNotFound
+	}
+	return &env, nil
+}
+
+func (f *fakeFlagStore) ListEnvironments(_ context.Context) ([]domain.Environment, error) {
+	var out []domain.Environment
+	for _, env := range f.environments {
+		out = append(out, env)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+func (f *fakeFlagStore) Promote(ctx context.Context, project, source, target string, names []string, at time.Time) (*domain.Promotion, []domain.Flag, error) {
+	for _, env := range []string{source, target} {
+		if _, ok := f.environments[env]; !ok {
+			return nil, nil, domain.ErrEnvironmentNotFound
+		}
+	}
+	if len(names) == 0 {
+		names, _ = f.ListNames(ctx, project, "")
+	}
+	promotion := domain.Promotion{ID: int64(len(f.promotions) + 1), Project: project, Source: source, Target: target, PromotedAt: at}
+	var updated []domain.Flag
+	for _, name := range names {
+		src, ok := f.resolve(project, source, name)
+		if !ok {
+			return nil, nil, domain.ErrNotFound
+		}
+		current, _ := f.resolve(project, target, name)
+		diff := domain.DiffFlag(current, src)
+		if diff.Empty() {
+			continue
+		}
+		flag := domain.Promote(current, src)
+		flag.SourceEnvironment = target
+		flag.Version++
+		flag.UpdatedAt = at
+		promotion.Diffs = append(promotion.Diffs, diff)
+		updated = append(updated, flag)
+	}
+	for _, flag := range updated {
+		f.project(project)[target][flag.Name] = flag
+	}
+	f.promotions = append(f.promotions, promotion)
+	return &promotion, updated, nil
+}
+
+func (f *fakeFlagStore) ListPromotions(_ context.Context, project string) ([]domain.Promotion, error) {
+	var out []domain.Promotion
+	for _, p := range slices.Backward(f.promotions) {
+		if p.Project == project {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeFlagStore) CreateProject(_ context.Context, project domain.Project) error {
+	if _, exists := f.projectDefs[project.Key]; exists {
+		return domain.ErrProjectExists
+	}
+	envs := make(map[string]map[string]domain.Flag, len(f.environments))
+	for env := range f.environments {
+		envs[env] = make(map[string]domain.Flag)
+	}
+	f.projectDefs[project.Key] = project
+	f.projects[project.Key] = envs
+	return nil
+}
+
+func (f *fakeFlagStore) GetProject(_ context.Context, key string) (*domain.Project, error) {
+	project, ok := f.projectDefs[key]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+func (f *fakeFlagStore) ListProjects(_ context.Context) ([]domain.Project, error) {
+	var out []domain.Project
+	for _, project := range f.projectDefs {
+		out = append(out, project)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// ListAudit records the query and returns the entries for its flag, or all of
+// them, newest first.
+func (f *fakeFlagStore) ListAudit(_ context.Context, q domain.AuditQuery) ([]domain.AuditEntry, error) {
+	f.auditQuery = q
+	var out []domain.AuditEntry
+	for _, entry := range slices.Backward(f.audit) {
+		if q.FlagName == "" || entry.FlagName == q.FlagName {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+// ListHistory returns the versions recorded in env and its ancestors, newest
+// first.
+func (f *fakeFlagStore) ListHistory(_ context.Context, project, env, name string) ([]domain.FlagVersion, error) {
+	if _, ok := f.resolve(project, env, name); !ok {
+		return nil, domain.ErrNotFound
+	}
+	var out []domain.FlagVersion
+	for _, version := range slices.Backward(f.history) {
+		if version.Project == project && version.FlagName == name && f.inherits(env, version.Environment) {
+			out = append(out, version)
+		}
+	}
+	return out, nil
+}
+
+// inherits reports whether env is ancestor or inherits from it.
+func (f *fakeFlagStore) inherits(env, ancestor string) bool {
+	for key := env; key != ""; key = f.environments[key].Parent {
+		if key == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeFlagStore) Rollback(ctx context.Context, project, env, name string, version int64, at time.Time) (*domain.Flag, error) {
+	versions, err := f.ListHistory(ctx, project, env, name)
+	if err != nil {
+		return nil, err
+	}
+	for key := env; key != ""; key = f.environments[key].Parent {
+		for _, v := range versions {
+			if v.Environment == key && v.Version == version {
+				return f.update(project, env, name, at, func(flag *domain.Flag) {
+					flag.Disabled = v.Disabled
+					flag.Value = v.Value
+					flag.Rules = v.Rules
+					flag.Targets = v.Targets
+				})
+			}
+		}
+	}
+	return nil, domain.ErrVersionNotFound
+}
+
+// GetAsOf resolves the flag from the latest version recorded at or before at
+// in the nearest environment of env's lineage that has one.
+func (f *fakeFlagStore) GetAsOf(_ context.Context, project, env, name string, at time.Time) (*domain.Flag, error) {
+	flag, ok := f.resolve(project, env, name)
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	for key := env; key != ""; key = f.environments[key].Parent {
+		for _, v := range slices.Backward(f.history) {
+			if v.Project != project || v.FlagName != name || v.Environment != key || v.RecordedAt.After(at) {
+				continue
+			}
+			flag.SourceEnvironment = key
+			flag.Disabled = v.Disabled
+			flag.Value = v.Value
+			flag.Rules = v.Rules
+			flag.Targets = v.Targets
+			flag.Experiment = nil
+			flag.Version = v.Version
+			flag.UpdatedAt = v.RecordedAt
+			return &flag, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (f *fakeFlagStore) ListAsOf(ctx context.Context, project, env string, at time.Time) ([]domain.Flag, error) {
+	names, _ := f.ListNames(ctx, project, "")
+	var out []domain.Flag
+	for _, name := range names {
+		if flag, err := f.GetAsOf(ctx, project, env, name, at); err == nil {
+			out = append(out, *flag)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeFlagStore) GetProtection(_ context.Context, project, name string) (*domain.FlagProtection, error) {
+	if _, ok := f.project(project)[domain.DefaultEnvironment][name]; !ok {
+		return nil, domain.ErrNotFound
+	}
+	protection, ok := f.protections[project+"/"+name]
+	if !ok {
+		protection = domain.FlagProtection{Project: project, FlagName: name}
+	}
+	return &protection, nil
+}
+
+func (f *fakeFlagStore) ListProtections(_ context.Context, project string) ([]domain.FlagProtection, error) {
+	var out []domain.FlagProtection
+	for _, protection := range f.protections {
+		if protection.Project == project && protection.RequiredApprovals > 0 {
+			out = append(out, protection)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.FlagProtection) int { return strings.Compare(a.FlagName, b.FlagName) })
+	return out, nil
+}
+
+func (f *fakeFlagStore) SetProtection(_ context.Context, protection domain.FlagProtection) error {
+	if _, ok := f.project(protection.Project)[domain.DefaultEnvironment][protection.FlagName]; !ok {
+		return domain.ErrNotFound
+	}
+	f.protections[protection.Project+"/"+protection.FlagName] = protection
+	return nil
+}
+
+func (f *fakeFlagStore) CreateChangeRequest(_ context.Context, cr domain.ChangeRequest) (*domain.ChangeRequest, error) {
+	cr.ID = int64(len(f.changes) + 1)
+	f.changes = append(f.changes, cr)
+	return &cr, nil
+}
+
+func (f *fakeFlagStore) GetChangeRequest(_ context.Context, project string, id int64) (*domain.ChangeRequest, error) {
+	if id < 1 || id > int64(len(f.changes)) || f.changes[id-1].Project != project {
+		return nil, domain.ErrChangeRequestNotFound
+	}
+	cr := f.changes[id-1]
+	return &cr, nil
+}
+
+func (f *fakeFlagStore) ListChangeRequests(_ context.Context, q domain.ChangeRequestQuery) ([]domain.ChangeRequest, error) {
+	var out []domain.ChangeRequest
+	for _, cr := range slices.Backward(f.changes) {
+		if cr.Project == q.Project && (q.FlagName == "" || cr.FlagName == q.FlagName) && (q.Status == "" || cr.StatusAt(q.At) == q.Status) {
+			out = append(out, cr)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeFlagStore) ApproveChangeRequest(ctx context.Context, project string, id int64, approver string, at time.Time) (*domain.ChangeRequest, *domain.Flag, error) {
+	cr, err := f.GetChangeRequest(ctx, project, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err := cr.Approve(approver, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	f.changes[id-1] = *cr
+	if !applied {
+		return cr, nil, nil
+	}
+	flag, err := f.UpdateValue(ctx, project, cr.Environment, cr.FlagName, cr.Value, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cr, flag, nil
+}
+
+func (f *fakeFlagStore) RejectChangeRequest(ctx context.Context, project string, id int64, reviewer string, at time.Time) (*domain.ChangeRequest, error) {
+	cr, err := f.GetChangeRequest(ctx, project, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.Reject(reviewer, at); err != nil {
+		return nil, err
+	}
+	f.changes[id-1] = *cr
+	return cr, nil
+}
+
+// fakeFlagCache is an in-memory hand-written fake implementing port.FlagCache.
+// It is keyed like fakeFlagStore: envs is the default project and flags its
+// default environment. Setting err makes every call fail with it, simulating
+// a cache outage.
+type fakeFlagCache struct {
+	flags    map[string]domain.Flag
+	envs     map[string]map[string]domain.Flag
+	projects map[string]map[string]map[string]domain.Flag
+	err      error
+}
+
+func newFakeFlagCache() *fakeFlagCache {
+	flags := make(map[string]domain.Flag)
+	envs := map[string]map[string]domain.Flag{domain.DefaultEnvironment: flags}
+	return &fakeFlagCache{
+		flags:    flags,
+		envs:     envs,
+		projects: map[string]map[string]map[string]domain.Flag{domain.DefaultProject: envs},
+	}
+}
+
+func (f *fakeFlagCache) Get(_ context.Context, project, env, name string) (*domain.Flag, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	flag, ok := f.projects[project][env][name]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &flag, nil
+}
+
+func (f *fakeFlagCache) GetMany(_ context.Context, project, env string, names []string) (map[string]domain.Flag, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	hits := make(map[string]domain.Flag)
+	for _, name := range names {
+		if flag, ok := f.projects[project][env][name]; ok {
+			hits[name] = flag
+		}
+	}
+	return hits, nil
+}
+
+func (f *fakeFlagCache) Set(_ context.Context, flag domain.Flag) error {
+	if f.err != nil {
+		return f.err
+	}
+	project, env := flag.Project, flag.Environment
+	if project == "" {
+		project = domain.DefaultProject
+	}
+	if env == "" {
+		env = domain.DefaultEnvironment
+	}
+	if f.projects[project] == nil {
+		f.projects[project] = make(map[string]map[string]domain.Flag)
+	}
+	if f.projects[project][env] == nil {
+		f.projects[project][env] = make(map[string]domain.Flag)
+	}
+	f.projects[project][env][flag.Name] = flag
+	return nil
+}
+
+func (f *fakeFlagCache) Delete(_ context.Context, project, env, name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.projects[project][env], name)
+	return nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time { return c.now }
+
+func newService(store *fakeFlagStore, cache *fakeFlagCache, opts ...service.Option) *service.Service {
+	opts = append([]service.Option{service.WithLogger(slog.New(slog.DiscardHandler))}, opts...)
+	return service.New(store, store, store, cache, opts...)
+}
+
 func TestService_CreateFlag(t *testing.T) {
 	t.Parallel()
 
@@ -88,6 +643,29 @@ func TestService_CreateFlag(t *testing.T) {
 			wantDesc:    "a numeric flag",
 			wantNumeric: &numVal,
 		},
+		{
+			name: "duplicate variant keys",
+			req: port.CreateFlagRequest{
+				Name:  "my-flag",
+				Type:  "boolean",
+				Value: port.FlagValue{Bool: &boolVal},
+				Variants: []port.Variant{
+					{Key: "on", Value: port.FlagValue{Bool: &boolVal}},
+					{Key: "on", Value: port.FlagValue{Bool: &boolVal}},
+				},
+			},
+			wantErr: domain.ErrInvalidVariant,
+		},
+		{
+			name: "invalid tag",
+			req: port.CreateFlagRequest{
+				Name:  "my-flag",
+				Type:  "boolean",
+				Tags:  []string{"Payments Team"},
+				Value: port.FlagValue{Bool: &boolVal},
+			},
+			wantErr: domain.ErrInvalidTag,
+		},
 		{
 			name: "empty name",
 			req: port.CreateFlagRequest{
@@ -151,6 +729,16 @@ func TestService_CreateFlag(t *testing.T) {
 			},
 			wantErr: domain.ErrTypeMismatch,
 		},
+		{
+			name: "invalid rule",
+			req: port.CreateFlagRequest{
+				Name:  "my-flag",
+				Type:  "boolean",
+				Value: port.FlagValue{Bool: &boolVal},
+				Rules: []port.Rule{{ID: "r1", Value: port.FlagValue{Bool: &boolVal}}},
+			},
+			wantErr: domain.ErrInvalidRule,
+		},
 		{
 			name: "duplicate flag name",
 			req: port.CreateFlagRequest{
@@ -176,7 +764,7 @@ func TestService_CreateFlag(t *testing.T) {
 				})
 			}
 
-			svc := service.New(store)
+			svc := newService(store, newFakeFlagCache())
 			resp, err := svc.CreateFlag(context.Background(), tt.req)
 
 			if tt.wantErr != nil {
@@ -196,8 +784,618 @@ func TestService_CreateFlag(t *testing.T) {
 			if tt.wantNumeric != nil {
 				assert.Equal(t, tt.wantNumeric, resp.Value.Numeric)
 			}
+			assert.Equal(t, int64(1), resp.Version)
 			assert.False(t, resp.CreatedAt.IsZero())
 			assert.False(t, resp.UpdatedAt.IsZero())
 		})
 	}
 }
+
+func TestService_CreateFlag_NamingPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := domain.DefaultNamingPolicy()
+	policy.Separators = ".-"
+	policy.Prefixes = map[string][]string{domain.DefaultProject: {"checkout."}}
+	policy.Reserved = []string{"checkout.all"}
+
+	on := true
+	tests := []struct {
+		name    string
+		flag    string
+		wantErr error
+	}{
+		{name: "team convention", flag: "checkout.cart.express"},
+		{name: "missing prefix", flag: "search.ranking", wantErr: domain.ErrInvalidName},
+		{name: "reserved", flag: "checkout.all", wantErr: domain.ErrInvalidName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(newFakeFlagStore(), newFakeFlagCache(), service.WithNamingPolicy(policy))
+			resp, err := svc.CreateFlag(context.Background(), port.CreateFlagRequest{
+				Name: tt.flag, Type: "boolean", Value: port.FlagValue{Bool: &on},
+			})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.flag, resp.Name)
+		})
+	}
+}
+
+func TestService_GetFlag(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:        "my-flag",
+		Type:        domain.FlagTypeBoolean,
+		Description: "desc",
+		Value:       domain.FlagValue{Bool: &boolVal},
+		Version:     3,
+	})
+	svc := newService(store, newFakeFlagCache())
+
+	resp, err := svc.GetFlag(context.Background(), "my-flag")
+	require.NoError(t, err)
+	assert.Equal(t, "my-flag", resp.Name)
+	assert.Equal(t, "desc", resp.Description)
+	assert.Equal(t, int64(3), resp.Version)
+
+	_, err = svc.GetFlag(context.Background(), "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_GetFlagValue(t *testing.T) {
+	t.Parallel()
+
+	storedVal := true
+	cachedVal := false
+	stored := domain.Flag{
+		Name:    "my-flag",
+		Type:    domain.FlagTypeBoolean,
+		Value:   domain.FlagValue{Bool: &storedVal},
+		Version: 2,
+	}
+
+	tests := []struct {
+		name        string
+		cached      *domain.Flag
+		cacheErr    error
+		wantBool    bool
+		wantVersion int64
+		wantSource  string
+	}{
+		{
+			name:        "cache hit",
+			cached:      &domain.Flag{Name: "my-flag", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &cachedVal}, Version: 1},
+			wantBool:    false,
+			wantVersion: 1,
+			wantSource:  "cache",
+		},
+		{
+			name:        "cache miss falls back to store",
+			wantBool:    true,
+			wantVersion: 2,
+			wantSource:  "store",
+		},
+		{
+			name:        "cache outage falls back to store",
+			cacheErr:    errors.New("connection refused"),
+			wantBool:    true,
+			wantVersion: 2,
+			wantSource:  "store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			_ = store.Create(context.Background(), stored)
+			cache := newFakeFlagCache()
+			if tt.cached != nil {
+				cache.flags[tt.cached.Name] = *tt.cached
+			}
+			cache.err = tt.cacheErr
+
+			resp, err := newService(store, cache).GetFlagValue(context.Background(), "my-flag")
+
+			require.NoError(t, err)
+			require.NotNil(t, resp.Value.Bool)
+			assert.Equal(t, tt.wantBool, *resp.Value.Bool)
+			assert.Equal(t, "STATIC", resp.Reason)
+			assert.Equal(t, tt.wantVersion, resp.Version)
+			assert.Equal(t, tt.wantSource, resp.Source)
+		})
+	}
+}
+
+func TestService_GetFlagValue_RepopulatesCache(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:  "my-flag",
+		Type:  domain.FlagTypeBoolean,
+		Value: domain.FlagValue{Bool: &boolVal},
+	})
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+
+	first, err := svc.GetFlagValue(context.Background(), "my-flag")
+	require.NoError(t, err)
+	assert.Equal(t, "store", first.Source)
+
+	second, err := svc.GetFlagValue(context.Background(), "my-flag")
+	require.NoError(t, err)
+	assert.Equal(t, "cache", second.Source)
+}
+
+func TestService_GetFlagValue_NotFound(t *testing.T) {
+	t.Parallel()
+
+	svc := newService(newFakeFlagStore(), newFakeFlagCache())
+
+	_, err := svc.GetFlagValue(context.Background(), "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_UpdateFlagValue(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	newBool := false
+	numVal := 1.5
+
+	tests := []struct {
+		name     string
+		flag     string
+		req      port.UpdateFlagValueRequest
+		cacheErr error
+		wantErr  error
+	}{
+		{
+			name: "updates value",
+			flag: "my-flag",
+			req:  port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &newBool}},
+		},
+		{
+			name:     "cache failure is not surfaced",
+			flag:     "my-flag",
+			req:      port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &newBool}},
+			cacheErr: errors.New("connection refused"),
+		},
+		{
+			name:    "type mismatch",
+			flag:    "my-flag",
+			req:     port.UpdateFlagValueRequest{Value: port.FlagValue{Numeric: &numVal}},
+			wantErr: domain.ErrTypeMismatch,
+		},
+		{
+			name:    "not found",
+			flag:    "ghost",
+			req:     port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &newBool}},
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			_ = store.Create(context.Background(), domain.Flag{
+				Name:    "my-flag",
+				Type:    domain.FlagTypeBoolean,
+				Value:   domain.FlagValue{Bool: &boolVal},
+				Version: 1,
+			})
+			cache := newFakeFlagCache()
+			cache.err = tt.cacheErr
+
+			resp, err := newService(store, cache).UpdateFlagValue(context.Background(), tt.flag, tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &newBool, resp.Value.Bool)
+			assert.Equal(t, int64(2), resp.Version)
+			if tt.cacheErr == nil {
+				assert.Equal(t, int64(2), cache.flags["my-flag"].Version)
+			}
+		})
+	}
+}
+
+func TestService_EvaluateAll(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	numVal := 10.0
+	seed := []domain.Flag{
+		{Name: "checkout-v2", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &boolVal}, Version: 1},
+		{Name: "checkout-limit", Type: domain.FlagTypeNumeric, Value: domain.FlagValue{Numeric: &numVal}, Version: 4},
+		{Name: "search", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &boolVal}, Version: 1},
+	}
+
+	tests := []struct {
+		name      string
+		filter    port.EvaluationFilter
+		cached    []string
+		cacheErr  error
+		wantFlags map[string]string
+	}{
+		{
+			name:      "all flags from store",
+			wantFlags: map[string]string{"checkout-v2": "store", "checkout-limit": "store", "search": "store"},
+		},
+		{
+			name:      "mixes cache hits and store misses",
+			cached:    []string{"search"},
+			wantFlags: map[string]string{"checkout-v2": "store", "checkout-limit": "store", "search": "cache"},
+		},
+		{
+			name:      "prefix filter",
+			filter:    port.EvaluationFilter{Prefix: "checkout-"},
+			cached:    []string{"checkout-v2"},
+			wantFlags: map[string]string{"checkout-v2": "cache", "checkout-limit": "store"},
+		},
+		{
+			name:      "names filter skips unknown flags",
+			filter:    port.EvaluationFilter{Names: []string{"search", "ghost"}},
+			wantFlags: map[string]string{"search": "store"},
+		},
+		{
+			name:      "names and prefix filters combine",
+			filter:    port.EvaluationFilter{Names: []string{"search", "checkout-v2"}, Prefix: "checkout-"},
+			wantFlags: map[string]string{"checkout-v2": "store"},
+		},
+		{
+			name:      "cache outage falls back to store",
+			cached:    []string{"search"},
+			cacheErr:  errors.New("connection refused"),
+			wantFlags: map[string]string{"checkout-v2": "store", "checkout-limit": "store", "search": "store"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			cache := newFakeFlagCache()
+			for _, flag := range seed {
+				_ = store.Create(context.Background(), flag)
+			}
+			for _, name := range tt.cached {
+				cache.flags[name] = store.flags[name]
+			}
+			cache.err = tt.cacheErr
+
+			resp, err := newService(store, cache).EvaluateAll(context.Background(), port.EvaluationContext{TargetingKey: "user-1"}, tt.filter)
+
+			require.NoError(t, err)
+			require.Len(t, resp.Flags, len(tt.wantFlags))
+			for name, wantSource := range tt.wantFlags {
+				got, ok := resp.Flags[name]
+				require.True(t, ok, "missing flag %q", name)
+				assert.Equal(t, wantSource, got.Source, "flag %q", name)
+				assert.Equal(t, "STATIC", got.Reason)
+				assert.Equal(t, store.flags[name].Version, got.Version)
+			}
+			if tt.cacheErr == nil {
+				for name := range tt.wantFlags {
+					assert.Contains(t, cache.flags, name, "flag %q was not cached", name)
+				}
+			}
+		})
+	}
+}
+
+func TestService_UpdateFlagRules(t *testing.T) {
+	t.Parallel()
+
+	boolVal := true
+	numVal := 3.0
+	weekend := []port.Clause{{Operator: "day-of-week-in", Values: []string{"sat", "sun"}}}
+
+	tests := []struct {
+		name    string
+		flag    string
+		rules   []port.Rule
+		wantErr error
+	}{
+		{
+			name:  "replaces rules",
+			flag:  "my-flag",
+			rules: []port.Rule{{ID: "weekend", Clauses: weekend, Value: port.FlagValue{Bool: &boolVal}}},
+		},
+		{
+			name:  "expression rule",
+			flag:  "my-flag",
+			rules: []port.Rule{{ID: "large-pro", Expression: `plan == "pro" && seats > 10`, Value: port.FlagValue{Bool: &boolVal}}},
+		},
+		{
+			name:    "invalid expression",
+			flag:    "my-flag",
+			rules:   []port.Rule{{ID: "large-pro", Expression: `plan == "pro" &&`, Value: port.FlagValue{Bool: &boolVal}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:  "clears rules",
+			flag:  "my-flag",
+			rules: nil,
+		},
+		{
+			name:    "invalid operator",
+			flag:    "my-flag",
+			rules:   []port.Rule{{ID: "r", Clauses: []port.Clause{{Attribute: "plan", Operator: "like", Values: []string{"x"}}}, Value: port.FlagValue{Bool: &boolVal}}},
+			wantErr: domain.ErrInvalidRule,
+		},
+		{
+			name:    "rule value of wrong type",
+			flag:    "my-flag",
+			rules:   []port.Rule{{ID: "weekend", Clauses: weekend, Value: port.FlagValue{Numeric: &numVal}}},
+			wantErr: domain.ErrTypeMismatch,
+		},
+		{
+			name:    "not found",
+			flag:    "ghost",
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			_ = store.Create(context.Background(), domain.Flag{
+				Name:    "my-flag",
+				Type:    domain.FlagTypeBoolean,
+				Value:   domain.FlagValue{Bool: &boolVal},
+				Version: 1,
+			})
+			cache := newFakeFlagCache()
+
+			resp, err := newService(store, cache).UpdateFlagRules(context.Background(), tt.flag, port.UpdateFlagRulesRequest{Rules: tt.rules})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.rules, resp.Rules)
+			assert.Equal(t, int64(2), resp.Version)
+			assert.Equal(t, int64(2), cache.flags["my-flag"].Version)
+		})
+	}
+}
+
+func TestService_GetFlagValue_ScheduleUsesClock(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:  "office-hours",
+		Type:  domain.FlagTypeBoolean,
+		Value: domain.FlagValue{Bool: &off},
+		Rules: []domain.Rule{{
+			ID:    "business-hours",
+			Value: domain.FlagValue{Bool: &on},
+			Clauses: []domain.Clause{
+				{Operator: domain.OperatorTimeOfDayBetween, Values: []string{"09:00", "17:00"}, Timezone: "Europe/Warsaw"},
+			},
+		}},
+		Version: 1,
+	})
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantValue  bool
+		wantReason string
+		wantRuleID string
+	}{
+		{
+			name:       "inside window",
+			now:        time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC),
+			wantValue:  true,
+			wantReason: "TARGETING_MATCH",
+			wantRuleID: "business-hours",
+		},
+		{
+			name:       "outside window",
+			now:        time.Date(2026, 6, 1, 16, 0, 0, 0, time.UTC),
+			wantValue:  false,
+			wantReason: "DEFAULT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: tt.now}))
+
+			resp, err := svc.GetFlagValue(context.Background(), "office-hours")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, *resp.Value.Bool)
+			assert.Equal(t, tt.wantReason, resp.Reason)
+			assert.Equal(t, tt.wantRuleID, resp.RuleID)
+		})
+	}
+}
+
+func TestService_AddTargets(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	numVal := 1.0
+
+	tests := []struct {
+		name    string
+		flag    string
+		req     port.AddTargetsRequest
+		wantErr error
+	}{
+		{
+			name: "adds targets",
+			flag: "new-checkout",
+			req:  port.AddTargetsRequest{Keys: []string{"user-1234", "user-5678"}, Value: port.FlagValue{Bool: &on}},
+		},
+		{
+			name:    "no keys",
+			flag:    "new-checkout",
+			req:     port.AddTargetsRequest{Value: port.FlagValue{Bool: &on}},
+			wantErr: domain.ErrInvalidTarget,
+		},
+		{
+			name:    "value of wrong type",
+			flag:    "new-checkout",
+			req:     port.AddTargetsRequest{Keys: []string{"user-1234"}, Value: port.FlagValue{Numeric: &numVal}},
+			wantErr: domain.ErrTypeMismatch,
+		},
+		{
+			name:    "not found",
+			flag:    "ghost",
+			req:     port.AddTargetsRequest{Keys: []string{"user-1234"}, Value: port.FlagValue{Bool: &on}},
+			wantErr: domain.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			_ = store.Create(context.Background(), domain.Flag{
+				Name:    "new-checkout",
+				Type:    domain.FlagTypeBoolean,
+				Value:   domain.FlagValue{Bool: &off},
+				Version: 1,
+			})
+			cache := newFakeFlagCache()
+
+			resp, err := newService(store, cache).AddTargets(context.Background(), tt.flag, tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, map[string]port.FlagValue{"user-1234": {Bool: &on}, "user-5678": {Bool: &on}}, resp.Targets)
+			assert.Equal(t, int64(2), resp.Version)
+			assert.Len(t, cache.flags["new-checkout"].Targets, 2)
+		})
+	}
+}
+
+func TestService_RemoveTargets(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:    "new-checkout",
+		Type:    domain.FlagTypeBoolean,
+		Value:   domain.FlagValue{Bool: &off},
+		Targets: map[string]domain.FlagValue{"user-1234": {Bool: &on}, "user-5678": {Bool: &on}},
+		Version: 1,
+	})
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+
+	resp, err := svc.RemoveTargets(context.Background(), "new-checkout", port.RemoveTargetsRequest{Keys: []string{"user-1234"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]port.FlagValue{"user-5678": {Bool: &on}}, resp.Targets)
+	assert.Equal(t, int64(2), cache.flags["new-checkout"].Version)
+
+	_, err = svc.RemoveTargets(context.Background(), "new-checkout", port.RemoveTargetsRequest{})
+	require.ErrorIs(t, err, domain.ErrInvalidTarget)
+
+	_, err = svc.RemoveTargets(context.Background(), "ghost", port.RemoveTargetsRequest{Keys: []string{"user-1"}})
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_EvaluateAll_Targets(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	_ = store.Create(context.Background(), domain.Flag{
+		Name:    "new-checkout",
+		Type:    domain.FlagTypeBoolean,
+		Value:   domain.FlagValue{Bool: &off},
+		Targets: map[string]domain.FlagValue{"user-1234": {Bool: &on}},
+		Version: 1,
+	})
+
+	resp, err := newService(store, newFakeFlagCache()).EvaluateAll(context.Background(),
+		port.EvaluationContext{TargetingKey: "user-1234"}, port.EvaluationFilter{})
+
+	require.NoError(t, err)
+	got := resp.Flags["new-checkout"]
+	require.NotNil(t, got.Value.Bool)
+	assert.True(t, *got.Value.Bool)
+	assert.Equal(t, string(domain.ReasonTargetMatch), got.Reason)
+}
+
+func (f *fakeFlagStore) CreateFreezeWindow(_ context.Context, window domain.FreezeWindow) (*domain.FreezeWindow, error) {
+	f.freezeSeq++
+	window.ID = f.freezeSeq
+	f.freezes = append(f.freezes, window)
+	return &window, nil
+}
+
+func (f *fakeFlagStore) GetFreezeWindow(_ context.Context, id int64) (*domain.FreezeWindow, error) {
+	for _, window := range f.freezes {
+		if window.ID == id {
+			return &window, nil
+		}
+	}
+	return nil, domain.ErrFreezeNotFound
+}
+
+func (f *fakeFlagStore) ListFreezeWindows(_ context.Context, q domain.FreezeQuery) ([]domain.FreezeWindow, error) {
+	var out []domain.FreezeWindow
+	for _, window := range f.freezes {
+		if q.ActiveAt.IsZero() || window.ActiveAt(q.ActiveAt) {
+			out = append(out, window)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeFlagStore) DeleteFreezeWindow(_ context.Context, id int64) error {
+	n := len(f.freezes)
+	f.freezes = slices.DeleteFunc(f.freezes, func(window domain.FreezeWindow) bool { return window.ID == id })
+	if len(f.freezes) == n {
+		return domain.ErrFreezeNotFound
+	}
+	return nil
+}
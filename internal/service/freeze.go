@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// CreateFreezeWindow stores a freeze window attributed to the context's
+// actor. The projects and environments it names must exist.
+func (s *Service) CreateFreezeWindow(ctx context.Context, req port.FreezeWindowRequest) (*port.FreezeWindowResponse, error) {
+	now := s.clock.Now().UTC()
+	window := domain.FreezeWindow{
+		Reason:       req.Reason,
+		StartsAt:     req.StartsAt.UTC(),
+		EndsAt:       req.EndsAt.UTC(),
+		Projects:     req.Projects,
+		Environments: req.Environments,
+		Tags:         req.Tags,
+		CreatedBy:    port.ActorFrom(ctx),
+		CreatedAt:    now,
+	}
+	if err := domain.ValidateFreezeWindow(window); err != nil {
+		return nil, err
+	}
+	for _, project := range window.Projects {
+		if _, err := s.store.GetProject(ctx, project); err != nil {
+			return nil, err
+		}
+	}
+	for _, env := range window.Environments {
+		if _, err := s.store.GetEnvironment(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+	created, err := s.windows.CreateFreezeWindow(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	return freezeToResponse(*created, now), nil
+}
+
+func (s *Service) ListFreezeWindows(ctx context.Context, filter port.FreezeWindowFilter) ([]port.FreezeWindowResponse, error) {
+	now := s.clock.Now().UTC()
+	var q domain.FreezeQuery
+	if filter.Active {
+		q.ActiveAt = now
+	}
+	windows, err := s.windows.ListFreezeWindows(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.FreezeWindowResponse, len(windows))
+	for i, window := range windows {
+		out[i] = *freezeToResponse(window, now)
+	}
+	return out, nil
+}
+
+func (s *Service) GetFreezeWindow(ctx context.Context, id int64) (*port.FreezeWindowResponse, error) {
+	window, err := s.windows.GetFreezeWindow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return freezeToResponse(*window, s.clock.Now().UTC()), nil
+}
+
+func (s *Service) DeleteFreezeWindow(ctx context.Context, id int64) error {
+	window, err := s.windows.GetFreezeWindow(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.windows.DeleteFreezeWindow(ctx, id); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "freeze window deleted", "window", id, "actor", port.ActorFrom(ctx), "active", window.ActiveAt(s.clock.Now().UTC()))
+	return nil
+}
+
+// freezeGuard refuses changes covered by a freeze window in force.
+type freezeGuard struct {
+	store   port.FlagStore
+	windows port.FreezeStore
+	clock   port.Clock
+	logger  *slog.Logger
+}
+
+// flagsFunc returns the flags a change touches, for matching the tags of
+// freeze windows. It is only called when a window scoped by tag applies.
+type flagsFunc func(ctx context.Context) ([]domain.Flag, error)
+
+// check returns an error wrapping domain.ErrFrozen when a window in force
+// covers a change to the flags of project in envs or in any environment
+// inheriting from them. A named actor's emergency override, set with
+// port.WithEmergency, lets the change through; it is logged here and recorded
+// with the change's audit entry.
+func (g freezeGuard) check(ctx context.Context, project string, envs []string, flags flagsFunc) error {
+	windows, err := g.windows.ListFreezeWindows(ctx, domain.FreezeQuery{ActiveAt: g.clock.Now().UTC()})
+	if err != nil || len(windows) == 0 {
+		return err
+	}
+	all, err := g.store.ListEnvironments(ctx)
+	if err != nil {
+		return err
+	}
+	affected := slices.Clone(envs)
+	for _, env := range envs {
+		affected = append(affected, domain.Descendants(all, env)...)
+	}
+
+	var touched []domain.Flag
+	loaded := false
+	for _, window := range windows {
+		for _, env := range affected {
+			if !window.Covers(project, env) {
+				continue
+			}
+			if len(window.Tags) == 0 {
+				return g.frozen(ctx, window, project, env, "")
+			}
+			if !loaded {
+				if touched, err = flags(ctx); err != nil {
+					return err
+				}
+				loaded = true
+			}
+			for _, flag := range touched {
+				if window.CoversTags(flag.Tags) {
+					return g.frozen(ctx, window, project, env, flag.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// frozen refuses the change window covers, unless the context carries an
+// emergency override by a named actor.
+func (g freezeGuard) frozen(ctx context.Context, window domain.FreezeWindow, project, env, name string) error {
+	reason, actor := port.EmergencyFrom(ctx), port.ActorFrom(ctx)
+	if reason == "" {
+		return fmt.Errorf("freeze window %d covers project %q in %q until %s: %w",
+			window.ID, project, env, window.EndsAt.Format(time.RFC3339), domain.ErrFrozen)
+	}
+	if actor == domain.AnonymousActor {
+		return fmt.Errorf("an emergency override needs a named actor: %w", domain.ErrFrozen)
+	}
+	g.logger.WarnContext(ctx, "change freeze overridden",
+		"window", window.ID, "project", project, "environment", env, "flag", name, "actor", actor, "reason", reason)
+	return nil
+}
+
+// named loads the flags called names in project and env, or all of the
+// project's flags when names is empty.
+func (g freezeGuard) named(project, env string, names ...string) flagsFunc {
+	return func(ctx context.Context) ([]domain.Flag, error) {
+		if len(names) == 0 {
+			var err error
+			if names, err = g.store.ListNames(ctx, project, ""); err != nil {
+				return nil, err
+			}
+		}
+		return g.store.GetByNames(ctx, project, env, names)
+	}
+}
+
+// these returns flags already at hand.
+func these(flags ...domain.Flag) flagsFunc {
+	return func(context.Context) ([]domain.Flag, error) { return flags, nil }
+}
+
+// freezeToResponse reports whether the window is in force as of now.
+func freezeToResponse(window domain.FreezeWindow, now time.Time) *port.FreezeWindowResponse {
+	return &port.FreezeWindowResponse{
+		ID:           window.ID,
+		Reason:       window.Reason,
+		StartsAt:     window.StartsAt,
+		EndsAt:       window.EndsAt,
+		Projects:     window.Projects,
+		Environments: window.Environments,
+		Tags:         window.Tags,
+		CreatedBy:    window.CreatedBy,
+		CreatedAt:    window.CreatedAt,
+		Active:       window.ActiveAt(now),
+	}
+}
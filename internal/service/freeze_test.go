@@ -0,0 +1,290 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+// blackFriday is the instant the freezes in these tests are checked at.
+var blackFriday = time.Date(2026, 11, 27, 9, 0, 0, 0, time.UTC)
+
+// frozenStore returns a store holding new-checkout, off and tagged
+// payments, and legacy-banner, untagged, with staging inheriting from
+// production, frozen by window.
+func frozenStore(t *testing.T, window domain.FreezeWindow) *fakeFlagStore {
+	t.Helper()
+	off := false
+	store := newFakeFlagStore()
+	store.environments["staging"] = domain.Environment{Key: "staging", Parent: domain.DefaultEnvironment}
+	store.envs["staging"] = make(map[string]domain.Flag)
+	svc := newService(store, newFakeFlagCache())
+	ctx := context.Background()
+	_, err := svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Tags: []string{"payments"}, Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	_, err = svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "legacy-banner", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	_, err = store.CreateFreezeWindow(ctx, window)
+	require.NoError(t, err)
+	return store
+}
+
+func TestService_CreateFreezeWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.FreezeWindowRequest
+		wantErr error
+	}{
+		{
+			name: "creates",
+			req: port.FreezeWindowRequest{
+				Reason: "black friday", StartsAt: blackFriday, EndsAt: blackFriday.Add(96 * time.Hour),
+				Projects: []string{domain.DefaultProject}, Environments: []string{domain.DefaultEnvironment}, Tags: []string{"payments"},
+			},
+		},
+		{name: "inverted", req: port.FreezeWindowRequest{StartsAt: blackFriday, EndsAt: blackFriday.Add(-time.Hour)}, wantErr: domain.ErrInvalidFreeze},
+		{name: "unknown project", req: port.FreezeWindowRequest{StartsAt: blackFriday, EndsAt: blackFriday.Add(time.Hour), Projects: []string{"search"}}, wantErr: domain.ErrProjectNotFound},
+		{name: "unknown environment", req: port.FreezeWindowRequest{StartsAt: blackFriday, EndsAt: blackFriday.Add(time.Hour), Environments: []string{"qa"}}, wantErr: domain.ErrEnvironmentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(newFakeFlagStore(), newFakeFlagCache(), service.WithClock(fakeClock{now: blackFriday.Add(-time.Hour)}))
+			ctx := port.WithActor(context.Background(), "alice")
+
+			resp, err := svc.CreateFreezeWindow(ctx, tt.req)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", resp.CreatedBy)
+			assert.False(t, resp.Active, "the window has not started yet")
+			got, err := svc.GetFreezeWindow(ctx, resp.ID)
+			require.NoError(t, err)
+			assert.Equal(t, resp, got)
+		})
+	}
+}
+
+func TestService_ListAndDeleteFreezeWindows(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeFlagStore()
+	svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: blackFriday}))
+	ctx := context.Background()
+	current, err := svc.CreateFreezeWindow(ctx, port.FreezeWindowRequest{StartsAt: blackFriday.Add(-time.Hour), EndsAt: blackFriday.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = svc.CreateFreezeWindow(ctx, port.FreezeWindowRequest{StartsAt: blackFriday.Add(24 * time.Hour), EndsAt: blackFriday.Add(48 * time.Hour)})
+	require.NoError(t, err)
+
+	all, err := svc.ListFreezeWindows(ctx, port.FreezeWindowFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	active, err := svc.ListFreezeWindows(ctx, port.FreezeWindowFilter{Active: true})
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, current.ID, active[0].ID)
+	assert.True(t, active[0].Active)
+
+	require.NoError(t, svc.DeleteFreezeWindow(ctx, current.ID))
+	require.ErrorIs(t, svc.DeleteFreezeWindow(ctx, current.ID), domain.ErrFreezeNotFound)
+	_, err = svc.GetFreezeWindow(ctx, current.ID)
+	require.ErrorIs(t, err, domain.ErrFreezeNotFound)
+}
+
+func TestService_Frozen(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	window := func(envs, tags []string) domain.FreezeWindow {
+		return domain.FreezeWindow{StartsAt: blackFriday.Add(-time.Hour), EndsAt: blackFriday.Add(time.Hour), Environments: envs, Tags: tags}
+	}
+	production := []string{domain.DefaultEnvironment}
+	update := func(svc *service.Service, ctx context.Context, name string) error {
+		_, err := svc.UpdateFlagValue(ctx, name, port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		window domain.FreezeWindow
+		env    string
+		flag   string
+		change func(svc *service.Service, ctx context.Context, name string) error
+		frozen bool
+	}{
+		{name: "value update", window: window(nil, nil), flag: "legacy-banner", change: update, frozen: true},
+		{name: "other environment", window: window([]string{"staging"}, nil), env: "staging", flag: "legacy-banner", change: update, frozen: true},
+		{name: "inheriting environment", window: window([]string{"staging"}, nil), flag: "legacy-banner", change: update, frozen: true},
+		{name: "unfrozen child", window: window(production, nil), env: "staging", flag: "legacy-banner", change: update, frozen: false},
+		{name: "tagged flag", window: window(production, []string{"payments"}), flag: "new-checkout", change: update, frozen: true},
+		{name: "untagged flag", window: window(production, []string{"payments"}), flag: "legacy-banner", change: update, frozen: false},
+		{name: "upcoming window", window: domain.FreezeWindow{StartsAt: blackFriday.Add(time.Hour), EndsAt: blackFriday.Add(2 * time.Hour)}, flag: "legacy-banner", change: update, frozen: false},
+		{
+			name: "rules", window: window(production, []string{"payments"}), flag: "new-checkout", frozen: true,
+			change: func(svc *service.Service, ctx context.Context, name string) error {
+				_, err := svc.UpdateFlagRules(ctx, name, port.UpdateFlagRulesRequest{})
+				return err
+			},
+		},
+		{
+			name: "enabled", window: window(production, []string{"payments"}), flag: "new-checkout", frozen: true,
+			change: func(svc *service.Service, ctx context.Context, name string) error {
+				_, err := svc.SetFlagEnabled(ctx, name, port.SetFlagEnabledRequest{Enabled: false})
+				return err
+			},
+		},
+		{
+			name: "targets", window: window(production, []string{"payments"}), flag: "new-checkout", frozen: true,
+			change: func(svc *service.Service, ctx context.Context, name string) error {
+				_, err := svc.RemoveTargets(ctx, name, port.RemoveTargetsRequest{Keys: []string{"user-1"}})
+				return err
+			},
+		},
+		{
+			name: "rollback", window: window(production, []string{"payments"}), flag: "new-checkout", frozen: true,
+			change: func(svc *service.Service, ctx context.Context, name string) error {
+				_, err := svc.RollbackFlag(ctx, name, 1)
+				return err
+			},
+		},
+		{
+			name: "promotion", window: window(production, []string{"payments"}), frozen: true,
+			change: func(svc *service.Service, ctx context.Context, _ string) error {
+				_, err := svc.PromoteFlags(ctx, port.PromoteRequest{Source: "staging", Target: domain.DefaultEnvironment})
+				return err
+			},
+		},
+		{
+			name: "new flag", window: window(nil, []string{"payments"}), frozen: true,
+			change: func(svc *service.Service, ctx context.Context, _ string) error {
+				_, err := svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "refunds", Type: "boolean", Tags: []string{"payments"}, Value: port.FlagValue{Bool: &on}})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newService(frozenStore(t, tt.window), newFakeFlagCache(), service.WithClock(fakeClock{now: blackFriday}))
+			ctx := port.WithEnvironment(port.WithActor(context.Background(), "alice"), tt.env)
+
+			err := tt.change(svc, ctx, tt.flag)
+			if tt.frozen {
+				require.ErrorIs(t, err, domain.ErrFrozen)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestService_EmergencyOverride(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	store := frozenStore(t, domain.FreezeWindow{StartsAt: blackFriday.Add(-time.Hour), EndsAt: blackFriday.Add(time.Hour)})
+	svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: blackFriday}))
+	req := port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}}
+
+	anonymous := port.WithEmergency(port.WithActor(context.Background(), domain.AnonymousActor), "incident 4711")
+	_, err := svc.UpdateFlagValue(anonymous, "new-checkout", req)
+	require.ErrorIs(t, err, domain.ErrFrozen, "an override needs a named actor")
+
+	responder := port.WithEmergency(port.WithActor(context.Background(), "alice"), "incident 4711")
+	resp, err := svc.UpdateFlagValue(responder, "new-checkout", req)
+	require.NoError(t, err)
+	assert.True(t, *resp.Value.Bool)
+}
+
+func TestService_ApproveChangeRequest_Frozen(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	store := protectedFlag(t)
+	svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: blackFriday}))
+	ctx := context.Background()
+	as := func(actor string) context.Context { return port.WithActor(ctx, actor) }
+
+	resp, err := svc.UpdateFlagValue(as("alice"), "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	_, err = svc.CreateFreezeWindow(ctx, port.FreezeWindowRequest{StartsAt: blackFriday, EndsAt: blackFriday.Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = svc.ApproveChangeRequest(as("bob"), resp.PendingChange.ID)
+	require.NoError(t, err, "approvals that do not apply the change are collected while frozen")
+	_, err = svc.ApproveChangeRequest(as("carol"), resp.PendingChange.ID)
+	require.ErrorIs(t, err, domain.ErrFrozen)
+	cr, err := svc.GetChangeRequest(ctx, resp.PendingChange.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", cr.Status)
+}
+
+func TestExperimentService_Frozen(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	svc := newExperimentService(experiments, flags, &fakeEventStore{}, newFakeFlagCache())
+	ctx := port.WithActor(context.Background(), "alice")
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "checkout-test", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+	})
+	require.NoError(t, err)
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err = flags.CreateFreezeWindow(ctx, domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(24 * time.Hour)})
+	require.NoError(t, err)
+
+	_, err = svc.StartExperiment(ctx, "checkout-test")
+	require.ErrorIs(t, err, domain.ErrFrozen)
+	started, err := svc.StartExperiment(port.WithEmergency(ctx, "rollout gone wrong"), "checkout-test")
+	require.NoError(t, err)
+	assert.Equal(t, "running", started.Status)
+	_, err = svc.StopExperiment(ctx, "checkout-test")
+	require.ErrorIs(t, err, domain.ErrFrozen)
+}
+
+func TestExperimentService_RebalanceBandits_Frozen(t *testing.T) {
+	t.Parallel()
+
+	flags := newMultivariateStore()
+	experiments := newFakeExperimentStore(flags)
+	events := &fakeEventStore{stats: []domain.VariantStats{
+		{Variant: "control", Users: 500, Conversions: 10},
+		{Variant: "treatment", Users: 500, Conversions: 60},
+	}}
+	svc := newExperimentService(experiments, flags, events, newFakeFlagCache())
+	ctx := context.Background()
+	_, err := svc.CreateExperiment(ctx, port.CreateExperimentRequest{
+		Key: "banner-bandit", FlagName: "checkout", Allocation: 100,
+		Variants: []port.VariantWeight{{Variant: "control", Weight: 1}, {Variant: "treatment", Weight: 1}},
+		Bandit:   &port.BanditConfig{Algorithm: "epsilon-greedy", Metric: "click", Epsilon: 0.2},
+	})
+	require.NoError(t, err)
+	_, err = svc.StartExperiment(ctx, "banner-bandit")
+	require.NoError(t, err)
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err = flags.CreateFreezeWindow(ctx, domain.FreezeWindow{StartsAt: start, EndsAt: start.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	version := flags.flags["checkout"].Version
+
+	require.NoError(t, svc.RebalanceBandits(ctx))
+
+	assert.Equal(t, 1, experiments.experiments["banner-bandit"].Variants[0].Weight, "a frozen experiment keeps its weights")
+	assert.Equal(t, version, flags.flags["checkout"].Version)
+	assert.Empty(t, experiments.history["banner-bandit"][1:])
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func (s *Service) GetFlagHistory(ctx context.Context, name string) ([]port.FlagVersionResponse, error) {
+	versions, err := s.store.ListHistory(ctx, port.ProjectFrom(ctx), port.EnvironmentFrom(ctx), name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.FlagVersionResponse, len(versions))
+	for i, version := range versions {
+		out[i] = versionToResponse(version)
+	}
+	return out, nil
+}
+
+// RollbackFlag restores a recorded version of the flag in the context's
+// environment and writes the result through to the cache.
+func (s *Service) RollbackFlag(ctx context.Context, name string, toVersion int64) (*port.FlagResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	if err := s.protected.check(ctx, project, env, name); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{env}, s.freezes.named(project, env, name)); err != nil {
+		return nil, err
+	}
+	updated, err := s.store.Rollback(ctx, project, env, name, toVersion, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, *updated)
+
+	return flagToResponse(*updated), nil
+}
+
+// GetSnapshot reconstructs every flag of the context's project and environment
+// from its history, as of the context's instant or now.
+func (s *Service) GetSnapshot(ctx context.Context) (*port.SnapshotResponse, error) {
+	project, env := port.ProjectFrom(ctx), port.EnvironmentFrom(ctx)
+	at := port.AsOfFrom(ctx)
+	if at.IsZero() {
+		at = s.clock.Now().UTC()
+	}
+	if _, err := s.store.GetProject(ctx, project); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetEnvironment(ctx, env); err != nil {
+		return nil, err
+	}
+	flags, err := s.store.ListAsOf(ctx, project, env, at)
+	if err != nil {
+		return nil, err
+	}
+	resp := &port.SnapshotResponse{Project: project, Environment: env, AsOf: at, Flags: make([]port.FlagResponse, len(flags))}
+	for i, flag := range flags {
+		resp.Flags[i] = *flagToResponse(flag)
+	}
+	return resp, nil
+}
+
+func versionToResponse(version domain.FlagVersion) port.FlagVersionResponse {
+	return port.FlagVersionResponse{
+		Version:     version.Version,
+		Environment: version.Environment,
+		Enabled:     !version.Disabled,
+		Value:       port.FlagValue{Bool: version.Value.Bool, Numeric: version.Value.Numeric},
+		Rules:       rulesToResponse(version.Rules),
+		Targets:     targetsToResponse(version.Targets),
+		RecordedAt:  version.RecordedAt,
+	}
+}
@@ -0,0 +1,206 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+func TestService_GetFlagHistory(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	svc := newService(newFakeFlagStore(), newFakeFlagCache())
+	ctx := context.Background()
+	staging := port.WithEnvironment(ctx, "staging")
+
+	_, err := svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	_, err = svc.UpdateFlagValue(ctx, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+	_, err = svc.CreateEnvironment(ctx, port.CreateEnvironmentRequest{Key: "staging", Parent: domain.DefaultEnvironment})
+	require.NoError(t, err)
+	_, err = svc.SetFlagEnabled(staging, "new-checkout", port.SetFlagEnabledRequest{Enabled: false})
+	require.NoError(t, err)
+
+	history, err := svc.GetFlagHistory(staging, "new-checkout")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, int64(3), history[0].Version)
+	assert.Equal(t, "staging", history[0].Environment)
+	assert.False(t, history[0].Enabled)
+	assert.Equal(t, int64(2), history[1].Version)
+	assert.Equal(t, domain.DefaultEnvironment, history[1].Environment, "versions inherited from the parent are listed")
+	assert.True(t, *history[1].Value.Bool)
+	assert.False(t, *history[2].Value.Bool)
+
+	history, err = svc.GetFlagHistory(ctx, "new-checkout")
+	require.NoError(t, err)
+	assert.Len(t, history, 2, "a parent does not list its children's versions")
+
+	_, err = svc.GetFlagHistory(ctx, "ghost")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestService_RollbackFlag(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+
+	tests := []struct {
+		name      string
+		toVersion int64
+		cacheErr  error
+		wantErr   error
+		wantValue bool
+	}{
+		{name: "restores the first version", toVersion: 1, wantValue: false},
+		{name: "restores a later version", toVersion: 2, wantValue: true},
+		{name: "cache failure is not surfaced", toVersion: 1, cacheErr: errors.New("connection refused"), wantValue: false},
+		{name: "unknown version", toVersion: 9, wantErr: domain.ErrVersionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := newFakeFlagCache()
+			now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+			svc := newService(newFakeFlagStore(), cache, service.WithClock(fakeClock{now: now}))
+			ctx := context.Background()
+			_, err := svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "new-checkout", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+			require.NoError(t, err)
+			_, err = svc.UpdateFlagValue(ctx, "new-checkout", port.UpdateFlagValueRequest{Value: port.FlagValue{Bool: &on}})
+			require.NoError(t, err)
+			_, err = svc.SetFlagEnabled(ctx, "new-checkout", port.SetFlagEnabledRequest{Enabled: false})
+			require.NoError(t, err)
+			cache.err = tt.cacheErr
+
+			resp, err := svc.RollbackFlag(ctx, "new-checkout", tt.toVersion)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, *resp.Value.Bool)
+			assert.True(t, resp.Enabled, "the enabled state is restored too")
+			assert.Equal(t, int64(4), resp.Version, "a rollback is a new version")
+			assert.Equal(t, now, resp.UpdatedAt, "the store stamps the change with the service clock")
+			if tt.cacheErr == nil {
+				assert.Equal(t, int64(4), cache.flags["new-checkout"].Version)
+			}
+		})
+	}
+}
+
+// seedHistory creates new-checkout off at 09:00 and records it switched on at
+// 14:00 in production.
+func seedHistory(t *testing.T, store *fakeFlagStore) time.Time {
+	t.Helper()
+	off := false
+	on := true
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Create(context.Background(), domain.Flag{
+		Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on},
+		Version: 2, CreatedAt: at, UpdatedAt: at.Add(5 * time.Hour),
+	}))
+	store.history = []domain.FlagVersion{
+		{Project: domain.DefaultProject, FlagName: "new-checkout", Environment: domain.DefaultEnvironment, Version: 1, Value: domain.FlagValue{Bool: &off}, RecordedAt: at},
+		{Project: domain.DefaultProject, FlagName: "new-checkout", Environment: domain.DefaultEnvironment, Version: 2, Value: domain.FlagValue{Bool: &on}, RecordedAt: at.Add(5 * time.Hour)},
+	}
+	return at
+}
+
+func TestService_GetFlag_AsOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		asOf        time.Duration
+		wantErr     error
+		wantValue   bool
+		wantVersion int64
+	}{
+		{name: "before the change", asOf: 5*time.Hour - time.Minute, wantValue: false, wantVersion: 1},
+		{name: "at the change", asOf: 5 * time.Hour, wantValue: true, wantVersion: 2},
+		{name: "before the flag existed", asOf: -time.Minute, wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newFakeFlagStore()
+			at := seedHistory(t, store)
+			svc := newService(store, newFakeFlagCache())
+			ctx := port.WithAsOf(context.Background(), at.Add(tt.asOf))
+
+			flag, err := svc.GetFlag(ctx, "new-checkout")
+			value, valueErr := svc.GetFlagValue(ctx, "new-checkout")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.ErrorIs(t, valueErr, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, *flag.Value.Bool)
+			assert.Equal(t, tt.wantVersion, flag.Version)
+			require.NoError(t, valueErr)
+			assert.Equal(t, tt.wantValue, *value.Value.Bool)
+			assert.Equal(t, tt.wantVersion, value.Version)
+			assert.Equal(t, "history", value.Source)
+		})
+	}
+}
+
+func TestService_GetFlagValue_AsOfBypassesCache(t *testing.T) {
+	t.Parallel()
+
+	on := true
+	store := newFakeFlagStore()
+	at := seedHistory(t, store)
+	cache := newFakeFlagCache()
+	cache.flags["new-checkout"] = domain.Flag{Name: "new-checkout", Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &on}, Version: 2}
+	svc := newService(store, cache)
+
+	value, err := svc.GetFlagValue(port.WithAsOf(context.Background(), at.Add(time.Hour)), "new-checkout")
+	require.NoError(t, err)
+	assert.False(t, *value.Value.Bool)
+	assert.Equal(t, "history", value.Source)
+}
+
+func TestService_GetSnapshot(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeFlagStore()
+	at := seedHistory(t, store)
+	svc := newService(store, newFakeFlagCache(), service.WithClock(fakeClock{now: at.Add(24 * time.Hour)}))
+
+	snapshot, err := svc.GetSnapshot(port.WithAsOf(context.Background(), at.Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, at.Add(time.Hour), snapshot.AsOf)
+	assert.Equal(t, domain.DefaultEnvironment, snapshot.Environment)
+	require.Len(t, snapshot.Flags, 1)
+	assert.False(t, *snapshot.Flags[0].Value.Bool)
+
+	now, err := svc.GetSnapshot(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, at.Add(24*time.Hour), now.AsOf, "defaults to now")
+	require.Len(t, now.Flags, 1)
+	assert.True(t, *now.Flags[0].Value.Bool)
+
+	_, err = svc.GetSnapshot(port.WithEnvironment(context.Background(), "ghost"))
+	require.ErrorIs(t, err, domain.ErrEnvironmentNotFound)
+}
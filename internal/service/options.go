@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"log/slog"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// defaultChangeRequestTTL is how long a change request stays open for
+// approval unless WithChangeRequestTTL says otherwise.
+const defaultChangeRequestTTL = 72 * time.Hour
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Option customises a service created by New, NewExperimentService,
+// NewAuditService, NewWebhookService, NewOutboxRelay, NewAPIKeyService,
+// NewAccessService or NewAuthorizedService.
+type Option func(*options)
+
+type options struct {
+	clock   port.Clock
+	logger  *slog.Logger
+	events  port.EventStore
+	holdout int
+	naming  domain.NamingPolicy
+	signing ed25519.PrivateKey
+	verify  ed25519.PublicKey
+	// changeTTL is how long a change request stays open for approval.
+	changeTTL time.Duration
+	retry     domain.RetryPolicy
+	adminKey  string
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		clock: systemClock{}, logger: slog.Default(), naming: domain.DefaultNamingPolicy(), changeTTL: defaultChangeRequestTTL,
+		retry: domain.DefaultRetryPolicy(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLogger sets the logger used to report soft failures such as cache errors.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithClock sets the clock used for timestamps and time-based targeting.
+func WithClock(clock port.Clock) Option {
+	return func(o *options) { o.clock = clock }
+}
+
+// WithEventStore sets the store experiment exposures are recorded in. Without
+// it, evaluations still assign variants but record nothing.
+func WithEventStore(events port.EventStore) Option {
+	return func(o *options) { o.events = events }
+}
+
+// WithHoldout sets the global holdout percentage applied to experiments as they
+// start. Contexts in the holdout never receive experimental treatment.
+func WithHoldout(percentage int) Option {
+	return func(o *options) { o.holdout = percentage }
+}
+
+// WithNamingPolicy sets the rules new flag names must follow, replacing
+// domain.DefaultNamingPolicy.
+func WithNamingPolicy(policy domain.NamingPolicy) Option {
+	return func(o *options) { o.naming = policy }
+}
+
+// WithSigningKey sets the key audit checkpoints are signed with. Its public
+// half also verifies them unless WithVerifyKey sets another key.
+func WithSigningKey(key ed25519.PrivateKey) Option {
+	return func(o *options) { o.signing = key }
+}
+
+// WithVerifyKey sets the key audit checkpoint signatures are verified with.
+func WithVerifyKey(key ed25519.PublicKey) Option {
+	return func(o *options) { o.verify = key }
+}
+
+// WithChangeRequestTTL sets how long a change request to a protected flag
+// stays open for approval before it expires.
+func WithChangeRequestTTL(ttl time.Duration) Option {
+	return func(o *options) { o.changeTTL = ttl }
+}
+
+// WithRetryPolicy sets how failed webhook deliveries are retried, replacing
+// domain.DefaultRetryPolicy.
+func WithRetryPolicy(policy domain.RetryPolicy) Option {
+	return func(o *options) { o.retry = policy }
+}
+
+// WithAdminKey sets a token that authenticates as domain.BootstrapPrincipal
+// with the admin scope without being stored, so the first keys can be
+// created.
+func WithAdminKey(token string) Option {
+	return func(o *options) { o.adminKey = token }
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+const (
+	// outboxBatch is the number of events read from the outbox and
+	// published to a sink at a time.
+	outboxBatch = 100
+	// outboxLease is how long a relay holds a sink after claiming it or
+	// advancing its cursor. Other relays leave the sink alone meanwhile.
+	outboxLease = time.Minute
+	// ownerBytes is the size of a relay's random owner id.
+	ownerBytes = 16
+)
+
+// OutboxRelay publishes the events written to the outbox with each flag
+// change to its sinks. Each sink receives the events in order and at least
+// once: its cursor only moves past events it has accepted.
+type OutboxRelay struct {
+	store  port.OutboxStore
+	sinks  []port.EventSink
+	owner  string
+	clock  port.Clock
+	logger *slog.Logger
+}
+
+func NewOutboxRelay(store port.OutboxStore, sinks []port.EventSink, opts ...Option) *OutboxRelay {
+	o := newOptions(opts)
+	return &OutboxRelay{store: store, sinks: sinks, owner: randomHex(ownerBytes), clock: o.clock, logger: o.logger}
+}
+
+// Relay publishes to every sink the events it has not received yet and
+// returns how many were published. A sink that fails is left where it
+// stopped and receives the same events on the next relay; the other sinks
+// carry on. Events every sink has received are then pruned.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	published := 0
+	var errs []error
+	names := make([]string, len(r.sinks))
+	for i, sink := range r.sinks {
+		names[i] = sink.Name()
+		n, err := r.relay(ctx, sink)
+		published += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", sink.Name(), err))
+		}
+	}
+	if _, err := r.store.PruneOutbox(ctx, names); err != nil {
+		errs = append(errs, fmt.Errorf("pruning outbox: %w", err))
+	}
+	return published, errors.Join(errs...)
+}
+
+// relay publishes the sink's events batch by batch while this relay holds
+// the sink. It does nothing while another relay holds it.
+func (r *OutboxRelay) relay(ctx context.Context, sink port.EventSink) (int, error) {
+	now := r.clock.Now().UTC()
+	cursor, ok, err := r.store.ClaimOutbox(ctx, sink.Name(), r.owner, now, now.Add(outboxLease))
+	if err != nil || !ok {
+		return 0, err
+	}
+	published := 0
+	for {
+		events, err := r.store.ListOutbox(ctx, cursor.Position, outboxBatch)
+		if err != nil || len(events) == 0 {
+			return published, err
+		}
+		if err := sink.Publish(ctx, events); err != nil {
+			return published, err
+		}
+		published += len(events)
+		cursor.Position = events[len(events)-1].ID
+		cursor.LeasedUntil = r.clock.Now().UTC().Add(outboxLease)
+		advanced, err := r.store.AdvanceOutbox(ctx, *cursor)
+		if err != nil {
+			return published, err
+		}
+		if !advanced {
+			// The lease ran out and another relay took the sink; it
+			// publishes these events again.
+			r.logger.WarnContext(ctx, "outbox lease lost", "sink", sink.Name(), "position", cursor.Position)
+			return published, nil
+		}
+		if len(events) < outboxBatch {
+			return published, nil
+		}
+	}
+}
+
+// RunRelay relays the outbox every interval until ctx is cancelled. Failures
+// are logged and retried on the next tick.
+func (r *OutboxRelay) RunRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Relay(ctx); err != nil && ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "outbox relay failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+var (
+	_ port.OutboxStore = (*fakeOutboxStore)(nil)
+	_ port.EventSink   = (*fakeSink)(nil)
+)
+
+// fakeOutboxStore keeps the outbox in memory. Cursors of sinks in heldBy
+// belong to another relay.
+type fakeOutboxStore struct {
+	events  []domain.FlagEvent
+	cursors map[string]domain.OutboxCursor
+	heldBy  map[string]string
+	pruned  []string
+}
+
+func newFakeOutboxStore(n int) *fakeOutboxStore {
+	f := &fakeOutboxStore{cursors: make(map[string]domain.OutboxCursor), heldBy: make(map[string]string)}
+	for i := range n {
+		f.events = append(f.events, domain.FlagEvent{ID: int64(i + 1), Key: fmt.Sprintf("evt-%d", i+1), Action: domain.AuditUpdateValue})
+	}
+	return f
+}
+
+func (f *fakeOutboxStore) ClaimOutbox(_ context.Context, sink, owner string, _, until time.Time) (*domain.OutboxCursor, bool, error) {
+	if other, ok := f.heldBy[sink]; ok && other != owner {
+		return nil, false, nil
+	}
+	cursor := f.cursors[sink]
+	cursor.Sink, cursor.Owner, cursor.LeasedUntil = sink, owner, until
+	f.cursors[sink] = cursor
+	return &cursor, true, nil
+}
+
+func (f *fakeOutboxStore) ListOutbox(_ context.Context, afterID int64, limit int) ([]domain.FlagEvent, error) {
+	var out []domain.FlagEvent
+	for _, event := range f.events {
+		if event.ID > afterID && len(out) < limit {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOutboxStore) AdvanceOutbox(_ context.Context, cursor domain.OutboxCursor) (bool, error) {
+	if f.cursors[cursor.Sink].Owner != cursor.Owner {
+		return false, nil
+	}
+	f.cursors[cursor.Sink] = cursor
+	return true, nil
+}
+
+func (f *fakeOutboxStore) PruneOutbox(_ context.Context, sinks []string) (int64, error) {
+	f.pruned = sinks
+	return 0, nil
+}
+
+// fakeSink records what it is sent and fails while err is set.
+type fakeSink struct {
+	name     string
+	err      error
+	received []domain.FlagEvent
+}
+
+func (f *fakeSink) Name() string { return f.name }
+
+func (f *fakeSink) Publish(_ context.Context, events []domain.FlagEvent) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.received = append(f.received, events...)
+	return nil
+}
+
+func newRelay(store *fakeOutboxStore, sinks ...port.EventSink) *service.OutboxRelay {
+	return service.NewOutboxRelay(store, sinks, service.WithLogger(slog.New(slog.DiscardHandler)))
+}
+
+func eventIDs(events []domain.FlagEvent) []int64 {
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func TestOutboxRelay_Relay(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeOutboxStore(250)
+	webhooks := &fakeSink{name: "webhooks"}
+	logs := &fakeSink{name: "log"}
+	relay := newRelay(store, webhooks, logs)
+	ctx := context.Background()
+
+	published, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 500, published)
+	for _, sink := range []*fakeSink{webhooks, logs} {
+		assert.True(t, slices.IsSorted(eventIDs(sink.received)), "events are published in order")
+		assert.Len(t, sink.received, 250)
+		assert.Equal(t, int64(250), store.cursors[sink.name].Position)
+	}
+	assert.Equal(t, []string{"webhooks", "log"}, store.pruned)
+
+	published, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "nothing is published twice without a failure")
+}
+
+func TestOutboxRelay_RetriesFailedSink(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeOutboxStore(3)
+	broken := &fakeSink{name: "redis-stream", err: errors.New("connection refused")}
+	logs := &fakeSink{name: "log"}
+	relay := newRelay(store, broken, logs)
+	ctx := context.Background()
+
+	published, err := relay.Relay(ctx)
+
+	require.ErrorContains(t, err, "sink redis-stream: connection refused")
+	assert.Equal(t, 3, published, "the other sinks carry on")
+	assert.Zero(t, store.cursors["redis-stream"].Position, "a failed sink keeps its position")
+
+	broken.err = nil
+	_, err = relay.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, eventIDs(broken.received), "the events are published once the sink recovers")
+}
+
+func TestOutboxRelay_LeavesSinksOfOtherRelays(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeOutboxStore(3)
+	store.heldBy["webhooks"] = "another-relay"
+	webhooks := &fakeSink{name: "webhooks"}
+
+	published, err := newRelay(store, webhooks).Relay(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Empty(t, webhooks.received)
+}
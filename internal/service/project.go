@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// CreateProject adds an empty project. Its flags are named independently of
+// those in other projects.
+func (s *Service) CreateProject(ctx context.Context, req port.CreateProjectRequest) (*port.ProjectResponse, error) {
+	project := domain.Project{
+		Key:         req.Key,
+		Description: req.Description,
+		CreatedAt:   s.clock.Now().UTC(),
+	}
+	if err := domain.ValidateProject(project); err != nil {
+		return nil, err
+	}
+	if err := s.store.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+	return projectToResponse(project), nil
+}
+
+func (s *Service) ListProjects(ctx context.Context) ([]port.ProjectResponse, error) {
+	projects, err := s.store.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.ProjectResponse, len(projects))
+	for i, project := range projects {
+		out[i] = *projectToResponse(project)
+	}
+	return out, nil
+}
+
+func projectToResponse(project domain.Project) *port.ProjectResponse {
+	return &port.ProjectResponse{Key: project.Key, Description: project.Description, CreatedAt: project.CreatedAt}
+}
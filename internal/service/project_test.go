@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+func TestService_CreateProject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.CreateProjectRequest
+		wantErr error
+	}{
+		{name: "valid", req: port.CreateProjectRequest{Key: "checkout", Description: "checkout team"}},
+		{name: "invalid key", req: port.CreateProjectRequest{Key: "Checkout Team"}, wantErr: domain.ErrInvalidProject},
+		{name: "key taken", req: port.CreateProjectRequest{Key: domain.DefaultProject}, wantErr: domain.ErrProjectExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, err := newService(newFakeFlagStore(), newFakeFlagCache()).CreateProject(context.Background(), tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.req.Key, resp.Key)
+			assert.Equal(t, tt.req.Description, resp.Description)
+			assert.False(t, resp.CreatedAt.IsZero())
+		})
+	}
+}
+
+func TestService_ProjectsIsolateFlags(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+	ctx := context.Background()
+	checkout := port.WithProject(ctx, "checkout")
+
+	_, err := svc.CreateProject(ctx, port.CreateProjectRequest{Key: "checkout"})
+	require.NoError(t, err)
+
+	_, err = svc.CreateFlag(ctx, port.CreateFlagRequest{Name: "new-ui", Type: "boolean", Value: port.FlagValue{Bool: &off}})
+	require.NoError(t, err)
+	created, err := svc.CreateFlag(checkout, port.CreateFlagRequest{Name: "new-ui", Type: "boolean", Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err, "names are unique per project")
+	assert.Equal(t, "checkout", created.Project)
+	_, err = svc.CreateFlag(checkout, port.CreateFlagRequest{Name: "new-ui", Type: "boolean", Value: port.FlagValue{Bool: &on}})
+	require.ErrorIs(t, err, domain.ErrAlreadyExists)
+	_, err = svc.CreateFlag(checkout, port.CreateFlagRequest{Name: "checkout-only", Type: "boolean", Value: port.FlagValue{Bool: &on}})
+	require.NoError(t, err)
+
+	value, err := svc.GetFlagValue(checkout, "new-ui")
+	require.NoError(t, err)
+	assert.True(t, *value.Value.Bool)
+	assert.True(t, *cache.projects["checkout"][domain.DefaultEnvironment]["new-ui"].Value.Bool, "cache is keyed by project")
+
+	value, err = svc.GetFlagValue(ctx, "new-ui")
+	require.NoError(t, err)
+	assert.False(t, *value.Value.Bool)
+
+	all, err := svc.EvaluateAll(ctx, port.EvaluationContext{TargetingKey: "user-1"}, port.EvaluationFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all.Flags, 1, "evaluation lists only the project's flags")
+	assert.Contains(t, all.Flags, "new-ui")
+
+	_, err = svc.CreateFlag(port.WithProject(ctx, "ghost"), port.CreateFlagRequest{Name: "new-ui", Type: "boolean", Value: port.FlagValue{Bool: &on}})
+	require.ErrorIs(t, err, domain.ErrProjectNotFound)
+	_, err = svc.GetFlag(port.WithProject(ctx, "ghost"), "new-ui")
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// PromoteFlags copies the value, enabled state, rules and targets of the
+// requested flags in the context's project from req.Source to req.Target. A dry run only reports the
+// diff; otherwise the store applies and records it in one transaction and the
+// updated flags are written through to the cache.
+func (s *Service) PromoteFlags(ctx context.Context, req port.PromoteRequest) (*port.PromotionResponse, error) {
+	if req.Source == req.Target {
+		return nil, fmt.Errorf("source and target are both %q: %w", req.Source, domain.ErrInvalidPromotion)
+	}
+	names := slices.Clone(req.Flags)
+	slices.Sort(names)
+	names = slices.Compact(names)
+	project := port.ProjectFrom(ctx)
+
+	if req.DryRun {
+		diffs, err := s.diffEnvironments(ctx, project, req.Source, req.Target, names)
+		if err != nil {
+			return nil, err
+		}
+		resp := promotionToResponse(domain.Promotion{Project: project, Source: req.Source, Target: req.Target, Diffs: diffs})
+		resp.DryRun = true
+		return resp, nil
+	}
+
+	if err := s.protected.check(ctx, project, req.Target, names...); err != nil {
+		return nil, err
+	}
+	if err := s.freezes.check(ctx, project, []string{req.Target}, s.freezes.named(project, req.Target, names...)); err != nil {
+		return nil, err
+	}
+	promotion, updated, err := s.store.Promote(ctx, project, req.Source, req.Target, names, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	s.writeThrough(ctx, updated...)
+	return promotionToResponse(*promotion), nil
+}
+
+func (s *Service) ListPromotions(ctx context.Context) ([]port.PromotionResponse, error) {
+	promotions, err := s.store.ListPromotions(ctx, port.ProjectFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.PromotionResponse, len(promotions))
+	for i, p := range promotions {
+		out[i] = *promotionToResponse(p)
+	}
+	return out, nil
+}
+
+// diffEnvironments returns the non-empty diffs of promoting the named flags,
+// or all of the project's flags, from source to target, ordered by flag name.
+func (s *Service) diffEnvironments(ctx context.Context, project, source, target string, names []string) ([]domain.FlagDiff, error) {
+	for _, env := range []string{source, target} {
+		if _, err := s.store.GetEnvironment(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+	if len(names) == 0 {
+		var err error
+		if names, err = s.store.ListNames(ctx, project, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	from, err := s.store.GetByNames(ctx, project, source, names)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.store.GetByNames(ctx, project, target, names)
+	if err != nil {
+		return nil, err
+	}
+	promoted := make(map[string]domain.Flag, len(from))
+	for _, flag := range from {
+		promoted[flag.Name] = flag
+	}
+	current := make(map[string]domain.Flag, len(to))
+	for _, flag := range to {
+		current[flag.Name] = flag
+	}
+
+	var diffs []domain.FlagDiff
+	for _, name := range names {
+		src, ok := promoted[name]
+		if !ok {
+			return nil, fmt.Errorf("flag %q: %w", name, domain.ErrNotFound)
+		}
+		if diff := domain.DiffFlag(current[name], src); !diff.Empty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+func promotionToResponse(p domain.Promotion) *port.PromotionResponse {
+	resp := &port.PromotionResponse{
+		ID:         p.ID,
+		Project:    p.Project,
+		Source:     p.Source,
+		Target:     p.Target,
+		Flags:      make([]port.FlagDiff, len(p.Diffs)),
+		PromotedAt: p.PromotedAt,
+	}
+	for i, d := range p.Diffs {
+		diff := port.FlagDiff{Name: d.Name}
+		if d.Value != nil {
+			diff.Value = &port.ValueChange{
+				From: port.FlagValue{Bool: d.Value.From.Bool, Numeric: d.Value.From.Numeric},
+				To:   port.FlagValue{Bool: d.Value.To.Bool, Numeric: d.Value.To.Numeric},
+			}
+		}
+		if d.Enabled != nil {
+			diff.Enabled = &port.EnabledChange{From: d.Enabled.From, To: d.Enabled.To}
+		}
+		for _, r := range d.Rules {
+			diff.Rules = append(diff.Rules, port.RuleChange{ID: r.ID, Change: string(r.Kind)})
+		}
+		for _, t := range d.Targets {
+			diff.Targets = append(diff.Targets, port.TargetChange{Key: t.Key, Change: string(t.Kind)})
+		}
+		resp.Flags[i] = diff
+	}
+	return resp
+}
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+// newStagingStore returns a store with production and staging, where staging
+// has new-checkout switched on and a rule added; other-flag is identical.
+func newStagingStore(t *testing.T) *fakeFlagStore {
+	t.Helper()
+	off := false
+	on := true
+	store := newFakeFlagStore()
+	ctx := context.Background()
+	for _, name := range []string{"new-checkout", "other-flag"} {
+		require.NoError(t, store.Create(ctx, domain.Flag{Name: name, Type: domain.FlagTypeBoolean, Value: domain.FlagValue{Bool: &off}, Version: 1}))
+	}
+	require.NoError(t, store.CreateEnvironment(ctx, domain.Environment{Key: "staging"}, domain.DefaultEnvironment))
+	_, err := store.UpdateValue(ctx, domain.DefaultProject, "staging", "new-checkout", domain.FlagValue{Bool: &on}, time.Time{})
+	require.NoError(t, err)
+	_, err = store.UpdateRules(ctx, domain.DefaultProject, "staging", "new-checkout", []domain.Rule{{
+		ID:      "pro",
+		Clauses: []domain.Clause{{Attribute: "plan", Operator: domain.OperatorIn, Values: []string{"pro"}}},
+		Value:   domain.FlagValue{Bool: &on},
+	}}, time.Time{})
+	require.NoError(t, err)
+	return store
+}
+
+func TestService_PromoteFlags_DryRun(t *testing.T) {
+	t.Parallel()
+
+	off := false
+	on := true
+	store := newStagingStore(t)
+	svc := newService(store, newFakeFlagCache())
+
+	resp, err := svc.PromoteFlags(context.Background(), port.PromoteRequest{Source: "staging", Target: "production", DryRun: true})
+
+	require.NoError(t, err)
+	assert.True(t, resp.DryRun)
+	assert.Zero(t, resp.ID)
+	assert.Equal(t, []port.FlagDiff{{
+		Name:  "new-checkout",
+		Value: &port.ValueChange{From: port.FlagValue{Bool: &off}, To: port.FlagValue{Bool: &on}},
+		Rules: []port.RuleChange{{ID: "pro", Change: "added"}},
+	}}, resp.Flags, "unchanged flags are left out")
+	assert.False(t, *store.flags["new-checkout"].Value.Bool, "a dry run changes nothing")
+	assert.Empty(t, store.promotions)
+}
+
+func TestService_PromoteFlags_Apply(t *testing.T) {
+	t.Parallel()
+
+	store := newStagingStore(t)
+	cache := newFakeFlagCache()
+	svc := newService(store, cache)
+
+	resp, err := svc.PromoteFlags(context.Background(), port.PromoteRequest{
+		Source: "staging", Target: "production", Flags: []string{"new-checkout", "other-flag", "new-checkout"},
+	})
+
+	require.NoError(t, err)
+	assert.False(t, resp.DryRun)
+	assert.Equal(t, int64(1), resp.ID)
+	assert.False(t, resp.PromotedAt.IsZero())
+	require.Len(t, resp.Flags, 1)
+
+	prod := store.flags["new-checkout"]
+	assert.True(t, *prod.Value.Bool)
+	assert.Len(t, prod.Rules, 1)
+	assert.Equal(t, domain.DefaultEnvironment, prod.Environment)
+	assert.Equal(t, int64(2), prod.Version)
+	assert.Equal(t, prod, cache.flags["new-checkout"], "promoted flags are written through to the cache")
+	assert.Equal(t, int64(1), store.flags["other-flag"].Version, "unchanged flags are not touched")
+
+	history, err := svc.ListPromotions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "staging", history[0].Source)
+}
+
+func TestService_PromoteFlags_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.PromoteRequest
+		wantErr error
+	}{
+		{name: "same environment", req: port.PromoteRequest{Source: "staging", Target: "staging"}, wantErr: domain.ErrInvalidPromotion},
+		{name: "unknown source", req: port.PromoteRequest{Source: "ghost", Target: "production", DryRun: true}, wantErr: domain.ErrEnvironmentNotFound},
+		{name: "unknown target", req: port.PromoteRequest{Source: "staging", Target: "ghost"}, wantErr: domain.ErrEnvironmentNotFound},
+		{name: "unknown flag", req: port.PromoteRequest{Source: "staging", Target: "production", Flags: []string{"ghost"}, DryRun: true}, wantErr: domain.ErrNotFound},
+		{name: "unknown flag applied", req: port.PromoteRequest{Source: "staging", Target: "production", Flags: []string{"new-checkout", "ghost"}}, wantErr: domain.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := newStagingStore(t)
+			_, err := newService(store, newFakeFlagCache()).PromoteFlags(context.Background(), tt.req)
+
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, *store.flags["new-checkout"].Value.Bool, "a failed promotion changes nothing")
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+)
+
+var (
+	_ port.WebhookService = (*WebhookService)(nil)
+	_ port.EventSink      = (*WebhookService)(nil)
+)
+
+const (
+	// defaultDeliveryPage is the number of deliveries returned when the
+	// filter sets no limit.
+	defaultDeliveryPage = 100
+	// deliveryBatch is the number of deliveries claimed per round trip.
+	deliveryBatch = 100
+	// deliveryLease hides a claimed delivery from other workers while it is
+	// attempted. It outlasts the sender's timeout.
+	deliveryLease = time.Minute
+	// secretBytes is the size of a generated webhook secret.
+	secretBytes = 32
+)
+
+// WebhookService manages webhook subscriptions and delivers the flag changes
+// queued for them, retrying failed deliveries with exponential backoff until
+// they succeed or are dead.
+type WebhookService struct {
+	store  port.WebhookStore
+	sender port.WebhookSender
+	retry  domain.RetryPolicy
+	clock  port.Clock
+	logger *slog.Logger
+}
+
+func NewWebhookService(store port.WebhookStore, sender port.WebhookSender, opts ...Option) *WebhookService {
+	o := newOptions(opts)
+	return &WebhookService{store: store, sender: sender, retry: o.retry, clock: o.clock, logger: o.logger}
+}
+
+// CreateWebhook stores a subscription attributed to the context's actor. The
+// secret is only returned here; without one a random secret is generated.
+func (s *WebhookService) CreateWebhook(ctx context.Context, req port.WebhookRequest) (*port.WebhookResponse, error) {
+	hook := domain.Webhook{
+		URL:       req.URL,
+		Events:    make([]domain.AuditAction, len(req.Events)),
+		Secret:    req.Secret,
+		CreatedBy: port.ActorFrom(ctx),
+		CreatedAt: s.clock.Now().UTC(),
+	}
+	for i, event := range req.Events {
+		hook.Events[i] = domain.AuditAction(event)
+	}
+	if hook.Secret == "" {
+		hook.Secret = randomHex(secretBytes)
+	}
+	if err := domain.ValidateWebhook(hook); err != nil {
+		return nil, err
+	}
+	created, err := s.store.CreateWebhook(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	resp := webhookToResponse(*created)
+	resp.Secret = created.Secret
+	return &resp, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]port.WebhookResponse, error) {
+	hooks, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		out[i] = webhookToResponse(hook)
+	}
+	return out, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id int64) (*port.WebhookResponse, error) {
+	hook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := webhookToResponse(*hook)
+	return &resp, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := s.store.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "webhook deleted", "webhook", id, "actor", port.ActorFrom(ctx))
+	return nil
+}
+
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, id int64, filter port.DeliveryFilter) ([]port.DeliveryResponse, error) {
+	q := domain.DeliveryQuery{WebhookID: id, Status: domain.DeliveryStatus(filter.Status), Limit: filter.Limit}
+	if q.Limit == 0 {
+		q.Limit = defaultDeliveryPage
+	}
+	if err := domain.ValidateDeliveryQuery(q); err != nil {
+		return nil, err
+	}
+	if _, err := s.store.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.store.ListDeliveries(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]port.DeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		out[i] = deliveryToResponse(d)
+	}
+	return out, nil
+}
+
+func (s *WebhookService) RetryWebhookDelivery(ctx context.Context, id, deliveryID int64) (*port.DeliveryResponse, error) {
+	d, err := s.store.GetDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != domain.DeliveryDead {
+		return nil, fmt.Errorf("delivery %d is %s: %w", d.ID, d.Status, domain.ErrDeliveryState)
+	}
+	d.Status = domain.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = s.clock.Now().UTC()
+	if err := s.store.UpdateDelivery(ctx, *d); err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "webhook delivery retried", "webhook", id, "delivery", d.ID, "actor", port.ActorFrom(ctx))
+	resp := deliveryToResponse(*d)
+	return &resp, nil
+}
+
+// Deliver attempts every delivery that is due and returns how many were
+// attempted. A delivery that fails is scheduled again after the retry
+// policy's backoff, or is dead once its attempts are spent.
+func (s *WebhookService) Deliver(ctx context.Context) (int, error) {
+	attempted := 0
+	for {
+		due, err := s.store.ClaimDeliveries(ctx, s.clock.Now().UTC(), deliveryLease, deliveryBatch)
+		if err != nil {
+			return attempted, err
+		}
+		hooks := make(map[int64]*domain.Webhook)
+		for _, d := range due {
+			hook, ok := hooks[d.WebhookID]
+			if !ok {
+				if hook, err = s.store.GetWebhook(ctx, d.WebhookID); errors.Is(err, domain.ErrWebhookNotFound) {
+					hook = nil
+				} else if err != nil {
+					return attempted, err
+				}
+				hooks[d.WebhookID] = hook
+			}
+			if hook == nil {
+				// Deleted since the claim; its deliveries went with it.
+				continue
+			}
+			if err := s.attempt(ctx, *hook, d); err != nil {
+				return attempted, err
+			}
+			attempted++
+		}
+		if len(due) < deliveryBatch {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt sends the delivery once and records the outcome.
+func (s *WebhookService) attempt(ctx context.Context, hook domain.Webhook, d domain.WebhookDelivery) error {
+	status, err := s.sender.Send(ctx, hook, d)
+	now := s.clock.Now().UTC()
+	if err == nil {
+		d.Succeed(now, status)
+	} else {
+		d.Fail(s.retry, now, status, err.Error())
+		if d.Status == domain.DeliveryDead {
+			s.logger.WarnContext(ctx, "webhook delivery dead", "webhook", hook.ID, "delivery", d.ID, "attempts", d.Attempts, "error", err)
+		}
+	}
+	return s.store.UpdateDelivery(ctx, d)
+}
+
+// RunDeliveries attempts the due deliveries every interval until ctx is
+// cancelled. Failures are logged and retried on the next tick.
+func (s *WebhookService) RunDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Deliver(ctx); err != nil && ctx.Err() == nil {
+				s.logger.WarnContext(ctx, "webhook deliveries failed", "error", err)
+			}
+		}
+	}
+}
+
+// Name identifies the webhooks to the outbox relay.
+func (s *WebhookService) Name() string { return "webhooks" }
+
+// Publish queues a delivery of each event to every webhook subscribed to it,
+// due at once. Publishing an event again queues nothing new.
+func (s *WebhookService) Publish(ctx context.Context, events []domain.FlagEvent) error {
+	hooks, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now().UTC()
+	var deliveries []domain.WebhookDelivery
+	for _, event := range events {
+		for _, hook := range hooks {
+			if !hook.Subscribes(event.Action) {
+				continue
+			}
+			deliveries = append(deliveries, domain.WebhookDelivery{
+				WebhookID:     hook.ID,
+				Event:         event,
+				Status:        domain.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			})
+		}
+	}
+	return s.store.EnqueueDeliveries(ctx, deliveries)
+}
+
+// randomHex returns n random bytes in hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func webhookToResponse(hook domain.Webhook) port.WebhookResponse {
+	resp := port.WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    make([]string, len(hook.Events)),
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt,
+	}
+	for i, event := range hook.Events {
+		resp.Events[i] = string(event)
+	}
+	return resp
+}
+
+func deliveryToResponse(d domain.WebhookDelivery) port.DeliveryResponse {
+	return port.DeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          string(d.Event.Action),
+		Project:        d.Event.Project,
+		Environment:    d.Event.Environment,
+		Flag:           d.Event.FlagName,
+		Version:        d.Event.Version,
+		Actor:          d.Event.Actor,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
@@ -0,0 +1,291 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xNakero/feature-flags/internal/domain"
+	"github.com/xNakero/feature-flags/internal/port"
+	"github.com/xNakero/feature-flags/internal/service"
+)
+
+var _ port.WebhookStore = (*fakeWebhookStore)(nil)
+
+type fakeWebhookStore struct {
+	hooks      []domain.Webhook
+	deliveries []domain.WebhookDelivery
+}
+
+func (f *fakeWebhookStore) CreateWebhook(_ context.Context, hook domain.Webhook) (*domain.Webhook, error) {
+	hook.ID = int64(len(f.hooks) + 1)
+	f.hooks = append(f.hooks, hook)
+	return &hook, nil
+}
+
+func (f *fakeWebhookStore) GetWebhook(_ context.Context, id int64) (*domain.Webhook, error) {
+	for _, hook := range f.hooks {
+		if hook.ID == id {
+			return &hook, nil
+		}
+	}
+	return nil, fmt.Errorf("webhook %d: %w", id, domain.ErrWebhookNotFound)
+}
+
+func (f *fakeWebhookStore) ListWebhooks(context.Context) ([]domain.Webhook, error) {
+	return f.hooks, nil
+}
+
+func (f *fakeWebhookStore) DeleteWebhook(_ context.Context, id int64) error {
+	n := len(f.hooks)
+	f.hooks = slices.DeleteFunc(f.hooks, func(hook domain.Webhook) bool { return hook.ID == id })
+	if len(f.hooks) == n {
+		return fmt.Errorf("webhook %d: %w", id, domain.ErrWebhookNotFound)
+	}
+	f.deliveries = slices.DeleteFunc(f.deliveries, func(d domain.WebhookDelivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+func (f *fakeWebhookStore) EnqueueDeliveries(_ context.Context, deliveries []domain.WebhookDelivery) error {
+	for _, d := range deliveries {
+		if slices.ContainsFunc(f.deliveries, func(queued domain.WebhookDelivery) bool {
+			return queued.WebhookID == d.WebhookID && queued.Event.Key == d.Event.Key
+		}) {
+			continue
+		}
+		d.ID = int64(len(f.deliveries) + 1)
+		f.deliveries = append(f.deliveries, d)
+	}
+	return nil
+}
+
+func (f *fakeWebhookStore) ClaimDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var out []domain.WebhookDelivery
+	for i, d := range f.deliveries {
+		if d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) && len(out) < limit {
+			f.deliveries[i].NextAttemptAt = now.Add(lease)
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeWebhookStore) UpdateDelivery(_ context.Context, delivery domain.WebhookDelivery) error {
+	for i, d := range f.deliveries {
+		if d.ID == delivery.ID {
+			f.deliveries[i] = delivery
+			return nil
+		}
+	}
+	return fmt.Errorf("delivery %d: %w", delivery.ID, domain.ErrDeliveryNotFound)
+}
+
+func (f *fakeWebhookStore) GetDelivery(_ context.Context, webhookID, id int64) (*domain.WebhookDelivery, error) {
+	for _, d := range f.deliveries {
+		if d.ID == id && d.WebhookID == webhookID {
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("delivery %d: %w", id, domain.ErrDeliveryNotFound)
+}
+
+func (f *fakeWebhookStore) ListDeliveries(_ context.Context, q domain.DeliveryQuery) ([]domain.WebhookDelivery, error) {
+	var out []domain.WebhookDelivery
+	for _, d := range slices.Backward(f.deliveries) {
+		if d.WebhookID == q.WebhookID && (q.Status == "" || d.Status == q.Status) && len(out) < q.Limit {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// fakeSender answers each attempt with the next of its statuses, failing
+// every answer outside 2xx, and records what it was asked to send.
+type fakeSender struct {
+	statuses []int
+	sent     []domain.WebhookDelivery
+}
+
+func (f *fakeSender) Send(_ context.Context, _ domain.Webhook, delivery domain.WebhookDelivery) (int, error) {
+	f.sent = append(f.sent, delivery)
+	status := f.statuses[0]
+	if len(f.statuses) > 1 {
+		f.statuses = f.statuses[1:]
+	}
+	switch {
+	case status == 0:
+		return 0, errors.New("connection refused")
+	case status >= 300:
+		return status, fmt.Errorf("receiver answered %d", status)
+	}
+	return status, nil
+}
+
+func newWebhookService(store *fakeWebhookStore, sender *fakeSender, opts ...service.Option) *service.WebhookService {
+	opts = append([]service.Option{service.WithLogger(slog.New(slog.DiscardHandler))}, opts...)
+	return service.NewWebhookService(store, sender, opts...)
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     port.WebhookRequest
+		wantErr error
+	}{
+		{name: "with secret", req: port.WebhookRequest{URL: "https://deploys.example.com/flags", Events: []string{"update-value"}, Secret: "0123456789abcdef"}},
+		{name: "generated secret", req: port.WebhookRequest{URL: "https://deploys.example.com/flags"}},
+		{name: "invalid url", req: port.WebhookRequest{URL: "deploys.example.com"}, wantErr: domain.ErrInvalidWebhook},
+		{name: "unknown event", req: port.WebhookRequest{URL: "https://deploys.example.com/flags", Events: []string{"delete"}}, wantErr: domain.ErrInvalidWebhook},
+		{name: "short secret", req: port.WebhookRequest{URL: "https://deploys.example.com/flags", Secret: "hunter2"}, wantErr: domain.ErrInvalidWebhook},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := &fakeWebhookStore{}
+			svc := newWebhookService(store, &fakeSender{})
+			ctx := port.WithActor(context.Background(), "alice")
+
+			resp, err := svc.CreateWebhook(ctx, tt.req)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, store.hooks)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", resp.CreatedBy)
+			assert.Equal(t, store.hooks[0].Secret, resp.Secret, "the secret is returned on creation")
+			if tt.req.Secret == "" {
+				assert.Len(t, resp.Secret, 64)
+			}
+			listed, err := svc.ListWebhooks(ctx)
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Empty(t, listed[0].Secret, "the secret is not listed")
+			assert.Equal(t, resp.Events, listed[0].Events)
+		})
+	}
+}
+
+func TestWebhookService_Publish(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeWebhookStore{}
+	ctx := context.Background()
+	_, err := store.CreateWebhook(ctx, domain.Webhook{URL: "https://deploys.example.com", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	_, err = store.CreateWebhook(ctx, domain.Webhook{URL: "https://chat.example.com", Events: []domain.AuditAction{domain.AuditUpdateValue}, Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	now := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	svc := newWebhookService(store, &fakeSender{}, service.WithClock(fakeClock{now: now}))
+	events := []domain.FlagEvent{
+		{ID: 1, Key: "evt-1", Action: domain.AuditCreate, FlagName: "new-checkout", Version: 1},
+		{ID: 2, Key: "evt-2", Action: domain.AuditUpdateValue, FlagName: "new-checkout", Version: 2, Actor: "alice", RequestID: "req-1", OccurredAt: now.Add(-time.Second)},
+	}
+
+	require.NoError(t, svc.Publish(ctx, events))
+
+	require.Len(t, store.deliveries, 3, "only the unfiltered webhook receives creations")
+	update := store.deliveries[2]
+	assert.Equal(t, int64(2), update.WebhookID)
+	assert.Equal(t, events[1], update.Event)
+	assert.Equal(t, domain.DeliveryPending, update.Status)
+	assert.Equal(t, now, update.NextAttemptAt, "new deliveries are due at once")
+	assert.Equal(t, "webhooks", svc.Name())
+
+	require.NoError(t, svc.Publish(ctx, events))
+	assert.Len(t, store.deliveries, 3, "events published again are not queued twice")
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeWebhookStore{}
+	ctx := context.Background()
+	hook, err := store.CreateWebhook(ctx, domain.Webhook{URL: "https://deploys.example.com", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	start := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, store.EnqueueDeliveries(ctx, []domain.WebhookDelivery{
+		{WebhookID: hook.ID, Event: domain.FlagEvent{Key: "evt-1", Action: domain.AuditCreate, FlagName: "new-checkout"}, Status: domain.DeliveryPending, NextAttemptAt: start},
+		{WebhookID: hook.ID, Event: domain.FlagEvent{Key: "evt-2", Action: domain.AuditUpdateValue, FlagName: "new-checkout"}, Status: domain.DeliveryPending, NextAttemptAt: start.Add(time.Hour)},
+	}))
+	clock := &fakeClock{now: start}
+	sender := &fakeSender{statuses: []int{503, 0, 500}}
+	policy := domain.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	svc := newWebhookService(store, sender, service.WithClock(clock), service.WithRetryPolicy(policy))
+
+	attempted, err := svc.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempted, "deliveries not yet due wait")
+	first := store.deliveries[0]
+	assert.Equal(t, domain.DeliveryPending, first.Status)
+	assert.Equal(t, 503, first.ResponseStatus)
+	assert.Equal(t, "receiver answered 503", first.LastError)
+	assert.Equal(t, start.Add(time.Minute), first.NextAttemptAt)
+
+	attempted, err = svc.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, attempted, "a failed delivery backs off")
+
+	clock.now = start.Add(time.Minute)
+	_, err = svc.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(3*time.Minute), store.deliveries[0].NextAttemptAt, "the delay doubles")
+	assert.Equal(t, 0, store.deliveries[0].ResponseStatus)
+
+	clock.now = start.Add(3 * time.Minute)
+	_, err = svc.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryDead, store.deliveries[0].Status)
+	assert.Equal(t, 3, store.deliveries[0].Attempts)
+
+	dead, err := svc.ListWebhookDeliveries(ctx, hook.ID, port.DeliveryFilter{Status: "dead"})
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "create", dead[0].Event)
+
+	_, err = svc.RetryWebhookDelivery(ctx, hook.ID, store.deliveries[1].ID)
+	require.ErrorIs(t, err, domain.ErrDeliveryState, "only dead deliveries are retried")
+	retried, err := svc.RetryWebhookDelivery(ctx, hook.ID, dead[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", retried.Status)
+	assert.Zero(t, retried.Attempts)
+
+	sender.statuses = []int{204}
+	clock.now = start.Add(time.Hour)
+	attempted, err = svc.Deliver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempted)
+	for _, d := range store.deliveries {
+		assert.Equal(t, domain.DeliveryDelivered, d.Status)
+		assert.Equal(t, 204, d.ResponseStatus)
+		assert.Equal(t, start.Add(time.Hour), d.DeliveredAt)
+	}
+	assert.Len(t, sender.sent, 5)
+}
+
+func TestWebhookService_ListWebhookDeliveries_Invalid(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeWebhookStore{}
+	svc := newWebhookService(store, &fakeSender{})
+	ctx := context.Background()
+
+	_, err := svc.ListWebhookDeliveries(ctx, 1, port.DeliveryFilter{})
+	require.ErrorIs(t, err, domain.ErrWebhookNotFound)
+	_, err = svc.ListWebhookDeliveries(ctx, 1, port.DeliveryFilter{Status: "lost"})
+	require.ErrorIs(t, err, domain.ErrInvalidDeliveryQuery)
+	_, err = svc.ListWebhookDeliveries(ctx, 1, port.DeliveryFilter{Limit: domain.MaxDeliveryPage + 1})
+	require.ErrorIs(t, err, domain.ErrInvalidDeliveryQuery)
+	require.ErrorIs(t, svc.DeleteWebhook(ctx, 1), domain.ErrWebhookNotFound)
+}